@@ -100,7 +100,7 @@ export function RFQForm({ portCallId, rfq, onSuccess, onCancel }: RFQFormProps)
 
   const updateMutation = useMutation({
     mutationFn: (data: CreateRFQInput) =>
-      api.updateRFQ(rfq!.id, data),
+      api.updateRFQ(rfq!.id, data, rfq!.version),
     onSuccess: (response) => {
       queryClient.invalidateQueries({ queryKey: ['rfqs'] });
       queryClient.invalidateQueries({ queryKey: ['rfq', rfq!.id] });
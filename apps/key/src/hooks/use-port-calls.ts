@@ -84,8 +84,8 @@ export function useUpdatePortCall() {
   const queryClient = useQueryClient();
 
   return useMutation({
-    mutationFn: ({ id, input }: { id: string; input: UpdatePortCallInput }) =>
-      api.updatePortCall(id, input),
+    mutationFn: ({ id, input, version }: { id: string; input: UpdatePortCallInput; version: number }) =>
+      api.updatePortCall(id, input, version),
     onSuccess: (_, { id }) => {
       queryClient.invalidateQueries({ queryKey: portCallKeys.detail(id) });
       queryClient.invalidateQueries({ queryKey: portCallKeys.lists() });
@@ -110,8 +110,8 @@ export function useUpdatePortCallStatus() {
   const queryClient = useQueryClient();
 
   return useMutation({
-    mutationFn: ({ id, status }: { id: string; status: string }) =>
-      api.updatePortCall(id, { status: status as PortCall['status'] }),
+    mutationFn: ({ id, status, version }: { id: string; status: string; version: number }) =>
+      api.updatePortCall(id, { status: status as PortCall['status'] }, version),
     onSuccess: (_, { id }) => {
       queryClient.invalidateQueries({ queryKey: portCallKeys.detail(id) });
       queryClient.invalidateQueries({ queryKey: portCallKeys.lists() });
@@ -82,8 +82,8 @@ export function useUpdateRFQ() {
   const queryClient = useQueryClient();
 
   return useMutation({
-    mutationFn: ({ id, input }: { id: string; input: UpdateRFQInput }) =>
-      api.updateRFQ(id, input),
+    mutationFn: ({ id, input, version }: { id: string; input: UpdateRFQInput; version: number }) =>
+      api.updateRFQ(id, input, version),
     onSuccess: (_, { id }) => {
       queryClient.invalidateQueries({ queryKey: rfqKeys.detail(id) });
       queryClient.invalidateQueries({ queryKey: rfqKeys.lists() });
@@ -65,8 +65,8 @@ export function useUpdateServiceOrder() {
   const queryClient = useQueryClient();
 
   return useMutation({
-    mutationFn: ({ id, input }: { id: string; input: UpdateServiceOrderInput }) =>
-      api.updateServiceOrder(id, input),
+    mutationFn: ({ id, input, version }: { id: string; input: UpdateServiceOrderInput; version: number }) =>
+      api.updateServiceOrder(id, input, version),
     onSuccess: (_, { id }) => {
       queryClient.invalidateQueries({ queryKey: serviceOrderKeys.detail(id) });
       queryClient.invalidateQueries({ queryKey: serviceOrderKeys.lists() });
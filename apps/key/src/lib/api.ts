@@ -8,6 +8,12 @@ interface FetchOptions extends RequestInit {
   skipAuth?: boolean;
 }
 
+// ifMatch bases an update on the version of the entity last read. Updates
+// are refused with a 409 if it has since changed.
+function ifMatch(version: number) {
+  return { 'If-Match': `"${version}"` };
+}
+
 class ApiClient {
   private baseUrl: string;
 
@@ -85,9 +91,10 @@ class ApiClient {
     });
   }
 
-  async updatePortCall(id: string, input: UpdatePortCallInput) {
+  async updatePortCall(id: string, input: UpdatePortCallInput, version: number) {
     return this.request<{ data: PortCall }>(`/port-calls/${id}`, {
       method: 'PUT',
+      headers: ifMatch(version),
       body: JSON.stringify(input),
     });
   }
@@ -142,9 +149,10 @@ class ApiClient {
     );
   }
 
-  async updateServiceOrder(id: string, input: UpdateServiceOrderInput) {
+  async updateServiceOrder(id: string, input: UpdateServiceOrderInput, version: number) {
     return this.request<{ data: ServiceOrder }>(`/service-orders/${id}`, {
       method: 'PUT',
+      headers: ifMatch(version),
       body: JSON.stringify(input),
     });
   }
@@ -206,9 +214,10 @@ class ApiClient {
     });
   }
 
-  async updateRFQ(id: string, input: UpdateRFQInput) {
+  async updateRFQ(id: string, input: UpdateRFQInput, version: number) {
     return this.request<{ data: RFQ }>(`/rfqs/${id}`, {
       method: 'PUT',
+      headers: ifMatch(version),
       body: JSON.stringify(input),
     });
   }
@@ -292,6 +301,7 @@ export interface PortCall {
   created_by: string;
   created_at: string;
   updated_at: string;
+  version: number;
   vessel?: Vessel;
   port?: Port;
   service_orders?: ServiceOrder[];
@@ -342,6 +352,7 @@ export interface ServiceOrder {
   created_by: string;
   created_at: string;
   updated_at: string;
+  version: number;
   service_type?: ServiceType;
   vendor?: Vendor;
 }
@@ -506,6 +517,7 @@ export interface RFQ {
   created_by: string;
   created_at: string;
   updated_at: string;
+  version: number;
   service_type?: ServiceType;
   quotes?: Quote[];
   quote_count?: number;
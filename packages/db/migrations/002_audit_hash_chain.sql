@@ -0,0 +1,62 @@
+-- ===========================================
+-- Tamper-evident audit log for Navo
+-- ===========================================
+-- Each organization's audit events form a hash chain: an event stores its
+-- position in the chain, the hash of the previous event and its own hash
+-- (see pkg/audit). Checkpoints sign the chain's latest hash periodically,
+-- so rewriting the chain up to a checkpoint is detected as well.
+--
+-- Events logged before this migration have no sequence and are not part
+-- of any chain.
+-- ===========================================
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS sequence BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_org_sequence
+  ON audit_logs(organization_id, sequence)
+  WHERE sequence IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+  organization_id TEXT NOT NULL,
+  sequence BIGINT NOT NULL,
+  hash TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  key_id TEXT NOT NULL,
+  signature TEXT NOT NULL,
+  PRIMARY KEY (organization_id, sequence)
+);
+
+-- Audit events and checkpoints are append-only. This stops mistakes by
+-- application roles; tampering by a database superuser is caught by
+-- verifying the chain.
+CREATE OR REPLACE FUNCTION audit_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+  BEFORE UPDATE OR DELETE ON audit_logs
+  FOR EACH ROW EXECUTE FUNCTION audit_append_only();
+
+DROP TRIGGER IF EXISTS audit_checkpoints_append_only ON audit_checkpoints;
+CREATE TRIGGER audit_checkpoints_append_only
+  BEFORE UPDATE OR DELETE ON audit_checkpoints
+  FOR EACH ROW EXECUTE FUNCTION audit_append_only();
+
+-- ===========================================
+-- Rollback script
+-- ===========================================
+--
+-- DROP TRIGGER IF EXISTS audit_checkpoints_append_only ON audit_checkpoints;
+-- DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+-- DROP FUNCTION IF EXISTS audit_append_only();
+-- DROP TABLE IF EXISTS audit_checkpoints;
+-- DROP INDEX IF EXISTS idx_audit_logs_org_sequence;
+-- ALTER TABLE audit_logs DROP COLUMN IF EXISTS hash;
+-- ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+-- ALTER TABLE audit_logs DROP COLUMN IF EXISTS sequence;
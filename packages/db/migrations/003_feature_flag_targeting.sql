@@ -0,0 +1,43 @@
+-- ===========================================
+-- Feature flag targeting for Navo
+-- ===========================================
+-- Flags gain attribute rules, percentage rollouts and multivariate
+-- variants (see pkg/features), and every change to a flag is recorded
+-- with the flag before and after it.
+-- ===========================================
+
+ALTER TABLE feature_flags ADD COLUMN IF NOT EXISTS rules JSONB;
+ALTER TABLE feature_flags ADD COLUMN IF NOT EXISTS rollout JSONB;
+ALTER TABLE feature_flags ADD COLUMN IF NOT EXISTS variants JSONB;
+ALTER TABLE feature_flags ADD COLUMN IF NOT EXISTS default_variant TEXT;
+
+CREATE TABLE IF NOT EXISTS feature_flag_history (
+  id TEXT PRIMARY KEY,
+  flag_key TEXT NOT NULL,
+  action TEXT NOT NULL,
+  previous JSONB,
+  current JSONB,
+  changed_by TEXT,
+  changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_flag_history_flag
+  ON feature_flag_history(flag_key, changed_at DESC);
+
+-- Flags are global reference data, readable by all like feature_flags
+ALTER TABLE feature_flag_history ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS feature_flag_history_read ON feature_flag_history;
+CREATE POLICY feature_flag_history_read ON feature_flag_history
+  FOR SELECT
+  USING (true);
+
+-- ===========================================
+-- Rollback script
+-- ===========================================
+--
+-- DROP TABLE IF EXISTS feature_flag_history;
+-- ALTER TABLE feature_flags DROP COLUMN IF EXISTS default_variant;
+-- ALTER TABLE feature_flags DROP COLUMN IF EXISTS variants;
+-- ALTER TABLE feature_flags DROP COLUMN IF EXISTS rollout;
+-- ALTER TABLE feature_flags DROP COLUMN IF EXISTS rules;
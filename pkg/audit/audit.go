@@ -5,9 +5,13 @@ package audit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 )
 
+// ErrEventNotFound is returned by GetByID for an unknown event
+var ErrEventNotFound = errors.New("audit event not found")
+
 // Action represents the type of action being audited
 type Action string
 
@@ -50,7 +54,9 @@ const (
 	EntityVendor       EntityType = "vendor"
 	EntityAgent        EntityType = "agent"
 	EntityDocument     EntityType = "document"
+	EntityInvoice      EntityType = "invoice"
 	EntityNotification EntityType = "notification"
+	EntityAuditLog     EntityType = "audit_log"
 )
 
 // Event represents a single audit log entry
@@ -71,6 +77,11 @@ type Event struct {
 	RequestID      string          `json:"request_id,omitempty"`
 	Status         string          `json:"status"` // success, failure
 	ErrorMessage   string          `json:"error_message,omitempty"`
+
+	// Hash chain, per organization: set when the event is stored
+	Sequence int64  `json:"sequence,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
 // Filter for querying audit logs
@@ -81,6 +92,8 @@ type Filter struct {
 	Action         Action
 	EntityType     EntityType
 	EntityID       string
+	Status         string // success, failure
+	Search         string // Free text matched against metadata
 	StartTime      time.Time
 	EndTime        time.Time
 	Page           int
@@ -113,6 +126,21 @@ type Logger interface {
 	Close() error
 }
 
+// Streamer reads every audit event matching a filter, without loading
+// them all at once
+type Streamer interface {
+	// Stream calls fn with each matching event, oldest first, stopping at
+	// the first error fn returns
+	Stream(ctx context.Context, filter Filter, fn func(*Event) error) error
+}
+
+// Verifier checks the integrity of an organization's audit trail
+type Verifier interface {
+	// Verify walks the events from sequence from to to (0 for the latest)
+	// and reports the first broken link
+	Verify(ctx context.Context, organizationID string, from, to int64) (*Verification, error)
+}
+
 // Builder helps construct audit events
 type Builder struct {
 	event Event
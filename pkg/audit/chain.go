@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GenesisHash is the previous hash of an organization's first event
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Break is the first broken link found verifying an audit trail
+type Break struct {
+	Sequence int64  `json:"sequence"`
+	EventID  string `json:"event_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Verification is the result of verifying a range of an organization's
+// audit trail
+type Verification struct {
+	OrganizationID string `json:"organization_id"`
+	From           int64  `json:"from"`
+	To             int64  `json:"to"`
+	Events         int64  `json:"events"`      // Number of events checked
+	Checkpoints    int    `json:"checkpoints"` // Number of signed checkpoints checked
+	Valid          bool   `json:"valid"`
+	Break          *Break `json:"break,omitempty"`
+}
+
+// canonicalEvent fixes the fields and field order an event is hashed with
+type canonicalEvent struct {
+	Sequence       int64           `json:"sequence"`
+	PrevHash       string          `json:"prev_hash"`
+	ID             string          `json:"id"`
+	Timestamp      string          `json:"timestamp"`
+	UserID         string          `json:"user_id"`
+	OrganizationID string          `json:"organization_id"`
+	WorkspaceID    string          `json:"workspace_id"`
+	Action         Action          `json:"action"`
+	EntityType     EntityType      `json:"entity_type"`
+	EntityID       string          `json:"entity_id"`
+	OldValue       json.RawMessage `json:"old_value"`
+	NewValue       json.RawMessage `json:"new_value"`
+	Metadata       json.RawMessage `json:"metadata"`
+	IPAddress      string          `json:"ip_address"`
+	UserAgent      string          `json:"user_agent"`
+	RequestID      string          `json:"request_id"`
+	Status         string          `json:"status"`
+	ErrorMessage   string          `json:"error_message"`
+}
+
+// ComputeHash returns the hash of the event's contents, sequence and
+// previous hash. Values are hashed in a canonical form, so an event hashes
+// the same before it is stored and after it is read back.
+func (e *Event) ComputeHash() (string, error) {
+	metadata, err := e.canonicalMetadata()
+	if err != nil {
+		return "", err
+	}
+	return e.computeHash(metadata)
+}
+
+// computeHash hashes the event with metadata already in JSON form
+func (e *Event) computeHash(metadata json.RawMessage) (string, error) {
+	canonical := canonicalEvent{
+		Sequence:       e.Sequence,
+		PrevHash:       e.PrevHash,
+		ID:             e.ID,
+		Timestamp:      normalizeTimestamp(e.Timestamp).Format(time.RFC3339Nano),
+		UserID:         e.UserID,
+		OrganizationID: e.OrganizationID,
+		WorkspaceID:    e.WorkspaceID,
+		Action:         e.Action,
+		EntityType:     e.EntityType,
+		EntityID:       e.EntityID,
+		IPAddress:      e.IPAddress,
+		UserAgent:      e.UserAgent,
+		RequestID:      e.RequestID,
+		Status:         e.Status,
+		ErrorMessage:   e.ErrorMessage,
+	}
+
+	var err error
+	if canonical.OldValue, err = canonicalJSON(e.OldValue); err != nil {
+		return "", fmt.Errorf("invalid old_value: %w", err)
+	}
+	if canonical.NewValue, err = canonicalJSON(e.NewValue); err != nil {
+		return "", fmt.Errorf("invalid new_value: %w", err)
+	}
+	if canonical.Metadata, err = canonicalJSON(metadata); err != nil {
+		return "", fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalMetadata returns the event's metadata as stored, nil when empty
+func (e *Event) canonicalMetadata() (json.RawMessage, error) {
+	if len(e.Metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(e.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	return canonicalJSON(data)
+}
+
+// canonicalJSON re-encodes a JSON value with sorted object keys and no
+// insignificant whitespace, as Postgres JSONB does. Empty values and null
+// are both nil.
+func canonicalJSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// normalizeTimestamp returns the timestamp as Postgres stores it: in UTC,
+// to the microsecond
+func normalizeTimestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// chainVerifier checks the links of an audit trail, one event at a time in
+// sequence order
+type chainVerifier struct {
+	next     int64
+	prevHash string
+}
+
+// check verifies the event follows the previous one and matches its hash.
+// metadata is the event's metadata as stored.
+func (v *chainVerifier) check(event *Event, metadata json.RawMessage) *Break {
+	if event.Sequence != v.next {
+		return &Break{Sequence: v.next, Reason: "event missing"}
+	}
+	if event.PrevHash != v.prevHash {
+		return &Break{Sequence: event.Sequence, EventID: event.ID, Reason: "previous hash does not match the previous event"}
+	}
+
+	hash, err := event.computeHash(metadata)
+	if err != nil {
+		return &Break{Sequence: event.Sequence, EventID: event.ID, Reason: err.Error()}
+	}
+	if hash != event.Hash {
+		return &Break{Sequence: event.Sequence, EventID: event.ID, Reason: "hash does not match the event's contents"}
+	}
+
+	v.next++
+	v.prevHash = event.Hash
+	return nil
+}
+
+// VerifyChain checks that events, in sequence order, form an unbroken
+// chain following prevHash. It returns the first broken link, or nil.
+func VerifyChain(prevHash string, events []Event) *Break {
+	if len(events) == 0 {
+		return nil
+	}
+
+	v := &chainVerifier{next: events[0].Sequence, prevHash: prevHash}
+	for i := range events {
+		metadata, err := events[i].canonicalMetadata()
+		if err != nil {
+			return &Break{Sequence: events[i].Sequence, EventID: events[i].ID, Reason: err.Error()}
+		}
+		if b := v.check(&events[i], metadata); b != nil {
+			return b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(i int) Event {
+	return Event{
+		ID:             fmt.Sprintf("event-%d", i),
+		Timestamp:      time.Date(2026, 3, 1, 12, 0, i, 123456789, time.FixedZone("CET", 3600)),
+		UserID:         "user-1",
+		OrganizationID: "org-1",
+		Action:         ActionUpdate,
+		EntityType:     EntityWorkspace,
+		EntityID:       "ws-1",
+		OldValue:       json.RawMessage(`{"name": "Old", "size": 1}`),
+		NewValue:       json.RawMessage(`{"size": 2, "name": "New"}`),
+		Metadata:       map[string]any{"reason": "rename", "attempt": 1},
+		Status:         "success",
+	}
+}
+
+// testChain returns n events chained from GenesisHash, as DBLogger stores them
+func testChain(t *testing.T, n int) []Event {
+	t.Helper()
+	events := make([]Event, n)
+	prevHash := GenesisHash
+	for i := range events {
+		events[i] = testEvent(i)
+		events[i].Sequence = int64(i + 1)
+		events[i].PrevHash = prevHash
+		hash, err := events[i].ComputeHash()
+		require.NoError(t, err)
+		events[i].Hash = hash
+		prevHash = hash
+	}
+	return events
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", ``, ``},
+		{"whitespace", "  \n", ``},
+		{"null", `null`, ``},
+		{"sorted keys", `{"b": 1, "a": {"d": 2, "c": 3}}`, `{"a":{"c":3,"d":2},"b":1}`},
+		{"large numbers", `{"n": 12345678901234567890, "f": 1.50}`, `{"f":1.50,"n":12345678901234567890}`},
+		{"array order kept", `[3, 1, 2]`, `[3,1,2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalJSON(json.RawMessage(tt.in))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			// Canonical JSON is its own canonical form
+			again, err := canonicalJSON(got)
+			require.NoError(t, err)
+			assert.Equal(t, string(got), string(again))
+		})
+	}
+
+	_, err := canonicalJSON(json.RawMessage(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestComputeHash_RoundTrip(t *testing.T) {
+	event := testEvent(1)
+	event.Sequence = 1
+	event.PrevHash = GenesisHash
+	hash, err := event.ComputeHash()
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	// As read back from Postgres: JSONB reformatted, timestamp in UTC to the
+	// microsecond, metadata decoded from JSON
+	stored := event
+	stored.Timestamp = event.Timestamp.UTC().Truncate(time.Microsecond)
+	stored.OldValue = json.RawMessage(`{"name":"Old","size":1}`)
+	stored.NewValue = json.RawMessage(`{"name":"New","size":2}`)
+	data, err := json.Marshal(event.Metadata)
+	require.NoError(t, err)
+	stored.Metadata = nil
+	require.NoError(t, json.Unmarshal(data, &stored.Metadata))
+
+	storedHash, err := stored.ComputeHash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, storedHash)
+
+	// Null and missing values hash the same
+	event.OldValue = nil
+	withoutOld, err := event.ComputeHash()
+	require.NoError(t, err)
+	event.OldValue = json.RawMessage(`null`)
+	nullOld, err := event.ComputeHash()
+	require.NoError(t, err)
+	assert.Equal(t, withoutOld, nullOld)
+	assert.NotEqual(t, hash, withoutOld)
+}
+
+func TestComputeHash_CoversChainFields(t *testing.T) {
+	event := testEvent(1)
+	event.Sequence = 1
+	event.PrevHash = GenesisHash
+	hash, err := event.ComputeHash()
+	require.NoError(t, err)
+
+	changes := map[string]func(e *Event){
+		"sequence":  func(e *Event) { e.Sequence = 2 },
+		"prev hash": func(e *Event) { e.PrevHash = "ff" + GenesisHash[2:] },
+		"entity":    func(e *Event) { e.EntityID = "ws-2" },
+		"new value": func(e *Event) { e.NewValue = json.RawMessage(`{"name":"Other"}`) },
+		"metadata":  func(e *Event) { e.Metadata = map[string]any{"reason": "other"} },
+		"timestamp": func(e *Event) { e.Timestamp = e.Timestamp.Add(time.Microsecond) },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			changed := event
+			change(&changed)
+			changedHash, err := changed.ComputeHash()
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, changedHash)
+		})
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(events []Event) []Event
+		want   *Break
+	}{
+		{
+			name:   "intact",
+			tamper: func(events []Event) []Event { return events },
+		},
+		{
+			name: "edited",
+			tamper: func(events []Event) []Event {
+				events[2].EntityID = "ws-2"
+				return events
+			},
+			want: &Break{Sequence: 3, EventID: "event-2", Reason: "hash does not match the event's contents"},
+		},
+		{
+			name: "edited and rehashed",
+			tamper: func(events []Event) []Event {
+				events[2].EntityID = "ws-2"
+				events[2].Hash, _ = events[2].ComputeHash()
+				return events
+			},
+			want: &Break{Sequence: 4, EventID: "event-3", Reason: "previous hash does not match the previous event"},
+		},
+		{
+			name: "missing",
+			tamper: func(events []Event) []Event {
+				return append(events[:2], events[3:]...)
+			},
+			want: &Break{Sequence: 3, Reason: "event missing"},
+		},
+		{
+			name: "reordered",
+			tamper: func(events []Event) []Event {
+				events[1], events[2] = events[2], events[1]
+				return events
+			},
+			want: &Break{Sequence: 2, Reason: "event missing"},
+		},
+		{
+			name: "resequenced after reordering",
+			tamper: func(events []Event) []Event {
+				events[1], events[2] = events[2], events[1]
+				events[1].Sequence, events[2].Sequence = 2, 3
+				return events
+			},
+			want: &Break{Sequence: 2, EventID: "event-2", Reason: "previous hash does not match the previous event"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := tt.tamper(testChain(t, 5))
+			assert.Equal(t, tt.want, VerifyChain(GenesisHash, events))
+		})
+	}
+}
+
+func TestVerifyChain_Range(t *testing.T) {
+	events := testChain(t, 5)
+
+	assert.Nil(t, VerifyChain(events[1].Hash, events[2:]))
+	assert.Nil(t, VerifyChain(GenesisHash, nil))
+	assert.Equal(t,
+		&Break{Sequence: 3, EventID: "event-2", Reason: "previous hash does not match the previous event"},
+		VerifyChain(GenesisHash, events[2:]))
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Checkpoint is a signed statement of the hash an organization's audit
+// trail had at a sequence. Rewriting the trail up to a checkpoint, hashes
+// included, is detected without the signing key.
+type Checkpoint struct {
+	OrganizationID string    `json:"organization_id"`
+	Sequence       int64     `json:"sequence"`
+	Hash           string    `json:"hash"`
+	CreatedAt      time.Time `json:"created_at"`
+	KeyID          string    `json:"key_id"`
+	Signature      string    `json:"signature"`
+}
+
+// signedMessage returns the bytes the checkpoint's signature covers
+func (c *Checkpoint) signedMessage() []byte {
+	return []byte(fmt.Sprintf("navo-audit-checkpoint:v1\n%s\n%d\n%s\n%s",
+		c.OrganizationID, c.Sequence, c.Hash, normalizeTimestamp(c.CreatedAt).Format(time.RFC3339Nano)))
+}
+
+// Sign signs the checkpoint with key
+func (c *Checkpoint) Sign(key ed25519.PrivateKey) {
+	c.CreatedAt = normalizeTimestamp(c.CreatedAt)
+	c.KeyID = KeyID(key.Public().(ed25519.PublicKey))
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, c.signedMessage()))
+}
+
+// VerifySignature reports whether the checkpoint was signed by key
+func (c *Checkpoint) VerifySignature(key ed25519.PublicKey) bool {
+	if c.KeyID != KeyID(key) {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, c.signedMessage(), signature)
+}
+
+// KeyID identifies a checkpoint signing key by its public key
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseSigningKey parses a base64 Ed25519 private key, either its 32 byte
+// seed or the 64 byte key
+func ParseSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit signing key: %w", err)
+	}
+	switch len(data) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(data), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(data), nil
+	default:
+		return nil, fmt.Errorf("invalid audit signing key: expected %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(data))
+	}
+}
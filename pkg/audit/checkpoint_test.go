@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCheckpoint(t *testing.T) (*Checkpoint, ed25519.PrivateKey) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	checkpoint := &Checkpoint{
+		OrganizationID: "org-1",
+		Sequence:       100,
+		Hash:           GenesisHash,
+		CreatedAt:      time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600)),
+	}
+	checkpoint.Sign(key)
+	return checkpoint, key
+}
+
+func TestCheckpoint_Sign(t *testing.T) {
+	checkpoint, key := testCheckpoint(t)
+	public := key.Public().(ed25519.PublicKey)
+
+	assert.Equal(t, KeyID(public), checkpoint.KeyID)
+	assert.Equal(t, time.UTC, checkpoint.CreatedAt.Location())
+	assert.Zero(t, checkpoint.CreatedAt.Nanosecond()%1000)
+	assert.True(t, checkpoint.VerifySignature(public))
+
+	// As read back from Postgres
+	stored := *checkpoint
+	stored.CreatedAt = checkpoint.CreatedAt.In(time.Local)
+	assert.True(t, stored.VerifySignature(public))
+}
+
+func TestCheckpoint_VerifySignature(t *testing.T) {
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		tamper func(c *Checkpoint)
+		key    func(key ed25519.PrivateKey) ed25519.PublicKey
+	}{
+		{"other key", func(c *Checkpoint) {}, func(ed25519.PrivateKey) ed25519.PublicKey {
+			return other.Public().(ed25519.PublicKey)
+		}},
+		{"other key with its key id", func(c *Checkpoint) {
+			c.KeyID = KeyID(other.Public().(ed25519.PublicKey))
+		}, func(ed25519.PrivateKey) ed25519.PublicKey {
+			return other.Public().(ed25519.PublicKey)
+		}},
+		{"hash", func(c *Checkpoint) { c.Hash = "ff" + c.Hash[2:] }, nil},
+		{"sequence", func(c *Checkpoint) { c.Sequence++ }, nil},
+		{"organization", func(c *Checkpoint) { c.OrganizationID = "org-2" }, nil},
+		{"created at", func(c *Checkpoint) { c.CreatedAt = c.CreatedAt.Add(time.Second) }, nil},
+		{"malformed signature", func(c *Checkpoint) { c.Signature = "not base64!" }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpoint, key := testCheckpoint(t)
+			public := key.Public().(ed25519.PublicKey)
+			if tt.key != nil {
+				public = tt.key(key)
+			}
+			tt.tamper(checkpoint)
+			assert.False(t, checkpoint.VerifySignature(public))
+		})
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	fromSeed, err := ParseSigningKey(base64.StdEncoding.EncodeToString(key.Seed()) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, key, fromSeed)
+
+	fromKey, err := ParseSigningKey(base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	assert.Equal(t, key, fromKey)
+
+	_, err = ParseSigningKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+	_, err = ParseSigningKey("not base64!")
+	assert.Error(t, err)
+}
@@ -2,25 +2,36 @@ package audit
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/navo/pkg/logger"
 	"go.uber.org/zap"
 )
 
-// DBLogger implements Logger interface using PostgreSQL
+// DBLogger implements Logger interface using PostgreSQL. Each
+// organization's events form a hash chain: every event stores the hash of
+// the previous one and its own hash, so edited, inserted and deleted rows
+// are detected by Verify. Writers append to a chain in turns, holding a
+// transaction-scoped advisory lock, which keeps the chain intact across
+// async batches, goroutines and service instances.
 type DBLogger struct {
-	pool        *pgxpool.Pool
-	asyncChan   chan Event
-	wg          sync.WaitGroup
-	closeOnce   sync.Once
-	closeChan   chan struct{}
-	bufferSize  int
-	flushPeriod time.Duration
+	pool               *pgxpool.Pool
+	asyncChan          chan Event
+	wg                 sync.WaitGroup
+	closeOnce          sync.Once
+	closeChan          chan struct{}
+	bufferSize         int
+	flushPeriod        time.Duration
+	checkpointKey      ed25519.PrivateKey
+	checkpointInterval time.Duration
 }
 
 // DBLoggerConfig holds configuration for DBLogger
@@ -31,29 +42,50 @@ type DBLoggerConfig struct {
 	FlushPeriod time.Duration
 	// Workers is the number of async workers
 	Workers int
+	// CheckpointKey signs periodic checkpoints of each organization's
+	// chain. Without it no checkpoints are written or verified.
+	CheckpointKey ed25519.PrivateKey
+	// CheckpointInterval is how often checkpoints are written
+	CheckpointInterval time.Duration
 }
 
 // DefaultDBLoggerConfig returns default configuration
 func DefaultDBLoggerConfig() *DBLoggerConfig {
 	return &DBLoggerConfig{
-		BufferSize:  1000,
-		FlushPeriod: time.Second * 5,
-		Workers:     2,
+		BufferSize:         1000,
+		FlushPeriod:        time.Second * 5,
+		Workers:            2,
+		CheckpointInterval: time.Hour,
 	}
 }
 
+const (
+	// maxBatchSize is the most buffered events an async worker stores at once
+	maxBatchSize = 100
+	// chainLockPrefix namespaces the advisory locks of the organizations' chains
+	chainLockPrefix = "audit_logs:"
+)
+
 // NewDBLogger creates a new database-backed audit logger
 func NewDBLogger(pool *pgxpool.Pool, cfg *DBLoggerConfig) *DBLogger {
 	if cfg == nil {
 		cfg = DefaultDBLoggerConfig()
 	}
+	if cfg.FlushPeriod <= 0 {
+		cfg.FlushPeriod = time.Second * 5
+	}
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = time.Hour
+	}
 
 	l := &DBLogger{
-		pool:        pool,
-		asyncChan:   make(chan Event, cfg.BufferSize),
-		closeChan:   make(chan struct{}),
-		bufferSize:  cfg.BufferSize,
-		flushPeriod: cfg.FlushPeriod,
+		pool:               pool,
+		asyncChan:          make(chan Event, cfg.BufferSize),
+		closeChan:          make(chan struct{}),
+		bufferSize:         cfg.BufferSize,
+		flushPeriod:        cfg.FlushPeriod,
+		checkpointKey:      cfg.CheckpointKey,
+		checkpointInterval: cfg.CheckpointInterval,
 	}
 
 	// Start async workers
@@ -62,34 +94,43 @@ func NewDBLogger(pool *pgxpool.Pool, cfg *DBLoggerConfig) *DBLogger {
 		go l.worker()
 	}
 
+	// Start checkpoint writer
+	if l.checkpointKey != nil {
+		l.wg.Add(1)
+		go l.checkpointer()
+	}
+
 	return l
 }
 
-// worker processes events from the async channel
+// worker stores events from the async channel in batches, when a batch is
+// full or the flush period passes
 func (l *DBLogger) worker() {
 	defer l.wg.Done()
 
+	ticker := time.NewTicker(l.flushPeriod)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, maxBatchSize)
 	for {
 		select {
 		case event := <-l.asyncChan:
-			if err := l.insertEvent(context.Background(), event); err != nil {
-				logger.Error("Failed to insert audit event",
-					zap.String("entity_type", string(event.EntityType)),
-					zap.String("action", string(event.Action)),
-					zap.Error(err),
-				)
+			batch = append(batch, event)
+			if len(batch) >= maxBatchSize {
+				l.flush(batch)
+				batch = batch[:0]
 			}
+		case <-ticker.C:
+			l.flush(batch)
+			batch = batch[:0]
 		case <-l.closeChan:
 			// Drain remaining events
 			for {
 				select {
 				case event := <-l.asyncChan:
-					if err := l.insertEvent(context.Background(), event); err != nil {
-						logger.Error("Failed to insert audit event during shutdown",
-							zap.Error(err),
-						)
-					}
+					batch = append(batch, event)
 				default:
+					l.flush(batch)
 					return
 				}
 			}
@@ -97,9 +138,38 @@ func (l *DBLogger) worker() {
 	}
 }
 
+// flush stores a batch of events. When the batch fails, its events are
+// stored one at a time so one bad event doesn't lose the others.
+func (l *DBLogger) flush(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := l.insertEvents(context.Background(), batch)
+	if err == nil {
+		return
+	}
+	if len(batch) == 1 {
+		logger.Error("Failed to insert audit event",
+			zap.String("entity_type", string(batch[0].EntityType)),
+			zap.String("action", string(batch[0].Action)),
+			zap.Error(err),
+		)
+		return
+	}
+
+	logger.Warn("Failed to insert audit event batch, retrying events one at a time",
+		zap.Int("count", len(batch)),
+		zap.Error(err),
+	)
+	for _, event := range batch {
+		l.flush([]Event{event})
+	}
+}
+
 // Log records an audit event synchronously
 func (l *DBLogger) Log(ctx context.Context, event Event) error {
-	return l.insertEvent(ctx, event)
+	return l.insertEvents(ctx, []Event{event})
 }
 
 // LogAsync records an audit event asynchronously (non-blocking)
@@ -113,7 +183,7 @@ func (l *DBLogger) LogAsync(ctx context.Context, event Event) {
 			zap.String("entity_type", string(event.EntityType)),
 		)
 		go func() {
-			if err := l.insertEvent(context.Background(), event); err != nil {
+			if err := l.insertEvents(context.Background(), []Event{event}); err != nil {
 				logger.Error("Failed to insert audit event",
 					zap.Error(err),
 				)
@@ -122,51 +192,193 @@ func (l *DBLogger) LogAsync(ctx context.Context, event Event) {
 	}
 }
 
-// insertEvent inserts an event into the database
-func (l *DBLogger) insertEvent(ctx context.Context, event Event) error {
-	if event.ID == "" {
-		event.ID = uuid.New().String()
+// insertEvents inserts events into the database, appending each to its
+// organization's chain in order
+func (l *DBLogger) insertEvents(ctx context.Context, events []Event) error {
+	var orgs []string
+	byOrg := make(map[string][]Event)
+	for _, event := range events {
+		if _, ok := byOrg[event.OrganizationID]; !ok {
+			orgs = append(orgs, event.OrganizationID)
+		}
+		byOrg[event.OrganizationID] = append(byOrg[event.OrganizationID], event)
+	}
+
+	var errs []error
+	for _, orgID := range orgs {
+		if err := l.appendToChain(ctx, orgID, byOrg[orgID]); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now().UTC()
+	return errors.Join(errs...)
+}
+
+// appendToChain inserts an organization's events in one transaction, after
+// the last event of its chain
+func (l *DBLogger) appendToChain(ctx context.Context, organizationID string, events []Event) error {
+	tx, err := l.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Wait for the other writers of the chain, released on commit
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, chainLockPrefix+organizationID); err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+
+	var sequence int64
+	prevHash := GenesisHash
+	err = tx.QueryRow(ctx, `
+		SELECT sequence, hash FROM audit_logs
+		WHERE organization_id = $1 AND sequence IS NOT NULL
+		ORDER BY sequence DESC
+		LIMIT 1
+	`, organizationID).Scan(&sequence, &prevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get audit chain head: %w", err)
 	}
 
 	query := `
 		INSERT INTO audit_logs (
 			id, timestamp, user_id, organization_id, workspace_id,
 			action, entity_type, entity_id, old_value, new_value,
-			metadata, ip_address, user_agent, request_id, status, error_message
+			metadata, ip_address, user_agent, request_id, status, error_message,
+			sequence, prev_hash, hash
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
 		)
 	`
 
-	_, err := l.pool.Exec(ctx, query,
-		event.ID,
-		event.Timestamp,
-		event.UserID,
-		event.OrganizationID,
-		nullableString(event.WorkspaceID),
-		event.Action,
-		event.EntityType,
-		event.EntityID,
-		event.OldValue,
-		event.NewValue,
-		event.Metadata,
-		nullableString(event.IPAddress),
-		nullableString(event.UserAgent),
-		nullableString(event.RequestID),
-		event.Status,
-		nullableString(event.ErrorMessage),
-	)
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now()
+		}
+		event.Timestamp = normalizeTimestamp(event.Timestamp)
 
-	if err != nil {
-		return fmt.Errorf("failed to insert audit event: %w", err)
+		metadata, err := event.canonicalMetadata()
+		if err != nil {
+			return err
+		}
+
+		sequence++
+		event.Sequence = sequence
+		event.PrevHash = prevHash
+		if event.Hash, err = event.computeHash(metadata); err != nil {
+			return fmt.Errorf("failed to hash audit event: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, query,
+			event.ID,
+			event.Timestamp,
+			event.UserID,
+			event.OrganizationID,
+			nullableString(event.WorkspaceID),
+			event.Action,
+			event.EntityType,
+			event.EntityID,
+			event.OldValue,
+			event.NewValue,
+			metadata,
+			nullableString(event.IPAddress),
+			nullableString(event.UserAgent),
+			nullableString(event.RequestID),
+			event.Status,
+			nullableString(event.ErrorMessage),
+			event.Sequence,
+			event.PrevHash,
+			event.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert audit event: %w", err)
+		}
+
+		prevHash = event.Hash
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit audit events: %w", err)
+	}
 	return nil
 }
 
+// eventColumns are the columns scanned by scanEvent
+const eventColumns = `
+	id, timestamp, user_id, organization_id, workspace_id,
+	action, entity_type, entity_id, old_value, new_value,
+	metadata, ip_address, user_agent, request_id, status, error_message,
+	sequence, prev_hash, hash
+`
+
+// scanEvent scans a row of eventColumns. The metadata is returned as
+// stored, for hashing, as well as decoded into the event.
+func scanEvent(row pgx.Row) (*Event, []byte, error) {
+	var event Event
+	var metadata []byte
+	var workspaceID, ipAddress, userAgent, requestID, errorMessage, prevHash, hash *string
+	var sequence *int64
+
+	err := row.Scan(
+		&event.ID,
+		&event.Timestamp,
+		&event.UserID,
+		&event.OrganizationID,
+		&workspaceID,
+		&event.Action,
+		&event.EntityType,
+		&event.EntityID,
+		&event.OldValue,
+		&event.NewValue,
+		&metadata,
+		&ipAddress,
+		&userAgent,
+		&requestID,
+		&event.Status,
+		&errorMessage,
+		&sequence,
+		&prevHash,
+		&hash,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if workspaceID != nil {
+		event.WorkspaceID = *workspaceID
+	}
+	if ipAddress != nil {
+		event.IPAddress = *ipAddress
+	}
+	if userAgent != nil {
+		event.UserAgent = *userAgent
+	}
+	if requestID != nil {
+		event.RequestID = *requestID
+	}
+	if errorMessage != nil {
+		event.ErrorMessage = *errorMessage
+	}
+	if sequence != nil {
+		event.Sequence = *sequence
+	}
+	if prevHash != nil {
+		event.PrevHash = *prevHash
+	}
+	if hash != nil {
+		event.Hash = *hash
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+	}
+
+	return &event, metadata, nil
+}
+
 // Query retrieves audit events matching the filter
 func (l *DBLogger) Query(ctx context.Context, filter Filter) (*Result, error) {
 	if filter.Page <= 0 {
@@ -176,59 +388,8 @@ func (l *DBLogger) Query(ctx context.Context, filter Filter) (*Result, error) {
 		filter.PerPage = 20
 	}
 
-	// Build WHERE clause
-	conditions := []string{}
-	args := []interface{}{}
-	argNum := 1
-
-	if filter.OrganizationID != "" {
-		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", argNum))
-		args = append(args, filter.OrganizationID)
-		argNum++
-	}
-	if filter.UserID != "" {
-		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argNum))
-		args = append(args, filter.UserID)
-		argNum++
-	}
-	if filter.WorkspaceID != "" {
-		conditions = append(conditions, fmt.Sprintf("workspace_id = $%d", argNum))
-		args = append(args, filter.WorkspaceID)
-		argNum++
-	}
-	if filter.Action != "" {
-		conditions = append(conditions, fmt.Sprintf("action = $%d", argNum))
-		args = append(args, filter.Action)
-		argNum++
-	}
-	if filter.EntityType != "" {
-		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argNum))
-		args = append(args, filter.EntityType)
-		argNum++
-	}
-	if filter.EntityID != "" {
-		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argNum))
-		args = append(args, filter.EntityID)
-		argNum++
-	}
-	if !filter.StartTime.IsZero() {
-		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", argNum))
-		args = append(args, filter.StartTime)
-		argNum++
-	}
-	if !filter.EndTime.IsZero() {
-		conditions = append(conditions, fmt.Sprintf("timestamp <= $%d", argNum))
-		args = append(args, filter.EndTime)
-		argNum++
-	}
-
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + conditions[0]
-		for i := 1; i < len(conditions); i++ {
-			whereClause += " AND " + conditions[i]
-		}
-	}
+	whereClause, args := filter.whereClause()
+	argNum := len(args) + 1
 
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_logs %s", whereClause)
@@ -242,14 +403,12 @@ func (l *DBLogger) Query(ctx context.Context, filter Filter) (*Result, error) {
 	args = append(args, filter.PerPage, offset)
 
 	query := fmt.Sprintf(`
-		SELECT id, timestamp, user_id, organization_id, workspace_id,
-			action, entity_type, entity_id, old_value, new_value,
-			metadata, ip_address, user_agent, request_id, status, error_message
+		SELECT %s
 		FROM audit_logs
 		%s
 		ORDER BY timestamp DESC
 		LIMIT $%d OFFSET $%d
-	`, whereClause, argNum, argNum+1)
+	`, eventColumns, whereClause, argNum, argNum+1)
 
 	rows, err := l.pool.Query(ctx, query, args...)
 	if err != nil {
@@ -259,48 +418,11 @@ func (l *DBLogger) Query(ctx context.Context, filter Filter) (*Result, error) {
 
 	events := []Event{}
 	for rows.Next() {
-		var event Event
-		var workspaceID, ipAddress, userAgent, requestID, errorMessage *string
-
-		err := rows.Scan(
-			&event.ID,
-			&event.Timestamp,
-			&event.UserID,
-			&event.OrganizationID,
-			&workspaceID,
-			&event.Action,
-			&event.EntityType,
-			&event.EntityID,
-			&event.OldValue,
-			&event.NewValue,
-			&event.Metadata,
-			&ipAddress,
-			&userAgent,
-			&requestID,
-			&event.Status,
-			&errorMessage,
-		)
+		event, _, err := scanEvent(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan audit event: %w", err)
 		}
-
-		if workspaceID != nil {
-			event.WorkspaceID = *workspaceID
-		}
-		if ipAddress != nil {
-			event.IPAddress = *ipAddress
-		}
-		if userAgent != nil {
-			event.UserAgent = *userAgent
-		}
-		if requestID != nil {
-			event.RequestID = *requestID
-		}
-		if errorMessage != nil {
-			event.ErrorMessage = *errorMessage
-		}
-
-		events = append(events, event)
+		events = append(events, *event)
 	}
 
 	return &Result{
@@ -311,58 +433,51 @@ func (l *DBLogger) Query(ctx context.Context, filter Filter) (*Result, error) {
 	}, nil
 }
 
-// GetByID retrieves a specific audit event
-func (l *DBLogger) GetByID(ctx context.Context, id string) (*Event, error) {
-	query := `
-		SELECT id, timestamp, user_id, organization_id, workspace_id,
-			action, entity_type, entity_id, old_value, new_value,
-			metadata, ip_address, user_agent, request_id, status, error_message
-		FROM audit_logs
-		WHERE id = $1
-	`
+// Stream calls fn with every event matching the filter, oldest first,
+// ignoring pagination. Rows are read as they arrive, so exports of any
+// size use constant memory.
+func (l *DBLogger) Stream(ctx context.Context, filter Filter, fn func(*Event) error) error {
+	whereClause, args := filter.whereClause()
 
-	var event Event
-	var workspaceID, ipAddress, userAgent, requestID, errorMessage *string
-
-	err := l.pool.QueryRow(ctx, query, id).Scan(
-		&event.ID,
-		&event.Timestamp,
-		&event.UserID,
-		&event.OrganizationID,
-		&workspaceID,
-		&event.Action,
-		&event.EntityType,
-		&event.EntityID,
-		&event.OldValue,
-		&event.NewValue,
-		&event.Metadata,
-		&ipAddress,
-		&userAgent,
-		&requestID,
-		&event.Status,
-		&errorMessage,
-	)
+	rows, err := l.pool.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM audit_logs
+		%s
+		ORDER BY timestamp, id
+	`, eventColumns, whereClause), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get audit event: %w", err)
+		return fmt.Errorf("failed to query audit events: %w", err)
 	}
+	defer rows.Close()
 
-	if workspaceID != nil {
-		event.WorkspaceID = *workspaceID
-	}
-	if ipAddress != nil {
-		event.IPAddress = *ipAddress
+	for rows.Next() {
+		event, _, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
 	}
-	if userAgent != nil {
-		event.UserAgent = *userAgent
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read audit events: %w", err)
 	}
-	if requestID != nil {
-		event.RequestID = *requestID
+	return nil
+}
+
+// GetByID retrieves a specific audit event
+func (l *DBLogger) GetByID(ctx context.Context, id string) (*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM audit_logs WHERE id = $1`
+
+	event, _, err := scanEvent(l.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
 	}
-	if errorMessage != nil {
-		event.ErrorMessage = *errorMessage
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit event: %w", err)
 	}
 
-	return &event, nil
+	return event, nil
 }
 
 // Close closes the logger and flushes any pending events
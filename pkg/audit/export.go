@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is the file format of an audit export
+type ExportFormat string
+
+const (
+	// ExportCSV writes one event per CSV row, after a header row
+	ExportCSV ExportFormat = "csv"
+	// ExportNDJSON writes one JSON event per line
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ParseExportFormat parses an export format, defaulting to CSV
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch ExportFormat(s) {
+	case "", ExportCSV:
+		return ExportCSV, nil
+	case ExportNDJSON, "json":
+		return ExportNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	if f == ExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// csvColumns are the columns of a CSV export
+var csvColumns = []string{
+	"id", "sequence", "timestamp", "organization_id", "workspace_id", "user_id",
+	"action", "entity_type", "entity_id", "status", "error_message",
+	"old_value", "new_value", "metadata", "ip_address", "user_agent", "request_id",
+	"prev_hash", "hash",
+}
+
+// ExportWriter writes audit events to a file, one at a time
+type ExportWriter struct {
+	format ExportFormat
+	csv    *csv.Writer
+	json   *json.Encoder
+	count  int64
+}
+
+// NewExportWriter creates an export writer, writing the CSV header row
+// right away
+func NewExportWriter(w io.Writer, format ExportFormat) (*ExportWriter, error) {
+	ew := &ExportWriter{format: format}
+	if format == ExportNDJSON {
+		ew.json = json.NewEncoder(w)
+		return ew, nil
+	}
+
+	ew.csv = csv.NewWriter(w)
+	if err := ew.csv.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+// Write writes an event
+func (ew *ExportWriter) Write(event *Event) error {
+	ew.count++
+	if ew.json != nil {
+		return ew.json.Encode(event)
+	}
+
+	metadata := ""
+	if len(event.Metadata) > 0 {
+		data, err := json.Marshal(event.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(data)
+	}
+	sequence := ""
+	if event.Sequence > 0 {
+		sequence = strconv.FormatInt(event.Sequence, 10)
+	}
+
+	return ew.csv.Write([]string{
+		event.ID,
+		sequence,
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		event.OrganizationID,
+		event.WorkspaceID,
+		event.UserID,
+		string(event.Action),
+		string(event.EntityType),
+		event.EntityID,
+		event.Status,
+		event.ErrorMessage,
+		string(event.OldValue),
+		string(event.NewValue),
+		metadata,
+		event.IPAddress,
+		event.UserAgent,
+		event.RequestID,
+		event.PrevHash,
+		event.Hash,
+	})
+}
+
+// Flush writes any buffered data
+func (ew *ExportWriter) Flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		return ew.csv.Error()
+	}
+	return nil
+}
+
+// Count returns the number of events written
+func (ew *ExportWriter) Count() int64 {
+	return ew.count
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereClause builds the SQL WHERE clause and arguments selecting the
+// events matching the filter
+func (f Filter) whereClause() (string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.OrganizationID != "" {
+		add("organization_id = $%d", f.OrganizationID)
+	}
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.WorkspaceID != "" {
+		add("workspace_id = $%d", f.WorkspaceID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.EntityType != "" {
+		add("entity_type = $%d", f.EntityType)
+	}
+	if f.EntityID != "" {
+		add("entity_id = $%d", f.EntityID)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if search := strings.TrimSpace(f.Search); search != "" {
+		add(`metadata::text ILIKE $%d ESCAPE '\'`, "%"+escapeLike(search)+"%")
+	}
+	if !f.StartTime.IsZero() {
+		add("timestamp >= $%d", f.StartTime)
+	}
+	if !f.EndTime.IsZero() {
+		add("timestamp <= $%d", f.EndTime)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/navo/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Verify walks an organization's events from sequence from to to (0 for
+// the latest) and reports the first broken link: a missing event, an event
+// not following the previous one, an event whose contents don't match its
+// hash, or a hash differing from a signed checkpoint. Deleting the latest
+// events is only detected up to the last checkpoint.
+func (l *DBLogger) Verify(ctx context.Context, organizationID string, from, to int64) (*Verification, error) {
+	if from < 1 {
+		from = 1
+	}
+	if to != 0 && to < from {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	if to == 0 {
+		err := l.pool.QueryRow(ctx, `
+			SELECT GREATEST(
+				(SELECT COALESCE(MAX(sequence), 0) FROM audit_logs WHERE organization_id = $1),
+				(SELECT COALESCE(MAX(sequence), 0) FROM audit_checkpoints WHERE organization_id = $1)
+			)
+		`, organizationID).Scan(&to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+		}
+	}
+
+	result := &Verification{OrganizationID: organizationID, From: from, To: to}
+	if to < from {
+		// Nothing logged yet
+		result.Valid = true
+		return result, nil
+	}
+
+	v := &chainVerifier{next: from, prevHash: GenesisHash}
+	if from > 1 {
+		err := l.pool.QueryRow(ctx, `
+			SELECT hash FROM audit_logs WHERE organization_id = $1 AND sequence = $2
+		`, organizationID, from-1).Scan(&v.prevHash)
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Break = &Break{Sequence: from - 1, Reason: "event missing"}
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous audit event: %w", err)
+		}
+	}
+
+	checkpoints, err := l.checkpoints(ctx, organizationID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := l.pool.Query(ctx, `
+		SELECT `+eventColumns+`
+		FROM audit_logs
+		WHERE organization_id = $1 AND sequence BETWEEN $2 AND $3
+		ORDER BY sequence
+	`, organizationID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, metadata, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+
+		if b := v.check(event, metadata); b != nil {
+			result.Break = b
+			return result, nil
+		}
+		result.Events++
+
+		if checkpoint, ok := checkpoints[event.Sequence]; ok {
+			if b := l.checkCheckpoint(checkpoint, event); b != nil {
+				result.Break = b
+				return result, nil
+			}
+			result.Checkpoints++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit events: %w", err)
+	}
+
+	if v.next <= to {
+		result.Break = &Break{Sequence: v.next, Reason: "event missing"}
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// checkCheckpoint checks a checkpoint is validly signed and matches the
+// event at its sequence
+func (l *DBLogger) checkCheckpoint(checkpoint *Checkpoint, event *Event) *Break {
+	if l.checkpointKey != nil && !checkpoint.VerifySignature(l.checkpointKey.Public().(ed25519.PublicKey)) {
+		return &Break{Sequence: event.Sequence, EventID: event.ID, Reason: "checkpoint signature is invalid"}
+	}
+	if checkpoint.Hash != event.Hash {
+		return &Break{Sequence: event.Sequence, EventID: event.ID, Reason: "hash differs from the signed checkpoint"}
+	}
+	return nil
+}
+
+// checkpoints returns an organization's checkpoints between two sequences,
+// by sequence
+func (l *DBLogger) checkpoints(ctx context.Context, organizationID string, from, to int64) (map[int64]*Checkpoint, error) {
+	rows, err := l.pool.Query(ctx, `
+		SELECT organization_id, sequence, hash, created_at, key_id, signature
+		FROM audit_checkpoints
+		WHERE organization_id = $1 AND sequence BETWEEN $2 AND $3
+	`, organizationID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	checkpoints := make(map[int64]*Checkpoint)
+	for rows.Next() {
+		var c Checkpoint
+		if err := rows.Scan(&c.OrganizationID, &c.Sequence, &c.Hash, &c.CreatedAt, &c.KeyID, &c.Signature); err != nil {
+			return nil, fmt.Errorf("failed to scan audit checkpoint: %w", err)
+		}
+		checkpoints[c.Sequence] = &c
+	}
+	return checkpoints, rows.Err()
+}
+
+// Checkpoint signs the latest hash of each organization's chain that grew
+// since its last checkpoint. It returns the number of checkpoints written.
+func (l *DBLogger) Checkpoint(ctx context.Context) (int, error) {
+	if l.checkpointKey == nil {
+		return 0, fmt.Errorf("no audit checkpoint key configured")
+	}
+
+	rows, err := l.pool.Query(ctx, `
+		SELECT head.organization_id, head.sequence, head.hash
+		FROM (
+			SELECT DISTINCT ON (organization_id) organization_id, sequence, hash
+			FROM audit_logs
+			WHERE sequence IS NOT NULL
+			ORDER BY organization_id, sequence DESC
+		) head
+		LEFT JOIN (
+			SELECT organization_id, MAX(sequence) AS sequence
+			FROM audit_checkpoints
+			GROUP BY organization_id
+		) last ON last.organization_id = head.organization_id
+		WHERE last.sequence IS NULL OR last.sequence < head.sequence
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query audit chain heads: %w", err)
+	}
+
+	var checkpoints []*Checkpoint
+	now := time.Now()
+	for rows.Next() {
+		c := &Checkpoint{CreatedAt: now}
+		if err := rows.Scan(&c.OrganizationID, &c.Sequence, &c.Hash); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan audit chain head: %w", err)
+		}
+		c.Sign(l.checkpointKey)
+		checkpoints = append(checkpoints, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read audit chain heads: %w", err)
+	}
+
+	written := 0
+	for _, c := range checkpoints {
+		// Another instance may have written the same checkpoint
+		tag, err := l.pool.Exec(ctx, `
+			INSERT INTO audit_checkpoints (organization_id, sequence, hash, created_at, key_id, signature)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (organization_id, sequence) DO NOTHING
+		`, c.OrganizationID, c.Sequence, c.Hash, c.CreatedAt, c.KeyID, c.Signature)
+		if err != nil {
+			return written, fmt.Errorf("failed to insert audit checkpoint: %w", err)
+		}
+		written += int(tag.RowsAffected())
+	}
+	return written, nil
+}
+
+// checkpointer writes checkpoints every checkpoint interval
+func (l *DBLogger) checkpointer() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			written, err := l.Checkpoint(context.Background())
+			if err != nil {
+				logger.Error("Failed to write audit checkpoints", zap.Error(err))
+			}
+			if written > 0 {
+				logger.Info("Wrote audit checkpoints", zap.Int("count", written))
+			}
+		case <-l.closeChan:
+			return
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"os"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
 	"go.uber.org/zap"
 )
 
@@ -98,6 +100,7 @@ func Connect(ctx context.Context, cfg *Config) (*pgxpool.Pool, error) {
 	poolConfig.MaxConnLifetime = cfg.MaxConnLife
 	poolConfig.MaxConnIdleTime = cfg.MaxConnIdle
 	poolConfig.HealthCheckPeriod = cfg.HealthCheck
+	poolConfig.ConnConfig.Tracer = observability.QueryTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -148,3 +151,27 @@ func getEnv(key, defaultValue string) string {
 func GetStdLib(pool *pgxpool.Pool) *sql.DB {
 	return stdlib.OpenDBFromPool(pool)
 }
+
+// OpenDB opens a *sql.DB on a connection string, for services that use
+// database/sql without a pool from Connect. Queries are traced like the
+// pool's.
+func OpenDB(databaseURL string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	connConfig.Tracer = observability.QueryTracer{}
+	return stdlib.OpenDB(*connConfig), nil
+}
+
+// NewPool creates a connection pool on a connection string, for services
+// configured with a URL rather than Config. Queries are traced like those of
+// Connect's pool.
+func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = observability.QueryTracer{}
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
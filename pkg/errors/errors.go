@@ -11,6 +11,8 @@ type AppError struct {
 	Message    string `json:"message"`
 	StatusCode int    `json:"-"`
 	Err        error  `json:"-"`
+	// Details maps fields to what is wrong with them, for validation errors
+	Details map[string]string `json:"details,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -31,6 +33,7 @@ const (
 	CodeForbidden           = "FORBIDDEN"
 	CodeNotFound            = "NOT_FOUND"
 	CodeConflict            = "CONFLICT"
+	CodePreconditionRequired = "PRECONDITION_REQUIRED"
 	CodeValidation          = "VALIDATION_ERROR"
 	CodeInternal            = "INTERNAL_ERROR"
 	CodeServiceUnavailable  = "SERVICE_UNAVAILABLE"
@@ -82,6 +85,16 @@ func NewConflict(message string) *AppError {
 	}
 }
 
+// NewPreconditionRequired creates an error for a conditional request sent
+// without its condition
+func NewPreconditionRequired(message string) *AppError {
+	return &AppError{
+		Code:       CodePreconditionRequired,
+		Message:    message,
+		StatusCode: http.StatusPreconditionRequired,
+	}
+}
+
 // NewValidation creates a validation error
 func NewValidation(message string) *AppError {
 	return &AppError{
@@ -91,6 +104,17 @@ func NewValidation(message string) *AppError {
 	}
 }
 
+// NewValidationFields creates a validation error detailing each invalid
+// field
+func NewValidationFields(details map[string]string) *AppError {
+	return &AppError{
+		Code:       CodeValidation,
+		Message:    "Request validation failed",
+		StatusCode: http.StatusBadRequest,
+		Details:    details,
+	}
+}
+
 // NewInternal creates an internal server error
 func NewInternal(err error) *AppError {
 	return &AppError{
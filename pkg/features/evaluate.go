@@ -0,0 +1,348 @@
+package features
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// EvalContext describes who a flag is evaluated for
+type EvalContext struct {
+	OrganizationID   string            `json:"organization_id,omitempty"`
+	WorkspaceID      string            `json:"workspace_id,omitempty"`
+	UserID           string            `json:"user_id,omitempty"`
+	PortalType       string            `json:"portal_type,omitempty"`
+	OrganizationType string            `json:"organization_type,omitempty"`
+	Roles            []string          `json:"roles,omitempty"`
+	Attributes       map[string]string `json:"attributes,omitempty"` // Any other attributes rules can match
+}
+
+// Attributes rules can match, besides custom ones
+const (
+	AttributeOrganizationID   = "organization_id"
+	AttributeWorkspaceID      = "workspace_id"
+	AttributeUserID           = "user_id"
+	AttributePortalType       = "portal_type"
+	AttributeOrganizationType = "organization_type"
+	AttributeRole             = "role"
+)
+
+// attributeValues returns the values of an attribute; role has one per role
+func (c EvalContext) attributeValues(attribute string) []string {
+	var value string
+	switch attribute {
+	case AttributeOrganizationID:
+		value = c.OrganizationID
+	case AttributeWorkspaceID:
+		value = c.WorkspaceID
+	case AttributeUserID:
+		value = c.UserID
+	case AttributePortalType:
+		value = c.PortalType
+	case AttributeOrganizationType:
+		value = c.OrganizationType
+	case AttributeRole:
+		return c.Roles
+	default:
+		value = c.Attributes[attribute]
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// RolloutUnit is what a percentage rollout is stable for
+type RolloutUnit string
+
+const (
+	RolloutByOrganization RolloutUnit = "organization"
+	RolloutByUser         RolloutUnit = "user"
+)
+
+// Rollout serves a flag to a percentage of organizations or users. The same
+// organization or user always gets the same answer for a flag, and raising
+// the percentage only adds to those already in.
+type Rollout struct {
+	Percentage float64     `json:"percentage"` // 0 to 100
+	By         RolloutUnit `json:"by"`
+}
+
+// includes reports whether the context is in the rollout of a flag
+func (r *Rollout) includes(flagKey string, ctx EvalContext) bool {
+	unit := r.unitID(ctx)
+	if unit == "" {
+		return false
+	}
+	return float64(bucket(flagKey, unit)) < r.Percentage*100
+}
+
+func (r *Rollout) unitID(ctx EvalContext) string {
+	if r.By == RolloutByUser {
+		return ctx.UserID
+	}
+	return ctx.OrganizationID
+}
+
+// bucket hashes a unit into one of 10000 buckets, independently per flag
+func bucket(flagKey, unitID string) uint32 {
+	sum := sha256.Sum256([]byte(flagKey + ":" + unitID))
+	return binary.BigEndian.Uint32(sum[:4]) % 10000
+}
+
+// Operator compares an attribute with a rule condition's values
+type Operator string
+
+const (
+	OperatorIn    Operator = "in"
+	OperatorNotIn Operator = "not_in"
+)
+
+// Condition matches an attribute of the evaluation context
+type Condition struct {
+	Attribute string   `json:"attribute"`
+	Operator  Operator `json:"operator"`
+	Values    []string `json:"values"`
+}
+
+// matches reports whether the context satisfies the condition. For roles,
+// "in" needs any of the user's roles listed and "not_in" none of them.
+func (c Condition) matches(ctx EvalContext) bool {
+	found := false
+	for _, v := range ctx.attributeValues(c.Attribute) {
+		for _, want := range c.Values {
+			if v == want {
+				found = true
+			}
+		}
+	}
+	if c.Operator == OperatorNotIn {
+		return !found
+	}
+	return found
+}
+
+// Rule serves a value to contexts matching all its conditions, optionally
+// to a percentage of them only
+type Rule struct {
+	Description string      `json:"description,omitempty"`
+	Conditions  []Condition `json:"conditions"`
+	Rollout     *Rollout    `json:"rollout,omitempty"`
+	Enabled     bool        `json:"enabled"`
+	Variant     string      `json:"variant,omitempty"` // Served variant when enabled, instead of the weighted split
+}
+
+// matches reports whether the rule applies to the context
+func (r *Rule) matches(flagKey string, ctx EvalContext) bool {
+	for _, c := range r.Conditions {
+		if !c.matches(ctx) {
+			return false
+		}
+	}
+	return r.Rollout == nil || r.Rollout.includes(flagKey, ctx)
+}
+
+// Variant is one value of a multivariate flag. Enabled contexts are split
+// between variants by weight.
+type Variant struct {
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value"` // Any JSON value, e.g. a string
+	Weight int             `json:"weight"`
+}
+
+// Reasons a flag evaluated as it did
+const (
+	ReasonNotFound             = "not_found"
+	ReasonWorkspaceOverride    = "workspace_override"
+	ReasonOrganizationOverride = "organization_override"
+	ReasonRule                 = "rule"
+	ReasonRollout              = "rollout"
+	ReasonDefault              = "default"
+)
+
+// Evaluation is the result of evaluating a flag for a context
+type Evaluation struct {
+	Key     string          `json:"key"`
+	Enabled bool            `json:"enabled"`
+	Variant string          `json:"variant,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Reason  string          `json:"reason"`
+}
+
+// Decode decodes the served variant's value into v
+func (e Evaluation) Decode(v any) error {
+	if len(e.Value) == 0 {
+		return fmt.Errorf("flag %s served no variant", e.Key)
+	}
+	return json.Unmarshal(e.Value, v)
+}
+
+// Evaluate evaluates a flag for a context. Workspace then organization
+// overrides apply first, then the first matching rule, then the rollout,
+// then the default value. Enabled contexts of a multivariate flag get the
+// matching rule's variant, or one picked by weight.
+func Evaluate(flag *Flag, ctx EvalContext) Evaluation {
+	eval := Evaluation{Key: flag.Key}
+	var ruleVariant string
+
+	switch {
+	case contains(flag.EnabledWorkspaces, ctx.WorkspaceID):
+		eval.Enabled, eval.Reason = true, ReasonWorkspaceOverride
+	case contains(flag.DisabledWorkspaces, ctx.WorkspaceID):
+		eval.Enabled, eval.Reason = false, ReasonWorkspaceOverride
+	case contains(flag.EnabledOrganizations, ctx.OrganizationID):
+		eval.Enabled, eval.Reason = true, ReasonOrganizationOverride
+	case contains(flag.DisabledOrganizations, ctx.OrganizationID):
+		eval.Enabled, eval.Reason = false, ReasonOrganizationOverride
+	default:
+		eval.Enabled, eval.Reason = flag.DefaultValue, ReasonDefault
+		if rule := flag.matchingRule(ctx); rule != nil {
+			eval.Enabled, eval.Reason = rule.Enabled, ReasonRule
+			ruleVariant = rule.Variant
+		} else if flag.Rollout != nil {
+			eval.Enabled, eval.Reason = flag.Rollout.includes(flag.Key, ctx), ReasonRollout
+		}
+	}
+
+	if eval.Enabled {
+		var variant *Variant
+		if ruleVariant != "" {
+			variant = flag.variant(ruleVariant)
+		} else {
+			variant = flag.pickVariant(ctx)
+		}
+		if variant != nil {
+			eval.Variant, eval.Value = variant.Key, variant.Value
+		}
+	}
+	return eval
+}
+
+// matchingRule returns the first rule matching the context
+func (f *Flag) matchingRule(ctx EvalContext) *Rule {
+	for i := range f.Rules {
+		if f.Rules[i].matches(f.Key, ctx) {
+			return &f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// pickVariant picks a variant by weight, stable for the rollout unit (the
+// organization by default), or returns the default variant when the
+// context has no such unit
+func (f *Flag) pickVariant(ctx EvalContext) *Variant {
+	total := 0
+	for _, v := range f.Variants {
+		total += v.Weight
+	}
+	rollout := f.Rollout
+	if rollout == nil {
+		rollout = &Rollout{By: RolloutByOrganization}
+	}
+	unit := rollout.unitID(ctx)
+	if total == 0 || unit == "" {
+		return f.variant(f.DefaultVariant)
+	}
+
+	// Salted apart from the rollout, so the split is even within it
+	n := int(bucket(f.Key+":variant", unit)) % total
+	for i := range f.Variants {
+		if n < f.Variants[i].Weight {
+			return &f.Variants[i]
+		}
+		n -= f.Variants[i].Weight
+	}
+	return nil
+}
+
+// variant returns the variant with a key
+func (f *Flag) variant(key string) *Variant {
+	for i := range f.Variants {
+		if f.Variants[i].Key == key {
+			return &f.Variants[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the flag's rollouts, rules and variants are consistent
+func (f *Flag) Validate() error {
+	if f.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if err := f.Rollout.validate(); err != nil {
+		return err
+	}
+
+	keys := make(map[string]bool)
+	for _, v := range f.Variants {
+		if v.Key == "" {
+			return fmt.Errorf("variant key is required")
+		}
+		if keys[v.Key] {
+			return fmt.Errorf("duplicate variant %q", v.Key)
+		}
+		keys[v.Key] = true
+		if v.Weight < 0 {
+			return fmt.Errorf("variant %q has a negative weight", v.Key)
+		}
+		if !json.Valid(v.Value) {
+			return fmt.Errorf("variant %q value is not valid JSON", v.Key)
+		}
+	}
+	if f.DefaultVariant != "" && !keys[f.DefaultVariant] {
+		return fmt.Errorf("unknown default variant %q", f.DefaultVariant)
+	}
+
+	for i, r := range f.Rules {
+		if len(r.Conditions) == 0 {
+			return fmt.Errorf("rule %d has no conditions", i+1)
+		}
+		for _, c := range r.Conditions {
+			if c.Attribute == "" {
+				return fmt.Errorf("rule %d has a condition without an attribute", i+1)
+			}
+			if c.Operator != OperatorIn && c.Operator != OperatorNotIn {
+				return fmt.Errorf("rule %d has an unknown operator %q", i+1, c.Operator)
+			}
+		}
+		if err := r.Rollout.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if r.Variant != "" && !keys[r.Variant] {
+			return fmt.Errorf("rule %d serves unknown variant %q", i+1, r.Variant)
+		}
+	}
+	return nil
+}
+
+func (r *Rollout) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Percentage < 0 || r.Percentage > 100 {
+		return fmt.Errorf("rollout percentage must be between 0 and 100")
+	}
+	if r.By != RolloutByOrganization && r.By != RolloutByUser {
+		return fmt.Errorf("rollout must be by organization or user")
+	}
+	return nil
+}
+
+// contains reports whether a non-empty id is in ids
+func contains(ids []string, id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
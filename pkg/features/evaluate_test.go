@@ -0,0 +1,369 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// units returns n distinct rollout unit IDs
+func units(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("org-%d", i)
+	}
+	return ids
+}
+
+func TestBucket(t *testing.T) {
+	// Buckets are fixed by the hash, so they must never change: a change
+	// would move organizations in and out of every running rollout
+	assert.Equal(t, uint32(7575), bucket("rfq_enabled", "org-1"))
+	assert.Equal(t, uint32(3502), bucket("rfq_enabled", "org-2"))
+	assert.Equal(t, uint32(6479), bucket("vessel_tracking_enabled", "org-1"))
+
+	same := 0
+	counts := make([]int, 4)
+	for _, unit := range units(10000) {
+		b := bucket("rfq_enabled", unit)
+		require.Less(t, b, uint32(10000))
+		counts[b/2500]++
+		if b == bucket("vessel_tracking_enabled", unit) {
+			same++
+		}
+	}
+
+	// Buckets are spread evenly, and independently per flag
+	for i, n := range counts {
+		assert.InDelta(t, 2500, n, 250, "quarter %d", i)
+	}
+	assert.Less(t, same, 10)
+}
+
+func TestRollout_Includes(t *testing.T) {
+	ids := units(2000)
+	previous := map[string]bool{}
+
+	for _, percentage := range []float64{0, 0.5, 10, 25, 50, 99.5, 100} {
+		rollout := &Rollout{Percentage: percentage, By: RolloutByOrganization}
+		included := map[string]bool{}
+		for _, id := range ids {
+			if rollout.includes("rfq_enabled", EvalContext{OrganizationID: id}) {
+				included[id] = true
+			}
+		}
+
+		// Raising the percentage only adds organizations
+		for id := range previous {
+			assert.True(t, included[id], "%s dropped out at %v%%", id, percentage)
+		}
+		assert.InDelta(t, percentage/100*float64(len(ids)), len(included), 0.05*float64(len(ids)), "%v%%", percentage)
+		previous = included
+	}
+	assert.Len(t, previous, len(ids))
+}
+
+func TestRollout_Unit(t *testing.T) {
+	byOrg := &Rollout{Percentage: 100, By: RolloutByOrganization}
+	byUser := &Rollout{Percentage: 100, By: RolloutByUser}
+
+	assert.True(t, byOrg.includes("f", EvalContext{OrganizationID: "org-1"}))
+	assert.False(t, byOrg.includes("f", EvalContext{UserID: "user-1"}))
+	assert.True(t, byUser.includes("f", EvalContext{UserID: "user-1"}))
+	assert.False(t, byUser.includes("f", EvalContext{OrganizationID: "org-1"}))
+
+	// Users of one organization are rolled out to independently
+	byUser.Percentage = 50
+	in := 0
+	for _, id := range units(1000) {
+		if byUser.includes("f", EvalContext{OrganizationID: "org-1", UserID: id}) {
+			in++
+		}
+	}
+	assert.InDelta(t, 500, in, 60)
+}
+
+func TestEvaluate(t *testing.T) {
+	variants := []Variant{
+		{Key: "control", Value: json.RawMessage(`"control"`), Weight: 1},
+		{Key: "compact", Value: json.RawMessage(`"compact"`), Weight: 0},
+	}
+
+	tests := []struct {
+		name    string
+		flag    Flag
+		ctx     EvalContext
+		enabled bool
+		reason  string
+		variant string
+	}{
+		{
+			name:   "default",
+			flag:   Flag{Key: "f"},
+			ctx:    EvalContext{OrganizationID: "org-1"},
+			reason: ReasonDefault,
+		},
+		{
+			name:    "default on",
+			flag:    Flag{Key: "f", DefaultValue: true},
+			ctx:     EvalContext{OrganizationID: "org-1"},
+			enabled: true,
+			reason:  ReasonDefault,
+		},
+		{
+			name: "workspace override beats organization override",
+			flag: Flag{
+				Key:                   "f",
+				EnabledWorkspaces:     []string{"ws-1"},
+				DisabledOrganizations: []string{"org-1"},
+			},
+			ctx:     EvalContext{OrganizationID: "org-1", WorkspaceID: "ws-1"},
+			enabled: true,
+			reason:  ReasonWorkspaceOverride,
+		},
+		{
+			name: "organization override beats rules",
+			flag: Flag{
+				Key:                   "f",
+				DisabledOrganizations: []string{"org-1"},
+				Rules: []Rule{{
+					Conditions: []Condition{{Attribute: AttributeOrganizationID, Operator: OperatorIn, Values: []string{"org-1"}}},
+					Enabled:    true,
+				}},
+			},
+			ctx:    EvalContext{OrganizationID: "org-1"},
+			reason: ReasonOrganizationOverride,
+		},
+		{
+			name: "first matching rule wins",
+			flag: Flag{
+				Key: "f",
+				Rules: []Rule{
+					{
+						Conditions: []Condition{{Attribute: AttributePortalType, Operator: OperatorIn, Values: []string{"vendor"}}},
+						Enabled:    true,
+					},
+					{
+						Conditions: []Condition{{Attribute: AttributeRole, Operator: OperatorIn, Values: []string{"viewer"}}},
+						Enabled:    false,
+					},
+					{
+						Conditions: []Condition{{Attribute: AttributeRole, Operator: OperatorIn, Values: []string{"operator"}}},
+						Enabled:    true,
+					},
+				},
+			},
+			ctx:    EvalContext{OrganizationID: "org-1", PortalType: "agent", Roles: []string{"operator", "viewer"}},
+			reason: ReasonRule,
+		},
+		{
+			name: "all conditions must match",
+			flag: Flag{
+				Key:          "f",
+				DefaultValue: true,
+				Rules: []Rule{{
+					Conditions: []Condition{
+						{Attribute: AttributePortalType, Operator: OperatorIn, Values: []string{"agent"}},
+						{Attribute: "region", Operator: OperatorIn, Values: []string{"emea"}},
+					},
+					Enabled: false,
+				}},
+			},
+			ctx:     EvalContext{OrganizationID: "org-1", PortalType: "agent", Attributes: map[string]string{"region": "apac"}},
+			enabled: true,
+			reason:  ReasonDefault,
+		},
+		{
+			name: "not_in needs none of the roles",
+			flag: Flag{
+				Key: "f",
+				Rules: []Rule{{
+					Conditions: []Condition{{Attribute: AttributeRole, Operator: OperatorNotIn, Values: []string{"viewer"}}},
+					Enabled:    true,
+				}},
+			},
+			ctx:    EvalContext{OrganizationID: "org-1", Roles: []string{"operator", "viewer"}},
+			reason: ReasonDefault,
+		},
+		{
+			name: "not_in matches a missing attribute",
+			flag: Flag{
+				Key: "f",
+				Rules: []Rule{{
+					Conditions: []Condition{{Attribute: AttributeWorkspaceID, Operator: OperatorNotIn, Values: []string{"ws-1"}}},
+					Enabled:    true,
+				}},
+			},
+			ctx:     EvalContext{OrganizationID: "org-1"},
+			enabled: true,
+			reason:  ReasonRule,
+		},
+		{
+			name: "rule outside its rollout falls through",
+			flag: Flag{
+				Key: "f",
+				Rules: []Rule{{
+					Conditions: []Condition{{Attribute: AttributeOrganizationID, Operator: OperatorIn, Values: []string{"org-1"}}},
+					Rollout:    &Rollout{Percentage: 0, By: RolloutByOrganization},
+					Enabled:    true,
+				}},
+				Rollout: &Rollout{Percentage: 100, By: RolloutByOrganization},
+			},
+			ctx:     EvalContext{OrganizationID: "org-1"},
+			enabled: true,
+			reason:  ReasonRollout,
+		},
+		{
+			name:   "rollout without its unit",
+			flag:   Flag{Key: "f", DefaultValue: true, Rollout: &Rollout{Percentage: 100, By: RolloutByUser}},
+			ctx:    EvalContext{OrganizationID: "org-1"},
+			reason: ReasonRollout,
+		},
+		{
+			name: "rule variant",
+			flag: Flag{
+				Key:          "f",
+				DefaultValue: true,
+				Variants:     variants,
+				Rules: []Rule{{
+					Conditions: []Condition{{Attribute: AttributeOrganizationID, Operator: OperatorIn, Values: []string{"org-1"}}},
+					Enabled:    true,
+					Variant:    "compact",
+				}},
+			},
+			ctx:     EvalContext{OrganizationID: "org-1"},
+			enabled: true,
+			reason:  ReasonRule,
+			variant: "compact",
+		},
+		{
+			name:    "weighted variant",
+			flag:    Flag{Key: "f", DefaultValue: true, Variants: variants},
+			ctx:     EvalContext{OrganizationID: "org-1"},
+			enabled: true,
+			reason:  ReasonDefault,
+			variant: "control",
+		},
+		{
+			name:   "no variant when disabled",
+			flag:   Flag{Key: "f", Variants: variants, DefaultVariant: "control"},
+			ctx:    EvalContext{OrganizationID: "org-1"},
+			reason: ReasonDefault,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := Evaluate(&tt.flag, tt.ctx)
+			assert.Equal(t, "f", eval.Key)
+			assert.Equal(t, tt.enabled, eval.Enabled)
+			assert.Equal(t, tt.reason, eval.Reason)
+			assert.Equal(t, tt.variant, eval.Variant)
+			if tt.variant != "" {
+				var value string
+				require.NoError(t, eval.Decode(&value))
+				assert.Equal(t, tt.variant, value)
+			} else {
+				assert.Error(t, eval.Decode(new(string)))
+			}
+		})
+	}
+}
+
+func TestPickVariant(t *testing.T) {
+	flag := &Flag{
+		Key: "checkout",
+		Variants: []Variant{
+			{Key: "a", Weight: 1},
+			{Key: "off", Weight: 0},
+			{Key: "b", Weight: 3},
+		},
+		DefaultVariant: "a",
+	}
+
+	counts := map[string]int{}
+	for _, id := range units(4000) {
+		ctx := EvalContext{OrganizationID: id}
+		variant := flag.pickVariant(ctx)
+		require.NotNil(t, variant)
+		// An organization always gets the same variant
+		assert.Equal(t, variant, flag.pickVariant(ctx))
+		counts[variant.Key]++
+	}
+	assert.InDelta(t, 1000, counts["a"], 150)
+	assert.InDelta(t, 3000, counts["b"], 150)
+	assert.Zero(t, counts["off"])
+
+	// Without a unit to split by, the default variant is served
+	assert.Equal(t, "a", flag.pickVariant(EvalContext{UserID: "user-1"}).Key)
+
+	flag.Rollout = &Rollout{Percentage: 100, By: RolloutByUser}
+	assert.Equal(t, "a", flag.pickVariant(EvalContext{OrganizationID: "org-1"}).Key)
+	assert.NotNil(t, flag.pickVariant(EvalContext{UserID: "user-1"}))
+
+	flag.Variants[0].Weight, flag.Variants[2].Weight = 0, 0
+	assert.Equal(t, "a", flag.pickVariant(EvalContext{UserID: "user-1"}).Key)
+	flag.DefaultVariant = ""
+	assert.Nil(t, flag.pickVariant(EvalContext{UserID: "user-1"}))
+}
+
+func TestFlag_Validate(t *testing.T) {
+	valid := func() Flag {
+		return Flag{
+			Key:     "f",
+			Name:    "Flag",
+			Rollout: &Rollout{Percentage: 50, By: RolloutByOrganization},
+			Variants: []Variant{
+				{Key: "a", Value: json.RawMessage(`"a"`), Weight: 1},
+				{Key: "b", Value: json.RawMessage(`{"size": 2}`), Weight: 1},
+			},
+			DefaultVariant: "a",
+			Rules: []Rule{{
+				Conditions: []Condition{{Attribute: AttributeRole, Operator: OperatorIn, Values: []string{"admin"}}},
+				Rollout:    &Rollout{Percentage: 10, By: RolloutByUser},
+				Enabled:    true,
+				Variant:    "b",
+			}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(f *Flag)
+		err    string
+	}{
+		{"valid", func(f *Flag) {}, ""},
+		{"minimal", func(f *Flag) { *f = Flag{Key: "f", Name: "Flag"} }, ""},
+		{"no key", func(f *Flag) { f.Key = "" }, "key is required"},
+		{"no name", func(f *Flag) { f.Name = "" }, "name is required"},
+		{"rollout above 100", func(f *Flag) { f.Rollout.Percentage = 101 }, "rollout percentage must be between 0 and 100"},
+		{"negative rollout", func(f *Flag) { f.Rollout.Percentage = -1 }, "rollout percentage must be between 0 and 100"},
+		{"unknown rollout unit", func(f *Flag) { f.Rollout.By = "workspace" }, "rollout must be by organization or user"},
+		{"variant without key", func(f *Flag) { f.Variants[1].Key = "" }, "variant key is required"},
+		{"duplicate variant", func(f *Flag) { f.Variants[1].Key = "a" }, `duplicate variant "a"`},
+		{"negative weight", func(f *Flag) { f.Variants[1].Weight = -1 }, `variant "b" has a negative weight`},
+		{"invalid value", func(f *Flag) { f.Variants[1].Value = json.RawMessage(`{size}`) }, `variant "b" value is not valid JSON`},
+		{"unknown default variant", func(f *Flag) { f.DefaultVariant = "c" }, `unknown default variant "c"`},
+		{"rule without conditions", func(f *Flag) { f.Rules[0].Conditions = nil }, "rule 1 has no conditions"},
+		{"condition without attribute", func(f *Flag) { f.Rules[0].Conditions[0].Attribute = "" }, "rule 1 has a condition without an attribute"},
+		{"unknown operator", func(f *Flag) { f.Rules[0].Conditions[0].Operator = "eq" }, `rule 1 has an unknown operator "eq"`},
+		{"invalid rule rollout", func(f *Flag) { f.Rules[0].Rollout.By = "" }, "rule 1: rollout must be by organization or user"},
+		{"unknown rule variant", func(f *Flag) { f.Rules[0].Variant = "c" }, `rule 1 serves unknown variant "c"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := valid()
+			tt.modify(&flag)
+			err := flag.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
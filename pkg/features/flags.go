@@ -4,16 +4,25 @@ package features
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/navo/pkg/logger"
-	"github.com/navo/pkg/redis"
+	"github.com/navo/pkg/metrics"
+	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// ErrFlagNotFound is returned for an unknown flag key
+var ErrFlagNotFound = errors.New("feature flag not found")
+
 // Flag represents a feature flag configuration
 type Flag struct {
 	Key          string   `json:"key"`
@@ -26,6 +35,13 @@ type Flag struct {
 	// Workspaces with override (opposite of default)
 	EnabledWorkspaces  []string `json:"enabled_workspaces,omitempty"`
 	DisabledWorkspaces []string `json:"disabled_workspaces,omitempty"`
+	// Rules are checked in order after the overrides; the first match wins
+	Rules []Rule `json:"rules,omitempty"`
+	// Rollout serves the flag to a percentage of the rest
+	Rollout *Rollout `json:"rollout,omitempty"`
+	// Variants split enabled contexts of a multivariate flag
+	Variants       []Variant `json:"variants,omitempty"`
+	DefaultVariant string    `json:"default_variant,omitempty"`
 }
 
 // Well-known feature flag keys
@@ -44,6 +60,8 @@ const (
 	FlagRealTimeEnabled = "realtime_enabled"
 	// FlagIncidentTrackingEnabled enables incident tracking
 	FlagIncidentTrackingEnabled = "incident_tracking_enabled"
+	// FlagQuoteEvaluationV2 enables the new quote evaluation
+	FlagQuoteEvaluationV2 = "quote_evaluation_v2"
 )
 
 // Service provides feature flag functionality
@@ -51,15 +69,33 @@ type Service interface {
 	// IsEnabled checks if a feature is enabled for the given context
 	IsEnabled(ctx context.Context, key string, orgID, workspaceID string) bool
 
+	// Variant evaluates a flag, multivariate or not, for the given context
+	Variant(ctx context.Context, key string, evalCtx EvalContext) Evaluation
+
 	// GetAll returns all feature flag states for the given context
 	GetAll(ctx context.Context, orgID, workspaceID string) map[string]bool
 
+	// EvaluateAll evaluates all feature flags for the given context
+	EvaluateAll(ctx context.Context, evalCtx EvalContext) map[string]Evaluation
+
 	// GetFlag retrieves a flag definition
 	GetFlag(ctx context.Context, key string) (*Flag, error)
 
+	// ListFlags retrieves all flag definitions
+	ListFlags(ctx context.Context) ([]Flag, error)
+
 	// SetFlag creates or updates a feature flag
 	SetFlag(ctx context.Context, flag Flag) error
 
+	// SaveFlag creates or updates a feature flag, recording who changed it
+	SaveFlag(ctx context.Context, flag Flag, changedBy string) error
+
+	// DeleteFlag deletes a feature flag, recording who deleted it
+	DeleteFlag(ctx context.Context, key, changedBy string) error
+
+	// History returns a flag's changes, newest first
+	History(ctx context.Context, key string, limit int) ([]Change, error)
+
 	// InvalidateCache clears the cache for a specific flag
 	InvalidateCache(ctx context.Context, key string) error
 }
@@ -67,15 +103,18 @@ type Service interface {
 // DBService implements Service using PostgreSQL and Redis caching
 type DBService struct {
 	pool        *pgxpool.Pool
-	redis       *redis.Client
+	redis       *goredis.Client
 	cache       sync.Map
 	cacheTTL    time.Duration
 	refreshChan chan string
+	metrics     *metrics.Metrics
 }
 
 // DBServiceConfig holds configuration for DBService
 type DBServiceConfig struct {
 	CacheTTL time.Duration
+	// Metrics records flag evaluations when set
+	Metrics *metrics.Metrics
 }
 
 // DefaultDBServiceConfig returns default configuration
@@ -86,7 +125,7 @@ func DefaultDBServiceConfig() *DBServiceConfig {
 }
 
 // NewDBService creates a new database-backed feature flag service
-func NewDBService(pool *pgxpool.Pool, redisClient *redis.Client, cfg *DBServiceConfig) *DBService {
+func NewDBService(pool *pgxpool.Pool, redisClient *goredis.Client, cfg *DBServiceConfig) *DBService {
 	if cfg == nil {
 		cfg = DefaultDBServiceConfig()
 	}
@@ -96,6 +135,7 @@ func NewDBService(pool *pgxpool.Pool, redisClient *redis.Client, cfg *DBServiceC
 		redis:       redisClient,
 		cacheTTL:    cfg.CacheTTL,
 		refreshChan: make(chan string, 100),
+		metrics:     cfg.Metrics,
 	}
 
 	// Start background cache refresher
@@ -122,59 +162,40 @@ type cachedFlag struct {
 
 // IsEnabled checks if a feature is enabled for the given context
 func (s *DBService) IsEnabled(ctx context.Context, key string, orgID, workspaceID string) bool {
+	return s.Variant(ctx, key, EvalContext{OrganizationID: orgID, WorkspaceID: workspaceID}).Enabled
+}
+
+// Variant evaluates a flag for the given context. Unknown flags are
+// disabled.
+func (s *DBService) Variant(ctx context.Context, key string, evalCtx EvalContext) Evaluation {
 	flag, err := s.GetFlag(ctx, key)
 	if err != nil {
 		logger.Debug("Feature flag not found, using default false",
 			zap.String("key", key),
 			zap.Error(err),
 		)
-		return false
+		eval := Evaluation{Key: key, Reason: ReasonNotFound}
+		s.record(eval)
+		return eval
 	}
 
-	return evaluateFlag(flag, orgID, workspaceID)
-}
-
-// evaluateFlag determines if a flag is enabled for the given context
-func evaluateFlag(flag *Flag, orgID, workspaceID string) bool {
-	// Check workspace-level overrides first (most specific)
-	if workspaceID != "" {
-		// If in enabled workspaces, return true
-		for _, ws := range flag.EnabledWorkspaces {
-			if ws == workspaceID {
-				return true
-			}
-		}
-		// If in disabled workspaces, return false
-		for _, ws := range flag.DisabledWorkspaces {
-			if ws == workspaceID {
-				return false
-			}
-		}
-	}
-
-	// Check organization-level overrides
-	if orgID != "" {
-		// If in enabled organizations, return true
-		for _, org := range flag.EnabledOrganizations {
-			if org == orgID {
-				return true
-			}
-		}
-		// If in disabled organizations, return false
-		for _, org := range flag.DisabledOrganizations {
-			if org == orgID {
-				return false
-			}
-		}
-	}
-
-	// Fall back to default value
-	return flag.DefaultValue
+	eval := Evaluate(flag, evalCtx)
+	s.record(eval)
+	return eval
 }
 
 // GetAll returns all feature flag states for the given context
 func (s *DBService) GetAll(ctx context.Context, orgID, workspaceID string) map[string]bool {
 	result := make(map[string]bool)
+	for key, eval := range s.EvaluateAll(ctx, EvalContext{OrganizationID: orgID, WorkspaceID: workspaceID}) {
+		result[key] = eval.Enabled
+	}
+	return result
+}
+
+// EvaluateAll evaluates all feature flags for the given context
+func (s *DBService) EvaluateAll(ctx context.Context, evalCtx EvalContext) map[string]Evaluation {
+	result := make(map[string]Evaluation)
 
 	// Load all flags from database
 	flags, err := s.loadAllFromDB(ctx)
@@ -184,13 +205,22 @@ func (s *DBService) GetAll(ctx context.Context, orgID, workspaceID string) map[s
 	}
 
 	// Evaluate each flag
-	for _, flag := range flags {
-		result[flag.Key] = evaluateFlag(&flag, orgID, workspaceID)
+	for i := range flags {
+		eval := Evaluate(&flags[i], evalCtx)
+		s.record(eval)
+		result[eval.Key] = eval
 	}
 
 	return result
 }
 
+// record records an evaluation in the metrics
+func (s *DBService) record(eval Evaluation) {
+	if s.metrics != nil {
+		s.metrics.RecordFeatureFlagEvaluation(eval.Key, strconv.FormatBool(eval.Enabled), eval.Variant, eval.Reason)
+	}
+}
+
 // GetFlag retrieves a flag definition
 func (s *DBService) GetFlag(ctx context.Context, key string) (*Flag, error) {
 	// Check local cache first
@@ -220,20 +250,19 @@ func (s *DBService) GetFlag(ctx context.Context, key string) (*Flag, error) {
 	return flag, nil
 }
 
-// loadFromDB loads a single flag from the database
-func (s *DBService) loadFromDB(ctx context.Context, key string) (*Flag, error) {
-	query := `
-		SELECT key, name, description, default_value,
-			enabled_organizations, disabled_organizations,
-			enabled_workspaces, disabled_workspaces
-		FROM feature_flags
-		WHERE key = $1
-	`
+// flagColumns are the feature_flags columns scanFlag reads
+const flagColumns = `key, name, description, default_value,
+	enabled_organizations, disabled_organizations,
+	enabled_workspaces, disabled_workspaces,
+	rules, rollout, variants, default_variant`
 
+// scanFlag scans a flag selected with flagColumns
+func scanFlag(row pgx.Row) (*Flag, error) {
 	var flag Flag
-	var description *string
+	var description, defaultVariant *string
+	var rules, rollout, variants []byte
 
-	err := s.pool.QueryRow(ctx, query, key).Scan(
+	err := row.Scan(
 		&flag.Key,
 		&flag.Name,
 		&description,
@@ -242,26 +271,68 @@ func (s *DBService) loadFromDB(ctx context.Context, key string) (*Flag, error) {
 		&flag.DisabledOrganizations,
 		&flag.EnabledWorkspaces,
 		&flag.DisabledWorkspaces,
+		&rules,
+		&rollout,
+		&variants,
+		&defaultVariant,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load feature flag %s: %w", key, err)
+		return nil, err
 	}
 
 	if description != nil {
 		flag.Description = *description
 	}
+	if defaultVariant != nil {
+		flag.DefaultVariant = *defaultVariant
+	}
+	if err := unmarshalColumn(rules, &flag.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules of feature flag %s: %w", flag.Key, err)
+	}
+	if err := unmarshalColumn(rollout, &flag.Rollout); err != nil {
+		return nil, fmt.Errorf("invalid rollout of feature flag %s: %w", flag.Key, err)
+	}
+	if err := unmarshalColumn(variants, &flag.Variants); err != nil {
+		return nil, fmt.Errorf("invalid variants of feature flag %s: %w", flag.Key, err)
+	}
 
 	return &flag, nil
 }
 
+// unmarshalColumn unmarshals a nullable JSONB column
+func unmarshalColumn(data []byte, v any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// marshalColumn marshals a value for a nullable JSONB column
+func marshalColumn(v any) ([]byte, error) {
+	if reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// loadFromDB loads a single flag from the database
+func (s *DBService) loadFromDB(ctx context.Context, key string) (*Flag, error) {
+	query := `SELECT ` + flagColumns + ` FROM feature_flags WHERE key = $1`
+
+	flag, err := scanFlag(s.pool.QueryRow(ctx, query, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFlagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flag %s: %w", key, err)
+	}
+
+	return flag, nil
+}
+
 // loadAllFromDB loads all flags from the database
 func (s *DBService) loadAllFromDB(ctx context.Context) ([]Flag, error) {
-	query := `
-		SELECT key, name, description, default_value,
-			enabled_organizations, disabled_organizations,
-			enabled_workspaces, disabled_workspaces
-		FROM feature_flags
-	`
+	query := `SELECT ` + flagColumns + ` FROM feature_flags ORDER BY key`
 
 	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
@@ -269,44 +340,72 @@ func (s *DBService) loadAllFromDB(ctx context.Context) ([]Flag, error) {
 	}
 	defer rows.Close()
 
-	var flags []Flag
+	flags := []Flag{}
 	for rows.Next() {
-		var flag Flag
-		var description *string
-
-		err := rows.Scan(
-			&flag.Key,
-			&flag.Name,
-			&description,
-			&flag.DefaultValue,
-			&flag.EnabledOrganizations,
-			&flag.DisabledOrganizations,
-			&flag.EnabledWorkspaces,
-			&flag.DisabledWorkspaces,
-		)
+		flag, err := scanFlag(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
 		}
-
-		if description != nil {
-			flag.Description = *description
-		}
-
-		flags = append(flags, flag)
+		flags = append(flags, *flag)
 	}
 
-	return flags, nil
+	return flags, rows.Err()
+}
+
+// ListFlags retrieves all flag definitions
+func (s *DBService) ListFlags(ctx context.Context) ([]Flag, error) {
+	return s.loadAllFromDB(ctx)
 }
 
 // SetFlag creates or updates a feature flag
 func (s *DBService) SetFlag(ctx context.Context, flag Flag) error {
+	return s.SaveFlag(ctx, flag, "")
+}
+
+// SaveFlag creates or updates a feature flag. Changes are recorded in the
+// flag's history; saving a flag unchanged records nothing.
+func (s *DBService) SaveFlag(ctx context.Context, flag Flag, changedBy string) error {
+	if err := flag.Validate(); err != nil {
+		return fmt.Errorf("invalid feature flag: %w", err)
+	}
+
+	rules, err := marshalColumn(flag.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	rollout, err := marshalColumn(flag.Rollout)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollout: %w", err)
+	}
+	variants, err := marshalColumn(flag.Variants)
+	if err != nil {
+		return fmt.Errorf("failed to marshal variants: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	previous, err := scanFlag(tx.QueryRow(ctx, `SELECT `+flagColumns+` FROM feature_flags WHERE key = $1 FOR UPDATE`, flag.Key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		previous = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to load feature flag %s: %w", flag.Key, err)
+	}
+	if previous != nil && sameFlag(previous, &flag) {
+		return nil
+	}
+
 	query := `
 		INSERT INTO feature_flags (
 			key, name, description, default_value,
 			enabled_organizations, disabled_organizations,
 			enabled_workspaces, disabled_workspaces,
+			rules, rollout, variants, default_variant,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
 		ON CONFLICT (key) DO UPDATE SET
 			name = EXCLUDED.name,
 			description = EXCLUDED.description,
@@ -315,10 +414,14 @@ func (s *DBService) SetFlag(ctx context.Context, flag Flag) error {
 			disabled_organizations = EXCLUDED.disabled_organizations,
 			enabled_workspaces = EXCLUDED.enabled_workspaces,
 			disabled_workspaces = EXCLUDED.disabled_workspaces,
+			rules = EXCLUDED.rules,
+			rollout = EXCLUDED.rollout,
+			variants = EXCLUDED.variants,
+			default_variant = EXCLUDED.default_variant,
 			updated_at = NOW()
 	`
 
-	_, err := s.pool.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		flag.Key,
 		flag.Name,
 		nullableString(flag.Description),
@@ -327,17 +430,79 @@ func (s *DBService) SetFlag(ctx context.Context, flag Flag) error {
 		flag.DisabledOrganizations,
 		flag.EnabledWorkspaces,
 		flag.DisabledWorkspaces,
+		rules,
+		rollout,
+		variants,
+		nullableString(flag.DefaultVariant),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to set feature flag: %w", err)
 	}
 
+	action := ChangeUpdated
+	if previous == nil {
+		action = ChangeCreated
+	}
+	if err := recordChange(ctx, tx, flag.Key, action, previous, &flag, changedBy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit feature flag: %w", err)
+	}
+
 	// Invalidate cache
 	s.cache.Delete(flag.Key)
 
 	return nil
 }
 
+// DeleteFlag deletes a feature flag
+func (s *DBService) DeleteFlag(ctx context.Context, key, changedBy string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	previous, err := scanFlag(tx.QueryRow(ctx, `SELECT `+flagColumns+` FROM feature_flags WHERE key = $1 FOR UPDATE`, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrFlagNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load feature flag %s: %w", key, err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if err := recordChange(ctx, tx, key, ChangeDeleted, previous, nil, changedBy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit feature flag: %w", err)
+	}
+
+	s.cache.Delete(key)
+	return nil
+}
+
+// sameFlag reports whether two flags are equal, comparing variant values
+// as JSON rather than as text
+func sameFlag(a, b *Flag) bool {
+	var va, vb any
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if json.Unmarshal(da, &va) != nil || json.Unmarshal(db, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
 // InvalidateCache clears the cache for a specific flag
 func (s *DBService) InvalidateCache(ctx context.Context, key string) error {
 	s.cache.Delete(key)
@@ -397,9 +562,24 @@ func InitializeDefaultFlags(ctx context.Context, svc Service) error {
 			Description:  "Enable incident reporting and tracking",
 			DefaultValue: false,
 		},
+		{
+			Key:          FlagQuoteEvaluationV2,
+			Name:         "Quote Evaluation v2",
+			Description:  "Enable the new quote evaluation, rolled out by organization",
+			DefaultValue: false,
+			Rollout:      &Rollout{Percentage: 0, By: RolloutByOrganization},
+		},
 	}
 
 	for _, flag := range defaults {
+		// Keep flags as administrators left them
+		_, err := svc.GetFlag(ctx, flag.Key)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrFlagNotFound) {
+			return fmt.Errorf("failed to check flag %s: %w", flag.Key, err)
+		}
 		if err := svc.SetFlag(ctx, flag); err != nil {
 			return fmt.Errorf("failed to initialize flag %s: %w", flag.Key, err)
 		}
@@ -0,0 +1,99 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangeAction is what a change did to a flag
+type ChangeAction string
+
+const (
+	ChangeCreated ChangeAction = "created"
+	ChangeUpdated ChangeAction = "updated"
+	ChangeDeleted ChangeAction = "deleted"
+)
+
+// Change is a recorded change to a feature flag, with the flag before and
+// after it
+type Change struct {
+	ID        string       `json:"id"`
+	FlagKey   string       `json:"flag_key"`
+	Action    ChangeAction `json:"action"`
+	Previous  *Flag        `json:"previous,omitempty"`
+	Current   *Flag        `json:"current,omitempty"`
+	ChangedBy string       `json:"changed_by,omitempty"`
+	ChangedAt time.Time    `json:"changed_at"`
+}
+
+// recordChange records a change to a flag within the transaction changing it
+func recordChange(ctx context.Context, tx pgx.Tx, key string, action ChangeAction, previous, current *Flag, changedBy string) error {
+	previousJSON, err := marshalColumn(previous)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature flag: %w", err)
+	}
+	currentJSON, err := marshalColumn(current)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature flag: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO feature_flag_history (id, flag_key, action, previous, current, changed_by, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, uuid.New().String(), key, action, previousJSON, currentJSON, nullableString(changedBy))
+	if err != nil {
+		return fmt.Errorf("failed to record feature flag change: %w", err)
+	}
+	return nil
+}
+
+// History returns a flag's changes, newest first, including those from
+// before it was deleted
+func (s *DBService) History(ctx context.Context, key string, limit int) ([]Change, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, flag_key, action, previous, current, changed_by, changed_at
+		FROM feature_flag_history
+		WHERE flag_key = $1
+		ORDER BY changed_at DESC
+		LIMIT $2
+	`, key, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feature flag history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []Change{}
+	for rows.Next() {
+		var c Change
+		var previous, current []byte
+		var changedBy *string
+		if err := rows.Scan(&c.ID, &c.FlagKey, &c.Action, &previous, &current, &changedBy, &c.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag change: %w", err)
+		}
+		if len(previous) > 0 {
+			if err := json.Unmarshal(previous, &c.Previous); err != nil {
+				return nil, fmt.Errorf("invalid feature flag change %s: %w", c.ID, err)
+			}
+		}
+		if len(current) > 0 {
+			if err := json.Unmarshal(current, &c.Current); err != nil {
+				return nil, fmt.Errorf("invalid feature flag change %s: %w", c.ID, err)
+			}
+		}
+		if changedBy != nil {
+			c.ChangedBy = *changedBy
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,305 @@
+// Package idempotency makes mutating API requests safe to retry.
+//
+// Clients send an Idempotency-Key header with POST and PUT requests. The
+// first request with a key claims it for its principal; its response is
+// stored for the retention window and replayed to retries of the same
+// request. A key reused with a different request, or while the first is
+// still in flight, is answered with a conflict.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// Header names
+const (
+	// Header carries the client's idempotency key
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from a stored record
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength is the longest accepted idempotency key
+const maxKeyLength = 255
+
+// Config configures the idempotency middleware
+type Config struct {
+	// Prefix namespaces the service's keys in the store
+	Prefix string
+	// Retention is how long responses are replayed
+	Retention time.Duration
+	// LockTTL is how long an in-flight request holds its key, in case the
+	// service dies before storing the response. The lock is renewed every
+	// half LockTTL while the handler runs, so slow requests keep it.
+	LockTTL time.Duration
+	// MaxBodySize is the largest request body fingerprinted and response
+	// body stored. Larger requests are processed without idempotency, and
+	// larger responses free their key.
+	MaxBodySize int64
+	// Methods are the methods that honor idempotency keys
+	Methods []string
+	// Principal returns who a request is made by. Keys are scoped to their
+	// principal, and requests without one are processed without
+	// idempotency.
+	Principal func(r *http.Request) string
+}
+
+// DefaultConfig returns the idempotency configuration of a service
+func DefaultConfig(service string) *Config {
+	return &Config{
+		Prefix:      "navo:idempotency:" + service + ":",
+		Retention:   24 * time.Hour,
+		LockTTL:     time.Minute,
+		MaxBodySize: 1 << 20,
+		Methods:     []string{http.MethodPost, http.MethodPut},
+		Principal:   HeaderPrincipal,
+	}
+}
+
+// HeaderPrincipal identifies the caller by the organization and user the
+// gateway forwards
+func HeaderPrincipal(r *http.Request) string {
+	orgID := r.Header.Get("X-Organization-ID")
+	userID := r.Header.Get("X-User-ID")
+	if orgID == "" && userID == "" {
+		return ""
+	}
+	return orgID + ":" + userID
+}
+
+// Middleware makes requests carrying an Idempotency-Key safe to retry. When
+// the store fails, requests are processed without idempotency rather than
+// refused.
+func Middleware(store Store, cfg *Config) func(http.Handler) http.Handler {
+	if cfg == nil {
+		cfg = DefaultConfig("default")
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		methods[method] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idempotencyKey := r.Header.Get(Header)
+			if idempotencyKey == "" || !methods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(idempotencyKey) > maxKeyLength {
+				response.Error(w, errors.NewBadRequest("Idempotency-Key must be at most 255 characters"))
+				return
+			}
+			principal := cfg.Principal(r)
+			if principal == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodySize+1))
+			if err != nil {
+				response.Error(w, errors.NewBadRequest("Failed to read request body"))
+				return
+			}
+			if int64(len(body)) > cfg.MaxBodySize {
+				logger.Debug("Request body too large for idempotency", zap.String("path", r.URL.Path))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key := cfg.Prefix + hash(principal, idempotencyKey)
+			fingerprint := hash(r.Method, r.URL.RequestURI(), string(body))
+			reservation := &Record{
+				Fingerprint: fingerprint,
+				CreatedAt:   time.Now().UTC(),
+			}
+			existing, err := store.Reserve(r.Context(), key, reservation, cfg.LockTTL)
+			if err != nil {
+				logger.Warn("Idempotency store unavailable, processing request without it", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if existing != nil {
+				replay(w, existing, fingerprint)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK, limit: cfg.MaxBodySize}
+			completed := false
+			unlock := hold(r.Context(), store, key, reservation, cfg.LockTTL)
+			defer func() {
+				unlock()
+				// Also frees the key when the handler panics
+				if !completed {
+					ctx := context.WithoutCancel(r.Context())
+					if err := store.Release(ctx, key); err != nil {
+						logger.Warn("Failed to release idempotency key", zap.Error(err))
+					}
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+			unlock()
+
+			// Server errors and rate limiting say nothing about the request,
+			// so it may be sent again
+			if recorder.statusCode >= 500 || recorder.statusCode == http.StatusTooManyRequests || recorder.overflow {
+				return
+			}
+
+			ctx := context.WithoutCancel(r.Context())
+			if err := store.Save(ctx, key, &Record{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  recorder.statusCode,
+				Header:      storedHeader(recorder.Header()),
+				Body:        recorder.body.Bytes(),
+				CreatedAt:   time.Now().UTC(),
+			}, cfg.Retention); err != nil {
+				logger.Warn("Failed to store idempotent response", zap.Error(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// hold keeps a reserved key locked while its request is processed,
+// renewing the lock every half ttl so a handler that runs longer than ttl
+// doesn't let a retry through. Renewal stops once the key no longer holds
+// the reservation, and the returned function stops it and waits for a
+// renewal in progress.
+func hold(ctx context.Context, store Store, key string, record *Record, ttl time.Duration) func() {
+	if ttl/2 <= 0 {
+		return func() {}
+	}
+	ctx = context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renewed, err := store.Renew(ctx, key, record, ttl)
+				if err != nil {
+					logger.Warn("Failed to renew idempotency key", zap.Error(err))
+					continue
+				}
+				if !renewed {
+					logger.Warn("Idempotency key expired while its request was processed")
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// replay answers a request whose key is taken: with the stored response
+// when it is the same request, or a conflict
+func replay(w http.ResponseWriter, record *Record, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		response.Error(w, errors.NewConflict("Idempotency-Key was already used for a different request"))
+	case !record.Completed:
+		w.Header().Set("Retry-After", strconv.Itoa(1))
+		response.Error(w, errors.NewConflict("A request with this Idempotency-Key is still being processed"))
+	default:
+		for name, values := range record.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+	}
+}
+
+// hash returns the hex SHA-256 of parts, separated so they can't run into
+// each other
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storedHeader returns the response headers worth replaying
+func storedHeader(header http.Header) http.Header {
+	stored := header.Clone()
+	for _, name := range []string{"Set-Cookie", "Date", "Content-Length"} {
+		stored.Del(name)
+	}
+	return stored
+}
+
+// readCloser reads a body already partly read, closing the original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int64
+	overflow    bool
+}
+
+func (rw *responseRecorder) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	if !rw.overflow {
+		if int64(rw.body.Len()+len(b)) > rw.limit {
+			rw.overflow = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
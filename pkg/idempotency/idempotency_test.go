@@ -0,0 +1,360 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory Store that honors TTLs
+type memoryStore struct {
+	mu       sync.Mutex
+	records  map[string]*Record
+	expires  map[string]time.Time
+	reserves int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]*Record{}, expires: map[string]time.Time{}}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reserves++
+	if existing, ok := s.records[key]; ok && time.Now().Before(s.expires[key]) {
+		return existing, nil
+	}
+	s.records[key] = record
+	s.expires[key] = time.Now().Add(ttl)
+	return nil, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	s.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *memoryStore) Renew(ctx context.Context, key string, record *Record, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[key] != record || !time.Now().Before(s.expires[key]) {
+		return false, nil
+	}
+	s.expires[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *memoryStore) get(key string) *Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !time.Now().Before(s.expires[key]) {
+		return nil
+	}
+	return s.records[key]
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	delete(s.expires, key)
+	return nil
+}
+
+func (s *memoryStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+func testConfig() *Config {
+	cfg := DefaultConfig("test")
+	cfg.MaxBodySize = 64
+	return cfg
+}
+
+func newRequest(method, path, key, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-Organization-ID", "org-1")
+	req.Header.Set("X-User-ID", "user-1")
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	return req
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	h := Middleware(store, testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/port-calls/pc1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"pc1","received":` + string(body) + `}`))
+	}))
+
+	first := serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(ReplayedHeader))
+
+	second := serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(ReplayedHeader))
+	assert.Equal(t, "/port-calls/pc1", second.Header().Get("Location"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+
+	// Keys are scoped to their principal
+	other := newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`)
+	other.Header.Set("X-User-ID", "user-2")
+	serve(h, other)
+	assert.Equal(t, 2, calls)
+}
+
+func TestMiddleware_Conflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"different body", http.MethodPost, "/port-calls", `{"a":2}`},
+		{"different path", http.MethodPost, "/vessels", `{"a":1}`},
+		{"different method", http.MethodPut, "/port-calls", `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := Middleware(newMemoryStore(), testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+			rec := serve(h, newRequest(tt.method, tt.path, "key-1", tt.body))
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Contains(t, rec.Body.String(), "different request")
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestMiddleware_InFlight(t *testing.T) {
+	var retry *httptest.ResponseRecorder
+	var h http.Handler
+	calls := 0
+	h = Middleware(newMemoryStore(), testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// The client retries while the first request is still running
+			retry = serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, calls)
+	require.NotNil(t, retry)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "1", retry.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_LockOutlivesTTL(t *testing.T) {
+	cfg := testConfig()
+	cfg.LockTTL = 20 * time.Millisecond
+	store := newMemoryStore()
+	var h http.Handler
+	calls := 0
+	var retry *httptest.ResponseRecorder
+	h = Middleware(store, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// Outlive several lock TTLs before the client retries
+			time.Sleep(5 * cfg.LockTTL)
+			retry = serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, calls)
+	require.NotNil(t, retry)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+
+	// Renewal stops with the request, leaving the stored response in place
+	time.Sleep(2 * cfg.LockTTL)
+	replayed := serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+	assert.Equal(t, "true", replayed.Header().Get(ReplayedHeader))
+	assert.Equal(t, 1, calls)
+}
+
+func TestHold_RenewsOnlyItsReservation(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		takeOver func(store *memoryStore) *Record
+	}{
+		{"completed", func(store *memoryStore) *Record {
+			completed := &Record{Fingerprint: "fp", Completed: true, StatusCode: http.StatusCreated}
+			store.Save(ctx, "key", completed, time.Hour)
+			return completed
+		}},
+		{"reserved by a retry", func(store *memoryStore) *Record {
+			// The lock lapsed and a retry of the same request took the key
+			store.Release(ctx, "key")
+			retry := &Record{Fingerprint: "fp", CreatedAt: time.Now().UTC()}
+			store.Reserve(ctx, "key", retry, time.Hour)
+			return retry
+		}},
+		{"released", func(store *memoryStore) *Record {
+			store.Release(ctx, "key")
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			reservation := &Record{Fingerprint: "fp", CreatedAt: time.Now().UTC()}
+			existing, err := store.Reserve(ctx, "key", reservation, ttl)
+			require.NoError(t, err)
+			require.Nil(t, existing)
+
+			unlock := hold(ctx, store, "key", reservation, ttl)
+			defer unlock()
+
+			// Renewal keeps the reservation past its TTL
+			time.Sleep(2 * ttl)
+			require.Same(t, reservation, store.get("key"))
+
+			want := tt.takeOver(store)
+			time.Sleep(2 * ttl)
+			if want == nil {
+				assert.Nil(t, store.get("key"))
+			} else {
+				assert.Same(t, want, store.get("key"))
+			}
+		})
+	}
+}
+
+func TestMiddleware_ReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+		{"rate limited", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}},
+		{"response too large", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strings.Repeat("x", 100)))
+		}},
+		{"panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("handler failed")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			calls := 0
+			h := Middleware(store, testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			}))
+
+			for i := 0; i < 2; i++ {
+				func() {
+					defer func() { recover() }()
+					serve(h, newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`))
+				}()
+				assert.Zero(t, store.len())
+			}
+			assert.Equal(t, 2, calls)
+		})
+	}
+}
+
+func TestMiddleware_Bypass(t *testing.T) {
+	large := strings.Repeat("x", 100)
+
+	tests := []struct {
+		name string
+		req  func() *http.Request
+	}{
+		{"no key", func() *http.Request {
+			return newRequest(http.MethodPost, "/port-calls", "", `{"a":1}`)
+		}},
+		{"other method", func() *http.Request {
+			return newRequest(http.MethodDelete, "/port-calls/pc1", "key-1", ``)
+		}},
+		{"no principal", func() *http.Request {
+			req := newRequest(http.MethodPost, "/port-calls", "key-1", `{"a":1}`)
+			req.Header.Del("X-Organization-ID")
+			req.Header.Del("X-User-ID")
+			return req
+		}},
+		{"body too large", func() *http.Request {
+			return newRequest(http.MethodPost, "/port-calls", "key-1", large)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			var bodies []string
+			h := Middleware(store, testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			first := tt.req()
+			want, _ := io.ReadAll(first.Body)
+			first.Body = io.NopCloser(strings.NewReader(string(want)))
+			serve(h, first)
+			serve(h, tt.req())
+
+			// Every request reaches the handler with its whole body
+			assert.Equal(t, []string{string(want), string(want)}, bodies)
+			assert.Zero(t, store.reserves)
+		})
+	}
+}
+
+func TestMiddleware_KeyTooLong(t *testing.T) {
+	h := Middleware(newMemoryStore(), testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request with an invalid key reached the handler")
+	}))
+
+	rec := serve(h, newRequest(http.MethodPost, "/port-calls", strings.Repeat("k", 256), `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
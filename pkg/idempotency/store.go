@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Record is what is stored under an idempotency key: the fingerprint of the
+// request that claimed it and, once the request completed, its response
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Store keeps idempotency records
+type Store interface {
+	// Reserve stores a record under a key unless the key is taken, and
+	// returns the record already there otherwise
+	Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error)
+	// Save replaces the record under a key
+	Save(ctx context.Context, key string, record *Record, ttl time.Duration) error
+	// Renew extends a key's TTL while it still holds record, and reports
+	// whether it did. A key that expired, was released or completed is left
+	// alone.
+	Renew(ctx context.Context, key string, record *Record, ttl time.Duration) (bool, error)
+	// Release deletes a key, so the request can be sent again
+	Release(ctx context.Context, key string) error
+}
+
+// RedisStore keeps idempotency records in Redis, shared by every instance
+// of a service
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore creates a Redis-backed store
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Reserve implements Store
+func (s *RedisStore) Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	// A key can expire between a failed SETNX and the GET, so try twice
+	for i := 0; i < 2; i++ {
+		ok, err := s.client.SetNX(ctx, key, data, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		if ok {
+			return nil, nil
+		}
+
+		existing, err := s.client.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read idempotency key: %w", err)
+		}
+
+		var stored Record
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return &stored, nil
+	}
+	return nil, fmt.Errorf("failed to reserve idempotency key: key keeps expiring")
+}
+
+// Save implements Store
+func (s *RedisStore) Save(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// renewScript extends a key's TTL only while it holds the given record,
+// so a renewal can't race a completed response or another reservation
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Renew implements Store. Reserve stores records encoded the same way, so
+// the stored value is compared byte for byte.
+func (s *RedisStore) Renew(ctx context.Context, key string, record *Record, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+	renewed, err := renewScript.Run(ctx, s.client, []string{key}, data, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew idempotency key: %w", err)
+	}
+	return renewed == 1, nil
+}
+
+// Release implements Store
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
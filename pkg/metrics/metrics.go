@@ -56,6 +56,9 @@ type Metrics struct {
 	WorkerJobsTotal      *prometheus.CounterVec
 	WorkerJobDuration    *prometheus.HistogramVec
 	WorkerJobsInProgress *prometheus.GaugeVec
+
+	// Feature Flag Metrics
+	FeatureFlagEvaluations *prometheus.CounterVec
 }
 
 // Config holds metrics configuration
@@ -347,6 +350,16 @@ func New(cfg Config) *Metrics {
 		[]string{"worker"},
 	)
 
+	// Feature Flag Metrics
+	m.FeatureFlagEvaluations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Name:      "feature_flag_evaluations_total",
+			Help:      "Total number of feature flag evaluations by result",
+		},
+		[]string{"flag", "enabled", "variant", "reason"},
+	)
+
 	return m
 }
 
@@ -462,3 +475,8 @@ func (m *Metrics) StartWorkerJob(worker string) func(err error) {
 		m.WorkerJobsInProgress.WithLabelValues(worker).Dec()
 	}
 }
+
+// RecordFeatureFlagEvaluation records a feature flag evaluation
+func (m *Metrics) RecordFeatureFlagEvaluation(flag, enabled, variant, reason string) {
+	m.FeatureFlagEvaluations.WithLabelValues(flag, enabled, variant, reason).Inc()
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Command runs the migrate subcommand of a service binary and returns its
+// exit code, 1 when migrating fails and 2 on bad usage.
+//
+//	<service> migrate status
+//	<service> migrate up
+//	<service> migrate down [n]
+//	<service> migrate redo
+func Command(ctx context.Context, m *Migrator, args []string, out io.Writer) int {
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var err error
+	switch command {
+	case "status":
+		err = printStatus(ctx, m, out)
+	case "up":
+		var migrated []Migration
+		migrated, err = m.Up(ctx)
+		printMigrated(out, "applied", migrated)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				fmt.Fprintf(out, "migrate: invalid count %q\n", args[1])
+				return 2
+			}
+		}
+		var migrated []Migration
+		migrated, err = m.Down(ctx, n)
+		printMigrated(out, "rolled back", migrated)
+	case "redo":
+		var redone *Migration
+		if redone, err = m.Redo(ctx); redone != nil {
+			fmt.Fprintf(out, "redone %s\n", redone)
+		}
+	default:
+		fmt.Fprintf(out, "migrate: unknown command %q, want status, up, down [n] or redo\n", command)
+		return 2
+	}
+
+	if errors.Is(err, ErrNothingApplied) {
+		fmt.Fprintln(out, "nothing to roll back")
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(out, "migrate: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printStatus prints a table of the service's migrations
+func printStatus(ctx context.Context, m *Migrator, out io.Writer) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		note := ""
+		switch {
+		case s.Unknown:
+			note = "not in this binary"
+		case s.Modified:
+			note = "changed since applied"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, note)
+	}
+	return w.Flush()
+}
+
+func printMigrated(out io.Writer, verb string, migrated []Migration) {
+	if len(migrated) == 0 {
+		fmt.Fprintf(out, "no migrations %s\n", verb)
+		return
+	}
+	for _, migration := range migrated {
+		fmt.Fprintf(out, "%s %s\n", verb, migration)
+	}
+}
@@ -0,0 +1,364 @@
+// Package migrate applies versioned SQL schema migrations embedded in each
+// service binary, recording what each service has applied in the database.
+//
+// Migrations are pairs of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, e.g. 0001_create_webhooks.up.sql. Each runs in
+// its own transaction, together with the update of schema_migrations.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey is the advisory lock held while migrating. It is the same for
+// every service, so services sharing a database don't race either.
+const lockKey = "schema_migrations"
+
+// ErrNothingApplied is returned by Down and Redo when no migration is applied
+var ErrNothingApplied = errors.New("no migration applied")
+
+// Migration is a versioned schema change and its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum identifies the migration's up SQL, to tell when an applied
+// migration was changed afterwards
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in the root of fsys, ordered by version. Every
+// migration needs both an up and a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %s needs both up and down SQL", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Status is a migration's state in the database
+type Status struct {
+	Migration
+	AppliedAt *time.Time // Nil while pending
+	Modified  bool       // Changed since it was applied
+	Unknown   bool       // Applied, but this binary doesn't have it
+}
+
+// Migrator applies a service's migrations
+type Migrator struct {
+	db         *sql.DB
+	service    string
+	migrations []Migration
+}
+
+// New creates a migrator for a service's migrations in fsys
+func New(db *sql.DB, service string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", service, err)
+	}
+	return &Migrator{db: db, service: service, migrations: migrations}, nil
+}
+
+// Migrations returns the service's migrations, ordered by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// applied records when a migration was applied, and with which SQL
+type applied struct {
+	at       time.Time
+	checksum string
+}
+
+// Status returns the state of every known or applied migration, ordered by
+// version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			s := Status{Migration: migration}
+			if a, ok := done[migration.Version]; ok {
+				at := a.at
+				s.AppliedAt = &at
+				s.Modified = a.checksum != migration.Checksum()
+				delete(done, migration.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		for version, a := range done {
+			at := a.at
+			statuses = append(statuses, Status{Migration: Migration{Version: version}, AppliedAt: &at, Unknown: true})
+		}
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, err
+}
+
+// Up applies every pending migration in version order and returns those it
+// applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var migrated []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			migrated = append(migrated, migration)
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// Down rolls back the latest n applied migrations and returns those it
+// rolled back
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var migrated []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn, n)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range latest {
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			migrated = append(migrated, migration)
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// Redo rolls back the latest applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+
+		migration := latest[0]
+		if err := m.apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+		if err := m.apply(ctx, conn, migration, true); err != nil {
+			return err
+		}
+		redone = &migration
+		return nil
+	})
+	return redone, err
+}
+
+// locked runs fn on a connection holding the migration lock, creating the
+// schema_migrations table first. Replicas starting together wait for each
+// other here.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1))`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext($1))`, lockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			service TEXT NOT NULL,
+			version BIGINT NOT NULL,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (service, version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// applied returns the service's applied migrations by version
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]applied, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version, checksum, applied_at FROM schema_migrations WHERE service = $1
+	`, m.service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]applied)
+	for rows.Next() {
+		var version int64
+		var a applied
+		if err := rows.Scan(&version, &a.checksum, &a.at); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		done[version] = a
+	}
+	return done, rows.Err()
+}
+
+// latestApplied returns the latest n applied migrations, newest first. It
+// fails when one of them isn't known to this binary, as there is no SQL to
+// roll it back with.
+func (m *Migrator) latestApplied(ctx context.Context, conn *sql.Conn, n int) ([]Migration, error) {
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(done) == 0 {
+		return nil, ErrNothingApplied
+	}
+
+	versions := make([]int64, 0, len(done))
+	for version := range done {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if n < len(versions) {
+		versions = versions[:n]
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	latest := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d is unknown to this binary", version)
+		}
+		latest = append(latest, migration)
+	}
+	return latest, nil
+}
+
+// apply runs a migration's up or down SQL and records it, in a transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction, script := "up", migration.Up
+	if !up {
+		direction, script = "down", migration.Down
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %s %s failed: %w", migration, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (service, version, name, checksum, applied_at)
+			VALUES ($1, $2, $3, $4, NOW())
+		`, m.service, migration.Version, migration.Name, migration.Checksum())
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM schema_migrations WHERE service = $1 AND version = $2
+		`, m.service, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration, err)
+	}
+
+	return tx.Commit()
+}
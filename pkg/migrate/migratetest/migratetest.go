@@ -0,0 +1,257 @@
+// Package migratetest checks a service's migrations against a throwaway
+// Postgres schema: that they apply, roll back cleanly and apply again, and
+// that the SQL in the service's repositories still matches the schema they
+// create.
+package migratetest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/navo/pkg/migrate"
+)
+
+// EnvDatabaseURL names the variable holding the Postgres to test against.
+// Tests are skipped when it is unset.
+const EnvDatabaseURL = "TEST_DATABASE_URL"
+
+// Options describe the service under test
+type Options struct {
+	Service    string
+	Migrations fs.FS
+
+	// QueryDirs are the directories of the Go packages whose SQL is checked,
+	// relative to the test's package. Defaults to the test's own package.
+	QueryDirs []string
+
+	// External are tables the service uses but doesn't migrate, such as those
+	// from the Prisma schema. Queries using them are not checked.
+	External []string
+
+	// Setup runs before the migrations, e.g. to create stand-ins for
+	// external tables the migrations alter
+	Setup string
+}
+
+// driftCodes are the errors that mean a query doesn't match the schema
+var driftCodes = map[string]bool{
+	"42P01": true, // undefined_table
+	"42703": true, // undefined_column
+	"42704": true, // undefined_object
+	"42P10": true, // invalid_column_reference, e.g. ON CONFLICT without a matching constraint
+}
+
+// Run applies the service's migrations to a throwaway schema, rolls them all
+// back and applies them again, then prepares every query found in the
+// service's packages against the result.
+func Run(t *testing.T, opts Options) {
+	t.Helper()
+
+	db := openSchema(t, opts.Service)
+	ctx := context.Background()
+
+	if opts.Setup != "" {
+		if _, err := db.ExecContext(ctx, opts.Setup); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	before := tables(t, db)
+
+	migrator, err := migrate.New(db, opts.Service, opts.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations := migrator.Migrations()
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	rolledBack, err := migrator.Down(ctx, len(migrations))
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if len(rolledBack) != len(migrations) {
+		t.Fatalf("rolled back %d of %d migrations", len(rolledBack), len(migrations))
+	}
+	if after := tables(t, db); !equal(before, after) {
+		t.Errorf("down migrations leave tables behind: before %v, after %v", before, after)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+	if _, err := migrator.Redo(ctx); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+
+	dirs := opts.QueryDirs
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	for _, dir := range dirs {
+		for _, q := range queries(t, dir) {
+			checkQuery(t, db, q, opts.External)
+		}
+	}
+}
+
+// openSchema creates a schema for the test, dropped afterwards, and returns
+// a database whose connections use it
+func openSchema(t *testing.T, service string) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(EnvDatabaseURL)
+	if url == "" {
+		t.Skipf("%s is not set", EnvDatabaseURL)
+	}
+	cfg, err := pgx.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", EnvDatabaseURL, err)
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	schema := "migratetest_" + strings.ReplaceAll(service, "-", "_") + "_" + hex.EncodeToString(suffix)
+
+	admin := stdlib.OpenDB(*cfg.Copy())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		admin.Close()
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		admin.Close()
+	})
+
+	cfg.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*cfg)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// tables returns the tables of the test's schema, besides schema_migrations
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name <> 'schema_migrations'
+		ORDER BY table_name
+	`)
+	if err != nil {
+		t.Fatalf("failed to list tables: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// query is a SQL string literal in the service's code
+type query struct {
+	pos string
+	sql string
+}
+
+var (
+	statement = regexp.MustCompile(`(?is)^\s*(SELECT|INSERT|UPDATE|DELETE|WITH)\s`)
+	verb      = regexp.MustCompile(`%[sdvq]`)
+)
+
+// queries returns the SQL statements written as string literals in a
+// package. Those built with fmt verbs can't be prepared as written and are
+// left out.
+func queries(t *testing.T, dir string) []query {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", dir, err)
+	}
+
+	var found []query
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				lit, ok := n.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+				s, err := strconv.Unquote(lit.Value)
+				if err != nil || !statement.MatchString(s) || verb.MatchString(s) {
+					return true
+				}
+				pos := fset.Position(lit.Pos())
+				found = append(found, query{
+					pos: filepath.Join(dir, filepath.Base(pos.Filename)) + ":" + strconv.Itoa(pos.Line),
+					sql: s,
+				})
+				return true
+			})
+		}
+	}
+	return found
+}
+
+// checkQuery prepares a query, failing the test when it doesn't match the
+// schema. Queries using external tables are skipped, as are those failing
+// for other reasons, such as fragments of a query built up in code.
+func checkQuery(t *testing.T, db *sql.DB, q query, external []string) {
+	t.Helper()
+
+	for _, table := range external {
+		if regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(table) + `\b`).MatchString(q.sql) {
+			return
+		}
+	}
+
+	stmt, err := db.Prepare(q.sql)
+	if err == nil {
+		stmt.Close()
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && driftCodes[pgErr.Code] {
+		t.Errorf("%s: query doesn't match the migrated schema: %s", q.pos, pgErr.Message)
+		return
+	}
+	t.Logf("%s: not checked: %v", q.pos, err)
+}
@@ -1,17 +1,22 @@
 package observability
 
 import (
+	"bufio"
+	"net"
 	"net/http"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// HTTPMiddleware adds tracing to HTTP handlers
+// HTTPMiddleware adds tracing to HTTP handlers, continuing the trace of the
+// caller when the request carries one
 func HTTPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx, span := StartSpan(r.Context(), r.Method+" "+r.URL.Path,
+		ctx := Extract(r.Context(), r.Header)
+		ctx, span := StartSpan(ctx, r.Method+" "+r.URL.Path,
 			trace.WithSpanKind(trace.SpanKindServer),
 		)
 		defer span.End()
@@ -42,6 +47,9 @@ func HTTPMiddleware(next http.Handler) http.Handler {
 		if wrapped.statusCode >= 400 {
 			span.SetAttributes(attribute.Bool("error", true))
 		}
+		if wrapped.statusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
 	})
 }
 
@@ -54,3 +62,22 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap returns the wrapped writer, so http.ResponseController reaches it
+// to flush or set deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush passes flushes through, for streamed responses such as server-sent
+// events
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes hijacking through, for WebSocket upgrades
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// W3C trace context headers, also used as field names in event envelopes
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// propagator carries W3C trace context and baggage between services
+var propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// TraceID returns the ID of the trace ctx is in, or "" outside a trace
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Inject writes the trace context of ctx into HTTP headers
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx continuing the trace in HTTP headers, if any
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceFields returns the W3C traceparent and tracestate of ctx, for
+// messages that carry trace context in fields rather than headers. Both are
+// empty outside a trace.
+func TraceFields(ctx context.Context) (traceParent, traceState string) {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier[HeaderTraceParent], carrier[HeaderTraceState]
+}
+
+// ContextWithTraceFields returns ctx continuing the trace of a message's
+// traceparent and tracestate. Without a valid traceparent, ctx is returned
+// unchanged.
+func ContextWithTraceFields(ctx context.Context, traceParent, traceState string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{
+		HeaderTraceParent: traceParent,
+		HeaderTraceState:  traceState,
+	})
+}
+
+// StartConsumerSpan starts the span of handling a message received on a
+// Redis channel, continuing the trace of its traceparent and tracestate
+func StartConsumerSpan(ctx context.Context, name, channel, traceParent, traceState string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{
+		attribute.String("messaging.system", "redis"),
+		attribute.String("messaging.destination.name", channel),
+	}, attrs...)
+	return StartSpan(ContextWithTraceFields(ctx, traceParent, traceState), name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
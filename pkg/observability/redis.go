@@ -0,0 +1,71 @@
+package observability
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentRedis traces the commands and pipelines of a Redis client
+func InstrumentRedis(client *redis.Client) {
+	client.AddHook(redisHook{})
+}
+
+// redisHook starts a client span per command or pipeline
+type redisHook struct{}
+
+var _ redis.Hook = redisHook{}
+
+func (redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = StartRedisSpan(ctx, cmd.Name())
+	return ctx, nil
+}
+
+func (redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	EndRedisSpan(ctx, cmd.Err(), cmd.Err() == redis.Nil)
+	return nil
+}
+
+func (redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, span := StartRedisSpan(ctx, "pipeline")
+	span.SetAttributes(attribute.Int("db.redis.num_cmd", len(cmds)))
+	return ctx, nil
+}
+
+func (redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && cmdErr != redis.Nil {
+			err = cmdErr
+			break
+		}
+	}
+	EndRedisSpan(ctx, err, false)
+	return nil
+}
+
+// StartRedisSpan starts the span of a Redis command. Clients of either
+// go-redis version trace their commands with it.
+func StartRedisSpan(ctx context.Context, command string) (context.Context, trace.Span) {
+	return StartSpan(ctx, "redis "+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", command),
+		),
+	)
+}
+
+// EndRedisSpan ends the Redis span in ctx, recording err unless it is a
+// missing key
+func EndRedisSpan(ctx context.Context, err error, missingKey bool) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && !missingKey {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -8,7 +8,6 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
@@ -37,8 +36,12 @@ func DefaultConfig(serviceName string) Config {
 	}
 }
 
-// InitTracer initializes OpenTelemetry tracing
+// InitTracer initializes OpenTelemetry tracing. Trace context is propagated
+// even when tracing is disabled, so a service without an exporter doesn't
+// break the traces passing through it.
 func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
 	if !cfg.Enabled || cfg.OTLPEndpoint == "" {
 		// Return no-op shutdown if disabled
 		tracer = otel.Tracer(cfg.ServiceName)
@@ -82,10 +85,6 @@ func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, e
 
 	// Register as global provider
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
 
 	tracer = tp.Tracer(cfg.ServiceName)
 
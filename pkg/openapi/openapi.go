@@ -0,0 +1,406 @@
+// Package openapi generates a service's OpenAPI 3.1 document from its routes
+// and the Go types its handlers read and write, and validates requests
+// against it.
+//
+// A service describes its operations by route, then walks its router so
+// routes without a description are documented too:
+//
+//	api := openapi.New(openapi.Config{Title: "Core Service", Envelope: true}, handler.Operations)
+//	r.Use(api.Validate)
+//	... routes ...
+//	chi.Walk(r, api.Walk)
+//	r.Get("/openapi.json", api.ServeHTTP)
+//
+// Request bodies are decoded into the operation's Request type and checked
+// against its validate tags (see package validation). Invalid requests are
+// answered with a VALIDATION_ERROR detailing each invalid field.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/navo/pkg/response"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.1.0"
+
+// Config describes a service
+type Config struct {
+	Title       string
+	Description string
+	Version     string
+	// Envelope is set for services answering with pkg/response envelopes,
+	// so their responses are documented wrapped in one
+	Envelope bool
+}
+
+// Operation describes what an operation reads and writes
+type Operation struct {
+	Summary     string
+	Description string
+	// Tags group the operation. It defaults to the path's resource.
+	Tags []string
+	// Query are the query parameters the operation reads
+	Query []Param
+	// Request is a value of the type the request body is decoded into. It
+	// is validated against its validate tags.
+	Request any
+	// Omit are request body fields the handler sets itself, from the path
+	// or the caller's identity. They are neither documented nor validated.
+	Omit []string
+	// Response is a value of the type of the response body
+	Response any
+	// Status is the status of successful responses, 200 by default
+	Status int
+}
+
+// Param describes a query parameter
+type Param struct {
+	Name        string
+	Description string
+	// Type is the parameter's JSON type: string (the default), integer,
+	// number or boolean
+	Type     string
+	Required bool
+	Enum     []string
+}
+
+// Paged returns the query parameters of a paginated list: page and
+// per_page, then params
+func Paged(params ...Param) []Param {
+	return append([]Param{
+		{Name: "page", Type: "integer", Description: "Page number, from 1"},
+		{Name: "per_page", Type: "integer", Description: "Items per page"},
+	}, params...)
+}
+
+// Operations describe a service's operations by route, such as
+// "POST /api/v1/port-calls". Path parameters are written {name}.
+type Operations map[string]Operation
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components *Components           `json:"components,omitempty"`
+}
+
+// Info describes an API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a URL an API is served at
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag describes a group of operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds a path's operations by lowercase method
+type PathItem map[string]*OperationObject
+
+// OperationObject documents an operation
+type OperationObject struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*ParameterObject    `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// ParameterObject documents a path or query parameter
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody documents a request body
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response documents a response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType documents a body of one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas operations refer to
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme documents how requests are authenticated
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Success bool                `json:"success"`
+	Error   *response.ErrorBody `json:"error" validate:"required"`
+}
+
+// errorMessage is the body of error responses of services without
+// envelopes. Validation errors add their code and details.
+type errorMessage struct {
+	Error   string            `json:"error" validate:"required"`
+	Code    string            `json:"code,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Spec is a service's API specification
+type Spec struct {
+	config Config
+	routes []*route
+
+	once sync.Once
+	doc  []byte
+}
+
+// New creates the specification of a service's operations
+func New(config Config, ops Operations) *Spec {
+	s := &Spec{config: config}
+	for key, op := range ops {
+		method, pattern, _ := strings.Cut(key, " ")
+		s.add(method, pattern).op = &op
+	}
+	return s
+}
+
+// Walk adds a route to the specification. It has the signature of a
+// chi.WalkFunc, so a router's routes are added with chi.Walk(r, s.Walk).
+func (s *Spec) Walk(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+	if strings.HasSuffix(pattern, "*") {
+		return nil // Mounted handlers serve their own paths
+	}
+	s.add(method, pattern).routed = true
+	return nil
+}
+
+// Unmatched returns the walked routes without an operation and the
+// operations no walked route serves, as "METHOD /pattern". Services test
+// that both are empty, so a route missing from Operations isn't left
+// unvalidated.
+func (s *Spec) Unmatched() (undocumented, unrouted []string) {
+	for _, rt := range s.routes {
+		switch {
+		case rt.op == nil:
+			undocumented = append(undocumented, rt.method+" "+rt.pattern)
+		case !rt.routed:
+			unrouted = append(unrouted, rt.method+" "+rt.pattern)
+		}
+	}
+	return undocumented, unrouted
+}
+
+// add returns the route of a method and pattern, adding it if needed
+func (s *Spec) add(method, pattern string) *route {
+	method = strings.ToUpper(method)
+	pattern = normalize(pattern)
+	for _, rt := range s.routes {
+		if rt.method == method && rt.pattern == pattern {
+			return rt
+		}
+	}
+	rt := newRoute(method, pattern)
+	s.routes = append(s.routes, rt)
+	sort.SliceStable(s.routes, func(i, j int) bool {
+		return s.routes[i].before(s.routes[j])
+	})
+	return rt
+}
+
+// ServeHTTP serves the specification as JSON
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.once.Do(func() {
+		s.doc, _ = json.Marshal(s.Document())
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.doc)
+}
+
+// Document generates the service's OpenAPI document
+func (s *Spec) Document() *Document {
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       s.config.Title,
+			Description: s.config.Description,
+			Version:     s.config.Version,
+		},
+		Paths: make(map[string]PathItem),
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "1.0.0"
+	}
+
+	errorSchema := g.schema(reflect.TypeOf(errorResponse{}))
+	if !s.config.Envelope {
+		errorSchema = g.schema(reflect.TypeOf(errorMessage{}))
+	}
+
+	for _, rt := range s.routes {
+		if rt.pattern == "/openapi.json" {
+			continue
+		}
+		op := rt.op
+		if op == nil {
+			op = &Operation{}
+		}
+		obj := &OperationObject{
+			Tags:        op.Tags,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Responses:   make(map[string]*Response),
+		}
+		if len(obj.Tags) == 0 {
+			if tag := resource(rt.pattern); tag != "" {
+				obj.Tags = []string{tag}
+			}
+		}
+
+		for _, name := range rt.params {
+			obj.Parameters = append(obj.Parameters, &ParameterObject{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+		for _, param := range op.Query {
+			schema := &Schema{Type: param.paramType()}
+			for _, value := range param.Enum {
+				schema.Enum = append(schema.Enum, value)
+			}
+			obj.Parameters = append(obj.Parameters, &ParameterObject{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Required:    param.Required,
+				Schema:      schema,
+			})
+		}
+
+		if op.Request != nil {
+			schema := g.schema(reflect.TypeOf(op.Request))
+			if len(op.Omit) > 0 {
+				schema = g.omit(schema, op.Omit)
+			}
+			obj.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(schema),
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Response{Description: http.StatusText(status)}
+		if op.Response != nil && status != http.StatusNoContent {
+			schema := g.schema(reflect.TypeOf(op.Response))
+			if s.config.Envelope {
+				schema = g.envelope(schema)
+			}
+			success.Content = jsonContent(schema)
+		}
+		obj.Responses[strconv.Itoa(status)] = success
+		if op.Request != nil || len(op.Query) > 0 {
+			obj.Responses[strconv.Itoa(http.StatusBadRequest)] = &Response{
+				Description: "The request is invalid",
+				Content:     jsonContent(errorSchema),
+			}
+		}
+		obj.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     jsonContent(errorSchema),
+		}
+
+		item := doc.Paths[rt.pattern]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[rt.pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = obj
+	}
+
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc
+}
+
+func (p Param) paramType() string {
+	if p.Type == "" {
+		return "string"
+	}
+	return p.Type
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+var paramPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// normalize strips a route pattern's parameter regexps and trailing slash
+func normalize(pattern string) string {
+	pattern = paramPattern.ReplaceAllString(pattern, "{$1}")
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// resource returns the resource a path is for: its first segment after the
+// API version
+func resource(pattern string) string {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, segment := range segments {
+		if segment == "v1" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+	if len(segments) > 0 && !strings.HasPrefix(segments[0], "{") {
+		return segments[0]
+	}
+	return ""
+}
@@ -0,0 +1,284 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/response"
+	"github.com/navo/pkg/validation"
+)
+
+// Schema is a JSON Schema, as OpenAPI 3.1 uses them
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // A type name, or names
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *float64           `json:"minLength,omitempty"`
+	MaxLength            *float64           `json:"maxLength,omitempty"`
+	MinItems             *float64           `json:"minItems,omitempty"`
+	MaxItems             *float64           `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+}
+
+const refPrefix = "#/components/schemas/"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+	invalidName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// generator derives schemas from Go types, collecting named structs as
+// components
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schema returns the schema of a type, as a reference for named structs
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.name(t)
+			g.names[t] = name
+			g.schemas[name] = &Schema{} // Placeholder for recursive types
+			g.schemas[name] = g.object(t)
+		}
+		return &Schema{Ref: refPrefix + name}
+	}
+	return &Schema{} // Any value
+}
+
+// name picks a component name for a struct type, qualifying it with its
+// package when another type has its name
+func (g *generator) name(t reflect.Type) string {
+	name := invalidName.ReplaceAllString(t.Name(), "_")
+	name = strings.ToUpper(name[:1]) + name[1:] // Unexported types too
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	qualified := path.Base(t.PkgPath()) + "." + name
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[qualified]; !taken {
+			return qualified
+		}
+		qualified = path.Base(t.PkgPath()) + "." + name + strconv.Itoa(i)
+	}
+}
+
+// object returns the schema of a struct's JSON fields
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, s)
+			}
+			continue
+		}
+		name := validation.FieldName(f)
+		if name == "" {
+			continue
+		}
+
+		var field *Schema
+		if strings.Contains(f.Tag.Get("json"), ",string") {
+			field = &Schema{Type: "string"}
+		} else {
+			field = g.schema(f.Type)
+		}
+		if f.Type.Kind() == reflect.Pointer {
+			field = nullable(field)
+		}
+		for _, rule := range validation.ParseTag(f.Tag.Get("validate")) {
+			if rule.Name == "required" {
+				s.Required = append(s.Required, name)
+				continue
+			}
+			constrain(field, rule)
+		}
+		s.Properties[name] = field
+	}
+}
+
+// nullable allows null besides a schema's type
+func nullable(s *Schema) *Schema {
+	if name, ok := s.Type.(string); ok {
+		s.Type = []string{name, "null"}
+	}
+	return s
+}
+
+// constrain applies a validate rule to a schema
+func constrain(s *Schema, rule validation.Rule) {
+	if s.Ref != "" {
+		return
+	}
+	switch rule.Name {
+	case "email":
+		s.Format = "email"
+	case "id":
+		s.Pattern = validation.IDPattern
+	case "uuid":
+		s.Format = "uuid"
+	case "url":
+		s.Format = "uri"
+	case "oneof":
+		for _, option := range strings.Fields(rule.Param) {
+			s.Enum = append(s.Enum, option)
+		}
+	case "len", "min", "max", "gt", "gte", "lt", "lte":
+		limit, err := strconv.ParseFloat(rule.Param, 64)
+		if err != nil {
+			return
+		}
+		var lower, upper **float64
+		switch typeName(s) {
+		case "string":
+			lower, upper = &s.MinLength, &s.MaxLength
+		case "array":
+			lower, upper = &s.MinItems, &s.MaxItems
+		case "integer", "number":
+			lower, upper = &s.Minimum, &s.Maximum
+		default:
+			return
+		}
+		switch rule.Name {
+		case "len":
+			*lower, *upper = &limit, &limit
+		case "min", "gte":
+			*lower = &limit
+		case "max", "lte":
+			*upper = &limit
+		case "gt":
+			if lower == &s.Minimum {
+				s.ExclusiveMinimum = &limit
+			} else {
+				*lower = ptr(limit + 1)
+			}
+		case "lt":
+			if upper == &s.Maximum {
+				s.ExclusiveMaximum = &limit
+			} else {
+				*upper = ptr(limit - 1)
+			}
+		}
+	}
+}
+
+// typeName returns a schema's type, ignoring null
+func typeName(s *Schema) string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	}
+	return ""
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+// omit returns an object schema without some of its properties
+func (g *generator) omit(s *Schema, names []string) *Schema {
+	if s.Ref != "" {
+		s = g.schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+	}
+	if s == nil || s.Properties == nil {
+		return s
+	}
+	trimmed := *s
+	trimmed.Properties = make(map[string]*Schema, len(s.Properties))
+	for name, property := range s.Properties {
+		trimmed.Properties[name] = property
+	}
+	trimmed.Required = nil
+	for _, name := range names {
+		delete(trimmed.Properties, name)
+	}
+	for _, name := range s.Required {
+		if _, ok := trimmed.Properties[name]; ok {
+			trimmed.Required = append(trimmed.Required, name)
+		}
+	}
+	return &trimmed
+}
+
+// envelope wraps a schema in a pkg/response envelope
+func (g *generator) envelope(data *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"data":    data,
+			"meta":    g.schema(reflect.TypeOf(response.Meta{})),
+		},
+		Required: []string{"success"},
+	}
+}
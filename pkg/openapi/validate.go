@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/response"
+	"github.com/navo/pkg/validation"
+)
+
+// route is a method and path pattern, and the operation described for it
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	params   []string
+	op       *Operation
+	// routed is set once the route was walked on a router
+	routed bool
+}
+
+func newRoute(method, pattern string) *route {
+	rt := &route{method: method, pattern: pattern}
+	if trimmed := strings.Trim(pattern, "/"); trimmed != "" {
+		rt.segments = strings.Split(trimmed, "/")
+	}
+	for _, segment := range rt.segments {
+		if isParam(segment) {
+			rt.params = append(rt.params, segment[1:len(segment)-1])
+		}
+	}
+	return rt
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// before orders routes by path, literal segments before parameters so the
+// most specific route matches first
+func (rt *route) before(other *route) bool {
+	for i := 0; i < len(rt.segments) && i < len(other.segments); i++ {
+		a, b := rt.segments[i], other.segments[i]
+		if isParam(a) != isParam(b) {
+			return !isParam(a)
+		}
+		if a != b {
+			return a < b
+		}
+	}
+	if len(rt.segments) != len(other.segments) {
+		return len(rt.segments) < len(other.segments)
+	}
+	return rt.method < other.method
+}
+
+// matches reports whether the route serves a request's method and path
+func (rt *route) matches(method string, segments []string) bool {
+	if rt.method != method || len(rt.segments) != len(segments) {
+		return false
+	}
+	for i, segment := range rt.segments {
+		if segments[i] == "" || !isParam(segment) && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns the route a request is for, or nil
+func (s *Spec) match(r *http.Request) *route {
+	var segments []string
+	if trimmed := strings.Trim(r.URL.Path, "/"); trimmed != "" {
+		segments = strings.Split(trimmed, "/")
+	}
+	for _, rt := range s.routes {
+		if rt.matches(r.Method, segments) {
+			return rt
+		}
+	}
+	return nil
+}
+
+// Validate is middleware checking requests' query parameters and bodies
+// against their operation. Invalid requests are answered with a validation
+// error detailing each invalid field.
+func (s *Spec) Validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt := s.match(r)
+		if rt == nil || rt.op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		details := make(map[string]string)
+		query := r.URL.Query()
+		for _, param := range rt.op.Query {
+			if message := param.check(query); message != "" {
+				details[param.Name] = message
+			}
+		}
+
+		if rt.op.Request != nil && isJSON(r) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, validation.MaxBodySize+1))
+			r.Body.Close()
+			if err != nil {
+				s.fail(w, errors.NewBadRequest("Failed to read request body"))
+				return
+			}
+			if len(body) > validation.MaxBodySize {
+				s.fail(w, errors.NewBadRequest("Request body is too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			input := reflect.New(reflect.TypeOf(rt.op.Request)).Interface()
+			if appErr := validation.DecodeJSON(bytes.NewReader(body), input); appErr != nil {
+				if appErr.Details == nil {
+					s.fail(w, appErr)
+					return
+				}
+				for field, message := range appErr.Details {
+					details[field] = message
+				}
+			} else {
+				for field, message := range validation.Struct(input) {
+					details[field] = message
+				}
+			}
+			for _, field := range rt.op.Omit {
+				delete(details, field)
+			}
+		}
+
+		if len(details) > 0 {
+			s.fail(w, errors.NewValidationFields(details))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fail answers a request with an error, in the service's error format
+func (s *Spec) fail(w http.ResponseWriter, err *errors.AppError) {
+	if s.config.Envelope {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(errorMessage{
+		Error:   err.Message,
+		Code:    err.Code,
+		Details: err.Details,
+	})
+}
+
+// isJSON reports whether a request's body is JSON. Operations taking other
+// content, such as file uploads, validate it themselves.
+func isJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// check returns what is wrong with a query parameter
+func (p Param) check(query map[string][]string) string {
+	values, ok := query[p.Name]
+	if !ok || len(values) == 0 || values[0] == "" {
+		if p.Required {
+			return "is required"
+		}
+		return ""
+	}
+	value := values[0]
+
+	switch p.paramType() {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an integer"
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		for _, option := range p.Enum {
+			if value == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(p.Enum, ", ")
+	}
+	return ""
+}
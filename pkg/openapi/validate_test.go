@@ -0,0 +1,282 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type portCallInput struct {
+	VesselID    string `json:"vessel_id" validate:"required,id"`
+	WorkspaceID string `json:"workspace_id" validate:"required,id"`
+	Berth       string `json:"berth" validate:"omitempty,max=5"`
+	Tons        int    `json:"tons" validate:"omitempty,gt=0"`
+}
+
+func testSpec(envelope bool) *Spec {
+	spec := New(Config{Title: "Test Service", Envelope: envelope}, Operations{
+		"GET /api/v1/port-calls": {
+			Query: Paged(Param{Name: "status", Enum: []string{"draft", "planned"}}),
+		},
+		"POST /api/v1/port-calls": {
+			Request: portCallInput{},
+		},
+		"PUT /api/v1/workspaces/{id}/port-calls": {
+			Request: portCallInput{},
+			Omit:    []string{"workspace_id"},
+		},
+		"GET /api/v1/port-calls/search": {
+			Query: []Param{{Name: "q", Required: true}},
+		},
+	})
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	spec.Walk(http.MethodGet, "/api/v1/port-calls/{id:[a-z0-9]+}/", noop)
+	spec.Walk(http.MethodGet, "/files/*", noop)
+	return spec
+}
+
+func TestSpec_Match(t *testing.T) {
+	spec := testSpec(true)
+
+	tests := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{http.MethodGet, "/api/v1/port-calls", "/api/v1/port-calls"},
+		{http.MethodGet, "/api/v1/port-calls/", "/api/v1/port-calls"},
+		{http.MethodPost, "/api/v1/port-calls", "/api/v1/port-calls"},
+		{http.MethodGet, "/api/v1/port-calls/search", "/api/v1/port-calls/search"},
+		{http.MethodGet, "/api/v1/port-calls/pc1", "/api/v1/port-calls/{id}"},
+		{http.MethodPut, "/api/v1/workspaces/ws_demo/port-calls", "/api/v1/workspaces/{id}/port-calls"},
+		{http.MethodDelete, "/api/v1/port-calls/pc1", ""},
+		{http.MethodGet, "/api/v1/port-calls/pc1/services", ""},
+		{http.MethodPut, "/api/v1/workspaces//port-calls", ""},
+		{http.MethodGet, "/files/report.pdf", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rt := spec.match(req)
+			if tt.pattern == "" {
+				assert.Nil(t, rt)
+				return
+			}
+			require.NotNil(t, rt)
+			assert.Equal(t, tt.pattern, rt.pattern)
+		})
+	}
+}
+
+func TestSpec_Unmatched(t *testing.T) {
+	spec := testSpec(true)
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	spec.Walk(http.MethodGet, "/api/v1/port-calls/", noop)
+	spec.Walk(http.MethodPost, "/api/v1/port-calls", noop)
+	spec.Walk(http.MethodGet, "/api/v1/port-calls/search", noop)
+
+	undocumented, unrouted := spec.Unmatched()
+	assert.Equal(t, []string{"GET /api/v1/port-calls/{id}"}, undocumented)
+	assert.Equal(t, []string{"PUT /api/v1/workspaces/{id}/port-calls"}, unrouted)
+}
+
+func TestParam_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   Param
+		query   string
+		message string
+	}{
+		{"optional missing", Param{Name: "q"}, "", ""},
+		{"optional empty", Param{Name: "q"}, "q=", ""},
+		{"required missing", Param{Name: "q", Required: true}, "", "is required"},
+		{"required empty", Param{Name: "q", Required: true}, "q=", "is required"},
+		{"string", Param{Name: "q"}, "q=ships", ""},
+		{"integer", Param{Name: "n", Type: "integer"}, "n=12", ""},
+		{"not an integer", Param{Name: "n", Type: "integer"}, "n=1.5", "must be an integer"},
+		{"number", Param{Name: "lat", Type: "number"}, "lat=1.29", ""},
+		{"not a number", Param{Name: "lat", Type: "number"}, "lat=north", "must be a number"},
+		{"boolean", Param{Name: "all", Type: "boolean"}, "all=true", ""},
+		{"not a boolean", Param{Name: "all", Type: "boolean"}, "all=yes", "must be true or false"},
+		{"enum", Param{Name: "s", Enum: []string{"a", "b"}}, "s=b", ""},
+		{"not in enum", Param{Name: "s", Enum: []string{"a", "b"}}, "s=c", "must be one of: a, b"},
+		{"first value", Param{Name: "n", Type: "integer"}, "n=1&n=x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			assert.Equal(t, tt.message, tt.param.check(req.URL.Query()))
+		})
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		envelope    bool
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		details     map[string]string
+	}{
+		{
+			name:   "valid query",
+			method: http.MethodGet,
+			path:   "/api/v1/port-calls?page=2&status=draft",
+			status: http.StatusOK,
+		},
+		{
+			name:   "invalid query",
+			method: http.MethodGet,
+			path:   "/api/v1/port-calls?page=two&status=open",
+			status: http.StatusBadRequest,
+			details: map[string]string{
+				"page":   "must be an integer",
+				"status": "must be one of: draft, planned",
+			},
+		},
+		{
+			name:    "missing required query",
+			method:  http.MethodGet,
+			path:    "/api/v1/port-calls/search",
+			status:  http.StatusBadRequest,
+			details: map[string]string{"q": "is required"},
+		},
+		{
+			name:   "valid body",
+			method: http.MethodPost,
+			path:   "/api/v1/port-calls",
+			body:   `{"vessel_id": "9m4e2mr0ui3e8a215n4g", "workspace_id": "ws_demo"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost,
+			path:   "/api/v1/port-calls",
+			body:   `{"vessel_id": "", "workspace_id": "ws demo", "berth": "North 7"}`,
+			status: http.StatusBadRequest,
+			details: map[string]string{
+				"vessel_id":    "is required",
+				"workspace_id": "must be a valid ID",
+				"berth":        "must be at most 5 characters",
+			},
+		},
+		{
+			name:    "wrong type",
+			method:  http.MethodPost,
+			path:    "/api/v1/port-calls",
+			body:    `{"vessel_id": "v1", "workspace_id": "ws_demo", "tons": "many"}`,
+			status:  http.StatusBadRequest,
+			details: map[string]string{"tons": "must be an integer"},
+		},
+		{
+			name:   "malformed body",
+			method: http.MethodPost,
+			path:   "/api/v1/port-calls",
+			body:   `{"vessel_id":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "omitted fields are set by the handler",
+			method: http.MethodPut,
+			path:   "/api/v1/workspaces/ws_demo/port-calls",
+			body:   `{"vessel_id": "v1"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:        "other content is left to the handler",
+			method:      http.MethodPost,
+			path:        "/api/v1/port-calls",
+			contentType: "application/xml",
+			body:        `<portCall/>`,
+			status:      http.StatusOK,
+		},
+		{
+			name:   "undescribed routes are left to the handler",
+			method: http.MethodPost,
+			path:   "/api/v1/vessels",
+			body:   `{}`,
+			status: http.StatusOK,
+		},
+		{
+			name:     "enveloped error",
+			envelope: true,
+			method:   http.MethodPost,
+			path:     "/api/v1/port-calls",
+			body:     `{"workspace_id": "ws_demo"}`,
+			status:   http.StatusBadRequest,
+			details:  map[string]string{"vessel_id": "is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			handler := testSpec(tt.envelope).Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received = string(body)
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status == http.StatusOK {
+				// The handler reads the body the middleware read
+				assert.Equal(t, tt.body, received)
+				return
+			}
+
+			var details map[string]string
+			if tt.envelope {
+				var body struct {
+					Success bool `json:"success"`
+					Error   struct {
+						Code    string            `json:"code"`
+						Details map[string]string `json:"details"`
+					} `json:"error"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.False(t, body.Success)
+				assert.Equal(t, "VALIDATION_ERROR", body.Error.Code)
+				details = body.Error.Details
+			} else {
+				var body errorMessage
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.NotEmpty(t, body.Error)
+				details = body.Details
+			}
+			assert.Equal(t, tt.details, details)
+		})
+	}
+}
+
+func TestSpec_ValidateBodyTooLarge(t *testing.T) {
+	handler := testSpec(false).Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("oversized body reached the handler")
+	}))
+
+	body := `{"vessel_id": "` + strings.Repeat("a", 1<<20) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/port-calls", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Request body is too large")
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EventType represents different types of real-time events
@@ -40,6 +43,9 @@ const (
 	// Notification events
 	EventNotificationNew  EventType = "notification:new"
 	EventNotificationRead EventType = "notification:read"
+
+	// Weather events
+	EventWeatherAlert EventType = "weather:alert"
 )
 
 // Redis channels
@@ -63,6 +69,23 @@ type Event struct {
 	UserIDs        []string        `json:"user_ids,omitempty"`
 	EntityID       string          `json:"entity_id,omitempty"`
 	EntityType     string          `json:"entity_type,omitempty"`
+	TraceParent    string          `json:"traceparent,omitempty"` // W3C trace context of the publisher
+	TraceState     string          `json:"tracestate,omitempty"`
+}
+
+// TraceContext returns ctx continuing the trace the event was published in
+func (e *Event) TraceContext(ctx context.Context) context.Context {
+	return observability.ContextWithTraceFields(ctx, e.TraceParent, e.TraceState)
+}
+
+// StartConsumerSpan starts the span of handling an event received on
+// channel, continuing the trace it was published in
+func (e *Event) StartConsumerSpan(ctx context.Context, consumer, channel string) (context.Context, trace.Span) {
+	return observability.StartConsumerSpan(ctx, consumer+" "+string(e.Type), channel, e.TraceParent, e.TraceState,
+		attribute.String("messaging.message.id", e.ID),
+		attribute.String("navo.event.type", string(e.Type)),
+		attribute.String("navo.entity.id", e.EntityID),
+	)
 }
 
 // Publisher publishes events to the realtime service via Redis
@@ -96,14 +119,32 @@ func (p *Publisher) Publish(ctx context.Context, eventType EventType, data inter
 		opt(event)
 	}
 
+	// Determine channel
+	channel := getChannelForEvent(eventType)
+
+	// Consumers continue the trace from the event
+	ctx, span := observability.StartSpan(ctx, "publish "+string(eventType),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", channel),
+			attribute.String("navo.event.type", string(eventType)),
+		),
+	)
+	defer span.End()
+	event.TraceParent, event.TraceState = observability.TraceFields(ctx)
+
 	// Marshal full event
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
-	// Determine channel
-	channel := getChannelForEvent(eventType)
+	// Organization events are kept for replay to reconnecting clients
+	if event.OrganizationID != "" {
+		_, err = AppendAndPublish(ctx, p.redis, event.OrganizationID, channel, eventJSON, DefaultStreamMaxLen)
+		return err
+	}
 
 	return p.redis.Publish(ctx, channel, eventJSON).Err()
 }
@@ -222,6 +263,15 @@ func (p *Publisher) PublishNotification(ctx context.Context, title, message, pri
 	)
 }
 
+// PublishWeatherAlert publishes a weather alert raised for a vessel or port call
+func (p *Publisher) PublishWeatherAlert(ctx context.Context, alert interface{}, entityType, entityID, orgID, workspaceID string) error {
+	return p.Publish(ctx, EventWeatherAlert, alert,
+		WithOrganization(orgID),
+		WithWorkspace(workspaceID),
+		WithEntity(entityType, entityID),
+	)
+}
+
 func getChannelForEvent(eventType EventType) string {
 	switch eventType {
 	case EventPortCallCreated, EventPortCallUpdated,
@@ -0,0 +1,24 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/navo/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTraceContinues(t *testing.T) {
+	payload := []byte(`{"stream_id":"1700000000000-0","event":{"id":"evt-1","type":"port_call:updated",` +
+		`"timestamp":"2026-03-01T12:00:00Z","data":{},"organization_id":"org-1",` +
+		`"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}`)
+
+	event, err := DecodeEvent(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000000-0", event.ID)
+
+	ctx, span := event.StartConsumerSpan(context.Background(), "webhooks", ChannelPortCalls)
+	defer span.End()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", observability.TraceID(ctx))
+}
@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Event streams
+const (
+	// StreamKeyPrefix prefixes the Redis Stream holding an organization's
+	// replayable events
+	StreamKeyPrefix = "navo:stream:org:"
+
+	// DefaultStreamMaxLen is the approximate number of events kept per
+	// organization for replay
+	DefaultStreamMaxLen int64 = 10000
+)
+
+// ErrInvalidStreamID is returned for IDs that are not Redis Stream entry IDs
+var ErrInvalidStreamID = errors.New("invalid stream ID")
+
+// StreamKey returns the stream holding the replayable events of an organization
+func StreamKey(orgID string) string {
+	return StreamKeyPrefix + orgID
+}
+
+// StreamMessage is the pub/sub payload of an event that was appended to an
+// organization stream. StreamID is the entry ID the stream assigned, which
+// becomes the event's ID.
+type StreamMessage struct {
+	StreamID string          `json:"stream_id"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// DecodeEvent parses an event received on a pub/sub channel, unwrapping it
+// from its StreamMessage when it was appended to an organization stream
+func DecodeEvent(payload []byte) (*Event, error) {
+	var envelope StreamMessage
+	if err := json.Unmarshal(payload, &envelope); err == nil && envelope.StreamID != "" {
+		payload = envelope.Event
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	if envelope.StreamID != "" {
+		event.ID = envelope.StreamID
+	}
+	return &event, nil
+}
+
+// appendScript appends the event to the stream and publishes it in one step,
+// so live subscribers see events in the same order as the stream
+var appendScript = redis.NewScript(`
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', 'event', ARGV[3])
+redis.call('PUBLISH', ARGV[2], '{"stream_id":"' .. id .. '","event":' .. ARGV[3] .. '}')
+return id
+`)
+
+// AppendAndPublish appends an organization event to the organization's
+// bounded stream and publishes it on channel as a StreamMessage. It returns
+// the stream entry ID assigned to the event.
+func AppendAndPublish(ctx context.Context, client redis.Scripter, orgID, channel string, event []byte, maxLen int64) (string, error) {
+	return appendScript.Run(ctx, client, []string{StreamKey(orgID)}, maxLen, channel, event).Text()
+}
+
+// ParseStreamID splits a stream entry ID ("<milliseconds>-<sequence>") into
+// its parts
+func ParseStreamID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, ErrInvalidStreamID
+	}
+	if ms, err = strconv.ParseUint(msPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidStreamID
+	}
+	if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidStreamID
+	}
+	return ms, seq, nil
+}
+
+// CompareStreamIDs returns -1, 0 or 1 when a is before, equal to or after b
+func CompareStreamIDs(a, b string) (int, error) {
+	aMs, aSeq, err := ParseStreamID(a)
+	if err != nil {
+		return 0, err
+	}
+	bMs, bSeq, err := ParseStreamID(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case aMs < bMs, aMs == bMs && aSeq < bSeq:
+		return -1, nil
+	case aMs == bMs && aSeq == bSeq:
+		return 0, nil
+	default:
+		return 1, nil
+	}
+}
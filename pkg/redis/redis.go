@@ -43,6 +43,7 @@ func Connect(ctx context.Context, cfg *Config) (*redis.Client, error) {
 		PoolSize:     100,
 		MinIdleConns: 10,
 	})
+	client.AddHook(tracingHook{})
 
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/navo/pkg/observability"
+	"github.com/redis/go-redis/v9"
+)
+
+// tracingHook traces the commands and pipelines of a client
+type tracingHook struct{}
+
+var _ redis.Hook = tracingHook{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, _ = observability.StartRedisSpan(ctx, cmd.Name())
+		err := next(ctx, cmd)
+		observability.EndRedisSpan(ctx, err, err == redis.Nil)
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, _ = observability.StartRedisSpan(ctx, "pipeline")
+		err := next(ctx, cmds)
+		observability.EndRedisSpan(ctx, err, err == redis.Nil)
+		return err
+	}
+}
@@ -67,6 +67,7 @@ func Error(w http.ResponseWriter, err error) {
 		errorBody = &ErrorBody{
 			Code:    appErr.Code,
 			Message: appErr.Message,
+			Details: appErr.Details,
 		}
 	} else {
 		statusCode = http.StatusInternalServerError
@@ -126,3 +127,21 @@ func NotFound(w http.ResponseWriter, resource string) {
 func InternalError(w http.ResponseWriter, err error) {
 	Error(w, errors.NewInternal(err))
 }
+
+// Conflict sends a 409 Conflict response carrying the resource's current
+// state, for clients whose update was based on a stale version
+func Conflict(w http.ResponseWriter, message string, current interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	response := Response{
+		Success: false,
+		Data:    current,
+		Error: &ErrorBody{
+			Code:    errors.CodeConflict,
+			Message: message,
+		},
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
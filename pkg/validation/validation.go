@@ -0,0 +1,375 @@
+// Package validation enforces the validate struct tags on request inputs.
+//
+// Tags list comma-separated rules, as in
+//
+//	VesselID string `json:"vessel_id" validate:"required,id"`
+//
+// Supported rules are required, omitempty, id, email, uuid, url, len, min,
+// max, gt, gte, lt, lte and oneof. id accepts the record IDs in use, such as
+// UUIDs, xids, cuids and slugs like "ws_demo". len, min and max bound the length of strings
+// (in characters), slices and maps, and the value of numbers; gt, gte, lt
+// and lte bound either the same way. Nested structs, and the structs in
+// slices and maps, are validated too.
+//
+// Invalid fields are reported by their JSON path, such as
+// "contacts[0].email".
+package validation
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/navo/pkg/errors"
+)
+
+// MaxBodySize is the largest request body Decode reads
+const MaxBodySize = 1 << 20
+
+// IDPattern is the pattern of values the id rule accepts
+const IDPattern = `^[A-Za-z0-9_-]{1,64}$`
+
+var idPattern = regexp.MustCompile(IDPattern)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Rule is one rule of a validate tag, such as "min=12"
+type Rule struct {
+	Name  string
+	Param string
+}
+
+// ParseTag parses a validate tag into its rules
+func ParseTag(tag string) []Rule {
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, Rule{Name: name, Param: param})
+	}
+	return rules
+}
+
+// FieldName returns the name a struct field has in JSON, or "" when it is
+// not encoded
+func FieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// Struct validates v, a struct or pointer to one, returning what is wrong
+// with each invalid field. It returns nil when v is valid.
+func Struct(v any) map[string]string {
+	details := make(map[string]string)
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		validateStruct(value, "", details)
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// Validate validates v, returning a validation error detailing each invalid
+// field
+func Validate(v any) *errors.AppError {
+	if details := Struct(v); details != nil {
+		return errors.NewValidationFields(details)
+	}
+	return nil
+}
+
+// Decode decodes a request's JSON body into v and validates it
+func Decode(r *http.Request, v any) *errors.AppError {
+	if err := DecodeJSON(io.LimitReader(r.Body, MaxBodySize), v); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// DecodeJSON decodes JSON into v, reporting values of the wrong type as
+// invalid fields
+func DecodeJSON(body io.Reader, v any) *errors.AppError {
+	err := json.NewDecoder(body).Decode(v)
+	if err == nil {
+		return nil
+	}
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) && typeErr.Field != "" {
+		return errors.NewValidationFields(map[string]string{
+			fieldPath(typeErr.Field): "must be " + describeType(typeErr.Type),
+		})
+	}
+	if stderrors.Is(err, io.EOF) {
+		return errors.NewBadRequest("Request body is required")
+	}
+	return errors.NewBadRequest("Invalid request body")
+}
+
+func validateStruct(v reflect.Value, prefix string, details map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				validateStruct(embedded, prefix, details)
+			}
+			continue
+		}
+		name := FieldName(f)
+		if name == "" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		validateField(v.Field(i), ParseTag(f.Tag.Get("validate")), path, details)
+	}
+}
+
+func validateField(v reflect.Value, rules []Rule, path string, details map[string]string) {
+	for _, rule := range rules {
+		switch rule.Name {
+		case "required":
+			if isEmpty(v) {
+				details[path] = "is required"
+				return
+			}
+		case "omitempty":
+			if isEmpty(v) {
+				return
+			}
+		}
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	for _, rule := range rules {
+		if rule.Name == "required" || rule.Name == "omitempty" {
+			continue
+		}
+		if message := check(v, rule); message != "" {
+			details[path] = message
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() != timeType {
+			validateStruct(v, path, details)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateField(v.Index(i), nil, fmt.Sprintf("%s[%d]", path, i), details)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			validateField(v.MapIndex(key), nil, fmt.Sprintf("%s.%v", path, key), details)
+		}
+	}
+}
+
+// check applies a rule to a value, returning what is wrong with it
+func check(v reflect.Value, rule Rule) string {
+	switch rule.Name {
+	case "email":
+		if s, ok := str(v); ok {
+			if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+				return "must be a valid email address"
+			}
+		}
+	case "id":
+		if s, ok := str(v); ok && !idPattern.MatchString(s) {
+			return "must be a valid ID"
+		}
+	case "uuid":
+		if s, ok := str(v); ok && !uuidPattern.MatchString(s) {
+			return "must be a valid UUID"
+		}
+	case "url":
+		if s, ok := str(v); ok {
+			if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+				return "must be a valid URL"
+			}
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		options := strings.Fields(rule.Param)
+		for _, option := range options {
+			if s == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "len", "min", "max", "gt", "gte", "lt", "lte":
+		return bound(v, rule)
+	}
+	return ""
+}
+
+// bound checks a length or value rule
+func bound(v reflect.Value, rule Rule) string {
+	limit, err := strconv.ParseFloat(rule.Param, 64)
+	if err != nil {
+		return ""
+	}
+
+	var n float64
+	var unit string
+	switch v.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return ""
+	}
+
+	param := rule.Param
+	if unit != "" {
+		if limit == 1 {
+			unit = strings.TrimSuffix(unit, "s")
+		}
+		param += unit
+	}
+	switch rule.Name {
+	case "len":
+		if n != limit {
+			if unit != "" {
+				return "must be exactly " + param
+			}
+			return "must equal " + param
+		}
+	case "min", "gte":
+		if n < limit {
+			return "must be at least " + param
+		}
+	case "max", "lte":
+		if n > limit {
+			return "must be at most " + param
+		}
+	case "gt":
+		if n <= limit {
+			return "must be more than " + param
+		}
+	case "lt":
+		if n >= limit {
+			return "must be less than " + param
+		}
+	}
+	return ""
+}
+
+// isEmpty reports whether a value is missing: nil, zero, or an empty
+// string, slice or map
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func str(v reflect.Value) (string, bool) {
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// fieldPath writes a decoding error's field, such as "contacts.0.email", the
+// way invalid fields are reported: "contacts[0].email"
+func fieldPath(field string) string {
+	var path strings.Builder
+	for i, segment := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(segment); err == nil && i > 0 {
+			path.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			path.WriteByte('.')
+		}
+		path.WriteString(segment)
+	}
+	return path.String()
+}
+
+// describeType names a Go type the way a JSON client knows it
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "an RFC 3339 timestamp"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a valid value"
+}
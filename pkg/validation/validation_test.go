@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contact struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+type address struct {
+	Country string `json:"country" validate:"len=2"`
+}
+
+type base struct {
+	WorkspaceID string `json:"workspace_id" validate:"required,id"`
+}
+
+type order struct {
+	base
+	VesselID  string             `json:"vessel_id" validate:"required,id"`
+	RequestID string             `json:"request_id" validate:"omitempty,uuid"`
+	Callback  string             `json:"callback" validate:"omitempty,url"`
+	Title     string             `json:"title" validate:"min=3,max=10"`
+	Status    string             `json:"status" validate:"omitempty,oneof=draft open"`
+	Quantity  int                `json:"quantity" validate:"gt=0"`
+	Discount  *float64           `json:"discount" validate:"omitempty,gte=0,lt=100"`
+	Tags      []string           `json:"tags" validate:"max=2"`
+	Contacts  []contact          `json:"contacts"`
+	Address   *address           `json:"address"`
+	Labels    map[string]contact `json:"labels"`
+	Due       time.Time          `json:"due"`
+	Ignored   string             `json:"-" validate:"required"`
+	internal  string             `validate:"required"`
+}
+
+func validOrder() order {
+	return order{
+		base:     base{WorkspaceID: "ws_demo"},
+		VesselID: "9m4e2mr0ui3e8a215n4g",
+		Title:    "Bunkers",
+		Quantity: 1,
+	}
+}
+
+func TestStruct(t *testing.T) {
+	discount := 150.0
+
+	tests := []struct {
+		name    string
+		modify  func(o *order)
+		details map[string]string
+	}{
+		{
+			name:   "valid",
+			modify: func(o *order) {},
+		},
+		{
+			name: "valid optional fields",
+			modify: func(o *order) {
+				o.RequestID = "3f0c5a4e-8d2b-4c1f-9a6e-1b7d2e9f0a3c"
+				o.Callback = "https://example.com/hook"
+				o.Status = "open"
+				o.Tags = []string{"a", "b"}
+				o.Contacts = []contact{{Name: "Ops", Email: "ops@example.com"}}
+				o.Address = &address{Country: "SG"}
+			},
+		},
+		{
+			name: "IDs in use",
+			modify: func(o *order) {
+				o.WorkspaceID = "clh3am8ct0000qz8r9x4h2k1d"
+				o.VesselID = "3f0c5a4e-8d2b-4c1f-9a6e-1b7d2e9f0a3c"
+			},
+		},
+		{
+			name: "required",
+			modify: func(o *order) {
+				o.WorkspaceID = ""
+				o.VesselID = ""
+			},
+			details: map[string]string{
+				"workspace_id": "is required",
+				"vessel_id":    "is required",
+			},
+		},
+		{
+			name: "formats",
+			modify: func(o *order) {
+				o.VesselID = "vessel/1"
+				o.RequestID = "not-a-uuid"
+				o.Callback = "example.com"
+				o.Status = "closed"
+			},
+			details: map[string]string{
+				"vessel_id":  "must be a valid ID",
+				"request_id": "must be a valid UUID",
+				"callback":   "must be a valid URL",
+				"status":     "must be one of: draft, open",
+			},
+		},
+		{
+			name: "IDs too long",
+			modify: func(o *order) {
+				o.VesselID = strings.Repeat("a", 65)
+			},
+			details: map[string]string{"vessel_id": "must be a valid ID"},
+		},
+		{
+			name: "bounds",
+			modify: func(o *order) {
+				o.Title = "Ab"
+				o.Quantity = 0
+				o.Discount = &discount
+				o.Tags = []string{"a", "b", "c"}
+			},
+			details: map[string]string{
+				"title":    "must be at least 3 characters",
+				"quantity": "must be more than 0",
+				"discount": "must be less than 100",
+				"tags":     "must be at most 2 items",
+			},
+		},
+		{
+			name: "string lengths count characters",
+			modify: func(o *order) {
+				o.Title = "Überführung"
+			},
+			details: map[string]string{"title": "must be at most 10 characters"},
+		},
+		{
+			name: "nested",
+			modify: func(o *order) {
+				o.Contacts = []contact{{Name: "Ops"}, {Email: "ops"}}
+				o.Address = &address{Country: "SGP"}
+				o.Labels = map[string]contact{"agent": {}}
+			},
+			details: map[string]string{
+				"contacts[1].name":  "is required",
+				"contacts[1].email": "must be a valid email address",
+				"address.country":   "must be exactly 2 characters",
+				"labels.agent.name": "is required",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := validOrder()
+			tt.modify(&o)
+			assert.Equal(t, tt.details, Struct(&o))
+		})
+	}
+}
+
+func TestStruct_NotAStruct(t *testing.T) {
+	var o *order
+	assert.Nil(t, Struct(o))
+	assert.Nil(t, Struct("order"))
+}
+
+func TestValidate(t *testing.T) {
+	o := validOrder()
+	assert.Nil(t, Validate(o))
+
+	o.VesselID = ""
+	err := Validate(o)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "VALIDATION_ERROR", err.Code)
+		assert.Equal(t, map[string]string{"vessel_id": "is required"}, err.Details)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+		details map[string]string
+	}{
+		{name: "valid", body: `{"title": "Bunkers"}`},
+		{name: "empty", body: ``, message: "Request body is required"},
+		{name: "malformed", body: `{"title":`, message: "Invalid request body"},
+		{
+			name:    "wrong type",
+			body:    `{"quantity": "two"}`,
+			message: "Request validation failed",
+			details: map[string]string{"quantity": "must be an integer"},
+		},
+		{
+			name:    "wrong nested type",
+			body:    `{"address": {"country": 1}}`,
+			message: "Request validation failed",
+			details: map[string]string{"address.country": "must be a string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o order
+			err := DecodeJSON(strings.NewReader(tt.body), &o)
+			if tt.message == "" {
+				assert.Nil(t, err)
+				return
+			}
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.message, err.Message)
+				assert.Equal(t, tt.details, err.Details)
+			}
+		})
+	}
+}
+
+func TestFieldPath(t *testing.T) {
+	tests := map[string]string{
+		"quantity":          "quantity",
+		"address.country":   "address.country",
+		"contacts.0.email":  "contacts[0].email",
+		"lines.2":           "lines[2]",
+		"matrix.1.0":        "matrix[1][0]",
+		"labels.agent.name": "labels.agent.name",
+	}
+	for field, want := range tests {
+		assert.Equal(t, want, fieldPath(field), field)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	assert.Equal(t, []Rule{
+		{Name: "required"},
+		{Name: "min", Param: "3"},
+		{Name: "oneof", Param: "a b"},
+	}, ParseTag("required, min=3,,oneof=a b"))
+	assert.Nil(t, ParseTag(""))
+}
@@ -15,10 +15,13 @@ import (
 	"github.com/go-chi/cors"
 	_ "github.com/lib/pq"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/migrate"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/services/analytics/internal/config"
 	"github.com/navo/services/analytics/internal/handler"
 	"github.com/navo/services/analytics/internal/repository"
 	"github.com/navo/services/analytics/internal/service"
+	"github.com/navo/services/analytics/migrations"
 	"go.uber.org/zap"
 )
 
@@ -48,14 +51,48 @@ func main() {
 	}
 	logger.Info("Connected to database")
 
-	// Initialize repository
-	analyticsRepo := repository.NewAnalyticsRepository(db)
+	// Apply schema migrations; replicas starting together wait on the
+	// migration lock. "analytics migrate ..." manages them instead.
+	migrator, err := migrate.New(db, "analytics", migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrate.Command(context.Background(), migrator, os.Args[2:], os.Stdout)
+		db.Close()
+		os.Exit(code)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to apply migrations", zap.Error(err))
+	}
+	logger.Info("Database schema up to date", zap.Int("applied", len(applied)))
 
-	// Initialize service
-	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	// Initialize repositories
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+
+	// Initialize services
+	rateProvider := service.NewECBRateProvider(cfg.FXRatesURL, cfg.FXHistoryURL)
+	exchangeRateService := service.NewExchangeRateService(currencyRepo, rateProvider, cfg.DefaultReportingCurrency)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, exchangeRateService)
+
+	// Start exchange rate sync
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	if cfg.FXSyncEnabled {
+		go exchangeRateService.Start(syncCtx, cfg.FXSyncInterval)
+	}
 
 	// Initialize handler
-	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService, exchangeRateService)
+
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Analytics Service",
+		Description: "Reports on port calls, costs, vendors and RFQs",
+	}, handler.Operations)
 
 	// Create router
 	r := chi.NewRouter()
@@ -70,12 +107,13 @@ func main() {
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-Organization-ID"},
+		AllowedMethods:   []string{"GET", "PUT", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-Organization-ID", "X-User-Roles"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	r.Use(api.Validate)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -89,6 +127,10 @@ func main() {
 		analyticsHandler.RegisterRoutes(r)
 	})
 
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Create server
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -112,6 +154,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down analytics service...")
+	stopSync()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -19,6 +19,13 @@ type Config struct {
 	RedisURL     string
 	CacheTTL     time.Duration
 	CacheEnabled bool
+
+	// Currency conversion
+	DefaultReportingCurrency string
+	FXRatesURL               string // Recent daily reference rates (ECB format)
+	FXHistoryURL             string // Full rate history, loaded once to backfill
+	FXSyncEnabled            bool
+	FXSyncInterval           time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -32,6 +39,12 @@ func Load() *Config {
 		RedisURL:     getEnv("REDIS_URL", "redis://localhost:6379"),
 		CacheTTL:     getDuration("ANALYTICS_CACHE_TTL", 5*time.Minute),
 		CacheEnabled: getBool("ANALYTICS_CACHE_ENABLED", true),
+
+		DefaultReportingCurrency: getEnv("ANALYTICS_REPORTING_CURRENCY", "USD"),
+		FXRatesURL:               getEnv("FX_RATES_URL", ""),
+		FXHistoryURL:             getEnv("FX_HISTORY_URL", ""),
+		FXSyncEnabled:            getBool("FX_SYNC_ENABLED", true),
+		FXSyncInterval:           getDuration("FX_SYNC_INTERVAL", 12*time.Hour),
 	}
 }
 
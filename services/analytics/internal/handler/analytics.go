@@ -3,19 +3,23 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/analytics/internal/model"
 	"github.com/navo/services/analytics/internal/service"
 )
 
 // AnalyticsHandler handles analytics HTTP requests
 type AnalyticsHandler struct {
-	svc *service.AnalyticsService
+	svc   *service.AnalyticsService
+	rates *service.ExchangeRateService
 }
 
 // NewAnalyticsHandler creates a new analytics handler
-func NewAnalyticsHandler(svc *service.AnalyticsService) *AnalyticsHandler {
-	return &AnalyticsHandler{svc: svc}
+func NewAnalyticsHandler(svc *service.AnalyticsService, rates *service.ExchangeRateService) *AnalyticsHandler {
+	return &AnalyticsHandler{svc: svc, rates: rates}
 }
 
 // RegisterRoutes registers analytics routes
@@ -25,6 +29,9 @@ func (h *AnalyticsHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/costs", h.GetCostAnalytics)
 	r.Get("/vendors", h.GetVendorAnalytics)
 	r.Get("/rfqs", h.GetRFQAnalytics)
+	r.Get("/settings", h.GetSettings)
+	r.Put("/settings", h.UpdateSettings)
+	r.Get("/exchange-rates", h.GetExchangeRates)
 }
 
 // GetDashboard handles GET /api/v1/analytics/dashboard
@@ -77,7 +84,16 @@ func (h *AnalyticsHandler) GetCostAnalytics(w http.ResponseWriter, r *http.Reque
 	endStr := r.URL.Query().Get("end_date")
 	start, end := h.svc.ParseDateRange(startStr, endStr)
 
-	analytics, err := h.svc.GetCostAnalytics(r.Context(), orgID, start, end)
+	var currency string
+	if c := r.URL.Query().Get("currency"); c != "" {
+		var err error
+		if currency, err = service.NormalizeCurrency(c); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	analytics, err := h.svc.GetCostAnalytics(r.Context(), orgID, start, end, currency)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to get cost analytics")
 		return
@@ -124,8 +140,111 @@ func (h *AnalyticsHandler) GetRFQAnalytics(w http.ResponseWriter, r *http.Reques
 	respondJSON(w, http.StatusOK, analytics)
 }
 
+// GetSettings handles GET /api/v1/analytics/settings
+func (h *AnalyticsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		respondError(w, http.StatusBadRequest, "organization ID required")
+		return
+	}
+
+	settings, err := h.rates.GetSettings(r.Context(), orgID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get analytics settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/v1/analytics/settings
+func (h *AnalyticsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		respondError(w, http.StatusBadRequest, "organization ID required")
+		return
+	}
+	if !isAdmin(r) {
+		respondError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	var input model.AnalyticsSettings
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if _, err := service.NormalizeCurrency(input.ReportingCurrency); err != nil {
+		respondError(w, http.StatusBadRequest, "reporting_currency: "+err.Error())
+		return
+	}
+
+	settings, err := h.rates.UpdateSettings(r.Context(), orgID, input)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update analytics settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// GetExchangeRates handles GET /api/v1/analytics/exchange-rates
+func (h *AnalyticsHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		respondError(w, http.StatusBadRequest, "organization ID required")
+		return
+	}
+
+	on := time.Now()
+	if d := r.URL.Query().Get("date"); d != "" {
+		t, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		on = t
+	}
+
+	var base string
+	if b := r.URL.Query().Get("base"); b != "" {
+		var err error
+		if base, err = service.NormalizeCurrency(b); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		var err error
+		if base, err = h.rates.ReportingCurrency(r.Context(), orgID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get reporting currency")
+			return
+		}
+	}
+
+	rates, err := h.rates.GetRates(r.Context(), base, on)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get exchange rates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"base":  base,
+		"date":  on.Format("2006-01-02"),
+		"rates": rates,
+	})
+}
+
 // Helper functions
 
+func isAdmin(r *http.Request) bool {
+	for _, role := range strings.Split(r.Header.Get("X-User-Roles"), ",") {
+		if strings.TrimSpace(role) == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/analytics/internal/model"
+)
+
+// dateRange are the query parameters of reports over a range of days. It
+// defaults to the last 30 days.
+var dateRange = []openapi.Param{
+	{Name: "start_date", Description: "First day, as YYYY-MM-DD"},
+	{Name: "end_date", Description: "Last day, as YYYY-MM-DD"},
+}
+
+// Operations describe what the analytics service's handlers read and write,
+// for its OpenAPI document and request validation
+var Operations = openapi.Operations{
+	"GET /api/v1/analytics/dashboard": {
+		Summary:  "Get the dashboard metrics",
+		Response: model.DashboardMetrics{},
+	},
+	"GET /api/v1/analytics/port-calls": {
+		Summary:  "Report on port calls",
+		Query:    dateRange,
+		Response: model.PortCallAnalytics{},
+	},
+	"GET /api/v1/analytics/costs": {
+		Summary: "Report on costs",
+		Query: []openapi.Param{
+			dateRange[0],
+			dateRange[1],
+			{Name: "currency", Description: "ISO 4217 currency to report in, the reporting currency by default"},
+		},
+		Response: model.CostAnalytics{},
+	},
+	"GET /api/v1/analytics/vendors": {
+		Summary:  "Report on vendors",
+		Response: model.VendorAnalytics{},
+	},
+	"GET /api/v1/analytics/rfqs": {
+		Summary:  "Report on RFQs",
+		Query:    dateRange,
+		Response: model.RFQAnalytics{},
+	},
+	"GET /api/v1/analytics/settings": {
+		Summary:  "Get the organization's analytics settings",
+		Response: model.AnalyticsSettings{},
+	},
+	"PUT /api/v1/analytics/settings": {
+		Summary:  "Update the organization's analytics settings",
+		Request:  model.AnalyticsSettings{},
+		Omit:     []string{"organization_id"},
+		Response: model.AnalyticsSettings{},
+	},
+	"GET /api/v1/analytics/exchange-rates": {
+		Summary: "Get exchange rates on a day",
+		Query: []openapi.Param{
+			{Name: "date", Description: "Day, as YYYY-MM-DD, today by default"},
+			{Name: "base", Description: "ISO 4217 base currency, the reporting currency by default"},
+		},
+		Response: struct {
+			Base  string               `json:"base"`
+			Date  string               `json:"date"`
+			Rates []model.ExchangeRate `json:"rates"`
+		}{},
+	},
+}
@@ -71,48 +71,80 @@ type MonthlyCount struct {
 	Count int    `json:"count"`
 }
 
-// CostAnalytics represents cost analytics
+// CostAnalytics represents cost analytics. Cost figures are converted to
+// Currency at the exchange rate on each order's completion date; the
+// original amounts per currency are reported alongside.
 type CostAnalytics struct {
-	TotalCost      float64           `json:"total_cost"`
-	AvgCostPerCall float64           `json:"avg_cost_per_call"`
-	ByServiceType  []ServiceTypeCost `json:"by_service_type"`
-	ByPort         []PortCost        `json:"by_port"`
-	ByVessel       []VesselCost      `json:"by_vessel"`
-	MonthlyTrend   []MonthlyCost     `json:"monthly_trend"`
-	Currency       string            `json:"currency"`
+	TotalCost         float64           `json:"total_cost"`
+	AvgCostPerCall    float64           `json:"avg_cost_per_call"`
+	OriginalTotals    []CurrencyAmount  `json:"original_totals"`
+	ByServiceType     []ServiceTypeCost `json:"by_service_type"`
+	ByPort            []PortCost        `json:"by_port"`
+	ByVessel          []VesselCost      `json:"by_vessel"`
+	MonthlyTrend      []MonthlyCost     `json:"monthly_trend"`
+	Currency          string            `json:"currency"`
+	ReportingCurrency string            `json:"reporting_currency"`
+	OrderCount        int               `json:"order_count"`
+	UnconvertedOrders int               `json:"unconverted_orders"`
+	MissingRates      []string          `json:"missing_rates,omitempty"` // Currencies without a usable rate
+}
+
+// CurrencyAmount is an amount in a specific currency
+type CurrencyAmount struct {
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
 }
 
 // ServiceTypeCost represents cost by service type
 type ServiceTypeCost struct {
-	ServiceTypeID   string  `json:"service_type_id"`
-	ServiceTypeName string  `json:"service_type_name"`
-	TotalCost       float64 `json:"total_cost"`
-	OrderCount      int     `json:"order_count"`
-	AvgCost         float64 `json:"avg_cost"`
+	ServiceTypeID   string           `json:"service_type_id"`
+	ServiceTypeName string           `json:"service_type_name"`
+	TotalCost       float64          `json:"total_cost"`
+	OriginalCosts   []CurrencyAmount `json:"original_costs"`
+	OrderCount      int              `json:"order_count"`
+	AvgCost         float64          `json:"avg_cost"`
 }
 
 // PortCost represents cost by port
 type PortCost struct {
-	PortID    string  `json:"port_id"`
-	PortName  string  `json:"port_name"`
-	TotalCost float64 `json:"total_cost"`
-	CallCount int     `json:"call_count"`
-	AvgCost   float64 `json:"avg_cost"`
+	PortID        string           `json:"port_id"`
+	PortName      string           `json:"port_name"`
+	TotalCost     float64          `json:"total_cost"`
+	OriginalCosts []CurrencyAmount `json:"original_costs"`
+	CallCount     int              `json:"call_count"`
+	AvgCost       float64          `json:"avg_cost"`
 }
 
 // VesselCost represents cost by vessel
 type VesselCost struct {
-	VesselID   string  `json:"vessel_id"`
-	VesselName string  `json:"vessel_name"`
-	TotalCost  float64 `json:"total_cost"`
-	CallCount  int     `json:"call_count"`
-	AvgCost    float64 `json:"avg_cost"`
+	VesselID      string           `json:"vessel_id"`
+	VesselName    string           `json:"vessel_name"`
+	TotalCost     float64          `json:"total_cost"`
+	OriginalCosts []CurrencyAmount `json:"original_costs"`
+	CallCount     int              `json:"call_count"`
+	AvgCost       float64          `json:"avg_cost"`
 }
 
 // MonthlyCost represents monthly cost
 type MonthlyCost struct {
-	Month string  `json:"month"`
-	Cost  float64 `json:"cost"`
+	Month         string           `json:"month"`
+	Cost          float64          `json:"cost"`
+	OriginalCosts []CurrencyAmount `json:"original_costs"`
+}
+
+// OrderCost is a completed service order's final price with its grouping keys
+type OrderCost struct {
+	ServiceOrderID  string
+	PortCallID      string
+	ServiceTypeID   string
+	ServiceTypeName string
+	PortID          string
+	PortName        string
+	VesselID        string
+	VesselName      string
+	Amount          float64
+	Currency        string
+	CompletedDate   time.Time
 }
 
 // VendorAnalytics represents vendor performance analytics
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// ExchangeRate is the value of one unit of BaseCurrency in QuoteCurrency on a given day
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Date          time.Time `json:"date"`
+	Rate          float64   `json:"rate"`
+	Source        string    `json:"source"`
+}
+
+// AnalyticsSettings are per-organization analytics settings
+type AnalyticsSettings struct {
+	OrganizationID    string `json:"organization_id"`
+	ReportingCurrency string `json:"reporting_currency"`
+}
+
+// ExchangeRateSyncResult summarizes an exchange rate sync
+type ExchangeRateSyncResult struct {
+	Source    string    `json:"source"`
+	Rates     int       `json:"rates"`
+	LatestDay time.Time `json:"latest_day"`
+}
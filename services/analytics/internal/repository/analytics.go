@@ -167,72 +167,42 @@ func (r *AnalyticsRepository) GetPortCallAnalytics(ctx context.Context, filter m
 	return analytics, nil
 }
 
-// GetCostAnalytics retrieves cost analytics
-func (r *AnalyticsRepository) GetCostAnalytics(ctx context.Context, filter model.AnalyticsFilter) (*model.CostAnalytics, error) {
-	analytics := &model.CostAnalytics{
-		Currency: "USD",
-	}
-
-	// Total cost
-	r.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(final_price), 0) FROM service_orders so
-		JOIN port_calls pc ON so.port_call_id = pc.id
-		JOIN workspaces w ON pc.workspace_id = w.id
-		WHERE w.organization_id = $1
-		AND so.status = 'completed'
-		AND so.completed_date >= $2 AND so.completed_date <= $3
-	`, filter.OrganizationID, filter.StartDate, filter.EndDate).Scan(&analytics.TotalCost)
-
-	// By service type
+// ListOrderCosts retrieves completed service orders with their final price in
+// the order's own currency. Amounts are aggregated by the service so they can
+// be converted at each order's completion date.
+func (r *AnalyticsRepository) ListOrderCosts(ctx context.Context, filter model.AnalyticsFilter) ([]model.OrderCost, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT st.id, st.name,
-			   COALESCE(SUM(so.final_price), 0) as total,
-			   COUNT(*) as count
+		SELECT so.id, so.port_call_id, st.id, st.name,
+			   p.id, p.name, v.id, v.name,
+			   COALESCE(so.final_price, 0), so.currency, so.completed_date
 		FROM service_orders so
 		JOIN service_types st ON so.service_type_id = st.id
 		JOIN port_calls pc ON so.port_call_id = pc.id
+		JOIN ports p ON pc.port_id = p.id
+		JOIN vessels v ON pc.vessel_id = v.id
 		JOIN workspaces w ON pc.workspace_id = w.id
 		WHERE w.organization_id = $1
 		AND so.status = 'completed'
 		AND so.completed_date >= $2 AND so.completed_date <= $3
-		GROUP BY st.id, st.name
-		ORDER BY total DESC
+		ORDER BY so.completed_date
 	`, filter.OrganizationID, filter.StartDate, filter.EndDate)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var stc model.ServiceTypeCost
-			rows.Scan(&stc.ServiceTypeID, &stc.ServiceTypeName, &stc.TotalCost, &stc.OrderCount)
-			if stc.OrderCount > 0 {
-				stc.AvgCost = stc.TotalCost / float64(stc.OrderCount)
-			}
-			analytics.ByServiceType = append(analytics.ByServiceType, stc)
-		}
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Monthly trend
-	rows, err = r.db.QueryContext(ctx, `
-		SELECT TO_CHAR(so.completed_date, 'YYYY-MM') as month,
-			   COALESCE(SUM(so.final_price), 0) as cost
-		FROM service_orders so
-		JOIN port_calls pc ON so.port_call_id = pc.id
-		JOIN workspaces w ON pc.workspace_id = w.id
-		WHERE w.organization_id = $1
-		AND so.status = 'completed'
-		AND so.completed_date >= $2 AND so.completed_date <= $3
-		GROUP BY month
-		ORDER BY month
-	`, filter.OrganizationID, filter.StartDate, filter.EndDate)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var mc model.MonthlyCost
-			rows.Scan(&mc.Month, &mc.Cost)
-			analytics.MonthlyTrend = append(analytics.MonthlyTrend, mc)
+	var costs []model.OrderCost
+	for rows.Next() {
+		var oc model.OrderCost
+		if err := rows.Scan(&oc.ServiceOrderID, &oc.PortCallID, &oc.ServiceTypeID, &oc.ServiceTypeName,
+			&oc.PortID, &oc.PortName, &oc.VesselID, &oc.VesselName,
+			&oc.Amount, &oc.Currency, &oc.CompletedDate); err != nil {
+			return nil, err
 		}
+		costs = append(costs, oc)
 	}
 
-	return analytics, nil
+	return costs, rows.Err()
 }
 
 // GetVendorAnalytics retrieves vendor analytics
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/navo/services/analytics/internal/model"
+)
+
+// CurrencyRepository handles exchange rates and reporting currency settings
+type CurrencyRepository struct {
+	db *sql.DB
+}
+
+// NewCurrencyRepository creates a new currency repository
+func NewCurrencyRepository(db *sql.DB) *CurrencyRepository {
+	return &CurrencyRepository{db: db}
+}
+
+// UpsertRates stores exchange rates, replacing existing rates for the same day
+func (r *CurrencyRepository) UpsertRates(ctx context.Context, rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate_date, rate, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (base_currency, quote_currency, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare rate upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.BaseCurrency, rate.QuoteCurrency, rate.Date, rate.Rate, rate.Source); err != nil {
+			return fmt.Errorf("failed to upsert rate %s/%s: %w", rate.BaseCurrency, rate.QuoteCurrency, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rates: %w", err)
+	}
+	return nil
+}
+
+// GetRates retrieves rates from base into the given currencies between two
+// days (inclusive). A nil currencies slice returns every quoted currency.
+func (r *CurrencyRepository) GetRates(ctx context.Context, base string, currencies []string, from, to time.Time) ([]model.ExchangeRate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT base_currency, quote_currency, rate_date, rate, source
+		FROM exchange_rates
+		WHERE base_currency = $1 AND ($2::text[] IS NULL OR quote_currency = ANY($2))
+		AND rate_date >= $3 AND rate_date <= $4
+		ORDER BY quote_currency, rate_date
+	`, base, pq.Array(currencies), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []model.ExchangeRate
+	for rows.Next() {
+		var rate model.ExchangeRate
+		if err := rows.Scan(&rate.BaseCurrency, &rate.QuoteCurrency, &rate.Date, &rate.Rate, &rate.Source); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// GetLatestRateDate returns the most recent day with rates from base, or zero time if none
+func (r *CurrencyRepository) GetLatestRateDate(ctx context.Context, base string) (time.Time, error) {
+	var latest sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT MAX(rate_date) FROM exchange_rates WHERE base_currency = $1
+	`, base).Scan(&latest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest rate date: %w", err)
+	}
+	return latest.Time, nil
+}
+
+// GetReportingCurrency returns the organization's reporting currency, or "" if not set
+func (r *CurrencyRepository) GetReportingCurrency(ctx context.Context, orgID string) (string, error) {
+	var currency sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT settings->>'reporting_currency' FROM organizations WHERE id = $1
+	`, orgID).Scan(&currency)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get reporting currency: %w", err)
+	}
+	return strings.ToUpper(currency.String), nil
+}
+
+// SetReportingCurrency sets the organization's reporting currency
+func (r *CurrencyRepository) SetReportingCurrency(ctx context.Context, orgID, currency string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE organizations
+		SET settings = COALESCE(settings, '{}'::jsonb) || jsonb_build_object('reporting_currency', $2::text),
+			updated_at = NOW()
+		WHERE id = $1
+	`, orgID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to set reporting currency: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("organization not found")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/navo/pkg/migrate/migratetest"
+	"github.com/navo/services/analytics/migrations"
+)
+
+// TestMigrations applies the migrations to the database in TEST_DATABASE_URL
+// and checks this package's queries against the schema they create
+func TestMigrations(t *testing.T) {
+	migratetest.Run(t, migratetest.Options{
+		Service:    "analytics",
+		Migrations: migrations.FS,
+		External: []string{
+			"organizations",
+			"workspaces",
+			"vessels",
+			"ports",
+			"port_calls",
+			"service_types",
+			"service_orders",
+			"rfqs",
+			"quotes",
+			"vendors",
+			"operator_vendor_lists",
+		},
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/navo/services/analytics/internal/model"
@@ -10,12 +11,13 @@ import (
 
 // AnalyticsService handles analytics business logic
 type AnalyticsService struct {
-	repo *repository.AnalyticsRepository
+	repo  *repository.AnalyticsRepository
+	rates *ExchangeRateService
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(repo *repository.AnalyticsRepository) *AnalyticsService {
-	return &AnalyticsService{repo: repo}
+func NewAnalyticsService(repo *repository.AnalyticsRepository, rates *ExchangeRateService) *AnalyticsService {
+	return &AnalyticsService{repo: repo, rates: rates}
 }
 
 // GetDashboardMetrics returns dashboard metrics for an organization
@@ -33,14 +35,206 @@ func (s *AnalyticsService) GetPortCallAnalytics(ctx context.Context, orgID strin
 	return s.repo.GetPortCallAnalytics(ctx, filter)
 }
 
-// GetCostAnalytics returns cost analytics
-func (s *AnalyticsService) GetCostAnalytics(ctx context.Context, orgID string, startDate, endDate time.Time) (*model.CostAnalytics, error) {
+// GetCostAnalytics returns cost analytics in the given currency, or in the
+// organization's reporting currency when currency is empty. Each order is
+// converted at the rate on its completion date.
+func (s *AnalyticsService) GetCostAnalytics(ctx context.Context, orgID string, startDate, endDate time.Time, currency string) (*model.CostAnalytics, error) {
 	filter := model.AnalyticsFilter{
 		OrganizationID: orgID,
 		StartDate:      startDate,
 		EndDate:        endDate,
 	}
-	return s.repo.GetCostAnalytics(ctx, filter)
+
+	reporting, err := s.rates.ReportingCurrency(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		currency = reporting
+	}
+
+	orders, err := s.repo.ListOrderCosts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := map[string]bool{currency: true}
+	for _, o := range orders {
+		currencies[o.Currency] = true
+	}
+	codes := make([]string, 0, len(currencies))
+	for c := range currencies {
+		codes = append(codes, c)
+	}
+
+	table, err := s.rates.LoadRateTable(ctx, codes, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := AggregateOrderCosts(orders, table, currency)
+	analytics.ReportingCurrency = reporting
+	return analytics, nil
+}
+
+// costGroup accumulates converted and original costs for one grouping key
+type costGroup struct {
+	id, name  string
+	total     float64
+	originals map[string]float64
+	orders    int
+	converted int
+	calls     map[string]bool
+}
+
+func newCostGroup(id, name string) *costGroup {
+	return &costGroup{id: id, name: name, originals: make(map[string]float64), calls: make(map[string]bool)}
+}
+
+func (g *costGroup) add(o model.OrderCost, converted float64, ok bool) {
+	g.orders++
+	g.originals[o.Currency] += o.Amount
+	if ok {
+		g.total += converted
+		g.converted++
+		g.calls[o.PortCallID] = true
+	}
+}
+
+func (g *costGroup) originalCosts() []model.CurrencyAmount {
+	return currencyAmounts(g.originals)
+}
+
+func perUnit(total float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return roundMoney(total / float64(count))
+}
+
+// AggregateOrderCosts converts order costs into currency and groups them.
+// Orders without a usable rate keep contributing to the original per-currency
+// figures but are left out of converted totals and counted as unconverted.
+func AggregateOrderCosts(orders []model.OrderCost, table *RateTable, currency string) *model.CostAnalytics {
+	analytics := &model.CostAnalytics{
+		Currency:          currency,
+		ReportingCurrency: currency,
+		OrderCount:        len(orders),
+		ByServiceType:     []model.ServiceTypeCost{},
+		ByPort:            []model.PortCost{},
+		ByVessel:          []model.VesselCost{},
+		MonthlyTrend:      []model.MonthlyCost{},
+	}
+
+	all := newCostGroup("", "")
+	byServiceType := make(map[string]*costGroup)
+	byPort := make(map[string]*costGroup)
+	byVessel := make(map[string]*costGroup)
+	byMonth := make(map[string]*costGroup)
+	missing := make(map[string]bool)
+
+	group := func(groups map[string]*costGroup, id, name string) *costGroup {
+		g, ok := groups[id]
+		if !ok {
+			g = newCostGroup(id, name)
+			groups[id] = g
+		}
+		return g
+	}
+
+	for _, o := range orders {
+		converted, ok := table.Convert(o.Amount, o.Currency, currency, o.CompletedDate)
+		if !ok {
+			analytics.UnconvertedOrders++
+			missing[o.Currency] = true
+		}
+
+		all.add(o, converted, ok)
+		group(byServiceType, o.ServiceTypeID, o.ServiceTypeName).add(o, converted, ok)
+		group(byPort, o.PortID, o.PortName).add(o, converted, ok)
+		group(byVessel, o.VesselID, o.VesselName).add(o, converted, ok)
+		month := o.CompletedDate.Format("2006-01")
+		group(byMonth, month, month).add(o, converted, ok)
+	}
+
+	analytics.TotalCost = roundMoney(all.total)
+	analytics.AvgCostPerCall = perUnit(all.total, len(all.calls))
+	analytics.OriginalTotals = all.originalCosts()
+
+	for _, g := range sortedByTotal(byServiceType) {
+		analytics.ByServiceType = append(analytics.ByServiceType, model.ServiceTypeCost{
+			ServiceTypeID:   g.id,
+			ServiceTypeName: g.name,
+			TotalCost:       roundMoney(g.total),
+			OriginalCosts:   g.originalCosts(),
+			OrderCount:      g.orders,
+			AvgCost:         perUnit(g.total, g.converted),
+		})
+	}
+	for _, g := range sortedByTotal(byPort) {
+		analytics.ByPort = append(analytics.ByPort, model.PortCost{
+			PortID:        g.id,
+			PortName:      g.name,
+			TotalCost:     roundMoney(g.total),
+			OriginalCosts: g.originalCosts(),
+			CallCount:     len(g.calls),
+			AvgCost:       perUnit(g.total, len(g.calls)),
+		})
+	}
+	for _, g := range sortedByTotal(byVessel) {
+		analytics.ByVessel = append(analytics.ByVessel, model.VesselCost{
+			VesselID:      g.id,
+			VesselName:    g.name,
+			TotalCost:     roundMoney(g.total),
+			OriginalCosts: g.originalCosts(),
+			CallCount:     len(g.calls),
+			AvgCost:       perUnit(g.total, len(g.calls)),
+		})
+	}
+
+	months := make([]string, 0, len(byMonth))
+	for m := range byMonth {
+		months = append(months, m)
+	}
+	sort.Strings(months)
+	for _, m := range months {
+		g := byMonth[m]
+		analytics.MonthlyTrend = append(analytics.MonthlyTrend, model.MonthlyCost{
+			Month:         m,
+			Cost:          roundMoney(g.total),
+			OriginalCosts: g.originalCosts(),
+		})
+	}
+
+	for c := range missing {
+		analytics.MissingRates = append(analytics.MissingRates, c)
+	}
+	sort.Strings(analytics.MissingRates)
+
+	return analytics
+}
+
+func sortedByTotal(groups map[string]*costGroup) []*costGroup {
+	sorted := make([]*costGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].total != sorted[j].total {
+			return sorted[i].total > sorted[j].total
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+func currencyAmounts(amounts map[string]float64) []model.CurrencyAmount {
+	result := make([]model.CurrencyAmount, 0, len(amounts))
+	for c, amount := range amounts {
+		result = append(result, model.CurrencyAmount{Currency: c, Amount: roundMoney(amount)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
 }
 
 // GetVendorAnalytics returns vendor analytics
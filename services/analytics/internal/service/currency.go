@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/logger"
+	"github.com/navo/services/analytics/internal/model"
+	"github.com/navo/services/analytics/internal/repository"
+	"go.uber.org/zap"
+)
+
+// RateBaseCurrency is the currency all stored reference rates are quoted against
+const RateBaseCurrency = "EUR"
+
+// maxRateAge is how far back a conversion may look for a rate when none was
+// published on the day itself (weekends and bank holidays)
+const maxRateAge = 7 * 24 * time.Hour
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases a currency code and validates it is a 3-letter ISO code
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return "", fmt.Errorf("currency must be a 3-letter ISO code")
+	}
+	return code, nil
+}
+
+// RateProvider fetches daily reference rates quoted against RateBaseCurrency
+type RateProvider interface {
+	Name() string
+	// FetchRates returns rates published on or after since. A zero since
+	// requests the full history the provider offers.
+	FetchRates(ctx context.Context, since time.Time) ([]model.ExchangeRate, error)
+}
+
+type ratePoint struct {
+	date time.Time
+	rate float64
+}
+
+// RateTable converts amounts between currencies using daily rates quoted
+// against RateBaseCurrency. Cross rates are derived through the base.
+type RateTable struct {
+	series map[string][]ratePoint
+}
+
+// NewRateTable builds a rate table from base-currency rates
+func NewRateTable(rates []model.ExchangeRate) *RateTable {
+	t := &RateTable{series: make(map[string][]ratePoint)}
+	for _, r := range rates {
+		if r.BaseCurrency != RateBaseCurrency || r.Rate <= 0 {
+			continue
+		}
+		t.series[r.QuoteCurrency] = append(t.series[r.QuoteCurrency], ratePoint{date: truncateDay(r.Date), rate: r.Rate})
+	}
+	for _, points := range t.series {
+		sort.Slice(points, func(i, j int) bool { return points[i].date.Before(points[j].date) })
+	}
+	return t
+}
+
+// baseRate returns the base-currency rate for a currency on or before the given day
+func (t *RateTable) baseRate(currency string, on time.Time) (float64, time.Time, bool) {
+	on = truncateDay(on)
+	if currency == RateBaseCurrency {
+		return 1, on, true
+	}
+
+	points := t.series[currency]
+	i := sort.Search(len(points), func(i int) bool { return points[i].date.After(on) })
+	if i == 0 {
+		return 0, time.Time{}, false
+	}
+	p := points[i-1]
+	if on.Sub(p.date) > maxRateAge {
+		return 0, time.Time{}, false
+	}
+	return p.rate, p.date, true
+}
+
+// Rate returns the rate from one currency to another on the given day and the
+// day the rate was published. ok is false when either side has no recent rate.
+func (t *RateTable) Rate(from, to string, on time.Time) (rate float64, rateDate time.Time, ok bool) {
+	if from == to {
+		return 1, truncateDay(on), true
+	}
+	fromRate, fromDate, ok := t.baseRate(from, on)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	toRate, toDate, ok := t.baseRate(to, on)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+
+	rateDate = fromDate
+	if toDate.Before(rateDate) {
+		rateDate = toDate
+	}
+	return toRate / fromRate, rateDate, true
+}
+
+// Convert converts an amount between currencies at the rate on the given day
+func (t *RateTable) Convert(amount float64, from, to string, on time.Time) (float64, bool) {
+	rate, _, ok := t.Rate(from, to, on)
+	if !ok {
+		return 0, false
+	}
+	return amount * rate, true
+}
+
+// ExchangeRateService keeps reference exchange rates up to date and resolves
+// the reporting currency of an organization
+type ExchangeRateService struct {
+	repo            *repository.CurrencyRepository
+	provider        RateProvider
+	defaultCurrency string
+}
+
+// NewExchangeRateService creates a new exchange rate service
+func NewExchangeRateService(repo *repository.CurrencyRepository, provider RateProvider, defaultCurrency string) *ExchangeRateService {
+	return &ExchangeRateService{
+		repo:            repo,
+		provider:        provider,
+		defaultCurrency: strings.ToUpper(defaultCurrency),
+	}
+}
+
+// Start syncs rates immediately and then on every interval until ctx is cancelled
+func (s *ExchangeRateService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := s.Sync(ctx); err != nil {
+			logger.Error("Exchange rate sync failed", zap.Error(err))
+		} else {
+			logger.Info("Exchange rates synced",
+				zap.String("source", result.Source),
+				zap.Int("rates", result.Rates),
+				zap.Time("latest_day", result.LatestDay))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync fetches rates published since the latest stored day and stores them.
+// When no rates are stored yet the provider's full history is loaded.
+func (s *ExchangeRateService) Sync(ctx context.Context) (*model.ExchangeRateSyncResult, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("no exchange rate provider configured")
+	}
+
+	latest, err := s.repo.GetLatestRateDate(ctx, RateBaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.provider.FetchRates(ctx, latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates from %s: %w", s.provider.Name(), err)
+	}
+	if err := s.repo.UpsertRates(ctx, rates); err != nil {
+		return nil, err
+	}
+
+	result := &model.ExchangeRateSyncResult{
+		Source:    s.provider.Name(),
+		Rates:     len(rates),
+		LatestDay: latest,
+	}
+	for _, r := range rates {
+		if r.Date.After(result.LatestDay) {
+			result.LatestDay = r.Date
+		}
+	}
+	return result, nil
+}
+
+// LoadRateTable loads the rates needed to convert between the given
+// currencies for days in [from, to]
+func (s *ExchangeRateService) LoadRateTable(ctx context.Context, currencies []string, from, to time.Time) (*RateTable, error) {
+	var quotes []string
+	for _, c := range currencies {
+		if c != RateBaseCurrency {
+			quotes = append(quotes, c)
+		}
+	}
+	if len(quotes) == 0 {
+		return NewRateTable(nil), nil
+	}
+
+	rates, err := s.repo.GetRates(ctx, RateBaseCurrency, quotes, truncateDay(from).Add(-maxRateAge), truncateDay(to))
+	if err != nil {
+		return nil, err
+	}
+	return NewRateTable(rates), nil
+}
+
+// GetRates returns the rates from base into each known currency on the given day
+func (s *ExchangeRateService) GetRates(ctx context.Context, base string, on time.Time) ([]model.ExchangeRate, error) {
+	rates, err := s.repo.GetRates(ctx, RateBaseCurrency, nil, truncateDay(on).Add(-maxRateAge), truncateDay(on))
+	if err != nil {
+		return nil, err
+	}
+	table := NewRateTable(rates)
+
+	currencies := []string{RateBaseCurrency}
+	for c := range table.series {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	result := make([]model.ExchangeRate, 0, len(currencies))
+	for _, c := range currencies {
+		if c == base {
+			continue
+		}
+		rate, rateDate, ok := table.Rate(base, c, on)
+		if !ok {
+			continue
+		}
+		result = append(result, model.ExchangeRate{
+			BaseCurrency:  base,
+			QuoteCurrency: c,
+			Date:          rateDate,
+			Rate:          rate,
+			Source:        s.sourceName(),
+		})
+	}
+	return result, nil
+}
+
+// GetSettings returns the analytics settings of an organization
+func (s *ExchangeRateService) GetSettings(ctx context.Context, orgID string) (*model.AnalyticsSettings, error) {
+	currency, err := s.ReportingCurrency(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AnalyticsSettings{
+		OrganizationID:    orgID,
+		ReportingCurrency: currency,
+	}, nil
+}
+
+// UpdateSettings updates the analytics settings of an organization
+func (s *ExchangeRateService) UpdateSettings(ctx context.Context, orgID string, settings model.AnalyticsSettings) (*model.AnalyticsSettings, error) {
+	currency, err := NormalizeCurrency(settings.ReportingCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetReportingCurrency(ctx, orgID, currency); err != nil {
+		return nil, err
+	}
+	return &model.AnalyticsSettings{
+		OrganizationID:    orgID,
+		ReportingCurrency: currency,
+	}, nil
+}
+
+// ReportingCurrency returns the organization's reporting currency, falling
+// back to the configured default
+func (s *ExchangeRateService) ReportingCurrency(ctx context.Context, orgID string) (string, error) {
+	currency, err := s.repo.GetReportingCurrency(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+	if currency == "" {
+		return s.defaultCurrency, nil
+	}
+	return currency, nil
+}
+
+func (s *ExchangeRateService) sourceName() string {
+	if s.provider == nil {
+		return ""
+	}
+	return s.provider.Name()
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/navo/services/analytics/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func testRateTable() *RateTable {
+	return NewRateTable([]model.ExchangeRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Date: day("2024-03-01"), Rate: 1.08},
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Date: day("2024-03-04"), Rate: 1.10},
+		{BaseCurrency: "EUR", QuoteCurrency: "SGD", Date: day("2024-03-01"), Rate: 1.45},
+		{BaseCurrency: "EUR", QuoteCurrency: "SGD", Date: day("2024-03-04"), Rate: 1.50},
+	})
+}
+
+func TestRateTable_Convert(t *testing.T) {
+	table := testRateTable()
+
+	tests := []struct {
+		name     string
+		amount   float64
+		from, to string
+		on       string
+		want     float64
+		wantOK   bool
+	}{
+		{"same currency", 100, "USD", "USD", "2024-03-01", 100, true},
+		{"from base", 100, "EUR", "USD", "2024-03-01", 108, true},
+		{"to base", 110, "USD", "EUR", "2024-03-04", 100, true},
+		{"cross rate", 110, "USD", "SGD", "2024-03-04", 150, true},
+		{"weekend uses previous rate", 100, "EUR", "USD", "2024-03-03", 108, true},
+		{"rate too old", 100, "EUR", "USD", "2024-03-20", 0, false},
+		{"before first rate", 100, "EUR", "USD", "2024-02-28", 0, false},
+		{"unknown currency", 100, "JPY", "USD", "2024-03-04", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.Convert(tt.amount, tt.from, tt.to, day(tt.on))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.InDelta(t, tt.want, got, 0.0001)
+		})
+	}
+}
+
+func TestAggregateOrderCosts(t *testing.T) {
+	orders := []model.OrderCost{
+		{ServiceOrderID: "so-1", PortCallID: "pc-1", ServiceTypeID: "st-water", ServiceTypeName: "Fresh water",
+			PortID: "port-sin", PortName: "Singapore", VesselID: "v-1", VesselName: "Aurora",
+			Amount: 1500, Currency: "SGD", CompletedDate: day("2024-03-04")},
+		{ServiceOrderID: "so-2", PortCallID: "pc-1", ServiceTypeID: "st-agency", ServiceTypeName: "Agency",
+			PortID: "port-sin", PortName: "Singapore", VesselID: "v-1", VesselName: "Aurora",
+			Amount: 108, Currency: "USD", CompletedDate: day("2024-03-01")},
+		{ServiceOrderID: "so-3", PortCallID: "pc-2", ServiceTypeID: "st-water", ServiceTypeName: "Fresh water",
+			PortID: "port-rtm", PortName: "Rotterdam", VesselID: "v-2", VesselName: "Borealis",
+			Amount: 200, Currency: "EUR", CompletedDate: day("2024-04-02")},
+		{ServiceOrderID: "so-4", PortCallID: "pc-2", ServiceTypeID: "st-water", ServiceTypeName: "Fresh water",
+			PortID: "port-rtm", PortName: "Rotterdam", VesselID: "v-2", VesselName: "Borealis",
+			Amount: 5000, Currency: "NOK", CompletedDate: day("2024-04-02")},
+	}
+
+	analytics := AggregateOrderCosts(orders, testRateTable(), "EUR")
+
+	assert.Equal(t, "EUR", analytics.Currency)
+	assert.Equal(t, 4, analytics.OrderCount)
+	assert.Equal(t, 1, analytics.UnconvertedOrders)
+	assert.Equal(t, []string{"NOK"}, analytics.MissingRates)
+
+	// 1500 SGD @1.50 + 108 USD @1.08 + 200 EUR
+	assert.InDelta(t, 1300, analytics.TotalCost, 0.001)
+	assert.InDelta(t, 650, analytics.AvgCostPerCall, 0.001)
+	assert.Equal(t, []model.CurrencyAmount{
+		{Currency: "EUR", Amount: 200},
+		{Currency: "NOK", Amount: 5000},
+		{Currency: "SGD", Amount: 1500},
+		{Currency: "USD", Amount: 108},
+	}, analytics.OriginalTotals)
+
+	require.Len(t, analytics.ByServiceType, 2)
+	water := analytics.ByServiceType[0]
+	assert.Equal(t, "st-water", water.ServiceTypeID)
+	assert.InDelta(t, 1200, water.TotalCost, 0.001)
+	assert.Equal(t, 3, water.OrderCount)
+	assert.InDelta(t, 600, water.AvgCost, 0.001)
+
+	require.Len(t, analytics.ByPort, 2)
+	assert.Equal(t, "port-sin", analytics.ByPort[0].PortID)
+	assert.InDelta(t, 1100, analytics.ByPort[0].TotalCost, 0.001)
+	assert.Equal(t, 1, analytics.ByPort[0].CallCount)
+
+	require.Len(t, analytics.MonthlyTrend, 2)
+	assert.Equal(t, "2024-03", analytics.MonthlyTrend[0].Month)
+	assert.InDelta(t, 1100, analytics.MonthlyTrend[0].Cost, 0.001)
+	assert.Equal(t, "2024-04", analytics.MonthlyTrend[1].Month)
+	assert.InDelta(t, 200, analytics.MonthlyTrend[1].Cost, 0.001)
+}
+
+func TestParseECBRates(t *testing.T) {
+	envelope := ecbEnvelope{Days: []ecbDay{
+		{Time: "2024-03-04", Rates: []ecbRate{{Currency: "USD", Rate: "1.0850"}, {Currency: "SGD", Rate: "1.4567"}}},
+		{Time: "2024-03-01", Rates: []ecbRate{{Currency: "USD", Rate: "1.0830"}}},
+	}}
+
+	rates, err := parseECBRates(envelope, day("2024-03-02"))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, model.ExchangeRate{
+		BaseCurrency:  "EUR",
+		QuoteCurrency: "USD",
+		Date:          day("2024-03-04"),
+		Rate:          1.085,
+		Source:        "ecb",
+	}, rates[0])
+
+	_, err = parseECBRates(ecbEnvelope{Days: []ecbDay{{Time: "2024-03-04", Rates: []ecbRate{{Currency: "USD", Rate: "n/a"}}}}}, time.Time{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/navo/services/analytics/internal/model"
+)
+
+// ECB reference rate feeds. The 90-day feed is used for regular syncs; the
+// full history is loaded once to backfill older orders.
+const (
+	DefaultECBRatesURL   = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+	DefaultECBHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml"
+)
+
+// ecbRecentWindow is the period covered by the 90-day feed, with a margin
+const ecbRecentWindow = 85 * 24 * time.Hour
+
+type ecbEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Days    []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// ECBRateProvider fetches euro foreign exchange reference rates published by
+// the European Central Bank
+type ECBRateProvider struct {
+	client     *http.Client
+	recentURL  string
+	historyURL string
+}
+
+// NewECBRateProvider creates a new ECB rate provider
+func NewECBRateProvider(recentURL, historyURL string) *ECBRateProvider {
+	if recentURL == "" {
+		recentURL = DefaultECBRatesURL
+	}
+	if historyURL == "" {
+		historyURL = DefaultECBHistoryURL
+	}
+	return &ECBRateProvider{
+		client:     &http.Client{Timeout: 60 * time.Second},
+		recentURL:  recentURL,
+		historyURL: historyURL,
+	}
+}
+
+// Name returns the provider name
+func (p *ECBRateProvider) Name() string {
+	return "ecb"
+}
+
+// FetchRates fetches rates published on or after since
+func (p *ECBRateProvider) FetchRates(ctx context.Context, since time.Time) ([]model.ExchangeRate, error) {
+	url := p.recentURL
+	if since.IsZero() || time.Since(since) > ecbRecentWindow {
+		url = p.historyURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid ECB rates document: %w", err)
+	}
+	return parseECBRates(envelope, since)
+}
+
+func parseECBRates(envelope ecbEnvelope, since time.Time) ([]model.ExchangeRate, error) {
+	since = truncateDay(since)
+
+	var rates []model.ExchangeRate
+	for _, day := range envelope.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB rate date %q: %w", day.Time, err)
+		}
+		if date.Before(since) {
+			continue
+		}
+		for _, r := range day.Rates {
+			rate, err := strconv.ParseFloat(r.Rate, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ECB rate for %s on %s: %w", r.Currency, day.Time, err)
+			}
+			rates = append(rates, model.ExchangeRate{
+				BaseCurrency:  RateBaseCurrency,
+				QuoteCurrency: r.Currency,
+				Date:          date,
+				Rate:          rate,
+				Source:        "ecb",
+			})
+		}
+	}
+	return rates, nil
+}
@@ -0,0 +1,13 @@
+-- Daily exchange rates used to report spend in one currency
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (base_currency, quote_currency, rate_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_quote_date ON exchange_rates(quote_currency, rate_date);
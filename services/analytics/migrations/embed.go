@@ -0,0 +1,10 @@
+// Package migrations holds the analytics service's schema migrations, applied
+// with pkg/migrate
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -16,10 +16,13 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/navo/pkg/auth"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/migrate"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/services/auth/internal/config"
 	"github.com/navo/services/auth/internal/handler"
 	"github.com/navo/services/auth/internal/repository"
 	"github.com/navo/services/auth/internal/service"
+	"github.com/navo/services/auth/migrations"
 	"go.uber.org/zap"
 )
 
@@ -52,20 +55,39 @@ func main() {
 	}
 	logger.Info("Connected to database")
 
+	// Apply schema migrations; replicas starting together wait on the
+	// migration lock. "auth migrate ..." manages them instead.
+	migrator, err := migrate.New(db, "auth", migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrate.Command(context.Background(), migrator, os.Args[2:], os.Stdout)
+		db.Close()
+		os.Exit(code)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to apply migrations", zap.Error(err))
+	}
+	logger.Info("Database schema up to date", zap.Int("applied", len(applied)))
+
 	// Initialize repository
 	userRepo := repository.NewUserRepository(db)
 
-	// Ensure required tables exist
-	if err := userRepo.EnsureTablesExist(context.Background()); err != nil {
-		logger.Fatal("Failed to ensure tables exist", zap.Error(err))
-	}
-
 	// Initialize service
 	authService := service.NewAuthService(userRepo, cfg)
 
 	// Initialize handler
 	authHandler := handler.NewAuthHandler(authService)
 
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Auth Service",
+		Description: "Logins, sessions and passwords",
+	}, handler.Operations)
+
 	// Create router
 	r := chi.NewRouter()
 
@@ -85,6 +107,7 @@ func main() {
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	r.Use(api.Validate)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -103,6 +126,10 @@ func main() {
 		authHandler.RegisterRoutes(r)
 	})
 
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Create server
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
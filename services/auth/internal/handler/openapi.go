@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/auth/internal/model"
+)
+
+// Operations describe what the auth service's handlers read and write, for
+// its OpenAPI document and request validation. Routes are served under
+// both /auth and /api/v1/auth.
+var Operations = aliased(openapi.Operations{
+	"POST /api/v1/auth/login": {
+		Summary:  "Log in with email and password",
+		Request:  model.LoginInput{},
+		Response: model.AuthResponse{},
+	},
+	"POST /api/v1/auth/logout": {
+		Summary: "Revoke a refresh token",
+	},
+	"POST /api/v1/auth/refresh": {
+		Summary:  "Exchange a refresh token for new tokens",
+		Request:  model.RefreshTokenInput{},
+		Response: model.AuthResponse{},
+	},
+	"POST /api/v1/auth/forgot-password": {
+		Summary: "Email a password reset link",
+		Request: model.ForgotPasswordInput{},
+	},
+	"POST /api/v1/auth/reset-password": {
+		Summary: "Reset a password with a reset token",
+		Request: model.ResetPasswordInput{},
+	},
+	"POST /api/v1/auth/validate": {
+		Summary:  "Validate an access token",
+		Response: model.TokenValidation{},
+	},
+	"GET /api/v1/auth/me": {
+		Summary:  "Get the caller's user",
+		Response: model.User{},
+	},
+	"PUT /api/v1/auth/profile": {
+		Summary:  "Update the caller's profile",
+		Request:  model.UpdateProfileInput{},
+		Response: model.User{},
+	},
+	"PUT /api/v1/auth/password": {
+		Summary: "Change the caller's password",
+		Request: model.ChangePasswordInput{},
+	},
+	"POST /api/v1/auth/logout-all": {
+		Summary: "Revoke all of the caller's sessions",
+	},
+})
+
+// aliased adds each operation under the /auth alias of its route
+func aliased(ops openapi.Operations) openapi.Operations {
+	all := make(openapi.Operations, 2*len(ops))
+	for route, op := range ops {
+		all[route] = op
+		all[strings.Replace(route, "/api/v1/auth", "/auth", 1)] = op
+	}
+	return all
+}
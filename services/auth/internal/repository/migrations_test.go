@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/navo/pkg/migrate/migratetest"
+	"github.com/navo/services/auth/migrations"
+)
+
+// TestMigrations applies the migrations to the database in TEST_DATABASE_URL
+// and checks this package's queries against the schema they create
+func TestMigrations(t *testing.T) {
+	migratetest.Run(t, migratetest.Options{
+		Service:    "auth",
+		Migrations: migrations.FS,
+		External: []string{
+			"users",
+			"organizations",
+		},
+	})
+}
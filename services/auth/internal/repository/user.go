@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/navo/services/auth/internal/model"
@@ -265,50 +264,3 @@ func (r *UserRepository) DeleteExpiredSessions(ctx context.Context) (int64, erro
 	}
 	return result.RowsAffected()
 }
-
-// EnsureTablesExist creates the required tables if they don't exist
-func (r *UserRepository) EnsureTablesExist(ctx context.Context) error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS login_attempts (
-			id VARCHAR(255) PRIMARY KEY,
-			user_id VARCHAR(255),
-			email VARCHAR(255) NOT NULL,
-			ip_address VARCHAR(45),
-			user_agent TEXT,
-			success BOOLEAN NOT NULL DEFAULT false,
-			fail_reason VARCHAR(255),
-			created_at TIMESTAMP NOT NULL DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_login_attempts_email_created ON login_attempts(email, created_at)`,
-
-		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
-			id VARCHAR(255) PRIMARY KEY,
-			user_id VARCHAR(255) NOT NULL,
-			token VARCHAR(255) NOT NULL UNIQUE,
-			expires_at TIMESTAMP NOT NULL,
-			used_at TIMESTAMP,
-			created_at TIMESTAMP NOT NULL DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_token ON password_reset_tokens(token)`,
-
-		`CREATE TABLE IF NOT EXISTS sessions (
-			id VARCHAR(255) PRIMARY KEY,
-			user_id VARCHAR(255) NOT NULL,
-			refresh_token VARCHAR(512) NOT NULL,
-			ip_address VARCHAR(45),
-			user_agent TEXT,
-			expires_at TIMESTAMP NOT NULL,
-			created_at TIMESTAMP NOT NULL DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_refresh_token ON sessions(refresh_token)`,
-	}
-
-	for _, query := range queries {
-		if _, err := r.db.ExecContext(ctx, query); err != nil {
-			return fmt.Errorf("failed to create table: %w", err)
-		}
-	}
-
-	return nil
-}
@@ -5,15 +5,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/navo/pkg/audit"
 	"github.com/navo/pkg/auth"
 	"github.com/navo/pkg/database"
+	"github.com/navo/pkg/features"
+	"github.com/navo/pkg/idempotency"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/metrics"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/pkg/redis"
+	"github.com/navo/pkg/storage"
 	"github.com/navo/services/core/internal/handler"
 	"github.com/navo/services/core/internal/middleware"
 	"github.com/navo/services/core/internal/repository"
@@ -39,6 +47,13 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(ctx, observability.DefaultConfig("core"))
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
 	// Connect to PostgreSQL (returns *pgxpool.Pool)
 	pool, err := database.Connect(ctx, database.DefaultConfig())
 	if err != nil {
@@ -46,6 +61,31 @@ func main() {
 	}
 	defer database.Close()
 
+	// Audit trail, hash chained per organization. Checkpoints of each chain
+	// are signed when AUDIT_SIGNING_KEY (base64 Ed25519 key) is set.
+	auditCfg := audit.DefaultDBLoggerConfig()
+	if key := os.Getenv("AUDIT_SIGNING_KEY"); key != "" {
+		auditCfg.CheckpointKey, err = audit.ParseSigningKey(key)
+		if err != nil {
+			log.Fatal("Failed to parse audit signing key", zap.Error(err))
+		}
+	} else {
+		log.Warn("AUDIT_SIGNING_KEY not set, audit checkpoints will not be signed")
+	}
+	if v, err := time.ParseDuration(os.Getenv("AUDIT_CHECKPOINT_INTERVAL")); err == nil {
+		auditCfg.CheckpointInterval = v
+	}
+	auditLogger := audit.NewDBLogger(pool, auditCfg)
+	defer auditLogger.Close()
+
+	// verify-audit command: verify an organization's audit trail and exit
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		code := verifyAudit(ctx, auditLogger, os.Args[2:])
+		auditLogger.Close()
+		database.Close()
+		os.Exit(code)
+	}
+
 	// Convert to *sql.DB for repositories and RLS
 	db := database.GetStdLib(pool)
 
@@ -56,29 +96,99 @@ func main() {
 	}
 	defer redis.Close()
 
+	appMetrics := metrics.New(metrics.Config{Subsystem: "core"})
+
+	// Feature flags, with evaluations counted in the metrics
+	flagSvc := features.NewDBService(pool, redisClient, &features.DBServiceConfig{
+		CacheTTL: time.Minute,
+		Metrics:  appMetrics,
+	})
+	if err := features.InitializeDefaultFlags(ctx, flagSvc); err != nil {
+		log.Warn("Failed to initialize default feature flags", zap.Error(err))
+	}
+
 	// Initialize repositories
 	portCallRepo := repository.NewPortCallRepository(db)
 	serviceOrderRepo := repository.NewServiceOrderRepository(db)
 	rfqRepo := repository.NewRFQRepository(db)
 	workspaceRepo := repository.NewWorkspaceRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+
+	// Document storage for invoice files (optional)
+	var documentSvc *storage.DocumentService
+	if bucket := os.Getenv("AWS_S3_BUCKET"); bucket != "" {
+		s3Storage, err := storage.NewS3Storage(ctx, storage.S3Config{
+			Region:          os.Getenv("AWS_REGION"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			Endpoint:        os.Getenv("AWS_S3_ENDPOINT"),
+			UsePathStyle:    os.Getenv("AWS_S3_ENDPOINT") != "",
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize document storage", zap.Error(err))
+		}
+		documentSvc = storage.NewDocumentService(s3Storage)
+	} else {
+		log.Warn("AWS_S3_BUCKET not set, uploaded invoice files will not be stored")
+	}
+
+	// Invoice matching tolerances
+	invoiceTolerance := service.DefaultInvoiceMatchTolerance
+	if v, err := strconv.ParseFloat(os.Getenv("INVOICE_MATCH_TOLERANCE_AMOUNT"), 64); err == nil {
+		invoiceTolerance.Amount = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("INVOICE_MATCH_TOLERANCE_PERCENT"), 64); err == nil {
+		invoiceTolerance.Percent = v
+	}
+
+	// The integration service keeps the port registry new port calls are
+	// checked against
+	integrationURL := os.Getenv("INTEGRATION_SERVICE_URL")
+	if integrationURL == "" {
+		integrationURL = "http://localhost:4008"
+	}
 
 	// Initialize services
-	portCallSvc := service.NewPortCallService(portCallRepo, redisClient)
-	serviceOrderSvc := service.NewServiceOrderService(serviceOrderRepo, redisClient)
-	rfqSvc := service.NewRFQService(rfqRepo, redisClient)
+	portCallSvc := service.NewPortCallServiceWithConfig(portCallRepo, redisClient, &service.PortCallServiceConfig{
+		AuditLogger: auditLogger,
+		Ports:       service.NewIntegrationClient(integrationURL),
+	})
+	serviceOrderSvc := service.NewServiceOrderService(serviceOrderRepo, redisClient).WithAuditLogger(auditLogger)
+	rfqSvc := service.NewRFQService(rfqRepo, redisClient).WithAuditLogger(auditLogger)
 	workspaceSvc := service.NewWorkspaceService(workspaceRepo, redisClient)
+	invoiceSvc := service.NewInvoiceService(invoiceRepo, serviceOrderRepo, invoiceTolerance).WithAuditLogger(auditLogger)
 
 	// Initialize handlers
 	portCallHandler := handler.NewPortCallHandler(portCallSvc)
 	serviceOrderHandler := handler.NewServiceOrderHandler(serviceOrderSvc)
 	rfqHandler := handler.NewRFQHandler(rfqSvc)
 	workspaceHandler := handler.NewWorkspaceHandler(workspaceSvc)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceSvc, documentSvc)
+	auditHandler := handler.NewAuditHandler(service.NewAuditService(auditLogger))
+	featureHandler := handler.NewFeatureHandler(service.NewFeatureService(flagSvc, organizationRepo))
+
+	// Retried POST and PUT requests with an Idempotency-Key get the first
+	// response instead of running twice
+	idempotent := idempotency.Middleware(idempotency.NewRedisStore(redisClient), idempotency.DefaultConfig("core"))
+
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Core Service",
+		Description: "Port calls, service orders, RFQs, invoices, audit trail and feature flags",
+		Envelope:    true,
+	}, handler.Operations)
 
 	// Setup router
 	r := chi.NewRouter()
 	r.Use(chimiddleware.RequestID)
+	r.Use(observability.HTTPMiddleware) // Continues the gateway's trace
 	r.Use(chimiddleware.Recoverer)
 	r.Use(middleware.ExtractUserContext)   // Extract user context from gateway headers
+	r.Use(api.Validate)                    // Reject requests not matching the OpenAPI document
+	r.Use(idempotent)                      // Replay retried requests
 	r.Use(middleware.TransactionalRLS(db)) // Enforce RLS via transaction
 
 	// Health check
@@ -87,52 +197,24 @@ func main() {
 		w.Write([]byte(`{"status":"healthy"}`))
 	})
 
-	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Workspaces
-		r.Route("/workspaces", func(r chi.Router) {
-			r.Get("/", workspaceHandler.List)
-			r.Post("/", workspaceHandler.Create)
-			r.Get("/{id}", workspaceHandler.Get)
-			r.Put("/{id}", workspaceHandler.Update)
-			r.Delete("/{id}", workspaceHandler.Delete)
-		})
+	// Prometheus metrics
+	r.Handle("/metrics", metrics.Handler())
 
-		// Port Calls
-		r.Route("/port-calls", func(r chi.Router) {
-			r.Get("/", portCallHandler.List)
-			r.Post("/", portCallHandler.Create)
-			r.Get("/{id}", portCallHandler.Get)
-			r.Put("/{id}", portCallHandler.Update)
-			r.Delete("/{id}", portCallHandler.Delete)
-			r.Get("/{id}/services", portCallHandler.ListServices)
-			r.Post("/{id}/services", serviceOrderHandler.Create)
-			r.Get("/{id}/timeline", portCallHandler.Timeline)
-		})
-
-		// Service Orders
-		r.Route("/service-orders", func(r chi.Router) {
-			r.Get("/", serviceOrderHandler.List)
-			r.Get("/{id}", serviceOrderHandler.Get)
-			r.Put("/{id}", serviceOrderHandler.Update)
-			r.Delete("/{id}", serviceOrderHandler.Delete)
-			r.Post("/{id}/confirm", serviceOrderHandler.Confirm)
-			r.Post("/{id}/complete", serviceOrderHandler.Complete)
-		})
-
-		// RFQs
-		r.Route("/rfqs", func(r chi.Router) {
-			r.Get("/", rfqHandler.List)
-			r.Post("/", rfqHandler.Create)
-			r.Get("/{id}", rfqHandler.Get)
-			r.Put("/{id}", rfqHandler.Update)
-			r.Delete("/{id}", rfqHandler.Delete)
-			r.Post("/{id}/send", rfqHandler.Send)
-			r.Get("/{id}/quotes", rfqHandler.ListQuotes)
-			r.Post("/{id}/award/{quoteId}", rfqHandler.Award)
-		})
+	// API routes
+	handler.Routes(r, handler.Handlers{
+		Workspaces:    workspaceHandler,
+		PortCalls:     portCallHandler,
+		ServiceOrders: serviceOrderHandler,
+		Invoices:      invoiceHandler,
+		RFQs:          rfqHandler,
+		Audit:         auditHandler,
+		Features:      featureHandler,
 	})
 
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         ":" + port,
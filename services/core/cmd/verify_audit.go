@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/navo/pkg/audit"
+)
+
+// verifyAudit runs the verify-audit command: it verifies an organization's
+// audit trail, prints the result as JSON and returns the exit code, 1 when
+// the trail is broken.
+//
+//	core verify-audit -org <organization id> [-from <sequence>] [-to <sequence>]
+func verifyAudit(ctx context.Context, verifier audit.Verifier, args []string) int {
+	flags := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	orgID := flags.String("org", "", "organization whose audit trail to verify")
+	from := flags.Int64("from", 1, "first sequence to verify")
+	to := flags.Int64("to", 0, "last sequence to verify, 0 for the latest")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *orgID == "" {
+		fmt.Fprintln(os.Stderr, "verify-audit: -org is required")
+		flags.Usage()
+		return 2
+	}
+
+	result, err := verifier.Verify(ctx, *orgID, *from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify-audit: %v\n", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
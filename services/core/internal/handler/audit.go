@@ -0,0 +1,250 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/audit"
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/core/internal/middleware"
+	"github.com/navo/services/core/internal/service"
+	"go.uber.org/zap"
+)
+
+// AuditHandler handles audit trail HTTP requests. All of them are admin
+// only and scoped to the caller's organization.
+type AuditHandler struct {
+	svc *service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(svc *service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// List handles GET /api/v1/audit/events
+//
+// Filters: user_id, workspace_id, action, entity_type, entity_id, status,
+// q (free text in metadata), start_time and end_time (RFC 3339).
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, ok := adminOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && page > 0 {
+		filter.Page = page
+	}
+	if perPage, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && perPage > 0 && perPage <= 100 {
+		filter.PerPage = perPage
+	}
+
+	result, err := h.svc.Query(ctx, orgID, filter)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, result.Events, &response.Meta{
+		Page:    result.Page,
+		PerPage: result.PerPage,
+		Total:   result.Total,
+	})
+}
+
+// Get handles GET /api/v1/audit/events/{id}
+func (h *AuditHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, ok := adminOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := h.svc.Get(ctx, orgID, chi.URLParam(r, "id"))
+	if stderrors.Is(err, audit.ErrEventNotFound) {
+		response.NotFound(w, "audit event")
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.OK(w, event)
+}
+
+// Export handles GET /api/v1/audit/export
+//
+// Streams every event matching the List filters as CSV (format=csv, the
+// default) or NDJSON (format=ndjson). The export itself is audited.
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, ok := adminOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	format, err := audit.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	// Exports may outlast the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="audit-`+time.Now().UTC().Format("20060102-150405")+`.`+string(format)+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	count, err := h.svc.Export(ctx, orgID, middleware.GetUserID(ctx), filter, format, w)
+	if err != nil {
+		// The response has started, so the export is cut short
+		logger.Error("Audit export failed",
+			zap.String("organization_id", orgID),
+			zap.Int64("events", count),
+			zap.Error(err))
+	}
+}
+
+// History handles GET /api/v1/audit/history/{entityType}/{entityId}
+//
+// Returns the audited actions on a port call, RFQ or vendor, oldest first,
+// with the fields each one changed.
+func (h *AuditHandler) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, ok := adminOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	entityType := audit.EntityType(chi.URLParam(r, "entityType"))
+	history, err := h.svc.History(ctx, orgID, entityType, chi.URLParam(r, "entityId"))
+	if stderrors.Is(err, service.ErrNoHistory) {
+		response.BadRequest(w, "history is available for port_call, rfq and vendor")
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.OK(w, history)
+}
+
+// Verify handles GET /api/v1/audit/verify
+//
+// Walks the organization's audit trail from sequence "from" (default 1) to
+// "to" (default the latest) and reports the first broken link.
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, ok := adminOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := sequenceParam(r, "from")
+	if err != nil {
+		response.BadRequest(w, "invalid from sequence")
+		return
+	}
+	to, err := sequenceParam(r, "to")
+	if err != nil {
+		response.BadRequest(w, "invalid to sequence")
+		return
+	}
+	if to != 0 && to < from {
+		response.BadRequest(w, "to must not be before from")
+		return
+	}
+
+	result, err := h.svc.Verify(ctx, orgID, from, to)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, result)
+}
+
+// adminOrganization returns the caller's organization, responding with an
+// error unless the caller is an administrator
+func adminOrganization(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ctx := r.Context()
+
+	orgID := middleware.GetOrganizationID(ctx)
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return "", false
+	}
+	if !middleware.IsAdmin(ctx) {
+		response.Error(w, errors.NewForbidden("only administrators can access the audit trail"))
+		return "", false
+	}
+	return orgID, true
+}
+
+// auditFilter parses the audit event filters of a request
+func auditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	filter := audit.Filter{
+		UserID:      q.Get("user_id"),
+		WorkspaceID: q.Get("workspace_id"),
+		Action:      audit.Action(q.Get("action")),
+		EntityType:  audit.EntityType(q.Get("entity_type")),
+		EntityID:    q.Get("entity_id"),
+		Status:      q.Get("status"),
+		Search:      q.Get("q"),
+	}
+
+	if v := q.Get("start_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, stderrors.New("invalid start_time, expected RFC 3339")
+		}
+		filter.StartTime = t
+	}
+	if v := q.Get("end_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, stderrors.New("invalid end_time, expected RFC 3339")
+		}
+		filter.EndTime = t
+	}
+	return filter, nil
+}
+
+// sequenceParam parses an optional non-negative sequence query parameter
+func sequenceParam(r *http.Request, name string) (int64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
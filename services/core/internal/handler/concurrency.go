@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/core/internal/service"
+)
+
+// setETag exposes an entity's version as its ETag, to be sent back in
+// If-Match on update
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// expectedVersion returns the version an update is based on. An If-Match
+// header takes precedence over the version in the request body; "*" matches
+// any version, for clients that mean to overwrite. Updates giving neither
+// are refused, so concurrent edits can't silently overwrite each other.
+func expectedVersion(r *http.Request, bodyVersion *int) (*int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if bodyVersion == nil {
+			return nil, errors.NewPreconditionRequired("updates must give the version they are based on, in an If-Match header or the request body")
+		}
+		return bodyVersion, nil
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return nil, errors.NewBadRequest("invalid If-Match header")
+	}
+	return &version, nil
+}
+
+// respondConflict writes a 409 with the entity's current state when err is a
+// version conflict, and reports whether it did
+func respondConflict(w http.ResponseWriter, err error) bool {
+	conflict, ok := service.AsConflict(err)
+	if !ok {
+		return false
+	}
+	setETag(w, conflict.Version)
+	response.Conflict(w, conflict.Error(), conflict.Current)
+	return true
+}
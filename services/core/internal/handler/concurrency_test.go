@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/navo/pkg/errors"
+	"github.com/navo/services/core/internal/model"
+	"github.com/navo/services/core/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		bodyVersion *int
+		want        *int
+		status      int
+	}{
+		{"body version", "", intPtr(3), intPtr(3), 0},
+		{"If-Match", `"5"`, nil, intPtr(5), 0},
+		{"weak If-Match", `W/"5"`, nil, intPtr(5), 0},
+		{"If-Match takes precedence", `"5"`, intPtr(3), intPtr(5), 0},
+		{"any version", "*", intPtr(3), nil, 0},
+		{"no version", "", nil, nil, http.StatusPreconditionRequired},
+		{"invalid If-Match", `"abc"`, nil, nil, http.StatusBadRequest},
+		{"zero If-Match", `"0"`, nil, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/v1/port-calls/pc-1", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			version, err := expectedVersion(r, tt.bodyVersion)
+			if tt.status != 0 {
+				var appErr *errors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.status, appErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+}
+
+func TestRespondConflict(t *testing.T) {
+	rec := httptest.NewRecorder()
+	assert.False(t, respondConflict(rec, errors.NewBadRequest("invalid")))
+
+	current := &model.RFQ{ID: "rfq-1", Version: 4}
+	rec = httptest.NewRecorder()
+	require.True(t, respondConflict(rec, &service.ConflictError{Entity: "RFQ", Version: 4, Current: current}))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	var body struct {
+		Data  model.RFQ `json:"data"`
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "rfq-1", body.Data.ID)
+	assert.Equal(t, 4, body.Data.Version)
+	assert.Equal(t, errors.CodeConflict, body.Error.Code)
+}
+
+func intPtr(i int) *int { return &i }
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/features"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/core/internal/middleware"
+	"github.com/navo/services/core/internal/service"
+)
+
+// platformAdminRole may manage feature flags. Flags apply to every
+// organization, so an organization's own administrators may not.
+const platformAdminRole = "platform_admin"
+
+// FeatureHandler handles feature flag HTTP requests
+type FeatureHandler struct {
+	svc *service.FeatureService
+}
+
+// NewFeatureHandler creates a new feature handler
+func NewFeatureHandler(svc *service.FeatureService) *FeatureHandler {
+	return &FeatureHandler{svc: svc}
+}
+
+// Evaluate handles GET /api/v1/features
+//
+// Returns every flag evaluated for the caller, with the served variant.
+func (h *FeatureHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response.OK(w, h.svc.EvaluateAll(ctx, evalContext(r)))
+}
+
+// EvaluateOne handles GET /api/v1/features/{key}
+func (h *FeatureHandler) EvaluateOne(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response.OK(w, h.svc.Evaluate(ctx, chi.URLParam(r, "key"), evalContext(r)))
+}
+
+// List handles GET /api/v1/admin/feature-flags
+func (h *FeatureHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	flags, err := h.svc.List(ctx)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.OK(w, flags)
+}
+
+// Get handles GET /api/v1/admin/feature-flags/{key}
+func (h *FeatureHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	flag, err := h.svc.Get(ctx, chi.URLParam(r, "key"))
+	if stderrors.Is(err, features.ErrFlagNotFound) {
+		response.NotFound(w, "feature flag")
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.OK(w, flag)
+}
+
+// Create handles POST /api/v1/admin/feature-flags
+func (h *FeatureHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	var flag features.Flag
+	if err := json.NewDecoder(r.Body).Decode(&flag); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	if existing, err := h.svc.Get(ctx, flag.Key); err == nil {
+		response.Conflict(w, "feature flag already exists", existing)
+		return
+	}
+	h.save(w, r, flag, http.StatusCreated)
+}
+
+// Update handles PUT /api/v1/admin/feature-flags/{key}
+//
+// Replaces the flag's definition with the request body.
+func (h *FeatureHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	var flag features.Flag
+	if err := json.NewDecoder(r.Body).Decode(&flag); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	flag.Key = chi.URLParam(r, "key")
+	h.save(w, r, flag, http.StatusOK)
+}
+
+// save saves a flag for an administrator
+func (h *FeatureHandler) save(w http.ResponseWriter, r *http.Request, flag features.Flag, status int) {
+	ctx := r.Context()
+
+	err := h.svc.Save(ctx, flag, middleware.GetUserID(ctx))
+	if stderrors.Is(err, service.ErrInvalidFlag) {
+		response.Error(w, errors.NewValidation(err.Error()))
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.JSON(w, status, flag)
+}
+
+// Delete handles DELETE /api/v1/admin/feature-flags/{key}
+func (h *FeatureHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	err := h.svc.Delete(ctx, chi.URLParam(r, "key"), middleware.GetUserID(ctx))
+	if stderrors.Is(err, features.ErrFlagNotFound) {
+		response.NotFound(w, "feature flag")
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// History handles GET /api/v1/admin/feature-flags/{key}/history
+func (h *FeatureHandler) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !requirePlatformAdmin(w, r) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	changes, err := h.svc.History(ctx, chi.URLParam(r, "key"), limit)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.OK(w, changes)
+}
+
+// evalContext builds the caller's flag evaluation context
+func evalContext(r *http.Request) features.EvalContext {
+	ctx := r.Context()
+	return features.EvalContext{
+		OrganizationID: middleware.GetOrganizationID(ctx),
+		WorkspaceID:    middleware.GetWorkspaceID(ctx),
+		UserID:         middleware.GetUserID(ctx),
+		PortalType:     middleware.GetPortalType(ctx),
+		Roles:          middleware.GetUserRoles(ctx),
+	}
+}
+
+// requirePlatformAdmin responds with an error unless the caller is a
+// platform administrator
+func requirePlatformAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !middleware.HasRole(r.Context(), platformAdminRole) {
+		response.Error(w, errors.NewForbidden("platform administrator role required"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/response"
+	"github.com/navo/pkg/storage"
+	"github.com/navo/services/core/internal/middleware"
+	"github.com/navo/services/core/internal/model"
+	"github.com/navo/services/core/internal/service"
+)
+
+const maxInvoiceSize = 20 * 1024 * 1024 // 20MB
+
+// invoiceApproverRole may approve and reject invoices, as may administrators
+const invoiceApproverRole = "approver"
+
+// InvoiceHandler handles vendor invoice HTTP requests
+type InvoiceHandler struct {
+	svc       *service.InvoiceService
+	documents *storage.DocumentService
+}
+
+// NewInvoiceHandler creates a new invoice handler. documents may be nil, in
+// which case uploaded invoice files are parsed but not stored.
+func NewInvoiceHandler(svc *service.InvoiceService, documents *storage.DocumentService) *InvoiceHandler {
+	return &InvoiceHandler{
+		svc:       svc,
+		documents: documents,
+	}
+}
+
+// Create handles POST /api/v1/service-orders/{id}/invoices
+//
+// Accepts a UBL 2.1 XML document (application/xml), a JSON invoice, or a
+// multipart form with a "file" field holding a UBL XML or PDF invoice. PDF
+// invoices carry their totals in form fields (invoice_number, currency,
+// subtotal, tax_amount, total, issue_date, due_date).
+func (h *InvoiceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceOrderID := chi.URLParam(r, "id")
+
+	// Get user context
+	userID := middleware.GetUserID(ctx)
+	orgID := middleware.GetOrganizationID(ctx)
+	if userID == "" {
+		response.Error(w, errors.NewUnauthorized("user not authenticated"))
+		return
+	}
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInvoiceSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var input *model.CreateInvoiceInput
+	var err error
+	switch {
+	case mediaType == "multipart/form-data":
+		input, err = h.parseMultipart(r, serviceOrderID, orgID, userID)
+	case isXMLContentType(mediaType):
+		input, err = service.ParseUBLInvoice(r.Body)
+	default:
+		input = &model.CreateInvoiceInput{}
+		if decodeErr := json.NewDecoder(r.Body).Decode(input); decodeErr != nil {
+			response.BadRequest(w, "invalid request body")
+			return
+		}
+		input.Source = model.InvoiceSourceManual
+	}
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			response.Error(w, appErr)
+			return
+		}
+		response.Error(w, errors.NewBadRequest(err.Error()))
+		return
+	}
+
+	invoice, err := h.svc.Create(ctx, serviceOrderID, *input, userID, orgID)
+	if err != nil {
+		if err.Error() == "service order not found" {
+			response.NotFound(w, "service order")
+			return
+		}
+		response.Error(w, errors.NewBadRequest(err.Error()))
+		return
+	}
+
+	response.Created(w, invoice)
+}
+
+// parseMultipart reads an uploaded invoice file, stores it as an invoice
+// document and returns the invoice input
+func (h *InvoiceHandler) parseMultipart(r *http.Request, serviceOrderID, orgID, userID string) (*model.CreateInvoiceInput, error) {
+	if err := r.ParseMultipartForm(maxInvoiceSize); err != nil {
+		return nil, errors.NewBadRequest("file too large or invalid form data")
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.NewBadRequest("no file provided")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.NewBadRequest("failed to read file")
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = storage.GetContentTypeFromFilename(header.Filename)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var input *model.CreateInvoiceInput
+	switch {
+	case isXMLContentType(mediaType) || strings.HasSuffix(strings.ToLower(header.Filename), ".xml"):
+		contentType = "application/xml"
+		input, err = service.ParseUBLInvoice(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+	case mediaType == "application/pdf":
+		input, err = invoiceInputFromForm(r)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.NewBadRequest("invoice must be a UBL XML or PDF file")
+	}
+
+	if h.documents != nil {
+		doc, err := h.documents.UploadDocument(r.Context(), storage.UploadOptions{
+			OrganizationID: orgID,
+			Type:           storage.DocumentTypeInvoice,
+			EntityID:       serviceOrderID,
+			Name:           input.InvoiceNumber,
+			OriginalName:   header.Filename,
+			Reader:         bytes.NewReader(data),
+			ContentType:    contentType,
+			Size:           int64(len(data)),
+			Metadata: map[string]string{
+				"service_order_id": serviceOrderID,
+				"invoice_number":   input.InvoiceNumber,
+			},
+			UploadedBy: userID,
+		})
+		if err != nil {
+			return nil, errors.NewInternal(err)
+		}
+		input.DocumentKey = &doc.StorageKey
+		input.DocumentURL = &doc.URL
+	}
+
+	return input, nil
+}
+
+// invoiceInputFromForm builds invoice input from form fields accompanying a PDF invoice
+func invoiceInputFromForm(r *http.Request) (*model.CreateInvoiceInput, error) {
+	input := &model.CreateInvoiceInput{
+		InvoiceNumber: r.FormValue("invoice_number"),
+		Currency:      r.FormValue("currency"),
+		Source:        model.InvoiceSourcePDF,
+	}
+
+	amounts := map[string]*float64{
+		"subtotal":   &input.Subtotal,
+		"tax_amount": &input.TaxAmount,
+		"total":      &input.Total,
+	}
+	for field, dest := range amounts {
+		value := r.FormValue(field)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.NewBadRequest(field + " must be a number")
+		}
+		*dest = v
+	}
+
+	dates := map[string]**time.Time{
+		"issue_date": &input.IssueDate,
+		"due_date":   &input.DueDate,
+	}
+	for field, dest := range dates {
+		value := r.FormValue(field)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, errors.NewBadRequest(field + " must be a date (YYYY-MM-DD)")
+		}
+		*dest = &t
+	}
+
+	if lines := r.FormValue("lines"); lines != "" {
+		if err := json.Unmarshal([]byte(lines), &input.Lines); err != nil {
+			return nil, errors.NewBadRequest("lines must be a JSON array")
+		}
+	}
+
+	return input, nil
+}
+
+func isXMLContentType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// ListByServiceOrder handles GET /api/v1/service-orders/{id}/invoices
+func (h *InvoiceHandler) ListByServiceOrder(w http.ResponseWriter, r *http.Request) {
+	serviceOrderID := chi.URLParam(r, "id")
+	filter := parseInvoiceFilter(r)
+	filter.ServiceOrderID = &serviceOrderID
+	h.list(w, r, filter)
+}
+
+// List handles GET /api/v1/invoices
+func (h *InvoiceHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, parseInvoiceFilter(r))
+}
+
+// ApprovalQueue handles GET /api/v1/invoices/approval-queue
+func (h *InvoiceHandler) ApprovalQueue(w http.ResponseWriter, r *http.Request) {
+	filter := parseInvoiceFilter(r)
+	status := model.InvoiceStatusPendingApproval
+	filter.Status = &status
+	h.list(w, r, filter)
+}
+
+func (h *InvoiceHandler) list(w http.ResponseWriter, r *http.Request, filter model.InvoiceFilter) {
+	ctx := r.Context()
+
+	orgID := middleware.GetOrganizationID(ctx)
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	result, err := h.svc.List(ctx, orgID, filter)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, result.Invoices, &response.Meta{
+		Page:    result.Page,
+		PerPage: result.PerPage,
+		Total:   int64(result.Total),
+	})
+}
+
+func parseInvoiceFilter(r *http.Request) model.InvoiceFilter {
+	filter := model.InvoiceFilter{
+		Page:    1,
+		PerPage: 20,
+	}
+
+	if page := r.URL.Query().Get("page"); page != "" {
+		if p, err := strconv.Atoi(page); err == nil && p > 0 {
+			filter.Page = p
+		}
+	}
+	if perPage := r.URL.Query().Get("per_page"); perPage != "" {
+		if pp, err := strconv.Atoi(perPage); err == nil && pp > 0 && pp <= 100 {
+			filter.PerPage = pp
+		}
+	}
+	if vendorID := r.URL.Query().Get("vendor_id"); vendorID != "" {
+		filter.VendorID = &vendorID
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		s := model.InvoiceStatus(status)
+		filter.Status = &s
+	}
+	if matchStatus := r.URL.Query().Get("match_status"); matchStatus != "" {
+		s := model.InvoiceMatchStatus(matchStatus)
+		filter.MatchStatus = &s
+	}
+
+	return filter
+}
+
+// Get handles GET /api/v1/invoices/{id}
+func (h *InvoiceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	orgID := middleware.GetOrganizationID(ctx)
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	invoice, err := h.svc.GetByID(ctx, id, orgID)
+	if err != nil {
+		invoiceError(w, err)
+		return
+	}
+
+	response.OK(w, invoice)
+}
+
+// Rematch handles POST /api/v1/invoices/{id}/match
+func (h *InvoiceHandler) Rematch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	// Get user context
+	userID := middleware.GetUserID(ctx)
+	orgID := middleware.GetOrganizationID(ctx)
+	if userID == "" {
+		response.Error(w, errors.NewUnauthorized("user not authenticated"))
+		return
+	}
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	invoice, err := h.svc.Rematch(ctx, id, userID, orgID)
+	if err != nil {
+		invoiceError(w, err)
+		return
+	}
+
+	response.OK(w, invoice)
+}
+
+// Approve handles POST /api/v1/invoices/{id}/approve
+func (h *InvoiceHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	if !requireInvoiceApprover(w, r) {
+		return
+	}
+
+	var input struct {
+		Notes *string `json:"notes"`
+	}
+	json.NewDecoder(r.Body).Decode(&input) // Optional body
+
+	// Get user context
+	userID := middleware.GetUserID(ctx)
+	orgID := middleware.GetOrganizationID(ctx)
+	if userID == "" {
+		response.Error(w, errors.NewUnauthorized("user not authenticated"))
+		return
+	}
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	invoice, err := h.svc.Approve(ctx, id, input.Notes, userID, orgID)
+	if err != nil {
+		invoiceError(w, err)
+		return
+	}
+
+	response.OK(w, invoice)
+}
+
+// Reject handles POST /api/v1/invoices/{id}/reject
+func (h *InvoiceHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	if !requireInvoiceApprover(w, r) {
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	// Get user context
+	userID := middleware.GetUserID(ctx)
+	orgID := middleware.GetOrganizationID(ctx)
+	if userID == "" {
+		response.Error(w, errors.NewUnauthorized("user not authenticated"))
+		return
+	}
+	if orgID == "" {
+		response.Error(w, errors.NewUnauthorized("organization context required"))
+		return
+	}
+
+	invoice, err := h.svc.Reject(ctx, id, input.Reason, userID, orgID)
+	if err != nil {
+		invoiceError(w, err)
+		return
+	}
+
+	response.OK(w, invoice)
+}
+
+// requireInvoiceApprover responds with an error unless the caller may
+// approve and reject invoices
+func requireInvoiceApprover(w http.ResponseWriter, r *http.Request) bool {
+	ctx := r.Context()
+	if !middleware.IsAdmin(ctx) && !middleware.HasRole(ctx, invoiceApproverRole) {
+		response.Error(w, errors.NewForbidden("invoice approver role required"))
+		return false
+	}
+	return true
+}
+
+// invoiceError responds with an invoice service error
+func invoiceError(w http.ResponseWriter, err error) {
+	if stderrors.Is(err, service.ErrInvoiceNotFound) {
+		response.NotFound(w, "invoice")
+		return
+	}
+	response.Error(w, errors.NewBadRequest(err.Error()))
+}
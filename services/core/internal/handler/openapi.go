@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/pkg/features"
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/core/internal/model"
+)
+
+var (
+	portCallStatuses     = []string{"draft", "planned", "confirmed", "arrived", "alongside", "departed", "completed", "cancelled"}
+	serviceOrderStatuses = []string{"draft", "requested", "rfq_sent", "quoted", "confirmed", "in_progress", "completed", "cancelled"}
+	rfqStatuses          = []string{"draft", "open", "closed", "awarded", "cancelled", "expired"}
+)
+
+// Operations describe what the core service's handlers read and write, for
+// its OpenAPI document and request validation
+var Operations = openapi.Operations{
+	// Workspaces
+	"GET /api/v1/workspaces": {
+		Summary: "List workspaces",
+	},
+	"POST /api/v1/workspaces": {
+		Summary: "Create a workspace",
+		Status:  http.StatusCreated,
+	},
+	"GET /api/v1/workspaces/{id}": {
+		Summary: "Get a workspace",
+	},
+	"PUT /api/v1/workspaces/{id}": {
+		Summary: "Update a workspace",
+	},
+	"DELETE /api/v1/workspaces/{id}": {
+		Summary: "Delete a workspace",
+	},
+
+	// Port calls
+	"GET /api/v1/port-calls": {
+		Summary: "List port calls",
+		Query: openapi.Paged(
+			openapi.Param{Name: "workspace_id"},
+			openapi.Param{Name: "vessel_id"},
+			openapi.Param{Name: "port_id"},
+			openapi.Param{Name: "status", Enum: portCallStatuses},
+		),
+		Response: []model.PortCall{},
+	},
+	"POST /api/v1/port-calls": {
+		Summary:     "Create a port call",
+		Description: "Fails with 422 when the vessel's draft or length exceeds the port's restrictions in the port registry, and with 503 when the registry can't be reached.",
+		Request:     model.CreatePortCallInput{},
+		Response:    model.PortCall{},
+		Status:      http.StatusCreated,
+	},
+	"GET /api/v1/port-calls/{id}": {
+		Summary:  "Get a port call",
+		Response: model.PortCall{},
+	},
+	"PUT /api/v1/port-calls/{id}": {
+		Summary:     "Update a port call",
+		Description: updateDescription("port call"),
+		Request:     model.UpdatePortCallInput{},
+		Response:    model.PortCall{},
+	},
+	"DELETE /api/v1/port-calls/{id}": {
+		Summary: "Delete a port call",
+	},
+	"GET /api/v1/port-calls/{id}/services": {
+		Summary:  "List a port call's service orders",
+		Response: []model.ServiceOrder{},
+	},
+	"POST /api/v1/port-calls/{id}/services": {
+		Summary:  "Order a service for a port call",
+		Tags:     []string{"service-orders"},
+		Request:  model.CreateServiceOrderInput{},
+		Omit:     []string{"port_call_id"},
+		Response: model.ServiceOrder{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/port-calls/{id}/timeline": {
+		Summary:  "Get a port call's timeline",
+		Response: []model.TimelineEvent{},
+	},
+	"POST /api/v1/port-calls/{id}/timeline": {
+		Summary:     "Add an event to a port call's timeline",
+		Description: "Adds a note unless event_type says otherwise. Other event types require the system role.",
+		Request:     model.AddTimelineEventInput{},
+		Response:    model.TimelineEvent{},
+		Status:      http.StatusCreated,
+	},
+
+	// Service orders
+	"GET /api/v1/service-orders": {
+		Summary: "List service orders",
+		Query: openapi.Paged(
+			openapi.Param{Name: "port_call_id"},
+			openapi.Param{Name: "vendor_id"},
+			openapi.Param{Name: "service_type_id"},
+			openapi.Param{Name: "status", Enum: serviceOrderStatuses},
+		),
+		Response: []model.ServiceOrder{},
+	},
+	"GET /api/v1/service-orders/{id}": {
+		Summary:  "Get a service order",
+		Response: model.ServiceOrder{},
+	},
+	"PUT /api/v1/service-orders/{id}": {
+		Summary:     "Update a service order",
+		Description: updateDescription("service order"),
+		Request:     model.UpdateServiceOrderInput{},
+		Response:    model.ServiceOrder{},
+	},
+	"DELETE /api/v1/service-orders/{id}": {
+		Summary: "Delete a service order",
+	},
+	"POST /api/v1/service-orders/{id}/confirm": {
+		Summary:  "Confirm a service order with a vendor",
+		Response: model.ServiceOrder{},
+	},
+	"POST /api/v1/service-orders/{id}/complete": {
+		Summary:  "Complete a service order",
+		Response: model.ServiceOrder{},
+	},
+	"GET /api/v1/service-orders/{id}/invoices": {
+		Summary:  "List a service order's invoices",
+		Tags:     []string{"invoices"},
+		Query:    openapi.Paged(),
+		Response: []model.Invoice{},
+	},
+	"POST /api/v1/service-orders/{id}/invoices": {
+		Summary:     "Submit an invoice for a service order",
+		Description: "Takes a JSON invoice, a UBL 2.1 document (application/xml), or a multipart form with a UBL or PDF file.",
+		Tags:        []string{"invoices"},
+		Request:     model.CreateInvoiceInput{},
+		Omit:        []string{"service_order_id"},
+		Response:    model.Invoice{},
+		Status:      http.StatusCreated,
+	},
+
+	// Invoices
+	"GET /api/v1/invoices": {
+		Summary: "List invoices",
+		Query: openapi.Paged(
+			openapi.Param{Name: "vendor_id"},
+			openapi.Param{Name: "status", Enum: []string{"pending_approval", "approved", "rejected"}},
+			openapi.Param{Name: "match_status", Enum: []string{"matched", "mismatched"}},
+		),
+		Response: []model.Invoice{},
+	},
+	"GET /api/v1/invoices/approval-queue": {
+		Summary:  "List invoices awaiting approval",
+		Query:    openapi.Paged(),
+		Response: []model.Invoice{},
+	},
+	"GET /api/v1/invoices/{id}": {
+		Summary:  "Get an invoice",
+		Response: model.Invoice{},
+	},
+	"POST /api/v1/invoices/{id}/match": {
+		Summary:  "Match an invoice against its service order again",
+		Response: model.Invoice{},
+	},
+	"POST /api/v1/invoices/{id}/approve": {
+		Summary:  "Approve an invoice",
+		Response: model.Invoice{},
+	},
+	"POST /api/v1/invoices/{id}/reject": {
+		Summary:  "Reject an invoice",
+		Response: model.Invoice{},
+	},
+
+	// RFQs
+	"GET /api/v1/rfqs": {
+		Summary: "List RFQs",
+		Query: openapi.Paged(
+			openapi.Param{Name: "port_call_id"},
+			openapi.Param{Name: "service_type_id"},
+			openapi.Param{Name: "status", Enum: rfqStatuses},
+		),
+		Response: []model.RFQ{},
+	},
+	"POST /api/v1/rfqs": {
+		Summary:  "Create an RFQ",
+		Request:  model.CreateRFQInput{},
+		Response: model.RFQ{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/rfqs/{id}": {
+		Summary:  "Get an RFQ",
+		Response: model.RFQ{},
+	},
+	"PUT /api/v1/rfqs/{id}": {
+		Summary:     "Update an RFQ",
+		Description: updateDescription("RFQ"),
+		Request:     model.UpdateRFQInput{},
+		Response:    model.RFQ{},
+	},
+	"DELETE /api/v1/rfqs/{id}": {
+		Summary: "Delete an RFQ",
+	},
+	"POST /api/v1/rfqs/{id}/send": {
+		Summary:  "Send an RFQ to vendors",
+		Response: model.RFQ{},
+	},
+	"GET /api/v1/rfqs/{id}/quotes": {
+		Summary:  "List an RFQ's quotes",
+		Response: []model.Quote{},
+	},
+	"POST /api/v1/rfqs/{id}/quotes": {
+		Summary:  "Submit a quote",
+		Request:  model.SubmitQuoteInput{},
+		Response: model.Quote{},
+		Status:   http.StatusCreated,
+	},
+	"POST /api/v1/rfqs/{id}/award/{quoteId}": {
+		Summary:  "Award an RFQ to a quote",
+		Response: model.RFQ{},
+	},
+
+	// Audit trail
+	"GET /api/v1/audit/events": {
+		Summary: "List audit events",
+		Query: openapi.Paged(
+			openapi.Param{Name: "user_id"},
+			openapi.Param{Name: "workspace_id"},
+			openapi.Param{Name: "action"},
+			openapi.Param{Name: "entity_type"},
+			openapi.Param{Name: "entity_id"},
+			openapi.Param{Name: "status"},
+			openapi.Param{Name: "q", Description: "Text to search for"},
+			openapi.Param{Name: "start_time", Description: "RFC 3339 timestamp"},
+			openapi.Param{Name: "end_time", Description: "RFC 3339 timestamp"},
+		),
+		Response: []audit.Event{},
+	},
+	"GET /api/v1/audit/events/{id}": {
+		Summary:  "Get an audit event",
+		Response: audit.Event{},
+	},
+	"GET /api/v1/audit/export": {
+		Summary: "Export audit events",
+		Query:   []openapi.Param{{Name: "format", Enum: []string{"csv", "ndjson", "json"}}},
+	},
+	"GET /api/v1/audit/history/{entityType}/{entityId}": {
+		Summary:  "Get the change history of a port call, RFQ or vendor",
+		Response: model.EntityHistory{},
+	},
+	"GET /api/v1/audit/verify": {
+		Summary: "Verify the audit trail's hash chain",
+		Query: []openapi.Param{
+			{Name: "from", Type: "integer", Description: "First sequence number, 1 by default"},
+			{Name: "to", Type: "integer", Description: "Last sequence number, the latest by default"},
+		},
+		Response: audit.Verification{},
+	},
+
+	// Feature flags
+	"GET /api/v1/features": {
+		Summary:  "Evaluate all feature flags for the caller",
+		Response: map[string]features.Evaluation{},
+	},
+	"GET /api/v1/features/{key}": {
+		Summary:  "Evaluate a feature flag for the caller",
+		Response: features.Evaluation{},
+	},
+	"GET /api/v1/admin/feature-flags": {
+		Summary:     "List feature flags",
+		Description: "Requires the platform_admin role.",
+		Response:    []features.Flag{},
+	},
+	"POST /api/v1/admin/feature-flags": {
+		Summary:     "Create a feature flag",
+		Description: "Requires the platform_admin role.",
+		Request:     features.Flag{},
+		Response:    features.Flag{},
+		Status:      http.StatusCreated,
+	},
+	"GET /api/v1/admin/feature-flags/{key}": {
+		Summary:     "Get a feature flag",
+		Description: "Requires the platform_admin role.",
+		Response:    features.Flag{},
+	},
+	"PUT /api/v1/admin/feature-flags/{key}": {
+		Summary:     "Replace a feature flag",
+		Description: "Requires the platform_admin role.",
+		Request:     features.Flag{},
+		Omit:        []string{"key"},
+		Response:    features.Flag{},
+	},
+	"DELETE /api/v1/admin/feature-flags/{key}": {
+		Summary:     "Delete a feature flag",
+		Description: "Requires the platform_admin role.",
+		Status:      http.StatusNoContent,
+	},
+	"GET /api/v1/admin/feature-flags/{key}/history": {
+		Summary:     "List a feature flag's changes",
+		Description: "Requires the platform_admin role.",
+		Query:       []openapi.Param{{Name: "limit", Type: "integer"}},
+		Response:    []features.Change{},
+	},
+}
+
+// updateDescription describes the optimistic concurrency of an entity's
+// update
+func updateDescription(entity string) string {
+	return "Requires the version of the " + entity + " the update is based on, in an If-Match header or the body, and fails with 428 without one. " +
+		"Fails with a conflict when the " + entity + " changed since that version; If-Match: * updates whatever the current version."
+}
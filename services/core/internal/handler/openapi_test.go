@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validated serves requests validated against the core service's
+// operations, answering those that pass with 204
+func validated() http.Handler {
+	api := openapi.New(openapi.Config{Title: "Navo Core Service", Envelope: true}, Operations)
+	return api.Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+// TestOperations_MatchRoutes fails on routes without an operation, which
+// would skip request validation, and on operations no route serves
+func TestOperations_MatchRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	Routes(r, Handlers{})
+
+	api := openapi.New(openapi.Config{Title: "Navo Core Service"}, Operations)
+	require.NoError(t, chi.Walk(r, api.Walk))
+	undocumented, unrouted := api.Unmatched()
+	assert.Empty(t, undocumented, "routes missing from Operations")
+	assert.Empty(t, unrouted, "operations without a route")
+}
+
+func TestOperations_CreatePortCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		details map[string]string
+	}{
+		{
+			name:   "xid vessel, cuid port and slug workspace",
+			body:   `{"vessel_id": "9m4e2mr0ui3e8a215n4g", "port_id": "clh3am8ct0000qz8r9x4h2k1d", "workspace_id": "ws_demo"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "UUIDs",
+			body:   `{"vessel_id": "3f0c5a4e-8d2b-4c1f-9a6e-1b7d2e9f0a3c", "port_id": "c1700000000000000000", "workspace_id": "3f0c5a4e-8d2b-4c1f-9a6e-1b7d2e9f0a3d"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "missing and malformed IDs",
+			body:   `{"vessel_id": "", "port_id": "port/../1", "workspace_id": "ws_demo"}`,
+			status: http.StatusBadRequest,
+			details: map[string]string{
+				"vessel_id": "is required",
+				"port_id":   "must be a valid ID",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/port-calls", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			validated().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.details == nil {
+				return
+			}
+			var body struct {
+				Error struct {
+					Code    string            `json:"code"`
+					Details map[string]string `json:"details"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "VALIDATION_ERROR", body.Error.Code)
+			assert.Equal(t, tt.details, body.Error.Details)
+		})
+	}
+}
@@ -96,6 +96,14 @@ func (h *PortCallHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	portCall, err := h.svc.Create(ctx, input, userID)
 	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			// The request is well formed but breaks the port's restrictions
+			if appErr.Code == errors.CodeValidation {
+				appErr.StatusCode = http.StatusUnprocessableEntity
+			}
+			response.Error(w, appErr)
+			return
+		}
 		response.Error(w, errors.NewBadRequest(err.Error()))
 		return
 	}
@@ -114,6 +122,7 @@ func (h *PortCallHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, portCall.Version)
 	response.OK(w, portCall)
 }
 
@@ -127,13 +136,23 @@ func (h *PortCallHandler) Update(w http.ResponseWriter, r *http.Request) {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
+	version, err := expectedVersion(r, input.Version)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	input.Version = version
 
 	portCall, err := h.svc.Update(ctx, id, input)
 	if err != nil {
+		if respondConflict(w, err) {
+			return
+		}
 		response.Error(w, errors.NewBadRequest(err.Error()))
 		return
 	}
 
+	setETag(w, portCall.Version)
 	response.OK(w, portCall)
 }
 
@@ -164,6 +183,42 @@ func (h *PortCallHandler) ListServices(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, services)
 }
 
+// systemRole is held by other services calling the API. Only they may
+// record timeline events other than notes.
+const systemRole = "system"
+
+// AddTimelineEvent handles POST /api/v1/port-calls/{id}/timeline
+func (h *PortCallHandler) AddTimelineEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	var input model.AddTimelineEventInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		response.Unauthorized(w, "user context not found")
+		return
+	}
+	if input.EventType != "" && input.EventType != model.TimelineEventNoteAdded && !middleware.HasRole(ctx, systemRole) {
+		response.Error(w, errors.NewForbidden("only notes may be added to the timeline"))
+		return
+	}
+
+	// Invalid input and unknown port calls come back as AppErrors; anything
+	// else is an internal error
+	event, err := h.svc.AddTimelineEvent(ctx, id, input, userID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, event)
+}
+
 // Timeline handles GET /api/v1/port-calls/{id}/timeline
 func (h *PortCallHandler) Timeline(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
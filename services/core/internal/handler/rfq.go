@@ -103,6 +103,7 @@ func (h *RFQHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, rfq.Version)
 	response.OK(w, rfq)
 }
 
@@ -116,6 +117,12 @@ func (h *RFQHandler) Update(w http.ResponseWriter, r *http.Request) {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
+	version, err := expectedVersion(r, input.Version)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	input.Version = version
 
 	userID := middleware.GetUserID(ctx)
 	orgID := middleware.GetOrganizationID(ctx)
@@ -126,10 +133,14 @@ func (h *RFQHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	rfq, err := h.svc.Update(ctx, id, input, userID, orgID)
 	if err != nil {
+		if respondConflict(w, err) {
+			return
+		}
 		response.Error(w, errors.NewBadRequest(err.Error()))
 		return
 	}
 
+	setETag(w, rfq.Version)
 	response.OK(w, rfq)
 }
 
@@ -234,7 +245,12 @@ func (h *RFQHandler) SubmitQuote(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rfqID := chi.URLParam(r, "id")
 
-	var input model.SubmitQuoteInput
+	// vendor_id is sent alongside the quote (in real app, would get from
+	// vendor auth context)
+	var input struct {
+		model.SubmitQuoteInput
+		VendorID string `json:"vendor_id"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.BadRequest(w, "invalid request body")
 		return
@@ -247,19 +263,12 @@ func (h *RFQHandler) SubmitQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get vendor ID from request body or context
-	var vendorInput struct {
-		VendorID string `json:"vendor_id"`
-	}
-	// Re-parse to get vendor_id (in real app, would get from vendor auth context)
-	json.NewDecoder(r.Body).Decode(&vendorInput)
-
-	if vendorInput.VendorID == "" {
+	if input.VendorID == "" {
 		response.BadRequest(w, "vendor_id is required")
 		return
 	}
 
-	quote, err := h.svc.SubmitQuote(ctx, rfqID, vendorInput.VendorID, input, userID, orgID)
+	quote, err := h.svc.SubmitQuote(ctx, rfqID, input.VendorID, input.SubmitQuoteInput, userID, orgID)
 	if err != nil {
 		response.Error(w, errors.NewBadRequest(err.Error()))
 		return
@@ -0,0 +1,100 @@
+package handler
+
+import "github.com/go-chi/chi/v5"
+
+// Handlers are the handlers serving the core service's API
+type Handlers struct {
+	Workspaces    *WorkspaceHandler
+	PortCalls     *PortCallHandler
+	ServiceOrders *ServiceOrderHandler
+	Invoices      *InvoiceHandler
+	RFQs          *RFQHandler
+	Audit         *AuditHandler
+	Features      *FeatureHandler
+}
+
+// Routes registers the API routes. Each must have an entry in Operations,
+// which the tests check.
+func Routes(r chi.Router, h Handlers) {
+	r.Route("/api/v1", func(r chi.Router) {
+		// Workspaces
+		r.Route("/workspaces", func(r chi.Router) {
+			r.Get("/", h.Workspaces.List)
+			r.Post("/", h.Workspaces.Create)
+			r.Get("/{id}", h.Workspaces.Get)
+			r.Put("/{id}", h.Workspaces.Update)
+			r.Delete("/{id}", h.Workspaces.Delete)
+		})
+
+		// Port Calls
+		r.Route("/port-calls", func(r chi.Router) {
+			r.Get("/", h.PortCalls.List)
+			r.Post("/", h.PortCalls.Create)
+			r.Get("/{id}", h.PortCalls.Get)
+			r.Put("/{id}", h.PortCalls.Update)
+			r.Delete("/{id}", h.PortCalls.Delete)
+			r.Get("/{id}/services", h.PortCalls.ListServices)
+			r.Post("/{id}/services", h.ServiceOrders.Create)
+			r.Get("/{id}/timeline", h.PortCalls.Timeline)
+			r.Post("/{id}/timeline", h.PortCalls.AddTimelineEvent)
+		})
+
+		// Service Orders
+		r.Route("/service-orders", func(r chi.Router) {
+			r.Get("/", h.ServiceOrders.List)
+			r.Get("/{id}", h.ServiceOrders.Get)
+			r.Put("/{id}", h.ServiceOrders.Update)
+			r.Delete("/{id}", h.ServiceOrders.Delete)
+			r.Post("/{id}/confirm", h.ServiceOrders.Confirm)
+			r.Post("/{id}/complete", h.ServiceOrders.Complete)
+			r.Get("/{id}/invoices", h.Invoices.ListByServiceOrder)
+			r.Post("/{id}/invoices", h.Invoices.Create)
+		})
+
+		// Invoices
+		r.Route("/invoices", func(r chi.Router) {
+			r.Get("/", h.Invoices.List)
+			r.Get("/approval-queue", h.Invoices.ApprovalQueue)
+			r.Get("/{id}", h.Invoices.Get)
+			r.Post("/{id}/match", h.Invoices.Rematch)
+			r.Post("/{id}/approve", h.Invoices.Approve)
+			r.Post("/{id}/reject", h.Invoices.Reject)
+		})
+
+		// RFQs
+		r.Route("/rfqs", func(r chi.Router) {
+			r.Get("/", h.RFQs.List)
+			r.Post("/", h.RFQs.Create)
+			r.Get("/{id}", h.RFQs.Get)
+			r.Put("/{id}", h.RFQs.Update)
+			r.Delete("/{id}", h.RFQs.Delete)
+			r.Post("/{id}/send", h.RFQs.Send)
+			r.Get("/{id}/quotes", h.RFQs.ListQuotes)
+			r.Post("/{id}/quotes", h.RFQs.SubmitQuote)
+			r.Post("/{id}/award/{quoteId}", h.RFQs.Award)
+		})
+
+		// Audit trail
+		r.Route("/audit", func(r chi.Router) {
+			r.Get("/events", h.Audit.List)
+			r.Get("/events/{id}", h.Audit.Get)
+			r.Get("/export", h.Audit.Export)
+			r.Get("/history/{entityType}/{entityId}", h.Audit.History)
+			r.Get("/verify", h.Audit.Verify)
+		})
+
+		// Feature flags evaluated for the caller
+		r.Get("/features", h.Features.Evaluate)
+		r.Get("/features/{key}", h.Features.EvaluateOne)
+
+		// Feature flag administration
+		r.Route("/admin/feature-flags", func(r chi.Router) {
+			r.Get("/", h.Features.List)
+			r.Post("/", h.Features.Create)
+			r.Get("/{key}", h.Features.Get)
+			r.Put("/{key}", h.Features.Update)
+			r.Delete("/{key}", h.Features.Delete)
+			r.Get("/{key}/history", h.Features.History)
+		})
+	})
+}
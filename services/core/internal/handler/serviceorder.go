@@ -112,6 +112,7 @@ func (h *ServiceOrderHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, order.Version)
 	response.OK(w, order)
 }
 
@@ -125,6 +126,12 @@ func (h *ServiceOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
+	version, err := expectedVersion(r, input.Version)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	input.Version = version
 
 	// Get user context
 	userID := middleware.GetUserID(ctx)
@@ -136,10 +143,14 @@ func (h *ServiceOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	order, err := h.svc.Update(ctx, id, input, userID, orgID)
 	if err != nil {
+		if respondConflict(w, err) {
+			return
+		}
 		response.Error(w, errors.NewBadRequest(err.Error()))
 		return
 	}
 
+	setETag(w, order.Version)
 	response.OK(w, order)
 }
 
@@ -26,7 +26,7 @@ func TransactionalRLS(db *sql.DB) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip for health checks or public routes if needed
-			if r.URL.Path == "/health" {
+			if r.URL.Path == "/health" || r.URL.Path == "/openapi.json" {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/navo/pkg/audit"
+)
+
+// FieldChange is a change to one field of an entity. Nested fields are
+// named by their dotted path, e.g. "agent.name". Old is absent for added
+// fields and New for removed ones.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// HistoryEntry is an audited action on an entity and the fields it changed
+type HistoryEntry struct {
+	EventID   string        `json:"event_id"`
+	Sequence  int64         `json:"sequence,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	UserID    string        `json:"user_id"`
+	Action    audit.Action  `json:"action"`
+	Status    string        `json:"status"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// EntityHistory is the audited history of an entity, oldest first
+type EntityHistory struct {
+	EntityType audit.EntityType `json:"entity_type"`
+	EntityID   string           `json:"entity_id"`
+	Entries    []HistoryEntry   `json:"entries"`
+}
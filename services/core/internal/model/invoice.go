@@ -0,0 +1,147 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// InvoiceStatus represents the approval status of a vendor invoice
+type InvoiceStatus string
+
+const (
+	InvoiceStatusPendingApproval InvoiceStatus = "pending_approval"
+	InvoiceStatusApproved        InvoiceStatus = "approved"
+	InvoiceStatusRejected        InvoiceStatus = "rejected"
+)
+
+// InvoiceSource identifies how an invoice was received
+type InvoiceSource string
+
+const (
+	InvoiceSourceUBL    InvoiceSource = "ubl"
+	InvoiceSourcePDF    InvoiceSource = "pdf"
+	InvoiceSourceManual InvoiceSource = "manual"
+)
+
+// InvoiceMatchStatus represents the outcome of three-way matching
+type InvoiceMatchStatus string
+
+const (
+	InvoiceMatchStatusMatched    InvoiceMatchStatus = "matched"
+	InvoiceMatchStatusMismatched InvoiceMatchStatus = "mismatched"
+)
+
+// Invoice represents a vendor invoice for a service order
+type Invoice struct {
+	ID             string              `json:"id" db:"id"`
+	ServiceOrderID string              `json:"service_order_id" db:"service_order_id"`
+	OrganizationID string              `json:"organization_id" db:"organization_id"`
+	VendorID       *string             `json:"vendor_id,omitempty" db:"vendor_id"`
+	InvoiceNumber  string              `json:"invoice_number" db:"invoice_number"`
+	Source         InvoiceSource       `json:"source" db:"source"`
+	Status         InvoiceStatus       `json:"status" db:"status"`
+	IssueDate      *time.Time          `json:"issue_date,omitempty" db:"issue_date"`
+	DueDate        *time.Time          `json:"due_date,omitempty" db:"due_date"`
+	Currency       string              `json:"currency" db:"currency"`
+	Subtotal       float64             `json:"subtotal" db:"subtotal"`
+	TaxAmount      float64             `json:"tax_amount" db:"tax_amount"`
+	Total          float64             `json:"total" db:"total"`
+	Lines          []InvoiceLine       `json:"lines" db:"lines"`
+	SupplierName   *string             `json:"supplier_name,omitempty" db:"supplier_name"`
+	SupplierTaxID  *string             `json:"supplier_tax_id,omitempty" db:"supplier_tax_id"`
+	DocumentKey    *string             `json:"document_key,omitempty" db:"document_key"`
+	DocumentURL    *string             `json:"document_url,omitempty" db:"document_url"`
+	MatchStatus    InvoiceMatchStatus  `json:"match_status" db:"match_status"`
+	MatchResult    *InvoiceMatchResult `json:"match_result,omitempty" db:"match_result"`
+	ReviewedBy     *string             `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt     *time.Time          `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNotes    *string             `json:"review_notes,omitempty" db:"review_notes"`
+	CreatedBy      string              `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// InvoiceLine represents a single line on a vendor invoice
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit,omitempty"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// NetAmount returns the tax-exclusive amount used for matching
+func (i *Invoice) NetAmount() float64 {
+	if i.Subtotal > 0 {
+		return i.Subtotal
+	}
+	return i.Total - i.TaxAmount
+}
+
+// InvoiceMatchTolerance configures how far an invoice may deviate from the
+// reference prices before it needs approval. A difference is accepted when it
+// is within either the absolute amount or the percentage of the reference.
+type InvoiceMatchTolerance struct {
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
+}
+
+// Allows reports whether the difference between actual and expected is within tolerance
+func (t InvoiceMatchTolerance) Allows(expected, actual float64) bool {
+	diff := math.Abs(actual - expected)
+	if diff <= t.Amount {
+		return true
+	}
+	if expected != 0 && diff <= math.Abs(expected)*t.Percent/100 {
+		return true
+	}
+	return false
+}
+
+// InvoiceMatchResult captures the outcome of matching an invoice against its order and quote
+type InvoiceMatchResult struct {
+	Status        InvoiceMatchStatus    `json:"status"`
+	InvoiceAmount float64               `json:"invoice_amount"`
+	QuoteTotal    *float64              `json:"quote_total,omitempty"`
+	QuotedPrice   *float64              `json:"quoted_price,omitempty"`
+	FinalPrice    *float64              `json:"final_price,omitempty"`
+	Tolerance     InvoiceMatchTolerance `json:"tolerance"`
+	Discrepancies []InvoiceDiscrepancy  `json:"discrepancies,omitempty"`
+	MatchedAt     time.Time             `json:"matched_at"`
+}
+
+// InvoiceDiscrepancy describes a single check that failed during matching
+type InvoiceDiscrepancy struct {
+	Field    string   `json:"field"`
+	Expected *float64 `json:"expected,omitempty"`
+	Actual   *float64 `json:"actual,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// CreateInvoiceInput represents input for recording an invoice
+type CreateInvoiceInput struct {
+	ServiceOrderID string        `json:"service_order_id"`
+	InvoiceNumber  string        `json:"invoice_number" validate:"required"`
+	Source         InvoiceSource `json:"source"`
+	IssueDate      *time.Time    `json:"issue_date"`
+	DueDate        *time.Time    `json:"due_date"`
+	Currency       string        `json:"currency" validate:"required"`
+	Subtotal       float64       `json:"subtotal"`
+	TaxAmount      float64       `json:"tax_amount"`
+	Total          float64       `json:"total" validate:"required,gt=0"`
+	Lines          []InvoiceLine `json:"lines"`
+	SupplierName   *string       `json:"supplier_name"`
+	SupplierTaxID  *string       `json:"supplier_tax_id"`
+	DocumentKey    *string       `json:"-"`
+	DocumentURL    *string       `json:"-"`
+}
+
+// InvoiceFilter represents filters for listing invoices
+type InvoiceFilter struct {
+	ServiceOrderID *string             `json:"service_order_id"`
+	VendorID       *string             `json:"vendor_id"`
+	Status         *InvoiceStatus      `json:"status"`
+	MatchStatus    *InvoiceMatchStatus `json:"match_status"`
+	Page           int                 `json:"page"`
+	PerPage        int                 `json:"per_page"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -37,6 +38,7 @@ type PortCall struct {
 	CreatedBy      string         `json:"created_by" db:"created_by"`
 	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	Version        int            `json:"version" db:"version"`
 
 	// Relations (populated when needed)
 	Vessel        *Vessel        `json:"vessel,omitempty"`
@@ -46,9 +48,9 @@ type PortCall struct {
 
 // CreatePortCallInput represents input for creating a port call
 type CreatePortCallInput struct {
-	VesselID      string     `json:"vessel_id" validate:"required,uuid"`
-	PortID        string     `json:"port_id" validate:"required,uuid"`
-	WorkspaceID   string     `json:"workspace_id" validate:"required,uuid"`
+	VesselID      string     `json:"vessel_id" validate:"required,id"`
+	PortID        string     `json:"port_id" validate:"required,id"`
+	WorkspaceID   string     `json:"workspace_id" validate:"required,id"`
 	ETA           *time.Time `json:"eta"`
 	ETD           *time.Time `json:"etd"`
 	BerthName     *string    `json:"berth_name"`
@@ -66,6 +68,10 @@ type UpdatePortCallInput struct {
 	BerthName     *string         `json:"berth_name"`
 	BerthTerminal *string         `json:"berth_terminal"`
 	AgentID       *string         `json:"agent_id"`
+
+	// Version the changes are based on; the update fails with a conflict
+	// when the port call has been modified since
+	Version *int `json:"version"`
 }
 
 // PortCallFilter represents filters for listing port calls
@@ -97,6 +103,40 @@ type Port struct {
 	Country  string `json:"country" db:"country"`
 }
 
+// VesselDimensions holds the vessel particulars checked against port restrictions
+type VesselDimensions struct {
+	VesselID string   `json:"vessel_id" db:"vessel_id"`
+	Draft    *float64 `json:"draft,omitempty" db:"draft"`   // Meters
+	Length   *float64 `json:"length,omitempty" db:"length"` // Meters
+}
+
+// PortRestrictionCheck is the port registry's verdict on a vessel's
+// dimensions
+type PortRestrictionCheck struct {
+	PortID     string                     `json:"port_id"`
+	UNLOCODE   string                     `json:"un_locode"`
+	Allowed    bool                       `json:"allowed"`
+	Violations []PortRestrictionViolation `json:"violations,omitempty"`
+}
+
+// PortRestrictionViolation is a vessel dimension exceeding a port limit
+type PortRestrictionViolation struct {
+	Restriction string  `json:"restriction"` // max_draft, max_loa
+	Limit       float64 `json:"limit"`       // Meters
+	Value       float64 `json:"value"`       // Meters
+}
+
+// String describes the violation
+func (v PortRestrictionViolation) String() string {
+	switch v.Restriction {
+	case "max_draft":
+		return fmt.Sprintf("vessel draft %.1fm exceeds maximum draft %.1fm", v.Value, v.Limit)
+	case "max_loa":
+		return fmt.Sprintf("vessel length %.1fm exceeds maximum LOA %.1fm", v.Value, v.Limit)
+	}
+	return fmt.Sprintf("vessel exceeds %s %.1f with %.1f", v.Restriction, v.Limit, v.Value)
+}
+
 // TimelineEventType represents the type of timeline event
 type TimelineEventType string
 
@@ -111,6 +151,7 @@ const (
 	TimelineEventServiceAdded   TimelineEventType = "service_added"
 	TimelineEventDocumentAdded  TimelineEventType = "document_added"
 	TimelineEventNoteAdded      TimelineEventType = "note_added"
+	TimelineEventWeatherAlert   TimelineEventType = "weather_alert"
 )
 
 // TimelineEvent represents a timeline event for a port call
@@ -127,6 +168,16 @@ type TimelineEvent struct {
 	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
 }
 
+// AddTimelineEventInput represents input for adding an event to a port
+// call's timeline. Notes are the default; other event types are recorded
+// by services, such as weather alerts by the integration service.
+type AddTimelineEventInput struct {
+	EventType   TimelineEventType `json:"event_type" validate:"omitempty,oneof=note_added weather_alert"`
+	Title       string            `json:"title" validate:"omitempty,max=200"`
+	Description string            `json:"description" validate:"required,max=4000"`
+	Metadata    map[string]any    `json:"metadata,omitempty"`
+}
+
 // StatusTransitionResult contains the result of a status transition
 type StatusTransitionResult struct {
 	PortCall      *PortCall
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortRestrictionViolation_String(t *testing.T) {
+	tests := []struct {
+		violation PortRestrictionViolation
+		want      string
+	}{
+		{PortRestrictionViolation{Restriction: "max_draft", Limit: 12, Value: 12.5}, "vessel draft 12.5m exceeds maximum draft 12.0m"},
+		{PortRestrictionViolation{Restriction: "max_loa", Limit: 300, Value: 320}, "vessel length 320.0m exceeds maximum LOA 300.0m"},
+		{PortRestrictionViolation{Restriction: "max_beam", Limit: 48, Value: 51}, "vessel exceeds max_beam 48.0 with 51.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.violation.Restriction, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.violation.String())
+		})
+	}
+}
@@ -45,6 +45,7 @@ type RFQ struct {
 	CreatedBy      string         `json:"created_by" db:"created_by"`
 	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	Version        int            `json:"version" db:"version"`
 
 	// Relations
 	ServiceType *ServiceType `json:"service_type,omitempty"`
@@ -94,6 +95,10 @@ type UpdateRFQInput struct {
 	DeliveryDate   *time.Time     `json:"delivery_date"`
 	Deadline       *time.Time     `json:"deadline"`
 	InvitedVendors []string       `json:"invited_vendors"`
+
+	// Version the changes are based on; the update fails with a conflict
+	// when the RFQ has been modified since
+	Version *int `json:"version"`
 }
 
 // SubmitQuoteInput represents input for submitting a quote
@@ -39,6 +39,12 @@ type ServiceOrder struct {
 	CreatedBy      string             `json:"created_by" db:"created_by"`
 	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+	Version        int                `json:"version" db:"version"`
+
+	// Accounting (maintained by the integration service's accounting connector)
+	PaymentStatus *string    `json:"payment_status,omitempty" db:"payment_status"`
+	PaidAt        *time.Time `json:"paid_at,omitempty" db:"paid_at"`
+	AccountingRef *string    `json:"accounting_ref,omitempty" db:"accounting_ref"`
 
 	// Relations
 	ServiceType *ServiceType `json:"service_type,omitempty"`
@@ -68,6 +74,10 @@ type UpdateServiceOrderInput struct {
 	QuotedPrice    *float64            `json:"quoted_price"`
 	FinalPrice     *float64            `json:"final_price"`
 	Currency       *string             `json:"currency"`
+
+	// Version the changes are based on; the update fails with a conflict
+	// when the order has been modified since
+	Version *int `json:"version"`
 }
 
 // ServiceOrderFilter represents filters for listing service orders
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 )
 
 // DBTX defines the interface for database operations
@@ -27,3 +29,41 @@ func GetDB(ctx context.Context, defaultDB DBTX) DBTX {
 	}
 	return defaultDB
 }
+
+// RunInTx runs fn with a transaction in its context, so the repository calls
+// it makes succeed or fail together. Within a transaction already in ctx, such
+// as the request transaction of middleware.TransactionalRLS, fn runs in a
+// savepoint instead.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(TxKey).(DBTX); ok {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT run_in_tx"); err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		if err := fn(ctx); err != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT run_in_tx")
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT run_in_tx"); err != nil {
+			return fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, TxKey, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ErrVersionConflict is returned by optimistic updates when the row was
+// modified after the version the update is based on
+var ErrVersionConflict = errors.New("version conflict")
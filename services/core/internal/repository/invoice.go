@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/navo/services/core/internal/model"
+)
+
+// InvoiceRepository handles vendor invoice database operations
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+const invoiceColumns = `
+	i.id, i.service_order_id, i.organization_id, i.vendor_id, i.invoice_number,
+	i.source, i.status, i.issue_date, i.due_date, i.currency, i.subtotal,
+	i.tax_amount, i.total, i.lines, i.supplier_name, i.supplier_tax_id,
+	i.document_key, i.document_url, i.match_status, i.match_result,
+	i.reviewed_by, i.reviewed_at, i.review_notes, i.created_by, i.created_at, i.updated_at`
+
+// Create creates a new invoice
+func (r *InvoiceRepository) Create(ctx context.Context, invoice *model.Invoice) error {
+	invoice.ID = generateCUID()
+	now := time.Now()
+	invoice.CreatedAt = now
+	invoice.UpdatedAt = now
+
+	lines, _ := json.Marshal(invoice.Lines)
+	if invoice.Lines == nil {
+		lines = []byte("[]")
+	}
+	var matchResult []byte
+	if invoice.MatchResult != nil {
+		matchResult, _ = json.Marshal(invoice.MatchResult)
+	}
+
+	query := `
+		INSERT INTO invoices (id, service_order_id, organization_id, vendor_id, invoice_number,
+			source, status, issue_date, due_date, currency, subtotal, tax_amount, total, lines,
+			supplier_name, supplier_tax_id, document_key, document_url, match_status, match_result,
+			created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23)`
+
+	_, err := GetDB(ctx, r.db).ExecContext(ctx, query,
+		invoice.ID, invoice.ServiceOrderID, invoice.OrganizationID, invoice.VendorID,
+		invoice.InvoiceNumber, invoice.Source, invoice.Status, invoice.IssueDate,
+		invoice.DueDate, invoice.Currency, invoice.Subtotal, invoice.TaxAmount,
+		invoice.Total, lines, invoice.SupplierName, invoice.SupplierTaxID,
+		invoice.DocumentKey, invoice.DocumentURL, invoice.MatchStatus, matchResult,
+		invoice.CreatedBy, invoice.CreatedAt, invoice.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	return nil
+}
+
+// InTx runs fn in a transaction (see RunInTx)
+func (r *InvoiceRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTx(ctx, r.db, fn)
+}
+
+// GetByID retrieves an organization's invoice by ID
+func (r *InvoiceRepository) GetByID(ctx context.Context, organizationID, id string) (*model.Invoice, error) {
+	query := fmt.Sprintf(`SELECT %s FROM invoices i WHERE i.id = $1 AND i.organization_id = $2`, invoiceColumns)
+
+	invoice, err := scanInvoice(GetDB(ctx, r.db).QueryRowContext(ctx, query, id, organizationID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// GetByNumber retrieves a vendor's invoice by its invoice number
+func (r *InvoiceRepository) GetByNumber(ctx context.Context, organizationID string, vendorID *string, invoiceNumber string) (*model.Invoice, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM invoices i
+		WHERE i.organization_id = $1 AND i.vendor_id IS NOT DISTINCT FROM $2
+			AND i.invoice_number = $3 AND i.status <> $4`, invoiceColumns)
+
+	invoice, err := scanInvoice(GetDB(ctx, r.db).QueryRowContext(ctx, query,
+		organizationID, vendorID, invoiceNumber, model.InvoiceStatusRejected))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// List retrieves invoices with filters
+func (r *InvoiceRepository) List(ctx context.Context, organizationID string, filter model.InvoiceFilter) ([]model.Invoice, int, error) {
+	conditions := []string{"i.organization_id = $1"}
+	args := []interface{}{organizationID}
+	argNum := 2
+
+	if filter.ServiceOrderID != nil {
+		conditions = append(conditions, fmt.Sprintf("i.service_order_id = $%d", argNum))
+		args = append(args, *filter.ServiceOrderID)
+		argNum++
+	}
+	if filter.VendorID != nil {
+		conditions = append(conditions, fmt.Sprintf("i.vendor_id = $%d", argNum))
+		args = append(args, *filter.VendorID)
+		argNum++
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("i.status = $%d", argNum))
+		args = append(args, *filter.Status)
+		argNum++
+	}
+	if filter.MatchStatus != nil {
+		conditions = append(conditions, fmt.Sprintf("i.match_status = $%d", argNum))
+		args = append(args, *filter.MatchStatus)
+		argNum++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	// Count query
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM invoices i %s", whereClause)
+	var total int
+	if err := GetDB(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count invoices: %w", err)
+	}
+
+	// Pagination
+	if filter.PerPage <= 0 {
+		filter.PerPage = 20
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	offset := (filter.Page - 1) * filter.PerPage
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM invoices i
+		%s
+		ORDER BY i.created_at DESC
+		LIMIT $%d OFFSET $%d`, invoiceColumns, whereClause, argNum, argNum+1)
+
+	args = append(args, filter.PerPage, offset)
+
+	rows, err := GetDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []model.Invoice
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, *invoice)
+	}
+
+	return invoices, total, nil
+}
+
+// UpdateMatch stores the result of matching an invoice
+func (r *InvoiceRepository) UpdateMatch(ctx context.Context, organizationID, id string, status model.InvoiceStatus, result *model.InvoiceMatchResult) error {
+	matchResult, _ := json.Marshal(result)
+
+	query := `
+		UPDATE invoices
+		SET status = $1, match_status = $2, match_result = $3, updated_at = $4
+		WHERE id = $5 AND organization_id = $6`
+
+	_, err := GetDB(ctx, r.db).ExecContext(ctx, query, status, result.Status, matchResult, time.Now(), id, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice match: %w", err)
+	}
+
+	return nil
+}
+
+// Review records an approval decision on an invoice
+func (r *InvoiceRepository) Review(ctx context.Context, organizationID, id string, status model.InvoiceStatus, reviewedBy string, notes *string) error {
+	now := time.Now()
+	query := `
+		UPDATE invoices
+		SET status = $1, reviewed_by = $2, reviewed_at = $3, review_notes = $4, updated_at = $5
+		WHERE id = $6 AND organization_id = $7`
+
+	_, err := GetDB(ctx, r.db).ExecContext(ctx, query, status, reviewedBy, now, notes, now, id, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to review invoice: %w", err)
+	}
+
+	return nil
+}
+
+// GetAwardedQuote retrieves the quote awarded on the service order's RFQ, if any
+func (r *InvoiceRepository) GetAwardedQuote(ctx context.Context, serviceOrderID string) (*model.Quote, error) {
+	query := `
+		SELECT q.id, q.rfq_id, q.vendor_id, q.status, q.unit_price, q.total_price,
+			q.currency, q.submitted_at
+		FROM service_orders so
+		JOIN rfqs r ON so.rfq_id = r.id
+		JOIN quotes q ON r.awarded_quote_id = q.id
+		WHERE so.id = $1`
+
+	quote := &model.Quote{}
+	err := GetDB(ctx, r.db).QueryRowContext(ctx, query, serviceOrderID).Scan(
+		&quote.ID, &quote.RFQID, &quote.VendorID, &quote.Status, &quote.UnitPrice,
+		&quote.TotalPrice, &quote.Currency, &quote.SubmittedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get awarded quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// invoiceScanner is satisfied by *sql.Row and *sql.Rows
+type invoiceScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvoice(row invoiceScanner) (*model.Invoice, error) {
+	invoice := &model.Invoice{}
+	var lines, matchResult []byte
+
+	err := row.Scan(
+		&invoice.ID, &invoice.ServiceOrderID, &invoice.OrganizationID, &invoice.VendorID,
+		&invoice.InvoiceNumber, &invoice.Source, &invoice.Status, &invoice.IssueDate,
+		&invoice.DueDate, &invoice.Currency, &invoice.Subtotal, &invoice.TaxAmount,
+		&invoice.Total, &lines, &invoice.SupplierName, &invoice.SupplierTaxID,
+		&invoice.DocumentKey, &invoice.DocumentURL, &invoice.MatchStatus, &matchResult,
+		&invoice.ReviewedBy, &invoice.ReviewedAt, &invoice.ReviewNotes,
+		&invoice.CreatedBy, &invoice.CreatedAt, &invoice.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lines != nil {
+		json.Unmarshal(lines, &invoice.Lines)
+	}
+	if matchResult != nil {
+		invoice.MatchResult = &model.InvoiceMatchResult{}
+		if err := json.Unmarshal(matchResult, invoice.MatchResult); err != nil {
+			invoice.MatchResult = nil
+		}
+	}
+
+	return invoice, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// OrganizationRepository handles organization database operations
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// GetType returns an organization's type: operator, customer, vendor or
+// agent
+func (r *OrganizationRepository) GetType(ctx context.Context, id string) (string, error) {
+	var orgType string
+	err := GetDB(ctx, r.db).QueryRowContext(ctx, `SELECT type FROM organizations WHERE id = $1`, id).Scan(&orgType)
+	if err != nil {
+		return "", err
+	}
+	return orgType, nil
+}
@@ -53,7 +53,7 @@ func (r *PortCallRepository) Create(ctx context.Context, input model.CreatePortC
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, reference, vessel_id, port_id, workspace_id, status, eta, etd,
 			ata, atd, berth_name, berth_terminal, berth_confirmed_at, agent_id,
-			created_by, created_at, updated_at`
+			created_by, created_at, updated_at, version`
 
 	portCall := &model.PortCall{}
 	err = GetDB(ctx, r.db).QueryRowContext(ctx, query,
@@ -65,7 +65,7 @@ func (r *PortCallRepository) Create(ctx context.Context, input model.CreatePortC
 		&portCall.WorkspaceID, &portCall.Status, &portCall.ETA, &portCall.ETD,
 		&portCall.ATA, &portCall.ATD, &portCall.BerthName, &portCall.BerthTerminal,
 		&portCall.BerthConfirmed, &portCall.AgentID, &portCall.CreatedBy,
-		&portCall.CreatedAt, &portCall.UpdatedAt,
+		&portCall.CreatedAt, &portCall.UpdatedAt, &portCall.Version,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create port call: %w", err)
@@ -80,7 +80,7 @@ func (r *PortCallRepository) GetByID(ctx context.Context, id string) (*model.Por
 		SELECT pc.id, pc.reference, pc.vessel_id, pc.port_id, pc.workspace_id, pc.status,
 			pc.eta, pc.etd, pc.ata, pc.atd, pc.berth_name, pc.berth_terminal,
 			pc.berth_confirmed_at, pc.agent_id, pc.created_by, pc.created_at, pc.updated_at,
-			v.id, v.name, v.imo, v.flag, v.type,
+			pc.version, v.id, v.name, v.imo, v.flag, v.type,
 			p.id, p.name, p.unlocode, p.country
 		FROM port_calls pc
 		LEFT JOIN vessels v ON pc.vessel_id = v.id
@@ -97,7 +97,7 @@ func (r *PortCallRepository) GetByID(ctx context.Context, id string) (*model.Por
 		&portCall.WorkspaceID, &portCall.Status, &portCall.ETA, &portCall.ETD,
 		&portCall.ATA, &portCall.ATD, &portCall.BerthName, &portCall.BerthTerminal,
 		&portCall.BerthConfirmed, &portCall.AgentID, &portCall.CreatedBy,
-		&portCall.CreatedAt, &portCall.UpdatedAt,
+		&portCall.CreatedAt, &portCall.UpdatedAt, &portCall.Version,
 		&portCall.Vessel.ID, &portCall.Vessel.Name, &portCall.Vessel.IMO,
 		&portCall.Vessel.Flag, &portCall.Vessel.Type,
 		&portCall.Port.ID, &portCall.Port.Name, &portCall.Port.UNLOCODE,
@@ -176,7 +176,7 @@ func (r *PortCallRepository) List(ctx context.Context, filter model.PortCallFilt
 		SELECT pc.id, pc.reference, pc.vessel_id, pc.port_id, pc.workspace_id, pc.status,
 			pc.eta, pc.etd, pc.ata, pc.atd, pc.berth_name, pc.berth_terminal,
 			pc.berth_confirmed_at, pc.agent_id, pc.created_by, pc.created_at, pc.updated_at,
-			v.id, v.name, v.imo, v.flag, v.type,
+			pc.version, v.id, v.name, v.imo, v.flag, v.type,
 			p.id, p.name, p.unlocode, p.country
 		FROM port_calls pc
 		LEFT JOIN vessels v ON pc.vessel_id = v.id
@@ -203,7 +203,7 @@ func (r *PortCallRepository) List(ctx context.Context, filter model.PortCallFilt
 			&pc.ID, &pc.Reference, &pc.VesselID, &pc.PortID, &pc.WorkspaceID,
 			&pc.Status, &pc.ETA, &pc.ETD, &pc.ATA, &pc.ATD, &pc.BerthName,
 			&pc.BerthTerminal, &pc.BerthConfirmed, &pc.AgentID, &pc.CreatedBy,
-			&pc.CreatedAt, &pc.UpdatedAt,
+			&pc.CreatedAt, &pc.UpdatedAt, &pc.Version,
 			&pc.Vessel.ID, &pc.Vessel.Name, &pc.Vessel.IMO, &pc.Vessel.Flag, &pc.Vessel.Type,
 			&pc.Port.ID, &pc.Port.Name, &pc.Port.UNLOCODE, &pc.Port.Country,
 		)
@@ -267,17 +267,28 @@ func (r *PortCallRepository) Update(ctx context.Context, id string, input model.
 		return r.GetByID(ctx, id)
 	}
 
-	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum))
+	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum), "version = version + 1")
 	args = append(args, time.Now())
 	argNum++
 
+	where := fmt.Sprintf("id = $%d", argNum)
 	args = append(args, id)
+	if input.Version != nil {
+		argNum++
+		where += fmt.Sprintf(" AND version = $%d", argNum)
+		args = append(args, *input.Version)
+	}
 
-	query := fmt.Sprintf(`UPDATE port_calls SET %s WHERE id = $%d`, strings.Join(sets, ", "), argNum)
-	_, err := GetDB(ctx, r.db).ExecContext(ctx, query, args...)
+	query := fmt.Sprintf(`UPDATE port_calls SET %s WHERE %s`, strings.Join(sets, ", "), where)
+	result, err := GetDB(ctx, r.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update port call: %w", err)
 	}
+	if input.Version != nil {
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return nil, ErrVersionConflict
+		}
+	}
 
 	return r.GetByID(ctx, id)
 }
@@ -304,7 +315,7 @@ func (r *PortCallRepository) GetServiceOrders(ctx context.Context, portCallID st
 		SELECT so.id, so.port_call_id, so.service_type_id, so.status, so.description,
 			so.quantity, so.unit, so.specifications, so.requested_date, so.confirmed_date,
 			so.completed_date, so.vendor_id, so.quoted_price, so.final_price, so.currency,
-			so.rfq_id, so.created_by, so.created_at, so.updated_at,
+			so.rfq_id, so.created_by, so.created_at, so.updated_at, so.version,
 			st.id, st.name, st.category, st.description
 		FROM service_orders so
 		LEFT JOIN service_types st ON so.service_type_id = st.id
@@ -327,7 +338,7 @@ func (r *PortCallRepository) GetServiceOrders(ctx context.Context, portCallID st
 			&so.ID, &so.PortCallID, &so.ServiceTypeID, &so.Status, &so.Description,
 			&so.Quantity, &so.Unit, &specs, &so.RequestedDate, &so.ConfirmedDate,
 			&so.CompletedDate, &so.VendorID, &so.QuotedPrice, &so.FinalPrice, &so.Currency,
-			&so.RFQID, &so.CreatedBy, &so.CreatedAt, &so.UpdatedAt,
+			&so.RFQID, &so.CreatedBy, &so.CreatedAt, &so.UpdatedAt, &so.Version,
 			&so.ServiceType.ID, &so.ServiceType.Name, &so.ServiceType.Category,
 			&so.ServiceType.Description,
 		)
@@ -343,6 +354,45 @@ func (r *PortCallRepository) GetServiceOrders(ctx context.Context, portCallID st
 	return orders, nil
 }
 
+// GetPortUNLOCODE retrieves a port's UN/LOCODE, which the port registry
+// knows it by. Returns "" if the port is not found or has none.
+func (r *PortCallRepository) GetPortUNLOCODE(ctx context.Context, portID string) (string, error) {
+	var unlocode sql.NullString
+	err := GetDB(ctx, r.db).QueryRowContext(ctx, `SELECT unlocode FROM ports WHERE id = $1`, portID).Scan(&unlocode)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get port: %w", err)
+	}
+
+	return unlocode.String, nil
+}
+
+// GetVesselDimensions retrieves a vessel's draft and length from its details.
+// Returns nil if the vessel is not found.
+func (r *PortCallRepository) GetVesselDimensions(ctx context.Context, vesselID string) (*model.VesselDimensions, error) {
+	query := `
+		SELECT id,
+			NULLIF((details->>'draft')::DOUBLE PRECISION, 0),
+			NULLIF((details->>'length')::DOUBLE PRECISION, 0)
+		FROM vessels
+		WHERE id = $1`
+
+	dimensions := &model.VesselDimensions{}
+	err := GetDB(ctx, r.db).QueryRowContext(ctx, query, vesselID).Scan(
+		&dimensions.VesselID, &dimensions.Draft, &dimensions.Length,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vessel dimensions: %w", err)
+	}
+
+	return dimensions, nil
+}
+
 // generateCUID generates a unique identifier (simplified)
 func generateCUID() string {
 	return fmt.Sprintf("c%d", time.Now().UnixNano())
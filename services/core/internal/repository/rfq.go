@@ -59,7 +59,7 @@ func (r *RFQRepository) GetByID(ctx context.Context, id string) (*model.RFQ, err
 		SELECT r.id, r.reference, r.service_type_id, r.port_call_id, r.status,
 			r.description, r.quantity, r.unit, r.specifications, r.delivery_date,
 			r.deadline, r.invited_vendors, r.awarded_quote_id, r.awarded_at,
-			r.created_by, r.created_at, r.updated_at,
+			r.created_by, r.created_at, r.updated_at, r.version,
 			st.id, st.name, st.category, st.description,
 			(SELECT COUNT(*) FROM quotes WHERE rfq_id = r.id) as quote_count
 		FROM rfqs r
@@ -76,7 +76,7 @@ func (r *RFQRepository) GetByID(ctx context.Context, id string) (*model.RFQ, err
 		&rfq.ID, &rfq.Reference, &rfq.ServiceTypeID, &rfq.PortCallID, &rfq.Status,
 		&rfq.Description, &rfq.Quantity, &rfq.Unit, &specs, &rfq.DeliveryDate,
 		&rfq.Deadline, &invitedVendors, &rfq.AwardedQuoteID, &rfq.AwardedAt,
-		&rfq.CreatedBy, &rfq.CreatedAt, &rfq.UpdatedAt,
+		&rfq.CreatedBy, &rfq.CreatedAt, &rfq.UpdatedAt, &rfq.Version,
 		&rfq.ServiceType.ID, &rfq.ServiceType.Name, &rfq.ServiceType.Category,
 		&rfq.ServiceType.Description, &rfq.QuoteCount,
 	)
@@ -161,7 +161,7 @@ func (r *RFQRepository) List(ctx context.Context, filter model.RFQFilter) ([]mod
 		SELECT r.id, r.reference, r.service_type_id, r.port_call_id, r.status,
 			r.description, r.quantity, r.unit, r.specifications, r.delivery_date,
 			r.deadline, r.invited_vendors, r.awarded_quote_id, r.awarded_at,
-			r.created_by, r.created_at, r.updated_at,
+			r.created_by, r.created_at, r.updated_at, r.version,
 			st.id, st.name, st.category, st.description,
 			(SELECT COUNT(*) FROM quotes WHERE rfq_id = r.id) as quote_count
 		FROM rfqs r
@@ -190,7 +190,7 @@ func (r *RFQRepository) List(ctx context.Context, filter model.RFQFilter) ([]mod
 			&rfq.ID, &rfq.Reference, &rfq.ServiceTypeID, &rfq.PortCallID, &rfq.Status,
 			&rfq.Description, &rfq.Quantity, &rfq.Unit, &specs, &rfq.DeliveryDate,
 			&rfq.Deadline, &invitedVendors, &rfq.AwardedQuoteID, &rfq.AwardedAt,
-			&rfq.CreatedBy, &rfq.CreatedAt, &rfq.UpdatedAt,
+			&rfq.CreatedBy, &rfq.CreatedAt, &rfq.UpdatedAt, &rfq.Version,
 			&rfq.ServiceType.ID, &rfq.ServiceType.Name, &rfq.ServiceType.Category,
 			&rfq.ServiceType.Description, &rfq.QuoteCount,
 		)
@@ -254,24 +254,35 @@ func (r *RFQRepository) Update(ctx context.Context, id string, input model.Updat
 		return r.GetByID(ctx, id)
 	}
 
-	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum))
+	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum), "version = version + 1")
 	args = append(args, time.Now())
 	argNum++
 
+	where := fmt.Sprintf("id = $%d", argNum)
 	args = append(args, id)
+	if input.Version != nil {
+		argNum++
+		where += fmt.Sprintf(" AND version = $%d", argNum)
+		args = append(args, *input.Version)
+	}
 
-	query := fmt.Sprintf(`UPDATE rfqs SET %s WHERE id = $%d`, strings.Join(sets, ", "), argNum)
-	_, err := r.db.ExecContext(ctx, query, args...)
+	query := fmt.Sprintf(`UPDATE rfqs SET %s WHERE %s`, strings.Join(sets, ", "), where)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update RFQ: %w", err)
 	}
+	if input.Version != nil {
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return nil, ErrVersionConflict
+		}
+	}
 
 	return r.GetByID(ctx, id)
 }
 
 // UpdateStatus updates the RFQ status
 func (r *RFQRepository) UpdateStatus(ctx context.Context, id string, status model.RFQStatus) error {
-	query := `UPDATE rfqs SET status = $1, updated_at = $2 WHERE id = $3`
+	query := `UPDATE rfqs SET status = $1, updated_at = $2, version = version + 1 WHERE id = $3`
 	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update RFQ status: %w", err)
@@ -282,7 +293,7 @@ func (r *RFQRepository) UpdateStatus(ctx context.Context, id string, status mode
 // Award awards the RFQ to a quote
 func (r *RFQRepository) Award(ctx context.Context, rfqID, quoteID string) error {
 	now := time.Now()
-	query := `UPDATE rfqs SET status = $1, awarded_quote_id = $2, awarded_at = $3, updated_at = $4 WHERE id = $5`
+	query := `UPDATE rfqs SET status = $1, awarded_quote_id = $2, awarded_at = $3, updated_at = $4, version = version + 1 WHERE id = $5`
 	_, err := r.db.ExecContext(ctx, query, model.RFQStatusAwarded, quoteID, now, now, rfqID)
 	if err != nil {
 		return fmt.Errorf("failed to award RFQ: %w", err)
@@ -37,7 +37,8 @@ func (r *ServiceOrderRepository) Create(ctx context.Context, input model.CreateS
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, port_call_id, service_type_id, status, description, quantity, unit,
 			specifications, requested_date, confirmed_date, completed_date, vendor_id,
-			quoted_price, final_price, currency, rfq_id, created_by, created_at, updated_at`
+			quoted_price, final_price, currency, rfq_id, created_by, created_at, updated_at,
+			version`
 
 	order := &model.ServiceOrder{}
 	var specsBytes []byte
@@ -51,6 +52,7 @@ func (r *ServiceOrderRepository) Create(ctx context.Context, input model.CreateS
 		&order.RequestedDate, &order.ConfirmedDate, &order.CompletedDate,
 		&order.VendorID, &order.QuotedPrice, &order.FinalPrice, &order.Currency,
 		&order.RFQID, &order.CreatedBy, &order.CreatedAt, &order.UpdatedAt,
+		&order.Version,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create service order: %w", err)
@@ -69,7 +71,8 @@ func (r *ServiceOrderRepository) GetByID(ctx context.Context, id string) (*model
 		SELECT so.id, so.port_call_id, so.service_type_id, so.status, so.description,
 			so.quantity, so.unit, so.specifications, so.requested_date, so.confirmed_date,
 			so.completed_date, so.vendor_id, so.quoted_price, so.final_price, so.currency,
-			so.rfq_id, so.created_by, so.created_at, so.updated_at,
+			so.rfq_id, so.created_by, so.created_at, so.updated_at, so.version,
+			so.payment_status, so.paid_at, so.accounting_ref,
 			st.id, st.name, st.category, st.description
 		FROM service_orders so
 		LEFT JOIN service_types st ON so.service_type_id = st.id
@@ -86,6 +89,7 @@ func (r *ServiceOrderRepository) GetByID(ctx context.Context, id string) (*model
 		&order.RequestedDate, &order.ConfirmedDate, &order.CompletedDate,
 		&order.VendorID, &order.QuotedPrice, &order.FinalPrice, &order.Currency,
 		&order.RFQID, &order.CreatedBy, &order.CreatedAt, &order.UpdatedAt,
+		&order.Version, &order.PaymentStatus, &order.PaidAt, &order.AccountingRef,
 		&order.ServiceType.ID, &order.ServiceType.Name, &order.ServiceType.Category,
 		&order.ServiceType.Description,
 	)
@@ -155,7 +159,8 @@ func (r *ServiceOrderRepository) List(ctx context.Context, filter model.ServiceO
 		SELECT so.id, so.port_call_id, so.service_type_id, so.status, so.description,
 			so.quantity, so.unit, so.specifications, so.requested_date, so.confirmed_date,
 			so.completed_date, so.vendor_id, so.quoted_price, so.final_price, so.currency,
-			so.rfq_id, so.created_by, so.created_at, so.updated_at,
+			so.rfq_id, so.created_by, so.created_at, so.updated_at, so.version,
+			so.payment_status, so.paid_at, so.accounting_ref,
 			st.id, st.name, st.category, st.description
 		FROM service_orders so
 		LEFT JOIN service_types st ON so.service_type_id = st.id
@@ -182,6 +187,7 @@ func (r *ServiceOrderRepository) List(ctx context.Context, filter model.ServiceO
 			&so.Quantity, &so.Unit, &specs, &so.RequestedDate, &so.ConfirmedDate,
 			&so.CompletedDate, &so.VendorID, &so.QuotedPrice, &so.FinalPrice, &so.Currency,
 			&so.RFQID, &so.CreatedBy, &so.CreatedAt, &so.UpdatedAt,
+			&so.Version, &so.PaymentStatus, &so.PaidAt, &so.AccountingRef,
 			&so.ServiceType.ID, &so.ServiceType.Name, &so.ServiceType.Category,
 			&so.ServiceType.Description,
 		)
@@ -259,17 +265,28 @@ func (r *ServiceOrderRepository) Update(ctx context.Context, id string, input mo
 		return r.GetByID(ctx, id)
 	}
 
-	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum))
+	sets = append(sets, fmt.Sprintf("updated_at = $%d", argNum), "version = version + 1")
 	args = append(args, time.Now())
 	argNum++
 
+	where := fmt.Sprintf("id = $%d", argNum)
 	args = append(args, id)
+	if input.Version != nil {
+		argNum++
+		where += fmt.Sprintf(" AND version = $%d", argNum)
+		args = append(args, *input.Version)
+	}
 
-	query := fmt.Sprintf(`UPDATE service_orders SET %s WHERE id = $%d`, strings.Join(sets, ", "), argNum)
-	_, err := GetDB(ctx, r.db).ExecContext(ctx, query, args...)
+	query := fmt.Sprintf(`UPDATE service_orders SET %s WHERE %s`, strings.Join(sets, ", "), where)
+	result, err := GetDB(ctx, r.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update service order: %w", err)
 	}
+	if input.Version != nil {
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return nil, ErrVersionConflict
+		}
+	}
 
 	return r.GetByID(ctx, id)
 }
@@ -295,7 +312,8 @@ func (r *ServiceOrderRepository) Confirm(ctx context.Context, id string, vendorI
 	now := time.Now()
 	query := `
 		UPDATE service_orders
-		SET status = $1, vendor_id = $2, quoted_price = $3, confirmed_date = $4, updated_at = $5
+		SET status = $1, vendor_id = $2, quoted_price = $3, confirmed_date = $4, updated_at = $5,
+			version = version + 1
 		WHERE id = $6`
 
 	_, err := GetDB(ctx, r.db).ExecContext(ctx, query, model.ServiceOrderStatusConfirmed, vendorID, quotedPrice, now, now, id)
@@ -314,10 +332,10 @@ func (r *ServiceOrderRepository) Complete(ctx context.Context, id string, finalP
 	var args []interface{}
 
 	if finalPrice != nil {
-		query = `UPDATE service_orders SET status = $1, final_price = $2, completed_date = $3, updated_at = $4 WHERE id = $5`
+		query = `UPDATE service_orders SET status = $1, final_price = $2, completed_date = $3, updated_at = $4, version = version + 1 WHERE id = $5`
 		args = []interface{}{model.ServiceOrderStatusCompleted, *finalPrice, now, now, id}
 	} else {
-		query = `UPDATE service_orders SET status = $1, final_price = quoted_price, completed_date = $2, updated_at = $3 WHERE id = $4`
+		query = `UPDATE service_orders SET status = $1, final_price = quoted_price, completed_date = $2, updated_at = $3, version = version + 1 WHERE id = $4`
 		args = []interface{}{model.ServiceOrderStatusCompleted, now, now, id}
 	}
 
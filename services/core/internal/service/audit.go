@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/services/core/internal/model"
+	"go.uber.org/zap"
+)
+
+// AuditStore is where the audit trail is kept
+type AuditStore interface {
+	audit.Logger
+	audit.Streamer
+	audit.Verifier
+}
+
+// ErrNoHistory is returned for entity types without a history view
+var ErrNoHistory = errors.New("entity type has no history")
+
+// historyEntityTypes are the entities with a history view
+var historyEntityTypes = map[audit.EntityType]bool{
+	audit.EntityPortCall: true,
+	audit.EntityRFQ:      true,
+	audit.EntityVendor:   true,
+}
+
+// AuditService reads an organization's audit trail
+type AuditService struct {
+	store AuditStore
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(store AuditStore) *AuditService {
+	return &AuditService{store: store}
+}
+
+// Query returns a page of the organization's audit events matching the
+// filter
+func (s *AuditService) Query(ctx context.Context, orgID string, filter audit.Filter) (*audit.Result, error) {
+	filter.OrganizationID = orgID
+	return s.store.Query(ctx, filter)
+}
+
+// Get returns one of the organization's audit events
+func (s *AuditService) Get(ctx context.Context, orgID, id string) (*audit.Event, error) {
+	event, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.OrganizationID != orgID {
+		return nil, audit.ErrEventNotFound
+	}
+	return event, nil
+}
+
+// Export writes every audit event of the organization matching the filter
+// to w, and records the export in the audit trail. It returns the number
+// of events written.
+func (s *AuditService) Export(ctx context.Context, orgID, userID string, filter audit.Filter, format audit.ExportFormat, w io.Writer) (int64, error) {
+	filter.OrganizationID = orgID
+
+	writer, err := audit.NewExportWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	err = s.store.Stream(ctx, filter, writer.Write)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	builder := audit.NewBuilder().
+		WithUser(userID, orgID).
+		WithAction(audit.ActionExport).
+		WithEntity(audit.EntityAuditLog, "").
+		WithMetadata("format", format).
+		WithMetadata("events", writer.Count()).
+		WithMetadata("filter", exportFilterMetadata(filter)).
+		WithRequestContext(ctx)
+	if err != nil {
+		builder.WithFailure(err.Error())
+	}
+	// Record the export even if the client went away mid-stream
+	if logErr := s.store.Log(context.WithoutCancel(ctx), builder.Build()); logErr != nil {
+		logger.Error("Failed to record audit export", zap.Error(logErr))
+	}
+
+	return writer.Count(), err
+}
+
+// History returns the audited history of a port call, RFQ or vendor, with
+// the fields each action changed
+func (s *AuditService) History(ctx context.Context, orgID string, entityType audit.EntityType, entityID string) (*model.EntityHistory, error) {
+	if !historyEntityTypes[entityType] {
+		return nil, ErrNoHistory
+	}
+
+	builder := newHistoryBuilder(entityType, entityID)
+	err := s.store.Stream(ctx, audit.Filter{
+		OrganizationID: orgID,
+		EntityType:     entityType,
+		EntityID:       entityID,
+	}, builder.add)
+	if err != nil {
+		return nil, err
+	}
+	return builder.history, nil
+}
+
+// Verify verifies the organization's audit trail between two sequences
+func (s *AuditService) Verify(ctx context.Context, orgID string, from, to int64) (*audit.Verification, error) {
+	return s.store.Verify(ctx, orgID, from, to)
+}
+
+// exportFilterMetadata describes an export's filter for its audit event
+func exportFilterMetadata(filter audit.Filter) map[string]any {
+	metadata := map[string]any{}
+	set := func(key, value string) {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	set("user_id", filter.UserID)
+	set("workspace_id", filter.WorkspaceID)
+	set("action", string(filter.Action))
+	set("entity_type", string(filter.EntityType))
+	set("entity_id", filter.EntityID)
+	set("status", filter.Status)
+	set("search", filter.Search)
+	if !filter.StartTime.IsZero() {
+		metadata["start_time"] = filter.StartTime
+	}
+	if !filter.EndTime.IsZero() {
+		metadata["end_time"] = filter.EndTime
+	}
+	return metadata
+}
+
+// historyBuilder builds an entity's history from its audit events, oldest
+// first, keeping the entity's last known snapshot
+type historyBuilder struct {
+	history  *model.EntityHistory
+	snapshot json.RawMessage
+}
+
+func newHistoryBuilder(entityType audit.EntityType, entityID string) *historyBuilder {
+	return &historyBuilder{
+		history: &model.EntityHistory{
+			EntityType: entityType,
+			EntityID:   entityID,
+			Entries:    []model.HistoryEntry{},
+		},
+	}
+}
+
+// add adds an event to the history. An event is diffed from its old value,
+// or the previous event's new value when it has none. Failed actions and
+// events without a new value, other than deletes, change nothing.
+func (b *historyBuilder) add(event *audit.Event) error {
+	entry := model.HistoryEntry{
+		EventID:   event.ID,
+		Sequence:  event.Sequence,
+		Timestamp: event.Timestamp,
+		UserID:    event.UserID,
+		Action:    event.Action,
+		Status:    event.Status,
+		Changes:   []model.FieldChange{},
+	}
+
+	before := event.OldValue
+	if len(before) == 0 {
+		before = b.snapshot
+	}
+	after := event.NewValue
+	if event.Status == "failure" || (len(after) == 0 && event.Action != audit.ActionDelete) {
+		after = before
+	}
+
+	changes, err := DiffSnapshots(before, after)
+	if err != nil {
+		return fmt.Errorf("audit event %s: %w", event.ID, err)
+	}
+	entry.Changes = append(entry.Changes, changes...)
+
+	b.history.Entries = append(b.history.Entries, entry)
+	b.snapshot = after
+	return nil
+}
+
+// DiffSnapshots returns the field-level changes between two JSON snapshots
+// of an entity, sorted by field. Nested objects are compared field by
+// field, arrays as a whole. Null fields count as absent.
+func DiffSnapshots(before, after json.RawMessage) ([]model.FieldChange, error) {
+	oldFields, err := flattenSnapshot(before)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old value: %w", err)
+	}
+	newFields, err := flattenSnapshot(after)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new value: %w", err)
+	}
+
+	fields := make([]string, 0, len(oldFields)+len(newFields))
+	for field := range oldFields {
+		fields = append(fields, field)
+	}
+	for field := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []model.FieldChange
+	for _, field := range fields {
+		oldValue, newValue := oldFields[field], newFields[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, model.FieldChange{Field: field, Old: oldValue, New: newValue})
+	}
+	return changes, nil
+}
+
+// flattenSnapshot decodes a JSON snapshot into its fields by dotted path
+func flattenSnapshot(data json.RawMessage) (map[string]any, error) {
+	fields := map[string]any{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fields, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	flattenValue("", value, fields)
+	return fields, nil
+}
+
+func flattenValue(path string, value any, fields map[string]any) {
+	if value == nil {
+		return
+	}
+	if object, ok := value.(map[string]any); ok && (len(object) > 0 || path == "") {
+		for key, child := range object {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenValue(childPath, child, fields)
+		}
+		return
+	}
+	if path == "" {
+		path = "value"
+	}
+	fields[path] = value
+}
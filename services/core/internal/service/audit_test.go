@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/services/core/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditStore keeps audit events in memory
+type fakeAuditStore struct {
+	events []audit.Event
+	logged []audit.Event
+}
+
+func (f *fakeAuditStore) Log(ctx context.Context, event audit.Event) error {
+	f.logged = append(f.logged, event)
+	return nil
+}
+
+func (f *fakeAuditStore) LogAsync(ctx context.Context, event audit.Event) {
+	f.logged = append(f.logged, event)
+}
+
+func (f *fakeAuditStore) Query(ctx context.Context, filter audit.Filter) (*audit.Result, error) {
+	var events []audit.Event
+	f.Stream(ctx, filter, func(e *audit.Event) error {
+		events = append(events, *e)
+		return nil
+	})
+	return &audit.Result{Events: events, Total: int64(len(events)), Page: 1, PerPage: 20}, nil
+}
+
+func (f *fakeAuditStore) GetByID(ctx context.Context, id string) (*audit.Event, error) {
+	for i := range f.events {
+		if f.events[i].ID == id {
+			return &f.events[i], nil
+		}
+	}
+	return nil, audit.ErrEventNotFound
+}
+
+func (f *fakeAuditStore) Stream(ctx context.Context, filter audit.Filter, fn func(*audit.Event) error) error {
+	for i := range f.events {
+		e := &f.events[i]
+		if e.OrganizationID != filter.OrganizationID ||
+			(filter.EntityType != "" && e.EntityType != filter.EntityType) ||
+			(filter.EntityID != "" && e.EntityID != filter.EntityID) {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuditStore) Verify(ctx context.Context, organizationID string, from, to int64) (*audit.Verification, error) {
+	return &audit.Verification{OrganizationID: organizationID, Valid: true}, nil
+}
+
+func (f *fakeAuditStore) Close() error { return nil }
+
+func TestDiffSnapshots(t *testing.T) {
+	before := json.RawMessage(`{"name":"Harbour Supplies","status":"pending","rating":4.5,"address":{"city":"Singapore","country":"SG"},"ports":["SGSIN"],"note":"x"}`)
+	after := json.RawMessage(`{"name":"Harbour Supplies","status":"active","rating":4.5,"address":{"city":"Jurong","country":"SG"},"ports":["SGSIN","MYPKG"],"note":null,"is_verified":true}`)
+
+	changes, err := DiffSnapshots(before, after)
+	require.NoError(t, err)
+
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		fields[i] = c.Field
+	}
+	assert.Equal(t, []string{"address.city", "is_verified", "note", "ports", "status"}, fields)
+
+	assert.Equal(t, model.FieldChange{Field: "address.city", Old: "Singapore", New: "Jurong"}, changes[0])
+	assert.Equal(t, model.FieldChange{Field: "is_verified", New: true}, changes[1])
+	assert.Equal(t, model.FieldChange{Field: "note", Old: "x"}, changes[2])
+	assert.Equal(t, model.FieldChange{Field: "status", Old: "pending", New: "active"}, changes[4])
+}
+
+func TestDiffSnapshots_Empty(t *testing.T) {
+	changes, err := DiffSnapshots(nil, json.RawMessage(`{"status":"draft"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []model.FieldChange{{Field: "status", New: "draft"}}, changes)
+
+	changes, err = DiffSnapshots(json.RawMessage(`{"a":1}`), json.RawMessage(`{"a":1.0}`))
+	require.NoError(t, err)
+	assert.Len(t, changes, 1, "numbers are compared as written")
+
+	_, err = DiffSnapshots(json.RawMessage(`{`), nil)
+	assert.Error(t, err)
+}
+
+func TestAuditService_History(t *testing.T) {
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeAuditStore{events: []audit.Event{
+		{ID: "e1", Timestamp: base, OrganizationID: "org-1", Action: audit.ActionCreate, EntityType: audit.EntityRFQ, EntityID: "rfq-1", Status: "success",
+			NewValue: json.RawMessage(`{"title":"Bunkers","status":"draft"}`)},
+		// Other entities and organizations are not part of the history
+		{ID: "e2", Timestamp: base, OrganizationID: "org-2", Action: audit.ActionUpdate, EntityType: audit.EntityRFQ, EntityID: "rfq-1", Status: "success",
+			NewValue: json.RawMessage(`{"title":"Stolen"}`)},
+		{ID: "e3", Timestamp: base, OrganizationID: "org-1", Action: audit.ActionUpdate, EntityType: audit.EntityRFQ, EntityID: "rfq-2", Status: "success",
+			NewValue: json.RawMessage(`{"title":"Other"}`)},
+		// Without an old value, diffed against the previous snapshot
+		{ID: "e4", Timestamp: base.Add(time.Hour), OrganizationID: "org-1", Action: audit.ActionUpdate, EntityType: audit.EntityRFQ, EntityID: "rfq-1", Status: "success",
+			NewValue: json.RawMessage(`{"title":"Bunkers","status":"sent"}`)},
+		// Failures change nothing
+		{ID: "e5", Timestamp: base.Add(2 * time.Hour), OrganizationID: "org-1", Action: audit.ActionUpdate, EntityType: audit.EntityRFQ, EntityID: "rfq-1", Status: "failure",
+			NewValue: json.RawMessage(`{"title":"Bunkers","status":"awarded"}`)},
+		{ID: "e6", Timestamp: base.Add(3 * time.Hour), OrganizationID: "org-1", Action: audit.ActionDelete, EntityType: audit.EntityRFQ, EntityID: "rfq-1", Status: "success",
+			OldValue: json.RawMessage(`{"title":"Bunkers","status":"sent"}`)},
+	}}
+	svc := NewAuditService(store)
+
+	history, err := svc.History(context.Background(), "org-1", audit.EntityRFQ, "rfq-1")
+	require.NoError(t, err)
+	require.Len(t, history.Entries, 4)
+
+	assert.Equal(t, "e1", history.Entries[0].EventID)
+	assert.Len(t, history.Entries[0].Changes, 2)
+
+	assert.Equal(t, "e4", history.Entries[1].EventID)
+	assert.Equal(t, []model.FieldChange{{Field: "status", Old: "draft", New: "sent"}}, history.Entries[1].Changes)
+
+	assert.Equal(t, "e5", history.Entries[2].EventID)
+	assert.Empty(t, history.Entries[2].Changes)
+
+	assert.Equal(t, "e6", history.Entries[3].EventID)
+	assert.Equal(t, []model.FieldChange{
+		{Field: "status", Old: "sent"},
+		{Field: "title", Old: "Bunkers"},
+	}, history.Entries[3].Changes)
+}
+
+func TestAuditService_History_UnsupportedEntity(t *testing.T) {
+	svc := NewAuditService(&fakeAuditStore{})
+
+	_, err := svc.History(context.Background(), "org-1", audit.EntityInvoice, "inv-1")
+	assert.ErrorIs(t, err, ErrNoHistory)
+}
+
+func TestAuditService_Get_OtherOrganization(t *testing.T) {
+	store := &fakeAuditStore{events: []audit.Event{{ID: "e1", OrganizationID: "org-2"}}}
+	svc := NewAuditService(store)
+
+	_, err := svc.Get(context.Background(), "org-1", "e1")
+	assert.ErrorIs(t, err, audit.ErrEventNotFound)
+
+	event, err := svc.Get(context.Background(), "org-2", "e1")
+	require.NoError(t, err)
+	assert.Equal(t, "e1", event.ID)
+}
+
+func TestAuditService_Export(t *testing.T) {
+	store := &fakeAuditStore{events: []audit.Event{
+		{ID: "e1", OrganizationID: "org-1", Action: audit.ActionCreate, EntityType: audit.EntityPortCall, EntityID: "pc-1", Status: "success",
+			Metadata: map[string]any{"source": "api"}},
+		{ID: "e2", OrganizationID: "org-1", Action: audit.ActionUpdate, EntityType: audit.EntityPortCall, EntityID: "pc-1", Status: "success"},
+		{ID: "e3", OrganizationID: "org-2", Action: audit.ActionCreate, EntityType: audit.EntityPortCall, EntityID: "pc-2", Status: "success"},
+	}}
+	svc := NewAuditService(store)
+
+	var csv bytes.Buffer
+	count, err := svc.Export(context.Background(), "org-1", "user-1", audit.Filter{EntityType: audit.EntityPortCall}, audit.ExportCSV, &csv)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,sequence,timestamp,"))
+	assert.True(t, strings.HasPrefix(lines[1], "e1,"))
+	assert.Contains(t, lines[1], `"{""source"":""api""}"`)
+
+	// The export is audited
+	require.Len(t, store.logged, 1)
+	logged := store.logged[0]
+	assert.Equal(t, audit.ActionExport, logged.Action)
+	assert.Equal(t, "org-1", logged.OrganizationID)
+	assert.Equal(t, "user-1", logged.UserID)
+	assert.Equal(t, int64(2), logged.Metadata["events"])
+	assert.Equal(t, "success", logged.Status)
+
+	var ndjson bytes.Buffer
+	count, err = svc.Export(context.Background(), "org-1", "user-1", audit.Filter{}, audit.ExportNDJSON, &ndjson)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	lines = strings.Split(strings.TrimSpace(ndjson.String()), "\n")
+	require.Len(t, lines, 2)
+	var event audit.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "e2", event.ID)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/navo/services/core/internal/repository"
+)
+
+// ConflictError is returned when an update is based on a version of the
+// entity that has since been modified by someone else. Current holds the
+// entity as it is now, so the client can merge and retry.
+type ConflictError struct {
+	Entity  string
+	Version int
+	Current interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s was modified by another user (current version %d)", e.Entity, e.Version)
+}
+
+// Unwrap lets errors.Is match repository.ErrVersionConflict
+func (e *ConflictError) Unwrap() error {
+	return repository.ErrVersionConflict
+}
+
+// AsConflict returns the ConflictError in err's chain, if any
+func AsConflict(err error) (*ConflictError, bool) {
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		return conflict, true
+	}
+	return nil, false
+}
+
+// isStale reports whether an update expecting version is based on an older
+// version than current
+func isStale(version *int, current int) bool {
+	return version != nil && *version != current
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/navo/pkg/features"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/services/core/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidFlag wraps feature flag validation failures
+var ErrInvalidFlag = errors.New("invalid feature flag")
+
+// FeatureService evaluates and administers feature flags
+type FeatureService struct {
+	flags   features.Service
+	orgRepo *repository.OrganizationRepository
+}
+
+// NewFeatureService creates a new feature service
+func NewFeatureService(flags features.Service, orgRepo *repository.OrganizationRepository) *FeatureService {
+	return &FeatureService{flags: flags, orgRepo: orgRepo}
+}
+
+// EvaluateAll evaluates every flag for the caller. The organization type
+// rules can target is looked up when not given.
+func (s *FeatureService) EvaluateAll(ctx context.Context, evalCtx features.EvalContext) map[string]features.Evaluation {
+	return s.flags.EvaluateAll(ctx, s.withOrganizationType(ctx, evalCtx))
+}
+
+// Evaluate evaluates one flag for the caller
+func (s *FeatureService) Evaluate(ctx context.Context, key string, evalCtx features.EvalContext) features.Evaluation {
+	return s.flags.Variant(ctx, key, s.withOrganizationType(ctx, evalCtx))
+}
+
+func (s *FeatureService) withOrganizationType(ctx context.Context, evalCtx features.EvalContext) features.EvalContext {
+	if evalCtx.OrganizationType != "" || evalCtx.OrganizationID == "" || s.orgRepo == nil {
+		return evalCtx
+	}
+	orgType, err := s.orgRepo.GetType(ctx, evalCtx.OrganizationID)
+	if err != nil {
+		logger.Debug("Failed to get organization type for feature flags",
+			zap.String("organization_id", evalCtx.OrganizationID),
+			zap.Error(err),
+		)
+		return evalCtx
+	}
+	evalCtx.OrganizationType = orgType
+	return evalCtx
+}
+
+// List returns all flag definitions
+func (s *FeatureService) List(ctx context.Context) ([]features.Flag, error) {
+	return s.flags.ListFlags(ctx)
+}
+
+// Get returns a flag definition
+func (s *FeatureService) Get(ctx context.Context, key string) (*features.Flag, error) {
+	return s.flags.GetFlag(ctx, key)
+}
+
+// Save creates or updates a flag
+func (s *FeatureService) Save(ctx context.Context, flag features.Flag, userID string) error {
+	if err := flag.Validate(); err != nil {
+		return errors.Join(ErrInvalidFlag, err)
+	}
+	return s.flags.SaveFlag(ctx, flag, userID)
+}
+
+// Delete deletes a flag
+func (s *FeatureService) Delete(ctx context.Context, key, userID string) error {
+	return s.flags.DeleteFlag(ctx, key, userID)
+}
+
+// History returns a flag's changes, newest first
+func (s *FeatureService) History(ctx context.Context, key string, limit int) ([]features.Change, error) {
+	return s.flags.History(ctx, key, limit)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/observability"
+	"github.com/navo/services/core/internal/middleware"
+	"github.com/navo/services/core/internal/model"
+)
+
+// IntegrationClient calls the integration service API, which keeps the port
+// registry, as the caller
+type IntegrationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewIntegrationClient creates a new integration service client
+func NewIntegrationClient(baseURL string) *IntegrationClient {
+	return &IntegrationClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: observability.Transport(nil),
+		},
+	}
+}
+
+// CheckPortRestrictions checks a vessel's dimensions against the limits of
+// the port with a UN/LOCODE. Returns nil if the port is not in the registry.
+func (c *IntegrationClient) CheckPortRestrictions(ctx context.Context, unlocode string, vessel model.VesselDimensions) (*model.PortRestrictionCheck, error) {
+	body, err := json.Marshal(vessel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode vessel dimensions: %w", err)
+	}
+
+	path := "/api/v1/ports/unlocode/" + url.PathEscape(unlocode) + "/restriction-check"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", middleware.GetUserID(ctx))
+	req.Header.Set("X-Organization-ID", middleware.GetOrganizationID(ctx))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("integration service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("integration service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var check model.PortRestrictionCheck
+	if err := json.NewDecoder(resp.Body).Decode(&check); err != nil {
+		return nil, fmt.Errorf("invalid integration service response: %w", err)
+	}
+	return &check, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/navo/services/core/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationClient_CheckPortRestrictions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/ports/unlocode/NLRTM/restriction-check":
+			assert.Equal(t, http.MethodPost, r.Method)
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, 14.5, body["draft"])
+			w.Write([]byte(`{"port_id": "p-1", "un_locode": "NLRTM", "allowed": false,
+				"violations": [{"restriction": "max_draft", "limit": 12, "value": 14.5}]}`))
+		case "/api/v1/ports/unlocode/XXGON/restriction-check":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "port not found"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "failed to check port restrictions"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewIntegrationClient(server.URL + "/")
+	ctx := context.Background()
+	vessel := model.VesselDimensions{VesselID: "vessel-1", Draft: floatPtr(14.5)}
+
+	check, err := client.CheckPortRestrictions(ctx, "NLRTM", vessel)
+	require.NoError(t, err)
+	assert.Equal(t, &model.PortRestrictionCheck{
+		PortID:     "p-1",
+		UNLOCODE:   "NLRTM",
+		Violations: []model.PortRestrictionViolation{{Restriction: "max_draft", Limit: 12, Value: 14.5}},
+	}, check)
+
+	check, err = client.CheckPortRestrictions(ctx, "XXGON", vessel)
+	require.NoError(t, err)
+	assert.Nil(t, check, "ports missing from the registry are not checked")
+
+	_, err = client.CheckPortRestrictions(ctx, "NLAMS", vessel)
+	assert.ErrorContains(t, err, "integration service returned status 500")
+}
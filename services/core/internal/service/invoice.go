@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/services/core/internal/model"
+	"github.com/navo/services/core/internal/repository"
+)
+
+// DefaultInvoiceMatchTolerance accepts differences up to 1.00 or 2% of the reference price
+var DefaultInvoiceMatchTolerance = model.InvoiceMatchTolerance{
+	Amount:  1.0,
+	Percent: 2.0,
+}
+
+// ErrInvoiceNotFound is returned for invoices that do not exist in the
+// caller's organization
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// InvoiceService handles vendor invoice ingestion, matching and approval
+type InvoiceService struct {
+	repo        *repository.InvoiceRepository
+	orderRepo   *repository.ServiceOrderRepository
+	auditLogger audit.Logger
+	tolerance   model.InvoiceMatchTolerance
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService(repo *repository.InvoiceRepository, orderRepo *repository.ServiceOrderRepository, tolerance model.InvoiceMatchTolerance) *InvoiceService {
+	return &InvoiceService{
+		repo:      repo,
+		orderRepo: orderRepo,
+		tolerance: tolerance,
+	}
+}
+
+// WithAuditLogger sets the audit logger
+func (s *InvoiceService) WithAuditLogger(logger audit.Logger) *InvoiceService {
+	s.auditLogger = logger
+	return s
+}
+
+// Create records an invoice against a service order and matches it. Invoices
+// that match within tolerance are approved immediately; the rest are queued
+// for approval.
+func (s *InvoiceService) Create(ctx context.Context, serviceOrderID string, input model.CreateInvoiceInput, userID, orgID string) (*model.Invoice, error) {
+	if err := s.validateInput(input); err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, serviceOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service order: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("service order not found")
+	}
+
+	// Only orders with a confirmed vendor can be invoiced
+	if order.Status != model.ServiceOrderStatusConfirmed &&
+		order.Status != model.ServiceOrderStatusInProgress &&
+		order.Status != model.ServiceOrderStatusCompleted {
+		return nil, fmt.Errorf("cannot invoice service order in %s status", order.Status)
+	}
+
+	existing, err := s.repo.GetByNumber(ctx, orgID, order.VendorID, input.InvoiceNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for duplicate invoice: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("invoice %s has already been received", input.InvoiceNumber)
+	}
+
+	source := input.Source
+	if source == "" {
+		source = model.InvoiceSourceManual
+	}
+
+	invoice := &model.Invoice{
+		ServiceOrderID: order.ID,
+		OrganizationID: orgID,
+		VendorID:       order.VendorID,
+		InvoiceNumber:  input.InvoiceNumber,
+		Source:         source,
+		IssueDate:      input.IssueDate,
+		DueDate:        input.DueDate,
+		Currency:       strings.ToUpper(input.Currency),
+		Subtotal:       input.Subtotal,
+		TaxAmount:      input.TaxAmount,
+		Total:          input.Total,
+		Lines:          input.Lines,
+		SupplierName:   input.SupplierName,
+		SupplierTaxID:  input.SupplierTaxID,
+		DocumentKey:    input.DocumentKey,
+		DocumentURL:    input.DocumentURL,
+		CreatedBy:      userID,
+	}
+
+	quote, err := s.repo.GetAwardedQuote(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	result := MatchInvoice(invoice, order, quote, s.tolerance)
+	invoice.MatchStatus = result.Status
+	invoice.MatchResult = result
+	invoice.Status = model.InvoiceStatusPendingApproval
+	if result.Status == model.InvoiceMatchStatusMatched {
+		invoice.Status = model.InvoiceStatusApproved
+	}
+
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, invoice); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		if invoice.Status == model.InvoiceStatusApproved {
+			return s.applyFinalPrice(ctx, invoice)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Audit log
+	if s.auditLogger != nil {
+		event := audit.NewBuilder().
+			WithUser(userID, orgID).
+			WithAction(audit.ActionCreate).
+			WithEntity(audit.EntityInvoice, invoice.ID).
+			WithNewValue(invoice).
+			WithMetadata("service_order_id", order.ID).
+			WithMetadata("match_status", string(invoice.MatchStatus)).
+			WithRequestContext(ctx).
+			Build()
+		s.auditLogger.LogAsync(ctx, event)
+	}
+
+	return invoice, nil
+}
+
+// GetByID retrieves an organization's invoice by ID
+func (s *InvoiceService) GetByID(ctx context.Context, id, orgID string) (*model.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if invoice == nil {
+		return nil, ErrInvoiceNotFound
+	}
+
+	return invoice, nil
+}
+
+// List retrieves invoices with filters
+func (s *InvoiceService) List(ctx context.Context, orgID string, filter model.InvoiceFilter) (*InvoiceListResult, error) {
+	invoices, total, err := s.repo.List(ctx, orgID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	return &InvoiceListResult{
+		Invoices: invoices,
+		Total:    total,
+		Page:     filter.Page,
+		PerPage:  filter.PerPage,
+	}, nil
+}
+
+// Rematch re-runs matching for an invoice awaiting approval, e.g. after the
+// order price was corrected. An invoice that now matches is approved.
+func (s *InvoiceService) Rematch(ctx context.Context, id string, userID, orgID string) (*model.Invoice, error) {
+	invoice, err := s.GetByID(ctx, id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != model.InvoiceStatusPendingApproval {
+		return nil, fmt.Errorf("can only rematch invoices pending approval")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, invoice.ServiceOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service order: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("service order not found")
+	}
+
+	quote, err := s.repo.GetAwardedQuote(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	result := MatchInvoice(invoice, order, quote, s.tolerance)
+	status := model.InvoiceStatusPendingApproval
+	if result.Status == model.InvoiceMatchStatusMatched {
+		status = model.InvoiceStatusApproved
+	}
+
+	invoice.Status = status
+	invoice.MatchStatus = result.Status
+	invoice.MatchResult = result
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateMatch(ctx, orgID, id, status, result); err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		if status == model.InvoiceStatusApproved {
+			return s.applyFinalPrice(ctx, invoice)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Audit log
+	if s.auditLogger != nil {
+		event := audit.NewBuilder().
+			WithUser(userID, orgID).
+			WithAction(audit.ActionUpdate).
+			WithEntity(audit.EntityInvoice, id).
+			WithMetadata("action", "rematch").
+			WithMetadata("match_status", string(result.Status)).
+			WithRequestContext(ctx).
+			Build()
+		s.auditLogger.LogAsync(ctx, event)
+	}
+
+	return s.GetByID(ctx, id, orgID)
+}
+
+// Approve approves an invoice from the approval queue and sets the service order's final price
+func (s *InvoiceService) Approve(ctx context.Context, id string, notes *string, userID, orgID string) (*model.Invoice, error) {
+	invoice, err := s.GetByID(ctx, id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != model.InvoiceStatusPendingApproval {
+		return nil, fmt.Errorf("cannot approve invoice in %s status", invoice.Status)
+	}
+
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Review(ctx, orgID, id, model.InvoiceStatusApproved, userID, notes); err != nil {
+			return fmt.Errorf("failed to approve invoice: %w", err)
+		}
+		return s.applyFinalPrice(ctx, invoice)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Audit log
+	if s.auditLogger != nil {
+		event := audit.NewBuilder().
+			WithUser(userID, orgID).
+			WithAction(audit.ActionApprove).
+			WithEntity(audit.EntityInvoice, id).
+			WithMetadata("service_order_id", invoice.ServiceOrderID).
+			WithMetadata("final_price", invoice.NetAmount()).
+			WithMetadata("match_status", string(invoice.MatchStatus)).
+			WithRequestContext(ctx).
+			Build()
+		s.auditLogger.LogAsync(ctx, event)
+	}
+
+	return s.GetByID(ctx, id, orgID)
+}
+
+// Reject rejects an invoice from the approval queue
+func (s *InvoiceService) Reject(ctx context.Context, id string, reason string, userID, orgID string) (*model.Invoice, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("reason is required to reject an invoice")
+	}
+
+	invoice, err := s.GetByID(ctx, id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != model.InvoiceStatusPendingApproval {
+		return nil, fmt.Errorf("cannot reject invoice in %s status", invoice.Status)
+	}
+
+	if err := s.repo.Review(ctx, orgID, id, model.InvoiceStatusRejected, userID, &reason); err != nil {
+		return nil, fmt.Errorf("failed to reject invoice: %w", err)
+	}
+
+	// Audit log
+	if s.auditLogger != nil {
+		event := audit.NewBuilder().
+			WithUser(userID, orgID).
+			WithAction(audit.ActionReject).
+			WithEntity(audit.EntityInvoice, id).
+			WithMetadata("service_order_id", invoice.ServiceOrderID).
+			WithMetadata("reason", reason).
+			WithRequestContext(ctx).
+			Build()
+		s.auditLogger.LogAsync(ctx, event)
+	}
+
+	return s.GetByID(ctx, id, orgID)
+}
+
+// applyFinalPrice sets the service order's final price to the invoiced net amount
+func (s *InvoiceService) applyFinalPrice(ctx context.Context, invoice *model.Invoice) error {
+	finalPrice := roundAmount(invoice.NetAmount())
+	input := model.UpdateServiceOrderInput{
+		FinalPrice: &finalPrice,
+	}
+	if _, err := s.orderRepo.Update(ctx, invoice.ServiceOrderID, input); err != nil {
+		return fmt.Errorf("failed to update final price: %w", err)
+	}
+	return nil
+}
+
+// validateInput validates invoice input
+func (s *InvoiceService) validateInput(input model.CreateInvoiceInput) error {
+	if strings.TrimSpace(input.InvoiceNumber) == "" {
+		return fmt.Errorf("invoice_number is required")
+	}
+	if len(strings.TrimSpace(input.Currency)) != 3 {
+		return fmt.Errorf("currency must be a 3-letter ISO code")
+	}
+	if input.Total <= 0 {
+		return fmt.Errorf("total must be greater than 0")
+	}
+	if input.Subtotal < 0 || input.TaxAmount < 0 {
+		return fmt.Errorf("subtotal and tax_amount cannot be negative")
+	}
+	if input.IssueDate != nil && input.DueDate != nil && input.DueDate.Before(*input.IssueDate) {
+		return fmt.Errorf("due_date must be on or after issue_date")
+	}
+	return nil
+}
+
+// MatchInvoice performs three-way matching of an invoice against the awarded
+// quote and the service order's quoted and final prices. The invoice's net
+// amount must agree with every available reference price within tolerance,
+// the currencies must agree, and the invoice lines must add up.
+func MatchInvoice(invoice *model.Invoice, order *model.ServiceOrder, quote *model.Quote, tolerance model.InvoiceMatchTolerance) *model.InvoiceMatchResult {
+	amount := roundAmount(invoice.NetAmount())
+	result := &model.InvoiceMatchResult{
+		Status:        model.InvoiceMatchStatusMatched,
+		InvoiceAmount: amount,
+		QuotedPrice:   order.QuotedPrice,
+		FinalPrice:    order.FinalPrice,
+		Tolerance:     tolerance,
+		MatchedAt:     time.Now().UTC(),
+	}
+
+	addDiscrepancy := func(field string, expected, actual *float64, format string, args ...interface{}) {
+		result.Discrepancies = append(result.Discrepancies, model.InvoiceDiscrepancy{
+			Field:    field,
+			Expected: expected,
+			Actual:   actual,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Currency
+	if order.Currency != "" && !strings.EqualFold(invoice.Currency, order.Currency) {
+		addDiscrepancy("currency", nil, nil, "invoice currency %s does not match order currency %s", invoice.Currency, order.Currency)
+	}
+
+	// Reference prices
+	references := 0
+	if quote != nil {
+		references++
+		total := quote.TotalPrice
+		result.QuoteTotal = &total
+		if quote.Currency != "" && !strings.EqualFold(invoice.Currency, quote.Currency) {
+			addDiscrepancy("quote_currency", nil, nil, "invoice currency %s does not match quote currency %s", invoice.Currency, quote.Currency)
+		}
+		if !tolerance.Allows(total, amount) {
+			addDiscrepancy("quote_total", &total, &amount, "invoice amount %.2f differs from quote total %.2f by %.2f", amount, total, amount-total)
+		}
+		if order.QuotedPrice != nil && !tolerance.Allows(total, *order.QuotedPrice) {
+			addDiscrepancy("order_quoted_price", &total, order.QuotedPrice, "order quoted price %.2f differs from quote total %.2f", *order.QuotedPrice, total)
+		}
+	}
+	if order.QuotedPrice != nil {
+		references++
+		if !tolerance.Allows(*order.QuotedPrice, amount) {
+			addDiscrepancy("quoted_price", order.QuotedPrice, &amount, "invoice amount %.2f differs from order quoted price %.2f by %.2f", amount, *order.QuotedPrice, amount-*order.QuotedPrice)
+		}
+	}
+	if order.FinalPrice != nil {
+		references++
+		if !tolerance.Allows(*order.FinalPrice, amount) {
+			addDiscrepancy("final_price", order.FinalPrice, &amount, "invoice amount %.2f differs from order final price %.2f by %.2f", amount, *order.FinalPrice, amount-*order.FinalPrice)
+		}
+	}
+	if references == 0 {
+		addDiscrepancy("reference", nil, nil, "no quote or order price to match against")
+	}
+
+	// Invoice lines
+	if len(invoice.Lines) > 0 {
+		var linesTotal, quantity float64
+		for i, line := range invoice.Lines {
+			linesTotal += line.Amount
+			quantity += line.Quantity
+			if line.UnitPrice > 0 && line.Quantity > 0 {
+				expected := roundAmount(line.UnitPrice * line.Quantity)
+				if !tolerance.Allows(expected, line.Amount) {
+					actual := line.Amount
+					addDiscrepancy(fmt.Sprintf("lines[%d].amount", i), &expected, &actual, "line %d amount %.2f does not equal quantity x unit price %.2f", i+1, line.Amount, expected)
+				}
+			}
+		}
+		linesTotal = roundAmount(linesTotal)
+		if !tolerance.Allows(amount, linesTotal) {
+			addDiscrepancy("lines", &amount, &linesTotal, "invoice lines total %.2f does not equal invoice amount %.2f", linesTotal, amount)
+		}
+
+		// Quantities are only comparable when the invoice bills the order as a single line
+		if order.Quantity != nil && len(invoice.Lines) == 1 && quantity > 0 && !tolerance.Allows(*order.Quantity, quantity) {
+			addDiscrepancy("quantity", order.Quantity, &quantity, "invoiced quantity %.2f differs from ordered quantity %.2f", quantity, *order.Quantity)
+		}
+	}
+
+	if len(result.Discrepancies) > 0 {
+		result.Status = model.InvoiceMatchStatusMismatched
+	}
+
+	return result
+}
+
+// roundAmount rounds a monetary amount to cents
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// InvoiceListResult represents paginated invoice results
+type InvoiceListResult struct {
+	Invoices []model.Invoice `json:"invoices"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PerPage  int             `json:"per_page"`
+}
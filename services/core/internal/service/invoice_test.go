@@ -0,0 +1,296 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navo/services/core/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleUBLInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+	<cbc:CustomizationID>urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0</cbc:CustomizationID>
+	<cbc:ID>INV-2024-0042</cbc:ID>
+	<cbc:IssueDate>2024-03-01</cbc:IssueDate>
+	<cbc:DueDate>2024-03-31</cbc:DueDate>
+	<cbc:InvoiceTypeCode>380</cbc:InvoiceTypeCode>
+	<cbc:DocumentCurrencyCode>USD</cbc:DocumentCurrencyCode>
+	<cac:AccountingSupplierParty>
+		<cac:Party>
+			<cac:PartyName><cbc:Name>Harbour Supplies</cbc:Name></cac:PartyName>
+			<cac:PartyTaxScheme>
+				<cbc:CompanyID>SG200012345A</cbc:CompanyID>
+				<cac:TaxScheme><cbc:ID>GST</cbc:ID></cac:TaxScheme>
+			</cac:PartyTaxScheme>
+			<cac:PartyLegalEntity><cbc:RegistrationName>Harbour Supplies Pte Ltd</cbc:RegistrationName></cac:PartyLegalEntity>
+		</cac:Party>
+	</cac:AccountingSupplierParty>
+	<cac:TaxTotal>
+		<cbc:TaxAmount currencyID="USD">90.00</cbc:TaxAmount>
+	</cac:TaxTotal>
+	<cac:TaxTotal>
+		<cbc:TaxAmount currencyID="SGD">121.50</cbc:TaxAmount>
+	</cac:TaxTotal>
+	<cac:LegalMonetaryTotal>
+		<cbc:LineExtensionAmount currencyID="USD">1000.00</cbc:LineExtensionAmount>
+		<cbc:TaxExclusiveAmount currencyID="USD">1000.00</cbc:TaxExclusiveAmount>
+		<cbc:TaxInclusiveAmount currencyID="USD">1090.00</cbc:TaxInclusiveAmount>
+		<cbc:PayableAmount currencyID="USD">1090.00</cbc:PayableAmount>
+	</cac:LegalMonetaryTotal>
+	<cac:InvoiceLine>
+		<cbc:ID>1</cbc:ID>
+		<cbc:InvoicedQuantity unitCode="TNE">80</cbc:InvoicedQuantity>
+		<cbc:LineExtensionAmount currencyID="USD">1000.00</cbc:LineExtensionAmount>
+		<cac:Item><cbc:Name>Fresh water delivery</cbc:Name></cac:Item>
+		<cac:Price><cbc:PriceAmount currencyID="USD">12.50</cbc:PriceAmount></cac:Price>
+	</cac:InvoiceLine>
+</Invoice>`
+
+func TestParseUBLInvoice(t *testing.T) {
+	input, err := ParseUBLInvoice(strings.NewReader(sampleUBLInvoice))
+	require.NoError(t, err)
+
+	assert.Equal(t, "INV-2024-0042", input.InvoiceNumber)
+	assert.Equal(t, model.InvoiceSourceUBL, input.Source)
+	assert.Equal(t, "USD", input.Currency)
+	assert.Equal(t, 1000.0, input.Subtotal)
+	assert.Equal(t, 90.0, input.TaxAmount)
+	assert.Equal(t, 1090.0, input.Total)
+	require.NotNil(t, input.IssueDate)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *input.IssueDate)
+	require.NotNil(t, input.DueDate)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), *input.DueDate)
+	require.NotNil(t, input.SupplierName)
+	assert.Equal(t, "Harbour Supplies Pte Ltd", *input.SupplierName)
+	require.NotNil(t, input.SupplierTaxID)
+	assert.Equal(t, "SG200012345A", *input.SupplierTaxID)
+
+	require.Len(t, input.Lines, 1)
+	assert.Equal(t, model.InvoiceLine{
+		Description: "Fresh water delivery",
+		Quantity:    80,
+		Unit:        "TNE",
+		UnitPrice:   12.5,
+		Amount:      1000,
+	}, input.Lines[0])
+}
+
+func TestParseUBLInvoice_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"not xml", "not an invoice"},
+		{"wrong root element", `<CreditNote><ID>CN-1</ID></CreditNote>`},
+		{"missing ID", `<Invoice><DocumentCurrencyCode>USD</DocumentCurrencyCode></Invoice>`},
+		{"bad amount", `<Invoice><ID>INV-1</ID><LegalMonetaryTotal><PayableAmount>abc</PayableAmount></LegalMonetaryTotal></Invoice>`},
+		{"bad date", `<Invoice><ID>INV-1</ID><IssueDate>01/03/2024</IssueDate></Invoice>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseUBLInvoice(strings.NewReader(tt.doc))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInvoiceMatchTolerance_Allows(t *testing.T) {
+	tolerance := model.InvoiceMatchTolerance{Amount: 1, Percent: 2}
+
+	assert.True(t, tolerance.Allows(1000, 1000))
+	assert.True(t, tolerance.Allows(10, 10.99))     // within amount
+	assert.True(t, tolerance.Allows(1000, 1019.99)) // within percent
+	assert.False(t, tolerance.Allows(1000, 1021))
+	assert.False(t, tolerance.Allows(10, 11.5))
+	assert.False(t, tolerance.Allows(0, 5))
+}
+
+func TestMatchInvoice(t *testing.T) {
+	tolerance := model.InvoiceMatchTolerance{Amount: 1, Percent: 2}
+
+	newOrder := func() *model.ServiceOrder {
+		return &model.ServiceOrder{
+			ID:          "so-1",
+			Currency:    "USD",
+			Quantity:    floatPtr(80),
+			QuotedPrice: floatPtr(1000),
+		}
+	}
+	newInvoice := func() *model.Invoice {
+		return &model.Invoice{
+			Currency:  "USD",
+			Subtotal:  1000,
+			TaxAmount: 90,
+			Total:     1090,
+			Lines: []model.InvoiceLine{
+				{Description: "Fresh water", Quantity: 80, UnitPrice: 12.5, Amount: 1000},
+			},
+		}
+	}
+	quote := &model.Quote{ID: "quote-1", TotalPrice: 1000, Currency: "USD"}
+
+	tests := []struct {
+		name       string
+		invoice    func(*model.Invoice)
+		order      func(*model.ServiceOrder)
+		quote      *model.Quote
+		wantStatus model.InvoiceMatchStatus
+		wantFields []string
+	}{
+		{
+			name:       "matches quote and order",
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMatched,
+		},
+		{
+			name: "within percentage tolerance",
+			invoice: func(i *model.Invoice) {
+				i.Subtotal = 1015
+				i.Lines[0].Amount = 1015
+				i.Lines[0].UnitPrice = 12.6875
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMatched,
+		},
+		{
+			name: "matches without quote",
+			order: func(o *model.ServiceOrder) {
+				o.FinalPrice = floatPtr(1000)
+			},
+			wantStatus: model.InvoiceMatchStatusMatched,
+		},
+		{
+			name: "overbilled against quote and order",
+			invoice: func(i *model.Invoice) {
+				i.Subtotal = 1100
+				i.Lines[0].Amount = 1100
+				i.Lines[0].UnitPrice = 13.75
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"quote_total", "quoted_price"},
+		},
+		{
+			name: "final price differs",
+			order: func(o *model.ServiceOrder) {
+				o.FinalPrice = floatPtr(900)
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"final_price"},
+		},
+		{
+			name: "order price differs from quote",
+			order: func(o *model.ServiceOrder) {
+				o.QuotedPrice = floatPtr(1200)
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"order_quoted_price", "quoted_price"},
+		},
+		{
+			name: "currency mismatch",
+			invoice: func(i *model.Invoice) {
+				i.Currency = "EUR"
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"currency", "quote_currency"},
+		},
+		{
+			name: "lines do not add up",
+			invoice: func(i *model.Invoice) {
+				i.Lines = append(i.Lines, model.InvoiceLine{Description: "Delivery fee", Quantity: 1, UnitPrice: 150, Amount: 150})
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"lines"},
+		},
+		{
+			name: "line amount is not quantity times price",
+			invoice: func(i *model.Invoice) {
+				i.Lines[0].UnitPrice = 15
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"lines[0].amount"},
+		},
+		{
+			name: "quantity differs from order",
+			invoice: func(i *model.Invoice) {
+				i.Lines[0].Quantity = 100
+				i.Lines[0].UnitPrice = 10
+			},
+			quote:      quote,
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"quantity"},
+		},
+		{
+			name: "nothing to match against",
+			order: func(o *model.ServiceOrder) {
+				o.QuotedPrice = nil
+			},
+			wantStatus: model.InvoiceMatchStatusMismatched,
+			wantFields: []string{"reference"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := newInvoice()
+			if tt.invoice != nil {
+				tt.invoice(invoice)
+			}
+			order := newOrder()
+			if tt.order != nil {
+				tt.order(order)
+			}
+
+			result := MatchInvoice(invoice, order, tt.quote, tolerance)
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tolerance, result.Tolerance)
+
+			var fields []string
+			for _, d := range result.Discrepancies {
+				fields = append(fields, d.Field)
+			}
+			assert.ElementsMatch(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestInvoiceService_ValidateInput(t *testing.T) {
+	svc := &InvoiceService{}
+	issued := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	due := issued.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name    string
+		input   model.CreateInvoiceInput
+		wantErr string
+	}{
+		{"valid", model.CreateInvoiceInput{InvoiceNumber: "INV-1", Currency: "USD", Total: 100}, ""},
+		{"missing number", model.CreateInvoiceInput{Currency: "USD", Total: 100}, "invoice_number is required"},
+		{"bad currency", model.CreateInvoiceInput{InvoiceNumber: "INV-1", Currency: "US", Total: 100}, "currency must be a 3-letter ISO code"},
+		{"zero total", model.CreateInvoiceInput{InvoiceNumber: "INV-1", Currency: "USD"}, "total must be greater than 0"},
+		{"due before issue", model.CreateInvoiceInput{InvoiceNumber: "INV-1", Currency: "USD", Total: 100, IssueDate: &issued, DueDate: &due}, "due_date must be on or after issue_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.validateInput(tt.input)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,31 +2,61 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/navo/pkg/audit"
+	apperrors "github.com/navo/pkg/errors"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/validation"
+	"github.com/navo/services/core/internal/middleware"
 	"github.com/navo/services/core/internal/model"
 	"github.com/navo/services/core/internal/repository"
 	"go.uber.org/zap"
 )
 
+// PortCallStore is where the PortCallService keeps port calls, implemented by
+// repository.PortCallRepository
+type PortCallStore interface {
+	Create(ctx context.Context, input model.CreatePortCallInput, createdBy string) (*model.PortCall, error)
+	GetByID(ctx context.Context, id string) (*model.PortCall, error)
+	List(ctx context.Context, filter model.PortCallFilter) ([]model.PortCall, int, error)
+	Update(ctx context.Context, id string, input model.UpdatePortCallInput) (*model.PortCall, error)
+	Delete(ctx context.Context, id string) error
+	GetServiceOrders(ctx context.Context, portCallID string) ([]model.ServiceOrder, error)
+	GetTimelineEvents(ctx context.Context, portCallID string) ([]model.TimelineEvent, error)
+	CreateTimelineEvent(ctx context.Context, event model.TimelineEvent) error
+	GetPortUNLOCODE(ctx context.Context, portID string) (string, error)
+	GetVesselDimensions(ctx context.Context, vesselID string) (*model.VesselDimensions, error)
+}
+
+// PortRegistry checks vessels against the limits of the ports it knows,
+// implemented by IntegrationClient
+type PortRegistry interface {
+	CheckPortRestrictions(ctx context.Context, unlocode string, vessel model.VesselDimensions) (*model.PortRestrictionCheck, error)
+}
+
 // PortCallService handles port call business logic
 type PortCallService struct {
-	repo        *repository.PortCallRepository
+	repo        PortCallStore
 	cache       *redis.Client
 	auditLogger audit.Logger
+	ports       PortRegistry
 }
 
 // PortCallServiceConfig holds configuration for the port call service
 type PortCallServiceConfig struct {
 	AuditLogger audit.Logger
+	// Ports checks new port calls against the port's restrictions. Port
+	// calls are not checked without it.
+	Ports PortRegistry
 }
 
 // NewPortCallService creates a new port call service
-func NewPortCallService(repo *repository.PortCallRepository, cache *redis.Client) *PortCallService {
+func NewPortCallService(repo PortCallStore, cache *redis.Client) *PortCallService {
 	return &PortCallService{
 		repo:  repo,
 		cache: cache,
@@ -34,13 +64,14 @@ func NewPortCallService(repo *repository.PortCallRepository, cache *redis.Client
 }
 
 // NewPortCallServiceWithConfig creates a new port call service with configuration
-func NewPortCallServiceWithConfig(repo *repository.PortCallRepository, cache *redis.Client, cfg *PortCallServiceConfig) *PortCallService {
+func NewPortCallServiceWithConfig(repo PortCallStore, cache *redis.Client, cfg *PortCallServiceConfig) *PortCallService {
 	svc := &PortCallService{
 		repo:  repo,
 		cache: cache,
 	}
 	if cfg != nil {
 		svc.auditLogger = cfg.AuditLogger
+		svc.ports = cfg.Ports
 	}
 	return svc
 }
@@ -65,6 +96,11 @@ func (s *PortCallService) Create(ctx context.Context, input model.CreatePortCall
 		}
 	}
 
+	// Validate vessel dimensions against port restrictions
+	if err := s.checkPortRestrictions(ctx, input.VesselID, input.PortID); err != nil {
+		return nil, err
+	}
+
 	portCall, err := s.repo.Create(ctx, input, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create port call: %w", err)
@@ -136,6 +172,10 @@ func (s *PortCallService) UpdateWithUser(ctx context.Context, id string, input m
 		return nil, fmt.Errorf("port call not found")
 	}
 
+	if isStale(input.Version, existing.Version) {
+		return nil, &ConflictError{Entity: "port call", Version: existing.Version, Current: existing}
+	}
+
 	// Validate status transitions
 	if input.Status != nil {
 		if err := s.validateStatusTransition(existing.Status, *input.Status); err != nil {
@@ -144,6 +184,9 @@ func (s *PortCallService) UpdateWithUser(ctx context.Context, id string, input m
 	}
 
 	portCall, err := s.repo.Update(ctx, id, input)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, s.versionConflict(ctx, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update port call: %w", err)
 	}
@@ -159,6 +202,16 @@ func (s *PortCallService) UpdateWithUser(ctx context.Context, id string, input m
 	return portCall, nil
 }
 
+// versionConflict reports an update that lost a race with another update,
+// along with the port call's current state
+func (s *PortCallService) versionConflict(ctx context.Context, id string) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Entity: "port call", Version: current.Version, Current: current}
+}
+
 // createUpdateTimelineEvents creates timeline events for port call updates
 func (s *PortCallService) createUpdateTimelineEvents(ctx context.Context, old, new *model.PortCall, input model.UpdatePortCallInput, userID string) {
 	// Status change
@@ -297,19 +350,40 @@ func (s *PortCallService) GetTimeline(ctx context.Context, portCallID string) ([
 
 // AddTimelineNote adds a note to the port call timeline
 func (s *PortCallService) AddTimelineNote(ctx context.Context, portCallID, note, userID string) (*model.TimelineEvent, error) {
+	return s.AddTimelineEvent(ctx, portCallID, model.AddTimelineEventInput{Description: note}, userID)
+}
+
+// AddTimelineEvent adds an event to the port call timeline, a note unless
+// the input says otherwise
+func (s *PortCallService) AddTimelineEvent(ctx context.Context, portCallID string, input model.AddTimelineEventInput, userID string) (*model.TimelineEvent, error) {
+	if details := validation.Struct(input); len(details) > 0 {
+		return nil, apperrors.NewValidationFields(details)
+	}
+
 	// Verify port call exists
-	if _, err := s.GetByID(ctx, portCallID); err != nil {
-		return nil, err
+	portCall, err := s.repo.GetByID(ctx, portCallID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port call: %w", err)
+	}
+	if portCall == nil {
+		return nil, apperrors.NewNotFound("port call")
 	}
 
 	event := model.TimelineEvent{
 		PortCallID:  portCallID,
-		EventType:   model.TimelineEventNoteAdded,
-		Title:       "Note added",
-		Description: note,
+		EventType:   input.EventType,
+		Title:       input.Title,
+		Description: input.Description,
+		Metadata:    input.Metadata,
 		CreatedBy:   userID,
 		CreatedAt:   time.Now().UTC(),
 	}
+	if event.EventType == "" {
+		event.EventType = model.TimelineEventNoteAdded
+	}
+	if event.Title == "" && event.EventType == model.TimelineEventNoteAdded {
+		event.Title = "Note added"
+	}
 
 	if err := s.repo.CreateTimelineEvent(ctx, event); err != nil {
 		return nil, fmt.Errorf("failed to create timeline event: %w", err)
@@ -345,6 +419,57 @@ func (s *PortCallService) validateStatusTransition(from, to model.PortCallStatus
 	return fmt.Errorf("cannot transition from %s to %s", from, to)
 }
 
+// checkPortRestrictions checks the vessel's particulars against the port's
+// limits in the port registry. Ports missing from the registry and vessels
+// without particulars are not checked. Failing to load either, or to reach
+// the registry, fails the check rather than let an oversized vessel through.
+func (s *PortCallService) checkPortRestrictions(ctx context.Context, vesselID, portID string) error {
+	if s.ports == nil {
+		return nil
+	}
+
+	unlocode, err := s.repo.GetPortUNLOCODE(ctx, portID)
+	if err != nil {
+		logger.Error("Failed to load port", zap.String("port_id", portID), zap.Error(err))
+		return apperrors.NewInternal(err)
+	}
+	if unlocode == "" {
+		return nil
+	}
+
+	dimensions, err := s.repo.GetVesselDimensions(ctx, vesselID)
+	if err != nil {
+		logger.Error("Failed to load vessel dimensions", zap.String("vessel_id", vesselID), zap.Error(err))
+		return apperrors.NewInternal(err)
+	}
+	if dimensions == nil || (dimensions.Draft == nil && dimensions.Length == nil) {
+		return nil
+	}
+
+	check, err := s.ports.CheckPortRestrictions(ctx, unlocode, *dimensions)
+	if err != nil {
+		logger.Error("Failed to check port restrictions", zap.String("unlocode", unlocode), zap.Error(err))
+		return apperrors.NewServiceUnavailable("port restrictions could not be checked, please try again")
+	}
+	if check == nil {
+		return nil
+	}
+	return validatePortRestrictions(*check)
+}
+
+// validatePortRestrictions returns the violations of a restriction check as
+// a validation error
+func validatePortRestrictions(check model.PortRestrictionCheck) error {
+	if len(check.Violations) == 0 {
+		return nil
+	}
+	violations := make([]string, len(check.Violations))
+	for i, v := range check.Violations {
+		violations[i] = v.String()
+	}
+	return apperrors.NewValidation(fmt.Sprintf("port %s restrictions not met: %s", check.UNLOCODE, strings.Join(violations, "; ")))
+}
+
 // invalidateCache invalidates cache for a workspace
 func (s *PortCallService) invalidateCache(ctx context.Context, workspaceID string) {
 	if s.cache != nil {
@@ -359,7 +484,7 @@ func (s *PortCallService) logAudit(ctx context.Context, action audit.Action, ent
 	}
 
 	event := audit.NewBuilder().
-		WithUser(userID, ""). // Org ID would be extracted from context in production
+		WithUser(userID, middleware.GetOrganizationID(ctx)).
 		WithAction(action).
 		WithEntity(entityType, entityID).
 		WithOldValue(oldValue).
@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	apperrors "github.com/navo/pkg/errors"
 	"github.com/navo/services/core/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockPortCallRepository is a mock implementation of the port call repository
@@ -64,6 +67,19 @@ func (m *MockPortCallRepository) CreateTimelineEvent(ctx context.Context, event
 	return args.Error(0)
 }
 
+func (m *MockPortCallRepository) GetPortUNLOCODE(ctx context.Context, portID string) (string, error) {
+	args := m.Called(ctx, portID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPortCallRepository) GetVesselDimensions(ctx context.Context, vesselID string) (*model.VesselDimensions, error) {
+	args := m.Called(ctx, vesselID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.VesselDimensions), args.Error(1)
+}
+
 func TestPortCallService_ValidateStatusTransition(t *testing.T) {
 	svc := &PortCallService{}
 
@@ -195,6 +211,110 @@ func TestPortCallService_Create_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// MockPortRegistry is a mock implementation of the port registry
+type MockPortRegistry struct {
+	mock.Mock
+}
+
+func (m *MockPortRegistry) CheckPortRestrictions(ctx context.Context, unlocode string, vessel model.VesselDimensions) (*model.PortRestrictionCheck, error) {
+	args := m.Called(ctx, unlocode, vessel)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PortRestrictionCheck), args.Error(1)
+}
+
+func TestPortCallService_Create_PortRestrictions(t *testing.T) {
+	ctx := context.Background()
+	input := model.CreatePortCallInput{
+		VesselID:    "vessel-1",
+		PortID:      "port-1",
+		WorkspaceID: "ws-1",
+	}
+	vessel := &model.VesselDimensions{VesselID: "vessel-1", Draft: floatPtr(14.5)}
+
+	newService := func(repo *MockPortCallRepository, registry *MockPortRegistry) *PortCallService {
+		return NewPortCallServiceWithConfig(repo, nil, &PortCallServiceConfig{Ports: registry})
+	}
+
+	t.Run("violations fail validation", func(t *testing.T) {
+		mockRepo, registry := new(MockPortCallRepository), new(MockPortRegistry)
+		mockRepo.On("GetPortUNLOCODE", ctx, "port-1").Return("NLRTM", nil)
+		mockRepo.On("GetVesselDimensions", ctx, "vessel-1").Return(vessel, nil)
+		registry.On("CheckPortRestrictions", ctx, "NLRTM", *vessel).Return(&model.PortRestrictionCheck{
+			UNLOCODE:   "NLRTM",
+			Violations: []model.PortRestrictionViolation{{Restriction: "max_draft", Limit: 12, Value: 14.5}},
+		}, nil)
+
+		result, err := newService(mockRepo, registry).Create(ctx, input, "user-1")
+
+		assert.Nil(t, result)
+		var appErr *apperrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+		assert.Equal(t, "port NLRTM restrictions not met: vessel draft 14.5m exceeds maximum draft 12.0m", appErr.Message)
+		mockRepo.AssertNotCalled(t, "Create", ctx, input, "user-1")
+	})
+
+	t.Run("registry failure is surfaced", func(t *testing.T) {
+		mockRepo, registry := new(MockPortCallRepository), new(MockPortRegistry)
+		mockRepo.On("GetPortUNLOCODE", ctx, "port-1").Return("NLRTM", nil)
+		mockRepo.On("GetVesselDimensions", ctx, "vessel-1").Return(vessel, nil)
+		registry.On("CheckPortRestrictions", ctx, "NLRTM", *vessel).Return(nil, errors.New("connection refused"))
+
+		_, err := newService(mockRepo, registry).Create(ctx, input, "user-1")
+
+		var appErr *apperrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeServiceUnavailable, appErr.Code)
+		mockRepo.AssertNotCalled(t, "Create", ctx, input, "user-1")
+	})
+
+	t.Run("lookup failure is surfaced", func(t *testing.T) {
+		mockRepo, registry := new(MockPortCallRepository), new(MockPortRegistry)
+		mockRepo.On("GetPortUNLOCODE", ctx, "port-1").Return("", errors.New("connection reset"))
+
+		_, err := newService(mockRepo, registry).Create(ctx, input, "user-1")
+
+		var appErr *apperrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeInternal, appErr.Code)
+		registry.AssertNotCalled(t, "CheckPortRestrictions", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Create", ctx, input, "user-1")
+	})
+
+	t.Run("unchecked without registry data", func(t *testing.T) {
+		for name, setup := range map[string]func(*MockPortCallRepository, *MockPortRegistry){
+			"port without UN/LOCODE": func(repo *MockPortCallRepository, _ *MockPortRegistry) {
+				repo.On("GetPortUNLOCODE", ctx, "port-1").Return("", nil)
+			},
+			"vessel without particulars": func(repo *MockPortCallRepository, _ *MockPortRegistry) {
+				repo.On("GetPortUNLOCODE", ctx, "port-1").Return("NLRTM", nil)
+				repo.On("GetVesselDimensions", ctx, "vessel-1").Return(&model.VesselDimensions{VesselID: "vessel-1"}, nil)
+			},
+			"port not in the registry": func(repo *MockPortCallRepository, registry *MockPortRegistry) {
+				repo.On("GetPortUNLOCODE", ctx, "port-1").Return("NLRTM", nil)
+				repo.On("GetVesselDimensions", ctx, "vessel-1").Return(vessel, nil)
+				registry.On("CheckPortRestrictions", ctx, "NLRTM", *vessel).Return(nil, nil)
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockRepo, registry := new(MockPortCallRepository), new(MockPortRegistry)
+				setup(mockRepo, registry)
+				mockRepo.On("Create", ctx, input, "user-1").Return(&model.PortCall{ID: "pc-1", WorkspaceID: "ws-1"}, nil)
+				mockRepo.On("CreateTimelineEvent", ctx, mock.AnythingOfType("model.TimelineEvent")).Return(nil)
+
+				result, err := newService(mockRepo, registry).Create(ctx, input, "user-1")
+
+				require.NoError(t, err)
+				assert.Equal(t, "pc-1", result.ID)
+				mockRepo.AssertExpectations(t)
+				registry.AssertExpectations(t)
+			})
+		}
+	})
+}
+
 func TestPortCallService_GetByID(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockPortCallRepository)
@@ -269,7 +389,7 @@ func TestPortCallService_List(t *testing.T) {
 	mockRepo := new(MockPortCallRepository)
 
 	filter := model.PortCallFilter{
-		WorkspaceID: "ws-1",
+		WorkspaceID: strPtr("ws-1"),
 		Page:        1,
 		PerPage:     10,
 	}
@@ -350,6 +470,59 @@ func TestPortCallService_ConfirmBerth(t *testing.T) {
 	assert.Equal(t, berthTerminal, *result.BerthTerminal)
 }
 
-func timePtr(t time.Time) *time.Time {
-	return &t
+func TestPortCallService_AddTimelineEvent(t *testing.T) {
+	ctx := context.Background()
+	note := model.AddTimelineEventInput{Description: "Pilot boarded"}
+
+	t.Run("note added", func(t *testing.T) {
+		mockRepo := new(MockPortCallRepository)
+		mockRepo.On("GetByID", ctx, "pc-1").Return(&model.PortCall{ID: "pc-1"}, nil).Once()
+		mockRepo.On("CreateTimelineEvent", ctx, mock.AnythingOfType("model.TimelineEvent")).Return(nil).Once()
+
+		svc := NewPortCallService(mockRepo, nil)
+		event, err := svc.AddTimelineEvent(ctx, "pc-1", note, "user-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, model.TimelineEventNoteAdded, event.EventType)
+		assert.Equal(t, "Note added", event.Title)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		mockRepo := new(MockPortCallRepository)
+
+		svc := NewPortCallService(mockRepo, nil)
+		_, err := svc.AddTimelineEvent(ctx, "pc-1", model.AddTimelineEventInput{}, "user-1")
+
+		var appErr *apperrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+		assert.Equal(t, 400, appErr.StatusCode)
+		assert.Contains(t, appErr.Details, "description")
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("port call not found", func(t *testing.T) {
+		mockRepo := new(MockPortCallRepository)
+		mockRepo.On("GetByID", ctx, "pc-999").Return(nil, nil).Once()
+
+		svc := NewPortCallService(mockRepo, nil)
+		_, err := svc.AddTimelineEvent(ctx, "pc-999", note, "user-1")
+
+		var appErr *apperrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 404, appErr.StatusCode)
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		mockRepo := new(MockPortCallRepository)
+		mockRepo.On("GetByID", ctx, "pc-1").Return(&model.PortCall{ID: "pc-1"}, nil).Once()
+		mockRepo.On("CreateTimelineEvent", ctx, mock.AnythingOfType("model.TimelineEvent")).Return(errors.New("connection reset")).Once()
+
+		svc := NewPortCallService(mockRepo, nil)
+		_, err := svc.AddTimelineEvent(ctx, "pc-1", note, "user-1")
+
+		require.Error(t, err)
+		var appErr *apperrors.AppError
+		assert.False(t, errors.As(err, &appErr), "failures are not client errors")
+	})
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,15 +12,32 @@ import (
 	"github.com/navo/services/core/internal/repository"
 )
 
+// RFQStore is where the RFQService keeps RFQs and their quotes, implemented by
+// repository.RFQRepository
+type RFQStore interface {
+	Create(ctx context.Context, input model.CreateRFQInput, createdBy string) (*model.RFQ, error)
+	GetByID(ctx context.Context, id string) (*model.RFQ, error)
+	GetByReference(ctx context.Context, reference string) (*model.RFQ, error)
+	List(ctx context.Context, filter model.RFQFilter) ([]model.RFQ, int, error)
+	Update(ctx context.Context, id string, input model.UpdateRFQInput) (*model.RFQ, error)
+	UpdateStatus(ctx context.Context, id string, status model.RFQStatus) error
+	GetQuotesByRFQ(ctx context.Context, rfqID string) ([]model.Quote, error)
+	GetQuoteByVendor(ctx context.Context, rfqID, vendorID string) (*model.Quote, error)
+	GetQuote(ctx context.Context, id string) (*model.Quote, error)
+	SubmitQuote(ctx context.Context, rfqID, vendorID string, input model.SubmitQuoteInput) (*model.Quote, error)
+	UpdateQuoteStatus(ctx context.Context, id string, status model.QuoteStatus) error
+	Award(ctx context.Context, rfqID, quoteID string) error
+}
+
 // RFQService handles RFQ business logic
 type RFQService struct {
-	repo        *repository.RFQRepository
+	repo        RFQStore
 	cache       *redis.Client
 	auditLogger audit.Logger
 }
 
 // NewRFQService creates a new RFQ service
-func NewRFQService(repo *repository.RFQRepository, cache *redis.Client) *RFQService {
+func NewRFQService(repo RFQStore, cache *redis.Client) *RFQService {
 	return &RFQService{
 		repo:  repo,
 		cache: cache,
@@ -116,12 +134,19 @@ func (s *RFQService) Update(ctx context.Context, id string, input model.UpdateRF
 		return nil, err
 	}
 
+	if isStale(input.Version, existing.Version) {
+		return nil, &ConflictError{Entity: "RFQ", Version: existing.Version, Current: existing}
+	}
+
 	// Can only update draft RFQs
 	if existing.Status != model.RFQStatusDraft {
 		return nil, fmt.Errorf("can only update RFQs in draft status")
 	}
 
 	rfq, err := s.repo.Update(ctx, id, input)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, s.versionConflict(ctx, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update RFQ: %w", err)
 	}
@@ -142,6 +167,16 @@ func (s *RFQService) Update(ctx context.Context, id string, input model.UpdateRF
 	return rfq, nil
 }
 
+// versionConflict reports an update that lost a race with another update,
+// along with the RFQ's current state
+func (s *RFQService) versionConflict(ctx context.Context, id string) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Entity: "RFQ", Version: current.Version, Current: current}
+}
+
 // Publish publishes an RFQ (opens it for quotes)
 func (s *RFQService) Publish(ctx context.Context, id string, userID, orgID string) (*model.RFQ, error) {
 	existing, err := s.GetByID(ctx, id)
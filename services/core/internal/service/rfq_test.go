@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/navo/services/core/internal/model"
+	"github.com/navo/services/core/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -253,6 +254,54 @@ func TestRFQService_Update_DraftOnly(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "draft status")
 	})
+
+	t.Run("stale version conflicts", func(t *testing.T) {
+		existingRFQ := &model.RFQ{
+			ID:      "rfq-3",
+			Status:  model.RFQStatusDraft,
+			Version: 4,
+		}
+
+		mockRepo.On("GetByID", ctx, "rfq-3").Return(existingRFQ, nil).Once()
+
+		svc := NewRFQService(mockRepo, nil)
+		staleVersion := 3
+		input := model.UpdateRFQInput{Version: &staleVersion}
+		_, err := svc.Update(ctx, "rfq-3", input, "user-1", "org-1")
+
+		assert.ErrorIs(t, err, repository.ErrVersionConflict)
+		conflict, ok := AsConflict(err)
+		assert.True(t, ok)
+		assert.Equal(t, 4, conflict.Version)
+		assert.Equal(t, existingRFQ, conflict.Current)
+	})
+
+	t.Run("concurrent update conflicts", func(t *testing.T) {
+		existingRFQ := &model.RFQ{
+			ID:      "rfq-4",
+			Status:  model.RFQStatusDraft,
+			Version: 2,
+		}
+		currentRFQ := &model.RFQ{
+			ID:      "rfq-4",
+			Status:  model.RFQStatusDraft,
+			Version: 3,
+		}
+
+		mockRepo.On("GetByID", ctx, "rfq-4").Return(existingRFQ, nil).Once()
+		mockRepo.On("Update", ctx, "rfq-4", mock.AnythingOfType("model.UpdateRFQInput")).Return(nil, repository.ErrVersionConflict).Once()
+		mockRepo.On("GetByID", ctx, "rfq-4").Return(currentRFQ, nil).Once()
+
+		svc := NewRFQService(mockRepo, nil)
+		version := 2
+		input := model.UpdateRFQInput{Version: &version}
+		_, err := svc.Update(ctx, "rfq-4", input, "user-1", "org-1")
+
+		conflict, ok := AsConflict(err)
+		assert.True(t, ok)
+		assert.Equal(t, 3, conflict.Version)
+		assert.Equal(t, currentRFQ, conflict.Current)
+	})
 }
 
 func TestRFQService_Publish(t *testing.T) {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,15 +12,27 @@ import (
 	"github.com/navo/services/core/internal/repository"
 )
 
+// ServiceOrderStore is where the ServiceOrderService keeps service orders, implemented by
+// repository.ServiceOrderRepository
+type ServiceOrderStore interface {
+	Create(ctx context.Context, input model.CreateServiceOrderInput, createdBy string) (*model.ServiceOrder, error)
+	GetByID(ctx context.Context, id string) (*model.ServiceOrder, error)
+	List(ctx context.Context, filter model.ServiceOrderFilter) ([]model.ServiceOrder, int, error)
+	Update(ctx context.Context, id string, input model.UpdateServiceOrderInput) (*model.ServiceOrder, error)
+	Delete(ctx context.Context, id string) error
+	Confirm(ctx context.Context, id string, vendorID string, quotedPrice float64) (*model.ServiceOrder, error)
+	Complete(ctx context.Context, id string, finalPrice *float64) (*model.ServiceOrder, error)
+}
+
 // ServiceOrderService handles service order business logic
 type ServiceOrderService struct {
-	repo        *repository.ServiceOrderRepository
+	repo        ServiceOrderStore
 	cache       *redis.Client
 	auditLogger audit.Logger
 }
 
 // NewServiceOrderService creates a new service order service
-func NewServiceOrderService(repo *repository.ServiceOrderRepository, cache *redis.Client) *ServiceOrderService {
+func NewServiceOrderService(repo ServiceOrderStore, cache *redis.Client) *ServiceOrderService {
 	return &ServiceOrderService{
 		repo:  repo,
 		cache: cache,
@@ -101,6 +114,10 @@ func (s *ServiceOrderService) Update(ctx context.Context, id string, input model
 		return nil, fmt.Errorf("service order not found")
 	}
 
+	if isStale(input.Version, existing.Version) {
+		return nil, &ConflictError{Entity: "service order", Version: existing.Version, Current: existing}
+	}
+
 	// Validate status transitions
 	if input.Status != nil {
 		if err := s.validateStatusTransition(existing.Status, *input.Status); err != nil {
@@ -109,6 +126,9 @@ func (s *ServiceOrderService) Update(ctx context.Context, id string, input model
 	}
 
 	order, err := s.repo.Update(ctx, id, input)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, s.versionConflict(ctx, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update service order: %w", err)
 	}
@@ -129,6 +149,16 @@ func (s *ServiceOrderService) Update(ctx context.Context, id string, input model
 	return order, nil
 }
 
+// versionConflict reports an update that lost a race with another update,
+// along with the order's current state
+func (s *ServiceOrderService) versionConflict(ctx context.Context, id string) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Entity: "service order", Version: current.Version, Current: current}
+}
+
 // Delete deletes a service order
 func (s *ServiceOrderService) Delete(ctx context.Context, id string, userID, orgID string) error {
 	// Get existing order
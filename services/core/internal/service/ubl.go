@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navo/services/core/internal/model"
+)
+
+// ublInvoice maps the subset of a UBL 2.1 (and Peppol BIS 3) invoice needed for matching.
+// Elements are matched by local name so both prefixed and default namespaces parse.
+type ublInvoice struct {
+	XMLName              xml.Name      `xml:"Invoice"`
+	ID                   string        `xml:"ID"`
+	IssueDate            string        `xml:"IssueDate"`
+	DueDate              string        `xml:"DueDate"`
+	DocumentCurrencyCode string        `xml:"DocumentCurrencyCode"`
+	Supplier             ublParty      `xml:"AccountingSupplierParty>Party"`
+	TaxTotals            []ublTaxTotal `xml:"TaxTotal"`
+	MonetaryTotal        ublMonetary   `xml:"LegalMonetaryTotal"`
+	Lines                []ublLine     `xml:"InvoiceLine"`
+	PaymentMeans         []ublPayment  `xml:"PaymentMeans"`
+}
+
+type ublParty struct {
+	Names            []string `xml:"PartyName>Name"`
+	RegistrationName string   `xml:"PartyLegalEntity>RegistrationName"`
+	CompanyID        string   `xml:"PartyTaxScheme>CompanyID"`
+}
+
+type ublAmount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+type ublQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount ublAmount `xml:"TaxAmount"`
+}
+
+type ublMonetary struct {
+	LineExtensionAmount ublAmount `xml:"LineExtensionAmount"`
+	TaxExclusiveAmount  ublAmount `xml:"TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount `xml:"TaxInclusiveAmount"`
+	PayableAmount       ublAmount `xml:"PayableAmount"`
+}
+
+type ublLine struct {
+	ID                  string      `xml:"ID"`
+	InvoicedQuantity    ublQuantity `xml:"InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"LineExtensionAmount"`
+	ItemName            string      `xml:"Item>Name"`
+	ItemDescription     string      `xml:"Item>Description"`
+	PriceAmount         ublAmount   `xml:"Price>PriceAmount"`
+}
+
+type ublPayment struct {
+	PaymentDueDate string `xml:"PaymentDueDate"`
+}
+
+// ParseUBLInvoice parses a UBL 2.1 invoice document into invoice input
+func ParseUBLInvoice(r io.Reader) (*model.CreateInvoiceInput, error) {
+	var doc ublInvoice
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid UBL invoice: %w", err)
+	}
+
+	input := &model.CreateInvoiceInput{
+		InvoiceNumber: strings.TrimSpace(doc.ID),
+		Source:        model.InvoiceSourceUBL,
+		Currency:      strings.ToUpper(strings.TrimSpace(doc.DocumentCurrencyCode)),
+	}
+	if input.InvoiceNumber == "" {
+		return nil, fmt.Errorf("invalid UBL invoice: missing invoice ID")
+	}
+
+	var err error
+	if input.IssueDate, err = parseUBLDate(doc.IssueDate); err != nil {
+		return nil, fmt.Errorf("invalid UBL invoice: issue date: %w", err)
+	}
+	dueDate := doc.DueDate
+	if dueDate == "" && len(doc.PaymentMeans) > 0 {
+		dueDate = doc.PaymentMeans[0].PaymentDueDate
+	}
+	if input.DueDate, err = parseUBLDate(dueDate); err != nil {
+		return nil, fmt.Errorf("invalid UBL invoice: due date: %w", err)
+	}
+
+	// Supplier
+	supplierName := doc.Supplier.RegistrationName
+	if supplierName == "" && len(doc.Supplier.Names) > 0 {
+		supplierName = doc.Supplier.Names[0]
+	}
+	if name := strings.TrimSpace(supplierName); name != "" {
+		input.SupplierName = &name
+	}
+	if taxID := strings.TrimSpace(doc.Supplier.CompanyID); taxID != "" {
+		input.SupplierTaxID = &taxID
+	}
+
+	// Totals
+	if input.Subtotal, err = parseUBLAmount(doc.MonetaryTotal.TaxExclusiveAmount, doc.MonetaryTotal.LineExtensionAmount); err != nil {
+		return nil, fmt.Errorf("invalid UBL invoice: tax exclusive amount: %w", err)
+	}
+	if input.Total, err = parseUBLAmount(doc.MonetaryTotal.TaxInclusiveAmount, doc.MonetaryTotal.PayableAmount); err != nil {
+		return nil, fmt.Errorf("invalid UBL invoice: tax inclusive amount: %w", err)
+	}
+	for _, tt := range doc.TaxTotals {
+		// Only the tax total in the document currency counts; a second
+		// TaxTotal may carry the tax in the accounting currency.
+		if tt.TaxAmount.CurrencyID != "" && input.Currency != "" && !strings.EqualFold(tt.TaxAmount.CurrencyID, input.Currency) {
+			continue
+		}
+		amount, err := parseUBLAmount(tt.TaxAmount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UBL invoice: tax amount: %w", err)
+		}
+		input.TaxAmount = amount
+		break
+	}
+	if input.Total == 0 {
+		input.Total = input.Subtotal + input.TaxAmount
+	}
+	if input.Currency == "" {
+		input.Currency = strings.ToUpper(doc.MonetaryTotal.PayableAmount.CurrencyID)
+	}
+
+	// Lines
+	for _, l := range doc.Lines {
+		line := model.InvoiceLine{
+			Description: strings.TrimSpace(l.ItemName),
+			Unit:        strings.TrimSpace(l.InvoicedQuantity.UnitCode),
+		}
+		if line.Description == "" {
+			line.Description = strings.TrimSpace(l.ItemDescription)
+		}
+		if line.Quantity, err = parseUBLNumber(l.InvoicedQuantity.Value); err != nil {
+			return nil, fmt.Errorf("invalid UBL invoice: line %s quantity: %w", l.ID, err)
+		}
+		if line.UnitPrice, err = parseUBLAmount(l.PriceAmount); err != nil {
+			return nil, fmt.Errorf("invalid UBL invoice: line %s price: %w", l.ID, err)
+		}
+		if line.Amount, err = parseUBLAmount(l.LineExtensionAmount); err != nil {
+			return nil, fmt.Errorf("invalid UBL invoice: line %s amount: %w", l.ID, err)
+		}
+		input.Lines = append(input.Lines, line)
+	}
+
+	return input, nil
+}
+
+// parseUBLAmount returns the first non-empty amount
+func parseUBLAmount(amounts ...ublAmount) (float64, error) {
+	for _, a := range amounts {
+		if strings.TrimSpace(a.Value) != "" {
+			return parseUBLNumber(a.Value)
+		}
+	}
+	return 0, nil
+}
+
+func parseUBLNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseUBLDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
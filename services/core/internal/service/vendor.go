@@ -7,20 +7,48 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/navo/pkg/audit"
+	"github.com/navo/services/core/internal/middleware"
 	"github.com/navo/services/core/internal/model"
-	"github.com/navo/services/core/internal/repository"
 )
 
+// VendorStore is where the VendorService keeps vendors and their invitations, implemented by
+// repository.VendorRepository
+type VendorStore interface {
+	Create(ctx context.Context, vendor *model.Vendor) error
+	GetByID(ctx context.Context, id string) (*model.Vendor, error)
+	GetByEmail(ctx context.Context, email string) (*model.Vendor, error)
+	Update(ctx context.Context, vendor *model.Vendor) error
+	List(ctx context.Context, filter model.VendorFilter) (*model.VendorListResult, error)
+	IsVendorInOperatorList(ctx context.Context, operatorOrgID, vendorID string) (bool, error)
+	AddToOperatorList(ctx context.Context, entry *model.OperatorVendorList) error
+	RemoveFromOperatorList(ctx context.Context, operatorOrgID, vendorID string) error
+	CreateInvitation(ctx context.Context, inv *model.VendorInvitation) error
+	GetInvitationByToken(ctx context.Context, token string) (*model.VendorInvitation, error)
+	GetInvitationByID(ctx context.Context, id string) (*model.VendorInvitation, error)
+	UpdateInvitation(ctx context.Context, inv *model.VendorInvitation) error
+	ListInvitations(ctx context.Context, filter model.InvitationFilter) (*model.InvitationListResult, error)
+	SetVerified(ctx context.Context, vendorID string, verified bool) error
+	SetCertified(ctx context.Context, vendorID string, certified bool) error
+}
+
 // VendorService handles vendor business logic
 type VendorService struct {
-	repo *repository.VendorRepository
+	repo        VendorStore
+	auditLogger audit.Logger
 }
 
 // NewVendorService creates a new vendor service
-func NewVendorService(repo *repository.VendorRepository) *VendorService {
+func NewVendorService(repo VendorStore) *VendorService {
 	return &VendorService{repo: repo}
 }
 
+// WithAuditLogger sets the audit logger for the service
+func (s *VendorService) WithAuditLogger(logger audit.Logger) *VendorService {
+	s.auditLogger = logger
+	return s
+}
+
 // Create creates a new vendor
 func (s *VendorService) Create(ctx context.Context, input model.CreateVendorInput, organizationID string) (*model.Vendor, error) {
 	vendor := &model.Vendor{
@@ -49,6 +77,8 @@ func (s *VendorService) Create(ctx context.Context, input model.CreateVendorInpu
 		return nil, fmt.Errorf("failed to create vendor: %w", err)
 	}
 
+	s.logAudit(ctx, audit.ActionCreate, vendor.ID, nil, vendor)
+
 	return vendor, nil
 }
 
@@ -63,6 +93,7 @@ func (s *VendorService) Update(ctx context.Context, id string, input model.Updat
 	if err != nil {
 		return nil, fmt.Errorf("vendor not found: %w", err)
 	}
+	existing := *vendor
 
 	if input.Name != nil {
 		vendor.Name = *input.Name
@@ -90,6 +121,8 @@ func (s *VendorService) Update(ctx context.Context, id string, input model.Updat
 		return nil, fmt.Errorf("failed to update vendor: %w", err)
 	}
 
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, &existing, vendor)
+
 	return vendor, nil
 }
 
@@ -315,7 +348,14 @@ func (s *VendorService) SetVerified(ctx context.Context, vendorID string, verifi
 		return nil, fmt.Errorf("failed to update verified status: %w", err)
 	}
 
-	return s.repo.GetByID(ctx, vendorID)
+	vendor, err := s.repo.GetByID(ctx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, nil, vendor)
+
+	return vendor, nil
 }
 
 // SetCertified sets the certified status of a vendor (admin only after document review)
@@ -324,7 +364,14 @@ func (s *VendorService) SetCertified(ctx context.Context, vendorID string, certi
 		return nil, fmt.Errorf("failed to update certified status: %w", err)
 	}
 
-	return s.repo.GetByID(ctx, vendorID)
+	vendor, err := s.repo.GetByID(ctx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, nil, vendor)
+
+	return vendor, nil
 }
 
 // SubmitCertification submits certification documents for review
@@ -334,6 +381,8 @@ func (s *VendorService) SubmitCertification(ctx context.Context, vendorID string
 		return nil, fmt.Errorf("vendor not found: %w", err)
 	}
 
+	existing := *vendor
+
 	certification := model.Certification{
 		Name:        input.Name,
 		Issuer:      input.Issuer,
@@ -349,6 +398,8 @@ func (s *VendorService) SubmitCertification(ctx context.Context, vendorID string
 		return nil, fmt.Errorf("failed to add certification: %w", err)
 	}
 
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, &existing, vendor)
+
 	// TODO: Notify admin for review
 
 	return vendor, nil
@@ -376,12 +427,15 @@ func (s *VendorService) Activate(ctx context.Context, vendorID string) (*model.V
 		return nil, fmt.Errorf("vendor not found: %w", err)
 	}
 
+	existing := *vendor
 	vendor.Status = model.VendorStatusActive
 
 	if err := s.repo.Update(ctx, vendor); err != nil {
 		return nil, fmt.Errorf("failed to activate vendor: %w", err)
 	}
 
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, &existing, vendor)
+
 	return vendor, nil
 }
 
@@ -392,15 +446,39 @@ func (s *VendorService) Suspend(ctx context.Context, vendorID string) (*model.Ve
 		return nil, fmt.Errorf("vendor not found: %w", err)
 	}
 
+	existing := *vendor
 	vendor.Status = model.VendorStatusSuspended
 
 	if err := s.repo.Update(ctx, vendor); err != nil {
 		return nil, fmt.Errorf("failed to suspend vendor: %w", err)
 	}
 
+	s.logAudit(ctx, audit.ActionUpdate, vendor.ID, &existing, vendor)
+
 	return vendor, nil
 }
 
+// logAudit logs an audit event if the audit logger is configured. Only
+// the new value is required: the history view diffs against the previous
+// event when the old value is missing.
+func (s *VendorService) logAudit(ctx context.Context, action audit.Action, vendorID string, oldValue, newValue any) {
+	if s.auditLogger == nil {
+		return
+	}
+
+	builder := audit.NewBuilder().
+		WithUser(middleware.GetUserID(ctx), middleware.GetOrganizationID(ctx)).
+		WithAction(action).
+		WithEntity(audit.EntityVendor, vendorID).
+		WithNewValue(newValue).
+		WithRequestContext(ctx)
+	if oldValue != nil {
+		builder.WithOldValue(oldValue)
+	}
+
+	s.auditLogger.LogAsync(ctx, builder.Build())
+}
+
 // Helper function
 func timePtr(t time.Time) *time.Time {
 	return &t
@@ -11,9 +11,11 @@ import (
 	"github.com/navo/pkg/auth"
 	"github.com/navo/pkg/database"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
 	"github.com/navo/pkg/redis"
 	"github.com/navo/services/gateway/internal/config"
 	"github.com/navo/services/gateway/internal/router"
+	"github.com/navo/services/gateway/internal/upstream"
 	"go.uber.org/zap"
 )
 
@@ -37,8 +39,15 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(ctx, observability.DefaultConfig("gateway"))
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
 	// Connect to PostgreSQL
-	_, err := database.Connect(ctx, database.DefaultConfig())
+	_, err = database.Connect(ctx, database.DefaultConfig())
 	if err != nil {
 		log.Fatal("Failed to connect to database", zap.Error(err))
 	}
@@ -51,8 +60,23 @@ func main() {
 	}
 	defer redis.Close()
 
+	// Upstream services, probed in the background until shutdown
+	opts := upstream.DefaultOptions()
+	opts.Retries = cfg.UpstreamRetries
+	upstreams, err := upstream.NewRegistry(cfg.ServiceURLs(), cfg.RequiredUpstreams, opts)
+	if err != nil {
+		log.Fatal("Failed to configure upstreams", zap.Error(err))
+	}
+	upstreams.StartHealthChecks(ctx, upstream.ProbeInterval)
+
+	routeTimeouts, err := upstream.ParseRouteTimeouts(cfg.RouteTimeouts)
+	if err != nil {
+		log.Fatal("Failed to parse route timeouts", zap.Error(err))
+	}
+	timeouts := upstream.NewTimeouts(cfg.UpstreamTimeout, append(routeTimeouts, upstream.DefaultRouteTimeouts...))
+
 	// Setup router
-	r := router.Setup(cfg)
+	r := router.Setup(cfg, upstreams, timeouts)
 
 	// Create server
 	srv := &http.Server{
@@ -77,6 +101,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
+	cancel() // Stops the health probes
 
 	// Graceful shutdown with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
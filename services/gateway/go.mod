@@ -6,6 +6,7 @@ require (
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/go-chi/cors v1.2.1
 	github.com/go-chi/httprate v0.9.0
+	github.com/graphql-go/graphql v0.8.1
 	github.com/navo/pkg v0.0.0
 )
 
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds gateway configuration
@@ -10,7 +13,8 @@ type Config struct {
 	Port    string
 	Version string
 
-	// Service URLs
+	// Service URLs. Services with several instances list their URLs
+	// separated by commas, and requests are balanced over them.
 	AuthServiceURL         string
 	CoreServiceURL         string
 	VesselServiceURL       string
@@ -18,13 +22,38 @@ type Config struct {
 	RealtimeServiceURL     string
 	NotificationServiceURL string
 	AnalyticsServiceURL    string
+	IntegrationServiceURL  string
+
+	// Upstreams. Requests to services time out after UpstreamTimeout, or
+	// as RouteTimeouts ("METHOD /path=duration,...") say for their route,
+	// and idempotent ones are retried up to UpstreamRetries times. The
+	// gateway is only ready while RequiredUpstreams are.
+	UpstreamTimeout   time.Duration
+	RouteTimeouts     string
+	UpstreamRetries   int
+	RequiredUpstreams []string
 
 	// CORS
 	AllowedOrigins []string
 
-	// Rate limiting
+	// Rate limiting. Unauthenticated requests are limited per IP to
+	// RateLimit requests per RateLimitTTL seconds; authenticated ones by the
+	// plan of their organization, DefaultPlan unless its settings say
+	// otherwise.
 	RateLimit    int
 	RateLimitTTL int
+	DefaultPlan  string
+
+	// GraphQL. Queries nested deeper than GraphQLMaxDepth or costing more
+	// than GraphQLMaxCost are refused, and persisted queries are kept for
+	// GraphQLPersistedQueryTTL after their last use.
+	GraphQLMaxDepth          int
+	GraphQLMaxCost           int
+	GraphQLPersistedQueryTTL time.Duration
+
+	// API docs. The services' OpenAPI documents are merged at most once per
+	// DocsCacheTTL.
+	DocsCacheTTL time.Duration
 }
 
 // Load returns configuration from environment variables
@@ -41,6 +70,12 @@ func Load() *Config {
 		RealtimeServiceURL:     getEnv("REALTIME_SERVICE_URL", "http://localhost:4005"),
 		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:4006"),
 		AnalyticsServiceURL:    getEnv("ANALYTICS_SERVICE_URL", "http://localhost:4007"),
+		IntegrationServiceURL:  getEnv("INTEGRATION_SERVICE_URL", "http://localhost:4008"),
+
+		UpstreamTimeout:   getDuration("UPSTREAM_TIMEOUT", 10*time.Second),
+		RouteTimeouts:     getEnv("ROUTE_TIMEOUTS", ""),
+		UpstreamRetries:   getInt("UPSTREAM_RETRIES", 2),
+		RequiredUpstreams: strings.Split(getEnv("REQUIRED_UPSTREAMS", "auth,core"), ","),
 
 		AllowedOrigins: []string{
 			"http://localhost:3000",
@@ -53,6 +88,27 @@ func Load() *Config {
 
 		RateLimit:    100,
 		RateLimitTTL: 60,
+		DefaultPlan:  getEnv("RATE_LIMIT_DEFAULT_PLAN", "standard"),
+
+		GraphQLMaxDepth:          getInt("GRAPHQL_MAX_DEPTH", 10),
+		GraphQLMaxCost:           getInt("GRAPHQL_MAX_COST", 1000),
+		GraphQLPersistedQueryTTL: getDuration("GRAPHQL_PERSISTED_QUERY_TTL", 7*24*time.Hour),
+
+		DocsCacheTTL: getDuration("DOCS_CACHE_TTL", 5*time.Minute),
+	}
+}
+
+// ServiceURLs returns the instance URLs of each upstream service by name
+func (c *Config) ServiceURLs() map[string]string {
+	return map[string]string{
+		"auth":         c.AuthServiceURL,
+		"core":         c.CoreServiceURL,
+		"vessel":       c.VesselServiceURL,
+		"vendor":       c.VendorServiceURL,
+		"realtime":     c.RealtimeServiceURL,
+		"notification": c.NotificationServiceURL,
+		"analytics":    c.AnalyticsServiceURL,
+		"integration":  c.IntegrationServiceURL,
 	}
 }
 
@@ -62,3 +118,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
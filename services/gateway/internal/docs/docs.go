@@ -0,0 +1,311 @@
+// Package docs serves the gateway's API documentation: the OpenAPI
+// documents of the services behind it, merged into one and trimmed to the
+// routes the gateway serves.
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/gateway/internal/upstream"
+	"go.uber.org/zap"
+)
+
+// maxDocumentSize is the largest service document read
+const maxDocumentSize = 10 << 20
+
+const refPrefix = "#/components/schemas/"
+
+// Handler serves the merged OpenAPI document
+type Handler struct {
+	upstreams *upstream.Registry
+	services  []string
+	routes    chi.Routes
+	ttl       time.Duration
+	timeout   time.Duration
+	version   string
+
+	mu      sync.Mutex
+	doc     []byte
+	expires time.Time
+}
+
+// NewHandler creates the handler of the documents of services, fetched from
+// the registry's upstreams within timeout. Only operations routes matches
+// are documented. Merged documents are cached for ttl.
+func NewHandler(upstreams *upstream.Registry, services []string, routes chi.Routes, version string, ttl, timeout time.Duration) *Handler {
+	return &Handler{
+		upstreams: upstreams,
+		services:  services,
+		routes:    routes,
+		ttl:       ttl,
+		timeout:   timeout,
+		version:   version,
+	}
+}
+
+// ServeHTTP serves the merged document as JSON
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.doc == nil || time.Now().After(h.expires) {
+		ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+		defer cancel()
+
+		doc, err := h.merge(ctx)
+		if err != nil {
+			logger.Warn("API docs unavailable", zap.Error(err))
+			response.Error(w, errors.NewServiceUnavailable("API docs are unavailable"))
+			return
+		}
+		h.doc, h.expires = doc, time.Now().Add(h.ttl)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.doc)
+}
+
+// merge fetches the services' documents and merges them. Services whose
+// document is unavailable are left out.
+func (h *Handler) merge(ctx context.Context) ([]byte, error) {
+	m := &merger{
+		routes:  h.routes,
+		paths:   make(map[string]openapi.PathItem),
+		schemas: make(map[string]*openapi.Schema),
+	}
+	for _, service := range h.services {
+		doc, err := h.fetch(ctx, service)
+		if err != nil {
+			logger.Warn("Service API docs unavailable", zap.String("service", service), zap.Error(err))
+			continue
+		}
+		m.add(service, doc)
+	}
+	if len(m.paths) == 0 {
+		return nil, stderrors.New("no service API docs are available")
+	}
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Navo API",
+			Description: "The Navo platform API. Requests are authenticated with a bearer token from /api/v1/auth/login, except logging in, refreshing tokens and resetting passwords.",
+			Version:     h.version,
+		},
+		Servers:  []openapi.Server{{URL: "/"}},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+		Tags:     m.tags(),
+		Paths:    m.paths,
+		Components: &openapi.Components{
+			Schemas: m.used(),
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	return json.Marshal(doc)
+}
+
+// fetch fetches a service's OpenAPI document
+func (h *Handler) fetch(ctx context.Context, service string) (*openapi.Document, error) {
+	u := h.upstreams.Get(service)
+	if u == nil {
+		return nil, fmt.Errorf("%s is not configured", service)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+service+"/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = "" // Sent to the instance's host, not the service name
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := u.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", service, resp.StatusCode)
+	}
+	var doc openapi.Document
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid %s document: %w", service, err)
+	}
+	return &doc, nil
+}
+
+// merger merges service documents
+type merger struct {
+	routes  chi.Routes
+	paths   map[string]openapi.PathItem
+	schemas map[string]*openapi.Schema
+}
+
+// add merges the operations of a service's document that the gateway
+// routes. Its schemas named like a different schema of another service are
+// renamed "service.Name".
+func (m *merger) add(service string, doc *openapi.Document) {
+	var schemas map[string]*openapi.Schema
+	if doc.Components != nil {
+		schemas = doc.Components.Schemas
+	}
+
+	// Renaming a schema changes the schemas referring to it, which may then
+	// collide in turn
+	renames := make(map[string]string)
+	for {
+		renamed := make(map[string]string)
+		for _, name := range sortedNames(schemas) {
+			if _, ok := renames[name]; ok {
+				continue
+			}
+			if existing, ok := m.schemas[name]; ok && !reflect.DeepEqual(existing, schemas[name]) {
+				renamed[name] = service + "." + name
+			}
+		}
+		if len(renamed) == 0 {
+			break
+		}
+		for _, s := range schemas {
+			rename(s, renamed)
+		}
+		for name, to := range renamed {
+			renames[name] = to
+		}
+	}
+	for name, s := range schemas {
+		if to, ok := renames[name]; ok {
+			name = to
+		}
+		m.schemas[name] = s
+	}
+
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if !m.routes.Match(chi.NewRouteContext(), strings.ToUpper(method), path) {
+				continue
+			}
+			if m.paths[path] == nil {
+				m.paths[path] = make(openapi.PathItem)
+			}
+			if _, ok := m.paths[path][method]; ok {
+				continue // Served by an earlier service
+			}
+			for _, s := range operationSchemas(op) {
+				rename(s, renames)
+			}
+			m.paths[path][method] = op
+		}
+	}
+}
+
+// used returns the schemas the merged operations refer to, directly or
+// through other schemas
+func (m *merger) used() map[string]*openapi.Schema {
+	used := make(map[string]*openapi.Schema)
+	var visit func(s *openapi.Schema)
+	visit = func(s *openapi.Schema) {
+		walk(s, func(s *openapi.Schema) {
+			name := strings.TrimPrefix(s.Ref, refPrefix)
+			if s.Ref == "" || used[name] != nil || m.schemas[name] == nil {
+				return
+			}
+			used[name] = m.schemas[name]
+			visit(m.schemas[name])
+		})
+	}
+	for _, item := range m.paths {
+		for _, op := range item {
+			for _, s := range operationSchemas(op) {
+				visit(s)
+			}
+		}
+	}
+	return used
+}
+
+// tags returns the tags of the merged operations
+func (m *merger) tags() []openapi.Tag {
+	seen := make(map[string]bool)
+	var tags []openapi.Tag
+	for _, item := range m.paths {
+		for _, op := range item {
+			for _, name := range op.Tags {
+				if !seen[name] {
+					seen[name] = true
+					tags = append(tags, openapi.Tag{Name: name})
+				}
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+// operationSchemas returns the schemas of an operation's parameters and
+// bodies
+func operationSchemas(op *openapi.OperationObject) []*openapi.Schema {
+	var schemas []*openapi.Schema
+	for _, param := range op.Parameters {
+		schemas = append(schemas, param.Schema)
+	}
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			schemas = append(schemas, media.Schema)
+		}
+	}
+	for _, resp := range op.Responses {
+		for _, media := range resp.Content {
+			schemas = append(schemas, media.Schema)
+		}
+	}
+	return schemas
+}
+
+// rename rewrites a schema's references to renamed schemas
+func rename(s *openapi.Schema, renames map[string]string) {
+	walk(s, func(s *openapi.Schema) {
+		if to, ok := renames[strings.TrimPrefix(s.Ref, refPrefix)]; ok && s.Ref != "" {
+			s.Ref = refPrefix + to
+		}
+	})
+}
+
+// walk calls fn for a schema and the schemas nested in it
+func walk(s *openapi.Schema, fn func(*openapi.Schema)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+	for _, property := range s.Properties {
+		walk(property, fn)
+	}
+	walk(s.Items, fn)
+	walk(s.AdditionalProperties, fn)
+}
+
+func sortedNames(schemas map[string]*openapi.Schema) []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,169 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/gateway/internal/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const coreDocument = `{
+	"openapi": "3.1.0",
+	"info": {"title": "Navo Core Service", "version": "1.0.0"},
+	"paths": {
+		"/api/v1/port-calls/{id}": {
+			"get": {"responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PortCall"}}}}}}
+		},
+		"/health": {
+			"get": {"responses": {"200": {"description": "OK"}}}
+		}
+	},
+	"components": {"schemas": {
+		"PortCall": {"type": "object", "properties": {"id": {"type": "string"}, "vessel": {"$ref": "#/components/schemas/Vessel"}}},
+		"Vessel": {"type": "object", "properties": {"id": {"type": "string"}}},
+		"ErrorMessage": {"type": "object", "properties": {"error": {"type": "string"}}},
+		"Unused": {"type": "object"}
+	}}
+}`
+
+const vesselDocument = `{
+	"openapi": "3.1.0",
+	"info": {"title": "Navo Vessel Service", "version": "1.0.0"},
+	"paths": {
+		"/api/v1/vessels/{id}": {
+			"get": {"responses": {
+				"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Fleet"}}}},
+				"default": {"description": "Error", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ErrorMessage"}}}}
+			}},
+			"delete": {"responses": {"204": {"description": "No Content"}}}
+		}
+	},
+	"components": {"schemas": {
+		"Fleet": {"type": "object", "properties": {"vessels": {"type": "array", "items": {"$ref": "#/components/schemas/Vessel"}}}},
+		"Vessel": {"type": "object", "properties": {"id": {"type": "string"}, "imo": {"type": "string"}}},
+		"ErrorMessage": {"type": "object", "properties": {"error": {"type": "string"}}}
+	}}
+}`
+
+// serveDocument starts a service serving an OpenAPI document, counting the
+// requests for it
+func serveDocument(t *testing.T, document string, requests *atomic.Int32) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi.json" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(document))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// gatewayRoutes are the routes of a gateway serving some of the services'
+// operations
+func gatewayRoutes() chi.Routes {
+	r := chi.NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/port-calls/{id}", noop)
+		r.Get("/vessels/{id}", noop)
+	})
+	return r
+}
+
+func newTestHandler(t *testing.T, services map[string]string, names []string) *Handler {
+	t.Helper()
+	upstreams, err := upstream.NewRegistry(services, nil, upstream.Options{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		Transport:        http.DefaultTransport,
+	})
+	require.NoError(t, err)
+	return NewHandler(upstreams, names, gatewayRoutes(), "1.2.3", time.Minute, 5*time.Second)
+}
+
+func getDocument(t *testing.T, h http.Handler) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	return rec.Code, doc
+}
+
+func TestMerge(t *testing.T) {
+	var requests atomic.Int32
+	h := newTestHandler(t, map[string]string{
+		"core":   serveDocument(t, coreDocument, &requests),
+		"vessel": serveDocument(t, vesselDocument, &requests),
+	}, []string{"core", "vessel"})
+
+	status, doc := getDocument(t, h)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Equal(t, "1.2.3", doc["info"].(map[string]any)["version"])
+
+	// Only operations the gateway routes are documented
+	paths := doc["paths"].(map[string]any)
+	assert.Len(t, paths, 2)
+	assert.Contains(t, paths, "/api/v1/port-calls/{id}")
+	assert.NotContains(t, paths, "/health")
+	vessel := paths["/api/v1/vessels/{id}"].(map[string]any)
+	assert.Contains(t, vessel, "get")
+	assert.NotContains(t, vessel, "delete")
+
+	// Different schemas of the same name are told apart by service, and
+	// references to them follow
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.Contains(t, schemas, "Vessel")
+	assert.Contains(t, schemas, "vessel.Vessel")
+	assert.Contains(t, schemas, "ErrorMessage")
+	assert.NotContains(t, schemas, "vessel.ErrorMessage")
+	assert.NotContains(t, schemas, "Unused")
+	fleet := schemas["Fleet"].(map[string]any)
+	items := fleet["properties"].(map[string]any)["vessels"].(map[string]any)["items"].(map[string]any)
+	assert.Equal(t, "#/components/schemas/vessel.Vessel", items["$ref"])
+	portCall := schemas["PortCall"].(map[string]any)
+	assert.Equal(t, "#/components/schemas/Vessel", portCall["properties"].(map[string]any)["vessel"].(map[string]any)["$ref"])
+
+	schemes := doc["components"].(map[string]any)["securitySchemes"].(map[string]any)
+	assert.Equal(t, "bearer", schemes["bearerAuth"].(map[string]any)["scheme"])
+
+	// The merge is cached
+	getDocument(t, h)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestMergeSkipsUnavailableServices(t *testing.T) {
+	var requests atomic.Int32
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	h := newTestHandler(t, map[string]string{
+		"core":   serveDocument(t, coreDocument, &requests),
+		"vendor": down.URL,
+	}, []string{"core", "vendor", "missing"})
+
+	status, doc := getDocument(t, h)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, doc["paths"], "/api/v1/port-calls/{id}")
+}
+
+func TestMergeWithoutServices(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+	h := newTestHandler(t, map[string]string{"core": down.URL}, []string{"core"})
+
+	status, doc := getDocument(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, false, doc["success"])
+}
@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/navo/services/gateway/internal/middleware"
+	"github.com/navo/services/gateway/internal/upstream"
+)
+
+// maxResponseSize is the largest upstream response read
+const maxResponseSize = 10 << 20
+
+// forwardedHeaders are the caller's headers passed on to upstreams
+var forwardedHeaders = []string{"X-Request-ID", "X-Workspace-ID", "Accept-Language"}
+
+// client fetches resources from upstream services as the caller
+type client struct {
+	upstreams *upstream.Registry
+}
+
+// get fetches a path from a service and returns its JSON, unwrapped from
+// the response envelope of services that use one. Missing resources are
+// nil.
+func (c *client) get(ctx context.Context, service, path string) (any, error) {
+	u := c.upstreams.Get(service)
+	if u == nil {
+		return nil, fmt.Errorf("%s is not configured", service)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+service+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = "" // Sent to the instance's host, not the service name
+	if s := sessionFrom(ctx); s != nil {
+		for _, name := range forwardedHeaders {
+			if value := s.header.Get(name); value != "" {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+	if claims := middleware.GetClaims(ctx); claims != nil {
+		req.Header.Set("X-User-ID", claims.UserID)
+		req.Header.Set("X-Organization-ID", claims.OrganizationID)
+		req.Header.Set("X-Portal-Type", claims.PortalType)
+		req.Header.Set("X-User-Roles", strings.Join(claims.Roles, ","))
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := u.RoundTrip(req)
+	if err != nil {
+		if stderrors.Is(err, upstream.ErrUnavailable) {
+			return nil, fmt.Errorf("%s is unavailable", service)
+		}
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s did not respond in time", service)
+		}
+		return nil, fmt.Errorf("failed to reach %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", service, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if message := errorMessage(body); message != "" {
+			return nil, fmt.Errorf("%s returned %d: %s", service, resp.StatusCode, message)
+		}
+		return nil, fmt.Errorf("%s returned %d", service, resp.StatusCode)
+	}
+
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", service, err)
+	}
+	return unwrap(payload), nil
+}
+
+// unwrap returns the data of a {"success": ..., "data": ...} envelope, or
+// the payload of services answering without one
+func unwrap(payload any) any {
+	envelope, ok := payload.(map[string]any)
+	if !ok {
+		return payload
+	}
+	if _, ok := envelope["success"].(bool); !ok {
+		return payload
+	}
+	return envelope["data"]
+}
+
+// errorMessage returns the message of an error response, whether a bare
+// {"error": "..."} or an envelope's {"error": {"message": "..."}}
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) != nil || payload.Error == nil {
+		return ""
+	}
+	var message string
+	if json.Unmarshal(payload.Error, &message) == nil {
+		return message
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(payload.Error, &detail)
+	return detail.Message
+}
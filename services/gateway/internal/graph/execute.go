@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Error codes reported in the extensions of request errors
+const (
+	codeBadRequest             = "BAD_REQUEST"
+	codeParseFailed            = "GRAPHQL_PARSE_FAILED"
+	codeValidationFailed       = "GRAPHQL_VALIDATION_FAILED"
+	codeBadUserInput           = "BAD_USER_INPUT"
+	codeQueryTooComplex        = "QUERY_TOO_COMPLEX"
+	codePersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+)
+
+// Request is a GraphQL request
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Options limits the queries executed
+type Options struct {
+	// MaxDepth is the deepest nesting of fields allowed, or 0 for no limit
+	MaxDepth int
+	// MaxCost is the highest query cost allowed, or 0 for no limit. Each
+	// field resolving an object costs 1, times the expected size of the
+	// lists it is in.
+	MaxCost int
+}
+
+// result is the response to a GraphQL request. Data is nil, and left out,
+// when the request failed before execution.
+type result struct {
+	Data   any                        `json:"data,omitempty"`
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// requestError returns a result failing the whole request
+func requestError(code string, errs ...gqlerrors.FormattedError) *result {
+	for i := range errs {
+		errs[i].Extensions = map[string]any{"code": code}
+	}
+	return &result{Errors: errs}
+}
+
+// execute parses, validates and executes a query. Field errors are reported
+// alongside the data; errors in the request itself leave Data nil.
+func execute(ctx context.Context, schema graphql.Schema, req Request, opts Options) *result {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return requestError(codeParseFailed, gqlerrors.FormatError(err))
+	}
+
+	op, err := operation(doc, req.OperationName)
+	if err != nil {
+		return requestError(codeValidationFailed, gqlerrors.NewFormattedError(err.Error()))
+	}
+	// graphql-go's validator recurses forever on fragment cycles
+	if err := fragmentCycle(doc); err != nil {
+		return requestError(codeValidationFailed, gqlerrors.NewFormattedError(err.Error()))
+	}
+	if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+		return requestError(codeValidationFailed, validation.Errors...)
+	}
+	if err := checkLimits(schema, doc, op, req.Variables, opts); err != nil {
+		return requestError(codeQueryTooComplex, gqlerrors.NewFormattedError(err.Error()))
+	}
+
+	executed := graphql.Execute(graphql.ExecuteParams{
+		Schema:        schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	if executed.Data == nil {
+		// Variables not matching their types fail before execution
+		return requestError(codeBadUserInput, executed.Errors...)
+	}
+	return &result{Data: executed.Data, Errors: executed.Errors}
+}
+
+// operation returns the operation of a document to execute. Only queries
+// are served.
+func operation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		candidate, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if op != nil {
+				return nil, errors.New("Must provide operation name if query contains multiple operations.")
+			}
+			op = candidate
+		} else if candidate.Name != nil && candidate.Name.Value == name {
+			op = candidate
+		}
+	}
+
+	if op == nil {
+		if name == "" {
+			return nil, errors.New("Must provide an operation.")
+		}
+		return nil, fmt.Errorf("Unknown operation named %q.", name)
+	}
+	if op.Operation != ast.OperationTypeQuery {
+		return nil, fmt.Errorf("Only queries are supported, not %ss.", op.Operation)
+	}
+	return op, nil
+}
+
+// fragmentCycle returns an error if a fragment spreads itself, directly or
+// through other fragments
+func fragmentCycle(doc *ast.Document) error {
+	spreads := make(map[string][]string)
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			spreads[fragment.Name.Value] = fragmentSpreads(selections(fragment.SelectionSet), nil)
+		}
+	}
+
+	// 0 unvisited, 1 on the current path, 2 done
+	state := make(map[string]int)
+	var visit func(name string) string
+	visit = func(name string) string {
+		switch state[name] {
+		case 1:
+			return name
+		case 2:
+			return ""
+		}
+		state[name] = 1
+		for _, spread := range spreads[name] {
+			if cycle := visit(spread); cycle != "" {
+				return cycle
+			}
+		}
+		state[name] = 2
+		return ""
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			if cycle := visit(fragment.Name.Value); cycle != "" {
+				return fmt.Errorf("Cannot spread fragment %q within itself.", cycle)
+			}
+		}
+	}
+	return nil
+}
+
+// fragmentSpreads appends the names of the fragments spread in set, including
+// within its fields and inline fragments
+func fragmentSpreads(set []ast.Selection, names []string) []string {
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			names = fragmentSpreads(selections(s.SelectionSet), names)
+		case *ast.FragmentSpread:
+			names = append(names, s.Name.Value)
+		case *ast.InlineFragment:
+			names = fragmentSpreads(selections(s.SelectionSet), names)
+		}
+	}
+	return names
+}
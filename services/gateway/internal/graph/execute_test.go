@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteRequestErrors(t *testing.T) {
+	schema := newSchema(&client{})
+
+	tests := []struct {
+		name  string
+		req   Request
+		code  string
+		error string
+	}{
+		{"syntax", Request{Query: `{ portCall(id: "1") { id }`}, codeParseFailed, "Syntax Error"},
+		{"unknown field", Request{Query: `{ portCall(id: "1") { draft } }`}, codeValidationFailed, `Cannot query field "draft" on type "PortCall"`},
+		{"missing argument", Request{Query: `{ portCall { id } }`}, codeValidationFailed, `argument "id" of type "ID!" is required`},
+		{"invalid argument", Request{Query: `{ portCalls(first: "two") { id } }`}, codeValidationFailed, `Argument "first" has invalid value "two"`},
+		{"missing selection", Request{Query: `{ portCall(id: "1") }`}, codeValidationFailed, `must have a sub selection`},
+		{"undefined variable", Request{Query: `{ portCall(id: $id) { id } }`}, codeValidationFailed, `Variable "$id" is not defined`},
+		{"fragment cycle", Request{Query: `{ portCall(id: "1") { ...A } } fragment A on PortCall { ...B } fragment B on PortCall { ...A }`}, codeValidationFailed, `Cannot spread fragment "A" within itself`},
+		{"mutation", Request{Query: `mutation { portCall(id: "1") { id } }`}, codeValidationFailed, "Only queries are supported"},
+		{"operation name", Request{Query: `query A { viewer { id } } query B { viewer { id } }`}, codeValidationFailed, "Must provide operation name"},
+		{"missing variable", Request{Query: `query($id: ID!) { portCall(id: $id) { id } }`}, codeBadUserInput, `"$id" of required type "ID!" was not provided`},
+		{"invalid variable", Request{Query: `query($first: Int) { portCalls(first: $first) { id } }`, Variables: map[string]any{"first": "many"}}, codeBadUserInput, `"$first" got invalid value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := execute(context.Background(), schema, tt.req, Options{})
+			assert.Nil(t, result.Data)
+			require.NotEmpty(t, result.Errors)
+			assert.Contains(t, result.Errors[0].Message, tt.error)
+			assert.Equal(t, tt.code, result.Errors[0].Extensions["code"])
+		})
+	}
+
+	result := execute(context.Background(), schema, Request{Query: `query A { viewer { id } } query B { viewer { id } }`, OperationName: "B"}, Options{})
+	assert.NotNil(t, result.Data, "operations are picked by name")
+}
+
+func TestLimits(t *testing.T) {
+	schema := newSchema(&client{})
+	query := `query($first: Int = 50) {
+		portCalls(first: $first) { ...PortCall vessel { name } }
+	}
+	fragment PortCall on PortCall { reference vessel { position { latitude } } }`
+
+	tests := []struct {
+		name      string
+		variables map[string]any
+		opts      Options
+		allowed   bool
+	}{
+		// portCalls 1, and a vessel and its position for each of 50 port calls
+		{"within cost", nil, Options{MaxCost: 101}, true},
+		{"over cost", nil, Options{MaxCost: 100}, false},
+		{"cost of a smaller page", map[string]any{"first": float64(5)}, Options{MaxCost: 11}, true},
+		// portCalls, vessel, position and latitude are four levels deep
+		{"over depth", nil, Options{MaxDepth: 3}, false},
+		{"within depth", nil, Options{MaxDepth: 4}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `{"query": ` + string(mustJSON(t, query)) + `, "variables": ` + string(mustJSON(t, tt.variables)) + `}`
+			var req Request
+			require.NoError(t, json.Unmarshal([]byte(doc), &req))
+
+			result := execute(context.Background(), schema, req, tt.opts)
+			if tt.allowed {
+				for _, err := range result.Errors {
+					assert.NotEqual(t, codeQueryTooComplex, err.Extensions["code"], err.Message)
+				}
+				return
+			}
+			require.Nil(t, result.Data)
+			assert.Equal(t, codeQueryTooComplex, result.Errors[0].Extensions["code"])
+		})
+	}
+
+	skipped := execute(context.Background(), schema, Request{
+		Query: `{ portCalls(first: 100) @skip(if: true) { vessel { name } } viewer { id } }`,
+	}, Options{MaxCost: 1})
+	assert.NotNil(t, skipped.Data, "skipped fields cost nothing")
+}
+
+func TestLoader(t *testing.T) {
+	var calls atomic.Int32
+	var keys [][]int
+	loader := NewLoader(func(ctx context.Context, batch []int) (map[int]string, error) {
+		calls.Add(1)
+		keys = append(keys, batch)
+		if batch[0] < 0 {
+			return nil, errors.New("failed")
+		}
+		values := make(map[int]string)
+		for _, k := range batch {
+			values[k] = "v" + string(rune('0'+k))
+		}
+		return values, nil
+	})
+	ctx := context.Background()
+
+	a, b, again := loader.Load(ctx, 1), loader.Load(ctx, 2), loader.Load(ctx, 1)
+	value, err := b()
+	require.NoError(t, err)
+	assert.Equal(t, "v2", value)
+	value, _ = a()
+	assert.Equal(t, "v1", value)
+	value, _ = again()
+	assert.Equal(t, "v1", value)
+	assert.Equal(t, [][]int{{1, 2}}, keys, "keys are deduplicated and fetched together")
+
+	value, _ = loader.Load(ctx, 2)()
+	assert.Equal(t, "v2", value, "values are cached")
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = loader.Load(ctx, -1)()
+	assert.Error(t, err)
+	_, err = loader.Load(ctx, -1)()
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "failed loads are retried")
+}
+
+func TestPersistedQueries(t *testing.T) {
+	s := &server{schema: newSchema(&client{}), queries: NewMemoryQueryStore()}
+	query := `{ me: viewer { id } }`
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	post := func(body string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body)))
+		var result map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return rec.Code, result
+	}
+	persisted := `"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}`
+
+	status, result := post(`{` + persisted + `}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "PersistedQueryNotFound", result["errors"].([]any)[0].(map[string]any)["message"])
+
+	status, _ = post(`{"query": "{ viewer { email } }", ` + persisted + `}`)
+	assert.Equal(t, http.StatusBadRequest, status, "the hash must match the query")
+
+	status, result = post(`{"query": ` + string(mustJSON(t, query)) + `, ` + persisted + `}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"me": nil}, result["data"])
+
+	status, result = post(`{` + persisted + `}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"me": nil}, result["data"], "stored queries run by hash")
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/graphql?query=%7B+viewer+%7D", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "invalid queries fail the request")
+	assert.NotContains(t, rec.Body.String(), `"data"`)
+
+	status, _ = post(`{}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func mustJSON(t *testing.T, value any) []byte {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,264 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navo/pkg/auth"
+	"github.com/navo/services/gateway/internal/middleware"
+	"github.com/navo/services/gateway/internal/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubs are stubbed core, vessel, integration and analytics services,
+// recording the requests they get
+type stubs struct {
+	mu       sync.Mutex
+	requests map[string]int
+	headers  []http.Header
+}
+
+func (s *stubs) record(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.RequestURI()]++
+	s.headers = append(s.headers, r.Header.Clone())
+}
+
+func (s *stubs) count(uri string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[uri]
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func newStubs(t *testing.T) (*stubs, map[string]string) {
+	s := &stubs{requests: make(map[string]int)}
+	serve := func(mux *http.ServeMux) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.record(r)
+			mux.ServeHTTP(w, r)
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	core := http.NewServeMux()
+	core.HandleFunc("GET /api/v1/port-calls", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": [
+			{"id": "pc-1", "reference": "PC-001", "vessel_id": "v-1", "port_id": "p-1", "port": {"id": "p-1", "name": "Rotterdam", "unlocode": "NLRTM", "country": "NL"}},
+			{"id": "pc-2", "reference": "PC-002", "vessel_id": "v-2", "port_id": "p-1", "port": {"id": "p-1", "name": "Rotterdam", "unlocode": "NLRTM", "country": "NL"}},
+			{"id": "pc-3", "reference": "PC-003", "vessel_id": "v-1", "port_id": "p-2", "port": {"id": "p-2", "name": "Harlingen", "unlocode": "NLHAR", "country": "NL"}}
+		], "meta": {"page": 1, "per_page": 3, "total": 3}}`)
+	})
+	core.HandleFunc("GET /api/v1/port-calls/pc-1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": {"id": "pc-1", "reference": "PC-001", "status": "confirmed", "vessel_id": "v-1", "port_id": "p-1", "port": {"id": "p-1", "name": "Rotterdam", "unlocode": "NLRTM", "country": "NL"}}}`)
+	})
+	core.HandleFunc("GET /api/v1/port-calls/pc-1/services", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": [{"id": "so-1", "status": "confirmed", "quoted_price": 1200.5}]}`)
+	})
+	core.HandleFunc("GET /api/v1/port-calls/pc-1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": [{"id": "te-1", "event_type": "status_change", "title": "Port call confirmed"}]}`)
+	})
+	core.HandleFunc("GET /api/v1/rfqs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": [{"id": "rfq-1", "reference": "RFQ-001", "quote_count": 1}]}`)
+	})
+	core.HandleFunc("GET /api/v1/rfqs/rfq-1/quotes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success": true, "data": [{"id": "q-1", "total_price": 980, "currency": "EUR"}]}`)
+	})
+
+	vessel := http.NewServeMux()
+	vessel.HandleFunc("GET /api/v1/vessels/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		writeJSON(w, http.StatusOK, `{"id": "`+id+`", "name": "Vessel `+id+`", "imo": "9074729"}`)
+	})
+	vessel.HandleFunc("GET /api/v1/vessels/{id}/position", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"latitude": 51.9, "longitude": 4.1, "speed": 12.5}`)
+	})
+
+	integration := http.NewServeMux()
+	integration.HandleFunc("GET /api/v1/ports/unlocode/NLRTM", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"id": "ip-1", "name": "Rotterdam", "un_locode": "NLRTM", "country": "Netherlands", "latitude": 51.95, "longitude": 4.14, "max_draft": 24}`)
+	})
+	integration.HandleFunc("GET /api/v1/ports/unlocode/{code}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, `{"error": "port not found"}`)
+	})
+	integration.HandleFunc("GET /api/v1/weather", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"temperature": 14.2, "wind_speed": 6.1, "description": "light rain"}`)
+	})
+
+	analytics := http.NewServeMux()
+	analytics.HandleFunc("GET /api/v1/analytics/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusInternalServerError, `{"error": "failed to get dashboard metrics"}`)
+	})
+
+	return s, map[string]string{
+		"core":        serve(core),
+		"vessel":      serve(vessel),
+		"integration": serve(integration),
+		"analytics":   serve(analytics),
+	}
+}
+
+// query runs a query as an authenticated agent
+func query(t *testing.T, services map[string]string, query string) map[string]any {
+	t.Helper()
+	upstreams, err := upstream.NewRegistry(services, nil, upstream.Options{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		Transport:        http.DefaultTransport,
+	})
+	require.NoError(t, err)
+	handler := NewHandler(upstreams, NewMemoryQueryStore(), Options{MaxDepth: 10, MaxCost: 1000}, 5*time.Second)
+
+	body, err := json.Marshal(Request{Query: query})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(string(body)))
+	req.Header.Set("X-Request-ID", "req-1")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ClaimsKey, &auth.Claims{
+		UserID:         "u-1",
+		OrganizationID: "org-1",
+		Email:          "agent@example.com",
+		Roles:          []string{"agent"},
+		PortalType:     "key",
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	return result
+}
+
+func TestPortCallPage(t *testing.T) {
+	s, services := newStubs(t)
+	result := query(t, services, `{
+		portCall(id: "pc-1") {
+			reference
+			status
+			vessel { name imo position { latitude speed } }
+			port { name unLocode latitude longitude maxDraft weather { temperature windSpeed description } }
+			serviceOrders { id status quotedPrice }
+			timeline { eventType title }
+			rfqs { reference quoteCount quotes { totalPrice currency } }
+		}
+	}`)
+
+	assert.Nil(t, result["errors"])
+	data, _ := json.Marshal(result["data"])
+	assert.JSONEq(t, `{"portCall": {
+		"reference": "PC-001",
+		"status": "confirmed",
+		"vessel": {"name": "Vessel v-1", "imo": "9074729", "position": {"latitude": 51.9, "speed": 12.5}},
+		"port": {"name": "Rotterdam", "unLocode": "NLRTM", "latitude": 51.95, "longitude": 4.14, "maxDraft": 24, "weather": {"temperature": 14.2, "windSpeed": 6.1, "description": "light rain"}},
+		"serviceOrders": [{"id": "so-1", "status": "confirmed", "quotedPrice": 1200.5}],
+		"timeline": [{"eventType": "status_change", "title": "Port call confirmed"}],
+		"rfqs": [{"reference": "RFQ-001", "quoteCount": 1, "quotes": [{"totalPrice": 980, "currency": "EUR"}]}]
+	}}`, string(data))
+
+	assert.Equal(t, 1, s.count("/api/v1/rfqs?per_page=100&port_call_id=pc-1"))
+	assert.Equal(t, 1, s.count("/api/v1/weather?lat=51.95&lon=4.14"))
+
+	// Upstreams are called as the user
+	require.NotEmpty(t, s.headers)
+	for _, header := range s.headers {
+		assert.Equal(t, "u-1", header.Get("X-User-ID"))
+		assert.Equal(t, "org-1", header.Get("X-Organization-ID"))
+		assert.Equal(t, "agent", header.Get("X-User-Roles"))
+		assert.Equal(t, "req-1", header.Get("X-Request-ID"))
+	}
+}
+
+func TestBatching(t *testing.T) {
+	s, services := newStubs(t)
+	result := query(t, services, `{
+		viewer { id email roles }
+		portCalls(first: 3) {
+			reference
+			vessel { name }
+			again: vessel { name }
+			port { name unLocode latitude }
+		}
+	}`)
+
+	assert.Nil(t, result["errors"], "ports missing from the registry are not errors")
+	data, _ := json.Marshal(result["data"])
+	assert.JSONEq(t, `{
+		"viewer": {"id": "u-1", "email": "agent@example.com", "roles": ["agent"]},
+		"portCalls": [
+			{"reference": "PC-001", "vessel": {"name": "Vessel v-1"}, "again": {"name": "Vessel v-1"}, "port": {"name": "Rotterdam", "unLocode": "NLRTM", "latitude": 51.95}},
+			{"reference": "PC-002", "vessel": {"name": "Vessel v-2"}, "again": {"name": "Vessel v-2"}, "port": {"name": "Rotterdam", "unLocode": "NLRTM", "latitude": 51.95}},
+			{"reference": "PC-003", "vessel": {"name": "Vessel v-1"}, "again": {"name": "Vessel v-1"}, "port": {"name": "Harlingen", "unLocode": "NLHAR", "latitude": null}}
+		]
+	}`, string(data))
+
+	assert.Equal(t, 1, s.count("/api/v1/port-calls?per_page=3"))
+	assert.Equal(t, 1, s.count("/api/v1/vessels/v-1"), "each vessel is fetched once")
+	assert.Equal(t, 1, s.count("/api/v1/vessels/v-2"))
+	assert.Equal(t, 1, s.count("/api/v1/ports/unlocode/NLRTM"), "ports are looked up by UN/LOCODE once")
+	assert.Equal(t, 1, s.count("/api/v1/ports/unlocode/NLHAR"))
+}
+
+func TestUpstreamErrors(t *testing.T) {
+	_, services := newStubs(t)
+	result := query(t, services, `{ viewer { organizationId } dashboard { activePortCalls } }`)
+
+	data := result["data"].(map[string]any)
+	assert.Equal(t, map[string]any{"organizationId": "org-1"}, data["viewer"], "other fields still resolve")
+	assert.Nil(t, data["dashboard"])
+
+	errs := result["errors"].([]any)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "analytics returned 500: failed to get dashboard metrics", errs[0].(map[string]any)["message"])
+	assert.Equal(t, []any{"dashboard"}, errs[0].(map[string]any)["path"])
+}
+
+func TestSchema(t *testing.T) {
+	schema := newSchema(&client{})
+	query := schema.QueryType()
+
+	portCall := query.Fields()["portCall"]
+	require.NotNil(t, portCall)
+	assert.Equal(t, "PortCall", portCall.Type.Name())
+	require.Len(t, portCall.Args, 1)
+	assert.Equal(t, "ID!", portCall.Args[0].Type.String())
+
+	portCalls := query.Fields()["portCalls"]
+	require.NotNil(t, portCalls)
+	assert.Equal(t, "[PortCall]", portCalls.Type.String())
+	args := make(map[string]string)
+	for _, arg := range portCalls.Args {
+		args[arg.Name()] = arg.Type.String()
+		if arg.Name() == "first" {
+			assert.Equal(t, 20, arg.DefaultValue)
+		}
+	}
+	assert.Equal(t, map[string]string{"first": "Int", "portId": "ID", "status": "String", "vesselId": "ID", "workspaceId": "ID"}, args)
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"id":          "id",
+		"unLocode":    "un_locode",
+		"maxLoa":      "max_loa",
+		"portCallID":  "port_call_id",
+		"HTTPStatus":  "http_status",
+		"avgQuotes10": "avg_quotes10",
+	} {
+		assert.Equal(t, want, snakeCase(name), name)
+	}
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/services/gateway/internal/upstream"
+	"go.uber.org/zap"
+)
+
+// maxRequestSize is the largest request body accepted
+const maxRequestSize = 1 << 20
+
+// NewHandler creates the GraphQL endpoint, resolving queries from the
+// registry's upstreams within timeout. Persisted queries are kept in
+// queries.
+func NewHandler(upstreams *upstream.Registry, queries QueryStore, opts Options, timeout time.Duration) http.Handler {
+	c := &client{upstreams: upstreams}
+	s := &server{schema: newSchema(c), queries: queries, opts: opts}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		header := r.Header.Clone()
+		if header.Get("X-Request-ID") == "" {
+			header.Set("X-Request-ID", chimiddleware.GetReqID(ctx))
+		}
+		ctx = context.WithValue(ctx, sessionKey{}, newSession(c, header))
+		s.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// server serves GraphQL queries over HTTP, as GET or POST requests.
+//
+// Clients may send the SHA-256 of a query in the persistedQuery extension
+// instead of the query. Unknown hashes are answered with a
+// PERSISTED_QUERY_NOT_FOUND error, and the client sends the query along with
+// its hash, which stores it for next time.
+type server struct {
+	schema  graphql.Schema
+	queries QueryStore
+	opts    Options
+}
+
+// httpRequest is a GraphQL request as sent over HTTP
+type httpRequest struct {
+	Request
+	Extensions struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// ServeHTTP implements http.Handler
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(r)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, requestError(codeBadRequest, gqlerrors.NewFormattedError(err.Error())))
+		return
+	}
+
+	var hash string
+	if pq := req.Extensions.PersistedQuery; pq != nil {
+		hash = pq.SHA256Hash
+		if req.Query == "" {
+			query, err := s.queries.Get(r.Context(), hash)
+			if err != nil {
+				logger.Warn("Persisted query store unavailable", zap.Error(err))
+			}
+			if query == "" {
+				// Clients look for this message to send the query again
+				writeResult(w, http.StatusOK, requestError(codePersistedQueryNotFound, gqlerrors.NewFormattedError("PersistedQueryNotFound")))
+				return
+			}
+			req.Query = query
+			hash = "" // Already stored
+		} else if sum := sha256.Sum256([]byte(req.Query)); hex.EncodeToString(sum[:]) != hash {
+			writeResult(w, http.StatusBadRequest, requestError(codeBadRequest, gqlerrors.NewFormattedError("provided sha does not match query")))
+			return
+		}
+	}
+	if req.Query == "" {
+		writeResult(w, http.StatusBadRequest, requestError(codeBadRequest, gqlerrors.NewFormattedError("GraphQL requests must contain a query or a persistedQuery extension")))
+		return
+	}
+
+	result := execute(r.Context(), s.schema, req.Request, s.opts)
+	if result.Data == nil {
+		writeResult(w, http.StatusBadRequest, result)
+		return
+	}
+
+	// Only queries that pass validation are persisted
+	if hash != "" {
+		if err := s.queries.Put(r.Context(), hash, req.Query); err != nil {
+			logger.Warn("Failed to persist query", zap.Error(err))
+		}
+	}
+	writeResult(w, http.StatusOK, result)
+}
+
+// readRequest reads a request from the query string of a GET request or
+// the JSON body of a POST
+func readRequest(r *http.Request) (*httpRequest, error) {
+	req := &httpRequest{}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, errInvalidParam("variables")
+			}
+		}
+		if extensions := query.Get("extensions"); extensions != "" {
+			if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+				return nil, errInvalidParam("extensions")
+			}
+		}
+		return req, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read request body")
+	}
+	if len(body) > maxRequestSize {
+		return nil, errors.New("request body too large")
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, errors.New("request body must be a JSON GraphQL request")
+	}
+	return req, nil
+}
+
+func errInvalidParam(name string) error {
+	return errors.New(name + " must be a JSON object")
+}
+
+func writeResult(w http.ResponseWriter, status int, result *result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.Error("Failed to write GraphQL response", zap.Error(err))
+	}
+}
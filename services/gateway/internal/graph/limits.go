@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize is the size assumed of lists without a "first" or
+// "limit" argument when costing a query
+const defaultListSize = 10
+
+// checkLimits refuses an operation nested deeper than opts.MaxDepth or
+// costing more than opts.MaxCost. Fields resolving an object each fetch
+// from a service, so they cost 1 for every item of the lists they are in;
+// scalar fields are read from their object and cost nothing.
+func checkLimits(schema graphql.Schema, doc *ast.Document, op *ast.OperationDefinition, variables map[string]any, opts Options) error {
+	if opts.MaxDepth <= 0 && opts.MaxCost <= 0 {
+		return nil
+	}
+
+	a := &analyzer{opts: opts, fragments: make(map[string]*ast.FragmentDefinition), vars: make(map[string]any)}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			a.vars[def.Variable.Name.Value] = def.DefaultValue.GetValue()
+		}
+	}
+	for name, value := range variables {
+		a.vars[name] = value
+	}
+
+	depth, cost := a.analyze(schema.QueryType(), selections(op.SelectionSet), 1, 1)
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return fmt.Errorf("Query is nested deeper than the maximum depth of %d.", opts.MaxDepth)
+	}
+	if opts.MaxCost > 0 && cost > opts.MaxCost {
+		return fmt.Errorf("Query cost exceeds the maximum cost of %d.", opts.MaxCost)
+	}
+	return nil
+}
+
+// analyzer measures an operation against the schema
+type analyzer struct {
+	opts      Options
+	fragments map[string]*ast.FragmentDefinition
+	vars      map[string]any
+}
+
+// fieldGroup is the fields selected under one response key
+type fieldGroup struct {
+	name   string
+	fields []*ast.Field
+}
+
+// children returns the merged selection sets of the group's fields
+func (g *fieldGroup) children() []ast.Selection {
+	var children []ast.Selection
+	for _, f := range g.fields {
+		children = append(children, selections(f.SelectionSet)...)
+	}
+	return children
+}
+
+// analyze returns the depth and cost of executing selections on type t,
+// stopping early once either exceeds the limits
+func (a *analyzer) analyze(t *graphql.Object, selections []ast.Selection, depth, multiplier int) (maxDepth, cost int) {
+	maxDepth = depth
+	for _, group := range a.collect(selections) {
+		def := t.Fields()[group.name]
+		if def == nil { // __typename and introspection
+			continue
+		}
+		named, list := namedType(def.Type)
+		object, ok := named.(*graphql.Object)
+		if !ok {
+			continue
+		}
+		cost += multiplier
+
+		if a.opts.MaxDepth > 0 && depth+1 > a.opts.MaxDepth {
+			return depth + 1, cost
+		}
+		childMultiplier := multiplier
+		if list {
+			childMultiplier *= a.listSize(def, group.fields[0])
+		}
+		childDepth, childCost := a.analyze(object, group.children(), depth+1, childMultiplier)
+		if childDepth > maxDepth {
+			maxDepth = childDepth
+		}
+		cost += childCost
+		if a.opts.MaxCost > 0 && cost > a.opts.MaxCost {
+			return maxDepth, cost
+		}
+	}
+	return maxDepth, cost
+}
+
+// collect groups the fields selected by response key, expanding fragments
+// and applying @skip and @include. Validation has checked fragments apply
+// to the type they are spread in.
+func (a *analyzer) collect(set []ast.Selection) []*fieldGroup {
+	var groups []*fieldGroup
+	byKey := make(map[string]*fieldGroup)
+	visited := make(map[string]bool)
+
+	var walk func(set []ast.Selection)
+	walk = func(set []ast.Selection) {
+		for _, selection := range set {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if !a.included(s.Directives) {
+					continue
+				}
+				key := s.Name.Value
+				if s.Alias != nil {
+					key = s.Alias.Value
+				}
+				if group, ok := byKey[key]; ok {
+					group.fields = append(group.fields, s)
+					continue
+				}
+				group := &fieldGroup{name: s.Name.Value, fields: []*ast.Field{s}}
+				byKey[key] = group
+				groups = append(groups, group)
+			case *ast.FragmentSpread:
+				name := s.Name.Value
+				if visited[name] || !a.included(s.Directives) {
+					continue
+				}
+				visited[name] = true
+				if fragment, ok := a.fragments[name]; ok {
+					walk(selections(fragment.SelectionSet))
+				}
+			case *ast.InlineFragment:
+				if a.included(s.Directives) {
+					walk(selections(s.SelectionSet))
+				}
+			}
+		}
+	}
+	walk(set)
+	return groups
+}
+
+// included evaluates @skip and @include
+func (a *analyzer) included(directives []*ast.Directive) bool {
+	for _, d := range directives {
+		for _, arg := range d.Arguments {
+			condition, _ := a.value(arg.Value).(bool)
+			if (d.Name.Value == "skip" && condition) || (d.Name.Value == "include" && !condition) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// listSize returns how many items a list field is expected to return: its
+// "first" or "limit" argument, or defaultListSize
+func (a *analyzer) listSize(def *graphql.FieldDefinition, field *ast.Field) int {
+	for _, name := range []string{"first", "limit"} {
+		var value any
+		for _, arg := range def.Args {
+			if arg.Name() == name {
+				value = arg.DefaultValue
+			}
+		}
+		for _, arg := range field.Arguments {
+			if arg.Name.Value == name {
+				value = a.value(arg.Value)
+			}
+		}
+		if n := toInt(value); n > 0 {
+			return n
+		}
+	}
+	return defaultListSize
+}
+
+// value returns the value of an argument, looking up variables
+func (a *analyzer) value(value ast.Value) any {
+	if variable, ok := value.(*ast.Variable); ok {
+		return a.vars[variable.Name.Value]
+	}
+	return value.GetValue()
+}
+
+// toInt returns an Int argument as parsed (a string), decoded from JSON (a
+// float64) or set in Go, or 0
+func toInt(value any) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// namedType returns the type a field's type wraps, and whether it is a list
+func namedType(t graphql.Type) (named graphql.Type, list bool) {
+	for {
+		switch wrapper := t.(type) {
+		case *graphql.NonNull:
+			t = wrapper.OfType
+		case *graphql.List:
+			list = true
+			t = wrapper.OfType
+		default:
+			return t, list
+		}
+	}
+}
+
+// selections returns the selections of a set, which may be nil
+func selections(set *ast.SelectionSet) []ast.Selection {
+	if set == nil {
+		return nil
+	}
+	return set.Selections
+}
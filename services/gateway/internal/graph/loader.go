@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchFunc loads the values of keys in one go. Keys missing from the
+// result resolve to the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches loads of values by key, so resolving a field of
+// every object in a list makes one fetch instead of one per object. Loaders
+// cache for their lifetime, so create one per request.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	batches map[K]*batch[K, V] // Batch each key was loaded in
+	pending *batch[K, V]       // Batch collecting keys, not yet fetched
+}
+
+// batch is a set of keys fetched together
+type batch[K comparable, V any] struct {
+	ctx    context.Context
+	keys   []K
+	once   sync.Once
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+// NewLoader creates a loader fetching with fetch
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, batches: make(map[K]*batch[K, V])}
+}
+
+// Load queues a key and returns a thunk for its value, which resolvers
+// return for graphql-go to call once the fields beside it are resolved. The
+// first thunk called fetches every key queued since the last fetch.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (any, error) {
+	l.mu.Lock()
+	b, ok := l.batches[key]
+	if !ok {
+		if l.pending == nil {
+			l.pending = &batch[K, V]{ctx: ctx, done: make(chan struct{})}
+		}
+		b = l.pending
+		b.keys = append(b.keys, key)
+		l.batches[key] = b
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		b.once.Do(func() { l.dispatch(b) })
+		<-b.done
+		if b.err != nil {
+			return nil, b.err
+		}
+		return b.values[key], nil
+	}
+}
+
+// dispatch fetches a batch. Failed batches are forgotten, so a later load
+// tries again.
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				b.err = fmt.Errorf("internal error: %v", r)
+			}
+		}()
+		b.values, b.err = l.fetch(b.ctx, b.keys)
+	}()
+	close(b.done)
+
+	if b.err != nil {
+		l.mu.Lock()
+		for _, key := range b.keys {
+			if l.batches[key] == b {
+				delete(l.batches, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentFetches bounds the upstream requests a batch makes at once
+const maxConcurrentFetches = 8
+
+// resource is something loaded from a service by key
+type resource struct {
+	service string
+	path    func(key string) string
+}
+
+// Resources loaded by key. The services have no batch endpoints, so a batch
+// fetches its distinct keys concurrently.
+var resources = map[string]resource{
+	"portCall": {"core", func(id string) string {
+		return "/api/v1/port-calls/" + url.PathEscape(id)
+	}},
+	"serviceOrders": {"core", func(portCallID string) string {
+		return "/api/v1/port-calls/" + url.PathEscape(portCallID) + "/services"
+	}},
+	"timeline": {"core", func(portCallID string) string {
+		return "/api/v1/port-calls/" + url.PathEscape(portCallID) + "/timeline"
+	}},
+	"rfqs": {"core", func(portCallID string) string {
+		return "/api/v1/rfqs?" + url.Values{"port_call_id": {portCallID}, "per_page": {"100"}}.Encode()
+	}},
+	"quotes": {"core", func(rfqID string) string {
+		return "/api/v1/rfqs/" + url.PathEscape(rfqID) + "/quotes"
+	}},
+	"vessel": {"vessel", func(id string) string {
+		return "/api/v1/vessels/" + url.PathEscape(id)
+	}},
+	"position": {"vessel", func(vesselID string) string {
+		return "/api/v1/vessels/" + url.PathEscape(vesselID) + "/position"
+	}},
+	"port": {"integration", func(id string) string {
+		return "/api/v1/ports/" + url.PathEscape(id)
+	}},
+	"portByUNLocode": {"integration", func(code string) string {
+		return "/api/v1/ports/unlocode/" + url.PathEscape(code)
+	}},
+	"weather": {"integration", func(coordinates string) string {
+		lat, lon, _ := strings.Cut(coordinates, ",")
+		return "/api/v1/weather?" + url.Values{"lat": {lat}, "lon": {lon}}.Encode()
+	}},
+}
+
+// session is the state of one GraphQL request: its loaders, which cache
+// for the request only, and the headers passed on to upstreams
+type session struct {
+	header  http.Header
+	loaders map[string]*Loader[string, any]
+}
+
+type sessionKey struct{}
+
+func newSession(c *client, header http.Header) *session {
+	s := &session{header: header, loaders: make(map[string]*Loader[string, any], len(resources))}
+	for name, res := range resources {
+		s.loaders[name] = NewLoader(c.fetchAll(res))
+	}
+	return s
+}
+
+func sessionFrom(ctx context.Context) *session {
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
+
+// load loads a resource by key through the request's loader
+func load(ctx context.Context, name, key string) func() (any, error) {
+	thunk := sessionFrom(ctx).loaders[name].Load(ctx, key)
+	return func() (any, error) {
+		value, err := thunk()
+		if err, ok := value.(error); ok {
+			return nil, err
+		}
+		return value, err
+	}
+}
+
+// fetchAll returns a batch function fetching each key of a resource. A key
+// that fails to load gets its error as its value, so only the fields that
+// need it fail.
+func (c *client) fetchAll(res resource) BatchFunc[string, any] {
+	return func(ctx context.Context, keys []string) (map[string]any, error) {
+		values := make(map[string]any, len(keys))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, maxConcurrentFetches)
+		for _, key := range keys {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(key string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				value, err := c.get(ctx, res.service, res.path(key))
+				if err != nil {
+					value = err
+				}
+				mu.Lock()
+				values[key] = value
+				mu.Unlock()
+			}(key)
+		}
+		wg.Wait()
+		return values, nil
+	}
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// QueryStore keeps persisted queries by the hex SHA-256 of their text
+type QueryStore interface {
+	// Get returns the query with a hash, or "" when none is stored
+	Get(ctx context.Context, hash string) (string, error)
+	// Put stores a query under its hash
+	Put(ctx context.Context, hash, query string) error
+}
+
+const persistedQueryPrefix = "navo:graphql:pq:"
+
+// RedisQueryStore keeps persisted queries in Redis, shared by every
+// gateway instance. Queries unused for the TTL are forgotten; clients send
+// them again when told they are not found.
+type RedisQueryStore struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+// NewRedisQueryStore creates a Redis-backed query store
+func NewRedisQueryStore(client redis.UniversalClient, ttl time.Duration) *RedisQueryStore {
+	return &RedisQueryStore{client: client, ttl: ttl}
+}
+
+// Get implements QueryStore, extending the query's TTL
+func (s *RedisQueryStore) Get(ctx context.Context, hash string) (string, error) {
+	query, err := s.client.GetEx(ctx, persistedQueryPrefix+hash, s.ttl).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get persisted query: %w", err)
+	}
+	return query, nil
+}
+
+// Put implements QueryStore
+func (s *RedisQueryStore) Put(ctx context.Context, hash, query string) error {
+	if err := s.client.Set(ctx, persistedQueryPrefix+hash, query, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store persisted query: %w", err)
+	}
+	return nil
+}
+
+// MemoryQueryStore keeps persisted queries in memory, for tests and single
+// instance deployments
+type MemoryQueryStore struct {
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+// NewMemoryQueryStore creates an empty in-memory query store
+func NewMemoryQueryStore() *MemoryQueryStore {
+	return &MemoryQueryStore{queries: make(map[string]string)}
+}
+
+// Get implements QueryStore
+func (s *MemoryQueryStore) Get(ctx context.Context, hash string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queries[hash], nil
+}
+
+// Put implements QueryStore
+func (s *MemoryQueryStore) Put(ctx context.Context, hash, query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries[hash] = query
+	return nil
+}
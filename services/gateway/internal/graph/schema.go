@@ -0,0 +1,397 @@
+// Package graph is the gateway's GraphQL API. It aggregates core, vessel,
+// integration and analytics, so a page such as a port call's loads in one
+// request instead of one per service.
+//
+// Queries are parsed, validated and executed by graphql-go; this package
+// holds the schema's resolvers and what the gateway adds around them:
+// persisted queries, depth and cost limits, and batched loading.
+//
+// Resolvers call the services' REST APIs as the caller, forwarding the
+// claims of their token like the proxies do, so the services authorize
+// every read. Related objects are loaded through per-request loaders,
+// which fetch each distinct key once however often it is selected.
+package graph
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/navo/services/gateway/internal/middleware"
+)
+
+// maxPageSize is the most port calls a portCalls query returns
+const maxPageSize = 100
+
+// jsonScalar passes any JSON value through
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize:   func(value any) any { return value },
+	ParseValue:  func(value any) any { return value },
+	ParseLiteral: func(value ast.Value) any {
+		return value.GetValue()
+	},
+})
+
+// newSchema creates the schema, resolving through c
+func newSchema(c *client) graphql.Schema {
+	viewer := object("Viewer", "The authenticated user", graphql.Fields{
+		"id":             {Type: graphql.ID, Resolve: fromSource("user_id")},
+		"email":          {Type: graphql.String},
+		"organizationId": {Type: graphql.ID},
+		"portalType":     {Type: graphql.String},
+		"roles":          {Type: graphql.NewList(graphql.String)},
+		"workspaceIds":   {Type: graphql.NewList(graphql.ID)},
+	})
+
+	position := object("Position", "A vessel's latest reported position", graphql.Fields{
+		"latitude":         {Type: graphql.Float},
+		"longitude":        {Type: graphql.Float},
+		"heading":          {Type: graphql.Float},
+		"course":           {Type: graphql.Float},
+		"speed":            {Type: graphql.Float, Description: "Speed in knots"},
+		"destination":      {Type: graphql.String},
+		"eta":              {Type: graphql.String},
+		"navigationStatus": {Type: graphql.String},
+		"source":           {Type: graphql.String},
+		"recordedAt":       {Type: graphql.String},
+	})
+
+	vessel := object("Vessel", "A vessel of the fleet", graphql.Fields{
+		"id":          {Type: graphql.ID},
+		"name":        {Type: graphql.String},
+		"imo":         {Type: graphql.String},
+		"mmsi":        {Type: graphql.String},
+		"flag":        {Type: graphql.String},
+		"type":        {Type: graphql.String},
+		"status":      {Type: graphql.String},
+		"workspaceId": {Type: graphql.ID},
+		"details":     {Type: jsonScalar},
+		"createdAt":   {Type: graphql.String},
+		"updatedAt":   {Type: graphql.String},
+		"position":    {Type: position, Resolve: related("current_position", "position", "id")},
+	})
+
+	weather := object("Weather", "Current weather at a port", graphql.Fields{
+		"temperature":    {Type: graphql.Float, Description: "Celsius"},
+		"feelsLike":      {Type: graphql.Float, Description: "Celsius"},
+		"humidity":       {Type: graphql.Int},
+		"pressure":       {Type: graphql.Int},
+		"windSpeed":      {Type: graphql.Float, Description: "Meters per second"},
+		"windDirection":  {Type: graphql.Int},
+		"windGust":       {Type: graphql.Float},
+		"visibility":     {Type: graphql.Int, Description: "Meters"},
+		"cloudCover":     {Type: graphql.Int},
+		"description":    {Type: graphql.String},
+		"waveHeight":     {Type: graphql.Float, Description: "Meters"},
+		"wavePeriod":     {Type: graphql.Float, Description: "Seconds"},
+		"seaTemperature": {Type: graphql.Float, Description: "Celsius"},
+		"alerts":         {Type: jsonScalar},
+		"recordedAt":     {Type: graphql.String},
+	})
+
+	port := object("Port", "A port", graphql.Fields{
+		"id":          {Type: graphql.ID},
+		"unLocode":    {Type: graphql.String},
+		"name":        {Type: graphql.String},
+		"country":     {Type: graphql.String},
+		"countryCode": {Type: graphql.String},
+		"latitude":    {Type: graphql.Float},
+		"longitude":   {Type: graphql.Float},
+		"timezone":    {Type: graphql.String},
+		"portType":    {Type: graphql.String},
+		"portSize":    {Type: graphql.String},
+		"maxDraft":    {Type: graphql.Float, Description: "Meters"},
+		"maxLoa":      {Type: graphql.Float, Description: "Meters"},
+		"weather":     {Type: weather, Resolve: portWeather},
+	})
+
+	quote := object("Quote", "A vendor's quote for an RFQ", graphql.Fields{
+		"id":           {Type: graphql.ID},
+		"rfqId":        {Type: graphql.ID},
+		"vendorId":     {Type: graphql.ID},
+		"status":       {Type: graphql.String},
+		"unitPrice":    {Type: graphql.Float},
+		"totalPrice":   {Type: graphql.Float},
+		"currency":     {Type: graphql.String},
+		"paymentTerms": {Type: graphql.String},
+		"deliveryDate": {Type: graphql.String},
+		"validUntil":   {Type: graphql.String},
+		"notes":        {Type: graphql.String},
+		"submittedAt":  {Type: graphql.String},
+	})
+
+	rfq := object("RFQ", "A request for quotes from vendors", graphql.Fields{
+		"id":             {Type: graphql.ID},
+		"reference":      {Type: graphql.String},
+		"portCallId":     {Type: graphql.ID},
+		"serviceTypeId":  {Type: graphql.ID},
+		"status":         {Type: graphql.String},
+		"description":    {Type: graphql.String},
+		"quantity":       {Type: graphql.Float},
+		"unit":           {Type: graphql.String},
+		"deliveryDate":   {Type: graphql.String},
+		"deadline":       {Type: graphql.String},
+		"invitedVendors": {Type: graphql.NewList(graphql.ID)},
+		"awardedQuoteId": {Type: graphql.ID},
+		"awardedAt":      {Type: graphql.String},
+		"quoteCount":     {Type: graphql.Int},
+		"createdAt":      {Type: graphql.String},
+		"quotes":         {Type: graphql.NewList(quote), Resolve: related("quotes", "quotes", "id")},
+	})
+
+	serviceOrder := object("ServiceOrder", "A service ordered for a port call", graphql.Fields{
+		"id":             {Type: graphql.ID},
+		"portCallId":     {Type: graphql.ID},
+		"serviceTypeId":  {Type: graphql.ID},
+		"status":         {Type: graphql.String},
+		"description":    {Type: graphql.String},
+		"quantity":       {Type: graphql.Float},
+		"unit":           {Type: graphql.String},
+		"specifications": {Type: jsonScalar},
+		"requestedDate":  {Type: graphql.String},
+		"confirmedDate":  {Type: graphql.String},
+		"completedDate":  {Type: graphql.String},
+		"vendorId":       {Type: graphql.ID},
+		"quotedPrice":    {Type: graphql.Float},
+		"finalPrice":     {Type: graphql.Float},
+		"currency":       {Type: graphql.String},
+		"rfqId":          {Type: graphql.ID},
+		"paymentStatus":  {Type: graphql.String},
+		"createdAt":      {Type: graphql.String},
+		"updatedAt":      {Type: graphql.String},
+	})
+
+	timelineEvent := object("TimelineEvent", "An event in a port call's history", graphql.Fields{
+		"id":          {Type: graphql.ID},
+		"eventType":   {Type: graphql.String},
+		"title":       {Type: graphql.String},
+		"description": {Type: graphql.String},
+		"oldValue":    {Type: graphql.String},
+		"newValue":    {Type: graphql.String},
+		"metadata":    {Type: jsonScalar},
+		"createdBy":   {Type: graphql.ID},
+		"createdAt":   {Type: graphql.String},
+	})
+
+	portCall := object("PortCall", "A vessel's visit to a port", graphql.Fields{
+		"id":               {Type: graphql.ID},
+		"reference":        {Type: graphql.String},
+		"status":           {Type: graphql.String},
+		"vesselId":         {Type: graphql.ID},
+		"portId":           {Type: graphql.ID},
+		"workspaceId":      {Type: graphql.ID},
+		"agentId":          {Type: graphql.ID},
+		"eta":              {Type: graphql.String},
+		"etd":              {Type: graphql.String},
+		"ata":              {Type: graphql.String},
+		"atd":              {Type: graphql.String},
+		"berthName":        {Type: graphql.String},
+		"berthTerminal":    {Type: graphql.String},
+		"berthConfirmedAt": {Type: graphql.String},
+		"createdAt":        {Type: graphql.String},
+		"updatedAt":        {Type: graphql.String},
+		"version":          {Type: graphql.Int},
+		"vessel":           {Type: vessel, Resolve: related("vessel", "vessel", "vessel_id")},
+		"port":             {Type: port, Resolve: portCallPort},
+		"serviceOrders":    {Type: graphql.NewList(serviceOrder), Resolve: related("service_orders", "serviceOrders", "id")},
+		"timeline":         {Type: graphql.NewList(timelineEvent), Resolve: related("", "timeline", "id")},
+		"rfqs":             {Type: graphql.NewList(rfq), Resolve: related("", "rfqs", "id")},
+	})
+
+	dashboard := object("Dashboard", "The organization's key metrics", graphql.Fields{
+		"activePortCalls":    {Type: graphql.Int},
+		"portCallsThisMonth": {Type: graphql.Int},
+		"portCallsChange":    {Type: graphql.Float, Description: "Percentage change from last month"},
+		"totalVessels":       {Type: graphql.Int},
+		"vesselsAtSea":       {Type: graphql.Int},
+		"vesselsInPort":      {Type: graphql.Int},
+		"activeServices":     {Type: graphql.Int},
+		"pendingApprovals":   {Type: graphql.Int},
+		"avgServiceCost":     {Type: graphql.Float},
+		"openRfqs":           {Type: graphql.Int},
+		"quotesPending":      {Type: graphql.Int},
+		"avgQuotesPerRfq":    {Type: graphql.Float},
+	})
+
+	idArg := graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}}
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"viewer":   {Type: viewer, Resolve: resolveViewer},
+			"portCall": {Type: portCall, Args: idArg, Resolve: byID("portCall")},
+			"portCalls": {
+				Type: graphql.NewList(portCall),
+				Args: graphql.FieldConfigArgument{
+					"status":      {Type: graphql.String},
+					"vesselId":    {Type: graphql.ID},
+					"portId":      {Type: graphql.ID},
+					"workspaceId": {Type: graphql.ID},
+					"first":       {Type: graphql.Int, DefaultValue: 20},
+				},
+				Resolve: c.portCalls,
+			},
+			"vessel":    {Type: vessel, Args: idArg, Resolve: byID("vessel")},
+			"port":      {Type: port, Args: idArg, Resolve: byID("port")},
+			"dashboard": {Type: dashboard, Resolve: c.dashboard},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	return schema
+}
+
+// object creates an object type. Fields without a resolver read their name,
+// in snake_case, from the map[string]any of a service's JSON.
+func object(name, description string, fields graphql.Fields) *graphql.Object {
+	for fieldName, field := range fields {
+		if field.Resolve == nil {
+			field.Resolve = fromSource(snakeCase(fieldName))
+		}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{Name: name, Description: description, Fields: fields})
+}
+
+// fromSource resolves a field by reading key from its source object
+func fromSource(key string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		source, _ := p.Source.(map[string]any)
+		return source[key], nil
+	}
+}
+
+func resolveViewer(p graphql.ResolveParams) (any, error) {
+	claims := middleware.GetClaims(p.Context)
+	if claims == nil {
+		return nil, nil
+	}
+	return map[string]any{
+		"user_id":         claims.UserID,
+		"email":           claims.Email,
+		"organization_id": claims.OrganizationID,
+		"portal_type":     claims.PortalType,
+		"roles":           claims.Roles,
+		"workspace_ids":   claims.WorkspaceIDs,
+	}, nil
+}
+
+// byID resolves a resource by its "id" argument
+func byID(resource string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		id, _ := p.Args["id"].(string)
+		return load(p.Context, resource, id), nil
+	}
+}
+
+// related resolves a resource an object refers to by the ID under idKey.
+// Services that already embedded it under embedded save the fetch.
+func related(embedded, resource, idKey string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		source, _ := p.Source.(map[string]any)
+		if value := source[embedded]; embedded != "" && value != nil {
+			return value, nil
+		}
+		id, _ := source[idKey].(string)
+		if id == "" {
+			return nil, nil
+		}
+		return load(p.Context, resource, id), nil
+	}
+}
+
+// portCallPort resolves a port call's port from integration's registry by
+// the UN/LOCODE of the port core embeds, which carries only its name and
+// codes. Core's port IDs are not integration's, so they can't be looked up.
+// Ports missing from the registry resolve to what core embedded.
+func portCallPort(p graphql.ResolveParams) (any, error) {
+	source, _ := p.Source.(map[string]any)
+	embedded, _ := source["port"].(map[string]any)
+	code, _ := embedded["unlocode"].(string)
+	if code == "" {
+		return embedded, nil
+	}
+
+	thunk := load(p.Context, "portByUNLocode", code)
+	return func() (any, error) {
+		port, err := thunk()
+		if err != nil || port != nil {
+			return port, err
+		}
+		return map[string]any{
+			"id":        embedded["id"],
+			"name":      embedded["name"],
+			"un_locode": code,
+			"country":   embedded["country"],
+		}, nil
+	}, nil
+}
+
+// portWeather resolves the weather at a port's coordinates
+func portWeather(p graphql.ResolveParams) (any, error) {
+	source, _ := p.Source.(map[string]any)
+	lat, okLat := source["latitude"].(float64)
+	lon, okLon := source["longitude"].(float64)
+	if !okLat || !okLon {
+		return nil, nil
+	}
+	coordinates := strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+	return load(p.Context, "weather", coordinates), nil
+}
+
+// portCalls lists port calls, filtered by the arguments given
+func (c *client) portCalls(p graphql.ResolveParams) (any, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > maxPageSize {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxPageSize)
+	}
+
+	query := url.Values{"per_page": {strconv.Itoa(first)}}
+	for arg, param := range map[string]string{
+		"status":      "status",
+		"vesselId":    "vessel_id",
+		"portId":      "port_id",
+		"workspaceId": "workspace_id",
+	} {
+		if value, ok := p.Args[arg].(string); ok && value != "" {
+			query.Set(param, value)
+		}
+	}
+	ctx := p.Context
+	return func() (any, error) {
+		return c.get(ctx, "core", "/api/v1/port-calls?"+query.Encode())
+	}, nil
+}
+
+// dashboard returns the organization's metrics from analytics
+func (c *client) dashboard(p graphql.ResolveParams) (any, error) {
+	ctx := p.Context
+	return func() (any, error) {
+		return c.get(ctx, "analytics", "/api/v1/analytics/dashboard")
+	}, nil
+}
+
+// snakeCase converts a camelCase field name to the snake_case of the
+// services' JSON
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || nextLower) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/navo/pkg/database"
 	"github.com/navo/pkg/redis"
 	"github.com/navo/pkg/response"
+	"github.com/navo/services/gateway/internal/upstream"
 )
 
 // HealthResponse represents health check response
@@ -25,43 +26,43 @@ func Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Ready handles readiness check requests
-func Ready(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+// Ready handles readiness check requests. The gateway is ready while its
+// database and Redis are reachable and every required upstream has an
+// available instance; other upstreams are reported without failing it.
+func Ready(upstreams *upstream.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
 
-	services := make(map[string]string)
-	allHealthy := true
+		services, allHealthy := upstreams.Status()
 
-	// Check database
-	if err := database.Health(ctx); err != nil {
-		services["database"] = "unhealthy"
-		allHealthy = false
-	} else {
-		services["database"] = "healthy"
-	}
-
-	// Check Redis
-	if err := redis.Health(ctx); err != nil {
-		services["redis"] = "unhealthy"
-		allHealthy = false
-	} else {
-		services["redis"] = "healthy"
-	}
+		// Check database
+		if err := database.Health(ctx); err != nil {
+			services["database"] = "unhealthy"
+			allHealthy = false
+		} else {
+			services["database"] = "healthy"
+		}
 
-	status := "ready"
-	statusCode := http.StatusOK
-	if !allHealthy {
-		status = "not_ready"
-		statusCode = http.StatusServiceUnavailable
-	}
+		// Check Redis
+		if err := redis.Health(ctx); err != nil {
+			services["redis"] = "unhealthy"
+			allHealthy = false
+		} else {
+			services["redis"] = "healthy"
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+		status := "ready"
+		statusCode := http.StatusOK
+		if !allHealthy {
+			status = "not_ready"
+			statusCode = http.StatusServiceUnavailable
+		}
 
-	response.JSON(w, statusCode, HealthResponse{
-		Status:    status,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Services:  services,
-	})
+		response.JSON(w, statusCode, HealthResponse{
+			Status:    status,
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Services:  services,
+		})
+	}
 }
@@ -1,51 +1,88 @@
 package handler
 
 import (
-	"io"
+	"context"
+	stderrors "errors"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
 	"strings"
 	"time"
 
+	"github.com/navo/pkg/errors"
 	"github.com/navo/pkg/logger"
-	"github.com/navo/services/gateway/internal/config"
+	"github.com/navo/pkg/response"
 	"github.com/navo/services/gateway/internal/middleware"
+	"github.com/navo/services/gateway/internal/upstream"
 	"go.uber.org/zap"
 )
 
-// createProxy creates a reverse proxy for a service
-func createProxy(targetURL string) (*httputil.ReverseProxy, error) {
-	target, err := url.Parse(targetURL)
-	if err != nil {
-		return nil, err
-	}
-
-	proxy := httputil.NewSingleHostReverseProxy(target)
+// writeGrace is how much longer than its upstream timeout a response may
+// take to write, so timeouts are answered with a 504 rather than a cut
+// connection
+const writeGrace = 5 * time.Second
+
+// Proxies are the handlers proxying requests to each service
+type Proxies struct {
+	Auth         http.HandlerFunc
+	Core         http.HandlerFunc
+	Vessel       http.HandlerFunc
+	Vendor       http.HandlerFunc
+	Realtime     http.HandlerFunc
+	Notification http.HandlerFunc
+	Analytics    http.HandlerFunc
+	Integration  http.HandlerFunc
+}
 
-	// Custom transport with timeouts
-	proxy.Transport = &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     90 * time.Second,
+// NewProxies creates the proxies of the registry's upstreams. Requests time
+// out as the timeout table says, except realtime ones, which stream.
+func NewProxies(upstreams *upstream.Registry, timeouts *upstream.Timeouts) *Proxies {
+	return &Proxies{
+		Auth:         proxyTo(upstreams.Get("auth"), timeouts),
+		Core:         proxyTo(upstreams.Get("core"), timeouts),
+		Vessel:       proxyTo(upstreams.Get("vessel"), timeouts),
+		Vendor:       proxyTo(upstreams.Get("vendor"), timeouts),
+		Realtime:     proxyRealtime(upstreams.Get("realtime")),
+		Notification: proxyTo(upstreams.Get("notification"), timeouts),
+		Analytics:    proxyTo(upstreams.Get("analytics"), timeouts),
+		Integration:  proxyTo(upstreams.Get("integration"), timeouts),
 	}
+}
+
+// createProxy creates a reverse proxy for an upstream, which picks the
+// instance each request goes to
+func createProxy(u *upstream.Upstream) *httputil.ReverseProxy {
+	proxy := &httputil.ReverseProxy{Transport: u}
 
-	// Custom error handler
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if stderrors.Is(err, context.Canceled) {
+			return // The client went away
+		}
+
+		appErr := &errors.AppError{
+			Code:       errors.CodeServiceUnavailable,
+			Message:    "Service temporarily unavailable",
+			StatusCode: http.StatusBadGateway,
+			Err:        err,
+		}
+		switch {
+		case stderrors.Is(err, upstream.ErrUnavailable):
+			appErr.StatusCode = http.StatusServiceUnavailable
+		case stderrors.Is(err, context.DeadlineExceeded):
+			appErr.Message = "Service did not respond in time"
+			appErr.StatusCode = http.StatusGatewayTimeout
+		}
+
 		logger.Error("Proxy error",
 			zap.Error(err),
-			zap.String("target", targetURL),
+			zap.String("upstream", u.Name),
 			zap.String("path", r.URL.Path),
+			zap.Int("status", appErr.StatusCode),
 		)
-		w.WriteHeader(http.StatusBadGateway)
-		io.WriteString(w, `{"success":false,"error":{"code":"SERVICE_UNAVAILABLE","message":"Service temporarily unavailable"}}`)
+		response.Error(w, appErr)
 	}
 
 	// Modify request before forwarding
-	originalDirector := proxy.Director
 	proxy.Director = func(req *http.Request) {
-		originalDirector(req)
-
 		// Forward user context headers
 		if claims := middleware.GetClaims(req.Context()); claims != nil {
 			req.Header.Set("X-User-ID", claims.UserID)
@@ -58,97 +95,42 @@ func createProxy(targetURL string) (*httputil.ReverseProxy, error) {
 		if reqID := req.Header.Get("X-Request-ID"); reqID != "" {
 			req.Header.Set("X-Request-ID", reqID)
 		}
-	}
 
-	return proxy, nil
-}
-
-// ProxyAuth proxies requests to the auth service
-func ProxyAuth(cfg *config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.AuthServiceURL)
-		if err != nil {
-			logger.Error("Failed to create auth proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+		if _, ok := req.Header["User-Agent"]; !ok {
+			// Keep Go's default User-Agent from being added
+			req.Header.Set("User-Agent", "")
 		}
-		proxy.ServeHTTP(w, r)
 	}
-}
 
-// ProxyCore proxies requests to the core service
-func ProxyCore(cfg *config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.CoreServiceURL)
-		if err != nil {
-			logger.Error("Failed to create core proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		proxy.ServeHTTP(w, r)
-	}
+	return proxy
 }
 
-// ProxyVessel proxies requests to the vessel service
-func ProxyVessel(cfg *config.Config) http.HandlerFunc {
+// proxyTo proxies requests to an upstream, bounded by the route's timeout.
+// The write deadline moves with the timeout, so slow routes can outlast the
+// server's write timeout.
+func proxyTo(u *upstream.Upstream, timeouts *upstream.Timeouts) http.HandlerFunc {
+	proxy := createProxy(u)
 	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.VesselServiceURL)
-		if err != nil {
-			logger.Error("Failed to create vessel proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		proxy.ServeHTTP(w, r)
-	}
-}
+		timeout := timeouts.For(r.Method, r.URL.Path)
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
 
-// ProxyVendor proxies requests to the vendor service
-func ProxyVendor(cfg *config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.VendorServiceURL)
-		if err != nil {
-			logger.Error("Failed to create vendor proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + writeGrace)); err != nil {
+			logger.Warn("Failed to extend write deadline for proxy", zap.String("upstream", u.Name), zap.Error(err))
 		}
-		proxy.ServeHTTP(w, r)
-	}
-}
-
-// ProxyRealtime proxies requests to the realtime service
-func ProxyRealtime(cfg *config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.RealtimeServiceURL)
-		if err != nil {
-			logger.Error("Failed to create realtime proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		proxy.ServeHTTP(w, r)
-	}
-}
-
-// ProxyNotification proxies requests to the notification service
-func ProxyNotification(cfg *config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.NotificationServiceURL)
-		if err != nil {
-			logger.Error("Failed to create notification proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		proxy.ServeHTTP(w, r)
+		proxy.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
-// ProxyAnalytics proxies requests to the analytics service
-func ProxyAnalytics(cfg *config.Config) http.HandlerFunc {
+// proxyRealtime proxies requests to the realtime service. Responses are
+// flushed as they arrive and may outlive the server's write timeout, so
+// Server-Sent Events and long polls pass through unbuffered.
+func proxyRealtime(u *upstream.Upstream) http.HandlerFunc {
+	proxy := createProxy(u)
+	proxy.FlushInterval = -1
 	return func(w http.ResponseWriter, r *http.Request) {
-		proxy, err := createProxy(cfg.AnalyticsServiceURL)
-		if err != nil {
-			logger.Error("Failed to create analytics proxy", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logger.Warn("Failed to clear write deadline for realtime proxy", zap.Error(err))
 		}
 		proxy.ServeHTTP(w, r)
 	}
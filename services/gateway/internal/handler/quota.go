@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/gateway/internal/middleware"
+	"github.com/navo/services/gateway/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// QuotaUsageResponse is the caller's plan and its current usage
+type QuotaUsageResponse struct {
+	Plan   ratelimit.Plan `json:"plan"`
+	Limits []LimitUsage   `json:"limits"`
+}
+
+// LimitUsage is the usage of one rate limit or quota, in request cost units
+type LimitUsage struct {
+	Kind      string `json:"kind"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+	ResetAt   string `json:"reset_at"`
+}
+
+// QuotaUsage returns the rate limits and quotas of the caller's plan and how
+// much of them the caller has used
+func QuotaUsage(limiter *ratelimit.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, plan, err := limiter.Usage(r.Context(), middleware.Principal(r))
+		if err != nil {
+			logger.Error("Failed to read quota usage", zap.Error(err))
+			response.InternalError(w, err)
+			return
+		}
+
+		limits := make([]LimitUsage, len(usage))
+		for i, u := range usage {
+			limits[i] = LimitUsage{
+				Kind:      u.Kind,
+				Limit:     u.Max,
+				Used:      u.Used,
+				Remaining: u.Remaining(),
+				ResetAt:   u.Reset.UTC().Format(time.RFC3339),
+			}
+		}
+
+		response.OK(w, QuotaUsageResponse{Plan: plan, Limits: limits})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +25,7 @@ func Logger(next http.Handler) http.Handler {
 			// Log after request completes
 			logger.Info("HTTP Request",
 				zap.String("request_id", requestID),
+				zap.String("trace_id", observability.TraceID(r.Context())),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("query", r.URL.RawQuery),
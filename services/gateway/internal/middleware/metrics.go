@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -217,10 +218,20 @@ type ServiceHealth struct {
 	lastCheck prometheus.Gauge
 }
 
-var serviceHealthMetrics = make(map[string]*ServiceHealth)
+var (
+	serviceHealthMu      sync.Mutex
+	serviceHealthMetrics = make(map[string]*ServiceHealth)
+)
 
-// RegisterServiceHealth registers health metrics for a service
+// RegisterServiceHealth registers health metrics for a service, or returns
+// them if already registered
 func RegisterServiceHealth(serviceName string) *ServiceHealth {
+	serviceHealthMu.Lock()
+	defer serviceHealthMu.Unlock()
+
+	if health, ok := serviceHealthMetrics[serviceName]; ok {
+		return health
+	}
 	health := &ServiceHealth{
 		healthy: promauto.NewGauge(prometheus.GaugeOpts{
 			Namespace:   "navo",
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/navo/pkg/errors"
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/response"
+	"github.com/navo/services/gateway/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the API key of integrations calling the API
+const APIKeyHeader = "X-API-Key"
+
+// RateLimit limits authenticated requests per organization, user and API
+// key, weighed by route cost, within the quotas of the organization's plan.
+// Every response carries RateLimit headers for the limit closest to running
+// out.
+func RateLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := Principal(r)
+			cost := limiter.Cost(r.Method, r.URL.Path)
+
+			result, _, err := limiter.Allow(r.Context(), principal, cost)
+			if err != nil {
+				logger.Warn("Rate limiter unavailable, allowing request", zap.Error(err))
+			}
+			if !allowed(w, result) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitByIP limits unauthenticated requests per client IP, to max
+// requests per window
+func RateLimitByIP(limiter *ratelimit.Limiter, max int64, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.AllowIP(r.Context(), clientIP(r), max, window)
+			if err != nil {
+				logger.Warn("Rate limiter unavailable, allowing request", zap.Error(err))
+			}
+			if !allowed(w, result) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Principal returns who a request is counted against
+func Principal(r *http.Request) ratelimit.Principal {
+	var p ratelimit.Principal
+	if claims := GetClaims(r.Context()); claims != nil {
+		p.OrganizationID = claims.OrganizationID
+		p.UserID = claims.UserID
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		p.APIKey = hex.EncodeToString(sum[:16])
+	}
+	return p
+}
+
+// allowed writes the RateLimit headers of a result and, when the request
+// was denied, the 429 response. It reports whether the request may proceed.
+func allowed(w http.ResponseWriter, result *ratelimit.Result) bool {
+	if usage, ok := result.Tightest(); ok {
+		reset := resetSeconds(usage.Reset)
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;name=%q", usage.Max, int64(usage.Window.Seconds()), usage.Kind))
+		w.Header().Set("RateLimit-Limit", strconv.FormatInt(usage.Max, 10))
+		w.Header().Set("RateLimit-Remaining", strconv.FormatInt(usage.Remaining(), 10))
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.FormatInt(reset, 10))
+		}
+	}
+
+	if result.Allowed {
+		return true
+	}
+	if result.Denied != nil {
+		RecordRateLimitHit(result.Denied.Kind)
+	}
+	response.Error(w, errors.NewRateLimited())
+	return false
+}
+
+// resetSeconds returns the whole seconds until a window ends, at least 1
+func resetSeconds(reset time.Time) int64 {
+	seconds := int64(time.Until(reset).Seconds() + 0.999)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// clientIP returns the client address, as set by chi's RealIP middleware
+// or else the connection's, without its port
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import "github.com/navo/services/gateway/internal/route"
+
+// RouteCost weighs requests to routes that are expensive to serve. Path
+// segments of "*" match any one segment.
+type RouteCost struct {
+	Method string
+	Path   string
+	Cost   int64
+}
+
+// DefaultRouteCosts are the costs of the gateway's expensive routes. Other
+// requests cost 1.
+var DefaultRouteCosts = []RouteCost{
+	{Method: "GET", Path: "/api/v1/audit/export", Cost: 20},
+	{Method: "POST", Path: "/api/v1/fleet/refresh", Cost: 20},
+	{Method: "POST", Path: "/api/v1/ports/import", Cost: 20},
+	{Method: "POST", Path: "/api/v1/accounting/sync", Cost: 10},
+	{Method: "GET", Path: "/api/v1/fleet/positions", Cost: 5},
+	{Method: "GET", Path: "/api/v1/fleet/bounds", Cost: 5},
+	{Method: "GET", Path: "/api/v1/analytics/*", Cost: 5},
+	{Method: "GET", Path: "/api/v1/vessels/*/track", Cost: 3},
+	{Method: "GET", Path: "/api/v1/graphql", Cost: 5},
+	{Method: "POST", Path: "/api/v1/graphql", Cost: 5},
+}
+
+// routeCost returns the cost of the first route matching the request, or 1
+func routeCost(costs []RouteCost, method, path string) int64 {
+	for _, c := range costs {
+		if c.Method == method && route.Match(c.Path, path) {
+			return c.Cost
+		}
+	}
+	return 1
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Plan is the rate limits and quotas of a subscription plan, in request
+// cost units. Zero means unlimited.
+type Plan struct {
+	Name                  string `json:"name"`
+	OrganizationPerMinute int64  `json:"organization_per_minute"`
+	UserPerMinute         int64  `json:"user_per_minute"`
+	APIKeyPerMinute       int64  `json:"api_key_per_minute"`
+	DailyQuota            int64  `json:"daily_quota"`
+	MonthlyQuota          int64  `json:"monthly_quota"`
+}
+
+// Plans are the known plans by name
+var Plans = map[string]Plan{
+	"free": {
+		Name:                  "free",
+		OrganizationPerMinute: 300,
+		UserPerMinute:         60,
+		APIKeyPerMinute:       60,
+		DailyQuota:            20000,
+		MonthlyQuota:          300000,
+	},
+	"standard": {
+		Name:                  "standard",
+		OrganizationPerMinute: 1200,
+		UserPerMinute:         240,
+		APIKeyPerMinute:       300,
+		DailyQuota:            200000,
+		MonthlyQuota:          4000000,
+	},
+	"enterprise": {
+		Name:                  "enterprise",
+		OrganizationPerMinute: 6000,
+		UserPerMinute:         600,
+		APIKeyPerMinute:       1200,
+	},
+}
+
+// planCacheTTL is how long an organization's plan is cached, and so how
+// long a plan change takes to apply
+const planCacheTTL = 5 * time.Minute
+
+type cachedPlan struct {
+	plan    Plan
+	expires time.Time
+}
+
+// PlanStore looks up organizations' plans, set as "plan" in the
+// organization's settings
+type PlanStore struct {
+	db          *pgxpool.Pool
+	defaultPlan Plan
+
+	mu    sync.Mutex
+	cache map[string]cachedPlan
+}
+
+// NewPlanStore creates a plan store. Organizations without a known plan, and
+// every organization when db is nil, get the default plan.
+func NewPlanStore(db *pgxpool.Pool, defaultPlan string) *PlanStore {
+	plan, ok := Plans[defaultPlan]
+	if !ok {
+		plan = Plans["standard"]
+	}
+	return &PlanStore{
+		db:          db,
+		defaultPlan: plan,
+		cache:       make(map[string]cachedPlan),
+	}
+}
+
+// Plan returns an organization's plan. Lookup failures fall back to the
+// default plan for a short while.
+func (s *PlanStore) Plan(ctx context.Context, orgID string) Plan {
+	if orgID == "" || s.db == nil {
+		return s.defaultPlan
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[orgID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.plan
+	}
+
+	plan, ttl := s.defaultPlan, planCacheTTL
+	var name string
+	err := s.db.QueryRow(ctx,
+		`SELECT COALESCE(settings->>'plan', '') FROM organizations WHERE id = $1`,
+		orgID,
+	).Scan(&name)
+	switch {
+	case err == nil:
+		if p, ok := Plans[name]; ok {
+			plan = p
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+	default:
+		ttl = time.Minute
+	}
+
+	s.mu.Lock()
+	s.cache[orgID] = cachedPlan{plan: plan, expires: now.Add(ttl)}
+	s.mu.Unlock()
+	return plan
+}
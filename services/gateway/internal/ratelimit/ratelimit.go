@@ -0,0 +1,249 @@
+// Package ratelimit limits gateway traffic in Redis, so every gateway
+// instance shares the same counters.
+//
+// Requests are weighed by route cost and counted against fixed windows per
+// organization, user and API key, and against the daily and monthly quotas
+// of the organization's plan. A request is only counted when every limit
+// has room for it.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "navo:ratelimit:"
+
+// Limit kinds, reported in metrics and the quota usage endpoint
+const (
+	KindIP           = "ip"
+	KindOrganization = "organization"
+	KindUser         = "user"
+	KindAPIKey       = "api_key"
+	KindDailyQuota   = "daily_quota"
+	KindMonthlyQuota = "monthly_quota"
+)
+
+// Limit is a counter with a maximum cost per window
+type Limit struct {
+	Kind   string
+	Key    string // Redis key of the current window
+	Max    int64
+	Window time.Duration
+	Reset  time.Time // End of the current window
+}
+
+// Usage is a limit's state after a request
+type Usage struct {
+	Limit
+	Used int64
+}
+
+// Remaining returns the cost left in the window
+func (u Usage) Remaining() int64 {
+	if u.Used >= u.Max {
+		return 0
+	}
+	return u.Max - u.Used
+}
+
+// Result is the outcome of checking a request against its limits
+type Result struct {
+	Allowed bool
+	Usage   []Usage
+	Denied  *Usage // The limit that denied the request, if any
+}
+
+// Tightest returns the usage closest to its limit, which is what the
+// RateLimit headers report. ok is false without limits.
+func (r *Result) Tightest() (usage Usage, ok bool) {
+	if r.Denied != nil {
+		return *r.Denied, true
+	}
+	for i, u := range r.Usage {
+		if i == 0 || u.Remaining() < usage.Remaining() {
+			usage = u
+		}
+	}
+	return usage, len(r.Usage) > 0
+}
+
+// allowScript checks every counter for room for the cost and only then
+// increments them all, setting the expiry of new windows. It returns the
+// 1-based index of the denying counter, or 0, followed by each counter's
+// value.
+var allowScript = redis.NewScript(`
+local cost = tonumber(ARGV[1])
+local counts = {}
+local denied = 0
+for i = 1, #KEYS do
+	counts[i] = tonumber(redis.call('GET', KEYS[i]) or '0')
+	if denied == 0 and counts[i] + cost > tonumber(ARGV[i * 2]) then
+		denied = i
+	end
+end
+if denied == 0 then
+	for i = 1, #KEYS do
+		counts[i] = redis.call('INCRBY', KEYS[i], cost)
+		if counts[i] == cost then
+			redis.call('PEXPIREAT', KEYS[i], ARGV[i * 2 + 1])
+		end
+	end
+end
+table.insert(counts, 1, denied)
+return counts
+`)
+
+// Limiter checks requests against limits kept in Redis
+type Limiter struct {
+	redis *redis.Client
+	plans *PlanStore
+	costs []RouteCost
+	now   func() time.Time
+}
+
+// NewLimiter creates a limiter. Requests cost what the first matching route
+// cost says, or 1.
+func NewLimiter(client *redis.Client, plans *PlanStore, costs []RouteCost) *Limiter {
+	return &Limiter{
+		redis: client,
+		plans: plans,
+		costs: costs,
+		now:   time.Now,
+	}
+}
+
+// Principal identifies who a request is counted against. Empty fields are
+// not limited.
+type Principal struct {
+	OrganizationID string
+	UserID         string
+	APIKey         string // Hash of the API key, never the key itself
+}
+
+// Allow counts a request of a principal against its plan's limits. When
+// Redis fails, the request is allowed along with the error: an outage of
+// the limiter shouldn't take the API down with it.
+func (l *Limiter) Allow(ctx context.Context, p Principal, cost int64) (*Result, Plan, error) {
+	plan := l.plans.Plan(ctx, p.OrganizationID)
+	result, err := l.allow(ctx, l.limits(p, plan), cost)
+	return result, plan, err
+}
+
+// AllowIP counts a request from an unauthenticated client against a limit
+// per IP address. Like Allow, it allows the request when Redis fails.
+func (l *Limiter) AllowIP(ctx context.Context, ip string, max int64, window time.Duration) (*Result, error) {
+	now := l.now()
+	start := now.Truncate(window)
+	return l.allow(ctx, []Limit{{
+		Kind:   KindIP,
+		Key:    fmt.Sprintf("%sip:%s:%d", keyPrefix, ip, start.Unix()),
+		Max:    max,
+		Window: window,
+		Reset:  start.Add(window),
+	}}, 1)
+}
+
+// Cost returns the cost of a request to a route
+func (l *Limiter) Cost(method, path string) int64 {
+	return routeCost(l.costs, method, path)
+}
+
+// Usage returns a principal's current usage of its plan's limits without
+// counting a request
+func (l *Limiter) Usage(ctx context.Context, p Principal) ([]Usage, Plan, error) {
+	plan := l.plans.Plan(ctx, p.OrganizationID)
+	limits := l.limits(p, plan)
+	if len(limits) == 0 {
+		return nil, plan, nil
+	}
+
+	keys := make([]string, len(limits))
+	for i, limit := range limits {
+		keys[i] = limit.Key
+	}
+	values, err := l.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, plan, fmt.Errorf("failed to read rate limit counters: %w", err)
+	}
+
+	usage := make([]Usage, len(limits))
+	for i, limit := range limits {
+		usage[i] = Usage{Limit: limit}
+		if s, ok := values[i].(string); ok {
+			usage[i].Used, _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return usage, plan, nil
+}
+
+// limits returns the windows a principal's requests count against now.
+// Limits of zero are unlimited and left out.
+func (l *Limiter) limits(p Principal, plan Plan) []Limit {
+	now := l.now().UTC()
+	minute := now.Truncate(time.Minute)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var limits []Limit
+	add := func(kind, id string, max int64, window string, start, reset time.Time) {
+		if id == "" || max <= 0 {
+			return
+		}
+		limits = append(limits, Limit{
+			Kind:   kind,
+			Key:    keyPrefix + kind + ":" + id + ":" + window,
+			Max:    max,
+			Window: reset.Sub(start),
+			Reset:  reset,
+		})
+	}
+
+	minuteWindow := strconv.FormatInt(minute.Unix(), 10)
+	add(KindOrganization, p.OrganizationID, plan.OrganizationPerMinute, minuteWindow, minute, minute.Add(time.Minute))
+	add(KindUser, p.UserID, plan.UserPerMinute, minuteWindow, minute, minute.Add(time.Minute))
+	add(KindAPIKey, p.APIKey, plan.APIKeyPerMinute, minuteWindow, minute, minute.Add(time.Minute))
+	add(KindDailyQuota, p.OrganizationID, plan.DailyQuota, day.Format("20060102"), day, day.AddDate(0, 0, 1))
+	add(KindMonthlyQuota, p.OrganizationID, plan.MonthlyQuota, month.Format("200601"), month, month.AddDate(0, 1, 0))
+	return limits
+}
+
+// allow runs the counters of a request. On errors the request is allowed,
+// without usage.
+func (l *Limiter) allow(ctx context.Context, limits []Limit, cost int64) (*Result, error) {
+	if len(limits) == 0 {
+		return &Result{Allowed: true}, nil
+	}
+
+	keys := make([]string, len(limits))
+	args := make([]interface{}, 0, 1+2*len(limits))
+	args = append(args, cost)
+	for i, limit := range limits {
+		keys[i] = limit.Key
+		// Windows outlive their end slightly, so a late increment can't
+		// leave a counter without expiry
+		args = append(args, limit.Max, limit.Reset.Add(time.Minute).UnixMilli())
+	}
+
+	values, err := allowScript.Run(ctx, l.redis, keys, args...).Int64Slice()
+	if err != nil {
+		return &Result{Allowed: true}, fmt.Errorf("failed to run rate limit counters: %w", err)
+	}
+	if len(values) != len(limits)+1 {
+		return &Result{Allowed: true}, fmt.Errorf("rate limit counters returned %d values for %d limits", len(values), len(limits))
+	}
+
+	result := &Result{Allowed: values[0] == 0, Usage: make([]Usage, len(limits))}
+	for i, limit := range limits {
+		result.Usage[i] = Usage{Limit: limit, Used: values[i+1]}
+	}
+	if !result.Allowed {
+		denied := result.Usage[values[0]-1]
+		result.Denied = &denied
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteCost(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   int64
+	}{
+		{"POST", "/api/v1/fleet/refresh", 20},
+		{"GET", "/api/v1/fleet/refresh", 1},
+		{"GET", "/api/v1/audit/export", 20},
+		{"GET", "/api/v1/analytics/overview", 5},
+		{"GET", "/api/v1/analytics/", 1},
+		{"GET", "/api/v1/vessels/v-1/track", 3},
+		{"GET", "/api/v1/vessels/v-1", 1},
+		{"GET", "/api/v1/port-calls", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, routeCost(DefaultRouteCosts, tt.method, tt.path))
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limiter := NewLimiter(nil, NewPlanStore(nil, "free"), DefaultRouteCosts)
+	limiter.now = func() time.Time { return time.Date(2026, 2, 28, 23, 59, 30, 0, time.UTC) }
+
+	plan := limiter.plans.Plan(context.Background(), "org-1")
+	require.Equal(t, "free", plan.Name)
+
+	limits := limiter.limits(Principal{OrganizationID: "org-1", UserID: "user-1"}, plan)
+	require.Len(t, limits, 4, "no API key limit without an API key")
+
+	byKind := make(map[string]Limit)
+	for _, limit := range limits {
+		byKind[limit.Kind] = limit
+	}
+
+	org := byKind[KindOrganization]
+	assert.Equal(t, "navo:ratelimit:organization:org-1:1772323140", org.Key)
+	assert.Equal(t, plan.OrganizationPerMinute, org.Max)
+	assert.Equal(t, time.Minute, org.Window)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), org.Reset)
+
+	daily := byKind[KindDailyQuota]
+	assert.Equal(t, "navo:ratelimit:daily_quota:org-1:20260228", daily.Key)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), daily.Reset)
+
+	monthly := byKind[KindMonthlyQuota]
+	assert.Equal(t, "navo:ratelimit:monthly_quota:org-1:202602", monthly.Key)
+	assert.Equal(t, 28*24*time.Hour, monthly.Window)
+
+	t.Run("unlimited plans have no quotas", func(t *testing.T) {
+		limits := limiter.limits(Principal{OrganizationID: "org-1", APIKey: "abc"}, Plans["enterprise"])
+		kinds := make([]string, len(limits))
+		for i, limit := range limits {
+			kinds[i] = limit.Kind
+		}
+		assert.Equal(t, []string{KindOrganization, KindAPIKey}, kinds)
+	})
+}
+
+func TestResultTightest(t *testing.T) {
+	result := &Result{Allowed: true, Usage: []Usage{
+		{Limit: Limit{Kind: KindOrganization, Max: 300}, Used: 10},
+		{Limit: Limit{Kind: KindUser, Max: 60}, Used: 55},
+		{Limit: Limit{Kind: KindDailyQuota, Max: 20000}, Used: 100},
+	}}
+
+	usage, ok := result.Tightest()
+	require.True(t, ok)
+	assert.Equal(t, KindUser, usage.Kind)
+	assert.Equal(t, int64(5), usage.Remaining())
+
+	denied := Usage{Limit: Limit{Kind: KindDailyQuota, Max: 20000}, Used: 20000}
+	result.Allowed, result.Denied = false, &denied
+	usage, _ = result.Tightest()
+	assert.Equal(t, KindDailyQuota, usage.Kind)
+
+	_, ok = (&Result{Allowed: true}).Tightest()
+	assert.False(t, ok)
+}
@@ -0,0 +1,21 @@
+// Package route matches requests against route patterns, for settings that
+// vary by route such as rate limit costs and upstream timeouts
+package route
+
+import "strings"
+
+// Match reports whether a request path matches a pattern. Segments of "*"
+// in the pattern match any one segment.
+func Match(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, p := range patternSegments {
+		if p != "*" && p != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
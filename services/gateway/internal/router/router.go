@@ -2,25 +2,34 @@ package router
 
 import (
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/httprate"
+	"github.com/navo/pkg/database"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/redis"
 	"github.com/navo/pkg/response"
 	"github.com/navo/services/gateway/internal/config"
+	"github.com/navo/services/gateway/internal/docs"
+	"github.com/navo/services/gateway/internal/graph"
 	"github.com/navo/services/gateway/internal/handler"
 	"github.com/navo/services/gateway/internal/middleware"
+	"github.com/navo/services/gateway/internal/ratelimit"
+	"github.com/navo/services/gateway/internal/upstream"
 )
 
-// Setup creates and configures the router
-func Setup(cfg *config.Config) *chi.Mux {
+// Setup creates and configures the router, proxying to the registry's
+// upstreams within the timeouts of each route
+func Setup(cfg *config.Config, upstreams *upstream.Registry, timeouts *upstream.Timeouts) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(observability.HTTPMiddleware) // Starts or continues the request's trace
 	r.Use(middleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.Compress(5))
@@ -29,8 +38,8 @@ func Setup(cfg *config.Config) *chi.Mux {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-Workspace-ID"},
-		ExposedHeaders:   []string{"Link", "X-Request-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "Last-Event-ID", "X-Request-ID", "X-Workspace-ID", "X-API-Key", "traceparent", "tracestate"},
+		ExposedHeaders:   []string{"Link", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -38,118 +47,261 @@ func Setup(cfg *config.Config) *chi.Mux {
 	// Security headers (XSS, clickjacking, MIME sniffing protection)
 	r.Use(middleware.SecurityHeaders)
 
-	// Rate limiting
-	r.Use(httprate.LimitByIP(100, time.Minute))
+	// Rate limiting, shared by gateway instances through Redis
+	limiter := ratelimit.NewLimiter(redis.Client, ratelimit.NewPlanStore(database.Pool, cfg.DefaultPlan), ratelimit.DefaultRouteCosts)
+	limitByIP := middleware.RateLimitByIP(limiter, int64(cfg.RateLimit), time.Duration(cfg.RateLimitTTL)*time.Second)
+
+	proxies := handler.NewProxies(upstreams, timeouts)
+	graphqlHandler := graph.NewHandler(upstreams,
+		graph.NewRedisQueryStore(redis.Client, cfg.GraphQLPersistedQueryTTL),
+		graph.Options{MaxDepth: cfg.GraphQLMaxDepth, MaxCost: cfg.GraphQLMaxCost},
+		timeouts.For(http.MethodPost, "/api/v1/graphql"),
+	)
+
+	// API docs of the services' operations the routes below serve
+	var services []string
+	for name := range cfg.ServiceURLs() {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	apiDocs := docs.NewHandler(upstreams, services, r, cfg.Version, cfg.DocsCacheTTL, timeouts.For(http.MethodGet, "/api/docs"))
 
 	// Health check (no auth required)
 	r.Get("/health", handler.Health)
-	r.Get("/ready", handler.Ready)
+	r.Get("/ready", handler.Ready(upstreams))
+
+	// OpenAPI document of the API (no auth required)
+	r.With(limitByIP).Method(http.MethodGet, "/api/docs", apiDocs)
 
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Public routes (no auth)
 		r.Group(func(r chi.Router) {
-			r.Post("/auth/login", handler.ProxyAuth(cfg))
-			r.Post("/auth/register", handler.ProxyAuth(cfg))
-			r.Post("/auth/forgot-password", handler.ProxyAuth(cfg))
-			r.Post("/auth/reset-password", handler.ProxyAuth(cfg))
-			r.Post("/auth/refresh", handler.ProxyAuth(cfg))
+			r.Use(limitByIP)
+
+			r.Post("/auth/login", proxies.Auth)
+			r.Post("/auth/register", proxies.Auth)
+			r.Post("/auth/forgot-password", proxies.Auth)
+			r.Post("/auth/reset-password", proxies.Auth)
+			r.Post("/auth/refresh", proxies.Auth)
+
+			// Delivery status callbacks, verified by the notification service
+			r.Post("/notifications/callbacks/{provider}", proxies.Notification)
 		})
 
 		// Protected routes (auth required)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Authenticate)
+			r.Use(middleware.RateLimit(limiter))
+
+			// Rate limits and quotas of the caller's plan
+			r.Get("/quota", handler.QuotaUsage(limiter))
+
+			// GraphQL aggregation of core, vessel, integration and analytics
+			r.Method(http.MethodGet, "/graphql", graphqlHandler)
+			r.Method(http.MethodPost, "/graphql", graphqlHandler)
 
 			// Auth routes
 			r.Route("/auth", func(r chi.Router) {
-				r.Get("/me", handler.ProxyAuth(cfg))
-				r.Post("/logout", handler.ProxyAuth(cfg))
-				r.Put("/profile", handler.ProxyAuth(cfg))
-				r.Put("/password", handler.ProxyAuth(cfg))
+				r.Get("/me", proxies.Auth)
+				r.Post("/logout", proxies.Auth)
+				r.Put("/profile", proxies.Auth)
+				r.Put("/password", proxies.Auth)
 			})
 
 			// Workspaces
 			r.Route("/workspaces", func(r chi.Router) {
-				r.Get("/", handler.ProxyCore(cfg))
-				r.Post("/", handler.ProxyCore(cfg))
-				r.Get("/{id}", handler.ProxyCore(cfg))
-				r.Put("/{id}", handler.ProxyCore(cfg))
-				r.Delete("/{id}", handler.ProxyCore(cfg))
+				r.Get("/", proxies.Core)
+				r.Post("/", proxies.Core)
+				r.Get("/{id}", proxies.Core)
+				r.Put("/{id}", proxies.Core)
+				r.Delete("/{id}", proxies.Core)
 			})
 
 			// Vessels
 			r.Route("/vessels", func(r chi.Router) {
-				r.Get("/", handler.ProxyVessel(cfg))
-				r.Post("/", handler.ProxyVessel(cfg))
-				r.Get("/{id}", handler.ProxyVessel(cfg))
-				r.Put("/{id}", handler.ProxyVessel(cfg))
-				r.Delete("/{id}", handler.ProxyVessel(cfg))
-				r.Get("/{id}/position", handler.ProxyVessel(cfg))
-				r.Get("/{id}/track", handler.ProxyVessel(cfg))
+				r.Get("/", proxies.Vessel)
+				r.Post("/", proxies.Vessel)
+				r.Get("/{id}", proxies.Vessel)
+				r.Put("/{id}", proxies.Vessel)
+				r.Delete("/{id}", proxies.Vessel)
+				r.Get("/{id}/position", proxies.Vessel)
+				r.Get("/{id}/track", proxies.Vessel)
+			})
+
+			// Fleet operations
+			r.Route("/fleet", func(r chi.Router) {
+				r.Get("/positions", proxies.Vessel)
+				r.Get("/bounds", proxies.Vessel)
+				r.Post("/refresh", proxies.Vessel)
 			})
 
 			// Port Calls
 			r.Route("/port-calls", func(r chi.Router) {
-				r.Get("/", handler.ProxyCore(cfg))
-				r.Post("/", handler.ProxyCore(cfg))
-				r.Get("/{id}", handler.ProxyCore(cfg))
-				r.Put("/{id}", handler.ProxyCore(cfg))
-				r.Delete("/{id}", handler.ProxyCore(cfg))
-				r.Get("/{id}/services", handler.ProxyCore(cfg))
-				r.Post("/{id}/services", handler.ProxyCore(cfg))
-				r.Get("/{id}/documents", handler.ProxyCore(cfg))
-				r.Get("/{id}/timeline", handler.ProxyCore(cfg))
+				r.Get("/", proxies.Core)
+				r.Post("/", proxies.Core)
+				r.Get("/{id}", proxies.Core)
+				r.Put("/{id}", proxies.Core)
+				r.Delete("/{id}", proxies.Core)
+				r.Get("/{id}/services", proxies.Core)
+				r.Post("/{id}/services", proxies.Core)
+				r.Get("/{id}/documents", proxies.Core)
+				r.Get("/{id}/timeline", proxies.Core)
+				r.Post("/{id}/timeline", proxies.Core)
 			})
 
 			// Service Orders
 			r.Route("/service-orders", func(r chi.Router) {
-				r.Get("/", handler.ProxyCore(cfg))
-				r.Post("/", handler.ProxyCore(cfg))
-				r.Get("/{id}", handler.ProxyCore(cfg))
-				r.Put("/{id}", handler.ProxyCore(cfg))
-				r.Delete("/{id}", handler.ProxyCore(cfg))
-				r.Post("/{id}/confirm", handler.ProxyCore(cfg))
-				r.Post("/{id}/complete", handler.ProxyCore(cfg))
+				r.Get("/", proxies.Core)
+				r.Post("/", proxies.Core)
+				r.Get("/{id}", proxies.Core)
+				r.Put("/{id}", proxies.Core)
+				r.Delete("/{id}", proxies.Core)
+				r.Post("/{id}/confirm", proxies.Core)
+				r.Post("/{id}/complete", proxies.Core)
+				r.Get("/{id}/invoices", proxies.Core)
+				r.Post("/{id}/invoices", proxies.Core)
+			})
+
+			// Invoices
+			r.Route("/invoices", func(r chi.Router) {
+				r.Get("/", proxies.Core)
+				r.Get("/approval-queue", proxies.Core)
+				r.Get("/{id}", proxies.Core)
+				r.Post("/{id}/match", proxies.Core)
+				r.Post("/{id}/approve", proxies.Core)
+				r.Post("/{id}/reject", proxies.Core)
 			})
 
 			// RFQs
 			r.Route("/rfqs", func(r chi.Router) {
-				r.Get("/", handler.ProxyCore(cfg))
-				r.Post("/", handler.ProxyCore(cfg))
-				r.Get("/{id}", handler.ProxyCore(cfg))
-				r.Put("/{id}", handler.ProxyCore(cfg))
-				r.Delete("/{id}", handler.ProxyCore(cfg))
-				r.Post("/{id}/send", handler.ProxyCore(cfg))
-				r.Get("/{id}/quotes", handler.ProxyCore(cfg))
-				r.Post("/{id}/quotes", handler.ProxyVendor(cfg)) // Vendor submits quote
-				r.Post("/{id}/award/{quoteId}", handler.ProxyCore(cfg))
+				r.Get("/", proxies.Core)
+				r.Post("/", proxies.Core)
+				r.Get("/{id}", proxies.Core)
+				r.Put("/{id}", proxies.Core)
+				r.Delete("/{id}", proxies.Core)
+				r.Post("/{id}/send", proxies.Core)
+				r.Get("/{id}/quotes", proxies.Core)
+				r.Post("/{id}/quotes", proxies.Core) // Vendor submits quote
+				r.Post("/{id}/award/{quoteId}", proxies.Core)
+			})
+
+			// Audit trail (admin only)
+			r.Route("/audit", func(r chi.Router) {
+				r.Use(middleware.RequireRole("admin"))
+				r.Get("/events", proxies.Core)
+				r.Get("/events/{id}", proxies.Core)
+				r.Get("/export", proxies.Core)
+				r.Get("/history/{entityType}/{entityId}", proxies.Core)
+				r.Get("/verify", proxies.Core)
+			})
+
+			// Feature flags
+			r.Get("/features", proxies.Core)
+			r.Get("/features/{key}", proxies.Core)
+			// Flags apply to every organization, so they are managed by
+			// platform administrators only
+			r.Route("/admin/feature-flags", func(r chi.Router) {
+				r.Use(middleware.RequireRole("platform_admin"))
+				r.Get("/", proxies.Core)
+				r.Post("/", proxies.Core)
+				r.Get("/{key}", proxies.Core)
+				r.Put("/{key}", proxies.Core)
+				r.Delete("/{key}", proxies.Core)
+				r.Get("/{key}/history", proxies.Core)
 			})
 
 			// Vendors
 			r.Route("/vendors", func(r chi.Router) {
-				r.Get("/", handler.ProxyVendor(cfg))
-				r.Post("/", handler.ProxyVendor(cfg))
-				r.Get("/{id}", handler.ProxyVendor(cfg))
-				r.Put("/{id}", handler.ProxyVendor(cfg))
-				r.Delete("/{id}", handler.ProxyVendor(cfg))
-				r.Get("/{id}/performance", handler.ProxyVendor(cfg))
-				r.Post("/{id}/verify", handler.ProxyVendor(cfg))
+				r.Get("/", proxies.Vendor)
+				r.Post("/", proxies.Vendor)
+				r.Get("/{id}", proxies.Vendor)
+				r.Put("/{id}", proxies.Vendor)
+				r.Delete("/{id}", proxies.Vendor)
+				r.Get("/{id}/performance", proxies.Vendor)
+				r.Post("/{id}/verify", proxies.Vendor)
 			})
 
 			// Analytics
 			r.Route("/analytics", func(r chi.Router) {
-				r.Get("/overview", handler.ProxyAnalytics(cfg))
-				r.Get("/sla", handler.ProxyAnalytics(cfg))
-				r.Get("/vendor-performance", handler.ProxyAnalytics(cfg))
-				r.Get("/vessel-performance", handler.ProxyAnalytics(cfg))
-				r.Get("/cost-analysis", handler.ProxyAnalytics(cfg))
+				r.Get("/overview", proxies.Analytics)
+				r.Get("/sla", proxies.Analytics)
+				r.Get("/vendor-performance", proxies.Analytics)
+				r.Get("/vessel-performance", proxies.Analytics)
+				r.Get("/cost-analysis", proxies.Analytics)
+				r.Get("/costs", proxies.Analytics)
+				r.Get("/settings", proxies.Analytics)
+				r.Put("/settings", proxies.Analytics)
+				r.Get("/exchange-rates", proxies.Analytics)
 			})
 
 			// Notifications
 			r.Route("/notifications", func(r chi.Router) {
-				r.Get("/", handler.ProxyNotification(cfg))
-				r.Put("/{id}/read", handler.ProxyNotification(cfg))
-				r.Post("/read-all", handler.ProxyNotification(cfg))
+				r.Get("/", proxies.Notification)
+				r.Put("/{id}/read", proxies.Notification)
+				r.Post("/{id}/acknowledge", proxies.Notification)
+				r.Post("/read-all", proxies.Notification)
+				r.Get("/preferences", proxies.Notification)
+				r.Put("/preferences", proxies.Notification)
+				r.Get("/push/vapid-key", proxies.Notification)
+				r.Post("/push/subscriptions", proxies.Notification)
+				r.Delete("/push/subscriptions", proxies.Notification)
+				r.Get("/retention", proxies.Notification)
+				r.Put("/retention", proxies.Notification)
+			})
+
+			// Escalations of unacknowledged notifications
+			r.Route("/escalation-policies", func(r chi.Router) {
+				r.Get("/", proxies.Notification)
+				r.Post("/", proxies.Notification)
+				r.Get("/{id}", proxies.Notification)
+				r.Put("/{id}", proxies.Notification)
+				r.Delete("/{id}", proxies.Notification)
+			})
+			r.Route("/escalations", func(r chi.Router) {
+				r.Get("/", proxies.Notification)
+				r.Get("/{id}", proxies.Notification)
+				r.Post("/{id}/acknowledge", proxies.Notification)
+			})
+
+			// Notification templates and organization overrides
+			r.Route("/templates", func(r chi.Router) {
+				r.Get("/", proxies.Notification)
+				r.Get("/overrides", proxies.Notification)
+				r.Get("/{name}", proxies.Notification)
+				r.Post("/{name}/preview", proxies.Notification)
+				r.Put("/{name}/overrides", proxies.Notification)
+				r.Delete("/{name}/overrides", proxies.Notification)
+				r.Post("/{name}/overrides/preview", proxies.Notification)
+				r.Get("/{name}/versions", proxies.Notification)
+				r.Post("/{name}/versions/{version}/restore", proxies.Notification)
+			})
+
+			// Realtime fallback transports (SSE and long polling)
+			r.Route("/realtime", func(r chi.Router) {
+				r.Get("/events", proxies.Realtime)
+				r.Get("/poll", proxies.Realtime)
+				r.Get("/sessions/{clientID}/subscriptions", proxies.Realtime)
+				r.Post("/sessions/{clientID}/subscriptions", proxies.Realtime)
+				r.Delete("/sessions/{clientID}/subscriptions/{channel}", proxies.Realtime)
+				r.Post("/sessions/{clientID}/presence", proxies.Realtime)
+				r.Delete("/sessions/{clientID}/presence/{channel}", proxies.Realtime)
+			})
+
+			// Ports
+			r.Route("/ports", func(r chi.Router) {
+				r.Get("/", proxies.Integration)
+				r.Get("/unlocode/{code}", proxies.Integration)
+				r.Get("/{id}", proxies.Integration)
+				r.Put("/{id}", proxies.Integration)
+				r.Post("/import", proxies.Integration)
+				r.Post("/{id}/restriction-check", proxies.Integration)
+			})
+
+			// Accounting
+			r.Route("/accounting", func(r chi.Router) {
+				r.Get("/exports", proxies.Integration)
+				r.Post("/sync", proxies.Integration)
 			})
 		})
 	})
@@ -0,0 +1,171 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navo/pkg/logger"
+	"github.com/navo/services/gateway/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// Health probe defaults
+const (
+	ProbeInterval = 10 * time.Second
+	ProbeTimeout  = 2 * time.Second
+	probePath     = "/health"
+)
+
+// Upstream health, as reported by the readiness endpoint
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded" // Some instances are down
+	StatusUnhealthy = "unhealthy"
+)
+
+// Registry holds the gateway's upstreams and probes their health
+type Registry struct {
+	upstreams map[string]*Upstream
+	required  map[string]bool
+	client    *http.Client
+}
+
+// NewRegistry creates the upstreams of services, given as comma-separated
+// instance URLs by name. The gateway is only ready while every required
+// upstream has an available instance.
+func NewRegistry(services map[string]string, required []string, opts Options) (*Registry, error) {
+	r := &Registry{
+		upstreams: make(map[string]*Upstream, len(services)),
+		required:  make(map[string]bool, len(required)),
+		client:    &http.Client{Timeout: ProbeTimeout},
+	}
+	for name, urls := range services {
+		u, err := New(name, strings.Split(urls, ","), opts)
+		if err != nil {
+			return nil, err
+		}
+		r.upstreams[name] = u
+	}
+	for _, name := range required {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := r.upstreams[name]; !ok {
+			return nil, fmt.Errorf("required upstream %s is not configured", name)
+		}
+		r.required[name] = true
+	}
+	return r, nil
+}
+
+// Get returns an upstream by name, or nil
+func (r *Registry) Get(name string) *Upstream {
+	return r.upstreams[name]
+}
+
+// Status returns the health of every upstream by name, and whether every
+// required upstream has an available instance
+func (r *Registry) Status() (map[string]string, bool) {
+	statuses := make(map[string]string, len(r.upstreams))
+	ready := true
+	for name, u := range r.upstreams {
+		available := 0
+		for _, instance := range u.Instances {
+			if instance.Available() {
+				available++
+			}
+		}
+
+		switch {
+		case available == len(u.Instances):
+			statuses[name] = StatusHealthy
+		case available > 0:
+			statuses[name] = StatusDegraded
+		default:
+			statuses[name] = StatusUnhealthy
+			if r.required[name] {
+				ready = false
+			}
+		}
+	}
+	return statuses, ready
+}
+
+// StartHealthChecks probes every instance now and then every interval in
+// the background, until ctx is done
+func (r *Registry) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	names := make([]string, 0, len(r.upstreams))
+	for name := range r.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		u := r.upstreams[name]
+		metrics := middleware.RegisterServiceHealth(name)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				metrics.SetHealthy(r.probe(ctx, u))
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// probe checks the health endpoint of every instance of an upstream
+// concurrently and reports whether any is healthy
+func (r *Registry) probe(ctx context.Context, u *Upstream) bool {
+	var wg sync.WaitGroup
+	for _, instance := range u.Instances {
+		wg.Add(1)
+		go func(instance *Instance) {
+			defer wg.Done()
+			healthy := r.probeInstance(ctx, instance)
+			if was := instance.healthy.Swap(healthy); was != healthy && ctx.Err() == nil {
+				log := logger.Info
+				if !healthy {
+					log = logger.Warn
+				}
+				log("Upstream instance health changed",
+					zap.String("upstream", u.Name),
+					zap.String("instance", instance.URL.Host),
+					zap.Bool("healthy", healthy),
+				)
+			}
+		}(instance)
+	}
+	wg.Wait()
+
+	for _, instance := range u.Instances {
+		if instance.Healthy() {
+			return true
+		}
+	}
+	return false
+}
+
+// probeInstance reports whether an instance's health endpoint answers 2xx
+func (r *Registry) probeInstance(ctx context.Context, instance *Instance) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(instance.URL.String(), "/")+probePath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
@@ -0,0 +1,79 @@
+package upstream
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/navo/services/gateway/internal/route"
+)
+
+// RouteTimeout is how long the gateway waits on an upstream for requests
+// to a route. Path segments of "*" match any one segment.
+type RouteTimeout struct {
+	Method  string
+	Path    string
+	Timeout time.Duration
+}
+
+// DefaultRouteTimeouts are the timeouts of the gateway's slow routes.
+// Other requests get the default timeout.
+var DefaultRouteTimeouts = []RouteTimeout{
+	{Method: "GET", Path: "/api/v1/audit/export", Timeout: 2 * time.Minute},
+	{Method: "POST", Path: "/api/v1/ports/import", Timeout: 2 * time.Minute},
+	{Method: "POST", Path: "/api/v1/accounting/sync", Timeout: 2 * time.Minute},
+	{Method: "POST", Path: "/api/v1/fleet/refresh", Timeout: time.Minute},
+	{Method: "GET", Path: "/api/v1/analytics/*", Timeout: 30 * time.Second},
+}
+
+// ParseRouteTimeouts parses route timeouts written as comma-separated
+// "METHOD /path=duration" entries, such as
+// "GET /api/v1/audit/export=3m,POST /api/v1/ports/import=5m"
+func ParseRouteTimeouts(s string) ([]RouteTimeout, error) {
+	var timeouts []RouteTimeout
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		routePart, durationPart, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(routePart)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid route timeout %q: want \"METHOD /path=duration\"", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(durationPart))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid duration in route timeout %q", entry)
+		}
+
+		timeouts = append(timeouts, RouteTimeout{
+			Method:  strings.ToUpper(fields[0]),
+			Path:    fields[1],
+			Timeout: timeout,
+		})
+	}
+	return timeouts, nil
+}
+
+// Timeouts looks up the upstream timeout of requests
+type Timeouts struct {
+	fallback time.Duration
+	routes   []RouteTimeout
+}
+
+// NewTimeouts creates a timeout table. Requests get the timeout of the
+// first matching route, or the fallback.
+func NewTimeouts(fallback time.Duration, routes []RouteTimeout) *Timeouts {
+	return &Timeouts{fallback: fallback, routes: routes}
+}
+
+// For returns the timeout of a request to a route
+func (t *Timeouts) For(method, path string) time.Duration {
+	for _, rt := range t.routes {
+		if rt.Method == method && route.Match(rt.Path, path) {
+			return rt.Timeout
+		}
+	}
+	return t.fallback
+}
@@ -0,0 +1,280 @@
+// Package upstream sends gateway traffic to the services behind it.
+//
+// Each service is an Upstream of one or more instances, picked round-robin
+// among those passing their health probes and whose circuit breakers are
+// closed. Idempotent requests that fail on one instance are retried on
+// another.
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/navo/pkg/observability"
+	"github.com/navo/services/gateway/internal/middleware"
+)
+
+// IdempotencyKeyHeader marks a request as safe to retry whatever its method
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxRetryBody is the largest request body buffered so the request can be
+// retried. Requests with larger bodies are sent once.
+const maxRetryBody = 1 << 20
+
+// ErrUnavailable is returned when no instance of an upstream can take a
+// request, because all are down or their circuits are open
+var ErrUnavailable = errors.New("no upstream instance available")
+
+// Options configure an upstream
+type Options struct {
+	// Retries is how many times a failed idempotent request is retried on
+	// another instance
+	Retries int
+	// FailureThreshold is how many consecutive failures open an instance's
+	// circuit
+	FailureThreshold int
+	// OpenTimeout is how long an open circuit fails requests before letting
+	// a trial request through
+	OpenTimeout time.Duration
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// retry after it
+	RetryBackoff time.Duration
+	// Transport sends requests to instances. Nil means a pooled transport
+	// passing the request's trace context on.
+	Transport http.RoundTripper
+}
+
+// DefaultOptions returns the options used for the gateway's upstreams
+func DefaultOptions() Options {
+	return Options{
+		Retries:          2,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		RetryBackoff:     50 * time.Millisecond,
+	}
+}
+
+// Instance is one address an upstream is served at
+type Instance struct {
+	URL     *url.URL
+	Breaker *Breaker
+
+	healthy atomic.Bool
+}
+
+// Healthy reports whether the instance passed its last health probe.
+// Instances are healthy until probed.
+func (i *Instance) Healthy() bool {
+	return i.healthy.Load()
+}
+
+// Available reports whether the instance would be picked for requests
+func (i *Instance) Available() bool {
+	return i.Healthy() && i.Breaker.State() != StateOpen
+}
+
+// Upstream is a service behind the gateway. It is an http.RoundTripper
+// that balances requests over the service's instances.
+type Upstream struct {
+	Name      string
+	Instances []*Instance
+
+	opts      Options
+	transport http.RoundTripper
+	next      atomic.Uint32
+}
+
+// New creates an upstream served at the given instance URLs
+func New(name string, urls []string, opts Options) (*Upstream, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("upstream %s has no instances", name)
+	}
+
+	u := &Upstream{Name: name, opts: opts, transport: opts.Transport}
+	if u.transport == nil {
+		u.transport = observability.Transport(&http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 100,
+			IdleConnTimeout:     90 * time.Second,
+		})
+	}
+
+	for _, raw := range urls {
+		target, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for upstream %s: %w", name, err)
+		}
+		if target.Scheme == "" || target.Host == "" {
+			return nil, fmt.Errorf("invalid URL for upstream %s: %q", name, raw)
+		}
+		instance := &Instance{
+			URL:     target,
+			Breaker: NewBreaker(opts.FailureThreshold, opts.OpenTimeout),
+		}
+		instance.healthy.Store(true)
+		u.Instances = append(u.Instances, instance)
+	}
+	return u, nil
+}
+
+// RoundTrip sends a request to an instance of the upstream. Requests that
+// are safe to repeat are retried on another instance when they fail to
+// connect or the instance answers 502, 503 or 504; others are only retried
+// when they never reached the instance.
+func (u *Upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req)
+	var body []byte
+	if retryable && req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength < 0 || req.ContentLength > maxRetryBody {
+			retryable = false
+		} else {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read request body: %w", err)
+			}
+		}
+	}
+
+	tried := make(map[*Instance]bool, len(u.Instances))
+	instance := u.pick(tried)
+	if instance == nil {
+		return nil, fmt.Errorf("%s: %w", u.Name, ErrUnavailable)
+	}
+	for attempt := 0; ; attempt++ {
+		tried[instance] = true
+
+		out := u.outgoing(req, instance)
+		if body != nil {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		start := time.Now()
+		resp, err := u.transport.RoundTrip(out)
+		failed := err != nil || isUnavailableStatus(resp.StatusCode)
+
+		status := http.StatusBadGateway
+		if err == nil {
+			status = resp.StatusCode
+		}
+		middleware.RecordUpstreamRequest(u.Name, time.Since(start), status)
+
+		switch {
+		case err != nil && req.Context().Err() == context.Canceled:
+			// The client went away, which says nothing about the instance
+			instance.Breaker.Release()
+			return nil, err
+		case failed:
+			instance.Breaker.Failure()
+		default:
+			instance.Breaker.Success()
+			return resp, nil
+		}
+
+		last := attempt >= u.opts.Retries || req.Context().Err() != nil
+		if last || !(retryable || (err != nil && neverSent(err))) {
+			return resp, err
+		}
+		next := u.pick(tried)
+		if next == nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if err := u.wait(req.Context(), attempt); err != nil {
+			next.Breaker.Release()
+			return nil, err
+		}
+		instance = next
+	}
+}
+
+// pick returns the next instance round-robin that hasn't been tried for the
+// request, preferring healthy ones, or nil when every circuit is open
+func (u *Upstream) pick(tried map[*Instance]bool) *Instance {
+	n := len(u.Instances)
+	start := int(u.next.Add(1) - 1)
+	for _, healthyOnly := range []bool{true, false} {
+		for i := 0; i < n; i++ {
+			instance := u.Instances[(start+i)%n]
+			if tried[instance] || (healthyOnly && !instance.Healthy()) {
+				continue
+			}
+			if instance.Breaker.Allow() {
+				return instance
+			}
+		}
+	}
+	return nil
+}
+
+// outgoing copies a request for an instance, keeping the client's Host
+func (u *Upstream) outgoing(req *http.Request, instance *Instance) *http.Request {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = instance.URL.Scheme
+	out.URL.Host = instance.URL.Host
+	if base := strings.TrimSuffix(instance.URL.Path, "/"); base != "" {
+		out.URL.Path = base + out.URL.Path
+		out.URL.RawPath = ""
+	}
+	return out
+}
+
+// wait sleeps before a retry, with jitter so retries of many requests
+// don't land on the next instance at once
+func (u *Upstream) wait(ctx context.Context, attempt int) error {
+	backoff := u.opts.RetryBackoff << attempt
+	if backoff <= 0 {
+		return nil
+	}
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isIdempotent reports whether a request can be sent more than once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// isUnavailableStatus reports whether a response means the instance
+// couldn't serve the request, rather than the request being wrong
+func isUnavailableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// neverSent reports whether a request failed before reaching the instance,
+// so even requests that aren't idempotent can be retried
+func neverSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
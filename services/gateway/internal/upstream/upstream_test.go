@@ -0,0 +1,212 @@
+package upstream
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions() Options {
+	return Options{
+		Retries:          2,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		Transport:        http.DefaultTransport,
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, StateClosed, b.State())
+	require.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.Allow(), "open circuits fail fast")
+
+	now = now.Add(30 * time.Second)
+	require.True(t, b.Allow(), "one trial request after the open timeout")
+	assert.False(t, b.Allow(), "only one trial at a time")
+	b.Failure()
+	assert.Equal(t, StateOpen, b.State(), "a failed trial reopens the circuit")
+
+	now = now.Add(30 * time.Second)
+	require.True(t, b.Allow())
+	b.Release()
+	require.True(t, b.Allow(), "a released trial can be retried")
+	b.Success()
+	assert.Equal(t, StateClosed, b.State())
+	assert.True(t, b.Allow())
+}
+
+func TestUpstreamBalances(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hitsA.Add(1) }))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hitsB.Add(1) }))
+	defer b.Close()
+
+	u, err := New("core", []string{a.URL, b.URL}, testOptions())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		resp, err := u.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/port-calls", nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(5), hitsA.Load())
+	assert.Equal(t, int32(5), hitsB.Load())
+
+	t.Run("unhealthy instances are skipped", func(t *testing.T) {
+		u.Instances[1].healthy.Store(false)
+		for i := 0; i < 4; i++ {
+			resp, err := u.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/port-calls", nil))
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+		assert.Equal(t, int32(9), hitsA.Load())
+		assert.Equal(t, int32(5), hitsB.Load())
+	})
+}
+
+func TestUpstreamRetries(t *testing.T) {
+	var failing, healthy atomic.Int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthy.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer good.Close()
+
+	u, err := New("vessel", []string{bad.URL, good.URL}, testOptions())
+	require.NoError(t, err)
+
+	t.Run("idempotent requests move to another instance", func(t *testing.T) {
+		u.next.Store(0)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/vessels/v-1", strings.NewReader(`{"name":"Aurora"}`))
+		resp, err := u.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"name":"Aurora"}`, string(body), "the body is replayed")
+		assert.Equal(t, int32(1), failing.Load())
+	})
+
+	t.Run("other requests are sent once", func(t *testing.T) {
+		u.next.Store(0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/vessels", strings.NewReader(`{}`))
+		resp, err := u.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(2), failing.Load())
+	})
+
+	t.Run("failures open the instance's circuit", func(t *testing.T) {
+		assert.Equal(t, StateOpen, u.Instances[0].Breaker.State())
+
+		u.next.Store(0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/vessels", nil)
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		resp, err := u.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), failing.Load(), "the open instance is skipped")
+	})
+}
+
+func TestUpstreamUnavailable(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	opts := testOptions()
+	opts.FailureThreshold = 1
+	u, err := New("vessel", []string{down.URL}, opts)
+	require.NoError(t, err)
+
+	_, err = u.RoundTrip(httptest.NewRequest(http.MethodPost, "/api/v1/fleet/refresh", nil))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+
+	_, err = u.RoundTrip(httptest.NewRequest(http.MethodPost, "/api/v1/fleet/refresh", nil))
+	assert.ErrorIs(t, err, ErrUnavailable, "requests fail fast while the circuit is open")
+}
+
+func TestUpstreamTimeout(t *testing.T) {
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	opts := testOptions()
+	opts.Retries = 0
+	u, err := New("vessel", []string{hanging.URL}, opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/vessels", nil).WithContext(ctx)
+	_, err = u.RoundTrip(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, StateClosed, u.Instances[0].Breaker.State(), "one timeout is below the threshold")
+	assert.Equal(t, 1, u.Instances[0].Breaker.failures)
+}
+
+func TestRegistryStatus(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	registry, err := NewRegistry(map[string]string{
+		"core":   healthy.URL + "," + down.URL,
+		"vendor": down.URL,
+	}, []string{"core"}, testOptions())
+	require.NoError(t, err)
+
+	assert.True(t, registry.probe(context.Background(), registry.Get("core")))
+	assert.False(t, registry.probe(context.Background(), registry.Get("vendor")))
+
+	statuses, ready := registry.Status()
+	assert.True(t, ready, "only required upstreams decide readiness")
+	assert.Equal(t, map[string]string{"core": StatusDegraded, "vendor": StatusUnhealthy}, statuses)
+
+	_, err = NewRegistry(map[string]string{"core": healthy.URL}, []string{"auth"}, testOptions())
+	assert.Error(t, err)
+}
+
+func TestTimeouts(t *testing.T) {
+	routes, err := ParseRouteTimeouts("get /api/v1/vessels/*/track=20s, POST /api/v1/ports/import=5m")
+	require.NoError(t, err)
+	timeouts := NewTimeouts(10*time.Second, append(routes, DefaultRouteTimeouts...))
+
+	assert.Equal(t, 20*time.Second, timeouts.For("GET", "/api/v1/vessels/v-1/track"))
+	assert.Equal(t, 5*time.Minute, timeouts.For("POST", "/api/v1/ports/import"), "configured timeouts override defaults")
+	assert.Equal(t, 2*time.Minute, timeouts.For("GET", "/api/v1/audit/export"))
+	assert.Equal(t, 10*time.Second, timeouts.For("GET", "/api/v1/port-calls"))
+
+	for _, invalid := range []string{"/api/v1/ports=1m", "GET /api/v1/ports", "GET /api/v1/ports=soon"} {
+		_, err := ParseRouteTimeouts(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,12 +13,18 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	_ "github.com/lib/pq"
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/database"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/migrate"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/services/integration/internal/config"
 	"github.com/navo/services/integration/internal/handler"
+	"github.com/navo/services/integration/internal/model"
 	"github.com/navo/services/integration/internal/repository"
 	"github.com/navo/services/integration/internal/service"
+	"github.com/navo/services/integration/migrations"
 	"go.uber.org/zap"
 )
 
@@ -31,8 +36,15 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(context.Background(), observability.DefaultConfig("integration"))
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
 	// Connect to database
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	db, err := database.OpenDB(cfg.DatabaseURL)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
@@ -49,10 +61,42 @@ func main() {
 	}
 	logger.Info("Connected to database")
 
-	// Initialize schema
+	// Apply schema migrations; replicas starting together wait on the
+	// migration lock. "integration migrate ..." manages them instead.
+	migrator, err := migrate.New(db, "integration", migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrate.Command(context.Background(), migrator, os.Args[2:], os.Stdout)
+		db.Close()
+		os.Exit(code)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to apply migrations", zap.Error(err))
+	}
+	logger.Info("Database schema up to date", zap.Int("applied", len(applied)))
+
+	// Initialize repositories
 	webhookRepo := repository.NewWebhookRepository(db)
-	if err := webhookRepo.InitSchema(context.Background()); err != nil {
-		logger.Warn("Failed to initialize schema (may already exist)", zap.Error(err))
+	weatherAlertRepo := repository.NewWeatherAlertRepository(db)
+	portRepo := repository.NewPortRepository(db)
+	accountingRepo := repository.NewAccountingRepository(db)
+
+	// Connect to Redis for event publishing (optional)
+	var redisClient *redis.Client
+	if cfg.RedisURL != "" {
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logger.Fatal("Invalid REDIS_URL", zap.Error(err))
+		}
+		redisClient = redis.NewClient(opts)
+		observability.InstrumentRedis(redisClient)
+		if err := redisClient.Ping(context.Background()).Err(); err != nil {
+			logger.Warn("Failed to connect to Redis, alerts will not be dispatched", zap.Error(err))
+			redisClient = nil
+		}
 	}
 
 	// Initialize services
@@ -71,21 +115,88 @@ func main() {
 		cfg.WeatherAPIKey,
 		cfg.WeatherAPIBaseURL,
 		zap.L(),
-	)
+	).WithMarineAPI(cfg.MarineAPIBaseURL)
 
 	exchangeSvc := service.NewExchangeRateService(
 		cfg.ExchangeRateAPI,
 		zap.L(),
 	)
 
+	weatherAlertSvc := service.NewWeatherAlertService(
+		weatherAlertRepo,
+		weatherSvc,
+		redisClient,
+		zap.L(),
+		service.WeatherAlertConfig{
+			Thresholds: model.WeatherThresholds{
+				MaxWindSpeed:  cfg.WeatherAlertMaxWindSpeed,
+				MaxWaveHeight: cfg.WeatherAlertMaxWaveHeight,
+				MinVisibility: cfg.WeatherAlertMinVisibility,
+			},
+			Interval:       cfg.WeatherAlertInterval,
+			Lookahead:      cfg.WeatherAlertLookahead,
+			MaxPositionAge: cfg.WeatherAlertMaxPositionAge,
+		},
+	).WithCore(service.NewCoreClient(cfg.CoreServiceURL))
+
+	portSvc := service.NewPortService(portRepo, zap.L())
+
+	var accountingProvider service.AccountingProvider
+	switch cfg.AccountingProvider {
+	case "file":
+		accountingProvider, err = service.NewFileAccountingProvider(cfg.AccountingExportDir, cfg.AccountingExportFormat)
+		if err != nil {
+			logger.Fatal("Failed to initialize accounting file provider", zap.Error(err))
+		}
+	case "rest":
+		accountingProvider = service.NewRESTAccountingProvider(cfg.AccountingAPIURL, cfg.AccountingAPIKey, 30*time.Second)
+	case "":
+	default:
+		logger.Fatal("Unknown ACCOUNTING_PROVIDER", zap.String("provider", cfg.AccountingProvider))
+	}
+
+	var accountingSvc *service.AccountingService
+	if accountingProvider != nil {
+		accountingSvc = service.NewAccountingService(
+			accountingRepo,
+			accountingProvider,
+			zap.L(),
+			service.AccountingConfig{
+				SyncInterval:     cfg.AccountingSyncInterval,
+				PaymentTermsDays: cfg.AccountingPaymentTermsDays,
+			},
+		)
+	}
+
+	// Start background evaluators
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	if cfg.WeatherAlertsEnabled {
+		go weatherAlertSvc.Start(bgCtx)
+	}
+	if accountingSvc != nil {
+		go accountingSvc.Start(bgCtx)
+	}
+
 	// Initialize handlers
 	webhookHandler := handler.NewWebhookHandler(webhookSvc, zap.L())
 	externalHandler := handler.NewExternalHandler(weatherSvc, exchangeSvc, zap.L())
+	weatherAlertHandler := handler.NewWeatherAlertHandler(weatherAlertSvc, zap.L())
+	portHandler := handler.NewPortHandler(portSvc, zap.L())
+
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Integration Service",
+		Description: "Webhooks, weather, exchange rates, ports and accounting sync",
+	}, handler.Operations)
 
 	// Create router
 	r := chi.NewRouter()
 
 	// Middleware
+	r.Use(observability.HTTPMiddleware) // Continues the gateway's trace
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
@@ -96,11 +207,12 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Organization-ID", "X-Workspace-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Organization-ID", "X-Workspace-ID", "X-User-Roles", "traceparent", "tracestate"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	r.Use(api.Validate)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -111,31 +223,53 @@ func main() {
 		})
 	})
 
-	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		webhookHandler.RegisterRoutes(r)
-		externalHandler.RegisterRoutes(r)
-	})
+	var accountingHandler *handler.AccountingHandler
+	if accountingSvc != nil {
+		accountingHandler = handler.NewAccountingHandler(accountingSvc, zap.L())
+	}
 
 	// Sync status endpoint
-	r.Get("/api/v1/sync-status", func(w http.ResponseWriter, r *http.Request) {
+	syncStatus := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"weather": map[string]interface{}{
 				"status":        "active",
 				"sync_interval": cfg.WeatherSyncInterval.String(),
 			},
+			"weather_alerts": map[string]interface{}{
+				"enabled":       cfg.WeatherAlertsEnabled,
+				"sync_interval": cfg.WeatherAlertInterval.String(),
+			},
 			"exchange_rates": map[string]interface{}{
 				"status":        "active",
 				"sync_interval": cfg.ExchangeRateSyncInterval.String(),
 			},
+			"accounting": map[string]interface{}{
+				"enabled":       accountingSvc != nil,
+				"provider":      cfg.AccountingProvider,
+				"sync_interval": cfg.AccountingSyncInterval.String(),
+			},
 			"port_info": map[string]interface{}{
 				"status":        "inactive",
 				"sync_interval": cfg.PortInfoSyncInterval.String(),
 			},
 		})
+	}
+
+	// API routes
+	handler.Routes(r, handler.Handlers{
+		Webhooks:      webhookHandler,
+		External:      externalHandler,
+		WeatherAlerts: weatherAlertHandler,
+		Ports:         portHandler,
+		Accounting:    accountingHandler,
+		SyncStatus:    syncStatus,
 	})
 
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Start HTTP server
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -161,6 +295,8 @@ func main() {
 
 	logger.Info("Shutting down integration service...")
 
+	bgCancel()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
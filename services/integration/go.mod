@@ -5,6 +5,8 @@ go 1.22
 require (
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/go-chi/cors v1.2.1
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 	github.com/navo/pkg v0.0.0
 	go.uber.org/zap v1.27.0
@@ -26,9 +26,13 @@ type Config struct {
 	WebhookRetryDelay   time.Duration
 	WebhookMaxBatchSize int
 
+	// Services
+	CoreServiceURL string
+
 	// External APIs
 	WeatherAPIKey     string
 	WeatherAPIBaseURL string
+	MarineAPIBaseURL  string // Open-Meteo marine forecasts, for wave heights
 	PortInfoAPIKey    string
 	PortInfoAPIURL    string
 	ExchangeRateAPI   string
@@ -45,6 +49,24 @@ type Config struct {
 	WeatherSyncInterval      time.Duration
 	PortInfoSyncInterval     time.Duration
 	ExchangeRateSyncInterval time.Duration
+
+	// Weather alerts for vessels and port calls
+	WeatherAlertsEnabled       bool
+	WeatherAlertInterval       time.Duration
+	WeatherAlertLookahead      time.Duration
+	WeatherAlertMaxPositionAge time.Duration
+	WeatherAlertMaxWindSpeed   float64 // m/s
+	WeatherAlertMaxWaveHeight  float64 // Meters
+	WeatherAlertMinVisibility  int     // Meters
+
+	// Accounting connector
+	AccountingProvider         string // file, rest; empty disables the connector
+	AccountingExportDir        string
+	AccountingExportFormat     string // csv, ubl
+	AccountingAPIURL           string
+	AccountingAPIKey           string
+	AccountingSyncInterval     time.Duration
+	AccountingPaymentTermsDays int
 }
 
 // Load loads configuration from environment variables
@@ -61,8 +83,11 @@ func Load() *Config {
 		WebhookRetryDelay:   getDuration("WEBHOOK_RETRY_DELAY", 5*time.Second),
 		WebhookMaxBatchSize: getInt("WEBHOOK_MAX_BATCH_SIZE", 100),
 
+		CoreServiceURL: getEnv("CORE_SERVICE_URL", "http://localhost:4002"),
+
 		WeatherAPIKey:     getEnv("WEATHER_API_KEY", ""),
 		WeatherAPIBaseURL: getEnv("WEATHER_API_URL", "https://api.openweathermap.org/data/2.5"),
+		MarineAPIBaseURL:  getEnv("MARINE_API_URL", "https://marine-api.open-meteo.com/v1"),
 		PortInfoAPIKey:    getEnv("PORT_INFO_API_KEY", ""),
 		PortInfoAPIURL:    getEnv("PORT_INFO_API_URL", ""),
 		ExchangeRateAPI:   getEnv("EXCHANGE_RATE_API_KEY", ""),
@@ -76,6 +101,22 @@ func Load() *Config {
 		WeatherSyncInterval:      getDuration("WEATHER_SYNC_INTERVAL", 30*time.Minute),
 		PortInfoSyncInterval:     getDuration("PORT_INFO_SYNC_INTERVAL", 24*time.Hour),
 		ExchangeRateSyncInterval: getDuration("EXCHANGE_RATE_SYNC_INTERVAL", 6*time.Hour),
+
+		WeatherAlertsEnabled:       getBool("WEATHER_ALERTS_ENABLED", true),
+		WeatherAlertInterval:       getDuration("WEATHER_ALERT_INTERVAL", 30*time.Minute),
+		WeatherAlertLookahead:      getDuration("WEATHER_ALERT_LOOKAHEAD", 72*time.Hour),
+		WeatherAlertMaxPositionAge: getDuration("WEATHER_ALERT_MAX_POSITION_AGE", 6*time.Hour),
+		WeatherAlertMaxWindSpeed:   getFloat("WEATHER_ALERT_MAX_WIND_SPEED", 17.2), // Beaufort 8 (gale)
+		WeatherAlertMaxWaveHeight:  getFloat("WEATHER_ALERT_MAX_WAVE_HEIGHT", 3.5),
+		WeatherAlertMinVisibility:  getInt("WEATHER_ALERT_MIN_VISIBILITY", 1000),
+
+		AccountingProvider:         getEnv("ACCOUNTING_PROVIDER", ""),
+		AccountingExportDir:        getEnv("ACCOUNTING_EXPORT_DIR", "/var/lib/navo/accounting"),
+		AccountingExportFormat:     getEnv("ACCOUNTING_EXPORT_FORMAT", "csv"),
+		AccountingAPIURL:           getEnv("ACCOUNTING_API_URL", ""),
+		AccountingAPIKey:           getEnv("ACCOUNTING_API_KEY", ""),
+		AccountingSyncInterval:     getDuration("ACCOUNTING_SYNC_INTERVAL", 15*time.Minute),
+		AccountingPaymentTermsDays: getInt("ACCOUNTING_PAYMENT_TERMS_DAYS", 30),
 	}
 }
 
@@ -95,6 +136,22 @@ func getInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		return value == "true" || value == "1"
+	}
+	return defaultValue
+}
+
 func getDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if d, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/service"
+	"go.uber.org/zap"
+)
+
+// AccountingHandler handles accounting connector endpoints
+type AccountingHandler struct {
+	service *service.AccountingService
+	logger  *zap.Logger
+}
+
+// NewAccountingHandler creates a new accounting handler
+func NewAccountingHandler(svc *service.AccountingService, logger *zap.Logger) *AccountingHandler {
+	return &AccountingHandler{
+		service: svc,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers accounting routes
+func (h *AccountingHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/accounting", func(r chi.Router) {
+		r.Get("/exports", h.ListExports)
+		r.With(requireAdmin).Post("/sync", h.Sync)
+	})
+}
+
+// ListExports lists accounting exports for the organization
+func (h *AccountingHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		h.errorResponse(w, http.StatusUnauthorized, "organization ID required")
+		return
+	}
+
+	filter := model.AccountingExportFilter{
+		OrganizationID: orgID,
+		EntityType:     model.AccountingEntityType(r.URL.Query().Get("entity_type")),
+		EntityID:       r.URL.Query().Get("entity_id"),
+		Status:         r.URL.Query().Get("status"),
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	exports, err := h.service.ListExports(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list accounting exports", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to list accounting exports")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"provider": h.service.ProviderName(),
+		"exports":  exports,
+	})
+}
+
+// Sync syncs suppliers, exports completed orders and polls payments for the organization
+func (h *AccountingHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		h.errorResponse(w, http.StatusUnauthorized, "organization ID required")
+		return
+	}
+
+	result, err := h.service.Sync(r.Context(), orgID)
+	if err != nil {
+		h.logger.Error("Failed to run accounting sync", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to run accounting sync")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, result)
+}
+
+func (h *AccountingHandler) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *AccountingHandler) errorResponse(w http.ResponseWriter, status int, message string) {
+	h.jsonResponse(w, status, map[string]string{"error": message})
+}
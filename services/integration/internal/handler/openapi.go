@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/integration/internal/model"
+)
+
+var coordinateParams = []openapi.Param{
+	{Name: "lat", Type: "number", Required: true, Description: "Latitude in degrees"},
+	{Name: "lon", Type: "number", Required: true, Description: "Longitude in degrees"},
+}
+
+// Operations describe what the integration service's handlers read and
+// write, for its OpenAPI document and request validation
+var Operations = openapi.Operations{
+	// Webhooks
+	"GET /api/v1/webhooks": {
+		Summary: "List webhooks",
+		Query: []openapi.Param{
+			{Name: "page", Type: "integer", Description: "Page number, from 1"},
+			{Name: "page_size", Type: "integer", Description: "Items per page"},
+		},
+		Response: model.WebhookListResponse{},
+	},
+	"POST /api/v1/webhooks": {
+		Summary:  "Create a webhook",
+		Request:  model.CreateWebhookRequest{},
+		Response: model.Webhook{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/webhooks/{id}": {
+		Summary:  "Get a webhook",
+		Response: model.Webhook{},
+	},
+	"PUT /api/v1/webhooks/{id}": {
+		Summary:  "Update a webhook",
+		Request:  model.UpdateWebhookRequest{},
+		Response: model.Webhook{},
+	},
+	"DELETE /api/v1/webhooks/{id}": {
+		Summary: "Delete a webhook",
+		Status:  http.StatusNoContent,
+	},
+	"POST /api/v1/webhooks/{id}/test": {
+		Summary:  "Send a test event to a webhook",
+		Response: model.WebhookDelivery{},
+	},
+	"POST /api/v1/webhooks/{id}/regenerate-secret": {
+		Summary:     "Regenerate a webhook's signing secret",
+		Description: "The new secret is only returned here.",
+		Response: struct {
+			Webhook model.Webhook `json:"webhook"`
+			Secret  string        `json:"secret"`
+		}{},
+	},
+	"GET /api/v1/webhooks/{id}/deliveries": {
+		Summary: "List a webhook's deliveries",
+		Query: []openapi.Param{
+			{Name: "page", Type: "integer", Description: "Page number, from 1"},
+			{Name: "page_size", Type: "integer", Description: "Items per page"},
+		},
+		Response: model.DeliveryListResponse{},
+	},
+	"GET /api/v1/webhook-events": {
+		Summary: "List the events webhooks subscribe to",
+		Tags:    []string{"webhooks"},
+		Response: struct {
+			Events []map[string]string `json:"events"`
+		}{},
+	},
+
+	// Weather and currencies
+	"GET /api/v1/weather": {
+		Summary:  "Get the current weather at a position",
+		Query:    coordinateParams,
+		Response: model.WeatherData{},
+	},
+	"GET /api/v1/weather/forecast": {
+		Summary: "Get the weather forecast at a position",
+		Query: []openapi.Param{
+			coordinateParams[0],
+			coordinateParams[1],
+			{Name: "days", Type: "integer", Description: "Days to forecast, 1 to 7, 5 by default"},
+		},
+		Response: struct {
+			Location map[string]float64      `json:"location"`
+			Days     int                     `json:"days"`
+			Forecast []model.WeatherForecast `json:"forecast"`
+		}{},
+	},
+	"GET /api/v1/currency/rates": {
+		Summary:  "Get exchange rates",
+		Query:    []openapi.Param{{Name: "base", Description: "Base currency, USD by default"}},
+		Response: model.ExchangeRates{},
+	},
+	"GET /api/v1/currency/convert": {
+		Summary: "Convert an amount between currencies",
+		Query: []openapi.Param{
+			{Name: "from", Required: true},
+			{Name: "to", Required: true},
+			{Name: "amount", Type: "number", Required: true},
+		},
+		Response: model.CurrencyConversion{},
+	},
+	"GET /api/v1/currency/supported": {
+		Summary: "List the supported currencies",
+		Response: struct {
+			Currencies []string `json:"currencies"`
+		}{},
+	},
+
+	// Weather alerts
+	"GET /api/v1/weather/alerts": {
+		Summary: "List operational weather alerts",
+		Query: []openapi.Param{
+			{Name: "target_type", Enum: []string{"vessel", "port_call"}},
+			{Name: "target_id"},
+			{Name: "active", Type: "boolean", Description: "Only alerts still active"},
+			{Name: "limit", Type: "integer"},
+		},
+		Response: struct {
+			Alerts []model.OperationalWeatherAlert `json:"alerts"`
+		}{},
+	},
+	"GET /api/v1/weather/alerts/thresholds": {
+		Summary:  "Get the weather alert thresholds",
+		Response: model.WeatherThresholds{},
+	},
+	"POST /api/v1/weather/alerts/evaluate": {
+		Summary:  "Evaluate weather alerts now",
+		Response: model.WeatherEvaluationResult{},
+	},
+
+	// Ports
+	"GET /api/v1/ports": {
+		Summary: "Search ports",
+		Query: []openapi.Param{
+			{Name: "q", Description: "Text to search for"},
+			{Name: "unlocode"},
+			{Name: "country", Description: "ISO 3166-1 alpha-2 country code"},
+			{Name: "lat", Type: "number"},
+			{Name: "lon", Type: "number"},
+			{Name: "radius_km", Type: "number"},
+			{Name: "limit", Type: "integer"},
+			{Name: "offset", Type: "integer"},
+		},
+		Response: struct {
+			Ports []model.PortSearchResult `json:"ports"`
+			Total int                      `json:"total"`
+		}{},
+	},
+	"GET /api/v1/ports/unlocode/{code}": {
+		Summary:  "Get a port by UN/LOCODE",
+		Response: model.PortInfo{},
+	},
+	"GET /api/v1/ports/{id}": {
+		Summary:  "Get a port",
+		Response: model.PortInfo{},
+	},
+	"PUT /api/v1/ports/{id}": {
+		Summary:  "Edit a port",
+		Request:  model.UpdatePortInfoRequest{},
+		Response: model.PortInfo{},
+	},
+	"POST /api/v1/ports/unlocode/{code}/restriction-check": {
+		Summary:  "Check vessel dimensions against the limits of a port by UN/LOCODE",
+		Request:  model.PortRestrictionCheckRequest{},
+		Response: model.PortRestrictionCheckResult{},
+	},
+	"POST /api/v1/ports/{id}/restriction-check": {
+		Summary:  "Check vessel dimensions against a port's limits",
+		Request:  model.PortRestrictionCheckRequest{},
+		Response: model.PortRestrictionCheckResult{},
+	},
+	"POST /api/v1/ports/import": {
+		Summary:     "Import ports from a UN/LOCODE CSV",
+		Description: "Takes the CSV as the request body, or as the file field of a multipart form.",
+		Response:    model.PortImportResult{},
+	},
+
+	// Accounting
+	"GET /api/v1/accounting/exports": {
+		Summary: "List accounting exports",
+		Query: []openapi.Param{
+			{Name: "entity_type", Enum: []string{"contact", "invoice"}},
+			{Name: "entity_id"},
+			{Name: "status"},
+			{Name: "limit", Type: "integer"},
+		},
+		Response: struct {
+			Provider string                   `json:"provider"`
+			Exports  []model.AccountingExport `json:"exports"`
+		}{},
+	},
+	"POST /api/v1/accounting/sync": {
+		Summary:  "Sync with the accounting system now",
+		Response: model.AccountingSyncResult{},
+	},
+
+	// Sync status
+	"GET /api/v1/sync-status": {
+		Summary:  "Get the status and interval of each background sync",
+		Response: map[string]map[string]any{},
+	},
+}
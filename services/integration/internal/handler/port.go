@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/service"
+	"go.uber.org/zap"
+)
+
+// maxImportSize limits the size of an uploaded UN/LOCODE file
+const maxImportSize = 64 << 20
+
+// PortHandler handles port registry endpoints
+type PortHandler struct {
+	service *service.PortService
+	logger  *zap.Logger
+}
+
+// NewPortHandler creates a new port handler
+func NewPortHandler(svc *service.PortService, logger *zap.Logger) *PortHandler {
+	return &PortHandler{
+		service: svc,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers port registry routes
+func (h *PortHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/ports", func(r chi.Router) {
+		r.Get("/", h.Search)
+		r.Get("/unlocode/{code}", h.GetByUNLocode)
+		r.Post("/unlocode/{code}/restriction-check", h.CheckRestrictionsByUNLocode)
+		r.Get("/{id}", h.Get)
+		r.Post("/{id}/restriction-check", h.CheckRestrictions)
+
+		// Admin endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(requireAdmin)
+			r.Post("/import", h.Import)
+			r.Put("/{id}", h.Update)
+		})
+	})
+}
+
+// Search searches ports by name, code, country or proximity
+func (h *PortHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := model.PortSearchFilter{
+		Query:       q.Get("q"),
+		UNLocode:    q.Get("unlocode"),
+		CountryCode: q.Get("country"),
+	}
+
+	if v := q.Get("lat"); v != "" {
+		lat, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "invalid latitude")
+			return
+		}
+		filter.Latitude = &lat
+	}
+	if v := q.Get("lon"); v != "" {
+		lon, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "invalid longitude")
+			return
+		}
+		filter.Longitude = &lon
+	}
+	if v := q.Get("radius_km"); v != "" {
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "invalid radius")
+			return
+		}
+		filter.RadiusKm = radius
+	}
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(q.Get("offset")); err == nil {
+		filter.Offset = offset
+	}
+
+	ports, total, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to search ports", zap.Error(err))
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"ports": ports,
+		"total": total,
+	})
+}
+
+// Get retrieves a port by ID
+func (h *PortHandler) Get(w http.ResponseWriter, r *http.Request) {
+	port, err := h.service.GetPort(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Error("Failed to get port", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to get port")
+		return
+	}
+	if port == nil {
+		h.errorResponse(w, http.StatusNotFound, "port not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, port)
+}
+
+// GetByUNLocode retrieves a port by UN/LOCODE
+func (h *PortHandler) GetByUNLocode(w http.ResponseWriter, r *http.Request) {
+	port, err := h.service.GetPortByUNLocode(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		h.logger.Error("Failed to get port", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to get port")
+		return
+	}
+	if port == nil {
+		h.errorResponse(w, http.StatusNotFound, "port not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, port)
+}
+
+// Import imports ports from a UN/LOCODE CSV, sent either as the raw request
+// body or as a multipart "file" field
+func (h *PortHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	body := r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.service.ImportUNLocode(r.Context(), body)
+	if err != nil {
+		h.logger.Error("Failed to import UN/LOCODE", zap.Error(err))
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, result)
+}
+
+// Update applies an admin edit to a port
+func (h *PortHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdatePortInfoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	port, err := h.service.UpdatePort(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.logger.Error("Failed to update port", zap.Error(err))
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if port == nil {
+		h.errorResponse(w, http.StatusNotFound, "port not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, port)
+}
+
+// CheckRestrictions validates vessel dimensions against a port's limits
+func (h *PortHandler) CheckRestrictions(w http.ResponseWriter, r *http.Request) {
+	h.checkRestrictions(w, r, h.service.CheckRestrictions, chi.URLParam(r, "id"))
+}
+
+// CheckRestrictionsByUNLocode validates vessel dimensions against the limits
+// of the port with a UN/LOCODE, for services that know ports by code
+func (h *PortHandler) CheckRestrictionsByUNLocode(w http.ResponseWriter, r *http.Request) {
+	h.checkRestrictions(w, r, h.service.CheckRestrictionsByUNLocode, chi.URLParam(r, "code"))
+}
+
+func (h *PortHandler) checkRestrictions(w http.ResponseWriter, r *http.Request, check func(context.Context, string, model.PortRestrictionCheckRequest) (*model.PortRestrictionCheckResult, error), key string) {
+	var req model.PortRestrictionCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := check(r.Context(), key, req)
+	if err != nil {
+		h.logger.Error("Failed to check port restrictions", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to check port restrictions")
+		return
+	}
+	if result == nil {
+		h.errorResponse(w, http.StatusNotFound, "port not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, result)
+}
+
+func (h *PortHandler) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *PortHandler) errorResponse(w http.ResponseWriter, status int, message string) {
+	h.jsonResponse(w, status, map[string]string{"error": message})
+}
+
+// requireAdmin rejects requests without the admin role forwarded by the gateway
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, role := range strings.Split(r.Header.Get("X-User-Roles"), ",") {
+			if strings.TrimSpace(role) == "admin" {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "admin role required"})
+	})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers are the handlers serving the integration service's API
+type Handlers struct {
+	Webhooks      *WebhookHandler
+	External      *ExternalHandler
+	WeatherAlerts *WeatherAlertHandler
+	Ports         *PortHandler
+	// Accounting is nil when no accounting provider is configured
+	Accounting *AccountingHandler
+	SyncStatus http.HandlerFunc
+}
+
+// Routes registers the API routes. Each must have an entry in Operations,
+// which the tests check.
+func Routes(r chi.Router, h Handlers) {
+	r.Route("/api/v1", func(r chi.Router) {
+		h.Webhooks.RegisterRoutes(r)
+		h.External.RegisterRoutes(r)
+		h.WeatherAlerts.RegisterRoutes(r)
+		h.Ports.RegisterRoutes(r)
+		if h.Accounting != nil {
+			h.Accounting.RegisterRoutes(r)
+		}
+		r.Get("/sync-status", h.SyncStatus)
+	})
+}
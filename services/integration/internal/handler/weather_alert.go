@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/service"
+	"go.uber.org/zap"
+)
+
+// WeatherAlertHandler handles weather alert endpoints
+type WeatherAlertHandler struct {
+	service *service.WeatherAlertService
+	logger  *zap.Logger
+}
+
+// NewWeatherAlertHandler creates a new weather alert handler
+func NewWeatherAlertHandler(svc *service.WeatherAlertService, logger *zap.Logger) *WeatherAlertHandler {
+	return &WeatherAlertHandler{
+		service: svc,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers weather alert routes
+func (h *WeatherAlertHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/weather/alerts", func(r chi.Router) {
+		r.Get("/", h.ListAlerts)
+		r.Get("/thresholds", h.GetThresholds)
+		r.Post("/evaluate", h.Evaluate)
+	})
+}
+
+// ListAlerts lists weather alerts for the organization
+func (h *WeatherAlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		h.errorResponse(w, http.StatusUnauthorized, "organization ID required")
+		return
+	}
+
+	filter := model.WeatherAlertFilter{
+		OrganizationID: orgID,
+		TargetType:     model.WeatherAlertTargetType(r.URL.Query().Get("target_type")),
+		TargetID:       r.URL.Query().Get("target_id"),
+		ActiveOnly:     r.URL.Query().Get("active") == "true",
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	alerts, err := h.service.ListAlerts(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list weather alerts", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to list weather alerts")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"alerts": alerts,
+	})
+}
+
+// GetThresholds returns the configured alert thresholds
+func (h *WeatherAlertHandler) GetThresholds(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, http.StatusOK, h.service.Thresholds())
+}
+
+// Evaluate runs the weather alert evaluator immediately
+func (h *WeatherAlertHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Evaluate(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to evaluate weather alerts", zap.Error(err))
+		h.errorResponse(w, http.StatusInternalServerError, "failed to evaluate weather alerts")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, result)
+}
+
+func (h *WeatherAlertHandler) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *WeatherAlertHandler) errorResponse(w http.ResponseWriter, status int, message string) {
+	h.jsonResponse(w, status, map[string]string{"error": message})
+}
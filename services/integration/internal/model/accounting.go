@@ -0,0 +1,129 @@
+package model
+
+import "time"
+
+// AccountingEntityType identifies what an accounting export refers to
+type AccountingEntityType string
+
+const (
+	AccountingEntityContact AccountingEntityType = "contact"
+	AccountingEntityInvoice AccountingEntityType = "invoice"
+)
+
+// AccountingInvoiceStatus represents the status of an invoice in the accounting system
+type AccountingInvoiceStatus string
+
+const (
+	AccountingInvoiceFailed        AccountingInvoiceStatus = "failed"
+	AccountingInvoiceSubmitted     AccountingInvoiceStatus = "submitted"
+	AccountingInvoiceAuthorised    AccountingInvoiceStatus = "authorised"
+	AccountingInvoicePartiallyPaid AccountingInvoiceStatus = "partially_paid"
+	AccountingInvoicePaid          AccountingInvoiceStatus = "paid"
+	AccountingInvoiceVoided        AccountingInvoiceStatus = "voided"
+)
+
+// IsOpen reports whether the invoice may still change payment status
+func (s AccountingInvoiceStatus) IsOpen() bool {
+	switch s {
+	case AccountingInvoiceSubmitted, AccountingInvoiceAuthorised, AccountingInvoicePartiallyPaid:
+		return true
+	}
+	return false
+}
+
+// SupplierContact is a vendor as represented in the accounting system
+type SupplierContact struct {
+	VendorID           string  `json:"vendor_id"`
+	ExternalID         string  `json:"external_id,omitempty"`
+	Name               string  `json:"name"`
+	RegistrationNumber string  `json:"registration_number,omitempty"`
+	Email              string  `json:"email,omitempty"`
+	Phone              string  `json:"phone,omitempty"`
+	Address            Address `json:"address"`
+	BankName           string  `json:"bank_name,omitempty"`
+	BankAccountName    string  `json:"bank_account_name,omitempty"`
+	BankAccountNumber  string  `json:"bank_account_number,omitempty"`
+	BankRoutingNumber  string  `json:"bank_routing_number,omitempty"`
+}
+
+// Address represents a postal address
+type Address struct {
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+// AccountingInvoice is a payable invoice created from a completed service order
+type AccountingInvoice struct {
+	ServiceOrderID    string                  `json:"service_order_id"`
+	Number            string                  `json:"number"`
+	OrganizationID    string                  `json:"organization_id"`
+	Supplier          SupplierContact         `json:"supplier"`
+	IssueDate         time.Time               `json:"issue_date"`
+	DueDate           time.Time               `json:"due_date"`
+	Currency          string                  `json:"currency"`
+	PortCallReference string                  `json:"port_call_reference"`
+	VesselName        string                  `json:"vessel_name,omitempty"`
+	PortName          string                  `json:"port_name,omitempty"`
+	Lines             []AccountingInvoiceLine `json:"lines"`
+	Total             float64                 `json:"total"`
+}
+
+// AccountingInvoiceLine is a single line on an accounting invoice
+type AccountingInvoiceLine struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit,omitempty"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// InvoicePaymentStatus is the payment state of an invoice reported by the accounting system
+type InvoicePaymentStatus struct {
+	ExternalID string                  `json:"external_id"`
+	Status     AccountingInvoiceStatus `json:"status"`
+	AmountPaid float64                 `json:"amount_paid"`
+	AmountDue  float64                 `json:"amount_due"`
+	PaidAt     *time.Time              `json:"paid_at,omitempty"`
+}
+
+// AccountingExport tracks an entity pushed to the accounting system
+type AccountingExport struct {
+	ID             string               `json:"id"`
+	OrganizationID string               `json:"organization_id"`
+	Provider       string               `json:"provider"`
+	EntityType     AccountingEntityType `json:"entity_type"`
+	EntityID       string               `json:"entity_id"`
+	ExternalID     *string              `json:"external_id,omitempty"`
+	Status         string               `json:"status"`
+	Amount         *float64             `json:"amount,omitempty"`
+	Currency       *string              `json:"currency,omitempty"`
+	LastError      *string              `json:"last_error,omitempty"`
+	ExportedAt     *time.Time           `json:"exported_at,omitempty"`
+	LastCheckedAt  *time.Time           `json:"last_checked_at,omitempty"`
+	PaidAt         *time.Time           `json:"paid_at,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// AccountingExportFilter represents filters for listing accounting exports
+type AccountingExportFilter struct {
+	OrganizationID string
+	EntityType     AccountingEntityType
+	EntityID       string
+	Status         string
+	Limit          int
+}
+
+// AccountingSyncResult summarizes a connector run
+type AccountingSyncResult struct {
+	Provider         string        `json:"provider"`
+	ContactsSynced   int           `json:"contacts_synced"`
+	InvoicesExported int           `json:"invoices_exported"`
+	PaymentsUpdated  int           `json:"payments_updated"`
+	Errors           []string      `json:"errors,omitempty"`
+	StartedAt        time.Time     `json:"started_at"`
+	Duration         time.Duration `json:"duration"`
+}
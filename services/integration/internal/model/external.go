@@ -57,6 +57,9 @@ type WeatherForecast struct {
 	Humidity       int       `json:"humidity"`
 	WindSpeed      float64   `json:"wind_speed"`
 	WindDirection  int       `json:"wind_direction"`
+	WindGust       *float64  `json:"wind_gust,omitempty"`   // m/s
+	Visibility     *int      `json:"visibility,omitempty"`  // Meters
+	WaveHeight     *float64  `json:"wave_height,omitempty"` // Meters
 	Precipitation  float64   `json:"precipitation"` // mm
 	Description    string    `json:"description"`
 	Icon           string    `json:"icon"`
@@ -69,9 +72,9 @@ type PortInfo struct {
 	Name      string `json:"name"`
 	Country   string `json:"country"`
 	CountryCode string `json:"country_code"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Timezone  string  `json:"timezone"`
+	Latitude  *float64 `json:"latitude"`  // nil when the source has no coordinates
+	Longitude *float64 `json:"longitude"`
+	Timezone  string   `json:"timezone"`
 
 	// Port characteristics
 	PortType       string   `json:"port_type"` // seaport, river_port, dry_port
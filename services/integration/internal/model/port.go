@@ -0,0 +1,92 @@
+package model
+
+import "time"
+
+// PortSearchFilter represents filters for searching the port registry
+type PortSearchFilter struct {
+	Query       string   // Matches name or UN/LOCODE
+	UNLocode    string   // Exact UN/LOCODE match
+	CountryCode string   // ISO 3166-1 alpha-2
+	Latitude    *float64 // Proximity search center
+	Longitude   *float64
+	RadiusKm    float64 // Proximity search radius, defaults to 50km
+	Limit       int
+	Offset      int
+}
+
+// PortSearchResult is a port registry entry returned from a search
+type PortSearchResult struct {
+	PortInfo
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+}
+
+// UpdatePortInfoRequest represents an admin edit of a port registry entry.
+// Nil fields are left unchanged.
+type UpdatePortInfoRequest struct {
+	Name      *string  `json:"name,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Timezone  *string  `json:"timezone,omitempty"`
+	PortType  *string  `json:"port_type,omitempty"`
+	PortSize  *string  `json:"port_size,omitempty"`
+
+	// Restrictions
+	MaxDraft   *float64 `json:"max_draft,omitempty"`
+	MaxLOA     *float64 `json:"max_loa,omitempty"`
+	TidalRange *float64 `json:"tidal_range,omitempty"`
+
+	// Facilities
+	HasContainerTerminal *bool `json:"has_container_terminal,omitempty"`
+	HasBulkTerminal      *bool `json:"has_bulk_terminal,omitempty"`
+	HasTankerTerminal    *bool `json:"has_tanker_terminal,omitempty"`
+	HasRoRoTerminal      *bool `json:"has_roro_terminal,omitempty"`
+	HasCruiseTerminal    *bool `json:"has_cruise_terminal,omitempty"`
+	HasDrydock           *bool `json:"has_drydock,omitempty"`
+	HasBunkering         *bool `json:"has_bunkering,omitempty"`
+	HasFreshWater        *bool `json:"has_fresh_water,omitempty"`
+	HasProvisions        *bool `json:"has_provisions,omitempty"`
+	HasRepairFacilities  *bool `json:"has_repair_facilities,omitempty"`
+
+	// Services
+	PilotageRequired   *bool `json:"pilotage_required,omitempty"`
+	TugAssistAvailable *bool `json:"tug_assist_available,omitempty"`
+	AnchorageAvailable *bool `json:"anchorage_available,omitempty"`
+	QuarantineRequired *bool `json:"quarantine_required,omitempty"`
+
+	// Contact
+	Website    *string `json:"website,omitempty"`
+	Phone      *string `json:"phone,omitempty"`
+	Email      *string `json:"email,omitempty"`
+	VHFChannel *string `json:"vhf_channel,omitempty"`
+}
+
+// PortImportResult summarizes a UN/LOCODE import run
+type PortImportResult struct {
+	RowsRead  int           `json:"rows_read"`
+	Imported  int           `json:"imported"`
+	Skipped   int           `json:"skipped"`
+	Errors    []string      `json:"errors,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// PortRestrictionCheckRequest describes a vessel to validate against port limits
+type PortRestrictionCheckRequest struct {
+	Draft  *float64 `json:"draft,omitempty"`  // Meters
+	Length *float64 `json:"length,omitempty"` // Meters (LOA)
+}
+
+// PortRestrictionViolation records a vessel dimension exceeding a port limit
+type PortRestrictionViolation struct {
+	Restriction string  `json:"restriction"` // max_draft, max_loa
+	Limit       float64 `json:"limit"`
+	Value       float64 `json:"value"`
+}
+
+// PortRestrictionCheckResult is the outcome of a restriction check
+type PortRestrictionCheckResult struct {
+	PortID     string                     `json:"port_id"`
+	UNLocode   string                     `json:"un_locode"`
+	Allowed    bool                       `json:"allowed"`
+	Violations []PortRestrictionViolation `json:"violations,omitempty"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// WeatherAlertTargetType identifies what a weather alert was raised for
+type WeatherAlertTargetType string
+
+const (
+	WeatherAlertTargetVessel   WeatherAlertTargetType = "vessel"
+	WeatherAlertTargetPortCall WeatherAlertTargetType = "port_call"
+)
+
+// Weather metrics that can breach a threshold
+const (
+	WeatherMetricWindSpeed  = "wind_speed"
+	WeatherMetricWaveHeight = "wave_height"
+	WeatherMetricVisibility = "visibility"
+)
+
+// WeatherThresholds configures the forecast conditions that raise an alert.
+// A zero value disables the corresponding check.
+type WeatherThresholds struct {
+	MaxWindSpeed  float64 `json:"max_wind_speed"`  // m/s, compared against gusts when available
+	MaxWaveHeight float64 `json:"max_wave_height"` // Meters
+	MinVisibility int     `json:"min_visibility"`  // Meters
+}
+
+// WeatherAlertTarget is a location and time window evaluated against forecasts
+type WeatherAlertTarget struct {
+	Type           WeatherAlertTargetType `json:"type"`
+	ID             string                 `json:"id"`
+	Reference      string                 `json:"reference"`
+	Name           string                 `json:"name"`
+	OrganizationID string                 `json:"organization_id"`
+	WorkspaceID    string                 `json:"workspace_id"`
+	Latitude       float64                `json:"latitude"`
+	Longitude      float64                `json:"longitude"`
+	WindowStart    time.Time              `json:"window_start"`
+	WindowEnd      time.Time              `json:"window_end"`
+	NotifyUserIDs  []string               `json:"notify_user_ids,omitempty"`
+}
+
+// ThresholdBreach records a forecast entry exceeding a configured threshold
+type ThresholdBreach struct {
+	Metric    string    `json:"metric"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	At        time.Time `json:"at"`
+}
+
+// OperationalWeatherAlert is a weather alert raised for a vessel or port call
+type OperationalWeatherAlert struct {
+	ID             string                 `json:"id"`
+	TargetType     WeatherAlertTargetType `json:"target_type"`
+	TargetID       string                 `json:"target_id"`
+	Reference      string                 `json:"reference,omitempty"`
+	OrganizationID string                 `json:"organization_id"`
+	WorkspaceID    string                 `json:"workspace_id"`
+	Latitude       float64                `json:"latitude"`
+	Longitude      float64                `json:"longitude"`
+	WeatherAlert
+	Breaches  []ThresholdBreach `json:"breaches"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// WeatherAlertFilter represents filters for listing weather alerts
+type WeatherAlertFilter struct {
+	OrganizationID string
+	TargetType     WeatherAlertTargetType
+	TargetID       string
+	ActiveOnly     bool
+	Limit          int
+}
+
+// WeatherEvaluationResult summarizes a single evaluation run
+type WeatherEvaluationResult struct {
+	TargetsEvaluated int           `json:"targets_evaluated"`
+	AlertsRaised     int           `json:"alerts_raised"`
+	AlertsEscalated  int           `json:"alerts_escalated"`
+	Errors           int           `json:"errors"`
+	StartedAt        time.Time     `json:"started_at"`
+	Duration         time.Duration `json:"duration"`
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+)
+
+// AccountingRepository handles accounting export persistence and source data lookups
+type AccountingRepository struct {
+	db *sql.DB
+}
+
+// NewAccountingRepository creates a new accounting repository
+func NewAccountingRepository(db *sql.DB) *AccountingRepository {
+	return &AccountingRepository{db: db}
+}
+
+// ListOrganizationIDs returns the active operator organizations
+func (r *AccountingRepository) ListOrganizationIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM organizations
+		WHERE status = 'active' AND type = 'operator'
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ListSupplierContacts returns the vendors on an organization's approved vendor
+// list that have not been synced to the provider since they were last updated
+func (r *AccountingRepository) ListSupplierContacts(ctx context.Context, orgID, provider string) ([]model.SupplierContact, error) {
+	query := `
+		SELECT v.id, v.name, COALESCE(v.registration_number, ''),
+			v.address, v.contacts, v.bank_details, COALESCE(e.external_id, '')
+		FROM operator_vendor_lists ovl
+		JOIN vendors v ON v.id = ovl.vendor_id
+		LEFT JOIN accounting_exports e
+			ON e.provider = $2 AND e.entity_type = 'contact' AND e.entity_id = v.id
+		WHERE ovl.operator_org_id = $1
+		  AND ovl.status = 'active'
+		  AND (e.id IS NULL OR e.status = 'failed' OR e.updated_at < v.updated_at)
+		ORDER BY v.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query supplier contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contacts []model.SupplierContact
+	for rows.Next() {
+		var c model.SupplierContact
+		var addressJSON, contactsJSON, bankJSON []byte
+
+		if err := rows.Scan(
+			&c.VendorID,
+			&c.Name,
+			&c.RegistrationNumber,
+			&addressJSON,
+			&contactsJSON,
+			&bankJSON,
+			&c.ExternalID,
+		); err != nil {
+			return nil, err
+		}
+
+		json.Unmarshal(addressJSON, &c.Address)
+
+		var people []struct {
+			Email     string `json:"email"`
+			Phone     string `json:"phone"`
+			IsPrimary bool   `json:"is_primary"`
+		}
+		json.Unmarshal(contactsJSON, &people)
+		for i, p := range people {
+			if p.IsPrimary || i == 0 {
+				c.Email = p.Email
+				c.Phone = p.Phone
+			}
+			if p.IsPrimary {
+				break
+			}
+		}
+
+		var bank struct {
+			BankName      string `json:"bank_name"`
+			AccountName   string `json:"account_name"`
+			AccountNumber string `json:"account_number"`
+			RoutingNumber string `json:"routing_number"`
+		}
+		json.Unmarshal(bankJSON, &bank)
+		c.BankName = bank.BankName
+		c.BankAccountName = bank.AccountName
+		c.BankAccountNumber = bank.AccountNumber
+		c.BankRoutingNumber = bank.RoutingNumber
+
+		contacts = append(contacts, c)
+	}
+
+	return contacts, rows.Err()
+}
+
+// ListPendingInvoices returns completed, priced service orders for an
+// organization that have not been exported to the provider yet. Failed
+// exports are returned again so they are retried.
+func (r *AccountingRepository) ListPendingInvoices(ctx context.Context, orgID, provider string, limit int) ([]model.AccountingInvoice, error) {
+	query := `
+		SELECT so.id, w.organization_id, so.vendor_id, v.name, COALESCE(ce.external_id, ''),
+			COALESCE(so.completed_date, so.updated_at), so.currency,
+			pc.reference, COALESCE(vs.name, ''), COALESCE(p.name, ''),
+			COALESCE(st.name, ''), COALESCE(so.description, ''),
+			COALESCE(so.quantity, 1), COALESCE(so.unit, ''),
+			COALESCE(so.final_price, so.quoted_price)
+		FROM service_orders so
+		JOIN port_calls pc ON pc.id = so.port_call_id
+		JOIN workspaces w ON w.id = pc.workspace_id
+		JOIN vendors v ON v.id = so.vendor_id
+		LEFT JOIN vessels vs ON vs.id = pc.vessel_id
+		LEFT JOIN ports p ON p.id = pc.port_id
+		LEFT JOIN service_types st ON st.id = so.service_type_id
+		LEFT JOIN accounting_exports ce
+			ON ce.provider = $2 AND ce.entity_type = 'contact' AND ce.entity_id = so.vendor_id
+		LEFT JOIN accounting_exports ie
+			ON ie.provider = $2 AND ie.entity_type = 'invoice' AND ie.entity_id = so.id
+		WHERE w.organization_id = $1
+		  AND so.status = 'completed'
+		  AND COALESCE(so.final_price, so.quoted_price) IS NOT NULL
+		  AND (ie.id IS NULL OR ie.status = 'failed')
+		ORDER BY so.completed_date ASC NULLS LAST
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID, provider, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []model.AccountingInvoice
+	for rows.Next() {
+		var inv model.AccountingInvoice
+		var serviceName, description string
+		var line model.AccountingInvoiceLine
+
+		if err := rows.Scan(
+			&inv.ServiceOrderID,
+			&inv.OrganizationID,
+			&inv.Supplier.VendorID,
+			&inv.Supplier.Name,
+			&inv.Supplier.ExternalID,
+			&inv.IssueDate,
+			&inv.Currency,
+			&inv.PortCallReference,
+			&inv.VesselName,
+			&inv.PortName,
+			&serviceName,
+			&description,
+			&line.Quantity,
+			&line.Unit,
+			&line.Amount,
+		); err != nil {
+			return nil, err
+		}
+
+		line.Description = serviceName
+		if description != "" {
+			if line.Description != "" {
+				line.Description += " - "
+			}
+			line.Description += description
+		}
+		inv.Lines = []model.AccountingInvoiceLine{line}
+		inv.Total = line.Amount
+
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// ListOpenInvoices returns exported invoices whose payment status may still
+// change. An empty orgID matches every organization.
+func (r *AccountingRepository) ListOpenInvoices(ctx context.Context, orgID, provider string, limit int) ([]model.AccountingExport, error) {
+	return r.List(ctx, model.AccountingExportFilter{
+		OrganizationID: orgID,
+		EntityType:     model.AccountingEntityInvoice,
+		Status:         "open",
+		Limit:          limit,
+	}, provider)
+}
+
+// UpsertExport records the outcome of pushing an entity to the provider
+func (r *AccountingRepository) UpsertExport(ctx context.Context, export *model.AccountingExport) error {
+	query := `
+		INSERT INTO accounting_exports (
+			id, organization_id, provider, entity_type, entity_id, external_id, status,
+			amount, currency, last_error, exported_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (provider, entity_type, entity_id) DO UPDATE SET
+			external_id = COALESCE(EXCLUDED.external_id, accounting_exports.external_id),
+			status = EXCLUDED.status,
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			last_error = EXCLUDED.last_error,
+			exported_at = COALESCE(EXCLUDED.exported_at, accounting_exports.exported_at),
+			updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		export.ID,
+		export.OrganizationID,
+		export.Provider,
+		export.EntityType,
+		export.EntityID,
+		export.ExternalID,
+		export.Status,
+		export.Amount,
+		export.Currency,
+		export.LastError,
+		export.ExportedAt,
+		export.CreatedAt,
+		export.UpdatedAt,
+	).Scan(&export.ID)
+}
+
+// MarkInvoiceExported stores the accounting reference on the service order
+func (r *AccountingRepository) MarkInvoiceExported(ctx context.Context, serviceOrderID, externalID string, status model.AccountingInvoiceStatus) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE service_orders
+		SET accounting_ref = $1, payment_status = $2, updated_at = $3
+		WHERE id = $4
+	`, externalID, status, time.Now().UTC(), serviceOrderID)
+	return err
+}
+
+// UpdatePaymentStatus records a polled payment status on the export and its service order
+func (r *AccountingRepository) UpdatePaymentStatus(ctx context.Context, export *model.AccountingExport, status *model.InvoicePaymentStatus) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE accounting_exports
+		SET status = $1, paid_at = $2, last_checked_at = $3, last_error = NULL, updated_at = $3
+		WHERE id = $4
+	`, status.Status, status.PaidAt, now, export.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update accounting export: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE service_orders
+		SET payment_status = $1, paid_at = $2, updated_at = $3
+		WHERE id = $4
+	`, status.Status, status.PaidAt, now, export.EntityID)
+	if err != nil {
+		return fmt.Errorf("failed to update service order payment status: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// TouchChecked records a payment status check that produced no change
+func (r *AccountingRepository) TouchChecked(ctx context.Context, exportID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE accounting_exports SET last_checked_at = $1 WHERE id = $2
+	`, time.Now().UTC(), exportID)
+	return err
+}
+
+// List lists accounting exports for a provider. The "open" status matches
+// every invoice status that may still change.
+func (r *AccountingRepository) List(ctx context.Context, filter model.AccountingExportFilter, provider string) ([]model.AccountingExport, error) {
+	conditions := []string{"provider = $1"}
+	args := []interface{}{provider}
+
+	if filter.OrganizationID != "" {
+		args = append(args, filter.OrganizationID)
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)))
+	}
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", len(args)))
+	}
+	if filter.EntityID != "" {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if filter.Status == "open" {
+		conditions = append(conditions, fmt.Sprintf("status IN ('%s', '%s', '%s')",
+			model.AccountingInvoiceSubmitted, model.AccountingInvoiceAuthorised, model.AccountingInvoicePartiallyPaid))
+	} else if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, organization_id, provider, entity_type, entity_id, external_id, status,
+			   amount, currency, last_error, exported_at, last_checked_at, paid_at,
+			   created_at, updated_at
+		FROM accounting_exports
+		WHERE %s
+		ORDER BY last_checked_at ASC NULLS FIRST, created_at ASC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.AccountingExport
+	for rows.Next() {
+		var e model.AccountingExport
+		if err := rows.Scan(
+			&e.ID,
+			&e.OrganizationID,
+			&e.Provider,
+			&e.EntityType,
+			&e.EntityID,
+			&e.ExternalID,
+			&e.Status,
+			&e.Amount,
+			&e.Currency,
+			&e.LastError,
+			&e.ExportedAt,
+			&e.LastCheckedAt,
+			&e.PaidAt,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+
+	return exports, rows.Err()
+}
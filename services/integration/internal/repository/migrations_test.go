@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/navo/pkg/migrate/migratetest"
+	"github.com/navo/services/integration/migrations"
+)
+
+// TestMigrations applies the migrations to the database in TEST_DATABASE_URL
+// and checks this package's queries against the schema they create
+func TestMigrations(t *testing.T) {
+	migratetest.Run(t, migratetest.Options{
+		Service:    "integration",
+		Migrations: migrations.FS,
+		// Accounting adds payment columns to the Prisma service orders table
+		Setup: `CREATE TABLE service_orders (id TEXT PRIMARY KEY)`,
+		External: []string{
+			"organizations",
+			"workspaces",
+			"user_workspaces",
+			"vessels",
+			"vessel_positions",
+			"ports",
+			"port_calls",
+			"service_types",
+			"service_orders",
+			"vendors",
+			"operator_vendor_lists",
+		},
+	})
+}
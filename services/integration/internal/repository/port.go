@@ -0,0 +1,439 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+)
+
+// PortRepository handles port registry persistence
+type PortRepository struct {
+	db *sql.DB
+}
+
+// NewPortRepository creates a new port repository
+func NewPortRepository(db *sql.DB) *PortRepository {
+	return &PortRepository{db: db}
+}
+
+const portInfoColumns = `
+	id, un_locode, name, country, country_code, latitude, longitude, timezone,
+	port_type, port_size, max_draft, max_loa, tidal_range,
+	has_container_terminal, has_bulk_terminal, has_tanker_terminal, has_roro_terminal,
+	has_cruise_terminal, has_drydock, has_bunkering, has_fresh_water, has_provisions,
+	has_repair_facilities, pilotage_required, tug_assist_available, anchorage_available,
+	quarantine_required, website, phone, email, vhf_channel,
+	annual_teus, annual_tonnage, average_wait_time, current_congestion,
+	updated_at, fetched_at`
+
+// UpsertFromUNLocode inserts or refreshes a port from a UN/LOCODE record.
+// Existing ports only have their name, country and coordinates filled in when
+// still empty, so admin edits survive re-imports. The stored port is loaded
+// back into port and registered in the shared ports table so it can be used
+// for port calls.
+func (r *PortRepository) UpsertFromUNLocode(ctx context.Context, port *model.PortInfo) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO port_info (
+			id, un_locode, name, country, country_code, latitude, longitude,
+			timezone, port_type, updated_at, fetched_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (un_locode) DO UPDATE SET
+			name = CASE WHEN port_info.name = '' THEN EXCLUDED.name ELSE port_info.name END,
+			country = CASE WHEN port_info.country = '' THEN EXCLUDED.country ELSE port_info.country END,
+			latitude = CASE WHEN port_info.latitude IS NULL OR port_info.longitude IS NULL
+				THEN EXCLUDED.latitude ELSE port_info.latitude END,
+			longitude = CASE WHEN port_info.latitude IS NULL OR port_info.longitude IS NULL
+				THEN EXCLUDED.longitude ELSE port_info.longitude END,
+			fetched_at = EXCLUDED.fetched_at
+		RETURNING ` + portInfoColumns
+
+	stored, err := scanPortInfo(tx.QueryRowContext(ctx, query,
+		port.ID,
+		port.UNLocode,
+		port.Name,
+		port.Country,
+		port.CountryCode,
+		port.Latitude,
+		port.Longitude,
+		port.Timezone,
+		port.PortType,
+		port.UpdatedAt,
+		port.FetchedAt,
+	), nil)
+	if err != nil {
+		return fmt.Errorf("failed to upsert port info: %w", err)
+	}
+	*port = *stored
+
+	if err := syncCorePort(ctx, tx, port); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves a port by ID
+func (r *PortRepository) GetByID(ctx context.Context, id string) (*model.PortInfo, error) {
+	query := `SELECT ` + portInfoColumns + ` FROM port_info WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+// GetByUNLocode retrieves a port by its UN/LOCODE
+func (r *PortRepository) GetByUNLocode(ctx context.Context, unlocode string) (*model.PortInfo, error) {
+	query := `SELECT ` + portInfoColumns + ` FROM port_info WHERE un_locode = $1`
+	return r.getOne(ctx, query, strings.ToUpper(unlocode))
+}
+
+func (r *PortRepository) getOne(ctx context.Context, query string, arg interface{}) (*model.PortInfo, error) {
+	port, err := scanPortInfo(r.db.QueryRowContext(ctx, query, arg), nil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return port, nil
+}
+
+// Search searches the port registry. When a center point is given, results
+// are limited to the radius and ordered by great-circle distance.
+func (r *PortRepository) Search(ctx context.Context, filter model.PortSearchFilter) ([]model.PortSearchResult, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Query != "" {
+		args = append(args, "%"+strings.ToLower(filter.Query)+"%")
+		conditions = append(conditions, fmt.Sprintf("(LOWER(name) LIKE $%d OR LOWER(un_locode) LIKE $%d)", len(args), len(args)))
+	}
+	if filter.UNLocode != "" {
+		args = append(args, strings.ToUpper(filter.UNLocode))
+		conditions = append(conditions, fmt.Sprintf("un_locode = $%d", len(args)))
+	}
+	if filter.CountryCode != "" {
+		args = append(args, strings.ToUpper(filter.CountryCode))
+		conditions = append(conditions, fmt.Sprintf("country_code = $%d", len(args)))
+	}
+
+	distanceExpr := "NULL::DOUBLE PRECISION"
+	orderBy := "name ASC"
+	if filter.Latitude != nil && filter.Longitude != nil {
+		radius := filter.RadiusKm
+		if radius <= 0 {
+			radius = 50
+		}
+		args = append(args, *filter.Latitude, *filter.Longitude)
+		latArg, lonArg := len(args)-1, len(args)
+
+		// Haversine distance in kilometers
+		distanceExpr = fmt.Sprintf(`(6371 * 2 * ASIN(SQRT(
+			POWER(SIN(RADIANS(latitude - $%d) / 2), 2) +
+			COS(RADIANS($%d)) * COS(RADIANS(latitude)) *
+			POWER(SIN(RADIANS(longitude - $%d) / 2), 2))))`, latArg, latArg, lonArg)
+
+		// Cheap bounding box first so the coordinate index can be used
+		latDelta := radius / 111.0
+		args = append(args, *filter.Latitude-latDelta, *filter.Latitude+latDelta, radius)
+		conditions = append(conditions,
+			fmt.Sprintf("latitude BETWEEN $%d AND $%d", len(args)-2, len(args)-1),
+			fmt.Sprintf("%s <= $%d", distanceExpr, len(args)),
+		)
+		// Ports without coordinates cannot be placed, so they never match
+		conditions = append(conditions, "latitude IS NOT NULL", "longitude IS NOT NULL")
+		orderBy = "distance_km ASC"
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM port_info %s", whereClause)
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count ports: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	args = append(args, limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s AS distance_km
+		FROM port_info
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, portInfoColumns, distanceExpr, whereClause, orderBy, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search ports: %w", err)
+	}
+	defer rows.Close()
+
+	var results []model.PortSearchResult
+	for rows.Next() {
+		var distance sql.NullFloat64
+		port, err := scanPortInfo(rows, &distance)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result := model.PortSearchResult{PortInfo: *port}
+		if distance.Valid {
+			d := distance.Float64
+			result.DistanceKm = &d
+		}
+		results = append(results, result)
+	}
+
+	return results, total, rows.Err()
+}
+
+// Update applies an admin edit to a port
+func (r *PortRepository) Update(ctx context.Context, id string, req model.UpdatePortInfoRequest) (*model.PortInfo, error) {
+	var sets []string
+	var args []interface{}
+
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if req.Name != nil {
+		set("name", *req.Name)
+	}
+	if req.Latitude != nil {
+		set("latitude", *req.Latitude)
+	}
+	if req.Longitude != nil {
+		set("longitude", *req.Longitude)
+	}
+	if req.Timezone != nil {
+		set("timezone", *req.Timezone)
+	}
+	if req.PortType != nil {
+		set("port_type", *req.PortType)
+	}
+	if req.PortSize != nil {
+		set("port_size", *req.PortSize)
+	}
+	if req.MaxDraft != nil {
+		set("max_draft", *req.MaxDraft)
+	}
+	if req.MaxLOA != nil {
+		set("max_loa", *req.MaxLOA)
+	}
+	if req.TidalRange != nil {
+		set("tidal_range", *req.TidalRange)
+	}
+	if req.HasContainerTerminal != nil {
+		set("has_container_terminal", *req.HasContainerTerminal)
+	}
+	if req.HasBulkTerminal != nil {
+		set("has_bulk_terminal", *req.HasBulkTerminal)
+	}
+	if req.HasTankerTerminal != nil {
+		set("has_tanker_terminal", *req.HasTankerTerminal)
+	}
+	if req.HasRoRoTerminal != nil {
+		set("has_roro_terminal", *req.HasRoRoTerminal)
+	}
+	if req.HasCruiseTerminal != nil {
+		set("has_cruise_terminal", *req.HasCruiseTerminal)
+	}
+	if req.HasDrydock != nil {
+		set("has_drydock", *req.HasDrydock)
+	}
+	if req.HasBunkering != nil {
+		set("has_bunkering", *req.HasBunkering)
+	}
+	if req.HasFreshWater != nil {
+		set("has_fresh_water", *req.HasFreshWater)
+	}
+	if req.HasProvisions != nil {
+		set("has_provisions", *req.HasProvisions)
+	}
+	if req.HasRepairFacilities != nil {
+		set("has_repair_facilities", *req.HasRepairFacilities)
+	}
+	if req.PilotageRequired != nil {
+		set("pilotage_required", *req.PilotageRequired)
+	}
+	if req.TugAssistAvailable != nil {
+		set("tug_assist_available", *req.TugAssistAvailable)
+	}
+	if req.AnchorageAvailable != nil {
+		set("anchorage_available", *req.AnchorageAvailable)
+	}
+	if req.QuarantineRequired != nil {
+		set("quarantine_required", *req.QuarantineRequired)
+	}
+	if req.Website != nil {
+		set("website", *req.Website)
+	}
+	if req.Phone != nil {
+		set("phone", *req.Phone)
+	}
+	if req.Email != nil {
+		set("email", *req.Email)
+	}
+	if req.VHFChannel != nil {
+		set("vhf_channel", *req.VHFChannel)
+	}
+
+	if len(sets) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	set("updated_at", time.Now().UTC())
+	args = append(args, id)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE port_info SET %s WHERE id = $%d RETURNING %s`,
+		strings.Join(sets, ", "), len(args), portInfoColumns)
+
+	port, err := scanPortInfo(tx.QueryRowContext(ctx, query, args...), nil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update port info: %w", err)
+	}
+
+	if err := syncCorePort(ctx, tx, port); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return port, nil
+}
+
+// syncCorePort mirrors the registry's identity, location and timezone into
+// the ports table used by port calls. Its coordinates are required, so ports
+// without any are added at 0,0 and existing coordinates are kept.
+func syncCorePort(ctx context.Context, tx *sql.Tx, port *model.PortInfo) error {
+	query := `
+		INSERT INTO ports (id, name, unlocode, country, latitude, longitude, timezone, status)
+		VALUES ($1, $2, $3, $4, COALESCE($5::DOUBLE PRECISION, 0), COALESCE($6::DOUBLE PRECISION, 0), $7, 'active')
+		ON CONFLICT (unlocode) DO UPDATE SET
+			name = EXCLUDED.name,
+			country = EXCLUDED.country,
+			latitude = CASE WHEN $5::DOUBLE PRECISION IS NULL OR $6::DOUBLE PRECISION IS NULL
+				THEN ports.latitude ELSE EXCLUDED.latitude END,
+			longitude = CASE WHEN $5::DOUBLE PRECISION IS NULL OR $6::DOUBLE PRECISION IS NULL
+				THEN ports.longitude ELSE EXCLUDED.longitude END,
+			timezone = EXCLUDED.timezone
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		port.ID,
+		port.Name,
+		port.UNLocode,
+		port.CountryCode,
+		port.Latitude,
+		port.Longitude,
+		port.Timezone,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to sync port: %w", err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPortInfo(row rowScanner, distance *sql.NullFloat64) (*model.PortInfo, error) {
+	p := &model.PortInfo{}
+	var lat, lon, maxDraft, maxLOA, tidalRange sql.NullFloat64
+	var website, phone, email, vhf sql.NullString
+	var teus, tonnage, waitTime sql.NullInt64
+
+	dest := []interface{}{
+		&p.ID, &p.UNLocode, &p.Name, &p.Country, &p.CountryCode,
+		&lat, &lon, &p.Timezone,
+		&p.PortType, &p.PortSize, &maxDraft, &maxLOA, &tidalRange,
+		&p.HasContainerTerminal, &p.HasBulkTerminal, &p.HasTankerTerminal, &p.HasRoRoTerminal,
+		&p.HasCruiseTerminal, &p.HasDrydock, &p.HasBunkering, &p.HasFreshWater, &p.HasProvisions,
+		&p.HasRepairFacilities, &p.PilotageRequired, &p.TugAssistAvailable, &p.AnchorageAvailable,
+		&p.QuarantineRequired, &website, &phone, &email, &vhf,
+		&teus, &tonnage, &waitTime, &p.CurrentCongestion,
+		&p.UpdatedAt, &p.FetchedAt,
+	}
+	if distance != nil {
+		dest = append(dest, distance)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if lat.Valid {
+		p.Latitude = &lat.Float64
+	}
+	if lon.Valid {
+		p.Longitude = &lon.Float64
+	}
+	if maxDraft.Valid {
+		p.MaxDraft = &maxDraft.Float64
+	}
+	if maxLOA.Valid {
+		p.MaxLOA = &maxLOA.Float64
+	}
+	if tidalRange.Valid {
+		p.TidalRange = &tidalRange.Float64
+	}
+	if website.Valid {
+		p.Website = &website.String
+	}
+	if phone.Valid {
+		p.Phone = &phone.String
+	}
+	if email.Valid {
+		p.Email = &email.String
+	}
+	if vhf.Valid {
+		p.VHFChannel = &vhf.String
+	}
+	if teus.Valid {
+		v := int(teus.Int64)
+		p.AnnualTEUs = &v
+	}
+	if tonnage.Valid {
+		v := int(tonnage.Int64)
+		p.AnnualTonnage = &v
+	}
+	if waitTime.Valid {
+		v := int(waitTime.Int64)
+		p.AverageWaitTime = &v
+	}
+
+	return p, nil
+}
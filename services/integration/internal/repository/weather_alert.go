@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/navo/services/integration/internal/model"
+)
+
+// alertLockPrefix namespaces the advisory locks taken per alert target
+const alertLockPrefix = "navo:weather_alert:"
+
+// WeatherAlertRepository handles weather alert persistence and target lookups
+type WeatherAlertRepository struct {
+	db *sql.DB
+}
+
+// NewWeatherAlertRepository creates a new weather alert repository
+func NewWeatherAlertRepository(db *sql.DB) *WeatherAlertRepository {
+	return &WeatherAlertRepository{db: db}
+}
+
+// ListVesselTargets returns active vessels with a position reported since the given time
+func (r *WeatherAlertRepository) ListVesselTargets(ctx context.Context, positionSince time.Time) ([]model.WeatherAlertTarget, error) {
+	query := `
+		SELECT DISTINCT ON (v.id)
+			v.id, v.name, COALESCE(v.imo, ''), v.workspace_id, w.organization_id,
+			p.latitude, p.longitude,
+			ARRAY(SELECT uw.user_id FROM user_workspaces uw WHERE uw.workspace_id = v.workspace_id)
+		FROM vessels v
+		JOIN workspaces w ON w.id = v.workspace_id
+		JOIN vessel_positions p ON p.vessel_id = v.id
+		WHERE v.status = 'active'
+		  AND p.recorded_at >= $1
+		ORDER BY v.id, p.recorded_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, positionSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vessel targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []model.WeatherAlertTarget
+	for rows.Next() {
+		t := model.WeatherAlertTarget{Type: model.WeatherAlertTargetVessel}
+		if err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Reference,
+			&t.WorkspaceID,
+			&t.OrganizationID,
+			&t.Latitude,
+			&t.Longitude,
+			pq.Array(&t.NotifyUserIDs),
+		); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// ListPortCallTargets returns port calls whose ETA-ETD window overlaps [from, to].
+// Port calls without an ETD are assumed to stay 24 hours, and ports the
+// registry has no coordinates for are skipped.
+func (r *WeatherAlertRepository) ListPortCallTargets(ctx context.Context, from, to time.Time) ([]model.WeatherAlertTarget, error) {
+	query := `
+		SELECT pc.id, pc.reference, p.name, pc.workspace_id, w.organization_id,
+			p.latitude, p.longitude,
+			pc.eta, COALESCE(pc.etd, pc.eta + INTERVAL '24 hours'),
+			ARRAY(SELECT uw.user_id FROM user_workspaces uw WHERE uw.workspace_id = pc.workspace_id)
+		FROM port_calls pc
+		JOIN ports p ON p.id = pc.port_id
+		JOIN workspaces w ON w.id = pc.workspace_id
+		WHERE pc.status IN ('planned', 'confirmed', 'arrived', 'alongside')
+		  AND pc.eta IS NOT NULL
+		  AND pc.eta <= $2
+		  AND COALESCE(pc.etd, pc.eta + INTERVAL '24 hours') >= $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM port_info pi
+			WHERE pi.un_locode = p.unlocode AND (pi.latitude IS NULL OR pi.longitude IS NULL)
+		  )
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query port call targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []model.WeatherAlertTarget
+	for rows.Next() {
+		t := model.WeatherAlertTarget{Type: model.WeatherAlertTargetPortCall}
+		if err := rows.Scan(
+			&t.ID,
+			&t.Reference,
+			&t.Name,
+			&t.WorkspaceID,
+			&t.OrganizationID,
+			&t.Latitude,
+			&t.Longitude,
+			&t.WindowStart,
+			&t.WindowEnd,
+			pq.Array(&t.NotifyUserIDs),
+		); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// severityOrder ranks alert severities, from least to most severe, for
+// array_position
+const severityOrder = `ARRAY['minor', 'moderate', 'severe', 'extreme']`
+
+// Create stores a weather alert and reports it created, unless an alert for
+// the same target already covers an overlapping or adjacent window: each
+// forecast run moves a storm's timing and strength a little, so an alert
+// is identified by its target and window, not the forecast it came from.
+// That alert is widened to cover both windows and takes the new forecast's
+// event, description and breaches, keeping the higher severity. escalated
+// reports whether its severity rose, in which case alert is updated to the
+// stored alert's ID and window.
+func (r *WeatherAlertRepository) Create(ctx context.Context, alert *model.OperationalWeatherAlert) (created, escalated bool, err error) {
+	breachesJSON, err := json.Marshal(alert.Breaches)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to marshal breaches: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, false, err
+	}
+	defer tx.Rollback()
+
+	// Serializes evaluators on different replicas checking the same target
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`,
+		alertLockPrefix+string(alert.TargetType)+":"+alert.TargetID,
+	); err != nil {
+		return false, false, fmt.Errorf("failed to lock alert target: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH existing AS (
+			SELECT id, severity FROM weather_alerts
+			WHERE target_type = $1 AND target_id = $2
+			  AND start_at <= $4 AND end_at >= $3
+		)
+		UPDATE weather_alerts a
+		SET start_at = LEAST(a.start_at, $3),
+			end_at = GREATEST(a.end_at, $4),
+			event = $5,
+			description = $6,
+			breaches = $7,
+			severity = CASE
+				WHEN array_position(`+severityOrder+`, $8::TEXT) > array_position(`+severityOrder+`, a.severity)
+				THEN $8 ELSE a.severity END
+		FROM existing e
+		WHERE a.id = e.id
+		RETURNING a.id, a.start_at, a.end_at,
+			COALESCE(array_position(`+severityOrder+`, $8::TEXT) > array_position(`+severityOrder+`, e.severity), false)
+	`, alert.TargetType, alert.TargetID, alert.StartAt, alert.EndAt,
+		alert.Event, alert.Description, breachesJSON, alert.Severity)
+	if err != nil {
+		return false, false, err
+	}
+	widened := false
+	for rows.Next() {
+		var id string
+		var startAt, endAt time.Time
+		var rose bool
+		if err := rows.Scan(&id, &startAt, &endAt, &rose); err != nil {
+			rows.Close()
+			return false, false, err
+		}
+		if !widened || rose {
+			alert.ID, alert.StartAt, alert.EndAt = id, startAt, endAt
+		}
+		widened = true
+		escalated = escalated || rose
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, false, err
+	}
+	if widened {
+		return false, escalated, tx.Commit()
+	}
+
+	query := `
+		INSERT INTO weather_alerts (
+			id, target_type, target_id, reference, organization_id, workspace_id,
+			latitude, longitude, event, sender, description, severity,
+			start_at, end_at, breaches, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		alert.ID,
+		alert.TargetType,
+		alert.TargetID,
+		alert.Reference,
+		alert.OrganizationID,
+		alert.WorkspaceID,
+		alert.Latitude,
+		alert.Longitude,
+		alert.Event,
+		alert.Sender,
+		alert.Description,
+		alert.Severity,
+		alert.StartAt,
+		alert.EndAt,
+		breachesJSON,
+		alert.CreatedAt,
+	)
+	if err != nil {
+		return false, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, false, err
+	}
+	return true, false, nil
+}
+
+// List lists weather alerts matching the filter
+func (r *WeatherAlertRepository) List(ctx context.Context, filter model.WeatherAlertFilter) ([]model.OperationalWeatherAlert, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{filter.OrganizationID}
+
+	if filter.TargetType != "" {
+		args = append(args, filter.TargetType)
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", len(args)))
+	}
+	if filter.TargetID != "" {
+		args = append(args, filter.TargetID)
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", len(args)))
+	}
+	if filter.ActiveOnly {
+		args = append(args, time.Now().UTC())
+		conditions = append(conditions, fmt.Sprintf("end_at >= $%d", len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, target_type, target_id, COALESCE(reference, ''), organization_id, workspace_id,
+			   latitude, longitude, event, sender, description, severity,
+			   start_at, end_at, breaches, created_at
+		FROM weather_alerts
+		WHERE %s
+		ORDER BY start_at DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []model.OperationalWeatherAlert
+	for rows.Next() {
+		var a model.OperationalWeatherAlert
+		var breachesJSON []byte
+
+		err := rows.Scan(
+			&a.ID,
+			&a.TargetType,
+			&a.TargetID,
+			&a.Reference,
+			&a.OrganizationID,
+			&a.WorkspaceID,
+			&a.Latitude,
+			&a.Longitude,
+			&a.Event,
+			&a.Sender,
+			&a.Description,
+			&a.Severity,
+			&a.StartAt,
+			&a.EndAt,
+			&breachesJSON,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		json.Unmarshal(breachesJSON, &a.Breaches)
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
@@ -360,48 +360,3 @@ func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID string
 
 	return deliveries, total, nil
 }
-
-// InitSchema creates the webhook tables if they don't exist
-func (r *WebhookRepository) InitSchema(ctx context.Context) error {
-	schema := `
-		CREATE TABLE IF NOT EXISTS webhooks (
-			id TEXT PRIMARY KEY,
-			organization_id TEXT NOT NULL,
-			workspace_id TEXT,
-			name TEXT NOT NULL,
-			url TEXT NOT NULL,
-			secret TEXT NOT NULL,
-			events JSONB NOT NULL DEFAULT '[]',
-			is_active BOOLEAN NOT NULL DEFAULT true,
-			headers JSONB DEFAULT '{}',
-			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
-			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
-			last_triggered_at TIMESTAMP WITH TIME ZONE,
-			failure_count INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_webhooks_org ON webhooks(organization_id);
-		CREATE INDEX IF NOT EXISTS idx_webhooks_events ON webhooks USING GIN(events);
-
-		CREATE TABLE IF NOT EXISTS webhook_deliveries (
-			id TEXT PRIMARY KEY,
-			webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
-			event_type TEXT NOT NULL,
-			payload JSONB NOT NULL,
-			response_code INTEGER,
-			response_body TEXT,
-			error_message TEXT,
-			attempts INTEGER NOT NULL DEFAULT 0,
-			status TEXT NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
-			delivered_at TIMESTAMP WITH TIME ZONE,
-			next_retry_at TIMESTAMP WITH TIME ZONE
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_deliveries_webhook ON webhook_deliveries(webhook_id);
-		CREATE INDEX IF NOT EXISTS idx_deliveries_status ON webhook_deliveries(status);
-	`
-
-	_, err := r.db.ExecContext(ctx, schema)
-	return err
-}
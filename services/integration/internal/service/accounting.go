@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/repository"
+	"go.uber.org/zap"
+)
+
+// AccountingProvider is implemented by accounting system connectors
+type AccountingProvider interface {
+	// Name identifies the provider in export records
+	Name() string
+
+	// SyncContacts creates or updates suppliers and returns their external
+	// contact IDs keyed by vendor ID
+	SyncContacts(ctx context.Context, contacts []model.SupplierContact) (map[string]string, error)
+
+	// CreateInvoice pushes a payable invoice and returns its external ID
+	CreateInvoice(ctx context.Context, invoice *model.AccountingInvoice) (string, error)
+
+	// GetInvoiceStatus returns the payment status of a previously created invoice
+	GetInvoiceStatus(ctx context.Context, externalID string) (*model.InvoicePaymentStatus, error)
+}
+
+// AccountingService pushes vendors and completed service orders to the
+// accounting system and polls invoice payment status back
+type AccountingService struct {
+	repo     *repository.AccountingRepository
+	provider AccountingProvider
+	logger   *zap.Logger
+	config   AccountingConfig
+}
+
+// AccountingConfig holds accounting connector configuration
+type AccountingConfig struct {
+	SyncInterval     time.Duration
+	PaymentTermsDays int
+	BatchSize        int
+}
+
+// NewAccountingService creates a new accounting service
+func NewAccountingService(
+	repo *repository.AccountingRepository,
+	provider AccountingProvider,
+	logger *zap.Logger,
+	config AccountingConfig,
+) *AccountingService {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.PaymentTermsDays <= 0 {
+		config.PaymentTermsDays = 30
+	}
+
+	return &AccountingService{
+		repo:     repo,
+		provider: provider,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// ProviderName returns the configured provider name
+func (s *AccountingService) ProviderName() string {
+	return s.provider.Name()
+}
+
+// Start runs the connector periodically until the context is cancelled
+func (s *AccountingService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.SyncInterval)
+	defer ticker.Stop()
+
+	s.logger.Info("Accounting connector started",
+		zap.String("provider", s.provider.Name()),
+		zap.Duration("interval", s.config.SyncInterval),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SyncAll(ctx); err != nil {
+				s.logger.Error("Accounting sync failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// SyncAll runs a full sync for every operator organization and polls open invoices
+func (s *AccountingService) SyncAll(ctx context.Context) (*model.AccountingSyncResult, error) {
+	result := &model.AccountingSyncResult{
+		Provider:  s.provider.Name(),
+		StartedAt: time.Now().UTC(),
+	}
+
+	orgIDs, err := s.repo.ListOrganizationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, orgID := range orgIDs {
+		s.syncOrganization(ctx, orgID, result)
+	}
+	s.pollPayments(ctx, "", result)
+
+	result.Duration = time.Since(result.StartedAt)
+	return result, nil
+}
+
+// Sync runs a full sync for a single organization and polls open invoices
+func (s *AccountingService) Sync(ctx context.Context, orgID string) (*model.AccountingSyncResult, error) {
+	result := &model.AccountingSyncResult{
+		Provider:  s.provider.Name(),
+		StartedAt: time.Now().UTC(),
+	}
+
+	s.syncOrganization(ctx, orgID, result)
+	s.pollPayments(ctx, orgID, result)
+
+	result.Duration = time.Since(result.StartedAt)
+	return result, nil
+}
+
+// ListExports lists accounting exports for the configured provider
+func (s *AccountingService) ListExports(ctx context.Context, filter model.AccountingExportFilter) ([]model.AccountingExport, error) {
+	return s.repo.List(ctx, filter, s.provider.Name())
+}
+
+func (s *AccountingService) syncOrganization(ctx context.Context, orgID string, result *model.AccountingSyncResult) {
+	// Contacts go first so new invoices can reference their supplier
+	synced, err := s.syncContacts(ctx, orgID)
+	result.ContactsSynced += synced
+	if err != nil {
+		s.logger.Error("Failed to sync supplier contacts", zap.String("organization_id", orgID), zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("contacts (%s): %v", orgID, err))
+	}
+
+	exported, errs := s.exportInvoices(ctx, orgID)
+	result.InvoicesExported += exported
+	result.Errors = append(result.Errors, errs...)
+}
+
+func (s *AccountingService) syncContacts(ctx context.Context, orgID string) (int, error) {
+	contacts, err := s.repo.ListSupplierContacts(ctx, orgID, s.provider.Name())
+	if err != nil {
+		return 0, err
+	}
+	if len(contacts) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	externalIDs, syncErr := s.provider.SyncContacts(ctx, contacts)
+
+	synced := 0
+	for _, contact := range contacts {
+		export := &model.AccountingExport{
+			ID:             uuid.New().String(),
+			OrganizationID: orgID,
+			Provider:       s.provider.Name(),
+			EntityType:     model.AccountingEntityContact,
+			EntityID:       contact.VendorID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+
+		if externalID, ok := externalIDs[contact.VendorID]; ok && syncErr == nil {
+			export.ExternalID = &externalID
+			export.Status = "synced"
+			export.ExportedAt = &now
+			synced++
+		} else {
+			msg := "contact not returned by provider"
+			if syncErr != nil {
+				msg = syncErr.Error()
+			}
+			export.Status = "failed"
+			export.LastError = &msg
+		}
+
+		if err := s.repo.UpsertExport(ctx, export); err != nil {
+			return synced, fmt.Errorf("failed to record contact export: %w", err)
+		}
+	}
+
+	return synced, syncErr
+}
+
+func (s *AccountingService) exportInvoices(ctx context.Context, orgID string) (int, []string) {
+	invoices, err := s.repo.ListPendingInvoices(ctx, orgID, s.provider.Name(), s.config.BatchSize)
+	if err != nil {
+		s.logger.Error("Failed to list pending invoices", zap.String("organization_id", orgID), zap.Error(err))
+		return 0, []string{fmt.Sprintf("invoices (%s): %v", orgID, err)}
+	}
+
+	exported := 0
+	var errs []string
+	for i := range invoices {
+		invoice := &invoices[i]
+		s.prepareInvoice(invoice)
+
+		if err := s.exportInvoice(ctx, invoice); err != nil {
+			s.logger.Error("Failed to export invoice",
+				zap.String("service_order_id", invoice.ServiceOrderID),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Sprintf("invoice %s: %v", invoice.Number, err))
+			continue
+		}
+		exported++
+	}
+
+	return exported, errs
+}
+
+// prepareInvoice fills in the invoice number, due date and unit prices
+func (s *AccountingService) prepareInvoice(invoice *model.AccountingInvoice) {
+	invoice.Number = fmt.Sprintf("%s-%s", invoice.PortCallReference, invoice.ServiceOrderID)
+	invoice.DueDate = invoice.IssueDate.AddDate(0, 0, s.config.PaymentTermsDays)
+
+	for i := range invoice.Lines {
+		line := &invoice.Lines[i]
+		if line.Quantity <= 0 {
+			line.Quantity = 1
+		}
+		line.UnitPrice = line.Amount / line.Quantity
+	}
+}
+
+func (s *AccountingService) exportInvoice(ctx context.Context, invoice *model.AccountingInvoice) error {
+	now := time.Now().UTC()
+	export := &model.AccountingExport{
+		ID:             uuid.New().String(),
+		OrganizationID: invoice.OrganizationID,
+		Provider:       s.provider.Name(),
+		EntityType:     model.AccountingEntityInvoice,
+		EntityID:       invoice.ServiceOrderID,
+		Amount:         &invoice.Total,
+		Currency:       &invoice.Currency,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	externalID, err := s.provider.CreateInvoice(ctx, invoice)
+	if err != nil {
+		msg := err.Error()
+		export.Status = string(model.AccountingInvoiceFailed)
+		export.LastError = &msg
+		if recordErr := s.repo.UpsertExport(ctx, export); recordErr != nil {
+			s.logger.Error("Failed to record invoice export", zap.Error(recordErr))
+		}
+		return err
+	}
+
+	export.ExternalID = &externalID
+	export.Status = string(model.AccountingInvoiceSubmitted)
+	export.ExportedAt = &now
+	if err := s.repo.UpsertExport(ctx, export); err != nil {
+		return fmt.Errorf("failed to record invoice export: %w", err)
+	}
+
+	return s.repo.MarkInvoiceExported(ctx, invoice.ServiceOrderID, externalID, model.AccountingInvoiceSubmitted)
+}
+
+func (s *AccountingService) pollPayments(ctx context.Context, orgID string, result *model.AccountingSyncResult) {
+	exports, err := s.repo.ListOpenInvoices(ctx, orgID, s.provider.Name(), s.config.BatchSize)
+	if err != nil {
+		s.logger.Error("Failed to list open invoices", zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("payments: %v", err))
+		return
+	}
+
+	for i := range exports {
+		export := &exports[i]
+		if export.ExternalID == nil {
+			continue
+		}
+
+		status, err := s.provider.GetInvoiceStatus(ctx, *export.ExternalID)
+		if err != nil {
+			s.logger.Warn("Failed to get invoice status",
+				zap.String("external_id", *export.ExternalID),
+				zap.Error(err),
+			)
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %s: %v", *export.ExternalID, err))
+			continue
+		}
+
+		if string(status.Status) == export.Status {
+			if err := s.repo.TouchChecked(ctx, export.ID); err != nil {
+				s.logger.Warn("Failed to record payment check", zap.Error(err))
+			}
+			continue
+		}
+
+		if err := s.repo.UpdatePaymentStatus(ctx, export, status); err != nil {
+			s.logger.Error("Failed to update payment status",
+				zap.String("service_order_id", export.EntityID),
+				zap.Error(err),
+			)
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %s: %v", *export.ExternalID, err))
+			continue
+		}
+		result.PaymentsUpdated++
+	}
+}
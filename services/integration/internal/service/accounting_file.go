@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+)
+
+// File export formats
+const (
+	AccountingFormatCSV = "csv"
+	AccountingFormatUBL = "ubl"
+)
+
+// ublUnitCodePattern matches UN/ECE Recommendation 20 unit codes
+var ublUnitCodePattern = regexp.MustCompile(`^[A-Z0-9]{2,3}$`)
+
+// unsafeFileChars matches characters that are not allowed in drop folder file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// FileAccountingProvider exports suppliers and invoices to a drop folder that
+// the accounting system imports from. Payment status is read back from files
+// the accounting system writes to the payments folder:
+//
+//	<dir>/contacts/suppliers-<timestamp>.csv   written by Navo
+//	<dir>/invoices/<number>.csv|.xml           written by Navo
+//	<dir>/payments/<number>.csv                written by the accounting system
+//
+// Payment files have a header row followed by a single row with the columns
+// status, amount_paid, amount_due and paid_at (RFC 3339).
+type FileAccountingProvider struct {
+	dir    string
+	format string
+}
+
+// NewFileAccountingProvider creates a file provider writing CSV or UBL 2.1 XML invoices
+func NewFileAccountingProvider(dir, format string) (*FileAccountingProvider, error) {
+	format = strings.ToLower(format)
+	if format != AccountingFormatCSV && format != AccountingFormatUBL {
+		return nil, fmt.Errorf("unsupported accounting export format %q", format)
+	}
+
+	for _, sub := range []string{"contacts", "invoices", "payments"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create export directory: %w", err)
+		}
+	}
+
+	return &FileAccountingProvider{dir: dir, format: format}, nil
+}
+
+// Name returns the provider name
+func (p *FileAccountingProvider) Name() string {
+	return "file"
+}
+
+// SyncContacts writes the suppliers to a CSV file. Vendor IDs are used as the
+// external contact IDs.
+func (p *FileAccountingProvider) SyncContacts(ctx context.Context, contacts []model.SupplierContact) (map[string]string, error) {
+	name := fmt.Sprintf("suppliers-%s.csv", time.Now().UTC().Format("20060102T150405.000000000"))
+
+	err := p.writeFile(filepath.Join(p.dir, "contacts", name), func(w io.Writer) error {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{
+			"supplier_id", "name", "registration_number", "email", "phone",
+			"street", "city", "state", "postal_code", "country",
+			"bank_name", "bank_account_name", "bank_account_number", "bank_routing_number",
+		})
+		for _, c := range contacts {
+			cw.Write([]string{
+				c.VendorID, c.Name, c.RegistrationNumber, c.Email, c.Phone,
+				c.Address.Street, c.Address.City, c.Address.State, c.Address.PostalCode, c.Address.Country,
+				c.BankName, c.BankAccountName, c.BankAccountNumber, c.BankRoutingNumber,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(contacts))
+	for _, c := range contacts {
+		ids[c.VendorID] = c.VendorID
+	}
+	return ids, nil
+}
+
+// CreateInvoice writes the invoice to the drop folder. The invoice number is
+// used as the external ID.
+func (p *FileAccountingProvider) CreateInvoice(ctx context.Context, invoice *model.AccountingInvoice) (string, error) {
+	base := unsafeFileChars.ReplaceAllString(invoice.Number, "_")
+
+	var err error
+	switch p.format {
+	case AccountingFormatUBL:
+		err = p.writeFile(filepath.Join(p.dir, "invoices", base+".xml"), func(w io.Writer) error {
+			return WriteUBLInvoice(w, invoice)
+		})
+	default:
+		err = p.writeFile(filepath.Join(p.dir, "invoices", base+".csv"), func(w io.Writer) error {
+			return writeCSVInvoice(w, invoice)
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return invoice.Number, nil
+}
+
+// GetInvoiceStatus reads the payment file for an invoice. Invoices without a
+// payment file are reported as submitted.
+func (p *FileAccountingProvider) GetInvoiceStatus(ctx context.Context, externalID string) (*model.InvoicePaymentStatus, error) {
+	base := unsafeFileChars.ReplaceAllString(externalID, "_")
+	f, err := os.Open(filepath.Join(p.dir, "payments", base+".csv"))
+	if os.IsNotExist(err) {
+		return &model.InvoicePaymentStatus{
+			ExternalID: externalID,
+			Status:     model.AccountingInvoiceSubmitted,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payment file: %w", err)
+	}
+	if len(records) < 2 || len(records[1]) < 4 {
+		return nil, fmt.Errorf("payment file for %s is incomplete", externalID)
+	}
+
+	row := records[1]
+	status := &model.InvoicePaymentStatus{
+		ExternalID: externalID,
+		Status:     model.AccountingInvoiceStatus(strings.ToLower(strings.TrimSpace(row[0]))),
+	}
+	status.AmountPaid, _ = strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+	status.AmountDue, _ = strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+	if v := strings.TrimSpace(row[3]); v != "" {
+		paidAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid paid_at %q: %w", v, err)
+		}
+		status.PaidAt = &paidAt
+	}
+
+	return status, nil
+}
+
+// writeFile writes to a temporary file and renames it into place so the
+// accounting system never picks up a partially written file
+func (p *FileAccountingProvider) writeFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func writeCSVInvoice(w io.Writer, invoice *model.AccountingInvoice) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"invoice_number", "supplier_id", "supplier_name", "issue_date", "due_date", "currency",
+		"reference", "vessel", "port", "description", "quantity", "unit", "unit_price", "amount",
+	})
+	for _, line := range invoice.Lines {
+		cw.Write([]string{
+			invoice.Number,
+			invoice.Supplier.VendorID,
+			invoice.Supplier.Name,
+			invoice.IssueDate.Format("2006-01-02"),
+			invoice.DueDate.Format("2006-01-02"),
+			invoice.Currency,
+			invoice.PortCallReference,
+			invoice.VesselName,
+			invoice.PortName,
+			line.Description,
+			formatAmount(line.Quantity),
+			line.Unit,
+			formatAmount(line.UnitPrice),
+			formatAmount(line.Amount),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// UBL 2.1 invoice document, limited to the elements needed for a payable invoice
+type ublInvoice struct {
+	XMLName                 xml.Name         `xml:"Invoice"`
+	Xmlns                   string           `xml:"xmlns,attr"`
+	XmlnsCac                string           `xml:"xmlns:cac,attr"`
+	XmlnsCbc                string           `xml:"xmlns:cbc,attr"`
+	UBLVersionID            string           `xml:"cbc:UBLVersionID"`
+	ID                      string           `xml:"cbc:ID"`
+	IssueDate               string           `xml:"cbc:IssueDate"`
+	DueDate                 string           `xml:"cbc:DueDate"`
+	InvoiceTypeCode         string           `xml:"cbc:InvoiceTypeCode"`
+	Note                    string           `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode    string           `xml:"cbc:DocumentCurrencyCode"`
+	OrderReference          ublReference     `xml:"cac:OrderReference"`
+	AccountingSupplierParty ublParty         `xml:"cac:AccountingSupplierParty"`
+	LegalMonetaryTotal      ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines            []ublInvoiceLine `xml:"cac:InvoiceLine"`
+}
+
+type ublReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublParty struct {
+	Party struct {
+		PartyIdentification ublReference `xml:"cac:PartyIdentification"`
+		PartyName           struct {
+			Name string `xml:"cbc:Name"`
+		} `xml:"cac:PartyName"`
+	} `xml:"cac:Party"`
+}
+
+type ublAmount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+type ublQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr,omitempty"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"`
+	PayableAmount       ublAmount `xml:"cbc:PayableAmount"`
+}
+
+type ublInvoiceLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	InvoicedQuantity    ublQuantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	Item                struct {
+		Name string `xml:"cbc:Name"`
+	} `xml:"cac:Item"`
+	Price struct {
+		PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+	} `xml:"cac:Price"`
+}
+
+// WriteUBLInvoice encodes an accounting invoice as a UBL 2.1 Invoice document
+func WriteUBLInvoice(w io.Writer, invoice *model.AccountingInvoice) error {
+	amount := func(v float64) ublAmount {
+		return ublAmount{Value: formatAmount(v), CurrencyID: invoice.Currency}
+	}
+
+	doc := ublInvoice{
+		Xmlns:                "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		XmlnsCac:             "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		XmlnsCbc:             "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		UBLVersionID:         "2.1",
+		ID:                   invoice.Number,
+		IssueDate:            invoice.IssueDate.Format("2006-01-02"),
+		DueDate:              invoice.DueDate.Format("2006-01-02"),
+		InvoiceTypeCode:      "380", // Commercial invoice
+		DocumentCurrencyCode: invoice.Currency,
+		OrderReference:       ublReference{ID: invoice.ServiceOrderID},
+		LegalMonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount(invoice.Total),
+			PayableAmount:       amount(invoice.Total),
+		},
+	}
+
+	var note []string
+	for _, v := range []string{invoice.PortCallReference, invoice.VesselName, invoice.PortName} {
+		if v != "" {
+			note = append(note, v)
+		}
+	}
+	doc.Note = strings.Join(note, " / ")
+
+	doc.AccountingSupplierParty.Party.PartyIdentification.ID = invoice.Supplier.VendorID
+	doc.AccountingSupplierParty.Party.PartyName.Name = invoice.Supplier.Name
+
+	for i, line := range invoice.Lines {
+		l := ublInvoiceLine{
+			ID:                  strconv.Itoa(i + 1),
+			InvoicedQuantity:    ublQuantity{Value: formatAmount(line.Quantity), UnitCode: ublUnitCode(line.Unit)},
+			LineExtensionAmount: amount(line.Amount),
+		}
+		l.Item.Name = line.Description
+		l.Price.PriceAmount = amount(line.UnitPrice)
+		doc.InvoiceLines = append(doc.InvoiceLines, l)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// ublUnitCode returns the unit if it is already a UN/ECE unit code, otherwise
+// the generic "unit" code C62
+func ublUnitCode(unit string) string {
+	if ublUnitCodePattern.MatchString(unit) {
+		return unit
+	}
+	return "C62"
+}
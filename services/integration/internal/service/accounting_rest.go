@@ -0,0 +1,198 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+)
+
+// RESTAccountingProvider talks to an accounting system over a JSON REST API:
+//
+//	POST {base}/contacts        {"contacts": [...]} -> {"contacts": [{"reference": vendorID, "id": externalID}]}
+//	POST {base}/invoices        invoice             -> {"id": externalID}
+//	GET  {base}/invoices/{id}                       -> {"id", "status", "amount_paid", "amount_due", "paid_at"}
+type RESTAccountingProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewRESTAccountingProvider creates a REST accounting provider
+func NewRESTAccountingProvider(baseURL, apiKey string, timeout time.Duration) *RESTAccountingProvider {
+	return &RESTAccountingProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Name returns the provider name
+func (p *RESTAccountingProvider) Name() string {
+	return "rest"
+}
+
+type restContact struct {
+	Reference          string        `json:"reference"`
+	ID                 string        `json:"id,omitempty"`
+	Name               string        `json:"name"`
+	RegistrationNumber string        `json:"registration_number,omitempty"`
+	Email              string        `json:"email,omitempty"`
+	Phone              string        `json:"phone,omitempty"`
+	Address            model.Address `json:"address"`
+	BankAccount        struct {
+		BankName      string `json:"bank_name,omitempty"`
+		AccountName   string `json:"account_name,omitempty"`
+		AccountNumber string `json:"account_number,omitempty"`
+		RoutingNumber string `json:"routing_number,omitempty"`
+	} `json:"bank_account"`
+	IsSupplier bool `json:"is_supplier"`
+}
+
+// SyncContacts upserts suppliers and returns their external IDs keyed by vendor ID
+func (p *RESTAccountingProvider) SyncContacts(ctx context.Context, contacts []model.SupplierContact) (map[string]string, error) {
+	payload := struct {
+		Contacts []restContact `json:"contacts"`
+	}{}
+
+	for _, c := range contacts {
+		rc := restContact{
+			Reference:          c.VendorID,
+			ID:                 c.ExternalID,
+			Name:               c.Name,
+			RegistrationNumber: c.RegistrationNumber,
+			Email:              c.Email,
+			Phone:              c.Phone,
+			Address:            c.Address,
+			IsSupplier:         true,
+		}
+		rc.BankAccount.BankName = c.BankName
+		rc.BankAccount.AccountName = c.BankAccountName
+		rc.BankAccount.AccountNumber = c.BankAccountNumber
+		rc.BankAccount.RoutingNumber = c.BankRoutingNumber
+		payload.Contacts = append(payload.Contacts, rc)
+	}
+
+	var resp struct {
+		Contacts []struct {
+			Reference string `json:"reference"`
+			ID        string `json:"id"`
+		} `json:"contacts"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/contacts", payload, &resp); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(resp.Contacts))
+	for _, c := range resp.Contacts {
+		if c.Reference != "" && c.ID != "" {
+			ids[c.Reference] = c.ID
+		}
+	}
+	return ids, nil
+}
+
+// CreateInvoice creates a payable invoice (bill) and returns its external ID
+func (p *RESTAccountingProvider) CreateInvoice(ctx context.Context, invoice *model.AccountingInvoice) (string, error) {
+	contact := map[string]string{"reference": invoice.Supplier.VendorID}
+	if invoice.Supplier.ExternalID != "" {
+		contact["id"] = invoice.Supplier.ExternalID
+	}
+
+	payload := map[string]interface{}{
+		"type":       "payable",
+		"number":     invoice.Number,
+		"reference":  invoice.PortCallReference,
+		"contact":    contact,
+		"issue_date": invoice.IssueDate.Format("2006-01-02"),
+		"due_date":   invoice.DueDate.Format("2006-01-02"),
+		"currency":   invoice.Currency,
+		"line_items": invoice.Lines,
+		"total":      invoice.Total,
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/invoices", payload, &resp); err != nil {
+		return "", err
+	}
+	if resp.ID == "" {
+		return "", fmt.Errorf("accounting API returned no invoice ID")
+	}
+
+	return resp.ID, nil
+}
+
+// GetInvoiceStatus returns the payment status of an invoice
+func (p *RESTAccountingProvider) GetInvoiceStatus(ctx context.Context, externalID string) (*model.InvoicePaymentStatus, error) {
+	var resp struct {
+		ID         string     `json:"id"`
+		Status     string     `json:"status"`
+		AmountPaid float64    `json:"amount_paid"`
+		AmountDue  float64    `json:"amount_due"`
+		PaidAt     *time.Time `json:"paid_at"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/invoices/"+url.PathEscape(externalID), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return &model.InvoicePaymentStatus{
+		ExternalID: externalID,
+		Status:     model.AccountingInvoiceStatus(strings.ToLower(resp.Status)),
+		AmountPaid: resp.AmountPaid,
+		AmountDue:  resp.AmountDue,
+		PaidAt:     resp.PaidAt,
+	}, nil
+}
+
+func (p *RESTAccountingProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("accounting API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("accounting API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode accounting API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAccountingAPI is a minimal in-memory accounting system
+type stubAccountingAPI struct {
+	mu       sync.Mutex
+	contacts map[string]string
+	invoices map[string]map[string]interface{}
+	status   map[string]string
+	apiKey   string
+}
+
+func newStubAccountingAPI(apiKey string) *stubAccountingAPI {
+	return &stubAccountingAPI{
+		contacts: make(map[string]string),
+		invoices: make(map[string]map[string]interface{}),
+		status:   make(map[string]string),
+		apiKey:   apiKey,
+	}
+}
+
+func (s *stubAccountingAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/contacts":
+		var req struct {
+			Contacts []struct {
+				Reference string `json:"reference"`
+			} `json:"contacts"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var out []map[string]string
+		for _, c := range req.Contacts {
+			if _, ok := s.contacts[c.Reference]; !ok {
+				s.contacts[c.Reference] = "contact-" + c.Reference
+			}
+			out = append(out, map[string]string{"reference": c.Reference, "id": s.contacts[c.Reference]})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"contacts": out})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/invoices":
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		id := "inv-" + req["number"].(string)
+		s.invoices[id] = req
+		s.status[id] = "authorised"
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+
+	case r.Method == http.MethodGet && len(r.URL.Path) > len("/invoices/"):
+		id := r.URL.Path[len("/invoices/"):]
+		status, ok := s.status[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resp := map[string]interface{}{"id": id, "status": status}
+		if strings.EqualFold(status, "paid") {
+			resp["amount_paid"] = s.invoices[id]["total"]
+			resp["paid_at"] = "2024-03-01T10:00:00Z"
+		}
+		json.NewEncoder(w).Encode(resp)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRESTAccountingProvider_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	stub := newStubAccountingAPI("secret")
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	provider := NewRESTAccountingProvider(server.URL+"/", "secret", 5*time.Second)
+
+	ids, err := provider.SyncContacts(ctx, []model.SupplierContact{
+		{VendorID: "vendor-1", Name: "Harbour Supplies"},
+		{VendorID: "vendor-2", Name: "Bunker Co"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "contact-vendor-1", ids["vendor-1"])
+	assert.Equal(t, "contact-vendor-2", ids["vendor-2"])
+
+	invoice := &model.AccountingInvoice{
+		ServiceOrderID:    "so-1",
+		Number:            "PC-24-0001-so-1",
+		Supplier:          model.SupplierContact{VendorID: "vendor-1", ExternalID: ids["vendor-1"]},
+		IssueDate:         time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:           time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		Currency:          "USD",
+		PortCallReference: "PC-24-0001",
+		Lines: []model.AccountingInvoiceLine{
+			{Description: "Fresh water", Quantity: 100, Unit: "t", UnitPrice: 12.5, Amount: 1250},
+		},
+		Total: 1250,
+	}
+
+	externalID, err := provider.CreateInvoice(ctx, invoice)
+	require.NoError(t, err)
+	assert.Equal(t, "inv-PC-24-0001-so-1", externalID)
+	assert.Equal(t, "payable", stub.invoices[externalID]["type"])
+	assert.Equal(t, "2024-03-02", stub.invoices[externalID]["due_date"])
+
+	status, err := provider.GetInvoiceStatus(ctx, externalID)
+	require.NoError(t, err)
+	assert.Equal(t, model.AccountingInvoiceAuthorised, status.Status)
+	assert.True(t, status.Status.IsOpen())
+	assert.Nil(t, status.PaidAt)
+
+	stub.mu.Lock()
+	stub.status[externalID] = "PAID"
+	stub.mu.Unlock()
+
+	status, err = provider.GetInvoiceStatus(ctx, externalID)
+	require.NoError(t, err)
+	assert.Equal(t, model.AccountingInvoicePaid, status.Status)
+	assert.False(t, status.Status.IsOpen())
+	assert.Equal(t, 1250.0, status.AmountPaid)
+	require.NotNil(t, status.PaidAt)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), status.PaidAt.UTC())
+}
+
+func TestRESTAccountingProvider_Errors(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(newStubAccountingAPI("secret"))
+	defer server.Close()
+
+	provider := NewRESTAccountingProvider(server.URL, "wrong", 5*time.Second)
+	_, err := provider.SyncContacts(ctx, []model.SupplierContact{{VendorID: "vendor-1"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+
+	provider = NewRESTAccountingProvider(server.URL, "secret", 5*time.Second)
+	_, err = provider.GetInvoiceStatus(ctx, "missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/observability"
+)
+
+// systemUserID and systemRole identify the integration service to the core
+// service, which records them as the author of what the service adds
+const (
+	systemUserID = "system"
+	systemRole   = "system"
+)
+
+// CoreClient calls the core service API on behalf of an organization,
+// with the identity headers the gateway would forward, so the core service
+// applies its own tenant isolation
+type CoreClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCoreClient creates a new core service client
+func NewCoreClient(baseURL string) *CoreClient {
+	return &CoreClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: observability.Transport(nil),
+		},
+	}
+}
+
+// TimelineEvent is an event added to a port call's timeline
+type TimelineEvent struct {
+	EventType   string         `json:"event_type"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// AddPortCallTimelineEvent adds an event to a port call's timeline
+func (c *CoreClient) AddPortCallTimelineEvent(ctx context.Context, organizationID, portCallID string, event TimelineEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode timeline event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/port-calls/"+portCallID+"/timeline", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Organization-ID", organizationID)
+	req.Header.Set("X-User-ID", systemUserID)
+	req.Header.Set("X-User-Roles", systemRole)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("core service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("core service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
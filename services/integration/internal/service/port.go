@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/repository"
+	"go.uber.org/zap"
+)
+
+// UN/LOCODE CSV column positions
+const (
+	unlocodeColChange = iota
+	unlocodeColCountry
+	unlocodeColLocation
+	unlocodeColName
+	unlocodeColNameWoDiacritics
+	unlocodeColSubdivision
+	unlocodeColFunction
+	unlocodeColStatus
+	unlocodeColDate
+	unlocodeColIATA
+	unlocodeColCoordinates
+	unlocodeColRemarks
+	unlocodeColumns
+)
+
+// maxImportErrors caps the number of row errors reported back from an import
+const maxImportErrors = 50
+
+// PortService manages the port reference registry
+type PortService struct {
+	repo   *repository.PortRepository
+	logger *zap.Logger
+}
+
+// NewPortService creates a new port service
+func NewPortService(repo *repository.PortRepository, logger *zap.Logger) *PortService {
+	return &PortService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ImportUNLocode imports ports from a UN/LOCODE code list CSV. Only entries
+// with the port function (position 1 of the function classifier) are imported;
+// entries marked for deletion and reference entries are skipped.
+func (s *PortService) ImportUNLocode(ctx context.Context, r io.Reader) (*model.PortImportResult, error) {
+	result := &model.PortImportResult{StartedAt: time.Now().UTC()}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	countries := make(map[string]string)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read UN/LOCODE CSV: %w", err)
+		}
+		result.RowsRead++
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if len(record) < unlocodeColumns-1 {
+			result.Skipped++
+			continue
+		}
+
+		// Country header rows carry the country name, e.g. ",DE,,.GERMANY,..."
+		countryCode := strings.TrimSpace(record[unlocodeColCountry])
+		location := strings.TrimSpace(record[unlocodeColLocation])
+		if location == "" {
+			name := strings.TrimPrefix(strings.TrimSpace(record[unlocodeColNameWoDiacritics]), ".")
+			countries[countryCode] = name
+			result.Skipped++
+			continue
+		}
+
+		port, ok, err := parseUNLocodeRecord(record)
+		if err != nil {
+			result.Skipped++
+			if len(result.Errors) < maxImportErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: %v", result.RowsRead, err))
+			}
+			continue
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		port.Country = countries[port.CountryCode]
+
+		if err := s.repo.UpsertFromUNLocode(ctx, port); err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", port.UNLocode, err)
+		}
+		result.Imported++
+	}
+
+	result.Duration = time.Since(result.StartedAt)
+
+	s.logger.Info("UN/LOCODE import completed",
+		zap.Int("rows", result.RowsRead),
+		zap.Int("imported", result.Imported),
+		zap.Int("skipped", result.Skipped),
+	)
+
+	return result, nil
+}
+
+// parseUNLocodeRecord converts a UN/LOCODE row into a port. It returns false
+// for rows that are not ports or should not be imported.
+func parseUNLocodeRecord(record []string) (*model.PortInfo, bool, error) {
+	change := strings.TrimSpace(record[unlocodeColChange])
+	if change == "X" || change == "=" {
+		return nil, false, nil
+	}
+
+	function := record[unlocodeColFunction]
+	if len(function) == 0 || function[0] != '1' {
+		return nil, false, nil
+	}
+
+	countryCode := strings.ToUpper(strings.TrimSpace(record[unlocodeColCountry]))
+	location := strings.ToUpper(strings.TrimSpace(record[unlocodeColLocation]))
+	if len(countryCode) != 2 || len(location) != 3 {
+		return nil, false, fmt.Errorf("invalid UN/LOCODE %q %q", countryCode, location)
+	}
+
+	// The distribution is published in ISO 8859-1; fall back to the
+	// diacritic-free name when the native name is not valid UTF-8.
+	name := strings.TrimSpace(record[unlocodeColName])
+	if name == "" || !utf8.ValidString(name) {
+		name = strings.TrimSpace(record[unlocodeColNameWoDiacritics])
+	}
+
+	// Many locations are published without coordinates; leave them unset
+	// rather than placing the port at 0,0.
+	var lat, lon *float64
+	if len(record) > unlocodeColCoordinates {
+		if coords := strings.TrimSpace(record[unlocodeColCoordinates]); coords != "" {
+			parsedLat, parsedLon, err := ParseUNLocodeCoordinates(coords)
+			if err != nil {
+				return nil, false, err
+			}
+			lat, lon = &parsedLat, &parsedLon
+		}
+	}
+
+	now := time.Now().UTC()
+	return &model.PortInfo{
+		ID:          uuid.New().String(),
+		UNLocode:    countryCode + location,
+		Name:        name,
+		CountryCode: countryCode,
+		Latitude:    lat,
+		Longitude:   lon,
+		Timezone:    "UTC",
+		PortType:    "seaport",
+		UpdatedAt:   now,
+		FetchedAt:   now,
+	}, true, nil
+}
+
+// ParseUNLocodeCoordinates parses UN/LOCODE coordinates such as "5133N 00014W"
+// (degrees and minutes) into decimal latitude and longitude.
+func ParseUNLocodeCoordinates(coords string) (float64, float64, error) {
+	parts := strings.Fields(coords)
+	if len(parts) != 2 || len(parts[0]) != 5 || len(parts[1]) != 6 {
+		return 0, 0, fmt.Errorf("invalid coordinates %q", coords)
+	}
+
+	lat, err := parseDegreesMinutes(parts[0], 2, 'N', 'S')
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q: %w", parts[0], err)
+	}
+	lon, err := parseDegreesMinutes(parts[1], 3, 'E', 'W')
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q: %w", parts[1], err)
+	}
+
+	return lat, lon, nil
+}
+
+func parseDegreesMinutes(value string, degreeDigits int, positive, negative byte) (float64, error) {
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(value[degreeDigits : degreeDigits+2])
+	if err != nil {
+		return 0, err
+	}
+	if minutes >= 60 {
+		return 0, fmt.Errorf("minutes out of range")
+	}
+
+	decimal := float64(degrees) + float64(minutes)/60
+	switch value[len(value)-1] {
+	case positive:
+		return decimal, nil
+	case negative:
+		return -decimal, nil
+	default:
+		return 0, fmt.Errorf("invalid hemisphere")
+	}
+}
+
+// GetPort retrieves a port by ID
+func (s *PortService) GetPort(ctx context.Context, id string) (*model.PortInfo, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// GetPortByUNLocode retrieves a port by UN/LOCODE
+func (s *PortService) GetPortByUNLocode(ctx context.Context, unlocode string) (*model.PortInfo, error) {
+	return s.repo.GetByUNLocode(ctx, unlocode)
+}
+
+// Search searches the port registry
+func (s *PortService) Search(ctx context.Context, filter model.PortSearchFilter) ([]model.PortSearchResult, int, error) {
+	if (filter.Latitude == nil) != (filter.Longitude == nil) {
+		return nil, 0, fmt.Errorf("both lat and lon are required for proximity search")
+	}
+	return s.repo.Search(ctx, filter)
+}
+
+// UpdatePort applies an admin edit to a port
+func (s *PortService) UpdatePort(ctx context.Context, id string, req model.UpdatePortInfoRequest) (*model.PortInfo, error) {
+	if req.Latitude != nil && (*req.Latitude < -90 || *req.Latitude > 90) {
+		return nil, fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if req.Longitude != nil && (*req.Longitude < -180 || *req.Longitude > 180) {
+		return nil, fmt.Errorf("longitude must be between -180 and 180")
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q", *req.Timezone)
+		}
+	}
+	if req.MaxDraft != nil && *req.MaxDraft <= 0 {
+		return nil, fmt.Errorf("max_draft must be positive")
+	}
+	if req.MaxLOA != nil && *req.MaxLOA <= 0 {
+		return nil, fmt.Errorf("max_loa must be positive")
+	}
+
+	return s.repo.Update(ctx, id, req)
+}
+
+// CheckRestrictions validates vessel dimensions against a port's limits
+func (s *PortService) CheckRestrictions(ctx context.Context, id string, req model.PortRestrictionCheckRequest) (*model.PortRestrictionCheckResult, error) {
+	port, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if port == nil {
+		return nil, nil
+	}
+
+	return CheckPortRestrictions(port, req), nil
+}
+
+// CheckRestrictionsByUNLocode validates vessel dimensions against the limits
+// of the port with a UN/LOCODE
+func (s *PortService) CheckRestrictionsByUNLocode(ctx context.Context, unlocode string, req model.PortRestrictionCheckRequest) (*model.PortRestrictionCheckResult, error) {
+	port, err := s.repo.GetByUNLocode(ctx, unlocode)
+	if err != nil {
+		return nil, err
+	}
+	if port == nil {
+		return nil, nil
+	}
+
+	return CheckPortRestrictions(port, req), nil
+}
+
+// CheckPortRestrictions compares vessel draft and length against the port's
+// maximum draft and LOA. Unknown dimensions or limits are not checked.
+func CheckPortRestrictions(port *model.PortInfo, req model.PortRestrictionCheckRequest) *model.PortRestrictionCheckResult {
+	result := &model.PortRestrictionCheckResult{
+		PortID:   port.ID,
+		UNLocode: port.UNLocode,
+	}
+
+	if port.MaxDraft != nil && req.Draft != nil && *req.Draft > *port.MaxDraft {
+		result.Violations = append(result.Violations, model.PortRestrictionViolation{
+			Restriction: "max_draft",
+			Limit:       *port.MaxDraft,
+			Value:       *req.Draft,
+		})
+	}
+	if port.MaxLOA != nil && req.Length != nil && *req.Length > *port.MaxLOA {
+		result.Violations = append(result.Violations, model.PortRestrictionViolation{
+			Restriction: "max_loa",
+			Limit:       *port.MaxLOA,
+			Value:       *req.Length,
+		})
+	}
+
+	result.Allowed = len(result.Violations) == 0
+	return result
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUNLocodeCoordinates(t *testing.T) {
+	tests := []struct {
+		coords  string
+		lat     float64
+		lon     float64
+		wantErr bool
+	}{
+		{coords: "5133N 00014W", lat: 51 + 33.0/60, lon: -(14.0 / 60)},
+		{coords: "5155N 00430E", lat: 51 + 55.0/60, lon: 4 + 30.0/60},
+		{coords: "3352S 15112E", lat: -(33 + 52.0/60), lon: 151 + 12.0/60},
+		{coords: "0000N 00000E", lat: 0, lon: 0},
+		{coords: " 2233N  11410E ", lat: 22 + 33.0/60, lon: 114 + 10.0/60},
+		{coords: "", wantErr: true},
+		{coords: "5133N", wantErr: true},
+		{coords: "513N 00014W", wantErr: true},
+		{coords: "5133N 0014W", wantErr: true},
+		{coords: "5160N 00014W", wantErr: true},
+		{coords: "5133X 00014W", wantErr: true},
+		{coords: "5133N 00014N", wantErr: true},
+		{coords: "51A3N 00014W", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.coords, func(t *testing.T) {
+			lat, lon, err := ParseUNLocodeCoordinates(tt.coords)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.lat, lat, 1e-9)
+			assert.InDelta(t, tt.lon, lon, 1e-9)
+		})
+	}
+}
+
+func TestParseUNLocodeRecord_Coordinates(t *testing.T) {
+	record := func(coords string) []string {
+		return []string{"", "NL", "RTM", "Rotterdam", "Rotterdam", "ZH", "12345---", "AI", "0701", "", coords, ""}
+	}
+
+	t.Run("with coordinates", func(t *testing.T) {
+		port, ok, err := parseUNLocodeRecord(record("5155N 00430E"))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NotNil(t, port.Latitude)
+		require.NotNil(t, port.Longitude)
+		assert.InDelta(t, 51+55.0/60, *port.Latitude, 1e-9)
+		assert.InDelta(t, 4+30.0/60, *port.Longitude, 1e-9)
+	})
+
+	t.Run("without coordinates", func(t *testing.T) {
+		port, ok, err := parseUNLocodeRecord(record(""))
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "NLRTM", port.UNLocode)
+		assert.Nil(t, port.Latitude)
+		assert.Nil(t, port.Longitude)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/navo/services/integration/internal/model"
@@ -15,6 +16,7 @@ import (
 type WeatherService struct {
 	apiKey     string
 	baseURL    string
+	marineURL  string
 	httpClient *http.Client
 	logger     *zap.Logger
 	cache      map[string]*cachedWeather
@@ -38,6 +40,13 @@ func NewWeatherService(apiKey, baseURL string, logger *zap.Logger) *WeatherServi
 	}
 }
 
+// WithMarineAPI sets the Open-Meteo marine API base URL. OpenWeatherMap has
+// no sea state, so forecasts only get wave heights from there.
+func (s *WeatherService) WithMarineAPI(baseURL string) *WeatherService {
+	s.marineURL = strings.TrimRight(baseURL, "/")
+	return s
+}
+
 // GetWeatherByCoordinates fetches weather for a specific location
 func (s *WeatherService) GetWeatherByCoordinates(ctx context.Context, lat, lon float64) (*model.WeatherData, error) {
 	cacheKey := fmt.Sprintf("%.4f,%.4f", lat, lon)
@@ -136,7 +145,7 @@ func (s *WeatherService) GetForecast(ctx context.Context, lat, lon float64, days
 
 	forecasts := make([]model.WeatherForecast, 0, len(forecastResp.List))
 	for _, item := range forecastResp.List {
-		forecasts = append(forecasts, model.WeatherForecast{
+		entry := model.WeatherForecast{
 			DateTime:      time.Unix(item.Dt, 0),
 			Temperature:   item.Main.Temp,
 			FeelsLike:     item.Main.FeelsLike,
@@ -146,12 +155,96 @@ func (s *WeatherService) GetForecast(ctx context.Context, lat, lon float64, days
 			Precipitation: item.Pop * 100,
 			Description:   item.Weather[0].Description,
 			Icon:          item.Weather[0].Icon,
-		})
+		}
+		if item.Wind.Gust > 0 {
+			gust := item.Wind.Gust
+			entry.WindGust = &gust
+		}
+		if item.Visibility > 0 {
+			visibility := item.Visibility
+			entry.Visibility = &visibility
+		}
+		forecasts = append(forecasts, entry)
 	}
 
+	s.addWaveHeights(ctx, forecasts, lat, lon, days)
+
 	return forecasts, nil
 }
 
+// addWaveHeights sets the wave height of each forecast entry to the highest
+// marine forecast during it. Entries keep no wave height when the marine
+// API has none for the location (e.g. inland) or fails.
+func (s *WeatherService) addWaveHeights(ctx context.Context, forecasts []model.WeatherForecast, lat, lon float64, days int) {
+	if s.marineURL == "" || len(forecasts) == 0 {
+		return
+	}
+
+	waves, err := s.getWaveHeights(ctx, lat, lon, days)
+	if err != nil {
+		s.logger.Warn("Marine forecast unavailable, forecast has no wave heights",
+			zap.Error(err),
+			zap.Float64("lat", lat),
+			zap.Float64("lon", lon),
+		)
+		return
+	}
+	applyWaveHeights(forecasts, waves)
+}
+
+// waveHeight is the forecast wave height at a time
+type waveHeight struct {
+	At     time.Time
+	Height float64 // Meters
+}
+
+// getWaveHeights fetches hourly wave height forecasts from Open-Meteo
+func (s *WeatherService) getWaveHeights(ctx context.Context, lat, lon float64, days int) ([]waveHeight, error) {
+	// One day more, as OpenWeatherMap entries start from now rather than midnight
+	url := fmt.Sprintf("%s/marine?latitude=%.6f&longitude=%.6f&hourly=wave_height&forecast_days=%d&timezone=GMT",
+		s.marineURL, lat, lon, days+1)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("marine API returned status %d", resp.StatusCode)
+	}
+
+	var marineResp openMeteoMarineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&marineResp); err != nil {
+		return nil, fmt.Errorf("failed to decode marine response: %w", err)
+	}
+
+	return marineResp.waveHeights()
+}
+
+// applyWaveHeights sets each forecast entry's wave height to the highest
+// of the wave heights within the forecastStep it covers
+func applyWaveHeights(forecasts []model.WeatherForecast, waves []waveHeight) {
+	for i := range forecasts {
+		start := forecasts[i].DateTime
+		end := start.Add(forecastStep)
+		for _, w := range waves {
+			if w.At.Before(start) || !w.At.Before(end) {
+				continue
+			}
+			if forecasts[i].WaveHeight == nil || w.Height > *forecasts[i].WaveHeight {
+				height := w.Height
+				forecasts[i].WaveHeight = &height
+			}
+		}
+	}
+}
+
 // getMockWeather returns mock weather data for development
 func (s *WeatherService) getMockWeather(lat, lon float64) *model.WeatherData {
 	now := time.Now().UTC()
@@ -182,6 +275,7 @@ func (s *WeatherService) getMockForecast(days int) []model.WeatherForecast {
 	now := time.Now().UTC()
 
 	for i := 0; i < days*8; i++ {
+		waves := 1.0 + 0.5*float64(i%4)
 		forecasts = append(forecasts, model.WeatherForecast{
 			DateTime:      now.Add(time.Duration(i*3) * time.Hour),
 			Temperature:   20.0 + float64(i%10),
@@ -190,6 +284,7 @@ func (s *WeatherService) getMockForecast(days int) []model.WeatherForecast {
 			WindSpeed:     4.0 + float64(i%5),
 			WindDirection: (90 * i) % 360,
 			Precipitation: float64(i % 30),
+			WaveHeight:    &waves,
 			Description:   "Partly cloudy",
 			Icon:          "03d",
 		})
@@ -284,7 +379,34 @@ type openWeatherMapForecastResponse struct {
 		Wind struct {
 			Speed float64 `json:"speed"`
 			Deg   float64 `json:"deg"`
+			Gust  float64 `json:"gust"`
 		} `json:"wind"`
-		Pop float64 `json:"pop"` // Probability of precipitation
+		Visibility int     `json:"visibility"`
+		Pop        float64 `json:"pop"` // Probability of precipitation
 	} `json:"list"`
 }
+
+// openMeteoMarineResponse is the Open-Meteo marine forecast response. Hours
+// without a forecast, such as on land, have null values.
+type openMeteoMarineResponse struct {
+	Hourly struct {
+		Time       []string   `json:"time"`
+		WaveHeight []*float64 `json:"wave_height"`
+	} `json:"hourly"`
+}
+
+// waveHeights returns the hourly wave heights that were forecast
+func (r *openMeteoMarineResponse) waveHeights() ([]waveHeight, error) {
+	waves := make([]waveHeight, 0, len(r.Hourly.Time))
+	for i, t := range r.Hourly.Time {
+		if i >= len(r.Hourly.WaveHeight) || r.Hourly.WaveHeight[i] == nil {
+			continue
+		}
+		at, err := time.Parse("2006-01-02T15:04", t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid marine forecast time %q: %w", t, err)
+		}
+		waves = append(waves, waveHeight{At: at, Height: *r.Hourly.WaveHeight[i]})
+	}
+	return waves, nil
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/realtime"
+	"github.com/navo/services/integration/internal/model"
+	"github.com/navo/services/integration/internal/repository"
+	"go.uber.org/zap"
+)
+
+// forecastStep is the interval covered by a single forecast entry
+const forecastStep = 3 * time.Hour
+
+// notificationTriggerChannel is consumed by the notification service worker
+const notificationTriggerChannel = "navo:notifications:trigger"
+
+// WeatherAlertStore persists weather alerts and finds what to evaluate,
+// implemented by repository.WeatherAlertRepository
+type WeatherAlertStore interface {
+	ListVesselTargets(ctx context.Context, positionSince time.Time) ([]model.WeatherAlertTarget, error)
+	ListPortCallTargets(ctx context.Context, from, to time.Time) ([]model.WeatherAlertTarget, error)
+	Create(ctx context.Context, alert *model.OperationalWeatherAlert) (created, escalated bool, err error)
+	List(ctx context.Context, filter model.WeatherAlertFilter) ([]model.OperationalWeatherAlert, error)
+}
+
+var _ WeatherAlertStore = (*repository.WeatherAlertRepository)(nil)
+
+// WeatherAlertService evaluates forecasts for vessels and port calls and raises alerts
+type WeatherAlertService struct {
+	repo      WeatherAlertStore
+	weather   *WeatherService
+	publisher *realtime.Publisher
+	redis     *redis.Client
+	core      *CoreClient
+	logger    *zap.Logger
+	config    WeatherAlertConfig
+}
+
+// WeatherAlertConfig holds weather alert evaluation configuration
+type WeatherAlertConfig struct {
+	Thresholds     model.WeatherThresholds
+	Interval       time.Duration
+	Lookahead      time.Duration
+	MaxPositionAge time.Duration
+}
+
+// NewWeatherAlertService creates a new weather alert service.
+// redisClient may be nil, in which case alerts are stored but not dispatched.
+func NewWeatherAlertService(
+	repo WeatherAlertStore,
+	weather *WeatherService,
+	redisClient *redis.Client,
+	logger *zap.Logger,
+	config WeatherAlertConfig,
+) *WeatherAlertService {
+	svc := &WeatherAlertService{
+		repo:    repo,
+		weather: weather,
+		redis:   redisClient,
+		logger:  logger,
+		config:  config,
+	}
+	if redisClient != nil {
+		svc.publisher = realtime.NewPublisher(redisClient)
+	}
+	return svc
+}
+
+// WithCore sets the core service client used to add timeline notes to
+// port calls. Without it, port call alerts get no timeline notes.
+func (s *WeatherAlertService) WithCore(core *CoreClient) *WeatherAlertService {
+	s.core = core
+	return s
+}
+
+// Start runs the evaluator periodically until the context is cancelled
+func (s *WeatherAlertService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	s.logger.Info("Weather alert evaluator started", zap.Duration("interval", s.config.Interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			evalCtx, span := observability.StartSpan(ctx, "weather alerts evaluate")
+			if _, err := s.Evaluate(evalCtx); err != nil {
+				observability.RecordError(evalCtx, err)
+				s.logger.Error("Weather alert evaluation failed", zap.Error(err))
+			}
+			span.End()
+		}
+	}
+}
+
+// Evaluate checks every active vessel and upcoming port call against the forecast
+func (s *WeatherAlertService) Evaluate(ctx context.Context) (*model.WeatherEvaluationResult, error) {
+	now := time.Now().UTC()
+	horizon := now.Add(s.config.Lookahead)
+	result := &model.WeatherEvaluationResult{StartedAt: now}
+
+	vessels, err := s.repo.ListVesselTargets(ctx, now.Add(-s.config.MaxPositionAge))
+	if err != nil {
+		return nil, err
+	}
+
+	portCalls, err := s.repo.ListPortCallTargets(ctx, now, horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]model.WeatherAlertTarget, 0, len(vessels)+len(portCalls))
+	for _, t := range vessels {
+		t.WindowStart = now
+		t.WindowEnd = horizon
+		targets = append(targets, t)
+	}
+	for _, t := range portCalls {
+		if t.WindowStart.Before(now) {
+			t.WindowStart = now
+		}
+		if t.WindowEnd.After(horizon) {
+			t.WindowEnd = horizon
+		}
+		targets = append(targets, t)
+	}
+
+	for _, target := range targets {
+		result.TargetsEvaluated++
+
+		outcome, err := s.evaluateTarget(ctx, target, now)
+		if err != nil {
+			result.Errors++
+			s.logger.Warn("Failed to evaluate weather for target",
+				zap.String("target_type", string(target.Type)),
+				zap.String("target_id", target.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		switch outcome {
+		case alertRaised:
+			result.AlertsRaised++
+		case alertEscalated:
+			result.AlertsEscalated++
+		}
+	}
+
+	result.Duration = time.Since(now)
+
+	s.logger.Info("Weather alert evaluation completed",
+		zap.Int("targets", result.TargetsEvaluated),
+		zap.Int("alerts_raised", result.AlertsRaised),
+		zap.Int("alerts_escalated", result.AlertsEscalated),
+		zap.Int("errors", result.Errors),
+		zap.Duration("duration", result.Duration),
+	)
+
+	return result, nil
+}
+
+// ListAlerts lists weather alerts for an organization
+func (s *WeatherAlertService) ListAlerts(ctx context.Context, filter model.WeatherAlertFilter) ([]model.OperationalWeatherAlert, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Thresholds returns the configured alert thresholds
+func (s *WeatherAlertService) Thresholds() model.WeatherThresholds {
+	return s.config.Thresholds
+}
+
+// alertOutcome is what evaluating a target did
+type alertOutcome int
+
+const (
+	alertNone alertOutcome = iota
+	alertRaised
+	alertEscalated
+)
+
+// evaluateTarget checks a single target and dispatches an alert if thresholds
+// are exceeded, or if an alert already raised for the window got more severe
+func (s *WeatherAlertService) evaluateTarget(ctx context.Context, target model.WeatherAlertTarget, now time.Time) (alertOutcome, error) {
+	if !target.WindowEnd.After(target.WindowStart) {
+		return alertNone, nil
+	}
+
+	days := int(math.Ceil(target.WindowEnd.Sub(now).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	if days > 5 {
+		days = 5
+	}
+
+	forecast, err := s.weather.GetForecast(ctx, target.Latitude, target.Longitude, days)
+	if err != nil {
+		return alertNone, fmt.Errorf("failed to get forecast: %w", err)
+	}
+
+	breaches := EvaluateForecast(forecast, s.config.Thresholds, target.WindowStart, target.WindowEnd)
+	if len(breaches) == 0 {
+		return alertNone, nil
+	}
+
+	alert := buildWeatherAlert(target, breaches, now)
+
+	created, escalated, err := s.repo.Create(ctx, alert)
+	if err != nil {
+		return alertNone, fmt.Errorf("failed to save weather alert: %w", err)
+	}
+	switch {
+	case created:
+		s.dispatch(ctx, target, alert, false)
+		return alertRaised, nil
+	case escalated:
+		s.dispatch(ctx, target, alert, true)
+		return alertEscalated, nil
+	default:
+		// Already raised for an overlapping window, at least as severe
+		return alertNone, nil
+	}
+}
+
+// dispatch fans a new or escalated alert out as realtime event,
+// notifications and timeline note
+func (s *WeatherAlertService) dispatch(ctx context.Context, target model.WeatherAlertTarget, alert *model.OperationalWeatherAlert, escalated bool) {
+	title := "Weather alert"
+	if escalated {
+		title = "Weather alert escalated to " + alert.Severity
+	}
+
+	if s.publisher != nil {
+		if err := s.publisher.PublishWeatherAlert(ctx, alert,
+			string(target.Type), target.ID, target.OrganizationID, target.WorkspaceID,
+		); err != nil {
+			s.logger.Warn("Failed to publish weather alert event", zap.String("alert_id", alert.ID), zap.Error(err))
+		}
+	}
+
+	if s.redis != nil {
+		for _, userID := range target.NotifyUserIDs {
+			if err := s.sendNotification(ctx, userID, title, target, alert); err != nil {
+				s.logger.Warn("Failed to trigger weather alert notification",
+					zap.String("alert_id", alert.ID),
+					zap.String("user_id", userID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	if target.Type == model.WeatherAlertTargetPortCall && s.core != nil {
+		err := s.core.AddPortCallTimelineEvent(ctx, target.OrganizationID, target.ID, TimelineEvent{
+			EventType:   "weather_alert",
+			Title:       title + ": " + alert.Event,
+			Description: alert.Description + " Berthing may be delayed.",
+			Metadata: map[string]any{
+				"weather_alert_id": alert.ID,
+				"severity":         alert.Severity,
+				"start_at":         alert.StartAt,
+				"end_at":           alert.EndAt,
+				"breaches":         alert.Breaches,
+			},
+		})
+		if err != nil {
+			s.logger.Warn("Failed to add weather timeline note",
+				zap.String("port_call_id", target.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// sendNotification asks the notification service to notify a user about an alert
+func (s *WeatherAlertService) sendNotification(ctx context.Context, userID, title string, target model.WeatherAlertTarget, alert *model.OperationalWeatherAlert) error {
+	priority := "high"
+	if alert.Severity == "extreme" {
+		priority = "critical"
+	}
+
+	traceParent, traceState := observability.TraceFields(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":        "notification:send",
+		"traceparent": traceParent,
+		"tracestate":  traceState,
+		"data": map[string]interface{}{
+			"type":        "in_app",
+			"category":    string(target.Type),
+			"priority":    priority,
+			"user_id":     userID,
+			"title":       fmt.Sprintf("%s for %s: %s", title, target.Name, alert.Event),
+			"body":        alert.Description,
+			"entity_type": string(target.Type),
+			"entity_id":   target.ID,
+			"metadata": map[string]string{
+				"weather_alert_id": alert.ID,
+				"severity":         alert.Severity,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Publish(ctx, notificationTriggerChannel, payload).Err()
+}
+
+// EvaluateForecast returns every forecast value within [from, to] that exceeds the thresholds
+func EvaluateForecast(forecast []model.WeatherForecast, thresholds model.WeatherThresholds, from, to time.Time) []model.ThresholdBreach {
+	var breaches []model.ThresholdBreach
+
+	for _, entry := range forecast {
+		// An entry covers the forecastStep following its timestamp
+		if !entry.DateTime.Add(forecastStep).After(from) || entry.DateTime.After(to) {
+			continue
+		}
+
+		if thresholds.MaxWindSpeed > 0 {
+			wind := entry.WindSpeed
+			if entry.WindGust != nil && *entry.WindGust > wind {
+				wind = *entry.WindGust
+			}
+			if wind > thresholds.MaxWindSpeed {
+				breaches = append(breaches, model.ThresholdBreach{
+					Metric:    model.WeatherMetricWindSpeed,
+					Threshold: thresholds.MaxWindSpeed,
+					Value:     wind,
+					At:        entry.DateTime,
+				})
+			}
+		}
+
+		if thresholds.MaxWaveHeight > 0 && entry.WaveHeight != nil && *entry.WaveHeight > thresholds.MaxWaveHeight {
+			breaches = append(breaches, model.ThresholdBreach{
+				Metric:    model.WeatherMetricWaveHeight,
+				Threshold: thresholds.MaxWaveHeight,
+				Value:     *entry.WaveHeight,
+				At:        entry.DateTime,
+			})
+		}
+
+		if thresholds.MinVisibility > 0 && entry.Visibility != nil && *entry.Visibility < thresholds.MinVisibility {
+			breaches = append(breaches, model.ThresholdBreach{
+				Metric:    model.WeatherMetricVisibility,
+				Threshold: float64(thresholds.MinVisibility),
+				Value:     float64(*entry.Visibility),
+				At:        entry.DateTime,
+			})
+		}
+	}
+
+	return breaches
+}
+
+// buildWeatherAlert summarizes breaches into a single alert for the target
+func buildWeatherAlert(target model.WeatherAlertTarget, breaches []model.ThresholdBreach, now time.Time) *model.OperationalWeatherAlert {
+	worst := make(map[string]model.ThresholdBreach)
+	startAt := breaches[0].At
+	endAt := breaches[0].At
+	maxRatio := 0.0
+
+	for _, b := range breaches {
+		if b.At.Before(startAt) {
+			startAt = b.At
+		}
+		if b.At.After(endAt) {
+			endAt = b.At
+		}
+
+		ratio := breachRatio(b)
+		if ratio > maxRatio {
+			maxRatio = ratio
+		}
+		if current, ok := worst[b.Metric]; !ok || ratio > breachRatio(current) {
+			worst[b.Metric] = b
+		}
+	}
+
+	var events, details []string
+	for _, metric := range []string{model.WeatherMetricWindSpeed, model.WeatherMetricWaveHeight, model.WeatherMetricVisibility} {
+		b, ok := worst[metric]
+		if !ok {
+			continue
+		}
+		switch metric {
+		case model.WeatherMetricWindSpeed:
+			events = append(events, "High wind")
+			details = append(details, fmt.Sprintf("wind up to %.1f m/s (limit %.1f m/s)", b.Value, b.Threshold))
+		case model.WeatherMetricWaveHeight:
+			events = append(events, "High seas")
+			details = append(details, fmt.Sprintf("waves up to %.1f m (limit %.1f m)", b.Value, b.Threshold))
+		case model.WeatherMetricVisibility:
+			events = append(events, "Restricted visibility")
+			details = append(details, fmt.Sprintf("visibility down to %.0f m (limit %.0f m)", b.Value, b.Threshold))
+		}
+	}
+
+	endAt = endAt.Add(forecastStep)
+	description := fmt.Sprintf("Forecast %s at %s between %s and %s UTC.",
+		strings.Join(details, ", "),
+		target.Name,
+		startAt.UTC().Format("02 Jan 15:04"),
+		endAt.UTC().Format("02 Jan 15:04"),
+	)
+
+	return &model.OperationalWeatherAlert{
+		ID:             uuid.New().String(),
+		TargetType:     target.Type,
+		TargetID:       target.ID,
+		Reference:      target.Reference,
+		OrganizationID: target.OrganizationID,
+		WorkspaceID:    target.WorkspaceID,
+		Latitude:       target.Latitude,
+		Longitude:      target.Longitude,
+		WeatherAlert: model.WeatherAlert{
+			Event:       strings.Join(events, ", "),
+			Sender:      "navo-weather-routing",
+			Description: description,
+			Severity:    severityForRatio(maxRatio),
+			StartAt:     startAt.UTC(),
+			EndAt:       endAt.UTC(),
+		},
+		Breaches:  breaches,
+		CreatedAt: now,
+	}
+}
+
+// breachRatio expresses how far a value is past its threshold (1.0 = at the limit)
+func breachRatio(b model.ThresholdBreach) float64 {
+	if b.Metric == model.WeatherMetricVisibility {
+		if b.Value <= 0 {
+			return math.Inf(1)
+		}
+		return b.Threshold / b.Value
+	}
+	if b.Threshold <= 0 {
+		return 1
+	}
+	return b.Value / b.Threshold
+}
+
+// severityForRatio maps a breach ratio onto WeatherAlert severities
+func severityForRatio(ratio float64) string {
+	switch {
+	case ratio >= 2:
+		return "extreme"
+	case ratio >= 1.5:
+		return "severe"
+	case ratio >= 1.2:
+		return "moderate"
+	default:
+		return "minor"
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/navo/services/integration/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var forecastStart = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns the time of the nth forecast step
+func at(step int) time.Time {
+	return forecastStart.Add(time.Duration(step) * forecastStep)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEvaluateForecast(t *testing.T) {
+	thresholds := model.WeatherThresholds{MaxWindSpeed: 17.2, MaxWaveHeight: 3.5, MinVisibility: 1000}
+
+	tests := []struct {
+		name       string
+		entries    []model.WeatherForecast
+		thresholds *model.WeatherThresholds
+		from, to   time.Time
+		breaches   []model.ThresholdBreach
+	}{
+		{
+			name:    "calm",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 8, WaveHeight: ptr(1.5), Visibility: ptr(10000)}},
+		},
+		{
+			name:    "wind",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 20}},
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 20, At: at(0)},
+			},
+		},
+		{
+			name:    "gusts count",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 15, WindGust: ptr(22.0)}},
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 22, At: at(0)},
+			},
+		},
+		{
+			name:    "gusts below the limit",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 10, WindGust: ptr(12.0)}},
+		},
+		{
+			name:    "waves",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 8, WaveHeight: ptr(4.0)}},
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWaveHeight, Threshold: 3.5, Value: 4, At: at(0)},
+			},
+		},
+		{
+			name:    "visibility",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 8, Visibility: ptr(400)}},
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricVisibility, Threshold: 1000, Value: 400, At: at(0)},
+			},
+		},
+		{
+			name:    "every metric of an entry",
+			entries: []model.WeatherForecast{{DateTime: at(0), WindSpeed: 25, WaveHeight: ptr(6.0), Visibility: ptr(200)}},
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 25, At: at(0)},
+				{Metric: model.WeatherMetricWaveHeight, Threshold: 3.5, Value: 6, At: at(0)},
+				{Metric: model.WeatherMetricVisibility, Threshold: 1000, Value: 200, At: at(0)},
+			},
+		},
+		{
+			name:       "disabled thresholds",
+			entries:    []model.WeatherForecast{{DateTime: at(0), WindSpeed: 25, WaveHeight: ptr(6.0), Visibility: ptr(200)}},
+			thresholds: &model.WeatherThresholds{},
+		},
+		{
+			name: "only entries covering the window",
+			entries: []model.WeatherForecast{
+				{DateTime: at(0), WindSpeed: 20}, // Ends as the window starts
+				{DateTime: at(1), WindSpeed: 21}, // Covers the window's start
+				{DateTime: at(2), WindSpeed: 22},
+				{DateTime: at(3), WindSpeed: 23}, // Starts as the window ends
+				{DateTime: at(4), WindSpeed: 24},
+			},
+			from: at(1).Add(time.Hour),
+			to:   at(3),
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 21, At: at(1)},
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 22, At: at(2)},
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 23, At: at(3)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := thresholds
+			if tt.thresholds != nil {
+				th = *tt.thresholds
+			}
+			from, to := tt.from, tt.to
+			if from.IsZero() {
+				from, to = at(0), at(8)
+			}
+			assert.Equal(t, tt.breaches, EvaluateForecast(tt.entries, th, from, to))
+		})
+	}
+}
+
+func TestBuildWeatherAlert(t *testing.T) {
+	target := model.WeatherAlertTarget{
+		Type:           model.WeatherAlertTargetPortCall,
+		ID:             "pc-1",
+		Reference:      "PC-2026-0001",
+		Name:           "Singapore",
+		OrganizationID: "org-1",
+		WorkspaceID:    "ws-1",
+		Latitude:       1.26,
+		Longitude:      103.84,
+	}
+	now := at(0)
+
+	tests := []struct {
+		name        string
+		breaches    []model.ThresholdBreach
+		event       string
+		description string
+		severity    string
+		start, end  time.Time
+	}{
+		{
+			name: "single breach",
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 18, At: at(2)},
+			},
+			event:       "High wind",
+			description: "Forecast wind up to 18.0 m/s (limit 17.2 m/s) at Singapore between 01 Mar 06:00 and 01 Mar 09:00 UTC.",
+			severity:    "minor",
+			start:       at(2),
+			end:         at(3),
+		},
+		{
+			name: "worst value per metric, in metric order",
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricWaveHeight, Threshold: 3.5, Value: 5, At: at(1)},
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 20, At: at(2)},
+				{Metric: model.WeatherMetricWindSpeed, Threshold: 17.2, Value: 25, At: at(3)},
+				{Metric: model.WeatherMetricWaveHeight, Threshold: 3.5, Value: 4, At: at(3)},
+			},
+			event:       "High wind, High seas",
+			description: "Forecast wind up to 25.0 m/s (limit 17.2 m/s), waves up to 5.0 m (limit 3.5 m) at Singapore between 01 Mar 03:00 and 01 Mar 12:00 UTC.",
+			severity:    "moderate",
+			start:       at(1),
+			end:         at(4),
+		},
+		{
+			name: "visibility",
+			breaches: []model.ThresholdBreach{
+				{Metric: model.WeatherMetricVisibility, Threshold: 1000, Value: 400, At: at(5)},
+			},
+			event:       "Restricted visibility",
+			description: "Forecast visibility down to 400 m (limit 1000 m) at Singapore between 01 Mar 15:00 and 01 Mar 18:00 UTC.",
+			severity:    "extreme",
+			start:       at(5),
+			end:         at(6),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := buildWeatherAlert(target, tt.breaches, now)
+
+			assert.NotEmpty(t, alert.ID)
+			assert.Equal(t, target.Type, alert.TargetType)
+			assert.Equal(t, target.ID, alert.TargetID)
+			assert.Equal(t, target.Reference, alert.Reference)
+			assert.Equal(t, target.OrganizationID, alert.OrganizationID)
+			assert.Equal(t, target.WorkspaceID, alert.WorkspaceID)
+			assert.Equal(t, tt.event, alert.Event)
+			assert.Equal(t, tt.description, alert.Description)
+			assert.Equal(t, tt.severity, alert.Severity)
+			assert.Equal(t, tt.start, alert.StartAt)
+			assert.Equal(t, tt.end, alert.EndAt)
+			assert.Equal(t, tt.breaches, alert.Breaches)
+			assert.Equal(t, now, alert.CreatedAt)
+		})
+	}
+}
+
+func TestBreachRatio(t *testing.T) {
+	assert.InDelta(t, 1.25, breachRatio(model.ThresholdBreach{Metric: model.WeatherMetricWindSpeed, Threshold: 20, Value: 25}), 1e-9)
+	assert.InDelta(t, 2.5, breachRatio(model.ThresholdBreach{Metric: model.WeatherMetricVisibility, Threshold: 1000, Value: 400}), 1e-9)
+	assert.True(t, math.IsInf(breachRatio(model.ThresholdBreach{Metric: model.WeatherMetricVisibility, Threshold: 1000, Value: 0}), 1))
+}
+
+func TestSeverityForRatio(t *testing.T) {
+	tests := []struct {
+		ratio    float64
+		severity string
+	}{
+		{1.0, "minor"},
+		{1.19, "minor"},
+		{1.2, "moderate"},
+		{1.49, "moderate"},
+		{1.5, "severe"},
+		{1.99, "severe"},
+		{2, "extreme"},
+		{math.Inf(1), "extreme"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.severity, severityForRatio(tt.ratio), "ratio %v", tt.ratio)
+	}
+}
+
+// fakeAlertStore is a WeatherAlertStore answering Create with a fixed outcome
+type fakeAlertStore struct {
+	created, escalated bool
+	saved              []*model.OperationalWeatherAlert
+}
+
+func (s *fakeAlertStore) ListVesselTargets(ctx context.Context, positionSince time.Time) ([]model.WeatherAlertTarget, error) {
+	return nil, nil
+}
+
+func (s *fakeAlertStore) ListPortCallTargets(ctx context.Context, from, to time.Time) ([]model.WeatherAlertTarget, error) {
+	return nil, nil
+}
+
+func (s *fakeAlertStore) Create(ctx context.Context, alert *model.OperationalWeatherAlert) (bool, bool, error) {
+	s.saved = append(s.saved, alert)
+	return s.created, s.escalated, nil
+}
+
+func (s *fakeAlertStore) List(ctx context.Context, filter model.WeatherAlertFilter) ([]model.OperationalWeatherAlert, error) {
+	return nil, nil
+}
+
+func TestWeatherAlertService_EvaluateTargetDispatch(t *testing.T) {
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"list": [
+			{"dt": 1772323200, "main": {"temp": 27}, "weather": [{"description": "storm"}], "wind": {"speed": 32}}
+		]}`))
+	}))
+	defer weather.Close()
+
+	var timeline []TimelineEvent
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event TimelineEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		timeline = append(timeline, event)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer core.Close()
+
+	target := model.WeatherAlertTarget{
+		Type:           model.WeatherAlertTargetPortCall,
+		ID:             "pc-1",
+		Name:           "Singapore",
+		OrganizationID: "org-1",
+		WindowStart:    at(0),
+		WindowEnd:      at(2),
+	}
+
+	tests := []struct {
+		name               string
+		created, escalated bool
+		outcome            alertOutcome
+		title              string
+	}{
+		{"new alert", true, false, alertRaised, "Weather alert: High wind"},
+		{"escalated alert", false, true, alertEscalated, "Weather alert escalated to severe: High wind"},
+		{"already raised", false, false, alertNone, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeline = nil
+			store := &fakeAlertStore{created: tt.created, escalated: tt.escalated}
+			svc := NewWeatherAlertService(
+				store,
+				NewWeatherService("key", weather.URL, zap.NewNop()),
+				nil,
+				zap.NewNop(),
+				WeatherAlertConfig{Thresholds: model.WeatherThresholds{MaxWindSpeed: 17.2}},
+			).WithCore(NewCoreClient(core.URL))
+
+			outcome, err := svc.evaluateTarget(context.Background(), target, at(0))
+			require.NoError(t, err)
+			assert.Equal(t, tt.outcome, outcome)
+			require.Len(t, store.saved, 1)
+			assert.Equal(t, "severe", store.saved[0].Severity)
+
+			if tt.title == "" {
+				assert.Empty(t, timeline)
+				return
+			}
+			require.Len(t, timeline, 1)
+			assert.Equal(t, tt.title, timeline[0].Title)
+			assert.Equal(t, "severe", timeline[0].Metadata["severity"])
+		})
+	}
+}
+
+func TestWeatherService_GetForecastWaveHeights(t *testing.T) {
+	marineStatus := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forecast":
+			w.Write([]byte(`{"list": [
+				{"dt": 1772323200, "main": {"temp": 27}, "weather": [{"description": "rain"}], "wind": {"speed": 12}},
+				{"dt": 1772334000, "main": {"temp": 26}, "weather": [{"description": "rain"}], "wind": {"speed": 14}},
+				{"dt": 1772344800, "main": {"temp": 26}, "weather": [{"description": "rain"}], "wind": {"speed": 15}}
+			]}`))
+		case "/marine/marine":
+			assert.Equal(t, "wave_height", r.URL.Query().Get("hourly"))
+			assert.Equal(t, "2", r.URL.Query().Get("forecast_days"))
+			w.WriteHeader(marineStatus)
+			w.Write([]byte(`{"hourly": {
+				"time": ["2026-03-01T00:00", "2026-03-01T01:00", "2026-03-01T02:00", "2026-03-01T03:00", "2026-03-01T04:00", "2026-03-01T06:00"],
+				"wave_height": [1.2, 2.8, 2.1, 3.9, null, null]
+			}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	svc := NewWeatherService("key", server.URL, zap.NewNop()).WithMarineAPI(server.URL + "/marine/")
+
+	forecast, err := svc.GetForecast(context.Background(), 1.26, 103.84, 1)
+	require.NoError(t, err)
+	require.Len(t, forecast, 3)
+	assert.Equal(t, ptr(2.8), forecast[0].WaveHeight) // Highest of 00:00 to 02:00
+	assert.Equal(t, ptr(3.9), forecast[1].WaveHeight)
+	assert.Nil(t, forecast[2].WaveHeight) // Nothing forecast from 06:00
+
+	// The rest of the forecast is kept when the marine API fails
+	marineStatus = http.StatusInternalServerError
+	forecast, err = svc.GetForecast(context.Background(), 1.26, 103.84, 1)
+	require.NoError(t, err)
+	require.Len(t, forecast, 3)
+	for _, entry := range forecast {
+		assert.Nil(t, entry.WaveHeight)
+	}
+	assert.Equal(t, 14.0, forecast[1].WindSpeed)
+}
+
+func TestCoreClient_AddPortCallTimelineEvent(t *testing.T) {
+	var received TimelineEvent
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/port-calls/pc-1/timeline", r.URL.Path)
+		assert.Equal(t, "org-1", r.Header.Get("X-Organization-ID"))
+		assert.Equal(t, "system", r.Header.Get("X-User-ID"))
+		assert.Equal(t, "system", r.Header.Get("X-User-Roles"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+		w.Write([]byte(`{"success": false, "error": {"code": "NOT_FOUND"}}`))
+	}))
+	defer server.Close()
+
+	client := NewCoreClient(server.URL + "/")
+	event := TimelineEvent{
+		EventType:   "weather_alert",
+		Title:       "Weather alert: High wind",
+		Description: "Forecast wind up to 25.0 m/s.",
+		Metadata:    map[string]any{"severity": "moderate"},
+	}
+
+	require.NoError(t, client.AddPortCallTimelineEvent(context.Background(), "org-1", "pc-1", event))
+	assert.Equal(t, event, received)
+
+	status = http.StatusNotFound
+	err := client.AddPortCallTimelineEvent(context.Background(), "org-1", "pc-1", event)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/navo/pkg/observability"
 	"github.com/navo/services/integration/internal/model"
 	"github.com/navo/services/integration/internal/repository"
 	"go.uber.org/zap"
@@ -39,7 +40,8 @@ func NewWebhookService(repo *repository.WebhookRepository, logger *zap.Logger, c
 	return &WebhookService{
 		repo: repo,
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: observability.Transport(nil),
 		},
 		logger: logger,
 		config: config,
@@ -185,7 +187,8 @@ func (s *WebhookService) DispatchEvent(ctx context.Context, event *model.Webhook
 			continue
 		}
 
-		go s.deliverWebhook(context.Background(), &webhook, event)
+		// Deliveries outlive the dispatch, but stay in its trace
+		go s.deliverWebhook(context.WithoutCancel(ctx), &webhook, event)
 	}
 
 	return nil
@@ -0,0 +1,23 @@
+-- Weather alerts raised for vessels and port calls
+
+CREATE TABLE IF NOT EXISTS weather_alerts (
+    id TEXT PRIMARY KEY,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    reference TEXT,
+    organization_id TEXT NOT NULL,
+    workspace_id TEXT NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    event TEXT NOT NULL,
+    sender TEXT NOT NULL,
+    description TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    breaches JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_weather_alerts_target ON weather_alerts(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_weather_alerts_org ON weather_alerts(organization_id, end_at);
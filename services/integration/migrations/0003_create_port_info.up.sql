@@ -0,0 +1,45 @@
+-- Port registry, refreshed from the port data providers
+
+CREATE TABLE IF NOT EXISTS port_info (
+    id TEXT PRIMARY KEY,
+    un_locode TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    country_code TEXT NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+    longitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    port_type TEXT NOT NULL DEFAULT 'seaport',
+    port_size TEXT NOT NULL DEFAULT '',
+    max_draft DOUBLE PRECISION,
+    max_loa DOUBLE PRECISION,
+    tidal_range DOUBLE PRECISION,
+    has_container_terminal BOOLEAN NOT NULL DEFAULT false,
+    has_bulk_terminal BOOLEAN NOT NULL DEFAULT false,
+    has_tanker_terminal BOOLEAN NOT NULL DEFAULT false,
+    has_roro_terminal BOOLEAN NOT NULL DEFAULT false,
+    has_cruise_terminal BOOLEAN NOT NULL DEFAULT false,
+    has_drydock BOOLEAN NOT NULL DEFAULT false,
+    has_bunkering BOOLEAN NOT NULL DEFAULT false,
+    has_fresh_water BOOLEAN NOT NULL DEFAULT false,
+    has_provisions BOOLEAN NOT NULL DEFAULT false,
+    has_repair_facilities BOOLEAN NOT NULL DEFAULT false,
+    pilotage_required BOOLEAN NOT NULL DEFAULT false,
+    tug_assist_available BOOLEAN NOT NULL DEFAULT false,
+    anchorage_available BOOLEAN NOT NULL DEFAULT false,
+    quarantine_required BOOLEAN NOT NULL DEFAULT false,
+    website TEXT,
+    phone TEXT,
+    email TEXT,
+    vhf_channel TEXT,
+    annual_teus INTEGER,
+    annual_tonnage INTEGER,
+    average_wait_time INTEGER,
+    current_congestion TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_port_info_country ON port_info(country_code);
+CREATE INDEX IF NOT EXISTS idx_port_info_name ON port_info(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_port_info_coordinates ON port_info(latitude, longitude);
@@ -0,0 +1,5 @@
+ALTER TABLE service_orders DROP COLUMN IF EXISTS accounting_ref;
+ALTER TABLE service_orders DROP COLUMN IF EXISTS paid_at;
+ALTER TABLE service_orders DROP COLUMN IF EXISTS payment_status;
+
+DROP TABLE IF EXISTS accounting_exports;
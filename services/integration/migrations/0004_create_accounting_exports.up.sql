@@ -0,0 +1,28 @@
+-- Invoices and service orders exported to accounting systems, and the
+-- payment state synced back onto service orders (a Prisma table)
+
+CREATE TABLE IF NOT EXISTS accounting_exports (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    external_id TEXT,
+    status TEXT NOT NULL,
+    amount NUMERIC(12, 2),
+    currency TEXT,
+    last_error TEXT,
+    exported_at TIMESTAMP WITH TIME ZONE,
+    last_checked_at TIMESTAMP WITH TIME ZONE,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (provider, entity_type, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_exports_org ON accounting_exports(organization_id, entity_type);
+CREATE INDEX IF NOT EXISTS idx_accounting_exports_status ON accounting_exports(provider, entity_type, status);
+
+ALTER TABLE service_orders ADD COLUMN IF NOT EXISTS payment_status TEXT;
+ALTER TABLE service_orders ADD COLUMN IF NOT EXISTS paid_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE service_orders ADD COLUMN IF NOT EXISTS accounting_ref TEXT;
@@ -0,0 +1,7 @@
+UPDATE port_info SET latitude = 0, longitude = 0
+WHERE latitude IS NULL OR longitude IS NULL;
+
+ALTER TABLE port_info ALTER COLUMN latitude SET DEFAULT 0;
+ALTER TABLE port_info ALTER COLUMN latitude SET NOT NULL;
+ALTER TABLE port_info ALTER COLUMN longitude SET DEFAULT 0;
+ALTER TABLE port_info ALTER COLUMN longitude SET NOT NULL;
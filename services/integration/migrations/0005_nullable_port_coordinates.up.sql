@@ -0,0 +1,10 @@
+-- Ports imported without coordinates were stored at 0,0; keep them unset
+-- instead so they stay out of proximity searches
+
+ALTER TABLE port_info ALTER COLUMN latitude DROP NOT NULL;
+ALTER TABLE port_info ALTER COLUMN latitude DROP DEFAULT;
+ALTER TABLE port_info ALTER COLUMN longitude DROP NOT NULL;
+ALTER TABLE port_info ALTER COLUMN longitude DROP DEFAULT;
+
+UPDATE port_info SET latitude = NULL, longitude = NULL
+WHERE latitude = 0 AND longitude = 0;
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,22 +13,38 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/database"
+	"github.com/navo/pkg/idempotency"
+	"github.com/navo/pkg/migrate"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
+	navoredis "github.com/navo/pkg/redis"
+	"github.com/navo/services/notification/internal/channel"
 	"github.com/navo/services/notification/internal/config"
 	"github.com/navo/services/notification/internal/handler"
 	"github.com/navo/services/notification/internal/repository"
 	"github.com/navo/services/notification/internal/service"
 	"github.com/navo/services/notification/internal/worker"
+	"github.com/navo/services/notification/migrations"
 )
 
 func main() {
 	cfg := config.Load()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(context.Background(), observability.DefaultConfig("notification"))
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracer(context.Background())
+
 	// Initialize Redis
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisAddr,
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
 	})
+	observability.InstrumentRedis(redisClient)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -37,9 +54,68 @@ func main() {
 	}
 	log.Println("Connected to Redis")
 
+	// Idempotency keys need a second connection: pkg/idempotency takes a
+	// go-redis v9 client, while the client above is go-redis v8 because the
+	// repositories, worker and observability.InstrumentRedis are built on
+	// v8. Both connect to the same Redis and DB. It can go once notification
+	// moves to v9.
+	redisHost, redisPort, err := net.SplitHostPort(cfg.RedisAddr)
+	if err != nil {
+		log.Fatalf("Invalid REDIS_ADDR: %v", err)
+	}
+	idempotencyRedis, err := navoredis.Connect(ctx, &navoredis.Config{
+		Host:     redisHost,
+		Port:     redisPort,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+
+	// Connect to database, which stores notifications, preferences and
+	// template overrides
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	db, err := database.OpenDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+	log.Println("Connected to database")
+
+	// Apply schema migrations; replicas starting together wait on the
+	// migration lock. "notification migrate ..." manages them instead.
+	migrator, err := migrate.New(db, "notification", migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrate.Command(context.Background(), migrator, os.Args[2:], os.Stdout)
+		db.Close()
+		os.Exit(code)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+	log.Printf("Database schema up to date, %d migrations applied", len(applied))
+
 	// Initialize repositories
-	notificationRepo := repository.NewNotificationRepository(redisClient)
-	templateRepo := repository.NewTemplateRepository()
+	notificationRepo := repository.NewNotificationRepository(db, redisClient, cfg.RetentionDays)
+	if queued, err := notificationRepo.RestorePendingQueue(ctx); err != nil {
+		log.Printf("Warning: Failed to restore pending notifications: %v", err)
+	} else if queued > 0 {
+		log.Printf("Queued %d pending notifications", queued)
+	}
+	templateRepo := repository.NewTemplateRepository(db)
+	preferencesRepo := repository.NewPreferencesRepository(db, redisClient)
+	escalationRepo := repository.NewEscalationRepository(redisClient)
 
 	// Initialize email service
 	emailService := service.NewEmailService(service.EmailConfig{
@@ -51,11 +127,48 @@ func main() {
 		FromName:     cfg.FromName,
 	})
 
+	// Initialize delivery channel providers
+	pushRepo := repository.NewPushSubscriptionRepository(redisClient)
+	providers := channel.NewRegistry()
+	if cfg.SMSGatewayURL != "" {
+		providers.Register(channel.NewSMSProvider(channel.SMSConfig{
+			GatewayURL:     cfg.SMSGatewayURL,
+			APIKey:         cfg.SMSGatewayAPIKey,
+			SenderID:       cfg.SMSSenderID,
+			CallbackURL:    cfg.SMSCallbackURL,
+			CallbackSecret: cfg.SMSCallbackSecret,
+		}), cfg.SMSRateLimitPerMin)
+		if cfg.SMSCallbackSecret == "" {
+			log.Println("Warning: SMS_CALLBACK_SECRET not set, SMS delivery callbacks are not verified")
+		}
+	}
+	providers.Register(channel.NewSlackProvider(channel.WebhookConfig{
+		WebhookURL: cfg.SlackWebhookURL,
+	}), cfg.SlackRateLimitPerMin)
+	providers.Register(channel.NewTeamsProvider(channel.WebhookConfig{
+		WebhookURL: cfg.TeamsWebhookURL,
+	}), cfg.TeamsRateLimitPerMin)
+	if cfg.VAPIDPrivateKey != "" {
+		webPush, err := channel.NewWebPushProvider(channel.WebPushConfig{
+			VAPIDPublicKey:  cfg.VAPIDPublicKey,
+			VAPIDPrivateKey: cfg.VAPIDPrivateKey,
+			Subject:         cfg.VAPIDSubject,
+		}, pushRepo)
+		if err != nil {
+			log.Fatalf("Failed to initialize Web Push: %v", err)
+		}
+		providers.Register(webPush, cfg.WebPushRateLimitPerMin)
+	}
+
 	// Initialize notification service
 	notificationService := service.NewNotificationService(
 		emailService,
 		notificationRepo,
 		templateRepo,
+		preferencesRepo,
+		pushRepo,
+		escalationRepo,
+		providers,
 		redisClient,
 	)
 
@@ -72,13 +185,23 @@ func main() {
 	// Initialize handlers
 	notificationHandler := handler.NewNotificationHandler(notificationService)
 
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Notification Service",
+		Description: "Notifications, preferences, escalations and templates",
+	}, handler.Operations)
+
 	// Setup router
 	r := chi.NewRouter()
+	r.Use(observability.HTTPMiddleware) // Continues the caller's trace
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(api.Validate)
+	r.Use(idempotency.Middleware(idempotency.NewRedisStore(idempotencyRedis), idempotency.DefaultConfig("notification")))
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -87,23 +210,11 @@ func main() {
 	})
 
 	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Route("/notifications", func(r chi.Router) {
-			r.Post("/", notificationHandler.Send)
-			r.Post("/batch", notificationHandler.SendBatch)
-			r.Get("/{id}", notificationHandler.Get)
-			r.Get("/user/{userID}", notificationHandler.GetByUser)
-			r.Put("/{id}/read", notificationHandler.MarkAsRead)
-			r.Put("/user/{userID}/read-all", notificationHandler.MarkAllAsRead)
-			r.Delete("/{id}", notificationHandler.Delete)
-		})
-
-		r.Route("/templates", func(r chi.Router) {
-			r.Get("/", notificationHandler.ListTemplates)
-			r.Get("/{name}", notificationHandler.GetTemplate)
-			r.Post("/{name}/preview", notificationHandler.PreviewTemplate)
-		})
-	})
+	r.Route("/api/v1", notificationHandler.RegisterRoutes)
+
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
 
 	// Start server
 	server := &http.Server{
@@ -138,5 +249,6 @@ func main() {
 	}
 
 	redisClient.Close()
+	navoredis.Close()
 	log.Println("Notification service stopped")
 }
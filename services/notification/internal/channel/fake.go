@@ -0,0 +1,77 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// Fake is a provider test double recording the notifications it is given.
+// Set Err to make sends fail and Status to change the reported status.
+type Fake struct {
+	ProviderName string
+	Type         model.NotificationType
+	Status       model.NotificationStatus
+	Err          error
+
+	mu   sync.Mutex
+	sent []*model.Notification
+}
+
+// NewFake creates a fake provider for a channel that reports notifications
+// as delivered
+func NewFake(channel model.NotificationType) *Fake {
+	return &Fake{
+		ProviderName: "fake-" + string(channel),
+		Type:         channel,
+		Status:       model.NotificationStatusDelivered,
+	}
+}
+
+// Name returns the provider name
+func (f *Fake) Name() string {
+	return f.ProviderName
+}
+
+// Channel returns the fake's notification type
+func (f *Fake) Channel() model.NotificationType {
+	return f.Type
+}
+
+// Send records the notification
+func (f *Fake) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.sent = append(f.sent, notification)
+	return &Delivery{
+		MessageID: fmt.Sprintf("%s-%d", f.ProviderName, len(f.sent)),
+		Status:    f.Status,
+	}, nil
+}
+
+// Sent returns the notifications sent so far
+func (f *Fake) Sent() []*model.Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*model.Notification(nil), f.sent...)
+}
+
+// ParseCallback reads a callback of the form
+// ?message_id=...&status=delivered|failed&reason=...
+func (f *Fake) ParseCallback(r *http.Request) ([]DeliveryStatus, error) {
+	query := r.URL.Query()
+	return []DeliveryStatus{{
+		MessageID: query.Get("message_id"),
+		Status:    model.NotificationStatus(query.Get("status")),
+		Reason:    query.Get("reason"),
+		At:        time.Now().UTC(),
+	}}, nil
+}
@@ -0,0 +1,212 @@
+// Package channel delivers notifications over external channels: SMS, Slack,
+// Microsoft Teams and Web Push.
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+var (
+	// ErrNoProvider is returned when no provider is registered for a channel
+	ErrNoProvider = errors.New("no provider configured for channel")
+
+	// ErrUnknownProvider is returned for callbacks of an unknown provider
+	ErrUnknownProvider = errors.New("unknown provider")
+
+	// ErrInvalidSignature is returned for callbacks that fail verification
+	ErrInvalidSignature = errors.New("invalid callback signature")
+)
+
+// Provider sends notifications over one channel
+type Provider interface {
+	// Name identifies the provider in delivery callbacks and notifications
+	Name() string
+	// Channel is the notification type the provider delivers
+	Channel() model.NotificationType
+	// Send delivers the notification
+	Send(ctx context.Context, notification *model.Notification) (*Delivery, error)
+}
+
+// CallbackProvider is a provider reporting delivery status asynchronously
+type CallbackProvider interface {
+	Provider
+	// ParseCallback verifies a delivery status callback and returns the
+	// statuses it reports
+	ParseCallback(r *http.Request) ([]DeliveryStatus, error)
+}
+
+// Delivery is the result of handing a notification to a provider
+type Delivery struct {
+	Provider  string
+	MessageID string
+	// Status is NotificationStatusSent when the provider reports delivery
+	// later through a callback, NotificationStatusDelivered otherwise
+	Status model.NotificationStatus
+}
+
+// DeliveryStatus is a delivery status reported by a provider callback
+type DeliveryStatus struct {
+	MessageID string
+	Status    model.NotificationStatus // delivered or failed
+	Reason    string
+	At        time.Time
+}
+
+// RateLimitError is returned when a provider's rate limit is exhausted,
+// either locally or by the provider itself
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Provider, e.RetryAfter)
+}
+
+// Registry routes notifications to the provider of their channel and
+// enforces each provider's rate limit
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[model.NotificationType]Provider
+	byName    map[string]Provider
+	limiters  map[string]*tokenBucket
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[model.NotificationType]Provider),
+		byName:    make(map[string]Provider),
+		limiters:  make(map[string]*tokenBucket),
+	}
+}
+
+// Register adds a provider, replacing any provider of the same channel.
+// perMinute limits the provider's sends on this instance; 0 disables the limit.
+func (r *Registry) Register(provider Provider, perMinute int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[provider.Channel()] = provider
+	r.byName[provider.Name()] = provider
+	if perMinute > 0 {
+		r.limiters[provider.Name()] = newTokenBucket(perMinute)
+	} else {
+		delete(r.limiters, provider.Name())
+	}
+}
+
+// Has reports whether a provider is registered for the channel
+func (r *Registry) Has(channel model.NotificationType) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.providers[channel]
+	return ok
+}
+
+// Get returns a provider by name
+func (r *Registry) Get(name string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.byName[name]
+	return provider, ok
+}
+
+// Send delivers a notification through the provider of its channel
+func (r *Registry) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	r.mu.RLock()
+	provider, ok := r.providers[notification.Type]
+	var limiter *tokenBucket
+	if ok {
+		limiter = r.limiters[provider.Name()]
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoProvider, notification.Type)
+	}
+
+	if limiter != nil {
+		if wait := limiter.take(time.Now()); wait > 0 {
+			return nil, &RateLimitError{Provider: provider.Name(), RetryAfter: wait}
+		}
+	}
+
+	delivery, err := provider.Send(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Provider = provider.Name()
+	return delivery, nil
+}
+
+// ParseCallback verifies and parses a delivery status callback of a provider
+func (r *Registry) ParseCallback(name string, req *http.Request) ([]DeliveryStatus, error) {
+	provider, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	callbackProvider, ok := provider.(CallbackProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not report delivery status", ErrUnknownProvider, name)
+	}
+	return callbackProvider.ParseCallback(req)
+}
+
+// tokenBucket is a token bucket refilled at a per-minute rate. Bursts are
+// limited to one second's worth of tokens so sends are spread out evenly.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	rate := float64(perMinute) / 60
+	capacity := rate
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		tokens:   capacity,
+		capacity: capacity,
+		rate:     rate,
+	}
+}
+
+// take takes a token, returning 0 on success or how long to wait for one
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// retryAfter parses a Retry-After header in seconds, defaulting to a minute
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Minute
+}
@@ -0,0 +1,86 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrySend(t *testing.T) {
+	registry := NewRegistry()
+	sms := NewFake(model.NotificationTypeSMS)
+	sms.Status = model.NotificationStatusSent
+	registry.Register(sms, 0)
+
+	delivery, err := registry.Send(context.Background(), &model.Notification{ID: "n-1", Type: model.NotificationTypeSMS})
+	require.NoError(t, err)
+	assert.Equal(t, "fake-sms", delivery.Provider)
+	assert.Equal(t, "fake-sms-1", delivery.MessageID)
+	assert.Equal(t, model.NotificationStatusSent, delivery.Status)
+	require.Len(t, sms.Sent(), 1)
+
+	_, err = registry.Send(context.Background(), &model.Notification{ID: "n-2", Type: model.NotificationTypeSlack})
+	assert.ErrorIs(t, err, ErrNoProvider)
+
+	sms.Err = errors.New("gateway down")
+	_, err = registry.Send(context.Background(), &model.Notification{ID: "n-3", Type: model.NotificationTypeSMS})
+	assert.EqualError(t, err, "gateway down")
+}
+
+func TestRegistryRateLimit(t *testing.T) {
+	registry := NewRegistry()
+	slack := NewFake(model.NotificationTypeSlack)
+	registry.Register(slack, 60) // one per second
+
+	notification := &model.Notification{Type: model.NotificationTypeSlack}
+	_, err := registry.Send(context.Background(), notification)
+	require.NoError(t, err)
+
+	_, err = registry.Send(context.Background(), notification)
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, "fake-slack", rateErr.Provider)
+	assert.Greater(t, rateErr.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, rateErr.RetryAfter, time.Second)
+	assert.Len(t, slack.Sent(), 1, "rate limited notifications are not sent")
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(120) // two per second
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Zero(t, bucket.take(now))
+	assert.Zero(t, bucket.take(now))
+	assert.Equal(t, 500*time.Millisecond, bucket.take(now))
+
+	// Refills at the configured rate but not beyond one second's worth
+	assert.Zero(t, bucket.take(now.Add(500*time.Millisecond)))
+	later := now.Add(time.Minute)
+	assert.Zero(t, bucket.take(later))
+	assert.Zero(t, bucket.take(later))
+	assert.NotZero(t, bucket.take(later))
+}
+
+func TestRegistryParseCallback(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(NewFake(model.NotificationTypeSMS), 0)
+	registry.Register(NewSlackProvider(WebhookConfig{}), 0)
+
+	req := httptest.NewRequest("POST", "/callbacks/fake-sms?message_id=m-1&status=delivered", nil)
+	statuses, err := registry.ParseCallback("fake-sms", req)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "m-1", statuses[0].MessageID)
+	assert.Equal(t, model.NotificationStatusDelivered, statuses[0].Status)
+
+	_, err = registry.ParseCallback("slack", req)
+	assert.ErrorIs(t, err, ErrUnknownProvider, "slack reports delivery synchronously")
+	_, err = registry.ParseCallback("pigeon", req)
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
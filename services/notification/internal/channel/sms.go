@@ -0,0 +1,193 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// maxSMSLength is the longest message sent, about three concatenated SMS
+const maxSMSLength = 459
+
+// SMSConfig configures the SMS gateway provider
+type SMSConfig struct {
+	GatewayURL     string // endpoint messages are POSTed to
+	APIKey         string // sent as a bearer token
+	SenderID       string
+	CallbackURL    string // where the gateway reports delivery status
+	CallbackSecret string // key of the callback HMAC-SHA256 signature
+	Timeout        time.Duration
+}
+
+// SMSProvider sends SMS through a generic HTTP gateway.
+//
+// Messages are POSTed as JSON {to, from, body, reference, callback_url} and
+// the gateway answers with {message_id}. Delivery status is POSTed back to
+// the callback URL as {message_id, status, error}, signed with an
+// HMAC-SHA256 hex signature of the body in the X-Signature header.
+type SMSProvider struct {
+	config SMSConfig
+	client *http.Client
+}
+
+// NewSMSProvider creates a new SMS gateway provider
+func NewSMSProvider(config SMSConfig) *SMSProvider {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &SMSProvider{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Name returns the provider name
+func (p *SMSProvider) Name() string {
+	return "sms"
+}
+
+// Channel returns the SMS notification type
+func (p *SMSProvider) Channel() model.NotificationType {
+	return model.NotificationTypeSMS
+}
+
+// Send sends the notification as an SMS
+func (p *SMSProvider) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	if notification.Phone == "" {
+		return nil, fmt.Errorf("phone number is required for SMS notifications")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"to":           notification.Phone,
+		"from":         p.config.SenderID,
+		"body":         smsText(notification),
+		"reference":    notification.ID,
+		"callback_url": p.config.CallbackURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SMS: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.GatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{Provider: p.Name(), RetryAfter: retryAfter(resp)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("SMS gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode SMS gateway response: %w", err)
+	}
+
+	return &Delivery{
+		MessageID: result.MessageID,
+		Status:    model.NotificationStatusSent,
+	}, nil
+}
+
+// ParseCallback verifies and parses a delivery status callback
+func (p *SMSProvider) ParseCallback(r *http.Request) ([]DeliveryStatus, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read callback: %w", err)
+	}
+
+	if p.config.CallbackSecret != "" {
+		mac := hmac.New(sha256.New, []byte(p.config.CallbackSecret))
+		mac.Write(body)
+		signature, err := hex.DecodeString(r.Header.Get("X-Signature"))
+		if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	var callback struct {
+		MessageID string `json:"message_id"`
+		Status    string `json:"status"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("failed to decode callback: %w", err)
+	}
+	if callback.MessageID == "" {
+		return nil, fmt.Errorf("message_id is required")
+	}
+
+	var status model.NotificationStatus
+	switch strings.ToLower(callback.Status) {
+	case "delivered":
+		status = model.NotificationStatusDelivered
+	case "failed", "undelivered", "rejected", "expired":
+		status = model.NotificationStatusFailed
+	default:
+		// Intermediate states (queued, sent, ...) don't change the notification
+		return nil, nil
+	}
+
+	return []DeliveryStatus{{
+		MessageID: callback.MessageID,
+		Status:    status,
+		Reason:    callback.Error,
+		At:        time.Now().UTC(),
+	}}, nil
+}
+
+// smsText returns the SMS text of a notification
+func smsText(notification *model.Notification) string {
+	text := notification.Title
+	if notification.Body != "" {
+		text += ": " + notification.Body
+	}
+	if notification.ActionURL != "" {
+		text += " " + notification.ActionURL
+	}
+	if len(text) > maxSMSLength {
+		text = truncate(text, maxSMSLength)
+	}
+	return text
+}
+
+// truncate shortens text to at most max bytes without splitting a character
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	cut := max - len("...")
+	for cut > 0 && !isRuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
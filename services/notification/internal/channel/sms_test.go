@@ -0,0 +1,108 @@
+package channel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/navo/services/notification/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSProviderSend(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sms-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message_id":"msg-42","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	provider := NewSMSProvider(SMSConfig{
+		GatewayURL:  server.URL,
+		APIKey:      "sms-key",
+		SenderID:    "Navo",
+		CallbackURL: "https://api.navo.io/api/v1/notifications/callbacks/sms",
+	})
+
+	delivery, err := provider.Send(context.Background(), &model.Notification{
+		ID:        "n-1",
+		Type:      model.NotificationTypeSMS,
+		Phone:     "+6591234567",
+		Title:     "MV Aurora berthed",
+		Body:      "Port call PC-1 is now at berth",
+		ActionURL: "https://app.navo.io/port-calls/1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "msg-42", delivery.MessageID)
+	assert.Equal(t, model.NotificationStatusSent, delivery.Status)
+
+	assert.Equal(t, "+6591234567", received["to"])
+	assert.Equal(t, "Navo", received["from"])
+	assert.Equal(t, "n-1", received["reference"])
+	assert.Equal(t, "MV Aurora berthed: Port call PC-1 is now at berth https://app.navo.io/port-calls/1", received["body"])
+
+	_, err = provider.Send(context.Background(), &model.Notification{ID: "n-2", Type: model.NotificationTypeSMS})
+	assert.Error(t, err, "phone number is required")
+}
+
+func TestSMSProviderRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := NewSMSProvider(SMSConfig{GatewayURL: server.URL})
+	_, err := provider.Send(context.Background(), &model.Notification{Phone: "+6591234567"})
+
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, "30s", rateErr.RetryAfter.String())
+}
+
+func TestSMSProviderCallback(t *testing.T) {
+	provider := NewSMSProvider(SMSConfig{CallbackSecret: "secret"})
+
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	callback := func(body, signature string) *http.Request {
+		req := httptest.NewRequest("POST", "/callbacks/sms", strings.NewReader(body))
+		req.Header.Set("X-Signature", signature)
+		return req
+	}
+
+	body := `{"message_id":"msg-42","status":"undelivered","error":"handset unreachable"}`
+	statuses, err := provider.ParseCallback(callback(body, sign(body)))
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "msg-42", statuses[0].MessageID)
+	assert.Equal(t, model.NotificationStatusFailed, statuses[0].Status)
+	assert.Equal(t, "handset unreachable", statuses[0].Reason)
+
+	_, err = provider.ParseCallback(callback(body, sign("tampered")))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	queued := `{"message_id":"msg-42","status":"queued"}`
+	statuses, err = provider.ParseCallback(callback(queued, sign(queued)))
+	require.NoError(t, err)
+	assert.Empty(t, statuses, "intermediate states are ignored")
+}
+
+func TestSMSTextTruncation(t *testing.T) {
+	text := smsText(&model.Notification{Title: "Alert", Body: strings.Repeat("é", 400)})
+	assert.LessOrEqual(t, len(text), maxSMSLength)
+	assert.True(t, strings.HasSuffix(text, "..."))
+	assert.True(t, strings.HasPrefix(text, "Alert: é"))
+}
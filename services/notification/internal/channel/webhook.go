@@ -0,0 +1,198 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// WebhookConfig configures a chat webhook provider
+type WebhookConfig struct {
+	WebhookURL string // used when the notification has no target
+	Timeout    time.Duration
+}
+
+// webhookPoster posts JSON messages to chat incoming webhooks
+type webhookPoster struct {
+	name   string
+	config WebhookConfig
+	client *http.Client
+}
+
+func newWebhookPoster(name string, config WebhookConfig) webhookPoster {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return webhookPoster{
+		name:   name,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// post sends the message to the notification's target, or the configured
+// webhook when it has none
+func (p webhookPoster) post(ctx context.Context, notification *model.Notification, message any) (*Delivery, error) {
+	url := notification.Target
+	if url == "" {
+		url = p.config.WebhookURL
+	}
+	if url == "" {
+		return nil, fmt.Errorf("webhook URL is required for %s notifications", p.name)
+	}
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("%s webhook URL must use https", p.name)
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s message: %w", p.name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", p.name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post %s message: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{Provider: p.name, RetryAfter: retryAfter(resp)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s webhook returned %d: %s", p.name, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// Webhooks post synchronously, so the message has been delivered
+	return &Delivery{Status: model.NotificationStatusDelivered}, nil
+}
+
+// SlackProvider posts notifications to Slack incoming webhooks
+type SlackProvider struct {
+	webhookPoster
+}
+
+// NewSlackProvider creates a new Slack incoming-webhook provider
+func NewSlackProvider(config WebhookConfig) *SlackProvider {
+	return &SlackProvider{newWebhookPoster("slack", config)}
+}
+
+// Name returns the provider name
+func (p *SlackProvider) Name() string {
+	return "slack"
+}
+
+// Channel returns the Slack notification type
+func (p *SlackProvider) Channel() model.NotificationType {
+	return model.NotificationTypeSlack
+}
+
+// Send posts the notification to Slack
+func (p *SlackProvider) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	return p.post(ctx, notification, slackMessage(notification))
+}
+
+// slackMessage builds a Block Kit message for a notification
+func slackMessage(notification *model.Notification) map[string]any {
+	title := notification.Title
+	if notification.Priority == model.NotificationPriorityCritical {
+		title = ":rotating_light: " + title
+	}
+
+	blocks := []map[string]any{
+		{
+			"type": "section",
+			"text": map[string]string{
+				"type": "mrkdwn",
+				"text": "*" + slackEscape(title) + "*\n" + slackEscape(notification.Body),
+			},
+		},
+	}
+	if notification.ActionURL != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{{
+				"type": "button",
+				"text": map[string]string{"type": "plain_text", "text": "View Details"},
+				"url":  notification.ActionURL,
+			}},
+		})
+	}
+
+	return map[string]any{
+		"text":   notification.Title + ": " + notification.Body, // notification fallback
+		"blocks": blocks,
+	}
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// TeamsProvider posts notifications to Microsoft Teams incoming webhook
+// connectors
+type TeamsProvider struct {
+	webhookPoster
+}
+
+// NewTeamsProvider creates a new Microsoft Teams connector provider
+func NewTeamsProvider(config WebhookConfig) *TeamsProvider {
+	return &TeamsProvider{newWebhookPoster("teams", config)}
+}
+
+// Name returns the provider name
+func (p *TeamsProvider) Name() string {
+	return "teams"
+}
+
+// Channel returns the Teams notification type
+func (p *TeamsProvider) Channel() model.NotificationType {
+	return model.NotificationTypeTeams
+}
+
+// Send posts the notification to Teams
+func (p *TeamsProvider) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	return p.post(ctx, notification, teamsMessage(notification))
+}
+
+// teamsMessage builds a connector MessageCard for a notification
+func teamsMessage(notification *model.Notification) map[string]any {
+	color := "0F172A"
+	switch notification.Priority {
+	case model.NotificationPriorityCritical:
+		color = "DC2626"
+	case model.NotificationPriorityHigh:
+		color = "F59E0B"
+	}
+
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    notification.Title,
+		"themeColor": color,
+		"title":      notification.Title,
+		"text":       notification.Body,
+	}
+	if notification.ActionURL != "" {
+		card["potentialAction"] = []map[string]any{{
+			"@type":   "OpenUri",
+			"name":    "View Details",
+			"targets": []map[string]string{{"os": "default", "uri": notification.ActionURL}},
+		}}
+	}
+	return card
+}
@@ -0,0 +1,93 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/navo/services/notification/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer records the JSON messages posted to it
+func webhookServer(t *testing.T, status int) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var messages []map[string]any
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		messages = append(messages, message)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &messages
+}
+
+func TestSlackProvider(t *testing.T) {
+	server, messages := webhookServer(t, http.StatusOK)
+	provider := NewSlackProvider(WebhookConfig{WebhookURL: server.URL})
+	provider.client = server.Client()
+
+	delivery, err := provider.Send(context.Background(), &model.Notification{
+		Type:      model.NotificationTypeSlack,
+		Priority:  model.NotificationPriorityCritical,
+		Title:     "MV Aurora <delayed>",
+		Body:      "ETA moved by 12h",
+		ActionURL: "https://app.navo.io/port-calls/1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.NotificationStatusDelivered, delivery.Status)
+
+	require.Len(t, *messages, 1)
+	message := (*messages)[0]
+	assert.Equal(t, "MV Aurora <delayed>: ETA moved by 12h", message["text"])
+	blocks := message["blocks"].([]any)
+	require.Len(t, blocks, 2)
+	section := blocks[0].(map[string]any)["text"].(map[string]any)
+	assert.Equal(t, "*:rotating_light: MV Aurora &lt;delayed&gt;*\nETA moved by 12h", section["text"])
+}
+
+func TestTeamsProvider(t *testing.T) {
+	server, messages := webhookServer(t, http.StatusOK)
+	provider := NewTeamsProvider(WebhookConfig{WebhookURL: "https://example.invalid/default"})
+	provider.client = server.Client()
+
+	// The notification's target overrides the default webhook
+	_, err := provider.Send(context.Background(), &model.Notification{
+		Type:     model.NotificationTypeTeams,
+		Priority: model.NotificationPriorityHigh,
+		Target:   server.URL,
+		Title:    "Quote received",
+		Body:     "Harbor Services quoted 1,200 USD",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, *messages, 1)
+	card := (*messages)[0]
+	assert.Equal(t, "MessageCard", card["@type"])
+	assert.Equal(t, "F59E0B", card["themeColor"])
+	assert.Equal(t, "Quote received", card["title"])
+	assert.NotContains(t, card, "potentialAction")
+}
+
+func TestWebhookErrors(t *testing.T) {
+	server, _ := webhookServer(t, http.StatusTooManyRequests)
+	provider := NewSlackProvider(WebhookConfig{WebhookURL: server.URL})
+	provider.client = server.Client()
+
+	_, err := provider.Send(context.Background(), &model.Notification{Title: "t", Body: "b"})
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, "slack", rateErr.Provider)
+
+	provider = NewSlackProvider(WebhookConfig{WebhookURL: "http://hooks.example.com/plain"})
+	_, err = provider.Send(context.Background(), &model.Notification{})
+	assert.ErrorContains(t, err, "https")
+
+	provider = NewSlackProvider(WebhookConfig{})
+	_, err = provider.Send(context.Background(), &model.Notification{})
+	assert.ErrorContains(t, err, "webhook URL is required")
+}
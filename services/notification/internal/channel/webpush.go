@@ -0,0 +1,363 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+const (
+	// webPushRecordSize is the aes128gcm record size advertised in the header
+	webPushRecordSize = 4096
+	// maxWebPushPayload is the largest payload push services must accept
+	maxWebPushPayload = 3993
+	// vapidTokenTTL is how long VAPID tokens are valid, at most 24 hours
+	vapidTokenTTL = 12 * time.Hour
+)
+
+// SubscriptionStore provides the Web Push subscriptions of users
+type SubscriptionStore interface {
+	ListPushSubscriptions(ctx context.Context, userID string) ([]*model.PushSubscription, error)
+	DeletePushSubscription(ctx context.Context, userID, endpoint string) error
+}
+
+// WebPushConfig configures the Web Push provider
+type WebPushConfig struct {
+	VAPIDPublicKey  string // base64url uncompressed P-256 point
+	VAPIDPrivateKey string // base64url P-256 scalar
+	Subject         string // mailto: or https: contact of the sender
+	TTL             time.Duration
+	Timeout         time.Duration
+}
+
+// WebPushProvider sends browser push notifications using the Web Push
+// protocol (RFC 8030) with VAPID authentication (RFC 8292) and aes128gcm
+// payload encryption (RFC 8291). Notifications go to every subscription of
+// the user; subscriptions the push service reports gone are removed.
+type WebPushProvider struct {
+	config     WebPushConfig
+	publicKey  []byte
+	privateKey *ecdsa.PrivateKey
+	store      SubscriptionStore
+	client     *http.Client
+}
+
+// NewWebPushProvider creates a new Web Push provider
+func NewWebPushProvider(config WebPushConfig, store SubscriptionStore) (*WebPushProvider, error) {
+	if config.TTL == 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	privateKey, err := parseVAPIDPrivateKey(config.VAPIDPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := elliptic.Marshal(elliptic.P256(), privateKey.X, privateKey.Y)
+	if config.VAPIDPublicKey != "" && config.VAPIDPublicKey != base64.RawURLEncoding.EncodeToString(publicKey) {
+		return nil, fmt.Errorf("VAPID public key does not match private key")
+	}
+
+	return &WebPushProvider{
+		config:     config,
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		store:      store,
+		client:     &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *WebPushProvider) Name() string {
+	return "webpush"
+}
+
+// Channel returns the push notification type
+func (p *WebPushProvider) Channel() model.NotificationType {
+	return model.NotificationTypePush
+}
+
+// PublicKey returns the base64url VAPID public key browsers subscribe with
+func (p *WebPushProvider) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(p.publicKey)
+}
+
+// Send pushes the notification to every browser the user subscribed
+func (p *WebPushProvider) Send(ctx context.Context, notification *model.Notification) (*Delivery, error) {
+	subscriptions, err := p.store.ListPushSubscriptions(ctx, notification.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil, fmt.Errorf("user has no push subscriptions")
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"id":         notification.ID,
+		"title":      notification.Title,
+		"body":       notification.Body,
+		"url":        notification.ActionURL,
+		"category":   string(notification.Category),
+		"priority":   string(notification.Priority),
+		"created_at": notification.CreatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal push payload: %w", err)
+	}
+	if len(payload) > maxWebPushPayload {
+		return nil, fmt.Errorf("push payload too large: %d bytes", len(payload))
+	}
+
+	var lastErr error
+	delivered := 0
+	for _, subscription := range subscriptions {
+		err := p.push(ctx, subscription, payload, urgency(notification.Priority))
+		switch {
+		case err == nil:
+			delivered++
+		case err == errSubscriptionGone:
+			if err := p.store.DeletePushSubscription(ctx, notification.UserID, subscription.Endpoint); err != nil {
+				log.Printf("[WebPush] Failed to delete expired subscription of user %s: %v", notification.UserID, err)
+			}
+		default:
+			lastErr = err
+		}
+	}
+
+	if delivered == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("all push subscriptions expired")
+		}
+		return nil, lastErr
+	}
+
+	// Push services accept messages for delivery when the browser is online
+	return &Delivery{Status: model.NotificationStatusSent}, nil
+}
+
+// errSubscriptionGone is returned when a subscription expired or was revoked
+var errSubscriptionGone = errors.New("push subscription gone")
+
+// push encrypts and sends a payload to one subscription
+func (p *WebPushProvider) push(ctx context.Context, subscription *model.PushSubscription, payload []byte, urgency string) error {
+	body, err := encryptPushPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+
+	token, err := p.vapidToken(subscription.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(p.config.TTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, p.PublicKey()))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{Provider: p.Name(), RetryAfter: retryAfter(resp)}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// ValidateSubscription checks that a browser subscription can be pushed to
+func ValidateSubscription(subscription *model.PushSubscription) error {
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return fmt.Errorf("endpoint must be an https URL")
+	}
+	clientPublic, err := decodeBase64URL(subscription.Keys.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid p256dh key")
+	}
+	if _, err := ecdh.P256().NewPublicKey(clientPublic); err != nil {
+		return fmt.Errorf("invalid p256dh key")
+	}
+	authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return fmt.Errorf("invalid auth secret")
+	}
+	return nil
+}
+
+// vapidToken returns the signed VAPID JWT for the push service of an endpoint
+func (p *WebPushProvider) vapidToken(endpoint string, now time.Time) (string, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]any{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": now.Add(vapidTokenTTL).Unix(),
+		"sub": p.config.Subject,
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, p.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// encryptPushPayload encrypts a payload for a subscription as a single
+// aes128gcm record (RFC 8291, RFC 8188)
+func encryptPushPayload(subscription *model.PushSubscription, payload []byte) ([]byte, error) {
+	clientPublic, err := decodeBase64URL(subscription.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	curve := ecdh.P256()
+	clientKey, err := curve.NewPublicKey(clientPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	serverKey, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	sharedSecret, err := serverKey.ECDH(clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	serverPublic := serverKey.PublicKey().Bytes()
+	contentKey, nonce := pushContentKeys(sharedSecret, authSecret, salt, clientPublic, serverPublic)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record, terminated by the last-record padding delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	return append(header, ciphertext...), nil
+}
+
+// pushContentKeys derives the content encryption key and nonce of a message
+func pushContentKeys(sharedSecret, authSecret, salt, clientPublic, serverPublic []byte) (key, nonce []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), clientPublic...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	key = hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce = hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+	return key, nonce
+}
+
+// hkdf derives up to 32 bytes with HKDF-SHA256 (RFC 5869)
+func hkdf(salt, secret, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// parseVAPIDPrivateKey parses a base64url encoded P-256 private scalar
+func parseVAPIDPrivateKey(encoded string) (*ecdsa.PrivateKey, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("invalid VAPID private key")
+	}
+	// Validates the scalar is in range
+	if _, err := ecdh.P256().NewPrivateKey(raw); err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(raw)
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         new(big.Int).SetBytes(raw),
+	}, nil
+}
+
+// decodeBase64URL decodes base64url with or without padding
+func decodeBase64URL(encoded string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
+
+// urgency maps a notification priority to the Web Push Urgency header
+func urgency(priority model.NotificationPriority) string {
+	switch priority {
+	case model.NotificationPriorityCritical, model.NotificationPriorityHigh:
+		return "high"
+	case model.NotificationPriorityLow:
+		return "low"
+	}
+	return "normal"
+}
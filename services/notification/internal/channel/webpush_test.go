@@ -0,0 +1,226 @@
+package channel
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySubscriptions is an in-memory SubscriptionStore
+type memorySubscriptions struct {
+	mu   sync.Mutex
+	subs map[string][]*model.PushSubscription
+}
+
+func (m *memorySubscriptions) ListPushSubscriptions(ctx context.Context, userID string) ([]*model.PushSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.PushSubscription(nil), m.subs[userID]...), nil
+}
+
+func (m *memorySubscriptions) DeletePushSubscription(ctx context.Context, userID, endpoint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.subs[userID][:0]
+	for _, sub := range m.subs[userID] {
+		if sub.Endpoint != endpoint {
+			kept = append(kept, sub)
+		}
+	}
+	m.subs[userID] = kept
+	return nil
+}
+
+// browser is the receiving side of a push subscription
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) *model.PushSubscription {
+	return &model.PushSubscription{
+		Endpoint: endpoint,
+		Keys: model.PushSubscriptionKeys{
+			P256dh: base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(b.auth),
+		},
+	}
+}
+
+// decrypt decrypts an aes128gcm push message as a browser would
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt := body[:16]
+	assert.Equal(t, uint32(webPushRecordSize), binary.BigEndian.Uint32(body[16:20]))
+	keyLen := int(body[20])
+	serverPublic := body[21 : 21+keyLen]
+	ciphertext := body[21+keyLen:]
+
+	serverKey, err := ecdh.P256().NewPublicKey(serverPublic)
+	require.NoError(t, err)
+	sharedSecret, err := b.key.ECDH(serverKey)
+	require.NoError(t, err)
+
+	key, nonce := pushContentKeys(sharedSecret, b.auth, salt, b.key.PublicKey().Bytes(), serverPublic)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1], "last record delimiter")
+	return plaintext[:len(plaintext)-1]
+}
+
+func newVAPIDKey(t *testing.T) string {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(key.Bytes())
+}
+
+func TestWebPushProviderSend(t *testing.T) {
+	alice := newBrowser(t)
+	var body []byte
+	var headers http.Header
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	store := &memorySubscriptions{subs: map[string][]*model.PushSubscription{
+		"user-1": {alice.subscription(server.URL + "/push"), newBrowser(t).subscription(server.URL + "/gone")},
+	}}
+	provider, err := NewWebPushProvider(WebPushConfig{
+		VAPIDPrivateKey: newVAPIDKey(t),
+		Subject:         "mailto:ops@navo.io",
+	}, store)
+	require.NoError(t, err)
+	provider.client = server.Client()
+
+	delivery, err := provider.Send(context.Background(), &model.Notification{
+		ID:       "n-1",
+		UserID:   "user-1",
+		Type:     model.NotificationTypePush,
+		Priority: model.NotificationPriorityCritical,
+		Title:    "MV Aurora delayed",
+		Body:     "ETA moved by 12h",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.NotificationStatusSent, delivery.Status)
+
+	assert.Equal(t, "aes128gcm", headers.Get("Content-Encoding"))
+	assert.Equal(t, "high", headers.Get("Urgency"))
+	assert.Equal(t, "86400", headers.Get("TTL"))
+
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal(alice.decrypt(t, body), &payload))
+	assert.Equal(t, "n-1", payload["id"])
+	assert.Equal(t, "MV Aurora delayed", payload["title"])
+
+	subscriptions, _ := store.ListPushSubscriptions(context.Background(), "user-1")
+	require.Len(t, subscriptions, 1, "gone subscriptions are removed")
+	assert.Equal(t, server.URL+"/push", subscriptions[0].Endpoint)
+
+	// The VAPID token is signed with the provider's key for the push service
+	authorization := headers.Get("Authorization")
+	require.True(t, strings.HasPrefix(authorization, "vapid t="))
+	token := strings.TrimSuffix(strings.Split(strings.TrimPrefix(authorization, "vapid t="), ", k=")[0], ",")
+	assert.Equal(t, "k="+provider.PublicKey(), strings.Split(authorization, ", ")[1])
+	verifyVAPIDToken(t, provider, token, server.URL)
+}
+
+func verifyVAPIDToken(t *testing.T, provider *WebPushProvider, token, audience string) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.Len(t, signature, 64)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	assert.True(t, ecdsa.Verify(&provider.privateKey.PublicKey, digest[:], r, s))
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+	assert.Equal(t, audience, claims.Aud)
+	assert.Equal(t, "mailto:ops@navo.io", claims.Sub)
+	assert.WithinDuration(t, time.Now().Add(vapidTokenTTL), time.Unix(claims.Exp, 0), time.Minute)
+}
+
+func TestWebPushProviderKeys(t *testing.T) {
+	store := &memorySubscriptions{subs: map[string][]*model.PushSubscription{}}
+
+	_, err := NewWebPushProvider(WebPushConfig{VAPIDPrivateKey: "not-a-key"}, store)
+	assert.Error(t, err)
+
+	privateKey := newVAPIDKey(t)
+	provider, err := NewWebPushProvider(WebPushConfig{VAPIDPrivateKey: privateKey}, store)
+	require.NoError(t, err)
+
+	_, err = NewWebPushProvider(WebPushConfig{VAPIDPrivateKey: privateKey, VAPIDPublicKey: provider.PublicKey()}, store)
+	assert.NoError(t, err)
+	_, err = NewWebPushProvider(WebPushConfig{VAPIDPrivateKey: newVAPIDKey(t), VAPIDPublicKey: provider.PublicKey()}, store)
+	assert.Error(t, err, "mismatched key pair")
+
+	_, err = provider.Send(context.Background(), &model.Notification{UserID: "user-1"})
+	assert.ErrorContains(t, err, "no push subscriptions")
+}
+
+func TestValidateSubscription(t *testing.T) {
+	valid := newBrowser(t).subscription("https://fcm.googleapis.com/fcm/send/abc")
+	assert.NoError(t, ValidateSubscription(valid))
+
+	insecure := *valid
+	insecure.Endpoint = "http://push.example.com/abc"
+	assert.Error(t, ValidateSubscription(&insecure))
+
+	badKey := *valid
+	badKey.Keys.P256dh = base64.RawURLEncoding.EncodeToString(make([]byte, 65))
+	assert.Error(t, ValidateSubscription(&badKey))
+
+	badAuth := *valid
+	badAuth.Keys.Auth = "c2hvcnQ"
+	assert.Error(t, ValidateSubscription(&badAuth))
+}
@@ -15,6 +15,13 @@ type Config struct {
 	RedisPassword string
 	RedisDB       int
 
+	// Database (notifications, preferences and template overrides)
+	DatabaseURL string
+
+	// Default number of days notifications are kept, for organizations
+	// without a retention of their own
+	RetentionDays int
+
 	// SMTP
 	SMTPHost     string
 	SMTPPort     int
@@ -23,6 +30,26 @@ type Config struct {
 	FromAddress  string
 	FromName     string
 
+	// SMS gateway
+	SMSGatewayURL      string
+	SMSGatewayAPIKey   string
+	SMSSenderID        string
+	SMSCallbackURL     string
+	SMSCallbackSecret  string
+	SMSRateLimitPerMin int
+
+	// Slack and Microsoft Teams incoming webhooks
+	SlackWebhookURL      string
+	SlackRateLimitPerMin int
+	TeamsWebhookURL      string
+	TeamsRateLimitPerMin int
+
+	// Web Push (VAPID)
+	VAPIDPublicKey         string
+	VAPIDPrivateKey        string
+	VAPIDSubject           string
+	WebPushRateLimitPerMin int
+
 	// Rate Limiting
 	RateLimitPerMinute int
 
@@ -40,6 +67,9 @@ func Load() *Config {
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvInt("REDIS_DB", 0),
 
+		DatabaseURL:   getEnv("DATABASE_URL", ""),
+		RetentionDays: getEnvInt("NOTIFICATION_RETENTION_DAYS", 90),
+
 		SMTPHost:     getEnv("SMTP_HOST", ""),
 		SMTPPort:     getEnvInt("SMTP_PORT", 587),
 		SMTPUsername: getEnv("SMTP_USERNAME", ""),
@@ -47,6 +77,23 @@ func Load() *Config {
 		FromAddress:  getEnv("FROM_ADDRESS", "noreply@navo.io"),
 		FromName:     getEnv("FROM_NAME", "Navo Maritime"),
 
+		SMSGatewayURL:      getEnv("SMS_GATEWAY_URL", ""),
+		SMSGatewayAPIKey:   getEnv("SMS_GATEWAY_API_KEY", ""),
+		SMSSenderID:        getEnv("SMS_SENDER_ID", "Navo"),
+		SMSCallbackURL:     getEnv("SMS_CALLBACK_URL", ""),
+		SMSCallbackSecret:  getEnv("SMS_CALLBACK_SECRET", ""),
+		SMSRateLimitPerMin: getEnvInt("SMS_RATE_LIMIT_PER_MINUTE", 60),
+
+		SlackWebhookURL:      getEnv("SLACK_WEBHOOK_URL", ""),
+		SlackRateLimitPerMin: getEnvInt("SLACK_RATE_LIMIT_PER_MINUTE", 60),
+		TeamsWebhookURL:      getEnv("TEAMS_WEBHOOK_URL", ""),
+		TeamsRateLimitPerMin: getEnvInt("TEAMS_RATE_LIMIT_PER_MINUTE", 60),
+
+		VAPIDPublicKey:         getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey:        getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:           getEnv("VAPID_SUBJECT", "mailto:noreply@navo.io"),
+		WebPushRateLimitPerMin: getEnvInt("WEBPUSH_RATE_LIMIT_PER_MINUTE", 600),
+
 		RateLimitPerMinute: getEnvInt("RATE_LIMIT_PER_MINUTE", 100),
 		MaxRetries:         getEnvInt("MAX_RETRIES", 3),
 		RetryInterval:      getEnvInt("RETRY_INTERVAL", 60),
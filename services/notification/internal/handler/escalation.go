@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/notification/internal/model"
+	"github.com/navo/services/notification/internal/repository"
+	"github.com/navo/services/notification/internal/service"
+)
+
+// Acknowledge records that the calling user has taken responsibility for one
+// of their notifications, stopping its escalation
+func (h *NotificationHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "Notification ID is required")
+		return
+	}
+
+	orgID := r.Header.Get("X-Organization-ID")
+	userID := r.Header.Get("X-User-ID")
+	if orgID == "" || userID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID and user ID are required")
+		return
+	}
+
+	notification, err := h.notificationService.Acknowledge(r.Context(), orgID, id, userID)
+	if err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notification)
+}
+
+// ListEscalationPolicies lists the escalation policies of the caller's organization
+func (h *NotificationHandler) ListEscalationPolicies(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	policies, err := h.notificationService.ListEscalationPolicies(r.Context(), orgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"items": policies})
+}
+
+// GetEscalationPolicy retrieves an escalation policy
+func (h *NotificationHandler) GetEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	policy, err := h.notificationService.GetEscalationPolicy(r.Context(), orgID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// CreateEscalationPolicy creates an escalation policy. It requires the admin
+// role.
+func (h *NotificationHandler) CreateEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	var policy model.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.notificationService.CreateEscalationPolicy(r.Context(), orgID, &policy); err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// UpdateEscalationPolicy replaces an escalation policy. It requires the
+// admin role.
+func (h *NotificationHandler) UpdateEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	var policy model.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.notificationService.UpdateEscalationPolicy(r.Context(), orgID, chi.URLParam(r, "id"), &policy); err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// DeleteEscalationPolicy deletes an escalation policy. It requires the admin
+// role.
+func (h *NotificationHandler) DeleteEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	if err := h.notificationService.DeleteEscalationPolicy(r.Context(), orgID, chi.URLParam(r, "id")); err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListEscalations lists the escalations of the caller's organization
+func (h *NotificationHandler) ListEscalations(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	limit := 20
+	offset := 0
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	escalations, err := h.notificationService.ListEscalations(r.Context(), orgID, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  escalations,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetEscalation retrieves an escalation
+func (h *NotificationHandler) GetEscalation(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	escalation, err := h.notificationService.GetEscalation(r.Context(), orgID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, escalation)
+}
+
+// AcknowledgeEscalation stops an escalation on behalf of the calling user
+func (h *NotificationHandler) AcknowledgeEscalation(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	userID := r.Header.Get("X-User-ID")
+	if orgID == "" || userID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID and user ID are required")
+		return
+	}
+
+	escalation, err := h.notificationService.AcknowledgeEscalation(r.Context(), orgID, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		writeEscalationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, escalation)
+}
+
+// writeEscalationError writes the response for an escalation service error
+func writeEscalationError(w http.ResponseWriter, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeError(w, http.StatusBadRequest, validationErr.Message)
+	case errors.Is(err, repository.ErrEscalationPolicyNotFound):
+		writeError(w, http.StatusNotFound, "Escalation policy not found")
+	case errors.Is(err, repository.ErrEscalationNotFound):
+		writeError(w, http.StatusNotFound, "Escalation not found")
+	case errors.Is(err, repository.ErrNotificationNotFound):
+		writeError(w, http.StatusNotFound, "Notification not found")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,10 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/notification/internal/channel"
 	"github.com/navo/services/notification/internal/model"
 	"github.com/navo/services/notification/internal/service"
 )
@@ -22,6 +27,58 @@ func NewNotificationHandler(notificationService *service.NotificationService) *N
 	}
 }
 
+// RegisterRoutes registers the notification routes. Each must have an entry
+// in Operations, which the tests check.
+func (h *NotificationHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", h.List)
+		r.Post("/", h.Send)
+		r.Post("/batch", h.SendBatch)
+		r.Get("/{id}", h.Get)
+		r.Get("/user/{userID}", h.GetByUser)
+		r.Get("/user/{userID}/preferences", h.GetPreferences)
+		r.Put("/user/{userID}/preferences", h.UpdatePreferences)
+		r.Get("/preferences", h.GetPreferences)
+		r.Put("/preferences", h.UpdatePreferences)
+		r.Post("/callbacks/{provider}", h.DeliveryCallback)
+		r.Get("/push/vapid-key", h.GetVAPIDKey)
+		r.Post("/push/subscriptions", h.SubscribePush)
+		r.Delete("/push/subscriptions", h.UnsubscribePush)
+		r.Get("/retention", h.GetRetention)
+		r.Put("/retention", h.UpdateRetention)
+		r.Put("/{id}/read", h.MarkAsRead)
+		r.Post("/{id}/acknowledge", h.Acknowledge)
+		r.Put("/user/{userID}/read-all", h.MarkAllAsRead)
+		r.Delete("/{id}", h.Delete)
+	})
+
+	r.Route("/escalation-policies", func(r chi.Router) {
+		r.Get("/", h.ListEscalationPolicies)
+		r.Post("/", h.CreateEscalationPolicy)
+		r.Get("/{id}", h.GetEscalationPolicy)
+		r.Put("/{id}", h.UpdateEscalationPolicy)
+		r.Delete("/{id}", h.DeleteEscalationPolicy)
+	})
+
+	r.Route("/escalations", func(r chi.Router) {
+		r.Get("/", h.ListEscalations)
+		r.Get("/{id}", h.GetEscalation)
+		r.Post("/{id}/acknowledge", h.AcknowledgeEscalation)
+	})
+
+	r.Route("/templates", func(r chi.Router) {
+		r.Get("/", h.ListTemplates)
+		r.Get("/overrides", h.ListTemplateOverrides)
+		r.Get("/{name}", h.GetTemplate)
+		r.Post("/{name}/preview", h.PreviewTemplate)
+		r.Put("/{name}/overrides", h.SaveTemplateOverride)
+		r.Delete("/{name}/overrides", h.DeleteTemplateOverride)
+		r.Post("/{name}/overrides/preview", h.PreviewTemplateOverride)
+		r.Get("/{name}/versions", h.ListTemplateVersions)
+		r.Post("/{name}/versions/{version}/restore", h.RestoreTemplateVersion)
+	})
+}
+
 // Send handles sending a single notification
 func (h *NotificationHandler) Send(w http.ResponseWriter, r *http.Request) {
 	var req model.SendNotificationRequest
@@ -57,15 +114,19 @@ func (h *NotificationHandler) SendBatch(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	notifications, err := h.notificationService.SendBatch(r.Context(), &req)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
-		return
+	results := h.notificationService.SendBatch(r.Context(), &req)
+	notifications := make([]*model.Notification, 0, len(results))
+	for _, result := range results {
+		if result.Notification != nil {
+			notifications = append(notifications, result.Notification)
+		}
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]any{
-		"sent":  len(notifications),
-		"items": notifications,
+		"sent":    len(notifications),
+		"failed":  len(results) - len(notifications),
+		"items":   notifications,
+		"results": results,
 	})
 }
 
@@ -86,6 +147,78 @@ func (h *NotificationHandler) Get(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, notification)
 }
 
+// List searches the notifications of the caller's organization. Without
+// the admin role, only the caller's own notifications are searched.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &model.NotificationFilter{
+		OrganizationID: orgID,
+		UserID:         query.Get("user_id"),
+		Category:       model.NotificationCategory(query.Get("category")),
+		Type:           model.NotificationType(query.Get("type")),
+		Status:         model.NotificationStatus(query.Get("status")),
+		EntityType:     query.Get("entity_type"),
+		EntityID:       query.Get("entity_id"),
+	}
+	if !isAdmin(r) {
+		if filter.UserID != "" && filter.UserID != r.Header.Get("X-User-ID") {
+			writeError(w, http.StatusForbidden, "admin role required")
+			return
+		}
+		filter.UserID = r.Header.Get("X-User-ID")
+		if filter.UserID == "" {
+			writeError(w, http.StatusBadRequest, "User ID is required")
+			return
+		}
+	}
+
+	var err error
+	if filter.From, err = timeParam(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.To, err = timeParam(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			filter.Limit = parsed
+		}
+	}
+
+	if o := query.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			filter.Offset = parsed
+		}
+	}
+
+	notifications, total, err := h.notificationService.Search(r.Context(), filter)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeError(w, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  notifications,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
 // GetByUser retrieves notifications for a user
 func (h *NotificationHandler) GetByUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userID")
@@ -170,53 +303,170 @@ func (h *NotificationHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// ListTemplates lists all available templates
-func (h *NotificationHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
-	templates := h.notificationService.ListTemplates()
-	writeJSON(w, http.StatusOK, templates)
+// GetPreferences retrieves a user's notification preferences
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prefs)
 }
 
-// GetTemplate retrieves a template by name
-func (h *NotificationHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
-	name := chi.URLParam(r, "name")
-	if name == "" {
-		writeError(w, http.StatusBadRequest, "Template name is required")
+// UpdatePreferences updates a user's notification preferences. Fields missing
+// from the request keep their current value.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "User ID is required")
 		return
 	}
 
-	template, err := h.notificationService.GetTemplate(name)
+	prefs, err := h.notificationService.GetPreferences(r.Context(), userID)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "Template not found")
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, template)
+	if err := json.NewDecoder(r.Body).Decode(prefs); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	prefs.UserID = userID
+
+	if err := h.notificationService.UpdatePreferences(r.Context(), prefs); err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeError(w, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prefs)
 }
 
-// PreviewTemplate previews a rendered template
-func (h *NotificationHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
-	name := chi.URLParam(r, "name")
-	if name == "" {
-		writeError(w, http.StatusBadRequest, "Template name is required")
+// DeliveryCallback receives delivery status reports from a channel provider
+func (h *NotificationHandler) DeliveryCallback(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+
+	updated, err := h.notificationService.HandleDeliveryCallback(r.Context(), provider, r)
+	if err != nil {
+		switch {
+		case errors.Is(err, channel.ErrUnknownProvider):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, channel.ErrInvalidSignature):
+			writeError(w, http.StatusUnauthorized, err.Error())
+		default:
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
 		return
 	}
 
-	var data map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		data = make(map[string]any)
+	writeJSON(w, http.StatusOK, map[string]int{"updated": updated})
+}
+
+// GetVAPIDKey returns the public key browsers subscribe to push with
+func (h *NotificationHandler) GetVAPIDKey(w http.ResponseWriter, r *http.Request) {
+	key := h.notificationService.VAPIDPublicKey()
+	if key == "" {
+		writeError(w, http.StatusNotFound, "Web Push is not configured")
+		return
 	}
 
-	emailMsg, err := h.notificationService.PreviewTemplate(name, data)
-	if err != nil {
+	writeJSON(w, http.StatusOK, map[string]string{"public_key": key})
+}
+
+// SubscribePush registers the calling browser for push notifications
+func (h *NotificationHandler) SubscribePush(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	var subscription model.PushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	subscription.UserAgent = r.UserAgent()
+
+	if err := h.notificationService.SavePushSubscription(r.Context(), userID, &subscription); err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeError(w, http.StatusBadRequest, validationErr.Message)
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"subject":   emailMsg.Subject,
-		"html_body": emailMsg.HTMLBody,
-		"text_body": emailMsg.TextBody,
-	})
+	writeJSON(w, http.StatusCreated, subscription)
+}
+
+// UnsubscribePush unregisters a browser from push notifications
+func (h *NotificationHandler) UnsubscribePush(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Endpoint == "" {
+		writeError(w, http.StatusBadRequest, "Endpoint is required")
+		return
+	}
+
+	if err := h.notificationService.DeletePushSubscription(r.Context(), userID, req.Endpoint); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestUserID returns the user a request is about: the user in the path,
+// or the calling user forwarded by the gateway
+func requestUserID(r *http.Request) string {
+	if userID := chi.URLParam(r, "userID"); userID != "" {
+		return userID
+	}
+	return r.Header.Get("X-User-ID")
+}
+
+// timeParam parses an optional RFC 3339 query parameter
+func timeParam(r *http.Request, name string) (*time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected an RFC 3339 time", name)
+	}
+	return &t, nil
+}
+
+// isAdmin reports whether the caller has the admin role forwarded by the gateway
+func isAdmin(r *http.Request) bool {
+	for _, role := range strings.Split(r.Header.Get("X-User-Roles"), ",") {
+		if strings.TrimSpace(role) == "admin" {
+			return true
+		}
+	}
+	return false
 }
 
 // Helper functions
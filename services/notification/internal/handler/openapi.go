@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/notification/internal/model"
+)
+
+var (
+	notificationTypes      = []string{"email", "push", "in_app", "sms", "slack", "teams"}
+	notificationCategories = []string{"port_call", "service_order", "rfq", "vessel", "document", "system", "approval"}
+	notificationStatuses   = []string{"pending", "queued", "sending", "sent", "delivered", "failed", "read", "batched", "suppressed"}
+)
+
+// offsetPaged returns the query parameters of a list paginated by limit and
+// offset, then params
+func offsetPaged(params ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{
+		{Name: "limit", Type: "integer", Description: "Items to return, 20 by default"},
+		{Name: "offset", Type: "integer", Description: "Items to skip"},
+	}, params...)
+}
+
+var localeParam = openapi.Param{Name: "locale", Description: "Locale of the template, the default locale if not given"}
+
+// templatePreview is the body of template preview responses
+type templatePreview struct {
+	Subject  string         `json:"subject"`
+	HTMLBody string         `json:"html_body"`
+	TextBody string         `json:"text_body"`
+	Locale   string         `json:"locale"`
+	Version  int            `json:"version"`
+	Data     map[string]any `json:"data"`
+}
+
+// Operations describe what the notification service's handlers read and
+// write, for its OpenAPI document and request validation
+var Operations = openapi.Operations{
+	// Notifications
+	"GET /api/v1/notifications": {
+		Summary:     "Search notifications",
+		Description: "Searches the caller's organization's notifications. Without the admin role, only the caller's own are searched.",
+		Query: offsetPaged(
+			openapi.Param{Name: "user_id"},
+			openapi.Param{Name: "category", Enum: notificationCategories},
+			openapi.Param{Name: "type", Enum: notificationTypes},
+			openapi.Param{Name: "status", Enum: notificationStatuses},
+			openapi.Param{Name: "entity_type"},
+			openapi.Param{Name: "entity_id"},
+			openapi.Param{Name: "from", Description: "RFC 3339 timestamp"},
+			openapi.Param{Name: "to", Description: "RFC 3339 timestamp"},
+		),
+		Response: struct {
+			Items  []model.Notification `json:"items"`
+			Total  int                  `json:"total"`
+			Limit  int                  `json:"limit"`
+			Offset int                  `json:"offset"`
+		}{},
+	},
+	"POST /api/v1/notifications": {
+		Summary:  "Send a notification",
+		Request:  model.SendNotificationRequest{},
+		Response: model.Notification{},
+		Status:   http.StatusCreated,
+	},
+	"POST /api/v1/notifications/batch": {
+		Summary:     "Send up to 100 notifications",
+		Description: "Notifications are sent independently. results has the outcome of each request, at its index; items has the notifications sent.",
+		Request:     model.BatchNotificationRequest{},
+		Response: struct {
+			Sent    int                             `json:"sent"`
+			Failed  int                             `json:"failed"`
+			Items   []model.Notification            `json:"items"`
+			Results []model.BatchNotificationResult `json:"results"`
+		}{},
+		Status: http.StatusCreated,
+	},
+	"GET /api/v1/notifications/{id}": {
+		Summary:  "Get a notification",
+		Response: model.Notification{},
+	},
+	"DELETE /api/v1/notifications/{id}": {
+		Summary: "Delete a notification",
+		Status:  http.StatusNoContent,
+	},
+	"PUT /api/v1/notifications/{id}/read": {
+		Summary: "Mark a notification as read",
+	},
+	"POST /api/v1/notifications/{id}/acknowledge": {
+		Summary:     "Acknowledge a notification, stopping its escalation",
+		Description: "Only the notification's recipient can acknowledge it.",
+		Tags:        []string{"escalations"},
+		Response:    model.Notification{},
+	},
+	"GET /api/v1/notifications/user/{userID}": {
+		Summary: "List a user's notifications",
+		Query:   offsetPaged(),
+		Response: struct {
+			Items  []model.Notification `json:"items"`
+			Limit  int                  `json:"limit"`
+			Offset int                  `json:"offset"`
+		}{},
+	},
+	"PUT /api/v1/notifications/user/{userID}/read-all": {
+		Summary: "Mark all of a user's notifications as read",
+	},
+	"POST /api/v1/notifications/callbacks/{provider}": {
+		Summary:     "Report delivery statuses",
+		Description: "Called by SMS, push and webhook providers, with a body and signature in the provider's format.",
+		Response:    map[string]int{},
+	},
+
+	// Preferences
+	"GET /api/v1/notifications/preferences": {
+		Summary:  "Get the caller's notification preferences",
+		Tags:     []string{"preferences"},
+		Response: model.UserNotificationPreferences{},
+	},
+	"PUT /api/v1/notifications/preferences": {
+		Summary:     "Update the caller's notification preferences",
+		Description: "Fields missing from the request keep their current value.",
+		Tags:        []string{"preferences"},
+		Request:     model.UserNotificationPreferences{},
+		Response:    model.UserNotificationPreferences{},
+	},
+	"GET /api/v1/notifications/user/{userID}/preferences": {
+		Summary:  "Get a user's notification preferences",
+		Tags:     []string{"preferences"},
+		Response: model.UserNotificationPreferences{},
+	},
+	"PUT /api/v1/notifications/user/{userID}/preferences": {
+		Summary:     "Update a user's notification preferences",
+		Description: "Fields missing from the request keep their current value.",
+		Tags:        []string{"preferences"},
+		Request:     model.UserNotificationPreferences{},
+		Response:    model.UserNotificationPreferences{},
+	},
+
+	// Web Push
+	"GET /api/v1/notifications/push/vapid-key": {
+		Summary:  "Get the public key browsers subscribe to push with",
+		Tags:     []string{"push"},
+		Response: map[string]string{},
+	},
+	"POST /api/v1/notifications/push/subscriptions": {
+		Summary:  "Subscribe the calling browser to push notifications",
+		Tags:     []string{"push"},
+		Request:  model.PushSubscription{},
+		Response: model.PushSubscription{},
+		Status:   http.StatusCreated,
+	},
+	"DELETE /api/v1/notifications/push/subscriptions": {
+		Summary: "Unsubscribe a browser from push notifications",
+		Tags:    []string{"push"},
+		Request: struct {
+			Endpoint string `json:"endpoint" validate:"required"`
+		}{},
+		Status: http.StatusNoContent,
+	},
+
+	// Retention
+	"GET /api/v1/notifications/retention": {
+		Summary:  "Get how long the caller's organization's notifications are kept",
+		Tags:     []string{"retention"},
+		Response: model.NotificationRetention{},
+	},
+	"PUT /api/v1/notifications/retention": {
+		Summary:  "Set how long the caller's organization's notifications are kept",
+		Tags:     []string{"retention"},
+		Request:  model.NotificationRetention{},
+		Response: model.NotificationRetention{},
+	},
+
+	// Escalations
+	"GET /api/v1/escalation-policies": {
+		Summary: "List escalation policies",
+		Response: struct {
+			Items []model.EscalationPolicy `json:"items"`
+		}{},
+	},
+	"POST /api/v1/escalation-policies": {
+		Summary:     "Create an escalation policy",
+		Description: "Requires the admin role.",
+		Request:     model.EscalationPolicy{},
+		Response:    model.EscalationPolicy{},
+		Status:      http.StatusCreated,
+	},
+	"GET /api/v1/escalation-policies/{id}": {
+		Summary:  "Get an escalation policy",
+		Response: model.EscalationPolicy{},
+	},
+	"PUT /api/v1/escalation-policies/{id}": {
+		Summary:     "Update an escalation policy",
+		Description: "Requires the admin role.",
+		Request:     model.EscalationPolicy{},
+		Response:    model.EscalationPolicy{},
+	},
+	"DELETE /api/v1/escalation-policies/{id}": {
+		Summary:     "Delete an escalation policy",
+		Description: "Requires the admin role.",
+		Status:      http.StatusNoContent,
+	},
+	"GET /api/v1/escalations": {
+		Summary: "List escalations",
+		Query:   offsetPaged(),
+		Response: struct {
+			Items  []model.Escalation `json:"items"`
+			Limit  int                `json:"limit"`
+			Offset int                `json:"offset"`
+		}{},
+	},
+	"GET /api/v1/escalations/{id}": {
+		Summary:  "Get an escalation",
+		Response: model.Escalation{},
+	},
+	"POST /api/v1/escalations/{id}/acknowledge": {
+		Summary:  "Acknowledge an escalation",
+		Response: model.Escalation{},
+	},
+
+	// Templates
+	"GET /api/v1/templates": {
+		Summary:  "List the default templates",
+		Response: []model.Template{},
+	},
+	"GET /api/v1/templates/overrides": {
+		Summary: "List the caller's organization's template overrides",
+		Response: struct {
+			Items []model.Template `json:"items"`
+		}{},
+	},
+	"GET /api/v1/templates/{name}": {
+		Summary:  "Get a template as the caller's organization sends it",
+		Query:    []openapi.Param{localeParam},
+		Response: model.Template{},
+	},
+	"POST /api/v1/templates/{name}/preview": {
+		Summary:     "Render a template",
+		Description: "Renders against sample data, overridden by the data in the request body, if any.",
+		Query:       []openapi.Param{localeParam},
+		Response:    templatePreview{},
+	},
+	"PUT /api/v1/templates/{name}/overrides": {
+		Summary:     "Save a new version of the caller's organization's override of a template",
+		Description: "Requires the admin role.",
+		Request:     model.Template{},
+		Response:    model.Template{},
+		Status:      http.StatusCreated,
+	},
+	"DELETE /api/v1/templates/{name}/overrides": {
+		Summary:     "Revert a template to the default",
+		Description: "Requires the admin role.",
+		Query:       []openapi.Param{localeParam},
+		Status:      http.StatusNoContent,
+	},
+	"POST /api/v1/templates/{name}/overrides/preview": {
+		Summary: "Render a draft override before it is saved",
+		Request: struct {
+			model.Template
+			Data map[string]any `json:"data"`
+		}{},
+		Response: templatePreview{},
+	},
+	"GET /api/v1/templates/{name}/versions": {
+		Summary: "List the versions of an override",
+		Query:   []openapi.Param{localeParam},
+		Response: struct {
+			Items []model.Template `json:"items"`
+		}{},
+	},
+	"POST /api/v1/templates/{name}/versions/{version}/restore": {
+		Summary:     "Make an earlier version of an override current",
+		Description: "Requires the admin role.",
+		Query:       []openapi.Param{localeParam},
+		Response:    model.Template{},
+		Status:      http.StatusCreated,
+	},
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOperations_MatchRoutes fails on routes without an operation, which
+// would skip request validation, and on operations no route serves
+func TestOperations_MatchRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	r.Route("/api/v1", (&NotificationHandler{}).RegisterRoutes)
+
+	api := openapi.New(openapi.Config{Title: "Navo Notification Service"}, Operations)
+	require.NoError(t, chi.Walk(r, api.Walk))
+	undocumented, unrouted := api.Unmatched()
+	assert.Empty(t, undocumented, "routes missing from Operations")
+	assert.Empty(t, unrouted, "operations without a route")
+}
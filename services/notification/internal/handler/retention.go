@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/navo/services/notification/internal/model"
+	"github.com/navo/services/notification/internal/service"
+)
+
+// GetRetention returns how long the caller's organization's notifications are kept
+func (h *NotificationHandler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	retention, err := h.notificationService.GetRetention(r.Context(), orgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, retention)
+}
+
+// UpdateRetention sets how long the caller's organization's notifications
+// are kept. It requires the admin role.
+func (h *NotificationHandler) UpdateRetention(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	var retention model.NotificationRetention
+	if err := json.NewDecoder(r.Body).Decode(&retention); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	retention.OrganizationID = orgID
+
+	if err := h.notificationService.UpdateRetention(r.Context(), &retention); err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeError(w, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, retention)
+}
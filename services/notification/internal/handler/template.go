@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/notification/internal/model"
+	"github.com/navo/services/notification/internal/repository"
+	"github.com/navo/services/notification/internal/service"
+)
+
+// ListTemplates lists all available templates
+func (h *NotificationHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := h.notificationService.ListTemplates()
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// ListTemplateOverrides lists the caller's organization's template overrides
+func (h *NotificationHandler) ListTemplateOverrides(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	templates, err := h.notificationService.ListTemplateOverrides(r.Context(), orgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"items": templates})
+}
+
+// GetTemplate retrieves a template by name, as the caller's organization
+// sends it in the requested locale
+func (h *NotificationHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Template name is required")
+		return
+	}
+
+	template, err := h.notificationService.GetTemplate(r.Context(), r.Header.Get("X-Organization-ID"), name, r.URL.Query().Get("locale"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+// PreviewTemplate renders a template against sample data. The request body
+// holds data overriding the samples.
+func (h *NotificationHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Template name is required")
+		return
+	}
+
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		data = make(map[string]any)
+	}
+
+	preview, err := h.notificationService.PreviewTemplate(r.Context(), r.Header.Get("X-Organization-ID"), name, r.URL.Query().Get("locale"), data, nil)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writePreview(w, preview)
+}
+
+// PreviewTemplateOverride renders a draft override before it is saved
+func (h *NotificationHandler) PreviewTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		model.Template
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preview, err := h.notificationService.PreviewTemplate(r.Context(), r.Header.Get("X-Organization-ID"), chi.URLParam(r, "name"), req.Locale, req.Data, &req.Template)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writePreview(w, preview)
+}
+
+// SaveTemplateOverride stores a new version of the caller's organization's
+// override of a template. It requires the admin role.
+func (h *NotificationHandler) SaveTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	var template model.Template
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.notificationService.SaveTemplateOverride(r.Context(), orgID, r.Header.Get("X-User-ID"), chi.URLParam(r, "name"), &template); err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, template)
+}
+
+// DeleteTemplateOverride reverts a template of the caller's organization to
+// the default in the requested locale. It requires the admin role.
+func (h *NotificationHandler) DeleteTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	if err := h.notificationService.DeleteTemplateOverride(r.Context(), orgID, r.Header.Get("X-User-ID"), chi.URLParam(r, "name"), r.URL.Query().Get("locale")); err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTemplateVersions lists the versions of an override in the requested locale
+func (h *NotificationHandler) ListTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+
+	versions, err := h.notificationService.ListTemplateVersions(r.Context(), orgID, chi.URLParam(r, "name"), r.URL.Query().Get("locale"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"items": versions})
+}
+
+// RestoreTemplateVersion makes an earlier version of an override current.
+// It requires the admin role.
+func (h *NotificationHandler) RestoreTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	orgID := r.Header.Get("X-Organization-ID")
+	if orgID == "" {
+		writeError(w, http.StatusBadRequest, "Organization ID is required")
+		return
+	}
+	if !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		writeError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	template, err := h.notificationService.RestoreTemplateVersion(r.Context(), orgID, r.Header.Get("X-User-ID"), chi.URLParam(r, "name"), r.URL.Query().Get("locale"), version)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, template)
+}
+
+func writePreview(w http.ResponseWriter, preview *service.TemplatePreview) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"subject":   preview.Message.Subject,
+		"html_body": preview.Message.HTMLBody,
+		"text_body": preview.Message.TextBody,
+		"locale":    preview.Template.Locale,
+		"version":   preview.Template.Version,
+		"data":      preview.Data,
+	})
+}
+
+// writeTemplateError writes the response for a template service error
+func writeTemplateError(w http.ResponseWriter, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeError(w, http.StatusBadRequest, validationErr.Message)
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		writeError(w, http.StatusNotFound, "Template not found")
+	case errors.Is(err, repository.ErrTemplateStoreUnavailable):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Escalation triggers raised by the notification worker
+const (
+	EscalationTriggerArrivedWithoutBerth = "port_call.arrived_without_berth"
+	EscalationTriggerRFQNoQuotes         = "rfq.no_quotes_before_deadline"
+	// EscalationTriggerAny matches every trigger without a policy of its own
+	EscalationTriggerAny = "*"
+)
+
+// EscalationRoleAssignee targets the recipient of the original notification
+const EscalationRoleAssignee = "assignee"
+
+// maxEscalationSteps limits the length of an escalation chain
+const maxEscalationSteps = 10
+
+// EscalationPolicy describes who is notified, and how, while a critical
+// notification stays unacknowledged
+type EscalationPolicy struct {
+	ID             string           `json:"id"`
+	OrganizationID string           `json:"organization_id"`
+	Name           string           `json:"name"`
+	Trigger        string           `json:"trigger"`
+	Enabled        bool             `json:"enabled"`
+	Steps          []EscalationStep `json:"steps"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// EscalationStep notifies recipients once the previous notification of the
+// chain went unacknowledged for AfterMinutes
+type EscalationStep struct {
+	AfterMinutes int                   `json:"after_minutes"`
+	Recipients   []EscalationRecipient `json:"recipients"`
+	Channels     []NotificationType    `json:"channels"`
+}
+
+// EscalationRecipient is a person notified by an escalation step, either a
+// fixed contact or the assignee of the original notification
+type EscalationRecipient struct {
+	Role   string `json:"role,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Phone  string `json:"phone,omitempty"`
+}
+
+// Validate checks the policy for invalid values
+func (p *EscalationPolicy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Trigger == "" {
+		return fmt.Errorf("trigger is required")
+	}
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	if len(p.Steps) > maxEscalationSteps {
+		return fmt.Errorf("at most %d steps are allowed", maxEscalationSteps)
+	}
+
+	for i, step := range p.Steps {
+		if step.AfterMinutes < 1 || step.AfterMinutes > 24*60 {
+			return fmt.Errorf("step %d: after_minutes must be between 1 and 1440", i+1)
+		}
+		if len(step.Recipients) == 0 {
+			return fmt.Errorf("step %d: at least one recipient is required", i+1)
+		}
+		if len(step.Channels) == 0 {
+			return fmt.Errorf("step %d: at least one channel is required", i+1)
+		}
+		for _, channel := range step.Channels {
+			switch channel {
+			case NotificationTypeEmail, NotificationTypeSMS, NotificationTypePush,
+				NotificationTypeInApp, NotificationTypeSlack, NotificationTypeTeams:
+			default:
+				return fmt.Errorf("step %d: invalid channel: %s", i+1, channel)
+			}
+		}
+		for _, recipient := range step.Recipients {
+			if recipient.Role != "" && recipient.Role != EscalationRoleAssignee {
+				return fmt.Errorf("step %d: invalid recipient role: %s", i+1, recipient.Role)
+			}
+			if recipient.Role == "" && recipient.UserID == "" {
+				return fmt.Errorf("step %d: recipients need a user_id or the assignee role", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// EscalationStatus represents the state of an escalation
+type EscalationStatus string
+
+const (
+	EscalationStatusPending      EscalationStatus = "pending"
+	EscalationStatusAcknowledged EscalationStatus = "acknowledged"
+	EscalationStatusExhausted    EscalationStatus = "exhausted" // every step ran unacknowledged
+)
+
+// Escalation tracks one notification escalating through a policy's steps.
+// The steps are copied from the policy when the escalation starts.
+type Escalation struct {
+	ID             string              `json:"id"`
+	PolicyID       string              `json:"policy_id"`
+	OrganizationID string              `json:"organization_id"`
+	Trigger        string              `json:"trigger"`
+	NotificationID string              `json:"notification_id"`
+	Status         EscalationStatus    `json:"status"`
+	Steps          []EscalationStep    `json:"steps"`
+	StepsDone      int                 `json:"steps_done"`
+	NextAt         *time.Time          `json:"next_at,omitempty"`
+	Assignee       EscalationRecipient `json:"assignee"`
+
+	// Content of the escalated notification
+	Category   NotificationCategory `json:"category"`
+	Title      string               `json:"title"`
+	Body       string               `json:"body"`
+	ActionURL  string               `json:"action_url,omitempty"`
+	EntityType string               `json:"entity_type,omitempty"`
+	EntityID   string               `json:"entity_id,omitempty"`
+
+	// Every notification sent for the escalation, the original first
+	NotificationIDs []string `json:"notification_ids"`
+
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
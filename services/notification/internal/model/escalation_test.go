@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscalationPolicyValidate(t *testing.T) {
+	policy := &EscalationPolicy{
+		Name:    "Arrival without berth",
+		Trigger: EscalationTriggerArrivedWithoutBerth,
+		Steps: []EscalationStep{
+			{
+				AfterMinutes: 15,
+				Recipients:   []EscalationRecipient{{UserID: "lead-1", Phone: "+6591234567"}},
+				Channels:     []NotificationType{NotificationTypeSMS},
+			},
+			{
+				AfterMinutes: 15,
+				Recipients:   []EscalationRecipient{{Role: EscalationRoleAssignee}, {UserID: "duty-1"}},
+				Channels:     []NotificationType{NotificationTypeEmail, NotificationTypePush},
+			},
+		},
+	}
+	require.NoError(t, policy.Validate())
+
+	invalid := *policy
+	invalid.Steps = nil
+	assert.ErrorContains(t, invalid.Validate(), "at least one step")
+
+	step := policy.Steps[0]
+	step.AfterMinutes = 0
+	invalid.Steps = []EscalationStep{step}
+	assert.ErrorContains(t, invalid.Validate(), "after_minutes")
+
+	step = policy.Steps[0]
+	step.Channels = []NotificationType{"pager"}
+	invalid.Steps = []EscalationStep{step}
+	assert.ErrorContains(t, invalid.Validate(), "invalid channel")
+
+	step = policy.Steps[0]
+	step.Recipients = []EscalationRecipient{{Name: "Nobody"}}
+	invalid.Steps = []EscalationStep{step}
+	assert.ErrorContains(t, invalid.Validate(), "user_id or the assignee role")
+
+	step = policy.Steps[0]
+	step.Recipients = []EscalationRecipient{{Role: "manager"}}
+	invalid.Steps = []EscalationStep{step}
+	assert.ErrorContains(t, invalid.Validate(), "invalid recipient role")
+}
@@ -14,6 +14,7 @@ const (
 	NotificationTypeInApp  NotificationType = "in_app"
 	NotificationTypeSMS    NotificationType = "sms"
 	NotificationTypeSlack  NotificationType = "slack"
+	NotificationTypeTeams  NotificationType = "teams"
 )
 
 // NotificationStatus represents the status of a notification
@@ -27,6 +28,8 @@ const (
 	NotificationStatusDelivered NotificationStatus = "delivered"
 	NotificationStatusFailed    NotificationStatus = "failed"
 	NotificationStatusRead      NotificationStatus = "read"
+	NotificationStatusBatched   NotificationStatus = "batched"    // waiting for the user's digest
+	NotificationStatusSuppressed NotificationStatus = "suppressed" // opted out by the user
 )
 
 // NotificationPriority represents the priority level
@@ -66,6 +69,8 @@ type Notification struct {
 	WorkspaceID    string   `json:"workspace_id,omitempty"`
 	Email          string   `json:"email,omitempty"`
 	Phone          string   `json:"phone,omitempty"`
+	Target         string   `json:"target,omitempty"` // Slack/Teams webhook URL, defaults to the provider's
+	Locale         string   `json:"locale,omitempty"` // Template language, defaults to the recipient's
 
 	// Content
 	Subject        string            `json:"subject"`
@@ -81,6 +86,11 @@ type Notification struct {
 	ActionURL      string            `json:"action_url,omitempty"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
 
+	// Escalation
+	EscalationID   string     `json:"escalation_id,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+
 	// Tracking
 	ReadAt         *time.Time `json:"read_at,omitempty"`
 	SentAt         *time.Time `json:"sent_at,omitempty"`
@@ -88,6 +98,8 @@ type Notification struct {
 	FailedAt       *time.Time `json:"failed_at,omitempty"`
 	FailureReason  string     `json:"failure_reason,omitempty"`
 	RetryCount     int        `json:"retry_count"`
+	Provider          string  `json:"provider,omitempty"`
+	ProviderMessageID string  `json:"provider_message_id,omitempty"`
 
 	// Timestamps
 	CreatedAt      time.Time  `json:"created_at"`
@@ -102,7 +114,11 @@ type SendNotificationRequest struct {
 	Category     NotificationCategory `json:"category" validate:"required"`
 	Priority     NotificationPriority `json:"priority"`
 	UserID       string               `json:"user_id" validate:"required"`
+	OrganizationID string             `json:"organization_id,omitempty"`
 	Email        string               `json:"email,omitempty"`
+	Phone        string               `json:"phone,omitempty"`
+	Target       string               `json:"target,omitempty"`
+	Locale       string               `json:"locale,omitempty"`
 	Subject      string               `json:"subject,omitempty"`
 	Title        string               `json:"title" validate:"required"`
 	Body         string               `json:"body" validate:"required"`
@@ -113,6 +129,12 @@ type SendNotificationRequest struct {
 	ActionURL    string               `json:"action_url,omitempty"`
 	Metadata     map[string]string    `json:"metadata,omitempty"`
 	ScheduledFor *time.Time           `json:"scheduled_for,omitempty"`
+
+	// Escalation is the trigger of the organization's escalation policy to
+	// start for the notification
+	Escalation   string               `json:"escalation,omitempty"`
+	// EscalationID is set on notifications sent by an escalation step
+	EscalationID string               `json:"-"`
 }
 
 // BatchNotificationRequest represents a batch notification request
@@ -120,6 +142,13 @@ type BatchNotificationRequest struct {
 	Notifications []SendNotificationRequest `json:"notifications" validate:"required,min=1,max=100"`
 }
 
+// BatchNotificationResult is the outcome of one notification of a batch,
+// at the index of its request
+type BatchNotificationResult struct {
+	Notification *Notification `json:"notification,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
 // NotificationEvent represents an event published to Redis
 type NotificationEvent struct {
 	Type           string         `json:"type"`
@@ -152,7 +181,8 @@ type EmailAttachment struct {
 	Content     []byte `json:"content"`
 }
 
-// Template represents an email template
+// Template represents an email template. Built-in templates have no
+// organization, locale or version; stored templates override them.
 type Template struct {
 	Name        string            `json:"name"`
 	Subject     string            `json:"subject"`
@@ -161,6 +191,14 @@ type Template struct {
 	Category    NotificationCategory `json:"category"`
 	Variables   []TemplateVariable `json:"variables"`
 	Description string            `json:"description"`
+
+	// Overrides
+	OrganizationID string     `json:"organization_id,omitempty"`
+	Locale         string     `json:"locale,omitempty"`
+	Version        int        `json:"version,omitempty"`
+	Deleted        bool       `json:"deleted,omitempty"` // Reverts to the default from this version on
+	CreatedBy      string     `json:"created_by,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
 }
 
 // TemplateVariable represents a variable in a template
@@ -169,6 +207,7 @@ type TemplateVariable struct {
 	Description string `json:"description"`
 	Required    bool   `json:"required"`
 	Default     string `json:"default,omitempty"`
+	Example     string `json:"example,omitempty"` // Used to preview the template
 }
 
 // MarshalJSON implements json.Marshaler
@@ -192,4 +231,8 @@ type UserNotificationPreferences struct {
 	QuietHoursStart *int                          `json:"quiet_hours_start,omitempty"` // 0-23
 	QuietHoursEnd   *int                          `json:"quiet_hours_end,omitempty"`   // 0-23
 	Timezone       string                        `json:"timezone"`
+	Locale          string                       `json:"locale,omitempty"` // Language of templated emails, e.g. "pt-BR"
+	DigestFrequency DigestFrequency              `json:"digest_frequency"`
+	DigestHour      int                          `json:"digest_hour"` // 0-23, local hour of the daily digest
+	UpdatedAt       time.Time                    `json:"updated_at"`
 }
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DigestFrequency controls how low-priority email notifications are batched
+type DigestFrequency string
+
+const (
+	DigestNone   DigestFrequency = "none"
+	DigestHourly DigestFrequency = "hourly"
+	DigestDaily  DigestFrequency = "daily"
+)
+
+// DefaultDigestHour is the local hour daily digests are sent at
+const DefaultDigestHour = 8
+
+// IsValid reports whether the frequency is known
+func (f DigestFrequency) IsValid() bool {
+	switch f {
+	case DigestNone, DigestHourly, DigestDaily:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the category is known
+func (c NotificationCategory) IsValid() bool {
+	switch c {
+	case CategoryPortCall, CategoryServiceOrder, CategoryRFQ, CategoryVessel,
+		CategoryDocument, CategorySystem, CategoryApproval:
+		return true
+	}
+	return false
+}
+
+// DefaultNotificationPreferences returns the preferences of a user who never
+// changed them: every channel and category enabled, no quiet hours, no digest
+func DefaultNotificationPreferences(userID string) *UserNotificationPreferences {
+	return &UserNotificationPreferences{
+		UserID:          userID,
+		EmailEnabled:    true,
+		PushEnabled:     true,
+		InAppEnabled:    true,
+		SMSEnabled:      true,
+		CategoryPrefs:   make(map[NotificationCategory]bool),
+		Timezone:        "UTC",
+		DigestFrequency: DigestNone,
+		DigestHour:      DefaultDigestHour,
+	}
+}
+
+// Validate checks the preferences for invalid values
+func (p *UserNotificationPreferences) Validate() error {
+	if (p.QuietHoursStart == nil) != (p.QuietHoursEnd == nil) {
+		return fmt.Errorf("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if p.QuietHoursStart != nil && (*p.QuietHoursStart < 0 || *p.QuietHoursStart > 23) {
+		return fmt.Errorf("quiet_hours_start must be between 0 and 23")
+	}
+	if p.QuietHoursEnd != nil && (*p.QuietHoursEnd < 0 || *p.QuietHoursEnd > 23) {
+		return fmt.Errorf("quiet_hours_end must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", p.Timezone)
+	}
+	if p.Locale != "" && NormalizeLocale(p.Locale) == "" {
+		return fmt.Errorf("invalid locale: %s", p.Locale)
+	}
+	if !p.DigestFrequency.IsValid() {
+		return fmt.Errorf("invalid digest_frequency: %s", p.DigestFrequency)
+	}
+	if p.DigestHour < 0 || p.DigestHour > 23 {
+		return fmt.Errorf("digest_hour must be between 0 and 23")
+	}
+	for category := range p.CategoryPrefs {
+		if !category.IsValid() {
+			return fmt.Errorf("invalid category: %s", category)
+		}
+	}
+	return nil
+}
+
+// ChannelEnabled reports whether the user accepts notifications of the type.
+// Types without a toggle are always enabled.
+func (p *UserNotificationPreferences) ChannelEnabled(t NotificationType) bool {
+	switch t {
+	case NotificationTypeEmail:
+		return p.EmailEnabled
+	case NotificationTypePush:
+		return p.PushEnabled
+	case NotificationTypeInApp:
+		return p.InAppEnabled
+	case NotificationTypeSMS:
+		return p.SMSEnabled
+	}
+	return true
+}
+
+// CategoryEnabled reports whether the user accepts notifications of the
+// category. Categories are enabled unless explicitly turned off.
+func (p *UserNotificationPreferences) CategoryEnabled(c NotificationCategory) bool {
+	enabled, ok := p.CategoryPrefs[c]
+	return !ok || enabled
+}
+
+// OptOutReason returns why the notification must not be sent to the user, or
+// "" when it may be sent. Critical notifications ignore category opt-outs but
+// are never sent over a channel the user turned off.
+func (p *UserNotificationPreferences) OptOutReason(n *Notification) string {
+	if !p.ChannelEnabled(n.Type) {
+		return fmt.Sprintf("%s notifications disabled by user", n.Type)
+	}
+	if n.Priority != NotificationPriorityCritical && !p.CategoryEnabled(n.Category) {
+		return fmt.Sprintf("%s notifications disabled by user", n.Category)
+	}
+	return ""
+}
+
+// Batches reports whether the notification goes into the user's digest
+// instead of being sent on its own. Only low-priority emails are batched.
+func (p *UserNotificationPreferences) Batches(n *Notification) bool {
+	return p.DigestFrequency != "" && p.DigestFrequency != DigestNone &&
+		n.Type == NotificationTypeEmail && n.Priority == NotificationPriorityLow
+}
+
+// Defers reports whether sending the notification at the given time must wait
+// for the end of the user's quiet hours. Critical and in-app notifications are
+// never deferred.
+func (p *UserNotificationPreferences) Defers(n *Notification, at time.Time) bool {
+	if n.Priority == NotificationPriorityCritical || n.Type == NotificationTypeInApp {
+		return false
+	}
+	return p.InQuietHours(at)
+}
+
+// InQuietHours reports whether the time falls in the user's quiet hours
+func (p *UserNotificationPreferences) InQuietHours(t time.Time) bool {
+	if p.QuietHoursStart == nil || p.QuietHoursEnd == nil || *p.QuietHoursStart == *p.QuietHoursEnd {
+		return false
+	}
+	start, end := *p.QuietHoursStart, *p.QuietHoursEnd
+	hour := t.In(p.location()).Hour()
+	if start < end {
+		return hour >= start && hour < end
+	}
+	// Quiet hours span midnight, e.g. 22-7
+	return hour >= start || hour < end
+}
+
+// QuietHoursEndAfter returns the first end of quiet hours after the time
+func (p *UserNotificationPreferences) QuietHoursEndAfter(t time.Time) time.Time {
+	if p.QuietHoursEnd == nil {
+		return t
+	}
+	local := t.In(p.location())
+	end := time.Date(local.Year(), local.Month(), local.Day(), *p.QuietHoursEnd, 0, 0, 0, local.Location())
+	if !end.After(local) {
+		end = time.Date(local.Year(), local.Month(), local.Day()+1, *p.QuietHoursEnd, 0, 0, 0, local.Location())
+	}
+	return end.UTC()
+}
+
+// NextDigestAt returns when the digest started at the given time is sent:
+// the next full hour for hourly digests, the next digest hour for daily ones.
+// Digests falling in quiet hours are sent when they end.
+func (p *UserNotificationPreferences) NextDigestAt(now time.Time) time.Time {
+	local := now.In(p.location())
+	var next time.Time
+	switch p.DigestFrequency {
+	case DigestDaily:
+		next = time.Date(local.Year(), local.Month(), local.Day(), p.DigestHour, 0, 0, 0, local.Location())
+		if !next.After(local) {
+			next = time.Date(local.Year(), local.Month(), local.Day()+1, p.DigestHour, 0, 0, 0, local.Location())
+		}
+	default:
+		next = time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, local.Location())
+	}
+	if p.InQuietHours(next) {
+		next = p.QuietHoursEndAfter(next)
+	}
+	return next.UTC()
+}
+
+func (p *UserNotificationPreferences) location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestOptOutReason(t *testing.T) {
+	prefs := DefaultNotificationPreferences("user-1")
+	prefs.SMSEnabled = false
+	prefs.CategoryPrefs[CategoryRFQ] = false
+
+	email := &Notification{Type: NotificationTypeEmail, Category: CategoryPortCall, Priority: NotificationPriorityNormal}
+	assert.Empty(t, prefs.OptOutReason(email))
+
+	sms := &Notification{Type: NotificationTypeSMS, Category: CategoryPortCall, Priority: NotificationPriorityCritical}
+	assert.NotEmpty(t, prefs.OptOutReason(sms), "channel opt-outs apply to critical notifications")
+
+	rfq := &Notification{Type: NotificationTypeEmail, Category: CategoryRFQ, Priority: NotificationPriorityNormal}
+	assert.NotEmpty(t, prefs.OptOutReason(rfq))
+	rfq.Priority = NotificationPriorityCritical
+	assert.Empty(t, prefs.OptOutReason(rfq), "critical notifications ignore category opt-outs")
+}
+
+func TestQuietHours(t *testing.T) {
+	prefs := DefaultNotificationPreferences("user-1")
+	prefs.Timezone = "Asia/Singapore" // UTC+8
+	prefs.QuietHoursStart = intPtr(22)
+	prefs.QuietHoursEnd = intPtr(7)
+	require.NoError(t, prefs.Validate())
+
+	// 23:30 in Singapore
+	late := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	assert.True(t, prefs.InQuietHours(late))
+	assert.Equal(t, time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), prefs.QuietHoursEndAfter(late))
+
+	// 05:00 in Singapore, the next morning
+	early := time.Date(2024, 3, 10, 21, 0, 0, 0, time.UTC)
+	assert.True(t, prefs.InQuietHours(early))
+	assert.Equal(t, time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), prefs.QuietHoursEndAfter(early))
+
+	// 12:00 in Singapore
+	noon := time.Date(2024, 3, 10, 4, 0, 0, 0, time.UTC)
+	assert.False(t, prefs.InQuietHours(noon))
+
+	normal := &Notification{Type: NotificationTypeEmail, Priority: NotificationPriorityNormal}
+	critical := &Notification{Type: NotificationTypeEmail, Priority: NotificationPriorityCritical}
+	inApp := &Notification{Type: NotificationTypeInApp, Priority: NotificationPriorityNormal}
+	assert.True(t, prefs.Defers(normal, late))
+	assert.False(t, prefs.Defers(critical, late))
+	assert.False(t, prefs.Defers(inApp, late))
+	assert.False(t, prefs.Defers(normal, noon))
+}
+
+func TestDigest(t *testing.T) {
+	prefs := DefaultNotificationPreferences("user-1")
+	low := &Notification{Type: NotificationTypeEmail, Priority: NotificationPriorityLow}
+	assert.False(t, prefs.Batches(low), "digests are opt-in")
+
+	prefs.DigestFrequency = DigestHourly
+	assert.True(t, prefs.Batches(low))
+	assert.False(t, prefs.Batches(&Notification{Type: NotificationTypeEmail, Priority: NotificationPriorityNormal}))
+	assert.False(t, prefs.Batches(&Notification{Type: NotificationTypePush, Priority: NotificationPriorityLow}))
+
+	now := time.Date(2024, 3, 10, 9, 20, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC), prefs.NextDigestAt(now))
+
+	prefs.DigestFrequency = DigestDaily
+	prefs.Timezone = "America/New_York" // UTC-4 in March after DST
+	assert.Equal(t, time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), prefs.NextDigestAt(now))
+	afterDigest := time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC), prefs.NextDigestAt(afterDigest))
+
+	// Digests falling in quiet hours wait for them to end
+	prefs.QuietHoursStart = intPtr(6)
+	prefs.QuietHoursEnd = intPtr(9)
+	assert.Equal(t, time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC), prefs.NextDigestAt(now))
+}
+
+func TestValidatePreferences(t *testing.T) {
+	prefs := DefaultNotificationPreferences("user-1")
+	require.NoError(t, prefs.Validate())
+
+	prefs.QuietHoursStart = intPtr(22)
+	assert.Error(t, prefs.Validate())
+	prefs.QuietHoursEnd = intPtr(24)
+	assert.Error(t, prefs.Validate())
+	prefs.QuietHoursEnd = intPtr(7)
+	require.NoError(t, prefs.Validate())
+
+	prefs.Timezone = "Mars/Olympus"
+	assert.Error(t, prefs.Validate())
+	prefs.Timezone = "Europe/Oslo"
+
+	prefs.DigestFrequency = "weekly"
+	assert.Error(t, prefs.Validate())
+	prefs.DigestFrequency = DigestDaily
+
+	prefs.CategoryPrefs["unknown"] = false
+	assert.Error(t, prefs.Validate())
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// PushSubscription is a browser's Web Push subscription, as returned by
+// PushManager.subscribe()
+type PushSubscription struct {
+	Endpoint  string               `json:"endpoint"`
+	Keys      PushSubscriptionKeys `json:"keys"`
+	UserAgent string               `json:"user_agent,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// PushSubscriptionKeys are the browser's keys for encrypting push messages,
+// base64url encoded
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultSearchLimit is the page size of notification searches without one
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the page size of notification searches
+	MaxSearchLimit = 100
+
+	// MinRetentionDays and MaxRetentionDays bound an organization's retention
+	MinRetentionDays = 1
+	MaxRetentionDays = 3650
+)
+
+// IsValid reports whether the channel is known
+func (t NotificationType) IsValid() bool {
+	switch t {
+	case NotificationTypeEmail, NotificationTypePush, NotificationTypeInApp,
+		NotificationTypeSMS, NotificationTypeSlack, NotificationTypeTeams:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the status is known
+func (s NotificationStatus) IsValid() bool {
+	switch s {
+	case NotificationStatusPending, NotificationStatusQueued, NotificationStatusSending,
+		NotificationStatusSent, NotificationStatusDelivered, NotificationStatusFailed,
+		NotificationStatusRead, NotificationStatusBatched, NotificationStatusSuppressed:
+		return true
+	}
+	return false
+}
+
+// NotificationFilter selects stored notifications. Empty fields match any
+// value; From and To bound the creation time.
+type NotificationFilter struct {
+	OrganizationID string
+	UserID         string
+	Category       NotificationCategory
+	Type           NotificationType
+	Status         NotificationStatus
+	EntityType     string
+	EntityID       string
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+	Offset         int
+}
+
+// Normalize checks the filter for invalid values and applies the default
+// and maximum page size
+func (f *NotificationFilter) Normalize() error {
+	if f.Category != "" && !f.Category.IsValid() {
+		return fmt.Errorf("invalid category: %s", f.Category)
+	}
+	if f.Type != "" && !f.Type.IsValid() {
+		return fmt.Errorf("invalid type: %s", f.Type)
+	}
+	if f.Status != "" && !f.Status.IsValid() {
+		return fmt.Errorf("invalid status: %s", f.Status)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return fmt.Errorf("to must not be before from")
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultSearchLimit
+	case f.Limit > MaxSearchLimit:
+		f.Limit = MaxSearchLimit
+	}
+	return nil
+}
+
+// NotificationRetention is how long an organization's notifications are
+// kept before they are deleted
+type NotificationRetention struct {
+	OrganizationID string     `json:"organization_id"`
+	RetentionDays  int        `json:"retention_days"`
+	Default        bool       `json:"default"` // No retention set, the service default applies
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the retention for invalid values
+func (r *NotificationRetention) Validate() error {
+	if r.RetentionDays < MinRetentionDays || r.RetentionDays > MaxRetentionDays {
+		return fmt.Errorf("retention_days must be between %d and %d", MinRetentionDays, MaxRetentionDays)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationFilterNormalize(t *testing.T) {
+	filter := &NotificationFilter{Category: CategoryRFQ, Status: NotificationStatusDelivered}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, DefaultSearchLimit, filter.Limit)
+
+	filter = &NotificationFilter{Limit: 1000}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, MaxSearchLimit, filter.Limit)
+
+	assert.ErrorContains(t, (&NotificationFilter{Category: "weather"}).Normalize(), "invalid category")
+	assert.ErrorContains(t, (&NotificationFilter{Type: "fax"}).Normalize(), "invalid type")
+	assert.ErrorContains(t, (&NotificationFilter{Status: "lost"}).Normalize(), "invalid status")
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	assert.ErrorContains(t, (&NotificationFilter{From: &from, To: &to}).Normalize(), "before from")
+}
+
+func TestNotificationRetentionValidate(t *testing.T) {
+	assert.NoError(t, (&NotificationRetention{RetentionDays: 30}).Validate())
+	assert.Error(t, (&NotificationRetention{RetentionDays: 0}).Validate())
+	assert.Error(t, (&NotificationRetention{RetentionDays: MaxRetentionDays + 1}).Validate())
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"time"
+)
+
+// StandardTemplateVariables are supplied to every templated notification
+var StandardTemplateVariables = []string{"Title", "Body", "ActionURL", "Year"}
+
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// NormalizeLocale returns the canonical form of a language tag, such as
+// "pt-BR" for "pt_br", or "" when it is not a valid tag
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if !localePattern.MatchString(locale) {
+		return ""
+	}
+
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2: // Region
+			parts[i] = strings.ToUpper(parts[i])
+		case 4: // Script
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// LocaleFallbacks returns the locales to look templates up with, most
+// specific first: "pt-BR" falls back to "pt", then to the default ""
+func LocaleFallbacks(locale string) []string {
+	locale = NormalizeLocale(locale)
+	fallbacks := []string{}
+	for locale != "" {
+		fallbacks = append(fallbacks, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return append(fallbacks, "")
+}
+
+// ReferencedVariables returns the top-level variables the template's
+// subject and bodies reference, sorted by name
+func (t *Template) ReferencedVariables() ([]string, error) {
+	seen := make(map[string]bool)
+	for _, part := range []struct{ name, text string }{
+		{"subject", t.Subject},
+		{"html_body", t.HTMLBody},
+		{"text_body", t.TextBody},
+	} {
+		tree := parse.New(part.name)
+		tree.Mode = parse.SkipFuncCheck
+		if _, err := tree.Parse(part.text, "", "", make(map[string]*parse.Tree)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", part.name, err)
+		}
+		collectVariables(tree.Root, true, seen)
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// collectVariables adds the fields of the template data referenced under
+// node. Inside range and with blocks, fields of "." belong to the element
+// instead, so only "$." references count there.
+func collectVariables(node parse.Node, top bool, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectVariables(child, top, seen)
+		}
+	case *parse.ActionNode:
+		collectPipeVariables(n.Pipe, top, seen)
+	case *parse.IfNode:
+		collectPipeVariables(n.Pipe, top, seen)
+		collectVariables(n.List, top, seen)
+		collectVariables(n.ElseList, top, seen)
+	case *parse.RangeNode:
+		collectPipeVariables(n.Pipe, top, seen)
+		collectVariables(n.List, false, seen)
+		collectVariables(n.ElseList, top, seen)
+	case *parse.WithNode:
+		collectPipeVariables(n.Pipe, top, seen)
+		collectVariables(n.List, false, seen)
+		collectVariables(n.ElseList, top, seen)
+	case *parse.TemplateNode:
+		collectPipeVariables(n.Pipe, top, seen)
+	}
+}
+
+func collectPipeVariables(pipe *parse.PipeNode, top bool, seen map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				if top {
+					seen[a.Ident[0]] = true
+				}
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" && len(a.Ident) > 1 {
+					seen[a.Ident[1]] = true
+				}
+			case *parse.PipeNode:
+				collectPipeVariables(a, top, seen)
+			}
+		}
+	}
+}
+
+// CheckVariables checks that the template parses and only references
+// declared or standard variables, which senders supply
+func (t *Template) CheckVariables() error {
+	referenced, err := t.ReferencedVariables()
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool)
+	for _, name := range StandardTemplateVariables {
+		declared[name] = true
+	}
+	for _, variable := range t.Variables {
+		declared[variable.Name] = true
+	}
+
+	var undeclared []string
+	for _, name := range referenced {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		return fmt.Errorf("undeclared template variables: %s", strings.Join(undeclared, ", "))
+	}
+	return nil
+}
+
+// ApplyDefaults fills in the default of every variable missing from data
+func (t *Template) ApplyDefaults(data map[string]any) {
+	for _, variable := range t.Variables {
+		if _, ok := data[variable.Name]; !ok && variable.Default != "" {
+			data[variable.Name] = variable.Default
+		}
+	}
+}
+
+// MissingVariables returns the required variables missing from data
+func (t *Template) MissingVariables(data map[string]any) []string {
+	var missing []string
+	for _, variable := range t.Variables {
+		if !variable.Required {
+			continue
+		}
+		if value, ok := data[variable.Name]; !ok || value == nil || value == "" {
+			missing = append(missing, variable.Name)
+		}
+	}
+	return missing
+}
+
+// SampleData returns data to preview the template with: each variable's
+// example, its default, or a placeholder naming it
+func (t *Template) SampleData() map[string]any {
+	data := map[string]any{
+		"Title":     "Sample notification",
+		"Body":      "This is a preview of the notification body.",
+		"ActionURL": "https://app.navo.io",
+		"Year":      time.Now().Year(),
+	}
+	for _, variable := range t.Variables {
+		switch {
+		case variable.Example != "":
+			data[variable.Name] = variable.Example
+		case variable.Default != "":
+			data[variable.Name] = variable.Default
+		default:
+			data[variable.Name] = "[" + variable.Name + "]"
+		}
+	}
+	return data
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, "pt-BR", NormalizeLocale("pt_br"))
+	assert.Equal(t, "zh-Hant-TW", NormalizeLocale("ZH-hant-tw"))
+	assert.Equal(t, "fil", NormalizeLocale(" fil "))
+	assert.Empty(t, NormalizeLocale("portuguese!"))
+	assert.Empty(t, NormalizeLocale(""))
+
+	assert.Equal(t, []string{"pt-BR", "pt", ""}, LocaleFallbacks("pt_BR"))
+	assert.Equal(t, []string{""}, LocaleFallbacks(""))
+}
+
+func TestTemplateVariables(t *testing.T) {
+	tmpl := &Template{
+		Subject:  "{{.VesselName}} at {{.PortName}}",
+		HTMLBody: `{{if .ActionURL}}<a href="{{.ActionURL}}">{{.Title}}</a>{{end}}{{range .Items}}{{.Title}} {{$.Period}}{{end}}`,
+		TextBody: "{{.Body}} {{printf \"%s\" .ETA}}",
+		Variables: []TemplateVariable{
+			{Name: "VesselName", Required: true},
+			{Name: "PortName", Required: true, Default: "port"},
+			{Name: "Items"},
+			{Name: "Period"},
+		},
+	}
+
+	referenced, err := tmpl.ReferencedVariables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ActionURL", "Body", "ETA", "Items", "Period", "PortName", "Title", "VesselName"}, referenced,
+		"fields inside range belong to the items")
+
+	assert.EqualError(t, tmpl.CheckVariables(), "undeclared template variables: ETA")
+	tmpl.Variables = append(tmpl.Variables, TemplateVariable{Name: "ETA"})
+	assert.NoError(t, tmpl.CheckVariables())
+
+	data := map[string]any{"VesselName": ""}
+	tmpl.ApplyDefaults(data)
+	assert.Equal(t, "port", data["PortName"])
+	assert.Equal(t, []string{"VesselName"}, tmpl.MissingVariables(data))
+
+	tmpl.Subject = "{{.VesselName"
+	_, err = tmpl.ReferencedVariables()
+	assert.ErrorContains(t, err, "invalid subject")
+}
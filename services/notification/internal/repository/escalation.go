@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/navo/services/notification/internal/model"
+)
+
+// Escalations are kept without a TTL: a pending escalation must survive
+// worker restarts until it is acknowledged or runs out of steps.
+const (
+	escalationPolicyKeyPrefix = "escalation:policy:"
+	orgEscalationPoliciesKey  = "escalation:policies:org:"
+	escalationKeyPrefix       = "escalation:"
+	orgEscalationsKey         = "escalations:org:"
+	dueEscalationsKey         = "escalations:due"
+	escalationLockKeyPrefix   = "escalation:lock:"
+)
+
+var (
+	ErrEscalationPolicyNotFound = errors.New("escalation policy not found")
+	ErrEscalationNotFound       = errors.New("escalation not found")
+)
+
+// unlockScript deletes a lock only while it is held by the same owner
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// EscalationRepository handles escalation policy and escalation persistence
+type EscalationRepository struct {
+	redis *redis.Client
+}
+
+// NewEscalationRepository creates a new escalation repository
+func NewEscalationRepository(redisClient *redis.Client) *EscalationRepository {
+	return &EscalationRepository{
+		redis: redisClient,
+	}
+}
+
+// SavePolicy saves an escalation policy
+func (r *EscalationRepository) SavePolicy(ctx context.Context, policy *model.EscalationPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal escalation policy: %w", err)
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.Set(ctx, escalationPolicyKeyPrefix+policy.ID, data, 0)
+	pipe.SAdd(ctx, orgEscalationPoliciesKey+policy.OrganizationID, policy.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save escalation policy: %w", err)
+	}
+	return nil
+}
+
+// GetPolicy retrieves an escalation policy by ID
+func (r *EscalationRepository) GetPolicy(ctx context.Context, id string) (*model.EscalationPolicy, error) {
+	data, err := r.redis.Get(ctx, escalationPolicyKeyPrefix+id).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("%w: %s", ErrEscalationPolicyNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+
+	var policy model.EscalationPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal escalation policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// ListPolicies returns the escalation policies of an organization
+func (r *EscalationRepository) ListPolicies(ctx context.Context, organizationID string) ([]*model.EscalationPolicy, error) {
+	ids, err := r.redis.SMembers(ctx, orgEscalationPoliciesKey+organizationID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policy IDs: %w", err)
+	}
+
+	policies := make([]*model.EscalationPolicy, 0, len(ids))
+	for _, id := range ids {
+		policy, err := r.GetPolicy(ctx, id)
+		if err != nil {
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// DeletePolicy deletes an escalation policy. Running escalations keep the
+// steps they started with.
+func (r *EscalationRepository) DeletePolicy(ctx context.Context, policy *model.EscalationPolicy) error {
+	pipe := r.redis.TxPipeline()
+	pipe.Del(ctx, escalationPolicyKeyPrefix+policy.ID)
+	pipe.SRem(ctx, orgEscalationPoliciesKey+policy.OrganizationID, policy.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete escalation policy: %w", err)
+	}
+	return nil
+}
+
+// Save saves an escalation and schedules its next step while it is pending
+func (r *EscalationRepository) Save(ctx context.Context, escalation *model.Escalation) error {
+	escalation.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(escalation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal escalation: %w", err)
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.Set(ctx, escalationKeyPrefix+escalation.ID, data, 0)
+	pipe.ZAdd(ctx, orgEscalationsKey+escalation.OrganizationID, &redis.Z{
+		Score:  float64(escalation.CreatedAt.Unix()),
+		Member: escalation.ID,
+	})
+	if escalation.Status == model.EscalationStatusPending && escalation.NextAt != nil {
+		pipe.ZAdd(ctx, dueEscalationsKey, &redis.Z{
+			Score:  float64(escalation.NextAt.Unix()),
+			Member: escalation.ID,
+		})
+	} else {
+		pipe.ZRem(ctx, dueEscalationsKey, escalation.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save escalation: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves an escalation by ID
+func (r *EscalationRepository) Get(ctx context.Context, id string) (*model.Escalation, error) {
+	data, err := r.redis.Get(ctx, escalationKeyPrefix+id).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("%w: %s", ErrEscalationNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get escalation: %w", err)
+	}
+
+	var escalation model.Escalation
+	if err := json.Unmarshal(data, &escalation); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal escalation: %w", err)
+	}
+	return &escalation, nil
+}
+
+// GetByOrganization retrieves the escalations of an organization, newest first
+func (r *EscalationRepository) GetByOrganization(ctx context.Context, organizationID string, limit, offset int) ([]*model.Escalation, error) {
+	ids, err := r.redis.ZRevRange(ctx, orgEscalationsKey+organizationID, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation IDs: %w", err)
+	}
+
+	escalations := make([]*model.Escalation, 0, len(ids))
+	for _, id := range ids {
+		escalation, err := r.Get(ctx, id)
+		if err != nil {
+			continue
+		}
+		escalations = append(escalations, escalation)
+	}
+	return escalations, nil
+}
+
+// GetDue returns the IDs of pending escalations whose next step is due
+func (r *EscalationRepository) GetDue(ctx context.Context, now time.Time) ([]string, error) {
+	ids, err := r.redis.ZRangeByScore(ctx, dueEscalationsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now.Unix()),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due escalations: %w", err)
+	}
+	return ids, nil
+}
+
+// Lock takes the lock of an escalation, so only one worker advances it at a
+// time. It returns the token to unlock with, or "" when the lock is held.
+func (r *EscalationRepository) Lock(ctx context.Context, id string, ttl time.Duration) (string, error) {
+	token := uuid.New().String()
+	ok, err := r.redis.SetNX(ctx, escalationLockKeyPrefix+id, token, ttl).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to lock escalation: %w", err)
+	}
+	if !ok {
+		return "", nil
+	}
+	return token, nil
+}
+
+// Unlock releases the lock of an escalation taken with token
+func (r *EscalationRepository) Unlock(ctx context.Context, id, token string) error {
+	if err := unlockScript.Run(ctx, r.redis, []string{escalationLockKeyPrefix + id}, token).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to unlock escalation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/navo/pkg/migrate/migratetest"
+	"github.com/navo/services/notification/migrations"
+)
+
+// TestMigrations applies the migrations to the database in TEST_DATABASE_URL
+// and checks this package's queries against the schema they create
+func TestMigrations(t *testing.T) {
+	migratetest.Run(t, migratetest.Options{
+		Service:    "notification",
+		Migrations: migrations.FS,
+	})
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,21 +14,33 @@ import (
 )
 
 const (
-	notificationKeyPrefix = "notification:"
-	userNotificationsKey  = "user:notifications:"
+	notificationKeyPrefix   = "notification:"
 	pendingNotificationsKey = "notifications:pending"
-	notificationTTL       = 30 * 24 * time.Hour // 30 days
+	digestKeyPrefix         = "notifications:digest:"
+	digestDueKey            = "notifications:digest:due"
+	notificationCacheTTL    = time.Hour
+	cleanupBatchSize        = 1000
 )
 
-// NotificationRepository handles notification persistence
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository persists notifications in Postgres. Redis caches
+// recent notifications and holds the queues of notifications waiting to be
+// sent and batched into digests.
 type NotificationRepository struct {
-	redis *redis.Client
+	db                   *sql.DB
+	redis                *redis.Client
+	defaultRetentionDays int
 }
 
-// NewNotificationRepository creates a new notification repository
-func NewNotificationRepository(redisClient *redis.Client) *NotificationRepository {
+// NewNotificationRepository creates a new notification repository.
+// Notifications of organizations without a retention of their own are kept
+// for defaultRetentionDays.
+func NewNotificationRepository(db *sql.DB, redisClient *redis.Client, defaultRetentionDays int) *NotificationRepository {
 	return &NotificationRepository{
-		redis: redisClient,
+		db:                   db,
+		redis:                redisClient,
+		defaultRetentionDays: defaultRetentionDays,
 	}
 }
 
@@ -38,32 +53,37 @@ func (r *NotificationRepository) Save(ctx context.Context, notification *model.N
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	// Save notification
-	key := notificationKeyPrefix + notification.ID
-	if err := r.redis.Set(ctx, key, data, notificationTTL).Err(); err != nil {
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO notification_records (
+			id, organization_id, user_id, type, category, priority, status,
+			entity_type, entity_id, provider, provider_message_id,
+			read_at, scheduled_for, created_at, updated_at, payload
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			priority = EXCLUDED.priority,
+			provider = EXCLUDED.provider,
+			provider_message_id = EXCLUDED.provider_message_id,
+			read_at = EXCLUDED.read_at,
+			scheduled_for = EXCLUDED.scheduled_for,
+			updated_at = EXCLUDED.updated_at,
+			payload = EXCLUDED.payload
+	`, notification.ID, notification.OrganizationID, notification.UserID,
+		notification.Type, notification.Category, notification.Priority, notification.Status,
+		notification.EntityType, notification.EntityID,
+		notification.Provider, notification.ProviderMessageID,
+		notification.ReadAt, notification.ScheduledFor,
+		notification.CreatedAt, notification.UpdatedAt, data)
+	if err != nil {
 		return fmt.Errorf("failed to save notification: %w", err)
 	}
 
-	// Add to user's notification list
-	userKey := userNotificationsKey + notification.UserID
-	score := float64(notification.CreatedAt.Unix())
-	if err := r.redis.ZAdd(ctx, userKey, &redis.Z{
-		Score:  score,
-		Member: notification.ID,
-	}).Err(); err != nil {
-		return fmt.Errorf("failed to add to user notifications: %w", err)
-	}
+	r.redis.Set(ctx, notificationKeyPrefix+notification.ID, data, notificationCacheTTL)
 
 	// If pending/scheduled, add to pending set
 	if notification.Status == model.NotificationStatusQueued || notification.Status == model.NotificationStatusPending {
-		var scheduleTime float64
-		if notification.ScheduledFor != nil {
-			scheduleTime = float64(notification.ScheduledFor.Unix())
-		} else {
-			scheduleTime = float64(time.Now().Unix())
-		}
 		if err := r.redis.ZAdd(ctx, pendingNotificationsKey, &redis.Z{
-			Score:  scheduleTime,
+			Score:  float64(pendingAt(notification).Unix()),
 			Member: notification.ID,
 		}).Err(); err != nil {
 			return fmt.Errorf("failed to add to pending notifications: %w", err)
@@ -76,13 +96,29 @@ func (r *NotificationRepository) Save(ctx context.Context, notification *model.N
 	return nil
 }
 
+// pendingAt returns when a pending notification is due to be sent
+func pendingAt(notification *model.Notification) time.Time {
+	if notification.ScheduledFor != nil {
+		return *notification.ScheduledFor
+	}
+	return time.Now()
+}
+
 // Get retrieves a notification by ID
 func (r *NotificationRepository) Get(ctx context.Context, id string) (*model.Notification, error) {
 	key := notificationKeyPrefix + id
 	data, err := r.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		var notification model.Notification
+		if err := json.Unmarshal(data, &notification); err == nil {
+			return &notification, nil
+		}
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT payload FROM notification_records WHERE id = $1`, id).Scan(&data)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, fmt.Errorf("notification not found: %s", id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrNotificationNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get notification: %w", err)
 	}
@@ -92,29 +128,92 @@ func (r *NotificationRepository) Get(ctx context.Context, id string) (*model.Not
 		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
 	}
 
+	r.redis.Set(ctx, key, data, notificationCacheTTL)
 	return &notification, nil
 }
 
-// GetByUser retrieves notifications for a user
+// GetByUser retrieves notifications for a user, newest first
 func (r *NotificationRepository) GetByUser(ctx context.Context, userID string, limit, offset int) ([]*model.Notification, error) {
-	userKey := userNotificationsKey + userID
+	notifications, _, err := r.Search(ctx, &model.NotificationFilter{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	return notifications, err
+}
+
+// Search retrieves the notifications matching a filter, newest first, and
+// the number of notifications matching it in total
+func (r *NotificationRepository) Search(ctx context.Context, filter *model.NotificationFilter) ([]*model.Notification, int, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		where("organization_id = $%d", filter.OrganizationID)
+	}
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.Category != "" {
+		where("category = $%d", filter.Category)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.EntityType != "" {
+		where("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		where("entity_id = $%d", filter.EntityID)
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+
+	clause := ""
+	if len(conditions) > 0 {
+		clause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_records `+clause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
 
-	// Get notification IDs (newest first)
-	ids, err := r.redis.ZRevRange(ctx, userKey, int64(offset), int64(offset+limit-1)).Result()
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT payload FROM notification_records %s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, clause, len(args)-1, len(args)), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user notification IDs: %w", err)
+		return nil, 0, fmt.Errorf("failed to search notifications: %w", err)
 	}
+	defer rows.Close()
 
-	notifications := make([]*model.Notification, 0, len(ids))
-	for _, id := range ids {
-		notification, err := r.Get(ctx, id)
-		if err != nil {
-			continue // Skip if notification not found
+	notifications := make([]*model.Notification, 0, filter.Limit)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan notification: %w", err)
 		}
-		notifications = append(notifications, notification)
+		var notification model.Notification
+		if err := json.Unmarshal(data, &notification); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal notification: %w", err)
+		}
+		notifications = append(notifications, &notification)
 	}
-
-	return notifications, nil
+	return notifications, total, rows.Err()
 }
 
 // GetPending retrieves pending notifications that are ready to be sent
@@ -134,6 +233,9 @@ func (r *NotificationRepository) GetPending(ctx context.Context) ([]*model.Notif
 	for _, id := range ids {
 		notification, err := r.Get(ctx, id)
 		if err != nil {
+			if errors.Is(err, ErrNotificationNotFound) {
+				r.redis.ZRem(ctx, pendingNotificationsKey, id)
+			}
 			continue
 		}
 		notifications = append(notifications, notification)
@@ -142,73 +244,218 @@ func (r *NotificationRepository) GetPending(ctx context.Context) ([]*model.Notif
 	return notifications, nil
 }
 
-// MarkAllAsRead marks all notifications for a user as read
-func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID string) error {
-	userKey := userNotificationsKey + userID
-	now := time.Now().UTC()
+// RestorePendingQueue queues the pending notifications stored in the
+// database, in case Redis lost the queue. It returns the number queued.
+func (r *NotificationRepository) RestorePendingQueue(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(scheduled_for, created_at)
+		FROM notification_records
+		WHERE status IN ('pending', 'queued')
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query pending notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*redis.Z
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return 0, fmt.Errorf("failed to scan pending notification: %w", err)
+		}
+		members = append(members, &redis.Z{Score: float64(at.Unix()), Member: id})
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
 
-	// Get all notification IDs for the user
-	ids, err := r.redis.ZRange(ctx, userKey, 0, -1).Result()
+	if len(members) == 0 {
+		return 0, nil
+	}
+	if err := r.redis.ZAddNX(ctx, pendingNotificationsKey, members...).Err(); err != nil {
+		return 0, fmt.Errorf("failed to queue pending notifications: %w", err)
+	}
+	return len(members), nil
+}
+
+// GetByProviderMessage retrieves the notification of a provider's message ID
+func (r *NotificationRepository) GetByProviderMessage(ctx context.Context, provider, messageID string) (*model.Notification, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT payload FROM notification_records
+		WHERE provider = $1 AND provider_message_id = $2
+	`, provider, messageID).Scan(&data)
 	if err != nil {
-		return fmt.Errorf("failed to get user notifications: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w for %s message %s", ErrNotificationNotFound, provider, messageID)
+		}
+		return nil, fmt.Errorf("failed to get provider message: %w", err)
 	}
 
-	// Update each notification
-	for _, id := range ids {
+	var notification model.Notification
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// AddToDigest adds a notification to the user's next digest. dueAt is only
+// used when the user has no digest waiting yet.
+func (r *NotificationRepository) AddToDigest(ctx context.Context, userID, notificationID string, dueAt time.Time) error {
+	pipe := r.redis.TxPipeline()
+	pipe.RPush(ctx, digestKeyPrefix+userID, notificationID)
+	pipe.ZAddNX(ctx, digestDueKey, &redis.Z{
+		Score:  float64(dueAt.Unix()),
+		Member: userID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add to digest: %w", err)
+	}
+	return nil
+}
+
+// GetDueDigests returns the users whose digest is due
+func (r *NotificationRepository) GetDueDigests(ctx context.Context, now time.Time) ([]string, error) {
+	userIDs, err := r.redis.ZRangeByScore(ctx, digestDueKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now.Unix()),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due digests: %w", err)
+	}
+	return userIDs, nil
+}
+
+// TakeDigest removes the user's digest and returns its notifications.
+// Notifications added afterwards start a new digest.
+func (r *NotificationRepository) TakeDigest(ctx context.Context, userID string) ([]*model.Notification, error) {
+	key := digestKeyPrefix + userID
+
+	pipe := r.redis.TxPipeline()
+	idsCmd := pipe.LRange(ctx, key, 0, -1)
+	pipe.Del(ctx, key)
+	pipe.ZRem(ctx, digestDueKey, userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to take digest: %w", err)
+	}
+
+	notifications := make([]*model.Notification, 0, len(idsCmd.Val()))
+	for _, id := range idsCmd.Val() {
 		notification, err := r.Get(ctx, id)
 		if err != nil {
-			continue
+			continue // Deleted since it was batched
 		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+// MarkAllAsRead marks all notifications for a user as read
+func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID string) error {
+	now := time.Now().UTC()
 
-		if notification.ReadAt == nil {
-			notification.Status = model.NotificationStatusRead
-			notification.ReadAt = &now
-			r.Save(ctx, notification)
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE notification_records
+		SET status = 'read', read_at = $2, updated_at = $2,
+			payload = payload || jsonb_build_object('status', 'read', 'read_at', $3::text, 'updated_at', $3::text)
+		WHERE user_id = $1 AND read_at IS NULL
+		RETURNING id
+	`, userID, now, now.Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan notification: %w", err)
 		}
+		keys = append(keys, notificationKeyPrefix+id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
+	if len(keys) > 0 {
+		r.redis.Del(ctx, keys...)
+	}
 	return nil
 }
 
 // Delete deletes a notification
 func (r *NotificationRepository) Delete(ctx context.Context, id string) error {
-	notification, err := r.Get(ctx, id)
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_records WHERE id = $1`, id)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotificationNotFound, id)
 	}
-
-	// Remove from all sets
-	key := notificationKeyPrefix + id
-	userKey := userNotificationsKey + notification.UserID
 
 	pipe := r.redis.Pipeline()
-	pipe.Del(ctx, key)
-	pipe.ZRem(ctx, userKey, id)
+	pipe.Del(ctx, notificationKeyPrefix+id)
 	pipe.ZRem(ctx, pendingNotificationsKey, id)
-
 	_, err = pipe.Exec(ctx)
 	return err
 }
 
 // GetUnreadCount returns the count of unread notifications for a user
 func (r *NotificationRepository) GetUnreadCount(ctx context.Context, userID string) (int, error) {
-	notifications, err := r.GetByUser(ctx, userID, 100, 0)
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notification_records WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count)
 	if err != nil {
-		return 0, err
-	}
-
-	count := 0
-	for _, n := range notifications {
-		if n.ReadAt == nil {
-			count++
-		}
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
 	}
 	return count, nil
 }
 
-// Cleanup removes expired notifications
-func (r *NotificationRepository) Cleanup(ctx context.Context) error {
-	// This would be called periodically to clean up old notifications
-	// Redis TTL handles most of this, but we need to clean up sorted sets
-	return nil
+// Cleanup deletes the notifications older than their organization's
+// retention, in batches. Notifications still waiting to be sent are kept.
+// It returns the number deleted.
+func (r *NotificationRepository) Cleanup(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		rows, err := r.db.QueryContext(ctx, `
+			DELETE FROM notification_records
+			WHERE id IN (
+				SELECT n.id
+				FROM notification_records n
+				LEFT JOIN notification_retention rt ON rt.organization_id = n.organization_id
+				WHERE n.created_at < NOW() - make_interval(days => COALESCE(rt.retention_days, $1))
+				AND n.status NOT IN ('pending', 'queued', 'batched')
+				LIMIT $2
+			)
+			RETURNING id
+		`, r.defaultRetentionDays, cleanupBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired notifications: %w", err)
+		}
+
+		var keys []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return deleted, fmt.Errorf("failed to scan notification: %w", err)
+			}
+			keys = append(keys, notificationKeyPrefix+id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return deleted, err
+		}
+
+		if len(keys) > 0 {
+			r.redis.Del(ctx, keys...)
+		}
+		deleted += len(keys)
+		if len(keys) < cleanupBatchSize {
+			return deleted, nil
+		}
+	}
 }
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/services/notification/internal/model"
+)
+
+const (
+	preferencesKeyPrefix = "notification:preferences:"
+	preferencesCacheTTL  = time.Hour
+)
+
+// PreferencesRepository persists user notification preferences in Postgres
+// and caches them in Redis. Without a database, Redis is the only store.
+type PreferencesRepository struct {
+	db    *sql.DB
+	redis *redis.Client
+}
+
+// NewPreferencesRepository creates a new preferences repository. db may be nil.
+func NewPreferencesRepository(db *sql.DB, redisClient *redis.Client) *PreferencesRepository {
+	return &PreferencesRepository{
+		db:    db,
+		redis: redisClient,
+	}
+}
+
+// Get retrieves a user's preferences, returning the defaults when the user
+// has none stored
+func (r *PreferencesRepository) Get(ctx context.Context, userID string) (*model.UserNotificationPreferences, error) {
+	key := preferencesKeyPrefix + userID
+	data, err := r.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		var prefs model.UserNotificationPreferences
+		if err := json.Unmarshal(data, &prefs); err == nil {
+			return &prefs, nil
+		}
+	} else if err != redis.Nil {
+		return nil, fmt.Errorf("failed to get cached preferences: %w", err)
+	}
+
+	if r.db == nil {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+
+	prefs, err := r.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	r.cache(ctx, prefs)
+	return prefs, nil
+}
+
+// load reads preferences from the database
+func (r *PreferencesRepository) load(ctx context.Context, userID string) (*model.UserNotificationPreferences, error) {
+	query := `
+		SELECT email_enabled, push_enabled, in_app_enabled, sms_enabled, category_prefs,
+			quiet_hours_start, quiet_hours_end, timezone, locale, digest_frequency, digest_hour, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	prefs := model.DefaultNotificationPreferences(userID)
+	var categoryPrefs []byte
+	var quietStart, quietEnd sql.NullInt32
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&prefs.EmailEnabled, &prefs.PushEnabled, &prefs.InAppEnabled, &prefs.SMSEnabled, &categoryPrefs,
+		&quietStart, &quietEnd, &prefs.Timezone, &prefs.Locale, &prefs.DigestFrequency, &prefs.DigestHour, &prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	if err := json.Unmarshal(categoryPrefs, &prefs.CategoryPrefs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal category preferences: %w", err)
+	}
+	if quietStart.Valid && quietEnd.Valid {
+		start, end := int(quietStart.Int32), int(quietEnd.Int32)
+		prefs.QuietHoursStart = &start
+		prefs.QuietHoursEnd = &end
+	}
+	return prefs, nil
+}
+
+// Save stores a user's preferences
+func (r *PreferencesRepository) Save(ctx context.Context, prefs *model.UserNotificationPreferences) error {
+	prefs.UpdatedAt = time.Now().UTC()
+
+	if r.db == nil {
+		data, err := json.Marshal(prefs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal preferences: %w", err)
+		}
+		if err := r.redis.Set(ctx, preferencesKeyPrefix+prefs.UserID, data, 0).Err(); err != nil {
+			return fmt.Errorf("failed to save preferences: %w", err)
+		}
+		return nil
+	}
+
+	categoryPrefs, err := json.Marshal(prefs.CategoryPrefs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal category preferences: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_preferences (user_id, email_enabled, push_enabled, in_app_enabled,
+			sms_enabled, category_prefs, quiet_hours_start, quiet_hours_end, timezone,
+			locale, digest_frequency, digest_hour, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			push_enabled = EXCLUDED.push_enabled,
+			in_app_enabled = EXCLUDED.in_app_enabled,
+			sms_enabled = EXCLUDED.sms_enabled,
+			category_prefs = EXCLUDED.category_prefs,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			locale = EXCLUDED.locale,
+			digest_frequency = EXCLUDED.digest_frequency,
+			digest_hour = EXCLUDED.digest_hour,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		prefs.UserID, prefs.EmailEnabled, prefs.PushEnabled, prefs.InAppEnabled,
+		prefs.SMSEnabled, categoryPrefs, prefs.QuietHoursStart, prefs.QuietHoursEnd, prefs.Timezone,
+		prefs.Locale, prefs.DigestFrequency, prefs.DigestHour, prefs.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	r.cache(ctx, prefs)
+	return nil
+}
+
+// cache stores preferences read from or written to the database in Redis
+func (r *PreferencesRepository) cache(ctx context.Context, prefs *model.UserNotificationPreferences) {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return
+	}
+	r.redis.Set(ctx, preferencesKeyPrefix+prefs.UserID, data, preferencesCacheTTL)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/services/notification/internal/model"
+)
+
+const pushSubscriptionsKeyPrefix = "notification:push:subscriptions:"
+
+// PushSubscriptionRepository stores users' Web Push subscriptions
+type PushSubscriptionRepository struct {
+	redis *redis.Client
+}
+
+// NewPushSubscriptionRepository creates a new push subscription repository
+func NewPushSubscriptionRepository(redisClient *redis.Client) *PushSubscriptionRepository {
+	return &PushSubscriptionRepository{
+		redis: redisClient,
+	}
+}
+
+// SavePushSubscription adds or replaces a subscription of a user
+func (r *PushSubscriptionRepository) SavePushSubscription(ctx context.Context, userID string, subscription *model.PushSubscription) error {
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push subscription: %w", err)
+	}
+
+	if err := r.redis.HSet(ctx, pushSubscriptionsKeyPrefix+userID, subscription.Endpoint, data).Err(); err != nil {
+		return fmt.Errorf("failed to save push subscription: %w", err)
+	}
+	return nil
+}
+
+// ListPushSubscriptions returns the subscriptions of a user
+func (r *PushSubscriptionRepository) ListPushSubscriptions(ctx context.Context, userID string) ([]*model.PushSubscription, error) {
+	values, err := r.redis.HVals(ctx, pushSubscriptionsKeyPrefix+userID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push subscriptions: %w", err)
+	}
+
+	subscriptions := make([]*model.PushSubscription, 0, len(values))
+	for _, value := range values {
+		var subscription model.PushSubscription
+		if err := json.Unmarshal([]byte(value), &subscription); err != nil {
+			continue
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	return subscriptions, nil
+}
+
+// DeletePushSubscription removes a subscription of a user
+func (r *PushSubscriptionRepository) DeletePushSubscription(ctx context.Context, userID, endpoint string) error {
+	if err := r.redis.HDel(ctx, pushSubscriptionsKeyPrefix+userID, endpoint).Err(); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// GetRetention retrieves how long an organization's notifications are
+// kept, the default retention when it has none set
+func (r *NotificationRepository) GetRetention(ctx context.Context, organizationID string) (*model.NotificationRetention, error) {
+	retention := &model.NotificationRetention{OrganizationID: organizationID}
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT retention_days, updated_at FROM notification_retention WHERE organization_id = $1
+	`, organizationID).Scan(&retention.RetentionDays, &updatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		retention.RetentionDays = r.defaultRetentionDays
+		retention.Default = true
+		return retention, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get retention: %w", err)
+	}
+
+	retention.UpdatedAt = &updatedAt
+	return retention, nil
+}
+
+// SaveRetention sets how long an organization's notifications are kept
+func (r *NotificationRepository) SaveRetention(ctx context.Context, retention *model.NotificationRetention) error {
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_retention (organization_id, retention_days, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			retention_days = EXCLUDED.retention_days,
+			updated_at = EXCLUDED.updated_at
+	`, retention.OrganizationID, retention.RetentionDays, now)
+	if err != nil {
+		return fmt.Errorf("failed to save retention: %w", err)
+	}
+
+	retention.Default = false
+	retention.UpdatedAt = &now
+	return nil
+}
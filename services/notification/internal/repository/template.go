@@ -1,35 +1,49 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/navo/services/notification/internal/model"
 )
 
-// TemplateRepository manages email templates
+var (
+	ErrTemplateNotFound         = errors.New("template not found")
+	ErrTemplateStoreUnavailable = errors.New("template overrides require a database")
+)
+
+// TemplateRepository manages email templates. The built-in templates are
+// the defaults; organizations override them per locale with versioned
+// templates stored in Postgres.
 type TemplateRepository struct {
 	templates map[string]*model.Template
+	db        *sql.DB
 }
 
-// NewTemplateRepository creates a new template repository with pre-defined templates
-func NewTemplateRepository() *TemplateRepository {
+// NewTemplateRepository creates a new template repository with pre-defined
+// templates. db may be nil, leaving only the built-in templates.
+func NewTemplateRepository(db *sql.DB) *TemplateRepository {
 	repo := &TemplateRepository{
 		templates: make(map[string]*model.Template),
+		db:        db,
 	}
 	repo.loadTemplates()
 	return repo
 }
 
-// Get retrieves a template by name
+// Get retrieves a built-in template by name
 func (r *TemplateRepository) Get(name string) (*model.Template, error) {
 	tmpl, ok := r.templates[name]
 	if !ok {
-		return nil, fmt.Errorf("template not found: %s", name)
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
 	}
 	return tmpl, nil
 }
 
-// List returns all available templates
+// List returns all built-in templates
 func (r *TemplateRepository) List() []*model.Template {
 	templates := make([]*model.Template, 0, len(r.templates))
 	for _, tmpl := range r.templates {
@@ -38,6 +52,218 @@ func (r *TemplateRepository) List() []*model.Template {
 	return templates
 }
 
+// Resolve returns the template to send to a recipient: the organization's
+// override in the closest locale, else the built-in template
+func (r *TemplateRepository) Resolve(ctx context.Context, organizationID, name, locale string) (*model.Template, error) {
+	builtIn, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if r.db == nil || organizationID == "" {
+		return builtIn, nil
+	}
+
+	// Latest version of each of the organization's locales of the template
+	query := `
+		SELECT DISTINCT ON (locale) locale, version, subject, html_body, text_body,
+			deleted, created_by, created_at
+		FROM notification_templates
+		WHERE organization_id = $1 AND name = $2
+		ORDER BY locale, version DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, organizationID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]*model.Template)
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows, builtIn, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if !tmpl.Deleted {
+			overrides[tmpl.Locale] = tmpl
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get template overrides: %w", err)
+	}
+
+	for _, candidate := range model.LocaleFallbacks(locale) {
+		if tmpl, ok := overrides[candidate]; ok {
+			return tmpl, nil
+		}
+	}
+	return builtIn, nil
+}
+
+// ListOverrides returns the current overrides of an organization, one per
+// template and locale
+func (r *TemplateRepository) ListOverrides(ctx context.Context, organizationID string) ([]*model.Template, error) {
+	if r.db == nil {
+		return []*model.Template{}, nil
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT DISTINCT ON (name, locale) name, locale, version, subject, html_body, text_body,
+				deleted, created_by, created_at
+			FROM notification_templates
+			WHERE organization_id = $1
+			ORDER BY name, locale, version DESC
+		) latest
+		WHERE NOT deleted
+		ORDER BY name, locale
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template overrides: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*model.Template{}
+	for rows.Next() {
+		var name string
+		tmpl := &model.Template{OrganizationID: organizationID}
+		var createdAt time.Time
+		if err := rows.Scan(&name, &tmpl.Locale, &tmpl.Version, &tmpl.Subject, &tmpl.HTMLBody,
+			&tmpl.TextBody, &tmpl.Deleted, &tmpl.CreatedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan template override: %w", err)
+		}
+		tmpl.CreatedAt = &createdAt
+		if builtIn, ok := r.templates[name]; ok {
+			withBuiltIn(tmpl, builtIn)
+		} else {
+			tmpl.Name = name
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, rows.Err()
+}
+
+// ListVersions returns every version of an organization's override of a
+// template in a locale, newest first
+func (r *TemplateRepository) ListVersions(ctx context.Context, organizationID, name, locale string) ([]*model.Template, error) {
+	builtIn, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if r.db == nil {
+		return []*model.Template{}, nil
+	}
+
+	query := `
+		SELECT locale, version, subject, html_body, text_body, deleted, created_by, created_at
+		FROM notification_templates
+		WHERE organization_id = $1 AND name = $2 AND locale = $3
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, organizationID, name, locale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*model.Template{}
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows, builtIn, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, tmpl)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion retrieves one version of an organization's override
+func (r *TemplateRepository) GetVersion(ctx context.Context, organizationID, name, locale string, version int) (*model.Template, error) {
+	builtIn, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if r.db == nil {
+		return nil, ErrTemplateStoreUnavailable
+	}
+
+	query := `
+		SELECT locale, version, subject, html_body, text_body, deleted, created_by, created_at
+		FROM notification_templates
+		WHERE organization_id = $1 AND name = $2 AND locale = $3 AND version = $4
+	`
+
+	tmpl, err := scanTemplate(r.db.QueryRowContext(ctx, query, organizationID, name, locale, version), builtIn, organizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, name, version)
+	}
+	return tmpl, err
+}
+
+// SaveVersion stores a template override as the next version of the
+// organization's template in its locale. A deleted version reverts the
+// locale to the default.
+func (r *TemplateRepository) SaveVersion(ctx context.Context, tmpl *model.Template) error {
+	if r.db == nil {
+		return ErrTemplateStoreUnavailable
+	}
+
+	query := `
+		INSERT INTO notification_templates (organization_id, name, locale, version, subject,
+			html_body, text_body, deleted, created_by, created_at)
+		SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5, $6, $7, $8, $9
+		FROM notification_templates
+		WHERE organization_id = $1 AND name = $2 AND locale = $3
+		RETURNING version
+	`
+
+	createdAt := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		tmpl.OrganizationID, tmpl.Name, tmpl.Locale, tmpl.Subject,
+		tmpl.HTMLBody, tmpl.TextBody, tmpl.Deleted, tmpl.CreatedBy, createdAt,
+	).Scan(&tmpl.Version)
+	if err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	tmpl.CreatedAt = &createdAt
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTemplate reads a stored override, completing it from its built-in
+// template
+func scanTemplate(row rowScanner, builtIn *model.Template, organizationID string) (*model.Template, error) {
+	tmpl := &model.Template{OrganizationID: organizationID}
+	var createdAt time.Time
+	err := row.Scan(&tmpl.Locale, &tmpl.Version, &tmpl.Subject, &tmpl.HTMLBody, &tmpl.TextBody,
+		&tmpl.Deleted, &tmpl.CreatedBy, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan template: %w", err)
+	}
+	tmpl.CreatedAt = &createdAt
+	withBuiltIn(tmpl, builtIn)
+	return tmpl, nil
+}
+
+// withBuiltIn completes an override with what it shares with its built-in
+// template: the variables senders supply, the category and description
+func withBuiltIn(tmpl, builtIn *model.Template) {
+	tmpl.Name = builtIn.Name
+	tmpl.Category = builtIn.Category
+	tmpl.Description = builtIn.Description
+	tmpl.Variables = builtIn.Variables
+}
+
 // loadTemplates initializes the pre-defined templates
 func (r *TemplateRepository) loadTemplates() {
 	// Port Call Templates
@@ -199,6 +425,21 @@ func (r *TemplateRepository) loadTemplates() {
 		HTMLBody: vesselArrivalAlertHTML,
 		TextBody: vesselArrivalAlertText,
 	}
+
+	// Digest Templates
+	r.templates["notification_digest"] = &model.Template{
+		Name:        "notification_digest",
+		Subject:     "Your Navo digest - {{.Count}} notification(s)",
+		Category:    model.CategorySystem,
+		Description: "Batches low-priority notifications into one hourly or daily email",
+		Variables: []model.TemplateVariable{
+			{Name: "Count", Description: "Number of notifications in the digest", Required: true},
+			{Name: "Period", Description: "Digest period (hourly or daily)", Required: true},
+			{Name: "Items", Description: "Notifications, each with Title, Body, ActionURL and CreatedAt", Required: true},
+		},
+		HTMLBody: notificationDigestHTML,
+		TextBody: notificationDigestText,
+	}
 }
 
 // Template HTML/Text content
@@ -929,3 +1170,59 @@ Distance: {{.Distance}}
 
 ---
 Navo Maritime Platform`
+
+const notificationDigestHTML = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"></head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f1f5f9;">
+	<table width="100%" cellpadding="0" cellspacing="0" style="padding: 40px 20px;">
+		<tr>
+			<td align="center">
+				<table width="600" cellpadding="0" cellspacing="0" style="background-color: #ffffff; border-radius: 8px;">
+					<tr>
+						<td style="background-color: #0f172a; padding: 24px; border-radius: 8px 8px 0 0;">
+							<h1 style="color: #ffffff; margin: 0;">Navo Maritime</h1>
+						</td>
+					</tr>
+					<tr>
+						<td style="padding: 32px 24px;">
+							<p style="color: #475569; margin: 0 0 24px 0;">Here is your {{.Period}} summary of {{.Count}} notification(s).</p>
+
+							{{range .Items}}
+							<table width="100%" style="background-color: #f8fafc; border-radius: 8px; padding: 16px; margin-bottom: 12px;">
+								<tr>
+									<td>
+										<p style="margin: 0; color: #64748b; font-size: 12px;">{{.CreatedAt}}</p>
+										<p style="margin: 4px 0 8px 0; color: #0f172a; font-weight: 600;">{{.Title}}</p>
+										<p style="margin: 0; color: #475569;">{{.Body}}</p>
+										{{if .ActionURL}}<p style="margin: 8px 0 0 0;"><a href="{{.ActionURL}}" style="color: #f59e0b; font-weight: 600;">View Details</a></p>{{end}}
+									</td>
+								</tr>
+							</table>
+							{{end}}
+						</td>
+					</tr>
+					<tr>
+						<td style="background-color: #f8fafc; padding: 24px; border-radius: 0 0 8px 8px;">
+							<p style="color: #94a3b8; margin: 0; font-size: 14px; text-align: center;">You can change how often you receive digests in your notification preferences.<br>&copy; {{.Year}} Navo Maritime</p>
+						</td>
+					</tr>
+				</table>
+			</td>
+		</tr>
+	</table>
+</body>
+</html>`
+
+const notificationDigestText = `Your Navo Digest
+
+Here is your {{.Period}} summary of {{.Count}} notification(s):
+{{range .Items}}
+- {{.Title}} ({{.CreatedAt}})
+  {{.Body}}{{if .ActionURL}}
+  {{.ActionURL}}{{end}}
+{{end}}
+You can change how often you receive digests in your notification preferences.
+
+---
+Navo Maritime Platform`
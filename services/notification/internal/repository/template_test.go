@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltInTemplatesDeclareVariables(t *testing.T) {
+	repo := NewTemplateRepository(nil)
+	for _, tmpl := range repo.List() {
+		assert.NoError(t, tmpl.CheckVariables(), tmpl.Name)
+	}
+}
+
+func TestResolveWithoutDatabase(t *testing.T) {
+	repo := NewTemplateRepository(nil)
+
+	tmpl, err := repo.Resolve(context.Background(), "org-1", "quote_received", "pt-BR")
+	require.NoError(t, err)
+	assert.Equal(t, "quote_received", tmpl.Name)
+	assert.Zero(t, tmpl.Version, "built-in templates are the defaults")
+
+	_, err = repo.Resolve(context.Background(), "org-1", "no_such_template", "")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	assert.ErrorIs(t, repo.SaveVersion(context.Background(), tmpl), ErrTemplateStoreUnavailable)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/navo/services/notification/internal/channel"
+	"github.com/navo/services/notification/internal/model"
+)
+
+// sendViaProvider delivers a notification through the provider of its channel
+func (s *NotificationService) sendViaProvider(ctx context.Context, notification *model.Notification) error {
+	delivery, err := s.providers.Send(ctx, notification)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	notification.Status = delivery.Status
+	notification.SentAt = &now
+	if delivery.Status == model.NotificationStatusDelivered {
+		notification.DeliveredAt = &now
+	}
+	notification.Provider = delivery.Provider
+	notification.ProviderMessageID = delivery.MessageID
+	return nil
+}
+
+// requeueRateLimited reschedules a notification its provider is rate limiting.
+// It returns false for other errors.
+func requeueRateLimited(notification *model.Notification, err error, now time.Time) bool {
+	var rateErr *channel.RateLimitError
+	if !errors.As(err, &rateErr) {
+		return false
+	}
+	retryAt := now.Add(rateErr.RetryAfter)
+	notification.Status = model.NotificationStatusQueued
+	notification.ScheduledFor = &retryAt
+	return true
+}
+
+// HandleDeliveryCallback applies a provider's delivery status callback to
+// the notifications it reports on. It returns the number updated.
+func (s *NotificationService) HandleDeliveryCallback(ctx context.Context, provider string, r *http.Request) (int, error) {
+	statuses, err := s.providers.ParseCallback(provider, r)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, status := range statuses {
+		notification, err := s.notificationRepo.GetByProviderMessage(ctx, provider, status.MessageID)
+		if err != nil {
+			log.Printf("[NotificationService] Delivery callback for unknown %s message %s", provider, status.MessageID)
+			continue
+		}
+
+		// Read notifications stay read
+		if notification.Status == model.NotificationStatusRead {
+			continue
+		}
+
+		at := status.At
+		switch status.Status {
+		case model.NotificationStatusDelivered:
+			notification.Status = model.NotificationStatusDelivered
+			notification.DeliveredAt = &at
+		case model.NotificationStatusFailed:
+			notification.Status = model.NotificationStatusFailed
+			notification.FailedAt = &at
+			notification.FailureReason = status.Reason
+		default:
+			continue
+		}
+
+		if err := s.notificationRepo.Save(ctx, notification); err != nil {
+			return updated, fmt.Errorf("failed to save notification: %w", err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// SavePushSubscription registers a browser for a user's push notifications
+func (s *NotificationService) SavePushSubscription(ctx context.Context, userID string, subscription *model.PushSubscription) error {
+	if err := channel.ValidateSubscription(subscription); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	subscription.CreatedAt = time.Now().UTC()
+	return s.pushRepo.SavePushSubscription(ctx, userID, subscription)
+}
+
+// DeletePushSubscription unregisters a browser from a user's push notifications
+func (s *NotificationService) DeletePushSubscription(ctx context.Context, userID, endpoint string) error {
+	return s.pushRepo.DeletePushSubscription(ctx, userID, endpoint)
+}
+
+// VAPIDPublicKey returns the key browsers subscribe to push notifications
+// with, or "" when Web Push is not configured
+func (s *NotificationService) VAPIDPublicKey() string {
+	provider, ok := s.providers.Get("webpush")
+	if !ok {
+		return ""
+	}
+	webPush, ok := provider.(*channel.WebPushProvider)
+	if !ok {
+		return ""
+	}
+	return webPush.PublicKey()
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navo/services/notification/internal/model"
+	"github.com/navo/services/notification/internal/repository"
+)
+
+const (
+	// escalationLockTTL bounds how long a crashed worker can hold an escalation
+	escalationLockTTL = time.Minute
+	// escalationLockWait is how long an acknowledgement waits for a worker
+	// sending a step to finish
+	escalationLockWait = 10 * time.Second
+)
+
+// ListEscalationPolicies lists the escalation policies of an organization
+func (s *NotificationService) ListEscalationPolicies(ctx context.Context, organizationID string) ([]*model.EscalationPolicy, error) {
+	return s.escalationRepo.ListPolicies(ctx, organizationID)
+}
+
+// GetEscalationPolicy retrieves an escalation policy of an organization
+func (s *NotificationService) GetEscalationPolicy(ctx context.Context, organizationID, id string) (*model.EscalationPolicy, error) {
+	policy, err := s.escalationRepo.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if policy.OrganizationID != organizationID {
+		return nil, fmt.Errorf("%w: %s", repository.ErrEscalationPolicyNotFound, id)
+	}
+	return policy, nil
+}
+
+// CreateEscalationPolicy creates an escalation policy for an organization
+func (s *NotificationService) CreateEscalationPolicy(ctx context.Context, organizationID string, policy *model.EscalationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	now := time.Now().UTC()
+	policy.ID = uuid.New().String()
+	policy.OrganizationID = organizationID
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	return s.escalationRepo.SavePolicy(ctx, policy)
+}
+
+// UpdateEscalationPolicy replaces an escalation policy of an organization.
+// Escalations already running keep the steps they started with.
+func (s *NotificationService) UpdateEscalationPolicy(ctx context.Context, organizationID, id string, policy *model.EscalationPolicy) error {
+	existing, err := s.GetEscalationPolicy(ctx, organizationID, id)
+	if err != nil {
+		return err
+	}
+	if err := policy.Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	policy.ID = existing.ID
+	policy.OrganizationID = existing.OrganizationID
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now().UTC()
+	return s.escalationRepo.SavePolicy(ctx, policy)
+}
+
+// DeleteEscalationPolicy deletes an escalation policy of an organization
+func (s *NotificationService) DeleteEscalationPolicy(ctx context.Context, organizationID, id string) error {
+	policy, err := s.GetEscalationPolicy(ctx, organizationID, id)
+	if err != nil {
+		return err
+	}
+	return s.escalationRepo.DeletePolicy(ctx, policy)
+}
+
+// findEscalationPolicy returns the organization's enabled policy for a
+// trigger, falling back to its catch-all policy, or nil
+func (s *NotificationService) findEscalationPolicy(ctx context.Context, organizationID, trigger string) (*model.EscalationPolicy, error) {
+	policies, err := s.escalationRepo.ListPolicies(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fallback *model.EscalationPolicy
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		switch policy.Trigger {
+		case trigger:
+			return policy, nil
+		case model.EscalationTriggerAny:
+			fallback = policy
+		}
+	}
+	return fallback, nil
+}
+
+// startEscalation starts the organization's escalation policy for the
+// trigger of a notification request. Notifications are still sent when no
+// escalation can be started.
+func (s *NotificationService) startEscalation(ctx context.Context, req *model.SendNotificationRequest, notification *model.Notification, now time.Time) {
+	if req.OrganizationID == "" {
+		log.Printf("[NotificationService] Cannot escalate notification %s without an organization", notification.ID)
+		return
+	}
+
+	policy, err := s.findEscalationPolicy(ctx, req.OrganizationID, req.Escalation)
+	if err != nil {
+		log.Printf("[NotificationService] Failed to find escalation policy for %s: %v", req.Escalation, err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+
+	nextAt := now.Add(time.Duration(policy.Steps[0].AfterMinutes) * time.Minute)
+	escalation := &model.Escalation{
+		ID:             uuid.New().String(),
+		PolicyID:       policy.ID,
+		OrganizationID: req.OrganizationID,
+		Trigger:        req.Escalation,
+		NotificationID: notification.ID,
+		Status:         model.EscalationStatusPending,
+		Steps:          policy.Steps,
+		NextAt:         &nextAt,
+		Assignee: model.EscalationRecipient{
+			Role:   model.EscalationRoleAssignee,
+			UserID: req.UserID,
+			Email:  req.Email,
+			Phone:  req.Phone,
+		},
+		Category:        notification.Category,
+		Title:           notification.Title,
+		Body:            notification.Body,
+		ActionURL:       notification.ActionURL,
+		EntityType:      notification.EntityType,
+		EntityID:        notification.EntityID,
+		NotificationIDs: []string{notification.ID},
+		CreatedAt:       now,
+	}
+
+	if err := s.escalationRepo.Save(ctx, escalation); err != nil {
+		log.Printf("[NotificationService] Failed to start escalation for notification %s: %v", notification.ID, err)
+		return
+	}
+	notification.EscalationID = escalation.ID
+}
+
+// ProcessDueEscalations sends the next step of every escalation that went
+// unacknowledged for long enough
+func (s *NotificationService) ProcessDueEscalations(ctx context.Context) error {
+	now := time.Now().UTC()
+	ids, err := s.escalationRepo.GetDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.advanceEscalation(ctx, id, now); err != nil {
+			log.Printf("[NotificationService] Failed to advance escalation %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// advanceEscalation sends the next step of an escalation. Escalations locked
+// by another worker are left to it.
+func (s *NotificationService) advanceEscalation(ctx context.Context, id string, now time.Time) error {
+	token, err := s.escalationRepo.Lock(ctx, id, escalationLockTTL)
+	if err != nil || token == "" {
+		return err
+	}
+	defer s.escalationRepo.Unlock(ctx, id, token)
+
+	escalation, err := s.escalationRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if escalation.Status != model.EscalationStatusPending || escalation.StepsDone >= len(escalation.Steps) {
+		// Acknowledged since it was listed; saving clears it from the due set
+		return s.escalationRepo.Save(ctx, escalation)
+	}
+	if escalation.NextAt != nil && escalation.NextAt.After(now) {
+		return nil // Advanced by another worker
+	}
+
+	step := escalation.Steps[escalation.StepsDone]
+	for _, recipient := range step.Recipients {
+		if recipient.Role == model.EscalationRoleAssignee {
+			recipient = escalation.Assignee
+		}
+		for _, channel := range step.Channels {
+			notification, err := s.Send(ctx, escalationRequest(escalation, recipient, channel))
+			if err != nil {
+				log.Printf("[NotificationService] Failed to send escalation %s step %d to %s: %v", escalation.ID, escalation.StepsDone+1, recipient.UserID, err)
+				continue
+			}
+			escalation.NotificationIDs = append(escalation.NotificationIDs, notification.ID)
+		}
+	}
+
+	escalation.StepsDone++
+	if escalation.StepsDone < len(escalation.Steps) {
+		nextAt := now.Add(time.Duration(escalation.Steps[escalation.StepsDone].AfterMinutes) * time.Minute)
+		escalation.NextAt = &nextAt
+	} else {
+		escalation.Status = model.EscalationStatusExhausted
+		escalation.NextAt = nil
+		log.Printf("[NotificationService] Escalation %s exhausted without acknowledgement", escalation.ID)
+	}
+	return s.escalationRepo.Save(ctx, escalation)
+}
+
+// escalationRequest builds the notification of an escalation step for one
+// recipient and channel
+func escalationRequest(escalation *model.Escalation, recipient model.EscalationRecipient, channel model.NotificationType) *model.SendNotificationRequest {
+	return &model.SendNotificationRequest{
+		Type:           channel,
+		Category:       escalation.Category,
+		Priority:       model.NotificationPriorityCritical,
+		UserID:         recipient.UserID,
+		OrganizationID: escalation.OrganizationID,
+		Email:          recipient.Email,
+		Phone:          recipient.Phone,
+		Title:          "Unacknowledged: " + escalation.Title,
+		Body:           escalation.Body,
+		EntityType:     escalation.EntityType,
+		EntityID:       escalation.EntityID,
+		ActionURL:      escalation.ActionURL,
+		Metadata: map[string]string{
+			"escalation_id":   escalation.ID,
+			"escalation_step": strconv.Itoa(escalation.StepsDone + 1),
+		},
+		EscalationID: escalation.ID,
+	}
+}
+
+// Acknowledge records that a user has taken responsibility for one of their
+// notifications, stopping its escalation. Unlike reading, acknowledging is an
+// explicit action. Other users' notifications are not found.
+func (s *NotificationService) Acknowledge(ctx context.Context, organizationID, id, userID string) (*model.Notification, error) {
+	notification, err := s.notificationRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if notification.UserID != userID ||
+		notification.OrganizationID != "" && notification.OrganizationID != organizationID {
+		return nil, fmt.Errorf("%w: %s", repository.ErrNotificationNotFound, id)
+	}
+
+	if notification.EscalationID != "" {
+		if _, err := s.acknowledgeEscalation(ctx, notification.EscalationID, userID); err != nil {
+			return nil, err
+		}
+		// Reload the notification, acknowledged along with its escalation
+		return s.notificationRepo.Get(ctx, id)
+	}
+
+	if notification.AcknowledgedAt == nil {
+		now := time.Now().UTC()
+		notification.AcknowledgedAt = &now
+		notification.AcknowledgedBy = userID
+		if err := s.notificationRepo.Save(ctx, notification); err != nil {
+			return nil, err
+		}
+	}
+	return notification, nil
+}
+
+// ListEscalations lists the escalations of an organization, newest first
+func (s *NotificationService) ListEscalations(ctx context.Context, organizationID string, limit, offset int) ([]*model.Escalation, error) {
+	return s.escalationRepo.GetByOrganization(ctx, organizationID, limit, offset)
+}
+
+// GetEscalation retrieves an escalation of an organization
+func (s *NotificationService) GetEscalation(ctx context.Context, organizationID, id string) (*model.Escalation, error) {
+	escalation, err := s.escalationRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if escalation.OrganizationID != organizationID {
+		return nil, fmt.Errorf("%w: %s", repository.ErrEscalationNotFound, id)
+	}
+	return escalation, nil
+}
+
+// AcknowledgeEscalation acknowledges an escalation of an organization
+func (s *NotificationService) AcknowledgeEscalation(ctx context.Context, organizationID, id, userID string) (*model.Escalation, error) {
+	if _, err := s.GetEscalation(ctx, organizationID, id); err != nil {
+		return nil, err
+	}
+	return s.acknowledgeEscalation(ctx, id, userID)
+}
+
+// acknowledgeEscalation stops an escalation and acknowledges every
+// notification it sent. It waits for a worker sending a step, so the
+// acknowledgement is not overwritten.
+func (s *NotificationService) acknowledgeEscalation(ctx context.Context, id, userID string) (*model.Escalation, error) {
+	token, err := s.lockEscalation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer s.escalationRepo.Unlock(ctx, id, token)
+
+	escalation, err := s.escalationRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if escalation.AcknowledgedAt != nil {
+		return escalation, nil
+	}
+
+	now := time.Now().UTC()
+	escalation.Status = model.EscalationStatusAcknowledged
+	escalation.NextAt = nil
+	escalation.AcknowledgedAt = &now
+	escalation.AcknowledgedBy = userID
+	if err := s.escalationRepo.Save(ctx, escalation); err != nil {
+		return nil, err
+	}
+
+	for _, notificationID := range escalation.NotificationIDs {
+		notification, err := s.notificationRepo.Get(ctx, notificationID)
+		if err != nil || notification.AcknowledgedAt != nil {
+			continue
+		}
+		notification.AcknowledgedAt = &now
+		notification.AcknowledgedBy = userID
+		if err := s.notificationRepo.Save(ctx, notification); err != nil {
+			log.Printf("[NotificationService] Failed to acknowledge notification %s: %v", notificationID, err)
+		}
+	}
+	return escalation, nil
+}
+
+// lockEscalation takes the lock of an escalation, waiting up to
+// escalationLockWait while it is held
+func (s *NotificationService) lockEscalation(ctx context.Context, id string) (string, error) {
+	deadline := time.Now().Add(escalationLockWait)
+	for {
+		token, err := s.escalationRepo.Lock(ctx, id, escalationLockTTL)
+		if err != nil {
+			return "", err
+		}
+		if token != "" {
+			return token, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("escalation %s is locked", id)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
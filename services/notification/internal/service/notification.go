@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/navo/services/notification/internal/channel"
 	"github.com/navo/services/notification/internal/model"
 	"github.com/navo/services/notification/internal/repository"
 )
@@ -18,6 +20,10 @@ type NotificationService struct {
 	emailService     *EmailService
 	notificationRepo *repository.NotificationRepository
 	templateRepo     *repository.TemplateRepository
+	preferencesRepo  *repository.PreferencesRepository
+	pushRepo         *repository.PushSubscriptionRepository
+	escalationRepo   *repository.EscalationRepository
+	providers        *channel.Registry
 	redis            *redis.Client
 }
 
@@ -26,12 +32,20 @@ func NewNotificationService(
 	emailService *EmailService,
 	notificationRepo *repository.NotificationRepository,
 	templateRepo *repository.TemplateRepository,
+	preferencesRepo *repository.PreferencesRepository,
+	pushRepo *repository.PushSubscriptionRepository,
+	escalationRepo *repository.EscalationRepository,
+	providers *channel.Registry,
 	redisClient *redis.Client,
 ) *NotificationService {
 	return &NotificationService{
 		emailService:     emailService,
 		notificationRepo: notificationRepo,
 		templateRepo:     templateRepo,
+		preferencesRepo:  preferencesRepo,
+		pushRepo:         pushRepo,
+		escalationRepo:   escalationRepo,
+		providers:        providers,
 		redis:            redisClient,
 	}
 }
@@ -41,25 +55,29 @@ func (s *NotificationService) Send(ctx context.Context, req *model.SendNotificat
 	// Create notification record
 	now := time.Now().UTC()
 	notification := &model.Notification{
-		ID:           uuid.New().String(),
-		Type:         req.Type,
-		Category:     req.Category,
-		Priority:     req.Priority,
-		Status:       model.NotificationStatusPending,
-		UserID:       req.UserID,
-		Email:        req.Email,
-		Subject:      req.Subject,
-		Title:        req.Title,
-		Body:         req.Body,
-		TemplateName: req.TemplateName,
-		TemplateData: req.TemplateData,
-		EntityType:   req.EntityType,
-		EntityID:     req.EntityID,
-		ActionURL:    req.ActionURL,
-		Metadata:     req.Metadata,
-		ScheduledFor: req.ScheduledFor,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:             uuid.New().String(),
+		Type:           req.Type,
+		Category:       req.Category,
+		Priority:       req.Priority,
+		Status:         model.NotificationStatusPending,
+		UserID:         req.UserID,
+		OrganizationID: req.OrganizationID,
+		Email:          req.Email,
+		Phone:          req.Phone,
+		Target:         req.Target,
+		Subject:        req.Subject,
+		Title:          req.Title,
+		Body:           req.Body,
+		TemplateName:   req.TemplateName,
+		TemplateData:   req.TemplateData,
+		EntityType:     req.EntityType,
+		EntityID:       req.EntityID,
+		ActionURL:      req.ActionURL,
+		Metadata:       req.Metadata,
+		ScheduledFor:   req.ScheduledFor,
+		EscalationID:   req.EscalationID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
 	// Set default priority
@@ -67,6 +85,21 @@ func (s *NotificationService) Send(ctx context.Context, req *model.SendNotificat
 		notification.Priority = model.NotificationPriorityNormal
 	}
 
+	// Start escalating before delivery, so the notification can be
+	// acknowledged whatever happens to it
+	if req.Escalation != "" && req.EscalationID == "" {
+		s.startEscalation(ctx, req, notification, now)
+	}
+
+	// Honor the recipient's opt-outs, digest and quiet hours
+	handled, err := s.applyPreferences(ctx, notification, now)
+	if err != nil {
+		return nil, err
+	}
+	if handled {
+		return notification, nil
+	}
+
 	// If scheduled for later, just save and return
 	if notification.ScheduledFor != nil && notification.ScheduledFor.After(now) {
 		notification.Status = model.NotificationStatusQueued
@@ -78,9 +111,11 @@ func (s *NotificationService) Send(ctx context.Context, req *model.SendNotificat
 
 	// Process immediately
 	if err := s.processNotification(ctx, notification); err != nil {
-		notification.Status = model.NotificationStatusFailed
-		notification.FailureReason = err.Error()
-		notification.FailedAt = &now
+		if !requeueRateLimited(notification, err, now) {
+			notification.Status = model.NotificationStatusFailed
+			notification.FailureReason = err.Error()
+			notification.FailedAt = &now
+		}
 	}
 
 	// Save notification
@@ -96,18 +131,20 @@ func (s *NotificationService) Send(ctx context.Context, req *model.SendNotificat
 	return notification, nil
 }
 
-// SendBatch sends multiple notifications
-func (s *NotificationService) SendBatch(ctx context.Context, req *model.BatchNotificationRequest) ([]*model.Notification, error) {
-	results := make([]*model.Notification, 0, len(req.Notifications))
-	for _, notifReq := range req.Notifications {
-		notification, err := s.Send(ctx, &notifReq)
+// SendBatch sends multiple notifications. Each request gets a result at
+// its index; a failed notification doesn't stop the others.
+func (s *NotificationService) SendBatch(ctx context.Context, req *model.BatchNotificationRequest) []model.BatchNotificationResult {
+	results := make([]model.BatchNotificationResult, len(req.Notifications))
+	for i := range req.Notifications {
+		notification, err := s.Send(ctx, &req.Notifications[i])
 		if err != nil {
 			log.Printf("[NotificationService] Failed to send notification: %v", err)
+			results[i].Error = err.Error()
 			continue
 		}
-		results = append(results, notification)
+		results[i].Notification = notification
 	}
-	return results, nil
+	return results
 }
 
 // processNotification handles the actual notification delivery
@@ -119,10 +156,11 @@ func (s *NotificationService) processNotification(ctx context.Context, notificat
 		return s.sendEmailNotification(ctx, notification)
 	case model.NotificationTypeInApp:
 		return s.sendInAppNotification(ctx, notification)
-	case model.NotificationTypePush:
-		return s.sendPushNotification(ctx, notification)
 	default:
-		return fmt.Errorf("unsupported notification type: %s", notification.Type)
+		if !s.providers.Has(notification.Type) {
+			return fmt.Errorf("unsupported notification type: %s", notification.Type)
+		}
+		return s.sendViaProvider(ctx, notification)
 	}
 }
 
@@ -136,7 +174,7 @@ func (s *NotificationService) sendEmailNotification(ctx context.Context, notific
 
 	// Check if using a template
 	if notification.TemplateName != "" {
-		tmpl, err := s.templateRepo.Get(notification.TemplateName)
+		tmpl, err := s.templateRepo.Resolve(ctx, notification.OrganizationID, notification.TemplateName, notification.Locale)
 		if err != nil {
 			return fmt.Errorf("failed to get template: %w", err)
 		}
@@ -150,6 +188,10 @@ func (s *NotificationService) sendEmailNotification(ctx context.Context, notific
 		data["Body"] = notification.Body
 		data["ActionURL"] = notification.ActionURL
 		data["Year"] = time.Now().Year()
+		tmpl.ApplyDefaults(data)
+		if missing := tmpl.MissingVariables(data); len(missing) > 0 {
+			return fmt.Errorf("missing template variables: %s", strings.Join(missing, ", "))
+		}
 
 		emailMsg, err = s.emailService.RenderTemplate(tmpl, data)
 		if err != nil {
@@ -196,16 +238,6 @@ func (s *NotificationService) sendInAppNotification(ctx context.Context, notific
 	return nil
 }
 
-// sendPushNotification sends a push notification
-func (s *NotificationService) sendPushNotification(ctx context.Context, notification *model.Notification) error {
-	// Push notification implementation would go here
-	// For now, just mark as sent
-	now := time.Now().UTC()
-	notification.Status = model.NotificationStatusSent
-	notification.SentAt = &now
-	return nil
-}
-
 // publishNotificationEvent publishes a real-time notification event
 func (s *NotificationService) publishNotificationEvent(ctx context.Context, notification *model.Notification) {
 	event := model.NotificationEvent{
@@ -242,6 +274,15 @@ func (s *NotificationService) GetByUser(ctx context.Context, userID string, limi
 	return s.notificationRepo.GetByUser(ctx, userID, limit, offset)
 }
 
+// Search retrieves the notifications matching a filter and the number
+// matching it in total
+func (s *NotificationService) Search(ctx context.Context, filter *model.NotificationFilter) ([]*model.Notification, int, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, 0, &ValidationError{Message: err.Error()}
+	}
+	return s.notificationRepo.Search(ctx, filter)
+}
+
 // MarkAsRead marks a notification as read
 func (s *NotificationService) MarkAsRead(ctx context.Context, id string) error {
 	notification, err := s.notificationRepo.Get(ctx, id)
@@ -267,26 +308,6 @@ func (s *NotificationService) Delete(ctx context.Context, id string) error {
 	return s.notificationRepo.Delete(ctx, id)
 }
 
-// GetTemplate retrieves a template by name
-func (s *NotificationService) GetTemplate(name string) (*model.Template, error) {
-	return s.templateRepo.Get(name)
-}
-
-// ListTemplates lists all available templates
-func (s *NotificationService) ListTemplates() []*model.Template {
-	return s.templateRepo.List()
-}
-
-// PreviewTemplate previews a rendered template
-func (s *NotificationService) PreviewTemplate(name string, data map[string]any) (*model.EmailMessage, error) {
-	tmpl, err := s.templateRepo.Get(name)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.emailService.RenderTemplate(tmpl, data)
-}
-
 // ProcessPendingNotifications processes notifications that are scheduled
 func (s *NotificationService) ProcessPendingNotifications(ctx context.Context) error {
 	notifications, err := s.notificationRepo.GetPending(ctx)
@@ -296,6 +317,10 @@ func (s *NotificationService) ProcessPendingNotifications(ctx context.Context) e
 
 	for _, notification := range notifications {
 		if err := s.processNotification(ctx, notification); err != nil {
+			if requeueRateLimited(notification, err, time.Now().UTC()) {
+				s.notificationRepo.Save(ctx, notification)
+				continue
+			}
 			log.Printf("[NotificationService] Failed to process notification %s: %v", notification.ID, err)
 			notification.RetryCount++
 			if notification.RetryCount >= 3 {
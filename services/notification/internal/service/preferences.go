@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// maxDigestRetries is how often sending a digest is retried before its
+// notifications are marked as failed
+const maxDigestRetries = 3
+
+// digestRetryDelay is how long a digest that failed to send waits for a retry
+const digestRetryDelay = 5 * time.Minute
+
+// GetPreferences retrieves a user's notification preferences
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (*model.UserNotificationPreferences, error) {
+	return s.preferencesRepo.Get(ctx, userID)
+}
+
+// UpdatePreferences validates and stores a user's notification preferences
+func (s *NotificationService) UpdatePreferences(ctx context.Context, prefs *model.UserNotificationPreferences) error {
+	if prefs.CategoryPrefs == nil {
+		prefs.CategoryPrefs = make(map[model.NotificationCategory]bool)
+	}
+	if prefs.DigestFrequency == "" {
+		prefs.DigestFrequency = model.DigestNone
+	}
+	if prefs.Timezone == "" {
+		prefs.Timezone = "UTC"
+	}
+	if err := prefs.Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	prefs.Locale = model.NormalizeLocale(prefs.Locale)
+	return s.preferencesRepo.Save(ctx, prefs)
+}
+
+// ValidationError is returned for invalid input
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// applyPreferences applies the recipient's preferences to a new notification.
+// It returns true when the notification was handled: suppressed by an
+// opt-out or added to the user's digest. Notifications that would be sent
+// during quiet hours are rescheduled for when they end.
+func (s *NotificationService) applyPreferences(ctx context.Context, notification *model.Notification, now time.Time) (bool, error) {
+	prefs, err := s.preferencesRepo.Get(ctx, notification.UserID)
+	if err != nil {
+		// Don't lose notifications because preferences are unavailable
+		log.Printf("[NotificationService] Failed to get preferences of user %s: %v", notification.UserID, err)
+		return false, nil
+	}
+
+	if notification.Locale == "" {
+		notification.Locale = prefs.Locale
+	}
+
+	if reason := prefs.OptOutReason(notification); reason != "" {
+		notification.Status = model.NotificationStatusSuppressed
+		notification.FailureReason = reason
+		return true, nil
+	}
+
+	if prefs.Batches(notification) {
+		notification.Status = model.NotificationStatusBatched
+		if err := s.notificationRepo.Save(ctx, notification); err != nil {
+			return true, fmt.Errorf("failed to save notification: %w", err)
+		}
+		if err := s.notificationRepo.AddToDigest(ctx, notification.UserID, notification.ID, prefs.NextDigestAt(now)); err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	sendAt := now
+	if notification.ScheduledFor != nil && notification.ScheduledFor.After(now) {
+		sendAt = *notification.ScheduledFor
+	}
+	if prefs.Defers(notification, sendAt) {
+		until := prefs.QuietHoursEndAfter(sendAt)
+		notification.ScheduledFor = &until
+	}
+	return false, nil
+}
+
+// SendDueDigests sends the digests that are due, one email per user
+func (s *NotificationService) SendDueDigests(ctx context.Context) error {
+	now := time.Now().UTC()
+	userIDs, err := s.notificationRepo.GetDueDigests(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		notifications, err := s.notificationRepo.TakeDigest(ctx, userID)
+		if err != nil {
+			log.Printf("[NotificationService] Failed to take digest of user %s: %v", userID, err)
+			continue
+		}
+		if err := s.sendDigest(ctx, userID, notifications); err != nil {
+			log.Printf("[NotificationService] Failed to send digest of user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// sendDigest sends the batched notifications as one email
+func (s *NotificationService) sendDigest(ctx context.Context, userID string, notifications []*model.Notification) error {
+	batched := make([]*model.Notification, 0, len(notifications))
+	email, organizationID := "", ""
+	for _, notification := range notifications {
+		// Skip notifications read or deleted in the meantime
+		if notification.Status != model.NotificationStatusBatched {
+			continue
+		}
+		batched = append(batched, notification)
+		if notification.Email != "" {
+			email = notification.Email
+		}
+		if notification.OrganizationID != "" {
+			organizationID = notification.OrganizationID
+		}
+	}
+	if len(batched) == 0 {
+		return nil
+	}
+
+	prefs, err := s.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		prefs = model.DefaultNotificationPreferences(userID)
+	}
+
+	err = s.sendDigestEmail(ctx, email, organizationID, prefs, batched)
+	now := time.Now().UTC()
+	for _, notification := range batched {
+		switch {
+		case err == nil:
+			notification.Status = model.NotificationStatusSent
+			notification.SentAt = &now
+		case notification.RetryCount+1 >= maxDigestRetries:
+			notification.Status = model.NotificationStatusFailed
+			notification.FailureReason = err.Error()
+			notification.FailedAt = &now
+		default:
+			notification.RetryCount++
+			s.notificationRepo.AddToDigest(ctx, userID, notification.ID, now.Add(digestRetryDelay))
+		}
+		s.notificationRepo.Save(ctx, notification)
+	}
+	return err
+}
+
+// sendDigestEmail renders and sends the digest email, with the
+// organization's template in the user's language
+func (s *NotificationService) sendDigestEmail(ctx context.Context, email, organizationID string, prefs *model.UserNotificationPreferences, notifications []*model.Notification) error {
+	if email == "" {
+		return fmt.Errorf("email address is required for digest")
+	}
+
+	tmpl, err := s.templateRepo.Resolve(ctx, organizationID, "notification_digest", prefs.Locale)
+	if err != nil {
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	items := make([]map[string]any, 0, len(notifications))
+	for _, notification := range notifications {
+		items = append(items, map[string]any{
+			"Title":     notification.Title,
+			"Body":      notification.Body,
+			"ActionURL": notification.ActionURL,
+			"CreatedAt": notification.CreatedAt.In(loc).Format("Jan 2, 15:04"),
+		})
+	}
+
+	period := string(prefs.DigestFrequency)
+	if !prefs.DigestFrequency.IsValid() || prefs.DigestFrequency == model.DigestNone {
+		period = string(model.DigestDaily)
+	}
+
+	emailMsg, err := s.emailService.RenderTemplate(tmpl, map[string]any{
+		"Count":  len(items),
+		"Period": period,
+		"Items":  items,
+		"Year":   time.Now().Year(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	emailMsg.To = []string{email}
+
+	if err := s.emailService.SendEmail(ctx, emailMsg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// GetRetention retrieves how long an organization's notifications are kept
+func (s *NotificationService) GetRetention(ctx context.Context, organizationID string) (*model.NotificationRetention, error) {
+	return s.notificationRepo.GetRetention(ctx, organizationID)
+}
+
+// UpdateRetention sets how long an organization's notifications are kept.
+// Older notifications are deleted by the next cleanup.
+func (s *NotificationService) UpdateRetention(ctx context.Context, retention *model.NotificationRetention) error {
+	if err := retention.Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	return s.notificationRepo.SaveRetention(ctx, retention)
+}
+
+// CleanupExpired deletes the notifications older than their organization's
+// retention
+func (s *NotificationService) CleanupExpired(ctx context.Context) error {
+	deleted, err := s.notificationRepo.Cleanup(ctx)
+	if deleted > 0 {
+		log.Printf("[NotificationService] Deleted %d expired notifications", deleted)
+	}
+	return err
+}
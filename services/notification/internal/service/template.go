@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/navo/services/notification/internal/model"
+)
+
+// TemplatePreview is a template rendered against sample data
+type TemplatePreview struct {
+	Template *model.Template     `json:"template"`
+	Data     map[string]any      `json:"data"`
+	Message  *model.EmailMessage `json:"message"`
+}
+
+// ListTemplates lists the built-in templates
+func (s *NotificationService) ListTemplates() []*model.Template {
+	return s.templateRepo.List()
+}
+
+// ListTemplateOverrides lists an organization's current template overrides
+func (s *NotificationService) ListTemplateOverrides(ctx context.Context, organizationID string) ([]*model.Template, error) {
+	return s.templateRepo.ListOverrides(ctx, organizationID)
+}
+
+// GetTemplate retrieves the template an organization sends in a locale
+func (s *NotificationService) GetTemplate(ctx context.Context, organizationID, name, locale string) (*model.Template, error) {
+	return s.templateRepo.Resolve(ctx, organizationID, name, locale)
+}
+
+// SaveTemplateOverride stores a new version of an organization's override
+// of a built-in template in a locale. Parts left empty keep the built-in
+// wording.
+func (s *NotificationService) SaveTemplateOverride(ctx context.Context, organizationID, userID, name string, override *model.Template) error {
+	tmpl, err := s.templateOverride(name, override)
+	if err != nil {
+		return err
+	}
+	tmpl.OrganizationID = organizationID
+	tmpl.CreatedBy = userID
+
+	if err := s.templateRepo.SaveVersion(ctx, tmpl); err != nil {
+		return err
+	}
+	*override = *tmpl
+	return nil
+}
+
+// templateOverride completes and validates an override of a built-in template
+func (s *NotificationService) templateOverride(name string, override *model.Template) (*model.Template, error) {
+	builtIn, err := s.templateRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := model.NormalizeLocale(override.Locale)
+	if override.Locale != "" && locale == "" {
+		return nil, &ValidationError{Message: fmt.Sprintf("invalid locale: %s", override.Locale)}
+	}
+
+	tmpl := &model.Template{
+		Name:        builtIn.Name,
+		Subject:     override.Subject,
+		HTMLBody:    override.HTMLBody,
+		TextBody:    override.TextBody,
+		Category:    builtIn.Category,
+		Variables:   builtIn.Variables,
+		Description: builtIn.Description,
+		Locale:      locale,
+	}
+	if tmpl.Subject == "" {
+		tmpl.Subject = builtIn.Subject
+	}
+	if tmpl.HTMLBody == "" {
+		tmpl.HTMLBody = builtIn.HTMLBody
+	}
+	if tmpl.TextBody == "" {
+		tmpl.TextBody = builtIn.TextBody
+	}
+
+	if err := tmpl.CheckVariables(); err != nil {
+		return nil, &ValidationError{Message: err.Error()}
+	}
+	return tmpl, nil
+}
+
+// DeleteTemplateOverride reverts an organization's template in a locale to
+// the default. Earlier versions are kept and can be restored.
+func (s *NotificationService) DeleteTemplateOverride(ctx context.Context, organizationID, userID, name, locale string) error {
+	if _, err := s.templateRepo.Get(name); err != nil {
+		return err
+	}
+	return s.templateRepo.SaveVersion(ctx, &model.Template{
+		Name:           name,
+		OrganizationID: organizationID,
+		Locale:         model.NormalizeLocale(locale),
+		Deleted:        true,
+		CreatedBy:      userID,
+	})
+}
+
+// ListTemplateVersions lists the versions of an organization's override of
+// a template in a locale, newest first
+func (s *NotificationService) ListTemplateVersions(ctx context.Context, organizationID, name, locale string) ([]*model.Template, error) {
+	return s.templateRepo.ListVersions(ctx, organizationID, name, model.NormalizeLocale(locale))
+}
+
+// RestoreTemplateVersion makes an earlier version of an override current
+// again, by saving it as a new version
+func (s *NotificationService) RestoreTemplateVersion(ctx context.Context, organizationID, userID, name, locale string, version int) (*model.Template, error) {
+	previous, err := s.templateRepo.GetVersion(ctx, organizationID, name, model.NormalizeLocale(locale), version)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Deleted {
+		return nil, &ValidationError{Message: fmt.Sprintf("version %d reverted to the default template", version)}
+	}
+
+	restored := &model.Template{
+		Locale:   previous.Locale,
+		Subject:  previous.Subject,
+		HTMLBody: previous.HTMLBody,
+		TextBody: previous.TextBody,
+	}
+	if err := s.SaveTemplateOverride(ctx, organizationID, userID, name, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PreviewTemplate renders an organization's template in a locale against
+// sample data, overridden by the data given. A draft override is previewed
+// instead of the stored template when given.
+func (s *NotificationService) PreviewTemplate(ctx context.Context, organizationID, name, locale string, data map[string]any, draft *model.Template) (*TemplatePreview, error) {
+	var tmpl *model.Template
+	var err error
+	if draft != nil {
+		draft.Locale = locale
+		tmpl, err = s.templateOverride(name, draft)
+		if err == nil {
+			tmpl.OrganizationID = organizationID
+		}
+	} else {
+		tmpl, err = s.templateRepo.Resolve(ctx, organizationID, name, locale)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sample := tmpl.SampleData()
+	for key, value := range data {
+		sample[key] = value
+	}
+	if missing := tmpl.MissingVariables(sample); len(missing) > 0 {
+		return nil, &ValidationError{Message: "missing template variables: " + strings.Join(missing, ", ")}
+	}
+
+	message, err := s.emailService.RenderTemplate(tmpl, sample)
+	if err != nil {
+		return nil, &ValidationError{Message: err.Error()}
+	}
+	return &TemplatePreview{Template: tmpl, Data: sample, Message: message}, nil
+}
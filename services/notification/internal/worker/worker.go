@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/observability"
 	"github.com/navo/services/notification/internal/model"
 	"github.com/navo/services/notification/internal/service"
 )
@@ -16,6 +17,9 @@ type NotificationWorker struct {
 	notificationService *service.NotificationService
 	redis               *redis.Client
 	processingInterval  time.Duration
+	digestInterval      time.Duration
+	escalationInterval  time.Duration
+	cleanupInterval     time.Duration
 }
 
 // NewNotificationWorker creates a new notification worker
@@ -27,6 +31,9 @@ func NewNotificationWorker(
 		notificationService: notificationService,
 		redis:               redisClient,
 		processingInterval:  30 * time.Second,
+		digestInterval:      time.Minute,
+		escalationInterval:  15 * time.Second,
+		cleanupInterval:     time.Hour,
 	}
 }
 
@@ -37,6 +44,15 @@ func (w *NotificationWorker) Start(ctx context.Context) {
 	// Start scheduled notification processor
 	go w.processScheduledNotifications(ctx)
 
+	// Start digest sender
+	go w.processDigests(ctx)
+
+	// Start escalation processor
+	go w.processEscalations(ctx)
+
+	// Start retention cleanup
+	go w.cleanupNotifications(ctx)
+
 	// Start event listener for real-time triggers
 	go w.listenForEvents(ctx)
 
@@ -61,6 +77,57 @@ func (w *NotificationWorker) processScheduledNotifications(ctx context.Context)
 	}
 }
 
+// processDigests sends the digest emails that are due
+func (w *NotificationWorker) processDigests(ctx context.Context) {
+	ticker := time.NewTicker(w.digestInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.notificationService.SendDueDigests(ctx); err != nil {
+				log.Printf("[NotificationWorker] Error sending digests: %v", err)
+			}
+		}
+	}
+}
+
+// processEscalations sends the escalation steps that are due
+func (w *NotificationWorker) processEscalations(ctx context.Context) {
+	ticker := time.NewTicker(w.escalationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.notificationService.ProcessDueEscalations(ctx); err != nil {
+				log.Printf("[NotificationWorker] Error processing escalations: %v", err)
+			}
+		}
+	}
+}
+
+// cleanupNotifications deletes the notifications past their retention
+func (w *NotificationWorker) cleanupNotifications(ctx context.Context) {
+	ticker := time.NewTicker(w.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.notificationService.CleanupExpired(ctx); err != nil {
+				log.Printf("[NotificationWorker] Error cleaning up notifications: %v", err)
+			}
+		}
+	}
+}
+
 // listenForEvents listens for notification trigger events from other services
 func (w *NotificationWorker) listenForEvents(ctx context.Context) {
 	// Subscribe to notification trigger channels
@@ -87,8 +154,10 @@ func (w *NotificationWorker) listenForEvents(ctx context.Context) {
 // handleEvent processes incoming notification trigger events
 func (w *NotificationWorker) handleEvent(ctx context.Context, msg *redis.Message) {
 	var event struct {
-		Type string          `json:"type"`
-		Data json.RawMessage `json:"data"`
+		Type        string          `json:"type"`
+		Data        json.RawMessage `json:"data"`
+		TraceParent string          `json:"traceparent"`
+		TraceState  string          `json:"tracestate"`
 	}
 
 	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
@@ -96,6 +165,11 @@ func (w *NotificationWorker) handleEvent(ctx context.Context, msg *redis.Message
 		return
 	}
 
+	// Continue the trace of the service that raised the event
+	ctx, span := observability.StartConsumerSpan(ctx, "notification "+event.Type, msg.Channel,
+		event.TraceParent, event.TraceState)
+	defer span.End()
+
 	switch event.Type {
 	case "port_call:created":
 		w.handlePortCallCreated(ctx, event.Data)
@@ -107,6 +181,8 @@ func (w *NotificationWorker) handleEvent(ctx context.Context, msg *redis.Message
 		w.handleQuoteSubmitted(ctx, event.Data)
 	case "rfq:quote_awarded":
 		w.handleQuoteAwarded(ctx, event.Data)
+	case "rfq:deadline_passed":
+		w.handleRFQDeadlinePassed(ctx, event.Data)
 	case "service_order:created":
 		w.handleServiceOrderCreated(ctx, event.Data)
 	case "service_order:completed":
@@ -167,15 +243,19 @@ func (w *NotificationWorker) handlePortCallCreated(ctx context.Context, data jso
 
 func (w *NotificationWorker) handlePortCallStatusChanged(ctx context.Context, data json.RawMessage) {
 	var event struct {
-		ID          string `json:"id"`
-		Reference   string `json:"reference"`
-		VesselName  string `json:"vessel_name"`
-		PortName    string `json:"port_name"`
-		OldStatus   string `json:"old_status"`
-		NewStatus   string `json:"new_status"`
-		NotifyUsers []struct {
+		ID               string     `json:"id"`
+		Reference        string     `json:"reference"`
+		VesselName       string     `json:"vessel_name"`
+		PortName         string     `json:"port_name"`
+		OldStatus        string     `json:"old_status"`
+		NewStatus        string     `json:"new_status"`
+		Critical         bool       `json:"critical"`
+		OrganizationID   string     `json:"organization_id"`
+		BerthConfirmedAt *time.Time `json:"berth_confirmed_at"`
+		NotifyUsers      []struct {
 			UserID string `json:"user_id"`
 			Email  string `json:"email"`
+			Phone  string `json:"phone"`
 		} `json:"notify_users"`
 		ActionURL string `json:"action_url"`
 	}
@@ -185,16 +265,32 @@ func (w *NotificationWorker) handlePortCallStatusChanged(ctx context.Context, da
 		return
 	}
 
-	for _, user := range event.NotifyUsers {
-		_, err := w.notificationService.Send(ctx, &model.SendNotificationRequest{
-			Type:         model.NotificationTypeEmail,
-			Category:     model.CategoryPortCall,
-			Priority:     model.NotificationPriorityNormal,
-			UserID:       user.UserID,
-			Email:        user.Email,
-			Title:        "Port Call Status Update",
-			Body:         event.VesselName + " status changed from " + event.OldStatus + " to " + event.NewStatus,
-			TemplateName: "port_call_status_changed",
+	// Critical changes reach duty officers off-hours: they bypass quiet hours
+	// and are also sent by SMS
+	priority := model.NotificationPriorityNormal
+	if event.Critical {
+		priority = model.NotificationPriorityCritical
+	}
+
+	// A vessel arriving without a confirmed berth escalates until the first
+	// user notified, the assigned agent, acknowledges it
+	escalation := ""
+	if event.NewStatus == "arrived" && event.BerthConfirmedAt == nil {
+		priority = model.NotificationPriorityCritical
+		escalation = model.EscalationTriggerArrivedWithoutBerth
+	}
+
+	for i, user := range event.NotifyUsers {
+		req := &model.SendNotificationRequest{
+			Type:           model.NotificationTypeEmail,
+			Category:       model.CategoryPortCall,
+			Priority:       priority,
+			UserID:         user.UserID,
+			OrganizationID: event.OrganizationID,
+			Email:          user.Email,
+			Title:          "Port Call Status Update",
+			Body:           event.VesselName + " status changed from " + event.OldStatus + " to " + event.NewStatus,
+			TemplateName:   "port_call_status_changed",
 			TemplateData: map[string]any{
 				"VesselName": event.VesselName,
 				"PortName":   event.PortName,
@@ -206,11 +302,32 @@ func (w *NotificationWorker) handlePortCallStatusChanged(ctx context.Context, da
 			EntityType: "port_call",
 			EntityID:   event.ID,
 			ActionURL:  event.ActionURL,
-		})
+		}
+		if i == 0 {
+			req.Escalation = escalation
+		}
 
-		if err != nil {
+		if _, err := w.notificationService.Send(ctx, req); err != nil {
 			log.Printf("[NotificationWorker] Failed to send status change notification: %v", err)
 		}
+
+		if event.Critical && user.Phone != "" {
+			_, err := w.notificationService.Send(ctx, &model.SendNotificationRequest{
+				Type:       model.NotificationTypeSMS,
+				Category:   model.CategoryPortCall,
+				Priority:   priority,
+				UserID:     user.UserID,
+				Phone:      user.Phone,
+				Title:      event.VesselName + " " + event.NewStatus,
+				Body:       "Port call " + event.Reference + " at " + event.PortName + " changed from " + event.OldStatus + " to " + event.NewStatus,
+				EntityType: "port_call",
+				EntityID:   event.ID,
+				ActionURL:  event.ActionURL,
+			})
+			if err != nil {
+				log.Printf("[NotificationWorker] Failed to send status change SMS: %v", err)
+			}
+		}
 	}
 }
 
@@ -363,6 +480,55 @@ func (w *NotificationWorker) handleQuoteAwarded(ctx context.Context, data json.R
 	}
 }
 
+func (w *NotificationWorker) handleRFQDeadlinePassed(ctx context.Context, data json.RawMessage) {
+	var event struct {
+		ID             string `json:"id"`
+		Reference      string `json:"reference"`
+		ServiceType    string `json:"service_type"`
+		PortName       string `json:"port_name"`
+		OrganizationID string `json:"organization_id"`
+		QuoteCount     int    `json:"quote_count"`
+		NotifyUsers    []struct {
+			UserID string `json:"user_id"`
+			Email  string `json:"email"`
+		} `json:"notify_users"`
+		ActionURL string `json:"action_url"`
+	}
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("[NotificationWorker] Failed to parse RFQ deadline event: %v", err)
+		return
+	}
+
+	// Only an RFQ left without any quote needs someone to act
+	if event.QuoteCount > 0 {
+		return
+	}
+
+	for i, user := range event.NotifyUsers {
+		req := &model.SendNotificationRequest{
+			Type:           model.NotificationTypeEmail,
+			Category:       model.CategoryRFQ,
+			Priority:       model.NotificationPriorityCritical,
+			UserID:         user.UserID,
+			OrganizationID: event.OrganizationID,
+			Email:          user.Email,
+			Title:          "No Quotes Received",
+			Body:           "RFQ " + event.Reference + " for " + event.ServiceType + " at " + event.PortName + " closed without any quote",
+			EntityType:     "rfq",
+			EntityID:       event.ID,
+			ActionURL:      event.ActionURL,
+		}
+		if i == 0 {
+			req.Escalation = model.EscalationTriggerRFQNoQuotes
+		}
+
+		if _, err := w.notificationService.Send(ctx, req); err != nil {
+			log.Printf("[NotificationWorker] Failed to send RFQ deadline notification: %v", err)
+		}
+	}
+}
+
 func (w *NotificationWorker) handleServiceOrderCreated(ctx context.Context, data json.RawMessage) {
 	var event struct {
 		ID            string `json:"id"`
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_retention;
+DROP TABLE IF EXISTS notification_records;
@@ -0,0 +1,32 @@
+-- Sent and scheduled notifications, and per-organization retention
+
+CREATE TABLE IF NOT EXISTS notification_records (
+    id TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    category TEXT NOT NULL,
+    priority TEXT NOT NULL,
+    status TEXT NOT NULL,
+    entity_type TEXT NOT NULL DEFAULT '',
+    entity_id TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL DEFAULT '',
+    provider_message_id TEXT NOT NULL DEFAULT '',
+    read_at TIMESTAMP WITH TIME ZONE,
+    scheduled_for TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    payload JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_records_user ON notification_records (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_records_org ON notification_records (organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_records_entity ON notification_records (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_notification_records_pending ON notification_records (status) WHERE status IN ('pending', 'queued');
+CREATE INDEX IF NOT EXISTS idx_notification_records_provider ON notification_records (provider, provider_message_id) WHERE provider_message_id <> '';
+
+CREATE TABLE IF NOT EXISTS notification_retention (
+    organization_id TEXT PRIMARY KEY,
+    retention_days INTEGER NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+-- Versioned per-organization template overrides, resolved with locale
+-- fallback before the built-in templates
+
+CREATE TABLE IF NOT EXISTS notification_templates (
+    organization_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    locale TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    html_body TEXT NOT NULL DEFAULT '',
+    text_body TEXT NOT NULL DEFAULT '',
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, name, locale, version)
+);
@@ -0,0 +1,19 @@
+-- Users' notification preferences. The locale column was added after the
+-- table, so databases created before it get it here too.
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id TEXT PRIMARY KEY,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    push_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    in_app_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    sms_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    category_prefs JSONB NOT NULL DEFAULT '{}',
+    quiet_hours_start INTEGER,
+    quiet_hours_end INTEGER,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    digest_frequency TEXT NOT NULL DEFAULT 'none',
+    digest_hour INTEGER NOT NULL DEFAULT 8,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
@@ -12,11 +12,18 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/audit"
 	"github.com/navo/pkg/auth"
+	"github.com/navo/pkg/database"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/realtime/internal/authz"
+	"github.com/navo/services/realtime/internal/cluster"
 	"github.com/navo/services/realtime/internal/config"
 	"github.com/navo/services/realtime/internal/handler"
 	"github.com/navo/services/realtime/internal/hub"
 	"github.com/navo/services/realtime/internal/middleware"
+	"github.com/navo/services/realtime/internal/replay"
 	"github.com/navo/services/realtime/internal/subscription"
 )
 
@@ -27,12 +34,20 @@ func main() {
 		log.Fatalf("Invalid configuration: %v", err)
 	}
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(context.Background(), observability.DefaultConfig("realtime"))
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracer(context.Background())
+
 	// Initialize Redis client
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisURL,
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
 	})
+	observability.InstrumentRedis(redisClient)
 
 	// Test Redis connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -43,20 +58,67 @@ func main() {
 	}
 	cancel()
 
-	// Initialize JWT service for authentication
-	var jwtService *auth.JWTService
-	if cfg.JWTSecret != "" {
-		jwtService = auth.NewJWTService(cfg.JWTSecret, 24*time.Hour)
+	// Initialize JWT validation for authentication
+	authEnabled := cfg.JWTSecret != ""
+	if authEnabled {
+		auth.Initialize()
 	} else {
 		log.Println("Warning: JWT_SECRET not set, authentication disabled")
 	}
 
+	// Audit logger for denied subscriptions (optional)
+	var auditLogger audit.Logger
+	if cfg.DatabaseURL != "" {
+		pool, err := database.NewPool(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Printf("Warning: Audit database connection failed: %v", err)
+		} else {
+			defer pool.Close()
+			dbLogger := audit.NewDBLogger(pool, nil)
+			defer dbLogger.Close()
+			auditLogger = dbLogger
+		}
+	}
+
+	// Channel authorization
+	var authorizer authz.Authorizer = authz.AllowAll{}
+	var ownershipCache *authz.CachingResolver
+	if authEnabled {
+		resolver := authz.NewHTTPResolver(authz.ServiceURLs{
+			Core:   cfg.CoreServiceURL,
+			Vessel: cfg.VesselServiceURL,
+			Vendor: cfg.VendorServiceURL,
+		}, cfg.OwnershipTimeout)
+		ownershipCache = authz.NewCachingResolver(resolver, cfg.OwnershipCacheTTL)
+		channelAuthorizer := authz.NewChannelAuthorizer(ownershipCache)
+		if auditLogger != nil {
+			channelAuthorizer.WithAuditLogger(auditLogger)
+		}
+		authorizer = channelAuthorizer
+	}
+
 	// Create hub
 	h := hub.NewHub()
 
+	// Cluster membership, so admin operations and stats cover all replicas.
+	// Created before the hub runs since it listens to connection changes.
+	var clusterNode *cluster.Node
+	if redisClient != nil {
+		clusterNode = cluster.NewNode(redisClient, h, cluster.Config{
+			NodeID:            cfg.NodeID,
+			Address:           cfg.NodeAddress,
+			HeartbeatInterval: cfg.ClusterHeartbeatInterval,
+			NodeTTL:           cfg.ClusterNodeTTL,
+			ControlTimeout:    cfg.ClusterControlTimeout,
+		})
+	}
+
 	// Start hub in background
 	hubCtx, hubCancel := context.WithCancel(context.Background())
 	go h.Run(hubCtx)
+	if ownershipCache != nil {
+		go ownershipCache.Cleanup(hubCtx, cfg.CleanupInterval)
+	}
 
 	// Create subscription manager (if Redis available)
 	var subMgr *subscription.Manager
@@ -68,48 +130,68 @@ func main() {
 		}
 	}
 
+	// Replay of missed events from the organization streams
+	var replayStore *replay.Store
+	if redisClient != nil {
+		replayStore = replay.NewStore(redisClient, cfg.ReplayMaxEvents)
+	}
+
+	// Join the cluster of realtime replicas
+	if clusterNode != nil {
+		if err := clusterNode.Start(hubCtx); err != nil {
+			log.Printf("Warning: Failed to join cluster: %v", err)
+			clusterNode = nil
+		}
+	}
+
 	// Create handlers
-	wsHandler := handler.NewWebSocketHandler(h)
-	apiHandler := handler.NewAPIHandler(h, subMgr)
+	wsHandler := handler.NewWebSocketHandler(h, authorizer, replayStore, !authEnabled)
+	streamHandler := handler.NewStreamHandler(h, authorizer, replayStore, !authEnabled)
+	apiHandler := handler.NewAPIHandler(h, subMgr, clusterNode)
+
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Realtime Service",
+		Description: "Events over WebSocket, server-sent events and long polling",
+	}, handler.Operations)
 
 	// Setup router
 	r := chi.NewRouter()
 
 	// Global middleware
+	r.Use(observability.HTTPMiddleware) // Continues the gateway's trace
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RealIP)
 	r.Use(chimiddleware.RequestID)
 	r.Use(middleware.CORS(cfg.AllowedOrigins))
+	r.Use(api.Validate)
 
 	// Health check (no auth required)
 	r.Get("/health", apiHandler.Health)
-	r.Get("/ready", apiHandler.Health)
+	r.Get("/ready", apiHandler.Ready)
 
 	// WebSocket endpoint
 	r.Group(func(r chi.Router) {
 		// Optional auth for WebSocket (can also auth via query param)
-		if jwtService != nil {
-			r.Use(middleware.NewOptionalAuthMiddleware(jwtService).Handler)
+		if authEnabled {
+			r.Use(middleware.NewOptionalAuthMiddleware().Handler)
 		}
 		r.Get("/ws", wsHandler.ServeWS)
 	})
 
-	// API endpoints (admin/internal)
-	r.Route("/api/v1", func(r chi.Router) {
-		// Stats endpoint (could be protected in production)
-		r.Get("/stats", apiHandler.Stats)
-		r.Get("/connections", apiHandler.GetConnections)
-
-		// Protected admin endpoints
-		r.Group(func(r chi.Router) {
-			if jwtService != nil {
-				r.Use(middleware.NewAuthMiddleware(jwtService).Handler)
-			}
-			r.Post("/events", apiHandler.PublishEvent)
-			r.Post("/disconnect", apiHandler.DisconnectUser)
-		})
-	})
+	// API routes
+	routes := handler.Handlers{API: apiHandler, Stream: streamHandler}
+	if authEnabled {
+		routes.OptionalAuth = middleware.NewOptionalAuthMiddleware().Handler
+		routes.Auth = middleware.NewAuthMiddleware().Handler
+	}
+	handler.Routes(r, routes)
+
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -144,6 +226,17 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
 
+	// Ask clients to reconnect to the remaining nodes
+	if clusterNode != nil {
+		clusterNode.SetDraining(shutdownCtx)
+	}
+	h.Drain(shutdownCtx, cfg.DrainTimeout)
+	if clusterNode != nil {
+		if err := clusterNode.Stop(shutdownCtx); err != nil {
+			log.Printf("Error leaving cluster: %v", err)
+		}
+	}
+
 	// Shutdown subscription manager
 	if subMgr != nil {
 		if err := subMgr.Stop(); err != nil {
@@ -0,0 +1,160 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/pkg/auth"
+	"github.com/navo/services/realtime/internal/model"
+)
+
+// PortalVendor is the portal type of vendor users
+const PortalVendor = "vendor"
+
+// ErrForbidden is returned when a client may not subscribe to a channel
+var ErrForbidden = errors.New("not authorized to subscribe to channel")
+
+// Authorizer decides whether a client may subscribe to a channel
+type Authorizer interface {
+	Authorize(ctx context.Context, client *model.Client, channel string) error
+}
+
+// AllowAll authorizes every subscription. It is only meant for development
+// setups running without authentication.
+type AllowAll struct{}
+
+// Authorize always allows the subscription
+func (AllowAll) Authorize(ctx context.Context, client *model.Client, channel string) error {
+	return nil
+}
+
+// vendorEventCategories are the event type channels vendors may subscribe to
+var vendorEventCategories = map[string]bool{
+	"rfq":          true,
+	"notification": true,
+	"system":       true,
+}
+
+// ChannelAuthorizer authorizes subscriptions to event type channels (for
+// example "port_call:updated") and entity channels (for example
+// "port_call:<id>").
+//
+// Event type channels are scoped to the client's organization and workspace by
+// the hub, so only the portal rules apply. Entity channels require the entity
+// to be visible to the client's organization and to belong to one of the
+// client's workspaces. Vendors may only subscribe to RFQs they are invited to.
+type ChannelAuthorizer struct {
+	resolver    OwnershipResolver
+	auditLogger audit.Logger
+}
+
+// NewChannelAuthorizer creates a new channel authorizer
+func NewChannelAuthorizer(resolver OwnershipResolver) *ChannelAuthorizer {
+	return &ChannelAuthorizer{resolver: resolver}
+}
+
+// WithAuditLogger sets the audit logger for denied subscriptions
+func (a *ChannelAuthorizer) WithAuditLogger(logger audit.Logger) *ChannelAuthorizer {
+	a.auditLogger = logger
+	return a
+}
+
+// Authorize checks whether the client may subscribe to the channel
+func (a *ChannelAuthorizer) Authorize(ctx context.Context, client *model.Client, channel string) error {
+	reason, err := a.check(ctx, client, channel)
+	if err != nil {
+		// Lookup failures deny the subscription but are not access violations
+		log.Printf("Channel authorization failed for client %s on %s: %v", client.ID, channel, err)
+		return ErrForbidden
+	}
+	if reason != "" {
+		a.recordDenial(ctx, client, channel, reason)
+		return ErrForbidden
+	}
+	return nil
+}
+
+// check returns a denial reason, or "" when the subscription is allowed
+func (a *ChannelAuthorizer) check(ctx context.Context, client *model.Client, channel string) (string, error) {
+	claims := client.Claims
+	if claims == nil {
+		return "unauthenticated client", nil
+	}
+
+	category, id, ok := strings.Cut(channel, ":")
+	if !ok || category == "" || id == "" {
+		return "invalid channel", nil
+	}
+
+	if model.IsEventType(channel) {
+		if isVendor(claims) && !vendorEventCategories[category] {
+			return "event type not available to vendors", nil
+		}
+		return "", nil
+	}
+
+	switch category {
+	case EntityPortCall, EntityRFQ, EntityServiceOrder, EntityVessel:
+	default:
+		return "unknown channel", nil
+	}
+
+	if isVendor(claims) && category != EntityRFQ {
+		return "vendors may only subscribe to RFQs", nil
+	}
+
+	ownership, err := a.resolver.Resolve(ctx, claims, category, id)
+	if errors.Is(err, ErrEntityNotFound) {
+		return "entity not found in organization", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if isVendor(claims) {
+		for _, orgID := range ownership.InvitedVendorOrgIDs {
+			if orgID == claims.OrganizationID {
+				return "", nil
+			}
+		}
+		return "vendor not invited to RFQ", nil
+	}
+
+	if !auth.HasWorkspaceAccess(claims, ownership.WorkspaceID) {
+		return "not a member of the entity's workspace", nil
+	}
+	return "", nil
+}
+
+func (a *ChannelAuthorizer) recordDenial(ctx context.Context, client *model.Client, channel, reason string) {
+	log.Printf("Subscription denied for client %s (user: %s) on %s: %s", client.ID, client.UserID, channel, reason)
+
+	if a.auditLogger == nil {
+		return
+	}
+
+	entityType, entityID, _ := strings.Cut(channel, ":")
+	if model.IsEventType(channel) {
+		entityType, entityID = "", ""
+	}
+
+	event := audit.NewBuilder().
+		WithUser(client.UserID, client.OrganizationID).
+		WithWorkspace(client.WorkspaceID).
+		WithAction(audit.ActionAccessDenied).
+		WithEntity(audit.EntityType(entityType), entityID).
+		WithMetadata("channel", channel).
+		WithMetadata("reason", reason).
+		WithMetadata("client_id", client.ID).
+		WithRequestContext(ctx).
+		WithFailure(reason).
+		Build()
+	a.auditLogger.LogAsync(ctx, event)
+}
+
+func isVendor(claims *auth.Claims) bool {
+	return claims != nil && claims.PortalType == PortalVendor
+}
@@ -0,0 +1,153 @@
+package authz
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navo/pkg/audit"
+	"github.com/navo/pkg/auth"
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver serves ownership from a map keyed by "<type>:<id>"
+type stubResolver struct {
+	entities map[string]*Ownership
+	calls    int
+}
+
+func (s *stubResolver) Resolve(ctx context.Context, claims *auth.Claims, entityType, entityID string) (*Ownership, error) {
+	s.calls++
+	if o, ok := s.entities[entityType+":"+entityID]; ok {
+		return o, nil
+	}
+	return nil, ErrEntityNotFound
+}
+
+// recordingLogger captures audit events
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (l *recordingLogger) Log(ctx context.Context, event audit.Event) error {
+	l.LogAsync(ctx, event)
+	return nil
+}
+
+func (l *recordingLogger) LogAsync(ctx context.Context, event audit.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingLogger) Query(ctx context.Context, filter audit.Filter) (*audit.Result, error) {
+	return nil, nil
+}
+
+func (l *recordingLogger) GetByID(ctx context.Context, id string) (*audit.Event, error) {
+	return nil, nil
+}
+
+func (l *recordingLogger) Close() error { return nil }
+
+func newTestClient(claims *auth.Claims) *model.Client {
+	client := model.NewClient(nil, "", "", "")
+	if claims != nil {
+		client.UserID = claims.UserID
+		client.OrganizationID = claims.OrganizationID
+	}
+	client.Claims = claims
+	return client
+}
+
+func TestChannelAuthorizer_Authorize(t *testing.T) {
+	resolver := &stubResolver{entities: map[string]*Ownership{
+		"port_call:pc-1":     {EntityType: EntityPortCall, EntityID: "pc-1", WorkspaceID: "ws-1"},
+		"port_call:pc-2":     {EntityType: EntityPortCall, EntityID: "pc-2", WorkspaceID: "ws-2"},
+		"rfq:rfq-1":          {EntityType: EntityRFQ, EntityID: "rfq-1", WorkspaceID: "ws-1", InvitedVendorOrgIDs: []string{"vendor-org-1"}},
+		"service_order:so-1": {EntityType: EntityServiceOrder, EntityID: "so-1", WorkspaceID: "ws-1"},
+	}}
+
+	operator := &auth.Claims{UserID: "user-1", OrganizationID: "org-1", WorkspaceIDs: []string{"ws-1"}, Roles: []string{"operator"}, PortalType: "key"}
+	admin := &auth.Claims{UserID: "user-2", OrganizationID: "org-1", Roles: []string{"admin"}, PortalType: "key"}
+	vendor := &auth.Claims{UserID: "user-3", OrganizationID: "vendor-org-1", PortalType: PortalVendor}
+	otherVendor := &auth.Claims{UserID: "user-4", OrganizationID: "vendor-org-2", PortalType: PortalVendor}
+
+	tests := []struct {
+		name    string
+		claims  *auth.Claims
+		channel string
+		allowed bool
+	}{
+		{"event type channel", operator, "port_call:updated", true},
+		{"port call in own workspace", operator, "port_call:pc-1", true},
+		{"port call in other workspace", operator, "port_call:pc-2", false},
+		{"admin sees all workspaces", admin, "port_call:pc-2", true},
+		{"entity of another tenant", operator, "port_call:unknown", false},
+		{"service order through port call", operator, "service_order:so-1", true},
+		{"unknown channel", operator, "invoice:inv-1", false},
+		{"malformed channel", operator, "port_call", false},
+		{"unauthenticated client", nil, "port_call:updated", false},
+		{"vendor invited to rfq", vendor, "rfq:rfq-1", true},
+		{"vendor not invited to rfq", otherVendor, "rfq:rfq-1", false},
+		{"vendor on port call", vendor, "port_call:pc-1", false},
+		{"vendor on rfq events", vendor, "rfq:quote_received", true},
+		{"vendor on port call events", vendor, "port_call:updated", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			authorizer := NewChannelAuthorizer(resolver).WithAuditLogger(logger)
+
+			err := authorizer.Authorize(context.Background(), newTestClient(tt.claims), tt.channel)
+			if tt.allowed {
+				assert.NoError(t, err)
+				assert.Empty(t, logger.events)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrForbidden)
+			require.Len(t, logger.events, 1)
+			assert.Equal(t, audit.ActionAccessDenied, logger.events[0].Action)
+			assert.Equal(t, tt.channel, logger.events[0].Metadata["channel"])
+		})
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	stub := &stubResolver{entities: map[string]*Ownership{
+		"port_call:pc-1": {EntityType: EntityPortCall, EntityID: "pc-1", WorkspaceID: "ws-1"},
+	}}
+	cache := NewCachingResolver(stub, 50*time.Millisecond)
+	claims := &auth.Claims{OrganizationID: "org-1"}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Resolve(ctx, claims, EntityPortCall, "pc-1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, stub.calls)
+
+	// Cached per organization
+	_, err := cache.Resolve(ctx, &auth.Claims{OrganizationID: "org-2"}, EntityPortCall, "pc-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stub.calls)
+
+	// Failures are not cached
+	for i := 0; i < 2; i++ {
+		_, err := cache.Resolve(ctx, claims, EntityPortCall, "missing")
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+	}
+	assert.Equal(t, 4, stub.calls)
+
+	// Entries expire
+	time.Sleep(60 * time.Millisecond)
+	_, err = cache.Resolve(ctx, claims, EntityPortCall, "pc-1")
+	require.NoError(t, err)
+	assert.Equal(t, 5, stub.calls)
+}
@@ -0,0 +1,263 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navo/pkg/auth"
+)
+
+// ErrEntityNotFound is returned when an entity does not exist or is not
+// visible to the requesting principal
+var ErrEntityNotFound = errors.New("entity not found")
+
+// Entity types that can be subscribed to as "<type>:<id>" channels
+const (
+	EntityPortCall     = "port_call"
+	EntityRFQ          = "rfq"
+	EntityServiceOrder = "service_order"
+	EntityVessel       = "vessel"
+)
+
+// Ownership describes who an entity belongs to
+type Ownership struct {
+	EntityType  string
+	EntityID    string
+	WorkspaceID string
+	PortCallID  string
+	// InvitedVendorOrgIDs lists the organizations of vendors invited to an RFQ
+	InvitedVendorOrgIDs []string
+}
+
+// OwnershipResolver looks up the ownership of an entity on behalf of a principal
+type OwnershipResolver interface {
+	Resolve(ctx context.Context, claims *auth.Claims, entityType, entityID string) (*Ownership, error)
+}
+
+// ServiceURLs holds the base URLs of the services that own entities
+type ServiceURLs struct {
+	Core   string
+	Vessel string
+	Vendor string
+}
+
+// HTTPResolver resolves ownership through the core, vessel and vendor service
+// APIs. Requests carry the principal's identity headers, the same way the
+// gateway forwards them, so each service applies its own tenant isolation.
+type HTTPResolver struct {
+	client *http.Client
+	urls   ServiceURLs
+}
+
+// NewHTTPResolver creates a new HTTP ownership resolver
+func NewHTTPResolver(urls ServiceURLs, timeout time.Duration) *HTTPResolver {
+	return &HTTPResolver{
+		client: &http.Client{Timeout: timeout},
+		urls:   urls,
+	}
+}
+
+// Resolve looks up the ownership of an entity
+func (r *HTTPResolver) Resolve(ctx context.Context, claims *auth.Claims, entityType, entityID string) (*Ownership, error) {
+	switch entityType {
+	case EntityPortCall:
+		var portCall struct {
+			WorkspaceID string `json:"workspace_id"`
+		}
+		if err := r.get(ctx, claims, r.urls.Core, "/api/v1/port-calls/"+entityID, true, &portCall); err != nil {
+			return nil, err
+		}
+		return &Ownership{EntityType: entityType, EntityID: entityID, WorkspaceID: portCall.WorkspaceID, PortCallID: entityID}, nil
+
+	case EntityServiceOrder:
+		var order struct {
+			PortCallID string `json:"port_call_id"`
+		}
+		if err := r.get(ctx, claims, r.urls.Core, "/api/v1/service-orders/"+entityID, true, &order); err != nil {
+			return nil, err
+		}
+		return r.throughPortCall(ctx, claims, entityType, entityID, order.PortCallID)
+
+	case EntityRFQ:
+		var rfq struct {
+			PortCallID     string   `json:"port_call_id"`
+			InvitedVendors []string `json:"invited_vendors"`
+		}
+		if err := r.get(ctx, claims, r.urls.Core, "/api/v1/rfqs/"+entityID, true, &rfq); err != nil {
+			return nil, err
+		}
+		ownership := &Ownership{EntityType: entityType, EntityID: entityID, PortCallID: rfq.PortCallID}
+		if isVendor(claims) {
+			// Vendors cannot see the operator's port call; only the
+			// invitation matters for them
+			orgIDs, err := r.vendorOrganizations(ctx, claims, rfq.InvitedVendors)
+			if err != nil {
+				return nil, err
+			}
+			ownership.InvitedVendorOrgIDs = orgIDs
+			return ownership, nil
+		}
+		portCall, err := r.throughPortCall(ctx, claims, entityType, entityID, rfq.PortCallID)
+		if err != nil {
+			return nil, err
+		}
+		ownership.WorkspaceID = portCall.WorkspaceID
+		return ownership, nil
+
+	case EntityVessel:
+		var vessel struct {
+			WorkspaceID string `json:"workspace_id"`
+		}
+		if err := r.get(ctx, claims, r.urls.Vessel, "/api/v1/vessels/"+entityID, false, &vessel); err != nil {
+			return nil, err
+		}
+		return &Ownership{EntityType: entityType, EntityID: entityID, WorkspaceID: vessel.WorkspaceID}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported entity type %q", entityType)
+}
+
+func (r *HTTPResolver) throughPortCall(ctx context.Context, claims *auth.Claims, entityType, entityID, portCallID string) (*Ownership, error) {
+	if portCallID == "" {
+		return nil, ErrEntityNotFound
+	}
+	portCall, err := r.Resolve(ctx, claims, EntityPortCall, portCallID)
+	if err != nil {
+		return nil, err
+	}
+	return &Ownership{EntityType: entityType, EntityID: entityID, WorkspaceID: portCall.WorkspaceID, PortCallID: portCallID}, nil
+}
+
+func (r *HTTPResolver) vendorOrganizations(ctx context.Context, claims *auth.Claims, vendorIDs []string) ([]string, error) {
+	var orgIDs []string
+	for _, id := range vendorIDs {
+		var vendor struct {
+			OrganizationID string `json:"organization_id"`
+		}
+		err := r.get(ctx, claims, r.urls.Vendor, "/api/v1/vendors/"+id, true, &vendor)
+		if errors.Is(err, ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		orgIDs = append(orgIDs, vendor.OrganizationID)
+	}
+	return orgIDs, nil
+}
+
+// get fetches a JSON resource. enveloped indicates the service wraps
+// payloads in the pkg/response {"success", "data"} envelope.
+func (r *HTTPResolver) get(ctx context.Context, claims *auth.Claims, baseURL, path string, enveloped bool, out interface{}) error {
+	if baseURL == "" {
+		return fmt.Errorf("no service URL configured for %s", path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-User-ID", claims.UserID)
+	req.Header.Set("X-Organization-ID", claims.OrganizationID)
+	req.Header.Set("X-Portal-Type", claims.PortalType)
+	req.Header.Set("X-User-Roles", strings.Join(claims.Roles, ","))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ownership lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusForbidden:
+		return ErrEntityNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("ownership lookup for %s returned status %d", path, resp.StatusCode)
+	}
+
+	if !enveloped {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+type cacheEntry struct {
+	ownership *Ownership
+	expiresAt time.Time
+}
+
+// CachingResolver caches resolved ownership for a short time. Entries are
+// keyed by organization because visibility depends on the caller's tenant.
+// Lookup failures are never cached.
+type CachingResolver struct {
+	next    OwnershipResolver
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	mu      sync.Mutex
+}
+
+// NewCachingResolver wraps a resolver with a short-lived cache
+func NewCachingResolver(next OwnershipResolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Resolve returns cached ownership or resolves it
+func (c *CachingResolver) Resolve(ctx context.Context, claims *auth.Claims, entityType, entityID string) (*Ownership, error) {
+	key := claims.OrganizationID + "|" + entityType + ":" + entityID
+	now := time.Now()
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.ownership, nil
+	}
+	c.mu.Unlock()
+
+	ownership, err := c.next.Resolve(ctx, claims, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{ownership: ownership, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return ownership, nil
+}
+
+// Cleanup removes expired entries until ctx is cancelled
+func (c *CachingResolver) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for key, entry := range c.entries {
+				if now.After(entry.expiresAt) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
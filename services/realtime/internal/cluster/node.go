@@ -0,0 +1,495 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/services/realtime/internal/hub"
+)
+
+// Redis keys of the cluster registry
+const (
+	keyPrefix = "navo:realtime:"
+
+	// Set of the IDs of registered nodes
+	nodesKey = keyPrefix + "nodes"
+)
+
+// nodeKey holds a node's NodeInfo; it expires when the node stops sending
+// heartbeats
+func nodeKey(nodeID string) string {
+	return keyPrefix + "node:" + nodeID
+}
+
+// userKey is a hash of the nodes a user is connected to, with the number of
+// connections on each
+func userKey(userID string) string {
+	return keyPrefix + "user:" + userID
+}
+
+// controlChannel is the pub/sub channel a node receives control messages on
+func controlChannel(nodeID string) string {
+	return keyPrefix + "control:" + nodeID
+}
+
+func replyKey(requestID string) string {
+	return keyPrefix + "reply:" + requestID
+}
+
+// ErrNodesUnanswered is returned when some nodes did not answer a control
+// message in time; the result only covers the nodes that did
+var ErrNodesUnanswered = errors.New("some nodes did not answer")
+
+// Config configures a cluster node
+type Config struct {
+	// NodeID identifies this replica; generated when empty
+	NodeID string
+
+	// Address other replicas and operators can reach this node at (optional)
+	Address string
+
+	// How often the node refreshes its registration and user index
+	HeartbeatInterval time.Duration
+
+	// How long a node stays registered without heartbeats
+	NodeTTL time.Duration
+
+	// How long to wait for other nodes to answer control messages
+	ControlTimeout time.Duration
+}
+
+// NodeInfo is what a node publishes about itself on every heartbeat
+type NodeInfo struct {
+	ID          string                 `json:"id"`
+	Address     string                 `json:"address,omitempty"`
+	StartedAt   time.Time              `json:"started_at"`
+	HeartbeatAt time.Time              `json:"heartbeat_at"`
+	Draining    bool                   `json:"draining"`
+	Stats       map[string]interface{} `json:"stats"`
+}
+
+// controlMessage is sent to a node's control channel
+type controlMessage struct {
+	Type     string `json:"type"`
+	UserID   string `json:"user_id"`
+	ClientID string `json:"client_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	ReplyTo  string `json:"reply_to,omitempty"`
+}
+
+// Control message types
+const controlDisconnect = "disconnect"
+
+// Node registers a realtime replica in Redis so the replicas together behave
+// like one hub: it publishes the node's heartbeat and statistics, indexes
+// which nodes each user is connected to, and executes control messages, such
+// as disconnecting a user, that other nodes direct at it.
+type Node struct {
+	cfg       Config
+	redis     *redis.Client
+	hub       *hub.Hub
+	startedAt time.Time
+	draining  atomic.Bool
+
+	// Users whose connection count changed since the last sync
+	dirty chan string
+
+	// Users this node has indexed; only used by the run loop
+	indexed map[string]bool
+
+	pubsub *redis.PubSub
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewNode creates a cluster node for the hub
+func NewNode(redisClient *redis.Client, h *hub.Hub, cfg Config) *Node {
+	if cfg.NodeID == "" {
+		cfg.NodeID = generateNodeID()
+	}
+	n := &Node{
+		cfg:       cfg,
+		redis:     redisClient,
+		hub:       h,
+		startedAt: time.Now().UTC(),
+		dirty:     make(chan string, 1024),
+		indexed:   make(map[string]bool),
+	}
+	h.OnUserChange(n.markDirty)
+	return n
+}
+
+// ID returns the node's ID
+func (n *Node) ID() string {
+	return n.cfg.NodeID
+}
+
+// Start registers the node and starts heartbeats and the control listener
+func (n *Node) Start(ctx context.Context) error {
+	ctx, n.cancel = context.WithCancel(ctx)
+
+	n.pubsub = n.redis.Subscribe(ctx, controlChannel(n.cfg.NodeID))
+	if _, err := n.pubsub.Receive(ctx); err != nil {
+		n.cancel()
+		return fmt.Errorf("failed to subscribe to control channel: %w", err)
+	}
+	if err := n.heartbeat(ctx); err != nil {
+		n.pubsub.Close()
+		n.cancel()
+		return fmt.Errorf("failed to register node: %w", err)
+	}
+
+	n.wg.Add(2)
+	go n.run(ctx)
+	go n.listen(ctx)
+
+	log.Printf("Cluster node %s registered", n.cfg.NodeID)
+	return nil
+}
+
+// SetDraining marks the node as draining in the registry, ahead of Hub.Drain
+func (n *Node) SetDraining(ctx context.Context) {
+	n.draining.Store(true)
+	if err := n.writeInfo(ctx); err != nil {
+		log.Printf("Error marking node %s as draining: %v", n.cfg.NodeID, err)
+	}
+}
+
+// Stop stops the node and removes it and its users from the registry
+func (n *Node) Stop(ctx context.Context) error {
+	if n.cancel == nil {
+		return nil
+	}
+	n.cancel()
+	n.pubsub.Close()
+	n.wg.Wait()
+
+	pipe := n.redis.Pipeline()
+	pipe.Del(ctx, nodeKey(n.cfg.NodeID))
+	pipe.SRem(ctx, nodesKey, n.cfg.NodeID)
+	for userID := range n.indexed {
+		pipe.HDel(ctx, userKey(userID), n.cfg.NodeID)
+	}
+	_, err := pipe.Exec(ctx)
+
+	log.Printf("Cluster node %s deregistered", n.cfg.NodeID)
+	return err
+}
+
+func (n *Node) markDirty(userID string) {
+	select {
+	case n.dirty <- userID:
+	default:
+		// The next heartbeat resyncs every user
+	}
+}
+
+// run sends heartbeats and keeps the user index up to date
+func (n *Node) run(ctx context.Context) {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(n.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := n.heartbeat(ctx); err != nil {
+				log.Printf("Cluster heartbeat of node %s failed: %v", n.cfg.NodeID, err)
+			}
+
+		case userID := <-n.dirty:
+			if err := n.syncUser(ctx, userID, n.hub.UserConnectionCount(userID)); err != nil {
+				log.Printf("Error indexing user %s on node %s: %v", userID, n.cfg.NodeID, err)
+			}
+		}
+	}
+}
+
+// heartbeat refreshes the node's registration and its whole user index
+func (n *Node) heartbeat(ctx context.Context) error {
+	if err := n.writeInfo(ctx); err != nil {
+		return err
+	}
+
+	counts := n.hub.UserConnections()
+	for userID := range n.indexed {
+		if _, ok := counts[userID]; !ok {
+			counts[userID] = 0
+		}
+	}
+
+	pipe := n.redis.Pipeline()
+	for userID, count := range counts {
+		n.queueUser(ctx, pipe, userID, count)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (n *Node) writeInfo(ctx context.Context) error {
+	info, err := json.Marshal(n.info())
+	if err != nil {
+		return err
+	}
+
+	pipe := n.redis.Pipeline()
+	pipe.Set(ctx, nodeKey(n.cfg.NodeID), info, n.cfg.NodeTTL)
+	pipe.SAdd(ctx, nodesKey, n.cfg.NodeID)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (n *Node) info() NodeInfo {
+	return NodeInfo{
+		ID:          n.cfg.NodeID,
+		Address:     n.cfg.Address,
+		StartedAt:   n.startedAt,
+		HeartbeatAt: time.Now().UTC(),
+		Draining:    n.draining.Load(),
+		Stats:       n.hub.Stats(),
+	}
+}
+
+func (n *Node) syncUser(ctx context.Context, userID string, count int) error {
+	pipe := n.redis.Pipeline()
+	n.queueUser(ctx, pipe, userID, count)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// queueUser records the user's connection count on this node, or removes the
+// node from the user's index once the user has no connections left
+func (n *Node) queueUser(ctx context.Context, pipe redis.Pipeliner, userID string, count int) {
+	key := userKey(userID)
+	if count > 0 {
+		pipe.HSet(ctx, key, n.cfg.NodeID, count)
+		pipe.Expire(ctx, key, n.cfg.NodeTTL)
+		n.indexed[userID] = true
+		return
+	}
+	pipe.HDel(ctx, key, n.cfg.NodeID)
+	delete(n.indexed, userID)
+}
+
+// listen executes control messages directed at this node
+func (n *Node) listen(ctx context.Context) {
+	defer n.wg.Done()
+
+	ch := n.pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			n.handleControl(ctx, msg)
+		}
+	}
+}
+
+func (n *Node) handleControl(ctx context.Context, msg *redis.Message) {
+	var control controlMessage
+	if err := json.Unmarshal([]byte(msg.Payload), &control); err != nil {
+		log.Printf("Error unmarshaling control message: %v", err)
+		return
+	}
+
+	switch control.Type {
+	case controlDisconnect:
+		disconnected := n.hub.DisconnectUser(control.UserID, control.ClientID, control.Reason)
+		if control.ReplyTo == "" {
+			return
+		}
+		pipe := n.redis.Pipeline()
+		pipe.RPush(ctx, control.ReplyTo, disconnected)
+		pipe.Expire(ctx, control.ReplyTo, n.cfg.ControlTimeout)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Error answering control message: %v", err)
+		}
+
+	default:
+		log.Printf("Unknown control message type: %s", control.Type)
+	}
+}
+
+// UserNodes returns the IDs of the nodes a user is connected to
+func (n *Node) UserNodes(ctx context.Context, userID string) ([]string, error) {
+	return n.redis.HKeys(ctx, userKey(userID)).Result()
+}
+
+// DisconnectUser disconnects a user's connections on every node, or only the
+// given connection when clientID is set. Remote nodes are found through the
+// user index. It returns the number of connections closed; with
+// ErrNodesUnanswered it only covers the nodes that answered.
+func (n *Node) DisconnectUser(ctx context.Context, userID, clientID, reason string) (int, error) {
+	disconnected := n.hub.DisconnectUser(userID, clientID, reason)
+
+	nodeIDs, err := n.UserNodes(ctx, userID)
+	if err != nil {
+		return disconnected, fmt.Errorf("failed to look up user nodes: %w", err)
+	}
+
+	requestID := randomHex(8)
+	payload, err := json.Marshal(controlMessage{
+		Type:     controlDisconnect,
+		UserID:   userID,
+		ClientID: clientID,
+		Reason:   reason,
+		ReplyTo:  replyKey(requestID),
+	})
+	if err != nil {
+		return disconnected, err
+	}
+
+	// Nodes that are gone have no subscriber and are not waited for
+	pending := 0
+	for _, nodeID := range nodeIDs {
+		if nodeID == n.cfg.NodeID {
+			continue
+		}
+		receivers, err := n.redis.Publish(ctx, controlChannel(nodeID), payload).Result()
+		if err != nil {
+			return disconnected, fmt.Errorf("failed to send control message: %w", err)
+		}
+		pending += int(receivers)
+	}
+
+	deadline := time.Now().Add(n.cfg.ControlTimeout)
+	for ; pending > 0; pending-- {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			break
+		}
+		reply, err := n.redis.BLPop(ctx, wait, replyKey(requestID)).Result()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return disconnected, fmt.Errorf("failed to read control reply: %w", err)
+		}
+		var count int
+		if _, err := fmt.Sscan(reply[1], &count); err == nil {
+			disconnected += count
+		}
+	}
+
+	if pending > 0 {
+		return disconnected, fmt.Errorf("%w: %d pending", ErrNodesUnanswered, pending)
+	}
+	return disconnected, nil
+}
+
+// Nodes returns the registered nodes. The entry of this node carries live
+// statistics. Nodes whose registration expired are removed from the registry.
+func (n *Node) Nodes(ctx context.Context) ([]NodeInfo, error) {
+	nodeIDs, err := n.redis.SMembers(ctx, nodesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	keys := make([]string, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		keys[i] = nodeKey(nodeID)
+	}
+
+	var values []interface{}
+	if len(keys) > 0 {
+		values, err = n.redis.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nodes: %w", err)
+		}
+	}
+
+	nodes := []NodeInfo{n.info()}
+	for i, value := range values {
+		nodeID := nodeIDs[i]
+		if nodeID == n.cfg.NodeID {
+			continue
+		}
+		data, ok := value.(string)
+		if !ok {
+			// Stopped sending heartbeats
+			n.redis.SRem(ctx, nodesKey, nodeID)
+			continue
+		}
+		var info NodeInfo
+		if err := json.Unmarshal([]byte(data), &info); err != nil {
+			log.Printf("Error unmarshaling info of node %s: %v", nodeID, err)
+			continue
+		}
+		nodes = append(nodes, info)
+	}
+	return nodes, nil
+}
+
+// Stats are the statistics of all nodes of the cluster
+type Stats struct {
+	NodeID string           `json:"node_id"`
+	Nodes  []NodeInfo       `json:"nodes"`
+	Totals map[string]int64 `json:"totals"`
+}
+
+// Stats returns the statistics of every node, and their sum
+func (n *Node) Stats(ctx context.Context) (*Stats, error) {
+	nodes, err := n.Nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stats{
+		NodeID: n.cfg.NodeID,
+		Nodes:  nodes,
+		Totals: sumStats(nodes),
+	}, nil
+}
+
+// sumStats adds up the numeric statistics of the nodes
+func sumStats(nodes []NodeInfo) map[string]int64 {
+	totals := map[string]int64{"nodes": int64(len(nodes))}
+	for _, node := range nodes {
+		for name, value := range node.Stats {
+			switch v := value.(type) {
+			case int:
+				totals[name] += int64(v)
+			case int64:
+				totals[name] += v
+			case float64:
+				// Stats of remote nodes are decoded from JSON
+				totals[name] += int64(v)
+			}
+		}
+	}
+	return totals
+}
+
+// generateNodeID returns the host name with a random suffix, which keeps IDs
+// unique across restarts of the same container
+func generateNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "realtime"
+	}
+	return host + "-" + randomHex(4)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
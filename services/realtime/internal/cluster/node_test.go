@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumStats(t *testing.T) {
+	// Remote stats are decoded from the registry, local ones are live
+	var remote NodeInfo
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"node-2","stats":{"active_connections":3,"total_messages":40,"draining":true}}`), &remote))
+	local := NodeInfo{
+		ID: "node-1",
+		Stats: map[string]interface{}{
+			"active_connections": 2,
+			"total_messages":     int64(10),
+			"draining":           false,
+		},
+	}
+
+	totals := sumStats([]NodeInfo{local, remote})
+	assert.Equal(t, int64(2), totals["nodes"])
+	assert.Equal(t, int64(5), totals["active_connections"])
+	assert.Equal(t, int64(50), totals["total_messages"])
+	assert.NotContains(t, totals, "draining")
+}
+
+func TestRegistryKeys(t *testing.T) {
+	assert.Equal(t, "navo:realtime:node:node-1", nodeKey("node-1"))
+	assert.Equal(t, "navo:realtime:user:user-1", userKey("user-1"))
+	assert.Equal(t, "navo:realtime:control:node-1", controlChannel("node-1"))
+	assert.NotEqual(t, generateNodeID(), generateNodeID())
+}
@@ -36,11 +36,34 @@ type Config struct {
 	CleanupInterval    time.Duration
 	StaleTimeout       time.Duration
 
+	// Event replay for reconnecting clients
+	ReplayMaxEvents int64
+
+	// Cluster membership across replicas (requires Redis)
+	NodeID                   string
+	NodeAddress              string
+	ClusterHeartbeatInterval time.Duration
+	ClusterNodeTTL           time.Duration
+	ClusterControlTimeout    time.Duration
+
+	// Period over which clients are asked to reconnect on shutdown
+	DrainTimeout time.Duration
+
 	// Security
 	JWTSecret string
 	EnableTLS bool
 	TLSCert   string
 	TLSKey    string
+
+	// Channel authorization
+	CoreServiceURL    string
+	VesselServiceURL  string
+	VendorServiceURL  string
+	OwnershipCacheTTL time.Duration
+	OwnershipTimeout  time.Duration
+
+	// Audit logging (optional)
+	DatabaseURL string
 }
 
 // Load loads configuration from environment variables
@@ -74,11 +97,32 @@ func Load() *Config {
 		CleanupInterval:   getDurationEnv("CLEANUP_INTERVAL", 60*time.Second),
 		StaleTimeout:      getDurationEnv("STALE_TIMEOUT", 120*time.Second),
 
+		// Event replay
+		ReplayMaxEvents: getIntEnv("REPLAY_MAX_EVENTS", 1000),
+
+		// Cluster
+		NodeID:                   getEnv("NODE_ID", ""),
+		NodeAddress:              getEnv("NODE_ADDRESS", ""),
+		ClusterHeartbeatInterval: getDurationEnv("CLUSTER_HEARTBEAT_INTERVAL", 10*time.Second),
+		ClusterNodeTTL:           getDurationEnv("CLUSTER_NODE_TTL", 30*time.Second),
+		ClusterControlTimeout:    getDurationEnv("CLUSTER_CONTROL_TIMEOUT", 3*time.Second),
+		DrainTimeout:             getDurationEnv("DRAIN_TIMEOUT", 10*time.Second),
+
 		// Security
 		JWTSecret: getEnv("JWT_SECRET", ""),
 		EnableTLS: getBoolEnv("ENABLE_TLS", false),
 		TLSCert:   getEnv("TLS_CERT", ""),
 		TLSKey:    getEnv("TLS_KEY", ""),
+
+		// Channel authorization
+		CoreServiceURL:    getEnv("CORE_SERVICE_URL", "http://localhost:4002"),
+		VesselServiceURL:  getEnv("VESSEL_SERVICE_URL", "http://localhost:4003"),
+		VendorServiceURL:  getEnv("VENDOR_SERVICE_URL", "http://localhost:4004"),
+		OwnershipCacheTTL: getDurationEnv("OWNERSHIP_CACHE_TTL", 30*time.Second),
+		OwnershipTimeout:  getDurationEnv("OWNERSHIP_TIMEOUT", 5*time.Second),
+
+		// Audit logging
+		DatabaseURL: getEnv("DATABASE_URL", ""),
 	}
 }
 
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
+	"github.com/navo/services/realtime/internal/cluster"
 	"github.com/navo/services/realtime/internal/hub"
 	"github.com/navo/services/realtime/internal/model"
 	"github.com/navo/services/realtime/internal/subscription"
@@ -13,13 +15,16 @@ import (
 type APIHandler struct {
 	hub     *hub.Hub
 	subMgr  *subscription.Manager
+	cluster *cluster.Node
 }
 
-// NewAPIHandler creates a new API handler
-func NewAPIHandler(h *hub.Hub, subMgr *subscription.Manager) *APIHandler {
+// NewAPIHandler creates a new API handler. clusterNode may be nil when
+// running as a single instance, in which case only local clients are covered.
+func NewAPIHandler(h *hub.Hub, subMgr *subscription.Manager, clusterNode *cluster.Node) *APIHandler {
 	return &APIHandler{
-		hub:    h,
-		subMgr: subMgr,
+		hub:     h,
+		subMgr:  subMgr,
+		cluster: clusterNode,
 	}
 }
 
@@ -32,6 +37,24 @@ func (h *APIHandler) Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Ready reports whether the node accepts new clients. It fails while the node
+// drains on shutdown, so load balancers send reconnecting clients elsewhere.
+func (h *APIHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.hub.Draining() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "draining",
+			"service": "realtime",
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "ready",
+		"service": "realtime",
+	})
+}
+
 // Stats returns hub and subscription statistics
 func (h *APIHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	hubStats := h.hub.Stats()
@@ -44,6 +67,15 @@ func (h *APIHandler) Stats(w http.ResponseWriter, r *http.Request) {
 		stats["subscriptions"] = h.subMgr.ChannelStats()
 	}
 
+	if h.cluster != nil {
+		clusterStats, err := h.cluster.Stats(r.Context())
+		if err != nil {
+			log.Printf("Error reading cluster stats: %v", err)
+		} else {
+			stats["cluster"] = clusterStats
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
@@ -99,34 +131,72 @@ func (h *APIHandler) PublishEvent(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetConnections returns active connection information
+// GetConnections returns active connection information, across all nodes
+// when running as a cluster
 func (h *APIHandler) GetConnections(w http.ResponseWriter, r *http.Request) {
 	stats := h.hub.Stats()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	connections := map[string]interface{}{
 		"active_connections": stats["active_connections"],
 		"total_connections":  stats["total_connections"],
-	})
+	}
+
+	if h.cluster != nil {
+		clusterStats, err := h.cluster.Stats(r.Context())
+		if err != nil {
+			log.Printf("Error reading cluster stats: %v", err)
+		} else {
+			nodes := make([]map[string]interface{}, 0, len(clusterStats.Nodes))
+			for _, node := range clusterStats.Nodes {
+				nodes = append(nodes, map[string]interface{}{
+					"id":                 node.ID,
+					"draining":           node.Draining,
+					"active_connections": node.Stats["active_connections"],
+				})
+			}
+			connections = map[string]interface{}{
+				"active_connections": clusterStats.Totals["active_connections"],
+				"total_connections":  clusterStats.Totals["total_connections"],
+				"nodes":              nodes,
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(connections)
 }
 
-// DisconnectUser disconnects all connections for a specific user
+// DisconnectUser disconnects all connections for a specific user, or the one
+// named by client_id, on whichever nodes they are connected to
 func (h *APIHandler) DisconnectUser(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
 		http.Error(w, "user_id required", http.StatusBadRequest)
 		return
 	}
+	clientID := r.URL.Query().Get("client_id")
+	reason := r.URL.Query().Get("reason")
+	if reason == "" {
+		reason = "disconnected by administrator"
+	}
 
-	clients := h.hub.GetClientsByUser(userID)
-	for _, client := range clients {
-		h.hub.Unregister(client)
+	complete := true
+	var disconnected int
+	if h.cluster != nil {
+		var err error
+		disconnected, err = h.cluster.DisconnectUser(r.Context(), userID, clientID, reason)
+		if err != nil {
+			log.Printf("Cluster disconnect of user %s incomplete: %v", userID, err)
+			complete = false
+		}
+	} else {
+		disconnected = h.hub.DisconnectUser(userID, clientID, reason)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":       "disconnected",
 		"user_id":      userID,
-		"disconnected": len(clients),
+		"disconnected": disconnected,
+		"complete":     complete,
 	})
 }
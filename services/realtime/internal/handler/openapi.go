@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/navo/pkg/openapi"
+)
+
+// sessionParams are the query parameters opening an SSE or long-poll
+// session
+var sessionParams = []openapi.Param{
+	{Name: "last_event_id", Description: "Event to resume after. Browsers send it as the Last-Event-ID header instead."},
+}
+
+// Operations describe what the realtime service's HTTP handlers read and
+// write, for its OpenAPI document and request validation. The WebSocket
+// protocol on /ws is described in the API documentation.
+var Operations = openapi.Operations{
+	// Fallback transports
+	"GET /api/v1/realtime/events": {
+		Summary:     "Stream events as server-sent events",
+		Description: "Opens a session streaming text/event-stream. The first event names the session's client ID.",
+		Query:       sessionParams,
+	},
+	"GET /api/v1/realtime/poll": {
+		Summary:     "Long-poll for events",
+		Description: "The first poll, without client_id, opens a session. Answers 410 Gone once the session expired.",
+		Query: append([]openapi.Param{
+			{Name: "client_id", Description: "Session to poll, from an earlier poll"},
+		}, sessionParams...),
+		Response: struct {
+			ClientID string            `json:"client_id"`
+			Messages []json.RawMessage `json:"messages"`
+		}{},
+	},
+	"GET /api/v1/realtime/sessions/{clientID}/subscriptions": {
+		Summary: "List a session's subscriptions",
+		Response: struct {
+			ClientID string   `json:"client_id"`
+			Channels []string `json:"channels"`
+		}{},
+	},
+	"POST /api/v1/realtime/sessions/{clientID}/subscriptions": {
+		Summary: "Subscribe a session to a channel",
+		Request: struct {
+			Channel string `json:"channel" validate:"required"`
+		}{},
+		Response: map[string]string{},
+	},
+	"DELETE /api/v1/realtime/sessions/{clientID}/subscriptions/{channel}": {
+		Summary:  "Unsubscribe a session from a channel",
+		Response: map[string]string{},
+	},
+	"POST /api/v1/realtime/sessions/{clientID}/presence": {
+		Summary: "Show a session's user as viewing or editing an entity",
+		Request: struct {
+			Channel string `json:"channel" validate:"required"`
+			Mode    string `json:"mode" validate:"omitempty,oneof=viewing editing"`
+		}{},
+		Response: struct {
+			Channel string `json:"channel"`
+			Viewers any    `json:"viewers"`
+		}{},
+	},
+	"DELETE /api/v1/realtime/sessions/{clientID}/presence/{channel}": {
+		Summary:  "Remove a session's presence from an entity",
+		Response: map[string]string{},
+	},
+
+	// Administration
+	"GET /api/v1/stats": {
+		Summary:  "Get hub, subscription and cluster statistics",
+		Tags:     []string{"admin"},
+		Response: map[string]any{},
+	},
+	"GET /api/v1/connections": {
+		Summary:  "Count active connections",
+		Tags:     []string{"admin"},
+		Response: map[string]any{},
+	},
+	"POST /api/v1/events": {
+		Summary: "Publish an event to connected clients",
+		Tags:    []string{"admin"},
+		Request: struct {
+			Type           string   `json:"type" validate:"required"`
+			Data           any      `json:"data"`
+			OrganizationID string   `json:"organization_id"`
+			WorkspaceID    string   `json:"workspace_id"`
+			UserIDs        []string `json:"user_ids,omitempty"`
+		}{},
+		Response: struct {
+			Status  string `json:"status"`
+			EventID string `json:"event_id"`
+		}{},
+		Status: http.StatusAccepted,
+	},
+	"POST /api/v1/disconnect": {
+		Summary: "Disconnect a user's connections",
+		Tags:    []string{"admin"},
+		Query: []openapi.Param{
+			{Name: "user_id", Required: true},
+			{Name: "client_id", Description: "Only this connection of the user's"},
+			{Name: "reason"},
+		},
+		Response: struct {
+			Status       string `json:"status"`
+			UserID       string `json:"user_id"`
+			Disconnected int    `json:"disconnected"`
+			Complete     bool   `json:"complete"`
+		}{},
+	},
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/services/realtime/internal/model"
+)
+
+var (
+	errPresenceChannel = errors.New("presence requires an entity channel")
+	errPresenceMode    = errors.New("presence mode must be viewing or editing")
+)
+
+// presenceMode reads the mode from a presence message's data, defaulting to
+// viewing
+func presenceMode(data any) model.PresenceMode {
+	if fields, ok := data.(map[string]any); ok {
+		if mode, ok := fields["mode"].(string); ok && mode != "" {
+			return model.PresenceMode(mode)
+		}
+	}
+	return model.PresenceViewing
+}
+
+// isEntityChannel reports whether a channel names a single entity, such as
+// "port_call:<id>", rather than an event type
+func isEntityChannel(channel string) bool {
+	category, id, ok := strings.Cut(channel, ":")
+	return ok && category != "" && id != "" && !model.IsEventType(channel)
+}
+
+// joinPresence marks a client as viewing or editing an entity, subscribing it
+// to the entity's channel first with the usual authorization. It returns the
+// other clients present.
+func (s *sessions) joinPresence(client *model.Client, channel string, mode model.PresenceMode) ([]model.Presence, error) {
+	if !isEntityChannel(channel) {
+		return nil, errPresenceChannel
+	}
+	if !mode.IsValid() {
+		return nil, errPresenceMode
+	}
+
+	// Viewers receive the entity's updates along with presence
+	if !client.IsSubscribed(channel) {
+		if err := s.subscribe(client, channel); err != nil {
+			return nil, err
+		}
+	}
+	return s.hub.JoinPresence(client, channel, mode), nil
+}
+
+// handlePresenceJoin processes presence_join messages
+func (h *WebSocketHandler) handlePresenceJoin(client *model.Client, msg model.ClientMessage) {
+	viewers, err := h.joinPresence(client, msg.Channel, presenceMode(msg.Data))
+	switch {
+	case errors.Is(err, errPresenceChannel), errors.Is(err, errPresenceMode):
+		h.sendError(client, err.Error())
+		return
+	case err != nil:
+		h.sendError(client, "Not authorized to subscribe to channel")
+		return
+	}
+
+	h.sendResponse(client, model.MsgTypePresenceState, map[string]interface{}{
+		"channel": msg.Channel,
+		"viewers": viewers,
+	})
+}
+
+// handlePresenceHeartbeat processes presence_heartbeat messages. Clients send
+// them while the entity stays open, and to switch between viewing and editing.
+func (h *WebSocketHandler) handlePresenceHeartbeat(client *model.Client, msg model.ClientMessage) {
+	mode := presenceMode(msg.Data)
+	if !mode.IsValid() {
+		h.sendError(client, errPresenceMode.Error())
+		return
+	}
+
+	client.UpdatePing()
+	if !h.hub.HeartbeatPresence(client, msg.Channel, mode) {
+		h.sendError(client, "Not present on channel, join first")
+	}
+}
+
+// handlePresenceLeave processes presence_leave messages
+func (h *WebSocketHandler) handlePresenceLeave(client *model.Client, channel string) {
+	h.hub.LeavePresence(client, channel)
+}
+
+// JoinPresence marks an SSE or long-poll client as viewing or editing an
+// entity. Calling it again acts as a heartbeat, so HTTP clients only need
+// this and LeavePresence.
+func (h *StreamHandler) JoinPresence(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.sessionClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Channel string             `json:"channel"`
+		Mode    model.PresenceMode `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = model.PresenceViewing
+	}
+
+	client.UpdatePing()
+	viewers, err := h.joinPresence(client, req.Channel, req.Mode)
+	switch {
+	case errors.Is(err, errPresenceChannel), errors.Is(err, errPresenceMode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Not authorized to subscribe to channel", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"channel": req.Channel,
+		"viewers": viewers,
+	})
+}
+
+// LeavePresence removes the presence of an SSE or long-poll client from an
+// entity
+func (h *StreamHandler) LeavePresence(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.sessionClient(w, r)
+	if !ok {
+		return
+	}
+
+	channel := chi.URLParam(r, "channel")
+	h.hub.LeavePresence(client, channel)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  model.PresenceActionLeft,
+		"channel": channel,
+	})
+}
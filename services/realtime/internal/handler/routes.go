@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers are the handlers serving the realtime service's API
+type Handlers struct {
+	API    *APIHandler
+	Stream *StreamHandler
+
+	// OptionalAuth authenticates the fallback transports when a token is
+	// given, and Auth guards the admin endpoints. Both are nil when
+	// authentication is disabled.
+	OptionalAuth func(http.Handler) http.Handler
+	Auth         func(http.Handler) http.Handler
+}
+
+// Routes registers the API routes. Each must have an entry in Operations,
+// which the tests check.
+func Routes(r chi.Router, h Handlers) {
+	// Fallback transports for networks that block WebSocket upgrades
+	r.Group(func(r chi.Router) {
+		if h.OptionalAuth != nil {
+			r.Use(h.OptionalAuth)
+		}
+		r.Route("/api/v1/realtime", func(r chi.Router) {
+			r.Get("/events", h.Stream.ServeSSE)
+			r.Get("/poll", h.Stream.Poll)
+			r.Get("/sessions/{clientID}/subscriptions", h.Stream.ListSubscriptions)
+			r.Post("/sessions/{clientID}/subscriptions", h.Stream.Subscribe)
+			r.Delete("/sessions/{clientID}/subscriptions/{channel}", h.Stream.Unsubscribe)
+			r.Post("/sessions/{clientID}/presence", h.Stream.JoinPresence)
+			r.Delete("/sessions/{clientID}/presence/{channel}", h.Stream.LeavePresence)
+		})
+	})
+
+	// API endpoints (admin/internal)
+	r.Route("/api/v1", func(r chi.Router) {
+		// Stats endpoint (could be protected in production)
+		r.Get("/stats", h.API.Stats)
+		r.Get("/connections", h.API.GetConnections)
+
+		// Protected admin endpoints
+		r.Group(func(r chi.Router) {
+			if h.Auth != nil {
+				r.Use(h.Auth)
+			}
+			r.Post("/events", h.API.PublishEvent)
+			r.Post("/disconnect", h.API.DisconnectUser)
+		})
+	})
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/navo/pkg/auth"
+	"github.com/navo/pkg/realtime"
+	"github.com/navo/services/realtime/internal/authz"
+	"github.com/navo/services/realtime/internal/hub"
+	"github.com/navo/services/realtime/internal/middleware"
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/navo/services/realtime/internal/replay"
+)
+
+const (
+	// Time allowed to authorize a channel subscription
+	authorizeTimeout = 5 * time.Second
+
+	// Time allowed to read missed events, and to queue each of them
+	replayTimeout     = 10 * time.Second
+	replaySendTimeout = 5 * time.Second
+)
+
+// identity is the principal a client connects as
+type identity struct {
+	userID      string
+	orgID       string
+	workspaceID string
+	claims      *auth.Claims
+}
+
+// sessions holds what the WebSocket, SSE and long-poll transports share:
+// identifying and registering clients, replaying missed events and
+// authorizing subscriptions
+type sessions struct {
+	hub            *hub.Hub
+	authorizer     authz.Authorizer
+	replay         *replay.Store
+	allowAnonymous bool
+}
+
+func newSessions(h *hub.Hub, authorizer authz.Authorizer, replayStore *replay.Store, allowAnonymous bool) *sessions {
+	return &sessions{
+		hub:            h,
+		authorizer:     authorizer,
+		replay:         replayStore,
+		allowAnonymous: allowAnonymous,
+	}
+}
+
+// identify returns the requesting principal. userID is empty when the request
+// is not authenticated.
+func (s *sessions) identify(r *http.Request) identity {
+	// Extract user information from context (set by auth middleware)
+	id := identity{
+		userID:      middleware.GetUserID(r.Context()),
+		orgID:       middleware.GetOrganizationID(r.Context()),
+		workspaceID: middleware.GetWorkspaceID(r.Context()),
+		claims:      middleware.GetClaims(r.Context()),
+	}
+
+	// For development, allow query params
+	if id.claims == nil && s.allowAnonymous {
+		id.userID = r.URL.Query().Get("user_id")
+		id.orgID = r.URL.Query().Get("organization_id")
+		id.workspaceID = r.URL.Query().Get("workspace_id")
+	}
+	return id
+}
+
+// owns reports whether the requesting principal is the client's user
+func (s *sessions) owns(r *http.Request, client *model.Client) bool {
+	id := s.identify(r)
+	return id.userID != "" && id.userID == client.UserID && id.orgID == client.OrganizationID
+}
+
+// refuseDraining answers 503 while the node drains on shutdown, so the
+// client reconnects to another node. It reports whether it did.
+func (s *sessions) refuseDraining(w http.ResponseWriter) bool {
+	if !s.hub.Draining() {
+		return false
+	}
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "Server shutting down, reconnect", http.StatusServiceUnavailable)
+	return true
+}
+
+// lastEventID returns the event a reconnecting client last received, from the
+// last_event_id query parameter or the Last-Event-ID header that browsers send
+// when an EventSource reconnects
+func lastEventID(r *http.Request) string {
+	if id := r.URL.Query().Get("last_event_id"); id != "" {
+		return id
+	}
+	return r.Header.Get("Last-Event-ID")
+}
+
+// open creates a client and registers it with the hub. conn is nil for the
+// HTTP transports. When lastEventID is set, the events missed since are
+// replayed before live events.
+func (s *sessions) open(id identity, conn *websocket.Conn, lastEventID string) *model.Client {
+	client := model.NewClient(conn, id.userID, id.orgID, id.workspaceID)
+	client.Claims = id.claims
+
+	// Hold back live events until missed ones have been replayed
+	if lastEventID != "" {
+		client.BeginReplay()
+	}
+
+	// Register client with hub
+	s.hub.Register(client)
+
+	// Send welcome message
+	s.sendWelcome(client)
+
+	if lastEventID != "" {
+		go s.replayMissed(client, lastEventID)
+	}
+	return client
+}
+
+// subscribe authorizes and adds a channel subscription
+func (s *sessions) subscribe(client *model.Client, channel string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), authorizeTimeout)
+	defer cancel()
+	if err := s.authorizer.Authorize(ctx, client, channel); err != nil {
+		return err
+	}
+
+	s.hub.Subscribe(client, channel)
+	return nil
+}
+
+// replayMissed delivers the events a reconnecting client missed after
+// lastEventID, in order, followed by the live events held back meanwhile
+func (s *sessions) replayMissed(client *model.Client, lastEventID string) {
+	events, err := s.readMissed(client, lastEventID)
+	if err != nil {
+		log.Printf("Replay for client %s after %s failed: %v", client.ID, lastEventID, err)
+		client.EndReplay()
+		s.sendResyncRequired(client, lastEventID, err)
+		return
+	}
+
+	replayed, lastReplayedID := 0, lastEventID
+	for _, event := range events {
+		lastReplayedID = event.ID
+		if !hub.InScope(client, event) {
+			continue
+		}
+		message, err := json.Marshal(model.ServerMessage{Type: model.MsgTypeEvent, Event: event})
+		if err != nil {
+			log.Printf("Error marshaling replayed event %s: %v", event.ID, err)
+			continue
+		}
+		if !s.deliver(client, message) {
+			return
+		}
+		replayed++
+	}
+
+	s.sendResponse(client, model.MsgTypeReplayComplete, map[string]interface{}{
+		"replayed":      replayed,
+		"last_event_id": lastReplayedID,
+	})
+
+	// Flush live events that arrived during the replay; anything already
+	// covered by the replay is skipped
+	for {
+		held, overflowed := client.TakeHeld()
+		if overflowed {
+			s.sendResyncRequired(client, lastReplayedID, replay.ErrGapTooLarge)
+			return
+		}
+		if len(held) == 0 {
+			return
+		}
+		for _, msg := range held {
+			if cmp, err := realtime.CompareStreamIDs(msg.EventID, lastReplayedID); err == nil && cmp <= 0 {
+				continue
+			}
+			if !s.deliver(client, msg.Message) {
+				return
+			}
+		}
+	}
+}
+
+func (s *sessions) readMissed(client *model.Client, lastEventID string) ([]*model.Event, error) {
+	if s.replay == nil || client.OrganizationID == "" {
+		return nil, errors.New("event replay unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	defer cancel()
+	return s.replay.Since(ctx, client.OrganizationID, lastEventID)
+}
+
+// deliver queues a message for a client, waiting for room in its send
+// buffer. Clients that cannot keep up are disconnected.
+func (s *sessions) deliver(client *model.Client, message []byte) bool {
+	if s.hub.SendDirect(client, message, replaySendTimeout) {
+		return true
+	}
+	client.EndReplay()
+	go s.hub.Unregister(client)
+	return false
+}
+
+// sendResyncRequired tells a client its missed events cannot be replayed and
+// it has to reload its state
+func (s *sessions) sendResyncRequired(client *model.Client, lastEventID string, reason error) {
+	msg := "event replay failed, please resync"
+	if errors.Is(reason, replay.ErrGapTooLarge) || errors.Is(reason, replay.ErrInvalidEventID) {
+		msg = reason.Error()
+	}
+	s.sendResponse(client, model.MsgTypeResyncRequired, map[string]string{
+		"error":         msg,
+		"last_event_id": lastEventID,
+	})
+}
+
+// sendWelcome sends a welcome message to a newly connected client
+func (s *sessions) sendWelcome(client *model.Client) {
+	s.sendResponse(client, "connected", map[string]interface{}{
+		"client_id":       client.ID,
+		"user_id":         client.UserID,
+		"organization_id": client.OrganizationID,
+		"workspace_id":    client.WorkspaceID,
+		"connected_at":    client.ConnectedAt,
+	})
+}
+
+// sendResponse sends a response message to a client
+func (s *sessions) sendResponse(client *model.Client, msgType string, data interface{}) {
+	msg := model.ServerMessage{
+		Type: msgType,
+		Data: data,
+	}
+
+	jsonMsg, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error marshaling response: %v", err)
+		return
+	}
+
+	select {
+	case client.Send <- jsonMsg:
+	default:
+		log.Printf("Client %s send buffer full, dropping message", client.ID)
+	}
+}
+
+// sendError sends an error message to a client
+func (s *sessions) sendError(client *model.Client, errMsg string) {
+	s.sendResponse(client, model.MsgTypeError, map[string]string{
+		"error": errMsg,
+	})
+}
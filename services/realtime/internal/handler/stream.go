@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navo/pkg/realtime"
+	"github.com/navo/services/realtime/internal/authz"
+	"github.com/navo/services/realtime/internal/hub"
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/navo/services/realtime/internal/replay"
+)
+
+const (
+	// Interval of SSE comments that keep proxies from closing idle streams;
+	// they also keep the client from being removed as stale
+	sseKeepAlive = 15 * time.Second
+
+	// Reconnect delay suggested to EventSource clients
+	sseRetry = 3 * time.Second
+
+	// Time a long poll waits for the first message
+	longPollTimeout = 25 * time.Second
+
+	// Maximum number of messages returned by one long poll
+	maxPollMessages = 100
+)
+
+// StreamHandler serves the HTTP fallback transports for networks that block
+// WebSocket upgrades: Server-Sent Events and long polling. Clients are
+// registered with the hub exactly like WebSocket clients; since these
+// transports are one-way, subscriptions are managed through a small REST API
+// keyed by the client ID from the "connected" message.
+type StreamHandler struct {
+	*sessions
+}
+
+// NewStreamHandler creates a new SSE and long-poll handler. The arguments are
+// the same as for NewWebSocketHandler.
+func NewStreamHandler(h *hub.Hub, authorizer authz.Authorizer, replayStore *replay.Store, allowAnonymous bool) *StreamHandler {
+	return &StreamHandler{
+		sessions: newSessions(h, authorizer, replayStore, allowAnonymous),
+	}
+}
+
+// ServeSSE streams a client's messages as Server-Sent Events. Events carry
+// their stream ID, so a reconnecting EventSource resumes through the
+// Last-Event-ID header.
+func (h *StreamHandler) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	id := h.identify(r)
+	if id.userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if h.refuseDraining(w) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Could not clear write deadline for SSE stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	flusher.Flush()
+
+	client := h.open(id, nil, lastEventID(r))
+	defer h.hub.Unregister(client)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case message, ok := <-client.Send:
+			if !ok {
+				// Hub closed the client
+				return
+			}
+			if err := writeSSE(w, message); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-keepAlive.C:
+			client.UpdatePing()
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE writes a server message as an SSE event named after the message
+// type. Events with a stream ID also carry it as the SSE event ID.
+func writeSSE(w io.Writer, message []byte) error {
+	var msg struct {
+		Type  string `json:"type"`
+		Event *struct {
+			ID string `json:"id"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+
+	if msg.Event != nil {
+		if _, _, err := realtime.ParseStreamID(msg.Event.ID); err == nil {
+			if _, err := fmt.Fprintf(w, "id: %s\n", msg.Event.ID); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, message)
+	return err
+}
+
+// Poll returns the messages queued for a long-poll client, waiting for up to
+// longPollTimeout when there are none. The first poll, without client_id,
+// opens a session and may resume from last_event_id. Sessions that stop
+// polling expire like stale WebSocket connections.
+func (h *StreamHandler) Poll(w http.ResponseWriter, r *http.Request) {
+	var client *model.Client
+	if clientID := r.URL.Query().Get("client_id"); clientID != "" {
+		existing, ok := h.hub.GetClient(clientID)
+		if !ok || !h.owns(r, existing) {
+			http.Error(w, "Session expired, reconnect with last_event_id", http.StatusGone)
+			return
+		}
+		client = existing
+	} else {
+		id := h.identify(r)
+		if id.userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if h.refuseDraining(w) {
+			return
+		}
+		client = h.open(id, nil, lastEventID(r))
+	}
+	client.UpdatePing()
+
+	// The poll may outlast the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(longPollTimeout + writeWait)); err != nil {
+		log.Printf("Could not extend write deadline for long poll: %v", err)
+	}
+
+	messages, open := collectMessages(r.Context(), client, longPollTimeout)
+	if !open && len(messages) == 0 {
+		http.Error(w, "Session expired, reconnect with last_event_id", http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"client_id": client.ID,
+		"messages":  messages,
+	})
+}
+
+// collectMessages waits until a message is queued, then takes what else is
+// queued. open is false once the hub has closed the client.
+func collectMessages(ctx context.Context, client *model.Client, timeout time.Duration) (messages []json.RawMessage, open bool) {
+	messages = make([]json.RawMessage, 0)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return messages, true
+	case <-timer.C:
+		return messages, true
+	case message, ok := <-client.Send:
+		if !ok {
+			return messages, false
+		}
+		messages = append(messages, message)
+	}
+
+	for len(messages) < maxPollMessages {
+		select {
+		case message, ok := <-client.Send:
+			if !ok {
+				return messages, false
+			}
+			messages = append(messages, message)
+		default:
+			return messages, true
+		}
+	}
+	return messages, true
+}
+
+// ListSubscriptions returns the channels an SSE or long-poll client is
+// subscribed to
+func (h *StreamHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.sessionClient(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"client_id": client.ID,
+		"channels":  client.GetSubscriptions(),
+	})
+}
+
+// Subscribe subscribes an SSE or long-poll client to a channel, with the same
+// authorization as a WebSocket subscribe message
+func (h *StreamHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.sessionClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Channel string `json:"channel"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Channel == "" {
+		http.Error(w, "Channel required for subscription", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscribe(client, req.Channel); err != nil {
+		http.Error(w, "Not authorized to subscribe to channel", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  model.MsgTypeSubscribed,
+		"channel": req.Channel,
+	})
+}
+
+// Unsubscribe removes a channel subscription of an SSE or long-poll client
+func (h *StreamHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.sessionClient(w, r)
+	if !ok {
+		return
+	}
+
+	channel := chi.URLParam(r, "channel")
+	if client.IsSubscribed(channel) {
+		h.hub.Unsubscribe(client, channel)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  model.MsgTypeUnsubscribed,
+		"channel": channel,
+	})
+}
+
+// sessionClient returns the connected client named in the URL, provided it
+// belongs to the requesting user
+func (h *StreamHandler) sessionClient(w http.ResponseWriter, r *http.Request) (*model.Client, bool) {
+	client, ok := h.hub.GetClient(chi.URLParam(r, "clientID"))
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !h.owns(r, client) {
+		// Do not reveal other users' sessions
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return client, true
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSSE(t *testing.T) {
+	var buf bytes.Buffer
+	event := []byte(`{"type":"event","event":{"id":"1700000000000-4","type":"port_call:updated"}}`)
+	require.NoError(t, writeSSE(&buf, event))
+	assert.Equal(t, "id: 1700000000000-4\nevent: event\ndata: "+string(event)+"\n\n", buf.String())
+
+	// Events without a stream ID cannot be resumed from
+	buf.Reset()
+	local := []byte(`{"type":"event","event":{"id":"20240101120000.000000"}}`)
+	require.NoError(t, writeSSE(&buf, local))
+	assert.Equal(t, "event: event\ndata: "+string(local)+"\n\n", buf.String())
+
+	buf.Reset()
+	heartbeat := []byte(`{"type":"heartbeat","data":{}}`)
+	require.NoError(t, writeSSE(&buf, heartbeat))
+	assert.Equal(t, "event: heartbeat\ndata: "+string(heartbeat)+"\n\n", buf.String())
+}
+
+func TestCollectMessages(t *testing.T) {
+	client := model.NewClient(nil, "user-1", "org-1", "")
+
+	messages, open := collectMessages(context.Background(), client, 10*time.Millisecond)
+	assert.True(t, open)
+	assert.Empty(t, messages)
+
+	client.Send <- []byte(`{"type":"a"}`)
+	client.Send <- []byte(`{"type":"b"}`)
+	messages, open = collectMessages(context.Background(), client, time.Second)
+	assert.True(t, open)
+	require.Len(t, messages, 2)
+	assert.JSONEq(t, `{"type":"a"}`, string(messages[0]))
+
+	close(client.Send)
+	_, open = collectMessages(context.Background(), client, time.Second)
+	assert.False(t, open)
+}
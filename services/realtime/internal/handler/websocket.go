@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/navo/services/realtime/internal/authz"
 	"github.com/navo/services/realtime/internal/hub"
 	"github.com/navo/services/realtime/internal/model"
+	"github.com/navo/services/realtime/internal/replay"
 )
 
 const (
@@ -36,36 +38,29 @@ var upgrader = websocket.Upgrader{
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub *hub.Hub
+	*sessions
 }
 
-// NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(h *hub.Hub) *WebSocketHandler {
-	return &WebSocketHandler{hub: h}
+// NewWebSocketHandler creates a new WebSocket handler. replayStore may be nil
+// when running without Redis, in which case reconnecting clients are asked to
+// resync. allowAnonymous lets clients identify themselves through query
+// parameters and should only be set when authentication is disabled.
+func NewWebSocketHandler(h *hub.Hub, authorizer authz.Authorizer, replayStore *replay.Store, allowAnonymous bool) *WebSocketHandler {
+	return &WebSocketHandler{
+		sessions: newSessions(h, authorizer, replayStore, allowAnonymous),
+	}
 }
 
 // ServeWS handles WebSocket upgrade requests
 func (h *WebSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
-	// Extract user information from context (set by auth middleware)
-	userID := r.Context().Value("user_id")
-	orgID := r.Context().Value("organization_id")
-	workspaceID := r.Context().Value("workspace_id")
-
-	// For development, allow query params
-	if userID == nil || userID == "" {
-		userID = r.URL.Query().Get("user_id")
-	}
-	if orgID == nil || orgID == "" {
-		orgID = r.URL.Query().Get("organization_id")
-	}
-	if workspaceID == nil || workspaceID == "" {
-		workspaceID = r.URL.Query().Get("workspace_id")
-	}
-
-	if userID == nil || userID == "" {
+	id := h.identify(r)
+	if id.userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if h.refuseDraining(w) {
+		return
+	}
 
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -74,23 +69,12 @@ func (h *WebSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create client
-	client := model.NewClient(
-		conn,
-		toString(userID),
-		toString(orgID),
-		toString(workspaceID),
-	)
-
-	// Register client with hub
-	h.hub.Register(client)
+	// Create and register client
+	client := h.open(id, conn, lastEventID(r))
 
 	// Start read/write pumps
 	go h.writePump(client)
 	go h.readPump(client)
-
-	// Send welcome message
-	h.sendWelcome(client)
 }
 
 // readPump pumps messages from the WebSocket connection to the hub
@@ -183,6 +167,15 @@ func (h *WebSocketHandler) handleMessage(client *model.Client, message []byte) {
 	case model.MsgTypePing:
 		h.handlePing(client)
 
+	case model.MsgTypePresenceJoin:
+		h.handlePresenceJoin(client, msg)
+
+	case model.MsgTypePresenceHeartbeat:
+		h.handlePresenceHeartbeat(client, msg)
+
+	case model.MsgTypePresenceLeave:
+		h.handlePresenceLeave(client, msg.Channel)
+
 	default:
 		h.sendError(client, "Unknown message type")
 	}
@@ -195,13 +188,11 @@ func (h *WebSocketHandler) handleSubscribe(client *model.Client, channel string)
 		return
 	}
 
-	// Validate channel access (could check permissions here)
-	if !h.canSubscribe(client, channel) {
+	if err := h.subscribe(client, channel); err != nil {
 		h.sendError(client, "Not authorized to subscribe to channel")
 		return
 	}
 
-	h.hub.Subscribe(client, channel)
 	h.sendResponse(client, model.MsgTypeSubscribed, map[string]string{
 		"channel": channel,
 	})
@@ -227,58 +218,3 @@ func (h *WebSocketHandler) handlePing(client *model.Client) {
 		"timestamp": time.Now().UTC(),
 	})
 }
-
-// canSubscribe checks if a client can subscribe to a channel
-func (h *WebSocketHandler) canSubscribe(client *model.Client, channel string) bool {
-	// TODO: Implement proper channel authorization
-	// For now, allow all subscriptions within the client's org/workspace scope
-	return true
-}
-
-// sendWelcome sends a welcome message to a newly connected client
-func (h *WebSocketHandler) sendWelcome(client *model.Client) {
-	h.sendResponse(client, "connected", map[string]interface{}{
-		"client_id":       client.ID,
-		"user_id":         client.UserID,
-		"organization_id": client.OrganizationID,
-		"workspace_id":    client.WorkspaceID,
-		"connected_at":    client.ConnectedAt,
-	})
-}
-
-// sendResponse sends a response message to a client
-func (h *WebSocketHandler) sendResponse(client *model.Client, msgType string, data interface{}) {
-	msg := model.ServerMessage{
-		Type: msgType,
-		Data: data,
-	}
-
-	jsonMsg, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
-		return
-	}
-
-	select {
-	case client.Send <- jsonMsg:
-	default:
-		log.Printf("Client %s send buffer full, dropping message", client.ID)
-	}
-}
-
-// sendError sends an error message to a client
-func (h *WebSocketHandler) sendError(client *model.Client, errMsg string) {
-	h.sendResponse(client, model.MsgTypeError, map[string]string{
-		"error": errMsg,
-	})
-}
-
-func toString(v interface{}) string {
-	if v == nil {
-		return ""
-	}
-	if s, ok := v.(string); ok {
-		return s
-	}
-	return ""
-}
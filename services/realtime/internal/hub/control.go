@@ -0,0 +1,116 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+)
+
+// drainFlushDelay is how long a draining hub waits for the reconnect message
+// to reach clients before disconnecting them
+const drainFlushDelay = time.Second
+
+// OnUserChange registers a function called whenever a user connects or
+// disconnects. It runs on the hub's loop and must not block. Set it before
+// calling Run.
+func (h *Hub) OnUserChange(fn func(userID string)) {
+	h.userListener = fn
+}
+
+func (h *Hub) notifyUser(userID string) {
+	if h.userListener != nil {
+		h.userListener(userID)
+	}
+}
+
+// UserConnections returns the number of connections of each connected user
+func (h *Hub) UserConnections() map[string]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	counts := make(map[string]int, len(h.userClients))
+	for userID, clients := range h.userClients {
+		counts[userID] = len(clients)
+	}
+	return counts
+}
+
+// UserConnectionCount returns the number of connections of a user
+func (h *Hub) UserConnectionCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.userClients[userID])
+}
+
+// DisconnectUser tells a user's clients why they are being disconnected and
+// disconnects them. When clientID is set only that connection is closed. It
+// returns the number of connections closed.
+func (h *Hub) DisconnectUser(userID, clientID, reason string) int {
+	message, _ := json.Marshal(model.ServerMessage{
+		Type: model.MsgTypeDisconnect,
+		Data: map[string]string{"reason": reason},
+	})
+
+	h.mu.RLock()
+	clients := make([]*model.Client, 0, len(h.userClients[userID]))
+	for _, client := range h.userClients[userID] {
+		if clientID != "" && client.ID != clientID {
+			continue
+		}
+		h.sendToClient(client, message)
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.Unregister(client)
+	}
+	if len(clients) > 0 {
+		log.Printf("Disconnected %d connection(s) of user %s: %s", len(clients), userID, reason)
+	}
+	return len(clients)
+}
+
+// Draining reports whether the hub is shutting down and refusing new clients
+func (h *Hub) Draining() bool {
+	return h.draining.Load()
+}
+
+// Drain refuses new clients and asks connected ones to reconnect, which
+// sends them to another node once this one reports not ready. Each client is
+// told to wait a different part of spread before reconnecting, so the
+// remaining nodes are not hit all at once. Clients are disconnected shortly
+// after. It returns the number of clients drained.
+func (h *Hub) Drain(ctx context.Context, spread time.Duration) int {
+	h.draining.Store(true)
+
+	h.mu.RLock()
+	clients := mapValues(h.clients)
+	for i, client := range clients {
+		delay := spread * time.Duration(i) / time.Duration(len(clients))
+		message, _ := json.Marshal(model.ServerMessage{
+			Type: model.MsgTypeReconnect,
+			Data: map[string]interface{}{
+				"reason":         "server shutting down",
+				"retry_after_ms": delay.Milliseconds(),
+			},
+		})
+		h.sendToClient(client, message)
+	}
+	h.mu.RUnlock()
+
+	log.Printf("Draining %d client(s)", len(clients))
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(drainFlushDelay):
+	}
+
+	for _, client := range clients {
+		h.Unregister(client)
+	}
+	return len(clients)
+}
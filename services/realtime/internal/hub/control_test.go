@@ -0,0 +1,102 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainMessages reads a client's messages until the hub closes it
+func drainMessages(t *testing.T, client *model.Client) []model.ServerMessage {
+	t.Helper()
+	var messages []model.ServerMessage
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case message, ok := <-client.Send:
+			if !ok {
+				return messages
+			}
+			var msg model.ServerMessage
+			require.NoError(t, json.Unmarshal(message, &msg))
+			messages = append(messages, msg)
+		case <-timeout:
+			t.Fatal("client was not closed")
+		}
+	}
+}
+
+func TestDisconnectUser(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub()
+	changed := make(chan string, 10)
+	h.OnUserChange(func(userID string) { changed <- userID })
+	go h.Run(ctx)
+
+	first := model.NewClient(nil, "user-1", "org-1", "")
+	second := model.NewClient(nil, "user-1", "org-1", "")
+	other := model.NewClient(nil, "user-2", "org-1", "")
+	first.ID, second.ID, other.ID = "client-1", "client-2", "client-3"
+	h.Register(first)
+	h.Register(second)
+	h.Register(other)
+	for _, userID := range []string{"user-1", "user-1", "user-2"} {
+		assert.Equal(t, userID, <-changed)
+	}
+	assert.Equal(t, map[string]int{"user-1": 2, "user-2": 1}, h.UserConnections())
+
+	assert.Equal(t, 1, h.DisconnectUser("user-1", "client-2", "removed"))
+	messages := drainMessages(t, second)
+	require.Len(t, messages, 1)
+	assert.Equal(t, model.MsgTypeDisconnect, messages[0].Type)
+	assert.Equal(t, "user-1", <-changed)
+	assert.Equal(t, 1, h.UserConnectionCount("user-1"))
+
+	assert.Equal(t, 1, h.DisconnectUser("user-1", "", "removed"))
+	drainMessages(t, first)
+	assert.Equal(t, "user-1", <-changed)
+	assert.Equal(t, 0, h.UserConnectionCount("user-1"))
+	assert.Equal(t, 1, h.UserConnectionCount("user-2"))
+	assert.Equal(t, 0, h.DisconnectUser("user-1", "", "removed"))
+}
+
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub()
+	registered := make(chan string, 10)
+	h.OnUserChange(func(userID string) { registered <- userID })
+	go h.Run(ctx)
+
+	clients := []*model.Client{
+		model.NewClient(nil, "user-1", "org-1", ""),
+		model.NewClient(nil, "user-2", "org-1", ""),
+	}
+	for i, client := range clients {
+		client.ID = string(rune('a' + i))
+		h.Register(client)
+		<-registered
+	}
+
+	drainCtx, drainCancel := context.WithCancel(ctx)
+	drainCancel() // don't wait for the flush delay
+	assert.Equal(t, 2, h.Drain(drainCtx, 10*time.Second))
+	assert.True(t, h.Draining())
+	assert.Equal(t, true, h.Stats()["draining"])
+
+	for _, client := range clients {
+		messages := drainMessages(t, client)
+		require.Len(t, messages, 1)
+		assert.Equal(t, model.MsgTypeReconnect, messages[0].Type)
+		data := messages[0].Data.(map[string]interface{})
+		assert.Less(t, data["retry_after_ms"].(float64), float64(10000))
+	}
+}
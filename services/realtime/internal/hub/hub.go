@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/navo/services/realtime/internal/model"
@@ -27,6 +28,9 @@ type Hub struct {
 	// Channel subscriptions: channel -> client IDs
 	channels map[string]map[string]bool
 
+	// Clients viewing or editing entities: channel -> client ID -> presence
+	presence map[string]map[string]*model.Presence
+
 	// Register requests from clients
 	register chan *model.Client
 
@@ -39,6 +43,17 @@ type Hub struct {
 	// Mutex for safe access
 	mu sync.RWMutex
 
+	// Set once the node shuts down; new clients are refused
+	draining atomic.Bool
+
+	// Called after a user's connections changed, see OnUserChange
+	userListener func(userID string)
+
+	// How often clients are checked for, and how long without a ping
+	// makes them, stale
+	cleanupInterval time.Duration
+	staleAfter      time.Duration
+
 	// Metrics
 	totalConnections    int64
 	totalMessages       int64
@@ -54,9 +69,12 @@ func NewHub() *Hub {
 		orgClients:       make(map[string]map[string]*model.Client),
 		workspaceClients: make(map[string]map[string]*model.Client),
 		channels:         make(map[string]map[string]bool),
+		presence:         make(map[string]map[string]*model.Presence),
 		register:         make(chan *model.Client),
 		unregister:       make(chan *model.Client),
 		broadcast:        make(chan *model.Event, 256),
+		cleanupInterval:  60 * time.Second,
+		staleAfter:       2 * time.Minute,
 	}
 }
 
@@ -67,9 +85,13 @@ func (h *Hub) Run(ctx context.Context) {
 	defer heartbeatTicker.Stop()
 
 	// Start cleanup ticker for stale connections
-	cleanupTicker := time.NewTicker(60 * time.Second)
+	cleanupTicker := time.NewTicker(h.cleanupInterval)
 	defer cleanupTicker.Stop()
 
+	// Start sweep ticker for expired presences
+	presenceTicker := time.NewTicker(presenceSweepInterval)
+	defer presenceTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -78,9 +100,11 @@ func (h *Hub) Run(ctx context.Context) {
 
 		case client := <-h.register:
 			h.registerClient(client)
+			h.notifyUser(client.UserID)
 
 		case client := <-h.unregister:
 			h.unregisterClient(client)
+			h.notifyUser(client.UserID)
 
 		case event := <-h.broadcast:
 			h.broadcastEvent(event)
@@ -90,6 +114,9 @@ func (h *Hub) Run(ctx context.Context) {
 
 		case <-cleanupTicker.C:
 			h.cleanupStaleConnections()
+
+		case <-presenceTicker.C:
+			h.expirePresence()
 		}
 	}
 }
@@ -154,6 +181,9 @@ func (h *Hub) unregisterClient(client *model.Client) {
 
 	client.SetState(model.ClientStateDisconnected)
 
+	// Tell other viewers the client left
+	h.removeClientPresence(client)
+
 	// Remove from all channel subscriptions
 	for channel := range client.Subscriptions {
 		if h.channels[channel] != nil {
@@ -209,10 +239,32 @@ func (h *Hub) broadcastEvent(event *model.Event) {
 	}
 
 	for _, client := range targets {
+		if client.Hold(event.ID, message) {
+			continue
+		}
 		h.sendToClient(client, message)
 	}
 }
 
+// InScope reports whether an event is routed to a client by its user,
+// organization or workspace. Channel subscriptions are not considered, since
+// they do not outlive a connection.
+func InScope(client *model.Client, event *model.Event) bool {
+	if len(event.UserIDs) > 0 {
+		for _, userID := range event.UserIDs {
+			if userID == client.UserID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if event.WorkspaceID != "" {
+		return client.WorkspaceID == event.WorkspaceID
+	}
+	return event.OrganizationID != "" && client.OrganizationID == event.OrganizationID
+}
+
 // findTargetClients determines which clients should receive an event
 func (h *Hub) findTargetClients(event *model.Event) []*model.Client {
 	clientSet := make(map[string]*model.Client)
@@ -289,6 +341,33 @@ func (h *Hub) sendToClient(client *model.Client, message []byte) {
 	}
 }
 
+// SendDirect queues a message for a single client, waiting up to timeout for
+// room in its send buffer. It returns false if the client disconnected or did
+// not keep up.
+func (h *Hub) SendDirect(client *model.Client, message []byte, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		// The send channel is only closed under the write lock
+		h.mu.RLock()
+		if client.GetState() == model.ClientStateDisconnected {
+			h.mu.RUnlock()
+			return false
+		}
+		select {
+		case client.Send <- message:
+			h.mu.RUnlock()
+			return true
+		default:
+		}
+		h.mu.RUnlock()
+
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // Subscribe adds a client subscription to a channel
 func (h *Hub) Subscribe(client *model.Client, channel string) {
 	h.mu.Lock()
@@ -340,18 +419,21 @@ func (h *Hub) sendHeartbeats() {
 func (h *Hub) cleanupStaleConnections() {
 	h.mu.RLock()
 	staleClients := make([]*model.Client, 0)
-	threshold := time.Now().Add(-2 * time.Minute)
+	threshold := time.Now().Add(-h.staleAfter)
 
 	for _, client := range h.clients {
-		if client.LastPing.Before(threshold) {
+		if client.GetLastPing().Before(threshold) {
 			staleClients = append(staleClients, client)
 		}
 	}
 	h.mu.RUnlock()
 
+	// Cleanup runs on the Run goroutine, the only reader of h.unregister, so
+	// clients are unregistered directly rather than through Unregister
 	for _, client := range staleClients {
 		log.Printf("Removing stale client: %s", client.ID)
-		h.Unregister(client)
+		h.unregisterClient(client)
+		h.notifyUser(client.UserID)
 	}
 }
 
@@ -361,9 +443,11 @@ func (h *Hub) shutdown() {
 
 	log.Println("Shutting down hub...")
 	for _, client := range h.clients {
+		client.SetState(model.ClientStateDisconnected)
 		close(client.Send)
 	}
 	h.clients = make(map[string]*model.Client)
+	h.presence = make(map[string]map[string]*model.Presence)
 }
 
 // Stats returns current hub statistics
@@ -378,6 +462,8 @@ func (h *Hub) Stats() map[string]interface{} {
 		"total_broadcasts":     h.totalBroadcasts,
 		"active_subscriptions": h.activeSubscriptions,
 		"channels":             len(h.channels),
+		"presence_channels":    len(h.presence),
+		"draining":             h.draining.Load(),
 	}
 }
 
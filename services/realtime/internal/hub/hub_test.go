@@ -0,0 +1,69 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupStaleConnectionsWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub()
+	h.cleanupInterval = 10 * time.Millisecond
+	h.staleAfter = time.Minute
+	changed := make(chan string, 10)
+	h.OnUserChange(func(userID string) { changed <- userID })
+	go h.Run(ctx)
+
+	stale := model.NewClient(nil, "user-1", "org-1", "ws-1")
+	fresh := model.NewClient(nil, "user-2", "org-1", "ws-1")
+	stale.ID, fresh.ID = "client-1", "client-2"
+	stale.LastPing = time.Now().Add(-2 * time.Minute)
+	h.Register(stale)
+	h.Register(fresh)
+
+	require.Eventually(t, func() bool {
+		_, ok := h.GetClient(stale.ID)
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond, "stale client was not removed")
+	assert.Empty(t, drainMessages(t, stale))
+
+	// The hub keeps serving after the cleanup
+	later := model.NewClient(nil, "user-3", "org-1", "ws-1")
+	later.ID = "client-3"
+	registered := make(chan struct{})
+	go func() {
+		h.Register(later)
+		h.Unregister(fresh)
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hub stopped serving after removing a stale client")
+	}
+
+	_, ok := h.GetClient(later.ID)
+	assert.True(t, ok)
+	assert.Contains(t, collectUsers(changed, 4), "user-1")
+}
+
+// collectUsers reads n user change notifications
+func collectUsers(changed <-chan string, n int) []string {
+	var users []string
+	for i := 0; i < n; i++ {
+		select {
+		case userID := <-changed:
+			users = append(users, userID)
+		case <-time.After(time.Second):
+			return users
+		}
+	}
+	return users
+}
@@ -0,0 +1,175 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+)
+
+// presenceSweepInterval is how often expired presences are removed
+const presenceSweepInterval = 30 * time.Second
+
+// presenceUpdate is the data of a presence message
+type presenceUpdate struct {
+	Action   string          `json:"action"`
+	Presence *model.Presence `json:"presence"`
+}
+
+// JoinPresence marks a client as viewing or editing the entity of a channel
+// and tells the channel's other clients. Joining again acts as a heartbeat.
+// It returns the other clients present.
+func (h *Hub) JoinPresence(client *model.Client, channel string, mode model.PresenceMode) []model.Presence {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now().UTC()
+	if entry, ok := h.presence[channel][client.ID]; ok {
+		entry.Mode = mode
+		entry.LastSeen = now
+		h.sendPresence(channel, model.PresenceActionHeartbeat, entry)
+		return h.presentExcept(channel, client.ID)
+	}
+
+	entry := &model.Presence{
+		Channel:  channel,
+		ClientID: client.ID,
+		UserID:   client.UserID,
+		Mode:     mode,
+		JoinedAt: now,
+		LastSeen: now,
+	}
+	if client.Claims != nil {
+		entry.Email = client.Claims.Email
+	}
+
+	if h.presence[channel] == nil {
+		h.presence[channel] = make(map[string]*model.Presence)
+	}
+	h.presence[channel][client.ID] = entry
+	h.sendPresence(channel, model.PresenceActionJoined, entry)
+
+	log.Printf("Client %s (user: %s) %s %s", client.ID, client.UserID, mode, channel)
+	return h.presentExcept(channel, client.ID)
+}
+
+// HeartbeatPresence keeps a client's presence alive, optionally switching
+// its mode. It returns false when the client is not present on the channel.
+func (h *Hub) HeartbeatPresence(client *model.Client, channel string, mode model.PresenceMode) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.presence[channel][client.ID]
+	if !ok {
+		return false
+	}
+	if mode != "" {
+		entry.Mode = mode
+	}
+	entry.LastSeen = time.Now().UTC()
+	h.sendPresence(channel, model.PresenceActionHeartbeat, entry)
+	return true
+}
+
+// LeavePresence removes a client's presence from a channel
+func (h *Hub) LeavePresence(client *model.Client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if entry, ok := h.presence[channel][client.ID]; ok {
+		h.removePresence(entry)
+	}
+}
+
+// GetPresence returns the clients present on a channel, earliest first
+func (h *Hub) GetPresence(channel string) []model.Presence {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.presentExcept(channel, "")
+}
+
+// removeClientPresence removes all presences of a disconnecting client.
+// Must be called with the write lock held.
+func (h *Hub) removeClientPresence(client *model.Client) {
+	for _, viewers := range h.presence {
+		if entry, ok := viewers[client.ID]; ok {
+			h.removePresence(entry)
+		}
+	}
+}
+
+// expirePresence removes presences that have not seen a heartbeat within
+// model.PresenceTimeout, such as those of clients that lost their connection
+// without closing it
+func (h *Hub) expirePresence() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	threshold := time.Now().UTC().Add(-model.PresenceTimeout)
+	for _, viewers := range h.presence {
+		for _, entry := range viewers {
+			if entry.LastSeen.Before(threshold) {
+				log.Printf("Presence of client %s on %s expired", entry.ClientID, entry.Channel)
+				h.removePresence(entry)
+			}
+		}
+	}
+}
+
+// removePresence deletes a presence and tells the channel's other clients.
+// Must be called with the write lock held.
+func (h *Hub) removePresence(entry *model.Presence) {
+	viewers := h.presence[entry.Channel]
+	delete(viewers, entry.ClientID)
+	if len(viewers) == 0 {
+		delete(h.presence, entry.Channel)
+	}
+	h.sendPresence(entry.Channel, model.PresenceActionLeft, entry)
+}
+
+// sendPresence sends a presence change to the clients subscribed to or
+// present on the channel, other than the one it is about. Must be called with
+// the lock held.
+func (h *Hub) sendPresence(channel, action string, entry *model.Presence) {
+	message, err := json.Marshal(model.ServerMessage{
+		Type:    model.MsgTypePresence,
+		Channel: channel,
+		Data:    presenceUpdate{Action: action, Presence: entry},
+	})
+	if err != nil {
+		log.Printf("Error marshaling presence: %v", err)
+		return
+	}
+
+	recipients := make(map[string]bool)
+	for clientID := range h.channels[channel] {
+		recipients[clientID] = true
+	}
+	for clientID := range h.presence[channel] {
+		recipients[clientID] = true
+	}
+	delete(recipients, entry.ClientID)
+
+	for clientID := range recipients {
+		if client, ok := h.clients[clientID]; ok {
+			h.sendToClient(client, message)
+		}
+	}
+}
+
+// presentExcept returns copies of a channel's presences, earliest first,
+// leaving out one client. Must be called with the lock held.
+func (h *Hub) presentExcept(channel, clientID string) []model.Presence {
+	present := make([]model.Presence, 0, len(h.presence[channel]))
+	for id, entry := range h.presence[channel] {
+		if id != clientID {
+			present = append(present, *entry)
+		}
+	}
+	sort.Slice(present, func(i, j int) bool {
+		return present[i].JoinedAt.Before(present[j].JoinedAt)
+	})
+	return present
+}
@@ -0,0 +1,105 @@
+package hub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextPresence reads the next queued presence message for a client
+func nextPresence(t *testing.T, client *model.Client) (action string, presence model.Presence) {
+	t.Helper()
+	select {
+	case message := <-client.Send:
+		var msg struct {
+			Type string `json:"type"`
+			Data struct {
+				Action   string         `json:"action"`
+				Presence model.Presence `json:"presence"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(message, &msg))
+		require.Equal(t, model.MsgTypePresence, msg.Type)
+		return msg.Data.Action, msg.Data.Presence
+	default:
+		t.Fatal("no presence message queued")
+		return "", model.Presence{}
+	}
+}
+
+func TestPresenceJoinHeartbeatLeave(t *testing.T) {
+	h := NewHub()
+	alice := model.NewClient(nil, "user-1", "org-1", "ws-1")
+	bob := model.NewClient(nil, "user-2", "org-1", "ws-1")
+	alice.ID, bob.ID = "client-1", "client-2"
+	h.registerClient(alice)
+	h.registerClient(bob)
+
+	channel := "port_call:pc-1"
+	viewers := h.JoinPresence(alice, channel, model.PresenceViewing)
+	assert.Empty(t, viewers)
+	assert.Empty(t, bob.Send, "bob is not on the channel yet")
+
+	viewers = h.JoinPresence(bob, channel, model.PresenceEditing)
+	require.Len(t, viewers, 1)
+	assert.Equal(t, "user-1", viewers[0].UserID)
+
+	action, presence := nextPresence(t, alice)
+	assert.Equal(t, model.PresenceActionJoined, action)
+	assert.Equal(t, "user-2", presence.UserID)
+	assert.Equal(t, model.PresenceEditing, presence.Mode)
+	assert.Empty(t, bob.Send, "clients are not told about themselves")
+
+	assert.True(t, h.HeartbeatPresence(bob, channel, model.PresenceViewing))
+	action, presence = nextPresence(t, alice)
+	assert.Equal(t, model.PresenceActionHeartbeat, action)
+	assert.Equal(t, model.PresenceViewing, presence.Mode)
+	assert.False(t, h.HeartbeatPresence(bob, "port_call:pc-2", model.PresenceViewing))
+
+	h.LeavePresence(bob, channel)
+	action, presence = nextPresence(t, alice)
+	assert.Equal(t, model.PresenceActionLeft, action)
+	assert.Equal(t, bob.ID, presence.ClientID)
+	present := h.GetPresence(channel)
+	require.Len(t, present, 1)
+	assert.Equal(t, alice.ID, present[0].ClientID)
+}
+
+func TestPresenceRemovedOnDisconnectAndExpiry(t *testing.T) {
+	h := NewHub()
+	alice := model.NewClient(nil, "user-1", "org-1", "ws-1")
+	bob := model.NewClient(nil, "user-2", "org-1", "ws-1")
+	carol := model.NewClient(nil, "user-3", "org-1", "ws-1")
+	alice.ID, bob.ID, carol.ID = "client-1", "client-2", "client-3"
+	h.registerClient(alice)
+	h.registerClient(bob)
+	h.registerClient(carol)
+
+	channel := "rfq:rfq-1"
+	h.JoinPresence(alice, channel, model.PresenceViewing)
+	h.JoinPresence(bob, channel, model.PresenceViewing)
+	h.JoinPresence(carol, channel, model.PresenceViewing)
+	for len(alice.Send) > 0 {
+		<-alice.Send
+	}
+
+	h.unregisterClient(bob)
+	action, presence := nextPresence(t, alice)
+	assert.Equal(t, model.PresenceActionLeft, action)
+	assert.Equal(t, "user-2", presence.UserID)
+
+	// Carol stopped sending heartbeats
+	h.presence[channel][carol.ID].LastSeen = time.Now().UTC().Add(-2 * model.PresenceTimeout)
+	h.expirePresence()
+	action, presence = nextPresence(t, alice)
+	assert.Equal(t, model.PresenceActionLeft, action)
+	assert.Equal(t, "user-3", presence.UserID)
+
+	present := h.GetPresence(channel)
+	require.Len(t, present, 1)
+	assert.Equal(t, alice.ID, present[0].ClientID)
+}
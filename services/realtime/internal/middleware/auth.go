@@ -15,27 +15,26 @@ const (
 	OrganizationIDKey contextKey = "organization_id"
 	WorkspaceIDKey    contextKey = "workspace_id"
 	RoleKey           contextKey = "role"
+	ClaimsKey         contextKey = "claims"
 )
 
 // AuthMiddleware handles JWT authentication for HTTP requests
 type AuthMiddleware struct {
-	jwtService *auth.JWTService
-	optional   bool
+	optional bool
 }
 
-// NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+// NewAuthMiddleware creates a new authentication middleware.
+// auth.Initialize must have been called.
+func NewAuthMiddleware() *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
-		optional:   false,
+		optional: false,
 	}
 }
 
 // NewOptionalAuthMiddleware creates auth middleware that doesn't require authentication
-func NewOptionalAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+func NewOptionalAuthMiddleware() *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
-		optional:   true,
+		optional: true,
 	}
 }
 
@@ -57,7 +56,7 @@ func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
 		}
 
 		// Validate token
-		claims, err := m.jwtService.ValidateToken(token)
+		claims, err := auth.ValidateToken(token)
 		if err != nil {
 			if m.optional {
 				next.ServeHTTP(w, r)
@@ -70,13 +69,36 @@ func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
 		// Add claims to context
 		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, OrganizationIDKey, claims.OrganizationID)
-		ctx = context.WithValue(ctx, WorkspaceIDKey, claims.WorkspaceID)
-		ctx = context.WithValue(ctx, RoleKey, claims.Role)
+		ctx = context.WithValue(ctx, WorkspaceIDKey, activeWorkspace(r, claims))
+		if len(claims.Roles) > 0 {
+			ctx = context.WithValue(ctx, RoleKey, claims.Roles[0])
+		}
+		ctx = context.WithValue(ctx, ClaimsKey, claims)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// activeWorkspace returns the workspace the client selected, provided the
+// token grants access to it. Without a selection it falls back to the only
+// workspace in the token, if there is exactly one.
+func activeWorkspace(r *http.Request, claims *auth.Claims) string {
+	workspaceID := r.Header.Get("X-Workspace-ID")
+	if workspaceID == "" {
+		workspaceID = r.URL.Query().Get("workspace_id")
+	}
+	if workspaceID != "" {
+		if auth.HasWorkspaceAccess(claims, workspaceID) {
+			return workspaceID
+		}
+		return ""
+	}
+	if len(claims.WorkspaceIDs) == 1 {
+		return claims.WorkspaceIDs[0]
+	}
+	return ""
+}
+
 // extractToken extracts the JWT token from the request
 func extractToken(r *http.Request) string {
 	// Check Authorization header first
@@ -131,6 +153,14 @@ func GetWorkspaceID(ctx context.Context) string {
 	return ""
 }
 
+// GetClaims retrieves the validated token claims from context
+func GetClaims(ctx context.Context) *auth.Claims {
+	if claims, ok := ctx.Value(ClaimsKey).(*auth.Claims); ok {
+		return claims
+	}
+	return nil
+}
+
 // GetRole retrieves the role from context
 func GetRole(ctx context.Context) string {
 	if val := ctx.Value(RoleKey); val != nil {
@@ -159,7 +189,7 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 			if allowed {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-Workspace-ID")
+				w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, Last-Event-ID, X-Workspace-ID")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 				w.Header().Set("Access-Control-Max-Age", "86400")
 			}
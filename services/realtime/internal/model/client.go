@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/navo/pkg/auth"
 )
 
 // ClientState represents the connection state of a client
@@ -23,6 +24,7 @@ type Client struct {
 	UserID         string
 	OrganizationID string
 	WorkspaceID    string
+	Claims         *auth.Claims // nil when authentication is disabled
 	Conn           *websocket.Conn
 	Send           chan []byte
 	State          ClientState
@@ -30,6 +32,21 @@ type Client struct {
 	LastPing       time.Time
 	Subscriptions  map[string]bool // Set of subscribed channels/topics
 	mu             sync.RWMutex
+
+	// Live events held back while missed events are replayed
+	replaying    bool
+	held         []HeldMessage
+	heldOverflow bool
+}
+
+// MaxHeldMessages is the number of live events held back during a replay
+// before the client is asked to resync instead
+const MaxHeldMessages = 1000
+
+// HeldMessage is a live event message held back during a replay
+type HeldMessage struct {
+	EventID string
+	Message []byte
 }
 
 // NewClient creates a new WebSocket client
@@ -88,6 +105,13 @@ func (c *Client) UpdatePing() {
 	c.LastPing = time.Now().UTC()
 }
 
+// GetLastPing returns when the client last pinged
+func (c *Client) GetLastPing() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.LastPing
+}
+
 // SetState updates the client state
 func (c *Client) SetState(state ClientState) {
 	c.mu.Lock()
@@ -102,6 +126,57 @@ func (c *Client) GetState() ClientState {
 	return c.State
 }
 
+// BeginReplay holds back live events until the replay has been delivered
+func (c *Client) BeginReplay() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replaying = true
+	c.held = nil
+	c.heldOverflow = false
+}
+
+// Hold keeps a live event message back if a replay is in progress. It returns
+// false when the message should be delivered directly.
+func (c *Client) Hold(eventID string, message []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.replaying {
+		return false
+	}
+	if len(c.held) >= MaxHeldMessages {
+		c.heldOverflow = true
+		return true
+	}
+	c.held = append(c.held, HeldMessage{EventID: eventID, Message: message})
+	return true
+}
+
+// TakeHeld returns the messages held back so far. Once nothing is left, or
+// messages had to be dropped (overflowed), the replay ends and later events
+// are delivered directly.
+func (c *Client) TakeHeld() (held []HeldMessage, overflowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held, overflowed = c.held, c.heldOverflow
+	c.held = nil
+	if len(held) == 0 || overflowed {
+		c.replaying = false
+		c.heldOverflow = false
+	}
+	return held, overflowed
+}
+
+// EndReplay stops holding back live events and discards those held so far
+func (c *Client) EndReplay() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replaying = false
+	c.held = nil
+	c.heldOverflow = false
+}
+
 // Helper to generate unique client IDs
 func generateClientID() string {
 	return time.Now().Format("20060102150405") + "-" + randomString(8)
@@ -129,6 +204,11 @@ const (
 	MsgTypeUnsubscribe = "unsubscribe"
 	MsgTypePing        = "ping"
 	MsgTypePong        = "pong"
+
+	// Presence on an entity channel; data carries the mode
+	MsgTypePresenceJoin      = "presence_join"
+	MsgTypePresenceHeartbeat = "presence_heartbeat"
+	MsgTypePresenceLeave     = "presence_leave"
 )
 
 // ServerMessage represents a message to a client
@@ -147,4 +227,19 @@ const (
 	MsgTypeUnsubscribed = "unsubscribed"
 	MsgTypeError        = "error"
 	MsgTypeHeartbeat    = "heartbeat"
+
+	// Replay of missed events after a reconnect
+	MsgTypeReplayComplete = "replay_complete"
+	MsgTypeResyncRequired = "resync_required"
+
+	// Presence of other clients on an entity channel, and the viewers
+	// present when joining
+	MsgTypePresence      = "presence"
+	MsgTypePresenceState = "presence_state"
+
+	// Sent before the server closes a connection: disconnect when an
+	// administrator removed it, reconnect when the node is shutting down and
+	// the client should connect again after retry_after_ms
+	MsgTypeDisconnect = "disconnect"
+	MsgTypeReconnect  = "reconnect"
 )
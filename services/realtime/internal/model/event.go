@@ -38,11 +38,31 @@ const (
 	EventNotificationNew  EventType = "notification:new"
 	EventNotificationRead EventType = "notification:read"
 
+	// Weather events
+	EventWeatherAlert EventType = "weather:alert"
+
 	// System events
 	EventSystemHeartbeat EventType = "system:heartbeat"
 	EventSystemError     EventType = "system:error"
 )
 
+var eventTypes = map[EventType]bool{
+	EventPortCallCreated: true, EventPortCallUpdated: true, EventPortCallStatusChanged: true, EventPortCallDeleted: true,
+	EventVesselPositionUpdated: true, EventVesselCreated: true, EventVesselUpdated: true,
+	EventServiceCreated: true, EventServiceUpdated: true, EventServiceStatusChanged: true,
+	EventRFQCreated: true, EventRFQUpdated: true, EventRFQPublished: true, EventRFQClosed: true,
+	EventRFQAwarded: true, EventQuoteReceived: true, EventQuoteWithdrawn: true,
+	EventNotificationNew: true, EventNotificationRead: true,
+	EventWeatherAlert: true,
+	EventSystemHeartbeat: true, EventSystemError: true,
+}
+
+// IsEventType reports whether a channel name is a known event type, as
+// opposed to an entity channel such as "port_call:<id>"
+func IsEventType(channel string) bool {
+	return eventTypes[EventType(channel)]
+}
+
 // Event represents a real-time event
 type Event struct {
 	ID        string          `json:"id"`
@@ -56,6 +76,10 @@ type Event struct {
 	UserIDs        []string `json:"user_ids,omitempty"` // Specific users to target
 	EntityID       string   `json:"entity_id,omitempty"`
 	EntityType     string   `json:"entity_type,omitempty"`
+
+	// W3C trace context of the publisher, continued by consumers
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
 }
 
 // NewEvent creates a new event with the given type and data
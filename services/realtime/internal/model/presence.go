@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// PresenceMode describes what a user is doing with an entity
+type PresenceMode string
+
+const (
+	PresenceViewing PresenceMode = "viewing"
+	PresenceEditing PresenceMode = "editing"
+)
+
+// IsValid reports whether the mode is known
+func (m PresenceMode) IsValid() bool {
+	return m == PresenceViewing || m == PresenceEditing
+}
+
+// PresenceTimeout is how long a presence lasts without a heartbeat
+const PresenceTimeout = 90 * time.Second
+
+// Presence is a client viewing or editing an entity, identified by its
+// entity channel (for example "port_call:<id>"). A user with several tabs
+// open has one presence per client.
+type Presence struct {
+	Channel  string       `json:"channel"`
+	ClientID string       `json:"client_id"`
+	UserID   string       `json:"user_id"`
+	Email    string       `json:"email,omitempty"`
+	Mode     PresenceMode `json:"mode"`
+	JoinedAt time.Time    `json:"joined_at"`
+	LastSeen time.Time    `json:"last_seen"`
+}
+
+// Presence actions sent by clients and broadcast to other viewers
+const (
+	PresenceActionJoined    = "joined"
+	PresenceActionLeft      = "left"
+	PresenceActionHeartbeat = "heartbeat"
+)
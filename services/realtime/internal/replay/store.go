@@ -0,0 +1,104 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/realtime"
+	"github.com/navo/services/realtime/internal/model"
+)
+
+var (
+	// ErrGapTooLarge is returned when events after the requested ID are no
+	// longer retained, or too many were missed to replay. The client has to
+	// reload its state instead.
+	ErrGapTooLarge = errors.New("gap too large, please resync")
+
+	// ErrInvalidEventID is returned when last_event_id is not a stream ID
+	ErrInvalidEventID = errors.New("invalid last_event_id")
+)
+
+// Store reads missed events from the per-organization Redis Streams written by
+// realtime.AppendAndPublish. Workspace and user targeted events are kept in
+// their organization's stream and filtered per client.
+type Store struct {
+	redis     *redis.Client
+	maxEvents int64
+}
+
+// NewStore creates a replay store that replays at most maxEvents events
+func NewStore(redisClient *redis.Client, maxEvents int64) *Store {
+	return &Store{
+		redis:     redisClient,
+		maxEvents: maxEvents,
+	}
+}
+
+// Since returns the organization's events published after lastEventID,
+// oldest first
+func (s *Store) Since(ctx context.Context, orgID, lastEventID string) ([]*model.Event, error) {
+	if _, _, err := realtime.ParseStreamID(lastEventID); err != nil {
+		return nil, ErrInvalidEventID
+	}
+	key := realtime.StreamKey(orgID)
+
+	oldest, err := s.redis.XRangeN(ctx, key, "-", "+", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+	if len(oldest) == 0 {
+		return nil, nil
+	}
+	if err := checkWindow(lastEventID, oldest[0].ID); err != nil {
+		return nil, err
+	}
+
+	// Read one extra entry to detect gaps larger than the replay limit
+	messages, err := s.redis.XRangeN(ctx, key, "("+lastEventID, "+", s.maxEvents+1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+	if int64(len(messages)) > s.maxEvents {
+		return nil, ErrGapTooLarge
+	}
+
+	events := make([]*model.Event, 0, len(messages))
+	for _, msg := range messages {
+		event, err := decodeEvent(msg)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// checkWindow fails when entries after lastEventID may have been trimmed from
+// the stream, that is when the oldest retained entry is newer
+func checkWindow(lastEventID, oldestID string) error {
+	cmp, err := realtime.CompareStreamIDs(lastEventID, oldestID)
+	if err != nil {
+		return ErrInvalidEventID
+	}
+	if cmp < 0 {
+		return ErrGapTooLarge
+	}
+	return nil
+}
+
+func decodeEvent(msg redis.XMessage) (*model.Event, error) {
+	payload, ok := msg.Values["event"].(string)
+	if !ok {
+		return nil, fmt.Errorf("stream entry %s has no event", msg.ID)
+	}
+
+	var event model.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return nil, fmt.Errorf("failed to decode stream entry %s: %w", msg.ID, err)
+	}
+	event.ID = msg.ID
+	return &event, nil
+}
@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/navo/services/realtime/internal/hub"
+	"github.com/navo/services/realtime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventID string
+		oldestID    string
+		want        error
+	}{
+		{"last event still retained", "1700000000000-3", "1700000000000-1", nil},
+		{"last event is oldest retained", "1700000000000-1", "1700000000000-1", nil},
+		{"sequence trimmed", "1700000000000-0", "1700000000000-1", ErrGapTooLarge},
+		{"older millisecond trimmed", "1699999999999-9", "1700000000000-0", ErrGapTooLarge},
+		{"malformed id", "abc", "1700000000000-0", ErrInvalidEventID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWindow(tt.lastEventID, tt.oldestID)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	event, err := decodeEvent(redis.XMessage{
+		ID:     "1700000000000-2",
+		Values: map[string]interface{}{"event": `{"id":"publisher-id","type":"port_call:updated","organization_id":"org-1","workspace_id":"ws-1","data":{}}`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000000-2", event.ID)
+	assert.Equal(t, model.EventPortCallUpdated, event.Type)
+	assert.Equal(t, "ws-1", event.WorkspaceID)
+
+	_, err = decodeEvent(redis.XMessage{ID: "1700000000000-3", Values: map[string]interface{}{}})
+	assert.Error(t, err)
+}
+
+func TestInScope(t *testing.T) {
+	client := model.NewClient(nil, "user-1", "org-1", "ws-1")
+
+	assert.True(t, hub.InScope(client, &model.Event{OrganizationID: "org-1"}))
+	assert.True(t, hub.InScope(client, &model.Event{OrganizationID: "org-1", WorkspaceID: "ws-1"}))
+	assert.False(t, hub.InScope(client, &model.Event{OrganizationID: "org-1", WorkspaceID: "ws-2"}))
+	assert.True(t, hub.InScope(client, &model.Event{OrganizationID: "org-1", UserIDs: []string{"user-2", "user-1"}}))
+	assert.False(t, hub.InScope(client, &model.Event{OrganizationID: "org-1", UserIDs: []string{"user-2"}}))
+	assert.False(t, hub.InScope(client, &model.Event{OrganizationID: "org-2"}))
+}
+
+func TestClientHoldsLiveEventsDuringReplay(t *testing.T) {
+	client := model.NewClient(nil, "user-1", "org-1", "ws-1")
+	assert.False(t, client.Hold("1-0", []byte("a")), "nothing is held outside a replay")
+
+	client.BeginReplay()
+	assert.True(t, client.Hold("1-1", []byte("b")))
+	assert.True(t, client.Hold("1-2", []byte("c")))
+
+	held, overflowed := client.TakeHeld()
+	assert.False(t, overflowed)
+	require.Len(t, held, 2)
+	assert.Equal(t, "1-1", held[0].EventID)
+	assert.Equal(t, "1-2", held[1].EventID)
+
+	// Still replaying until an empty take
+	assert.True(t, client.Hold("1-3", []byte("d")))
+	held, _ = client.TakeHeld()
+	require.Len(t, held, 1)
+	held, _ = client.TakeHeld()
+	assert.Empty(t, held)
+	assert.False(t, client.Hold("1-4", []byte("e")))
+
+	// Overflow ends the replay
+	client.BeginReplay()
+	for i := 0; i <= model.MaxHeldMessages; i++ {
+		client.Hold("2-0", []byte("f"))
+	}
+	_, overflowed = client.TakeHeld()
+	assert.True(t, overflowed)
+	assert.False(t, client.Hold("2-1", []byte("g")))
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/realtime"
 	"github.com/navo/services/realtime/internal/hub"
 	"github.com/navo/services/realtime/internal/model"
 )
@@ -99,11 +101,26 @@ func (m *Manager) handleMessage(msg *redis.Message) {
 		return
 	}
 
+	// Organization events arrive wrapped with their stream entry ID
+	payload := []byte(msg.Payload)
+	var envelope realtime.StreamMessage
+	if err := json.Unmarshal(payload, &envelope); err == nil && envelope.StreamID != "" {
+		payload = envelope.Event
+	}
+
 	var event model.Event
-	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+	if err := json.Unmarshal(payload, &event); err != nil {
 		log.Printf("Error unmarshaling event from channel %s: %v", msg.Channel, err)
 		return
 	}
+	if envelope.StreamID != "" {
+		event.ID = envelope.StreamID
+	}
+
+	// Continue the publisher's trace while fanning out
+	_, span := observability.StartConsumerSpan(m.ctx, "broadcast "+string(event.Type), msg.Channel,
+		event.TraceParent, event.TraceState)
+	defer span.End()
 
 	// Broadcast to connected clients via the hub
 	m.hub.Broadcast(&event)
@@ -111,6 +128,9 @@ func (m *Manager) handleMessage(msg *redis.Message) {
 
 // Publish sends an event to Redis for distribution
 func (m *Manager) Publish(ctx context.Context, event *model.Event) error {
+	if event.TraceParent == "" {
+		event.TraceParent, event.TraceState = observability.TraceFields(ctx)
+	}
 	data, err := json.Marshal(event)
 	if err != nil {
 		return err
@@ -119,11 +139,20 @@ func (m *Manager) Publish(ctx context.Context, event *model.Event) error {
 	// Determine the appropriate channel based on event type
 	channel := m.getChannelForEvent(event.Type)
 
+	// Organization events are kept for replay to reconnecting clients
+	if event.OrganizationID != "" {
+		_, err = realtime.AppendAndPublish(ctx, m.redis, event.OrganizationID, channel, data, realtime.DefaultStreamMaxLen)
+		return err
+	}
+
 	return m.redis.Publish(ctx, channel, data).Err()
 }
 
 // PublishToChannel publishes an event to a specific channel
 func (m *Manager) PublishToChannel(ctx context.Context, channel string, event *model.Event) error {
+	if event.TraceParent == "" {
+		event.TraceParent, event.TraceState = observability.TraceFields(ctx)
+	}
 	data, err := json.Marshal(event)
 	if err != nil {
 		return err
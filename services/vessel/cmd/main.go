@@ -12,7 +12,10 @@ import (
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/navo/pkg/auth"
 	"github.com/navo/pkg/database"
+	"github.com/navo/pkg/idempotency"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/pkg/redis"
 	"github.com/navo/services/vessel/internal/config"
 	"github.com/navo/services/vessel/internal/handler"
@@ -42,6 +45,13 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(ctx, observability.DefaultConfig("vessel"))
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
 	// Connect to PostgreSQL
 	db, err := database.Connect(ctx, database.DefaultConfig())
 	if err != nil {
@@ -76,11 +86,21 @@ func main() {
 		go trackingSvc.StartPositionPolling(ctx, cfg.PollingInterval)
 	}
 
+	// OpenAPI document of the routes below, which requests are validated
+	// against
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Vessel Service",
+		Description: "Vessels and their AIS positions",
+	}, handler.Operations)
+
 	// Setup router
 	r := chi.NewRouter()
 	r.Use(chimiddleware.RequestID)
+	r.Use(observability.HTTPMiddleware) // Continues the gateway's trace
 	r.Use(chimiddleware.Recoverer)
 	r.Use(middleware.ExtractUserContext)
+	r.Use(api.Validate)
+	r.Use(idempotency.Middleware(idempotency.NewRedisStore(redisClient), idempotency.DefaultConfig("vessel")))
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -90,29 +110,15 @@ func main() {
 	})
 
 	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Vessels
-		r.Route("/vessels", func(r chi.Router) {
-			r.Get("/", vesselHandler.List)
-			r.Post("/", vesselHandler.Create)
-			r.Get("/{id}", vesselHandler.GetByID)
-			r.Put("/{id}", vesselHandler.Update)
-			r.Delete("/{id}", vesselHandler.Delete)
-			r.Get("/imo/{imo}", vesselHandler.GetByIMO)
-			r.Get("/{id}/position", positionHandler.GetLatest)
-			r.Get("/{id}/track", positionHandler.GetTrack)
-			r.Get("/{id}/positions", positionHandler.GetHistory)
-			r.Post("/{id}/position", positionHandler.RecordPosition)
-		})
-
-		// Fleet operations
-		r.Route("/fleet", func(r chi.Router) {
-			r.Get("/positions", positionHandler.GetFleetPositions)
-			r.Get("/bounds", positionHandler.GetPositionsInBounds)
-			r.Post("/refresh", positionHandler.RefreshPositions)
-		})
+	handler.Routes(r, handler.Handlers{
+		Vessels:   vesselHandler,
+		Positions: positionHandler,
 	})
 
+	// OpenAPI document, merged into the gateway's /api/docs
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/navo/pkg/openapi"
+	"github.com/navo/services/vessel/internal/model"
+)
+
+// Operations describe what the vessel service's handlers read and write,
+// for its OpenAPI document and request validation
+var Operations = openapi.Operations{
+	// Vessels
+	"GET /api/v1/vessels": {
+		Summary: "List the workspace's vessels",
+		Query: openapi.Paged(
+			openapi.Param{Name: "type", Enum: []string{"bulk_carrier", "tanker", "container", "general_cargo", "ro_ro", "cruise", "tug", "offshore", "other"}},
+			openapi.Param{Name: "status", Enum: []string{"active", "inactive", "archived"}},
+			openapi.Param{Name: "search"},
+		),
+		Response: model.VesselListResult{},
+	},
+	"POST /api/v1/vessels": {
+		Summary:  "Add a vessel to the workspace",
+		Request:  model.CreateVesselInput{},
+		Omit:     []string{"workspace_id"},
+		Response: model.Vessel{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/vessels/{id}": {
+		Summary:  "Get a vessel",
+		Response: model.Vessel{},
+	},
+	"PUT /api/v1/vessels/{id}": {
+		Summary:  "Update a vessel",
+		Request:  model.UpdateVesselInput{},
+		Response: model.Vessel{},
+	},
+	"DELETE /api/v1/vessels/{id}": {
+		Summary: "Delete a vessel",
+		Status:  http.StatusNoContent,
+	},
+	"GET /api/v1/vessels/imo/{imo}": {
+		Summary:  "Get a vessel by IMO number",
+		Response: model.Vessel{},
+	},
+
+	// Positions
+	"GET /api/v1/vessels/{id}/position": {
+		Summary:  "Get a vessel's latest position",
+		Tags:     []string{"positions"},
+		Response: model.Position{},
+	},
+	"GET /api/v1/vessels/{id}/track": {
+		Summary: "Get a vessel's track",
+		Tags:    []string{"positions"},
+		Query: []openapi.Param{
+			{Name: "start", Description: "RFC 3339 timestamp, 24 hours ago by default"},
+			{Name: "end", Description: "RFC 3339 timestamp, now by default"},
+		},
+		Response: model.Track{},
+	},
+	"GET /api/v1/vessels/{id}/positions": {
+		Summary: "List a vessel's positions",
+		Tags:    []string{"positions"},
+		Query: []openapi.Param{
+			{Name: "start", Description: "RFC 3339 timestamp, 24 hours ago by default"},
+			{Name: "end", Description: "RFC 3339 timestamp, now by default"},
+			{Name: "limit", Type: "integer", Description: "At most 10000, 1000 by default"},
+		},
+	},
+	"POST /api/v1/vessels/{id}/position": {
+		Summary:  "Record a vessel's position",
+		Tags:     []string{"positions"},
+		Response: model.Position{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/fleet/positions": {
+		Summary: "Get the latest positions of the workspace's vessels",
+		Tags:    []string{"positions"},
+	},
+	"GET /api/v1/fleet/bounds": {
+		Summary: "List vessel positions within bounds",
+		Tags:    []string{"positions"},
+		Query: []openapi.Param{
+			{Name: "ne_lat", Type: "number", Required: true},
+			{Name: "ne_lng", Type: "number", Required: true},
+			{Name: "sw_lat", Type: "number", Required: true},
+			{Name: "sw_lng", Type: "number", Required: true},
+			{Name: "since", Description: "RFC 3339 timestamp, an hour ago by default"},
+		},
+	},
+	"POST /api/v1/fleet/refresh": {
+		Summary: "Refresh the workspace's vessel positions from AIS",
+		Tags:    []string{"positions"},
+	},
+}
@@ -0,0 +1,36 @@
+package handler
+
+import "github.com/go-chi/chi/v5"
+
+// Handlers are the handlers serving the vessel service's API
+type Handlers struct {
+	Vessels   *VesselHandler
+	Positions *PositionHandler
+}
+
+// Routes registers the API routes. Each must have an entry in Operations,
+// which the tests check.
+func Routes(r chi.Router, h Handlers) {
+	r.Route("/api/v1", func(r chi.Router) {
+		// Vessels
+		r.Route("/vessels", func(r chi.Router) {
+			r.Get("/", h.Vessels.List)
+			r.Post("/", h.Vessels.Create)
+			r.Get("/{id}", h.Vessels.GetByID)
+			r.Put("/{id}", h.Vessels.Update)
+			r.Delete("/{id}", h.Vessels.Delete)
+			r.Get("/imo/{imo}", h.Vessels.GetByIMO)
+			r.Get("/{id}/position", h.Positions.GetLatest)
+			r.Get("/{id}/track", h.Positions.GetTrack)
+			r.Get("/{id}/positions", h.Positions.GetHistory)
+			r.Post("/{id}/position", h.Positions.RecordPosition)
+		})
+
+		// Fleet operations
+		r.Route("/fleet", func(r chi.Router) {
+			r.Get("/positions", h.Positions.GetFleetPositions)
+			r.Get("/bounds", h.Positions.GetPositionsInBounds)
+			r.Post("/refresh", h.Positions.RefreshPositions)
+		})
+	})
+}
@@ -13,11 +13,15 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	_ "github.com/lib/pq"
+	"github.com/navo/pkg/database"
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/migrate"
+	"github.com/navo/pkg/observability"
+	"github.com/navo/pkg/openapi"
 	"github.com/navo/services/worker/internal/config"
 	"github.com/navo/services/worker/internal/jobs"
 	"github.com/navo/services/worker/internal/scheduler"
+	"github.com/navo/services/worker/migrations"
 	"go.uber.org/zap"
 )
 
@@ -29,8 +33,15 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Tracing, exported when OTEL_ENABLED and OTEL_EXPORTER_OTLP_ENDPOINT are set
+	shutdownTracer, err := observability.InitTracer(context.Background(), observability.DefaultConfig("worker"))
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
 	// Connect to database
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	db, err := database.OpenDB(cfg.DatabaseURL)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
@@ -47,6 +58,23 @@ func main() {
 	}
 	logger.Info("Connected to database")
 
+	// Apply schema migrations; replicas starting together wait on the
+	// migration lock. "worker migrate ..." manages them instead.
+	migrator, err := migrate.New(db, "worker", migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrate.Command(context.Background(), migrator, os.Args[2:], os.Stdout)
+		db.Close()
+		os.Exit(code)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to apply migrations", zap.Error(err))
+	}
+	logger.Info("Database schema up to date", zap.Int("applied", len(applied)))
+
 	// Create scheduler
 	sched := scheduler.NewScheduler(cfg.MaxConcurrentJobs, cfg.JobTimeout)
 
@@ -87,6 +115,17 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"message": "job triggered"})
 	})
 
+	// OpenAPI document
+	api := openapi.New(openapi.Config{
+		Title:       "Navo Worker",
+		Description: "Scheduled jobs",
+	}, openapi.Operations{
+		"GET /jobs":             {Summary: "List jobs and their status"},
+		"POST /jobs/{name}/run": {Summary: "Run a job now"},
+	})
+	chi.Walk(r, api.Walk)
+	r.Get("/openapi.json", api.ServeHTTP)
+
 	// Start HTTP server
 	server := &http.Server{
 		Addr:    ":8085",
@@ -127,16 +166,9 @@ func registerJobs(sched *scheduler.Scheduler, db *sql.DB, cfg *config.Config) {
 		cfg.SessionCleanupInterval,
 	)
 
-	// Notification sender - runs every 30 seconds
+	// Notification relay - runs every 30 seconds
 	sched.RegisterJob(
-		jobs.NewNotificationSenderJob(db, jobs.NotificationSenderConfig{
-			SMTPHost:     cfg.SMTPHost,
-			SMTPPort:     cfg.SMTPPort,
-			SMTPUser:     cfg.SMTPUser,
-			SMTPPassword: cfg.SMTPPassword,
-			FromEmail:    cfg.FromEmail,
-			FromName:     cfg.FromName,
-		}),
+		jobs.NewNotificationRelayJob(db, cfg.NotificationServiceURL),
 		cfg.NotificationCheckInterval,
 	)
 
@@ -29,13 +29,9 @@ type Config struct {
 	AISAPIKey      string
 	AISSyncEnabled bool
 
-	// Notification Configuration
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUser     string
-	SMTPPassword string
-	FromEmail    string
-	FromName     string
+	// Notification service, which delivers the notifications relayed from
+	// the notifications table
+	NotificationServiceURL string
 
 	// Worker Configuration
 	MaxConcurrentJobs int
@@ -60,12 +56,7 @@ func Load() *Config {
 		AISAPIKey:      getEnv("AIS_API_KEY", ""),
 		AISSyncEnabled: getBool("AIS_SYNC_ENABLED", false),
 
-		SMTPHost:     getEnv("SMTP_HOST", ""),
-		SMTPPort:     getInt("SMTP_PORT", 587),
-		SMTPUser:     getEnv("SMTP_USER", ""),
-		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-		FromEmail:    getEnv("FROM_EMAIL", "noreply@navo.io"),
-		FromName:     getEnv("FROM_NAME", "Navo"),
+		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:4006"),
 
 		MaxConcurrentJobs: getInt("MAX_CONCURRENT_JOBS", 10),
 		JobTimeout:        getDuration("JOB_TIMEOUT", 5*time.Minute),
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/navo/pkg/migrate/migratetest"
+	"github.com/navo/services/worker/migrations"
+)
+
+// TestMigrations applies the migrations to the database in TEST_DATABASE_URL
+// and checks this package's queries against the schema they create
+func TestMigrations(t *testing.T) {
+	migratetest.Run(t, migratetest.Options{
+		Service:    "worker",
+		Migrations: migrations.FS,
+		External: []string{
+			"users",
+			"notifications",
+			"port_calls",
+			"service_orders",
+			"rfqs",
+			"quotes",
+			"vendors",
+			"sessions",
+			"password_reset_tokens",
+			"login_attempts",
+		},
+	})
+}
@@ -0,0 +1,293 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
+	"go.uber.org/zap"
+)
+
+// notificationCategories are the categories the notification service knows.
+// Rows of other types are sent as system notifications.
+var notificationCategories = map[string]bool{
+	"port_call":     true,
+	"service_order": true,
+	"rfq":           true,
+	"vessel":        true,
+	"document":      true,
+	"system":        true,
+	"approval":      true,
+}
+
+// NotificationRelayJob hands the unsent rows of the notifications table to
+// the notification service, which stores and delivers them like any other
+// notification. A row is sent once per channel; the channels already
+// relayed are recorded, so a partially relayed row is retried only on the
+// channels that failed.
+type NotificationRelayJob struct {
+	db                     *sql.DB
+	notificationServiceURL string
+	client                 *http.Client
+}
+
+// NewNotificationRelayJob creates a new notification relay job
+func NewNotificationRelayJob(db *sql.DB, notificationServiceURL string) *NotificationRelayJob {
+	return &NotificationRelayJob{
+		db:                     db,
+		notificationServiceURL: strings.TrimSuffix(notificationServiceURL, "/"),
+		client:                 &http.Client{Timeout: 10 * time.Second, Transport: observability.Transport(nil)},
+	}
+}
+
+// Name returns the job name
+func (j *NotificationRelayJob) Name() string {
+	return "notification_relay"
+}
+
+// Notification represents an unsent row of the notifications table
+type Notification struct {
+	ID             string
+	UserID         string
+	OrganizationID string
+	Type           string
+	Title          string
+	Message        string
+	Link           string
+	Data           map[string]interface{}
+	Channels       []string
+	Email          string          // Joined from user
+	Relayed        map[string]bool // Channels already relayed
+}
+
+// sendNotificationRequest is the notification service's request to send a
+// notification
+type sendNotificationRequest struct {
+	Type           string            `json:"type"`
+	Category       string            `json:"category"`
+	UserID         string            `json:"user_id"`
+	OrganizationID string            `json:"organization_id,omitempty"`
+	Email          string            `json:"email,omitempty"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body"`
+	ActionURL      string            `json:"action_url,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// batchResult is the notification service's result for one notification
+// of a batch, at the index of its request
+type batchResult struct {
+	Notification *struct {
+		ID string `json:"id"`
+	} `json:"notification,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Run relays the unsent notifications
+func (j *NotificationRelayJob) Run(ctx context.Context) error {
+	query := `
+		SELECT n.id, n.user_id, u.organization_id, n.type, n.title, n.message,
+			COALESCE(n.link, ''), n.data, n.channels, u.email,
+			COALESCE((
+				SELECT json_agg(c.channel) FROM notification_relay_channels c
+				WHERE c.notification_id = n.id
+			), '[]')
+		FROM notifications n
+		JOIN users u ON n.user_id = u.id
+		WHERE n.sent_at IS NULL
+		ORDER BY n.created_at
+		LIMIT 100
+	`
+
+	rows, err := j.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		var n Notification
+		var dataJSON, channelsJSON, relayedJSON []byte
+
+		if err := rows.Scan(&n.ID, &n.UserID, &n.OrganizationID, &n.Type, &n.Title, &n.Message, &n.Link, &dataJSON, &channelsJSON, &n.Email, &relayedJSON); err != nil {
+			logger.Error("Failed to scan notification", zap.Error(err))
+			continue
+		}
+
+		json.Unmarshal(dataJSON, &n.Data)
+		json.Unmarshal(channelsJSON, &n.Channels)
+		var relayed []string
+		json.Unmarshal(relayedJSON, &relayed)
+		n.Relayed = make(map[string]bool, len(relayed))
+		for _, channel := range relayed {
+			n.Relayed[channel] = true
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	logger.Info("Relaying notifications", zap.Int("count", len(notifications)))
+
+	relayedCount := 0
+	for _, n := range notifications {
+		relayed, err := j.relay(ctx, n)
+		for _, channel := range relayed {
+			if _, err := j.db.ExecContext(ctx, `
+				INSERT INTO notification_relay_channels (notification_id, channel)
+				VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, n.ID, channel); err != nil {
+				logger.Error("Failed to record relayed channel",
+					zap.String("notification_id", n.ID),
+					zap.String("channel", channel),
+					zap.Error(err),
+				)
+			}
+		}
+		if err != nil {
+			logger.Error("Failed to relay notification",
+				zap.String("notification_id", n.ID),
+				zap.Strings("relayed_channels", relayed),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Mark as sent; the channels relayed are no longer needed
+		if _, err := j.db.ExecContext(ctx, `
+			UPDATE notifications SET sent_at = $1 WHERE id = $2
+		`, time.Now(), n.ID); err != nil {
+			logger.Error("Failed to mark notification as sent",
+				zap.String("notification_id", n.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if _, err := j.db.ExecContext(ctx, `
+			DELETE FROM notification_relay_channels WHERE notification_id = $1
+		`, n.ID); err != nil {
+			logger.Warn("Failed to clear relayed channels",
+				zap.String("notification_id", n.ID),
+				zap.Error(err),
+			)
+		}
+
+		relayedCount++
+	}
+
+	if relayedCount > 0 {
+		logger.Info("Relayed notifications", zap.Int("count", relayedCount))
+	}
+
+	return nil
+}
+
+// relay sends a notification to the notification service on each of its
+// channels not relayed yet. It returns the channels relayed, and an error if
+// any channel failed.
+func (j *NotificationRelayJob) relay(ctx context.Context, n Notification) ([]string, error) {
+	requests := buildSendRequests(n)
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"notifications": requests})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notifications: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.notificationServiceURL+"/api/v1/notifications/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach notification service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("notification service returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []batchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode notification service response: %w", err)
+	}
+	if len(result.Results) != len(requests) {
+		return nil, fmt.Errorf("notification service returned %d results for %d channels", len(result.Results), len(requests))
+	}
+
+	var relayed, failed []string
+	for i, r := range result.Results {
+		if r.Notification == nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", requests[i].Type, r.Error))
+			continue
+		}
+		relayed = append(relayed, requests[i].Type)
+	}
+	if len(failed) > 0 {
+		return relayed, fmt.Errorf("notification service failed %d of %d channels: %s", len(failed), len(requests), strings.Join(failed, "; "))
+	}
+	return relayed, nil
+}
+
+// buildSendRequests maps a row of the notifications table to a request to
+// send it on each of its channels not relayed yet
+func buildSendRequests(n Notification) []sendNotificationRequest {
+	category := "system"
+	for prefix := range notificationCategories {
+		if n.Type == prefix || strings.HasPrefix(n.Type, prefix+".") || strings.HasPrefix(n.Type, prefix+"_") {
+			category = prefix
+			break
+		}
+	}
+
+	var requests []sendNotificationRequest
+	seen := make(map[string]bool)
+	for _, channel := range n.Channels {
+		switch channel {
+		case "email", "in_app", "push", "sms":
+		default:
+			continue
+		}
+		if seen[channel] || n.Relayed[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		requests = append(requests, sendNotificationRequest{
+			Type:           channel,
+			Category:       category,
+			UserID:         n.UserID,
+			OrganizationID: n.OrganizationID,
+			Email:          n.Email,
+			Title:          n.Title,
+			Body:           n.Message,
+			ActionURL:      n.Link,
+			Metadata: map[string]string{
+				"source_notification_id": n.ID,
+				"source_type":            n.Type,
+			},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildSendRequests(t *testing.T) {
+	n := Notification{
+		ID:       "n1",
+		UserID:   "u1",
+		Type:     "port_call.arrived",
+		Title:    "Arrived",
+		Message:  "MV Example arrived",
+		Channels: []string{"email", "in_app", "fax", "email", "push"},
+		Relayed:  map[string]bool{"in_app": true},
+	}
+
+	requests := buildSendRequests(n)
+
+	var channels []string
+	for _, r := range requests {
+		channels = append(channels, r.Type)
+		if r.Category != "port_call" {
+			t.Errorf("category = %q, want port_call", r.Category)
+		}
+	}
+	if want := []string{"email", "push"}; !reflect.DeepEqual(channels, want) {
+		t.Errorf("channels = %v, want %v", channels, want)
+	}
+}
+
+func TestNotificationRelay_PartialFailure(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Notifications []sendNotificationRequest `json:"notifications"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode batch: %v", err)
+		}
+
+		results := make([]map[string]any, len(body.Notifications))
+		for i, n := range body.Notifications {
+			received = append(received, n.Type)
+			if n.Type == "email" {
+				results[i] = map[string]any{"error": "email address is required"}
+				continue
+			}
+			results[i] = map[string]any{"notification": map[string]any{"id": "sent-" + n.Type}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+	defer server.Close()
+
+	job := NewNotificationRelayJob(nil, server.URL+"/")
+	n := Notification{
+		ID:       "n1",
+		UserID:   "u1",
+		Type:     "rfq",
+		Title:    "Quote received",
+		Message:  "A vendor quoted",
+		Channels: []string{"email", "in_app", "push"},
+	}
+
+	relayed, err := job.relay(context.Background(), n)
+	if err == nil {
+		t.Fatal("expected an error for the failed channel")
+	}
+	if want := []string{"in_app", "push"}; !reflect.DeepEqual(relayed, want) {
+		t.Errorf("relayed = %v, want %v", relayed, want)
+	}
+
+	// The retry only sends the channel that failed
+	received = nil
+	n.Relayed = map[string]bool{"in_app": true, "push": true}
+	relayed, err = job.relay(context.Background(), n)
+	if err == nil {
+		t.Fatal("expected an error for the failed channel")
+	}
+	if len(relayed) != 0 {
+		t.Errorf("relayed = %v, want none", relayed)
+	}
+	if want := []string{"email"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("retry sent %v, want %v", received, want)
+	}
+
+	// Nothing is left to send once every channel is relayed
+	received = nil
+	n.Relayed["email"] = true
+	relayed, err = job.relay(context.Background(), n)
+	if err != nil || len(relayed) != 0 || len(received) != 0 {
+		t.Errorf("relay of a fully relayed notification = %v, %v; sent %v", relayed, err, received)
+	}
+}
+
+func TestNotificationRelay_ResultCountMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sent":2,"items":[{},{}]}`))
+	}))
+	defer server.Close()
+
+	job := NewNotificationRelayJob(nil, server.URL)
+	relayed, err := job.relay(context.Background(), Notification{ID: "n1", Channels: []string{"email", "in_app"}})
+	if err == nil {
+		t.Fatal("expected an error for a response without per-channel results")
+	}
+	if len(relayed) != 0 {
+		t.Errorf("relayed = %v, want none", relayed)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/navo/pkg/logger"
+	"github.com/navo/pkg/observability"
 	"go.uber.org/zap"
 )
 
@@ -160,10 +161,15 @@ func (s *Scheduler) runJob(sj *ScheduledJob) {
 		ctx, cancel := context.WithTimeout(context.Background(), s.jobTimeout)
 		defer cancel()
 
+		// Each run is a trace of its own, covering the job's queries and calls
+		ctx, span := observability.StartSpan(ctx, "job "+sj.Job.Name())
+		defer span.End()
+
 		start := time.Now()
 		logger.Debug("Starting job", zap.String("job", sj.Job.Name()))
 
 		if err := sj.Job.Run(ctx); err != nil {
+			observability.RecordError(ctx, err)
 			logger.Error("Job failed",
 				zap.String("job", sj.Job.Name()),
 				zap.Error(err),
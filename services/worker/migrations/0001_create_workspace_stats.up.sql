@@ -0,0 +1,13 @@
+-- Daily port call statistics per workspace, aggregated by the data
+-- aggregation job
+
+CREATE TABLE IF NOT EXISTS workspace_stats (
+    workspace_id TEXT NOT NULL,
+    date DATE NOT NULL,
+    port_calls_total INTEGER NOT NULL DEFAULT 0,
+    port_calls_completed INTEGER NOT NULL DEFAULT 0,
+    avg_port_stay_hours DOUBLE PRECISION,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, date)
+);
@@ -0,0 +1,10 @@
+-- Channels the notification relay job has handed to the notification
+-- service, so a partially relayed notification is retried only on the
+-- channels that failed
+
+CREATE TABLE IF NOT EXISTS notification_relay_channels (
+    notification_id TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    relayed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (notification_id, channel)
+);